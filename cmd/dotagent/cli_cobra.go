@@ -58,12 +58,24 @@ Use agent/gateway in dev mode only.`),
 	root.PersistentFlags().StringVar(&instanceID, "instance", defaultInstanceID, "Instance ID under ~/.dotagent/instances")
 
 	root.AddCommand(newInitCommand(&instanceID))
+	root.AddCommand(newInitTemplatesCommand(&instanceID))
 	root.AddCommand(newMigrateCommand(&instanceID))
 	root.AddCommand(newDoctorCommand(&instanceID))
 	root.AddCommand(newRuntimeCommand(&instanceID))
 	root.AddCommand(newConfigCommand(&instanceID))
+	root.AddCommand(newProvidersCommand(&instanceID))
 	root.AddCommand(newBackupCommand(&instanceID))
+	root.AddCommand(newDBCommand(&instanceID))
+	root.AddCommand(newFeedbackCommand(&instanceID))
+	root.AddCommand(newSearchCommand(&instanceID))
+	root.AddCommand(newSessionsCommand(&instanceID))
+	root.AddCommand(newMemoryCommand(&instanceID))
+	root.AddCommand(newReplayCommand(&instanceID))
+	root.AddCommand(newReportCommand(&instanceID))
+	root.AddCommand(newPersonaCommand(&instanceID))
+	root.AddCommand(newWorkspaceCommand(&instanceID))
 	root.AddCommand(newAgentCommand(&instanceID))
+	root.AddCommand(newPerfCommand())
 	root.AddCommand(newGatewayCommand(&instanceID))
 	root.AddCommand(newServeCommand())
 	root.AddCommand(newServeCheckCommand())
@@ -72,6 +84,8 @@ Use agent/gateway in dev mode only.`),
 	root.AddCommand(newCronCommand())
 	root.AddCommand(newSkillsCommand())
 	root.AddCommand(newToolpacksCommand())
+	root.AddCommand(newToolsCommand())
+	root.AddCommand(newTestToolCommand())
 	root.AddCommand(newVersionCommand())
 
 	if includeDocsCommand {
@@ -135,16 +149,22 @@ func newAgentCommand(instanceID *string) *cobra.Command {
 		message string
 		session string
 		debug   bool
+		stream  bool
+		profile bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "agent",
 		Short: "Run direct local chat with the agent (dev mode)",
-		Long:  "Run an interactive local agent session or send one-shot messages without Discord.",
+		Long: "Run an interactive local agent session or send one-shot messages without Discord. " +
+			"--session accepts a session key or an alias set with 'dotagent session rename'; in interactive mode, " +
+			"/session shows the current session and /session switch <session_key|alias> changes it.",
 		Example: strings.Join([]string{
 			"  dotagent agent",
 			"  dotagent agent --session cli:workspace",
 			"  dotagent agent --message \"summarize my TODOs\"",
+			"  dotagent agent --stream --message \"draft a release note\"",
+			"  dotagent agent --profile-session --message \"list open tasks\"",
 		}, "\n"),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			legacyArgs := []string{"agent"}
@@ -157,28 +177,59 @@ func newAgentCommand(instanceID *string) *cobra.Command {
 			if strings.TrimSpace(session) != "" {
 				legacyArgs = append(legacyArgs, "--session", session)
 			}
+			if stream {
+				legacyArgs = append(legacyArgs, "--stream")
+			}
+			if profile {
+				legacyArgs = append(legacyArgs, "--profile-session")
+			}
 			return runLegacyWithArgs(legacyArgs, agentCmd)
 		},
 	}
 
 	cmd.Flags().StringVarP(&message, "message", "m", "", "One-shot prompt to send to the agent")
-	cmd.Flags().StringVarP(&session, "session", "s", "cli:default", "Session key for continuity")
+	cmd.Flags().StringVarP(&session, "session", "s", "cli:default", "Session key or alias for continuity")
 	cmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable debug logging")
+	cmd.Flags().BoolVar(&stream, "stream", false, "Print response tokens as they arrive")
+	cmd.Flags().BoolVar(&profile, "profile-session", false, "Print a table of phase timings after each reply")
 
 	return cmd
 }
 
 func newGatewayCommand(instanceID *string) *cobra.Command {
 	var (
-		debug bool
-		dev   bool
+		debug   bool
+		dev     bool
+		dryRun  bool
+		channel string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "gateway",
 		Short: "Run native gateway (dev mode only)",
-		Long:  "Start native gateway process for development. Production should use `dotagent runtime up`.",
+		Long: "Start native gateway process for development. Production should use `dotagent runtime up`.\n\n" +
+			"With --channel stdin the gateway runs as a Unix filter without Discord: each input line is one message, " +
+			"each reply is written to stdout, status output goes to stderr, and the process exits once input closes and every line is answered.\n\n" +
+			"With --dry-run the gateway builds its config, provider, toolpacks and each enabled channel without calling any API or connecting, " +
+			"opens the configured memory store read-only to report pending migrations, " +
+			"prints PASS or FAIL for each, and exits non-zero if any failed.\n\n" +
+			"Sending SIGHUP to a running gateway re-reads and validates the config file, then applies the model, max tokens, " +
+			"heartbeat interval, cron jitter and log level without a restart; changes to paths, the memory backend or channel credentials are logged as needing a restart.",
+		Example: strings.Join([]string{
+			"  dotagent gateway --dev",
+			"  dotagent gateway --dry-run",
+			"  kill -HUP $(pgrep -f 'dotagent gateway')",
+			"  printf 'summarize today\\n' | dotagent gateway --dev --channel stdin",
+		}, "\n"),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if dryRun {
+				report := runGatewayDryRun(resolveInstanceID(os.Getenv("DOTAGENT_INSTANCE")))
+				printGatewayDryRun(os.Stdout, report)
+				if !report.Ready {
+					return fmt.Errorf("gateway dry run failed")
+				}
+				return nil
+			}
 			if !dev && strings.TrimSpace(os.Getenv("DOTAGENT_ALLOW_PROD_GATEWAY")) != "1" {
 				return fmt.Errorf("gateway is dev-only; use `dotagent runtime up` for production, or pass --dev")
 			}
@@ -186,12 +237,20 @@ func newGatewayCommand(instanceID *string) *cobra.Command {
 			if debug {
 				legacyArgs = append(legacyArgs, "--debug")
 			}
+			if channel = strings.TrimSpace(channel); channel != "" {
+				if channel != "stdin" {
+					return fmt.Errorf("unsupported --channel %q (supported: stdin)", channel)
+				}
+				legacyArgs = append(legacyArgs, "--channel", channel)
+			}
 			return runLegacyWithArgs(legacyArgs, gatewayCmd)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable debug logging")
 	cmd.Flags().BoolVar(&dev, "dev", false, "Acknowledge native gateway usage for development mode")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Check that every component can start, without connecting, then exit")
+	cmd.Flags().StringVar(&channel, "channel", "", "Run with a single channel instead of the configured ones (stdin)")
 	return cmd
 }
 
@@ -440,6 +499,54 @@ func newSkillsCommand() *cobra.Command {
 	}
 	skillsRoot.AddCommand(show)
 
+	var createOpts skillsCreateOptions
+	create := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Scaffold a new workspace skill",
+		Long:  "Create workspace/skills/<name>/SKILL.md with front-matter and a starter template. Values not given as flags are prompted for.",
+		Args:  cobra.ExactArgs(1),
+		Example: strings.Join([]string{
+			"  dotagent skills create deploy-helper",
+			"  dotagent skills create deploy-helper --description \"Guide production deploys\" --triggers deploy,rollback --template workflow",
+		}, "\n"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSkillsCreate(cmd.InOrStdin(), cmd.OutOrStdout(), args[0], createOpts)
+		},
+	}
+	create.Flags().StringVar(&createOpts.description, "description", "", "Skill description")
+	create.Flags().StringVar(&createOpts.triggers, "triggers", "", "Comma-separated phrases that should bring the skill into play")
+	create.Flags().StringVar(&createOpts.version, "version", "", "Skill version (default 0.1.0)")
+	create.Flags().StringVar(&createOpts.template, "template", "", "Content template: basic, reference, or workflow (default basic)")
+	create.Flags().BoolVar(&createOpts.validate, "validate", true, "Validate the skill after writing it")
+	skillsRoot.AddCommand(create)
+
+	validate := &cobra.Command{
+		Use:     "validate <name>",
+		Short:   "Check a workspace skill's front-matter and body",
+		Args:    cobra.ExactArgs(1),
+		Example: "  dotagent skills validate deploy-helper",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return fmt.Errorf("load config: %w", err)
+			}
+			return printSkillValidation(cmd.OutOrStdout(), cfg.WorkspacePath(), args[0])
+		},
+	}
+	skillsRoot.AddCommand(validate)
+
+	skillsRoot.AddCommand(&cobra.Command{
+		Use:     "deps",
+		Aliases: []string{"dependencies"},
+		Short:   "Show the tools each skill requires and which are missing",
+		Long:    "List installed skills with the tools named in their front-matter requires field. Tools the current config does not register are marked with ✗.",
+		Args:    cobra.NoArgs,
+		Example: "  dotagent skills deps",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSkillsDeps(cmd.OutOrStdout())
+		},
+	})
+
 	return skillsRoot
 }
 
@@ -447,7 +554,7 @@ func newToolpacksCommand() *cobra.Command {
 	toolpacksRoot := &cobra.Command{
 		Use:   "toolpacks",
 		Short: "Manage executable tool packs",
-		Long:  "Install, inspect, validate, and doctor executable toolpacks that extend agent capabilities.",
+		Long:  "Install, inspect, validate, lint, and doctor executable toolpacks that extend agent capabilities.",
 	}
 
 	toolpacksRoot.AddCommand(&cobra.Command{
@@ -460,16 +567,37 @@ func newToolpacksCommand() *cobra.Command {
 	})
 
 	install := &cobra.Command{
-		Use:     "install <path|owner/repo[@ref]>",
-		Short:   "Install a toolpack from local path or GitHub",
-		Args:    cobra.ExactArgs(1),
-		Example: "  dotagent toolpacks install ./examples/toolpacks/github-cli",
+		Use:   "install <path|file.zip|owner/repo[@ref]>",
+		Short: "Install a toolpack from local path, exported ZIP, or GitHub",
+		Args:  cobra.ExactArgs(1),
+		Example: strings.Join([]string{
+			"  dotagent toolpacks install ./examples/toolpacks/github-cli",
+			"  dotagent toolpacks install ./github-cli.zip",
+		}, "\n"),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runLegacyWithArgs([]string{"toolpacks", "install", args[0]}, toolpacksCmd)
 		},
 	}
 	toolpacksRoot.AddCommand(install)
 
+	var exportOutput string
+	export := &cobra.Command{
+		Use:     "export <id>",
+		Short:   "Package an installed toolpack as a ZIP",
+		Long:    "Write an installed toolpack to a ZIP that `dotagent toolpacks install` accepts. The lock entry is left out and MANIFEST_SHA256.txt records the manifest checksum, which install verifies.",
+		Args:    cobra.ExactArgs(1),
+		Example: "  dotagent toolpacks export github-cli --output github-cli.zip",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output := strings.TrimSpace(exportOutput)
+			if output == "" {
+				output = args[0] + ".zip"
+			}
+			return runLegacyWithArgs([]string{"toolpacks", "export", args[0], output}, toolpacksCmd)
+		},
+	}
+	export.Flags().StringVarP(&exportOutput, "output", "o", "", "ZIP file to write (default <id>.zip)")
+	toolpacksRoot.AddCommand(export)
+
 	enable := &cobra.Command{
 		Use:     "enable <id>",
 		Short:   "Enable a toolpack",
@@ -530,6 +658,22 @@ func newToolpacksCommand() *cobra.Command {
 	}
 	toolpacksRoot.AddCommand(validate)
 
+	lint := &cobra.Command{
+		Use:     "lint [id]",
+		Short:   "Check command templates for risky shell usage",
+		Long:    "Statically check toolpack command templates for unquoted variables and command substitutions, eval, placeholders inside quotes or in command position, and misplaced redirects or pipes.",
+		Args:    cobra.MaximumNArgs(1),
+		Example: "  dotagent toolpacks lint github-cli",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			legacyArgs := []string{"toolpacks", "lint"}
+			if len(args) == 1 {
+				legacyArgs = append(legacyArgs, args[0])
+			}
+			return runLegacyWithArgs(legacyArgs, toolpacksCmd)
+		},
+	}
+	toolpacksRoot.AddCommand(lint)
+
 	doctor := &cobra.Command{
 		Use:     "doctor [id]",
 		Short:   "Run connector health checks",
@@ -35,6 +35,16 @@ func TestCLIHelpSnapshots(t *testing.T) {
 			args:     []string{"toolpacks", "--help"},
 			snapshot: "toolpacks_help.txt",
 		},
+		{
+			name:     "tools_help",
+			args:     []string{"tools", "--help"},
+			snapshot: "tools_help.txt",
+		},
+		{
+			name:     "test_tool_help",
+			args:     []string{"test-tool", "--help"},
+			snapshot: "test_tool_help.txt",
+		},
 	}
 
 	for _, tc := range cases {
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/agent"
+	"github.com/dotsetgreg/dotagent/pkg/config"
+	"github.com/dotsetgreg/dotagent/pkg/cron"
+	"github.com/dotsetgreg/dotagent/pkg/heartbeat"
+	"github.com/dotsetgreg/dotagent/pkg/logger"
+)
+
+type configField struct {
+	name string
+	get  func(*config.Config) string
+}
+
+// reloadableConfigFields are applied to a running gateway by reloadConfig.
+var reloadableConfigFields = []configField{
+	{"agents.defaults.model", func(c *config.Config) string { return c.Agents.Defaults.Model }},
+	{"agents.defaults.max_tokens", func(c *config.Config) string { return strconv.Itoa(c.Agents.Defaults.MaxTokens) }},
+	{"agents.defaults.cron_jitter_seconds", func(c *config.Config) string { return strconv.Itoa(c.Agents.Defaults.CronJitterSeconds) }},
+	{"heartbeat.interval", func(c *config.Config) string { return strconv.Itoa(c.Heartbeat.Interval) }},
+	{"gateway.log_level", func(c *config.Config) string { return strings.ToLower(strings.TrimSpace(c.Gateway.LogLevel)) }},
+}
+
+// restartOnlyConfigFields are read once at startup; a reload only warns when
+// they change. Secrets are compared but never logged.
+var restartOnlyConfigFields = []configField{
+	{"paths.workspace", func(c *config.Config) string { return c.WorkspacePath() }},
+	{"paths.data", func(c *config.Config) string { return c.DataPath() }},
+	{"memory.backend", func(c *config.Config) string { return c.Memory.Backend }},
+	{"memory.postgres.dsn", func(c *config.Config) string { return c.Memory.Postgres.DSN }},
+	{"heartbeat.enabled", func(c *config.Config) string { return strconv.FormatBool(c.Heartbeat.Enabled) }},
+	{"gateway.host", func(c *config.Config) string { return c.Gateway.Host }},
+	{"gateway.port", func(c *config.Config) string { return strconv.Itoa(c.Gateway.Port) }},
+	{"gateway.dedup_window_seconds", func(c *config.Config) string { return strconv.Itoa(c.Gateway.DedupWindowSeconds) }},
+	{"channels.discord.token", func(c *config.Config) string { return c.Channels.Discord.Token }},
+	{"channels.matrix.access_token", func(c *config.Config) string { return c.Channels.Matrix.AccessToken }},
+	{"channels.email.imap_password", func(c *config.Config) string { return c.Channels.Email.IMAPPassword }},
+	{"channels.email.smtp_password", func(c *config.Config) string { return c.Channels.Email.SMTPPassword }},
+}
+
+type configFieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// gatewayConfigReloader re-reads the config file of a running gateway.
+type gatewayConfigReloader struct {
+	path           string
+	requireDiscord bool
+	// debug keeps the --debug log level across reloads.
+	debug bool
+
+	agent     *agent.AgentLoop
+	heartbeat *heartbeat.HeartbeatService
+	cron      *cron.CronService
+
+	// started is the config the gateway was built from; applied is the last
+	// config whose reloadable fields are in effect.
+	started *config.Config
+	applied *config.Config
+}
+
+// diffConfigFields lists the fields whose values differ between old and next.
+func diffConfigFields(fields []configField, old, next *config.Config) []configFieldChange {
+	var changes []configFieldChange
+	for _, f := range fields {
+		if before, after := f.get(old), f.get(next); before != after {
+			changes = append(changes, configFieldChange{Field: f.name, Old: before, New: after})
+		}
+	}
+	return changes
+}
+
+// reloadConfig reads and validates the config file, then applies the model,
+// max tokens, cron jitter, heartbeat interval and log level to the running
+// gateway. Fields that need a restart are reported, not applied. A config
+// that fails to load or validate leaves the gateway unchanged.
+func (r *gatewayConfigReloader) reloadConfig() ([]configFieldChange, []string, error) {
+	next, err := config.LoadConfig(r.path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load config: %w", err)
+	}
+	if err := validateRuntimeConfig(next, r.requireDiscord); err != nil {
+		return nil, nil, err
+	}
+	var level logger.LogLevel
+	if name := strings.TrimSpace(next.Gateway.LogLevel); name != "" {
+		var ok bool
+		if level, ok = logger.ParseLevel(name); !ok {
+			return nil, nil, fmt.Errorf("unknown gateway.log_level %q", name)
+		}
+	}
+
+	changes := diffConfigFields(reloadableConfigFields, r.applied, next)
+	var restart []string
+	for _, c := range diffConfigFields(restartOnlyConfigFields, r.started, next) {
+		restart = append(restart, c.Field)
+	}
+
+	if r.agent != nil {
+		r.agent.SetModelSettings(next.Agents.Defaults.Model, next.Agents.Defaults.MaxTokens)
+	}
+	if r.cron != nil {
+		r.cron.SetJitter(time.Duration(next.Agents.Defaults.CronJitterSeconds) * time.Second)
+	}
+	if r.heartbeat != nil {
+		r.heartbeat.SetInterval(next.Heartbeat.Interval)
+	}
+	if !r.debug && strings.TrimSpace(next.Gateway.LogLevel) != "" {
+		logger.SetLevel(level)
+	}
+	r.applied = next
+	return changes, restart, nil
+}
+
+// reloadAndLog runs reloadConfig and logs its outcome field by field.
+func (r *gatewayConfigReloader) reloadAndLog() {
+	logger.InfoCF("gateway", "Reloading config", map[string]interface{}{"path": r.path})
+	changes, restart, err := r.reloadConfig()
+	if err != nil {
+		logger.ErrorCF("gateway", "Config reload failed; keeping the running config", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	for _, c := range changes {
+		logger.InfoCF("gateway", "Config field reloaded", map[string]interface{}{
+			"field": c.Field,
+			"old":   c.Old,
+			"new":   c.New,
+		})
+	}
+	for _, field := range restart {
+		logger.WarnCF("gateway", "Config field changed but requires a restart", map[string]interface{}{"field": field})
+	}
+	logger.InfoCF("gateway", "Config reload complete", map[string]interface{}{
+		"changed":          len(changes),
+		"requires_restart": len(restart),
+	})
+}
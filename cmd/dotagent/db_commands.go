@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+	"github.com/spf13/cobra"
+)
+
+func newDBCommand(instanceID *string) *cobra.Command {
+	root := &cobra.Command{
+		Use:   "db",
+		Short: "Migrate, export and import the memory database",
+	}
+
+	root.AddCommand(&cobra.Command{
+		Use:   "migrate",
+		Short: "Apply pending memory database schema migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSchemaMigrate(resolveInstanceID(*instanceID))
+		},
+	})
+
+	var format string
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show applied and pending schema migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSchemaMigrationStatus(resolveInstanceID(*instanceID), format)
+		},
+	}
+	statusCmd.Flags().StringVar(&format, "format", "text", "Output format: text|json")
+	root.AddCommand(statusCmd)
+
+	root.AddCommand(&cobra.Command{
+		Use:   "rollback <id>",
+		Short: "Roll back schema migrations down to and including <id>",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.Atoi(strings.TrimSpace(args[0]))
+			if err != nil || id <= 0 {
+				return fmt.Errorf("invalid migration id %q", args[0])
+			}
+			return runSchemaRollback(resolveInstanceID(*instanceID), id)
+		},
+	})
+
+	var outPath string
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "Export all memory tables as newline-delimited JSON",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id := resolveInstanceID(*instanceID)
+			if strings.TrimSpace(outPath) == "" {
+				outPath = fmt.Sprintf("dotagent-%s-memory-%s.ndjson", id, time.Now().UTC().Format("20060102T150405Z"))
+			}
+			return exportMemoryDB(id, outPath)
+		},
+	}
+	export.Flags().StringVar(&outPath, "output", "", "Snapshot output path (.ndjson, - for stdout)")
+	root.AddCommand(export)
+
+	var (
+		inPath string
+		force  bool
+	)
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Replace the memory database with a snapshot",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if strings.TrimSpace(inPath) == "" {
+				return fmt.Errorf("--input is required")
+			}
+			if !force {
+				return fmt.Errorf("import replaces all existing memory data; re-run with --force to confirm")
+			}
+			return importMemoryDB(resolveInstanceID(*instanceID), inPath)
+		},
+	}
+	importCmd.Flags().StringVar(&inPath, "input", "", "Snapshot input path (.ndjson, - for stdin)")
+	importCmd.Flags().BoolVar(&force, "force", false, "Confirm replacing existing memory data")
+	root.AddCommand(importCmd)
+
+	return root
+}
+
+func exportMemoryDB(instanceID, outPath string) error {
+	store, err := openSQLiteMemoryStore(instanceID, memory.ErrSnapshotUnsupported)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	var w io.Writer = os.Stdout
+	if outPath != "-" {
+		f, err := os.OpenFile(outPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+		if err != nil {
+			return fmt.Errorf("create snapshot file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := store.ExportSnapshot(context.Background(), w); err != nil {
+		return err
+	}
+	if outPath != "-" {
+		fmt.Printf("Memory snapshot written: %s\n", outPath)
+	}
+	return nil
+}
+
+func importMemoryDB(instanceID, inPath string) error {
+	cfg, _, err := loadInstanceConfig(instanceID)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.Memory.Backend)) {
+	case "", "sqlite":
+	default:
+		return memory.ErrSnapshotUnsupported
+	}
+	path := filepath.Join(cfg.DataPath(), "state", "memory.db")
+	var r io.Reader = os.Stdin
+	if inPath != "-" {
+		f, err := os.Open(inPath)
+		if err != nil {
+			return fmt.Errorf("open snapshot file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	store, err := memory.NewSQLiteStore(path)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	if err := store.ImportSnapshot(context.Background(), r); err != nil {
+		return err
+	}
+	fmt.Printf("Memory snapshot imported into %s\n", path)
+	return nil
+}
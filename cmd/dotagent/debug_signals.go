@@ -0,0 +1,53 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"runtime/pprof"
+	"syscall"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/agent"
+	"github.com/dotsetgreg/dotagent/pkg/logger"
+)
+
+// watchDebugSignals lets operators inspect a running gateway: SIGUSR1 dumps
+// goroutine stacks to stderr and SIGUSR2 force-compacts all recent sessions.
+func watchDebugSignals(ctx context.Context, agentLoop *agent.AgentLoop) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		defer signal.Stop(sigChan)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-sigChan:
+				switch sig {
+				case syscall.SIGUSR1:
+					logger.InfoCF("gateway", "Received SIGUSR1; dumping goroutine stacks to stderr", nil)
+					if err := pprof.Lookup("goroutine").WriteTo(os.Stderr, 1); err != nil {
+						logger.WarnCF("gateway", "Goroutine dump failed", map[string]interface{}{"error": err.Error()})
+					}
+				case syscall.SIGUSR2:
+					logger.InfoCF("gateway", "Received SIGUSR2; forcing memory compaction for all sessions", nil)
+					start := time.Now()
+					compacted, err := agentLoop.CompactAllSessions(ctx)
+					fields := map[string]interface{}{
+						"sessions":    compacted,
+						"duration_ms": time.Since(start).Milliseconds(),
+					}
+					if err != nil {
+						fields["error"] = err.Error()
+						logger.WarnCF("gateway", "Forced memory compaction finished with errors", fields)
+						continue
+					}
+					logger.InfoCF("gateway", "Forced memory compaction finished", fields)
+				}
+			}
+		}
+	}()
+}
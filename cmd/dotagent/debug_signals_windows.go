@@ -0,0 +1,12 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+
+	"github.com/dotsetgreg/dotagent/pkg/agent"
+)
+
+// watchDebugSignals is a no-op on Windows, which has no SIGUSR1/SIGUSR2.
+func watchDebugSignals(ctx context.Context, agentLoop *agent.AgentLoop) {}
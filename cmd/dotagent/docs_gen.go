@@ -616,13 +616,15 @@ func collectProviderFields(t reflect.Type, prefix string, defaults map[string]st
 	}
 }
 
-type docsStubProvider struct{}
+// offlineProvider lets commands build an AgentLoop for introspection without
+// provider credentials. It never serves chat requests.
+type offlineProvider struct{}
 
-func (docsStubProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, options map[string]interface{}) (*providers.LLMResponse, error) {
-	return nil, fmt.Errorf("docs stub provider: chat is not supported")
+func (offlineProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, options map[string]interface{}) (*providers.LLMResponse, error) {
+	return nil, fmt.Errorf("offline provider: chat is not supported")
 }
 
-func (docsStubProvider) GetDefaultModel() string {
+func (offlineProvider) GetDefaultModel() string {
 	return "docs-stub"
 }
 
@@ -638,7 +640,7 @@ func buildToolsReferenceMarkdown() (string, error) {
 	cfg.Agents.Defaults.Model = "docs-stub"
 
 	msgBus := bus.NewMessageBus()
-	loop, err := agent.NewAgentLoop(cfg, msgBus, docsStubProvider{})
+	loop, err := agent.NewAgentLoop(cfg, msgBus, offlineProvider{})
 	if err != nil {
 		return "", fmt.Errorf("build tool reference: %w", err)
 	}
@@ -671,6 +673,13 @@ func buildToolsReferenceMarkdown() (string, error) {
 	}
 	cronTool := tools.NewCronTool(cronService, loop, msgBus, tmpWorkspace, cfg.Agents.Defaults.RestrictToWorkspace)
 	toolRows[cronTool.Name()] = cronTool.Description()
+	for _, tool := range []tools.Tool{
+		tools.NewSetReminderTool(cronService),
+		tools.NewListRemindersTool(cronService),
+		tools.NewCancelReminderTool(cronService),
+	} {
+		toolRows[tool.Name()] = tool.Description()
+	}
 
 	names := make([]string, 0, len(toolRows))
 	for name := range toolRows {
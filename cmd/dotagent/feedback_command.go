@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+	"github.com/spf13/cobra"
+)
+
+func newFeedbackCommand(instanceID *string) *cobra.Command {
+	root := &cobra.Command{
+		Use:   "feedback",
+		Short: "Export response ratings left with /feedback",
+	}
+
+	var (
+		format  string
+		outPath string
+		limit   int
+	)
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "Export recorded response feedback, newest first",
+		Example: strings.Join([]string{
+			"  dotagent feedback export --format csv > feedback.csv",
+			"  dotagent feedback export --format json --limit 100",
+		}, "\n"),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format = strings.ToLower(strings.TrimSpace(format))
+			if format != "csv" && format != "json" {
+				return fmt.Errorf("--format must be csv or json")
+			}
+			var w io.Writer = cmd.OutOrStdout()
+			if outPath != "" && outPath != "-" {
+				f, err := os.OpenFile(outPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+				if err != nil {
+					return fmt.Errorf("create output file: %w", err)
+				}
+				defer f.Close()
+				w = f
+			}
+			return exportFeedback(w, resolveInstanceID(*instanceID), format, limit)
+		},
+	}
+	export.Flags().StringVar(&format, "format", "csv", "Output format: csv or json")
+	export.Flags().StringVar(&outPath, "output", "-", "Output path (- for stdout)")
+	export.Flags().IntVar(&limit, "limit", 0, "Maximum rows to export (0 for all)")
+	root.AddCommand(export)
+
+	return root
+}
+
+func exportFeedback(w io.Writer, instanceID, format string, limit int) error {
+	store, err := openSQLiteMemoryStore(instanceID, memory.ErrFeedbackUnsupported)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	rows, err := store.ListFeedback(context.Background(), limit)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	return writeFeedbackCSV(w, rows)
+}
+
+func writeFeedbackCSV(w io.Writer, rows []memory.Feedback) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"created_at", "session_key", "turn_id", "user_id", "rating", "comment"}); err != nil {
+		return err
+	}
+	for _, fb := range rows {
+		if err := cw.Write([]string{
+			time.UnixMilli(fb.CreatedAtMS).UTC().Format(time.RFC3339),
+			fb.SessionKey,
+			fb.TurnID,
+			fb.UserID,
+			strconv.Itoa(fb.Rating),
+			fb.Comment,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+	"github.com/dotsetgreg/dotagent/pkg/channels"
+	"github.com/dotsetgreg/dotagent/pkg/config"
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+	"github.com/dotsetgreg/dotagent/pkg/providers"
+	"github.com/dotsetgreg/dotagent/pkg/toolpacks"
+)
+
+// runGatewayDryRun builds each gateway component the way gatewayCmd does but
+// stops short of anything that talks to the network: no provider calls, no
+// channel connections, no MCP handshakes.
+func runGatewayDryRun(instanceID string) doctorReport {
+	report := doctorReport{
+		Instance: instanceID,
+		Ready:    true,
+		Checks:   []doctorCheck{},
+	}
+	addCheck := func(name string, err error, detail string) {
+		if err != nil {
+			report.Checks = append(report.Checks, doctorCheck{Name: name, OK: false, Detail: err.Error()})
+			report.Ready = false
+			return
+		}
+		report.Checks = append(report.Checks, doctorCheck{Name: name, OK: true, Detail: detail})
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		addCheck("config", err, "")
+		return report
+	}
+	addCheck("config", nil, getConfigPath())
+
+	providerName := providers.ActiveProviderName(cfg)
+	if err := providers.ValidateProviderConfig(cfg); err != nil {
+		addCheck("provider", err, "")
+	} else if _, err := providers.CreateProvider(cfg); err != nil {
+		addCheck("provider", err, "")
+	} else {
+		addCheck("provider", nil, providerName)
+	}
+
+	detail, err := checkGatewayMemory(cfg)
+	addCheck("memory", err, detail)
+
+	packs := toolpacks.NewManager(cfg.WorkspacePath(), cfg.Agents.Defaults.RestrictToWorkspace)
+	if warnings, err := packs.Validate(""); err != nil {
+		addCheck("toolpacks", err, "")
+	} else if len(warnings) > 0 {
+		addCheck("toolpacks", fmt.Errorf("%s", strings.Join(warnings, "; ")), "")
+	} else {
+		manifests, _ := packs.List()
+		addCheck("toolpacks", nil, fmt.Sprintf("%d manifest(s) valid", len(manifests)))
+	}
+
+	// Each enabled channel is checked on its own so one bad channel does not
+	// hide problems in the others.
+	msgBus := bus.NewMessageBus()
+	addCheck("channel discord", channels.ValidateDiscordToken(cfg.Channels.Discord.Token), "token well-formed")
+	if cfg.Channels.Email.Enabled {
+		_, err := channels.NewEmailChannel(cfg.Channels.Email, msgBus)
+		addCheck("channel email", err, cfg.Channels.Email.IMAPHost)
+	}
+	if cfg.Channels.Matrix.Enabled {
+		detail := cfg.Channels.Matrix.HomeserverURL
+		matrix, err := channels.NewMatrixChannel(cfg.Channels.Matrix, msgBus)
+		if err == nil && cfg.Channels.Matrix.E2EE {
+			err = matrix.EnableE2EE(filepath.Join(cfg.WorkspacePath(), "matrix"))
+			detail += " (e2ee)"
+		}
+		addCheck("channel matrix", err, detail)
+	}
+
+	return report
+}
+
+// checkGatewayMemory opens the store memory.backend selects read-only and
+// reports migrations the gateway would apply at start, without applying them.
+func checkGatewayMemory(cfg *config.Config) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var (
+		where   string
+		pending []int
+		err     error
+	)
+	switch backend := strings.ToLower(strings.TrimSpace(cfg.Memory.Backend)); backend {
+	case "", "sqlite":
+		where = filepath.Join(cfg.DataPath(), "state", "memory.db")
+		if _, statErr := os.Stat(where); os.IsNotExist(statErr) {
+			return where + ": not created yet; the gateway creates it at start", nil
+		}
+		store, openErr := memory.NewSQLiteStoreWithOptions(where, memory.SQLiteStoreOptions{ReadOnly: true})
+		if openErr != nil {
+			return "", openErr
+		}
+		defer store.Close()
+		if err := store.QuickCheck(ctx); err != nil {
+			return "", err
+		}
+		pending, err = store.PendingMigrations(ctx)
+	case "postgres":
+		where = "postgres"
+		store, openErr := memory.NewPostgreSQLStoreWithOptions(strings.TrimSpace(cfg.Memory.Postgres.DSN), memory.PostgreSQLStoreOptions{ReadOnly: true})
+		if openErr != nil {
+			return "", openErr
+		}
+		defer store.Close()
+		pending, err = store.PendingMigrations(ctx)
+	default:
+		return "", fmt.Errorf("unknown memory backend %q", cfg.Memory.Backend)
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(pending) > 0 {
+		return fmt.Sprintf("%s: %d migration(s) pending, applied at start", where, len(pending)), nil
+	}
+	return where + ": schema up to date", nil
+}
+
+func printGatewayDryRun(w io.Writer, report doctorReport) {
+	fmt.Fprintf(w, "Instance: %s\n", report.Instance)
+	for _, c := range report.Checks {
+		status := "PASS"
+		if !c.OK {
+			status = "FAIL"
+		}
+		if strings.TrimSpace(c.Detail) == "" {
+			fmt.Fprintf(w, "  [%s] %s\n", status, c.Name)
+			continue
+		}
+		fmt.Fprintf(w, "  [%s] %s: %s\n", status, c.Name, c.Detail)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/tools"
+	"github.com/spf13/cobra"
+)
+
+func newInitTemplatesCommand(instanceID *string) *cobra.Command {
+	var merge bool
+	cmd := &cobra.Command{
+		Use:   "init-templates",
+		Short: "Add missing workspace templates without touching existing files",
+		Long: strings.TrimSpace(`Compare the workspace against the templates bundled with this build. Templates
+missing from the workspace are listed, and with --merge they are copied in.
+Existing files are never overwritten; when one differs from its bundled
+template, a diff from the workspace copy to the template is printed so changes
+can be applied by hand.`),
+		Example: strings.Join([]string{
+			"  dotagent init-templates",
+			"  dotagent init-templates --merge",
+		}, "\n"),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, _, err := loadInstanceConfig(resolveInstanceID(*instanceID))
+			if err != nil {
+				return fmt.Errorf("load config: %w", err)
+			}
+			return mergeWorkspaceTemplates(cmd.OutOrStdout(), cfg.WorkspacePath(), merge)
+		},
+	}
+	cmd.Flags().BoolVar(&merge, "merge", false, "Copy templates that are missing from the workspace")
+	return cmd
+}
+
+// mergeWorkspaceTemplates reports how the workspace differs from the embedded
+// templates and, when apply is set, writes the templates that don't exist yet.
+func mergeWorkspaceTemplates(w io.Writer, workspace string, apply bool) error {
+	var missing, changed []string
+	unchanged := 0
+	err := fs.WalkDir(embeddedFiles, "workspace", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		template, err := embeddedFiles.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read embedded file %s: %w", path, err)
+		}
+		rel, err := filepath.Rel("workspace", path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(workspace, rel)
+
+		current, err := os.ReadFile(target)
+		switch {
+		case os.IsNotExist(err):
+			missing = append(missing, rel)
+			if !apply {
+				return nil
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("create directory %s: %w", filepath.Dir(target), err)
+			}
+			if err := os.WriteFile(target, template, 0644); err != nil {
+				return fmt.Errorf("write %s: %w", target, err)
+			}
+		case err != nil:
+			return fmt.Errorf("read %s: %w", target, err)
+		case string(current) == string(template):
+			unchanged++
+		default:
+			changed = append(changed, rel)
+			fmt.Fprint(w, tools.UnifiedDiff(rel+" (workspace)", rel+" (template)", string(current), string(template), 3))
+			fmt.Fprintln(w)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	verb := "Missing (use --merge to add)"
+	if apply {
+		verb = "Added"
+	}
+	for _, rel := range missing {
+		fmt.Fprintf(w, "%s: %s\n", verb, rel)
+	}
+	fmt.Fprintf(w, "Workspace %s: %d missing, %d changed, %d unchanged.\n", workspace, len(missing), len(changed), unchanged)
+	if len(changed) > 0 {
+		fmt.Fprintln(w, "Changed files were left as they are; review the diffs above to pick up template updates.")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/config"
+)
+
+// instanceLockPayload is written to the instance lock so another process can
+// tell who holds it and whether that process is still alive.
+type instanceLockPayload struct {
+	PID       int    `json:"pid"`
+	Holder    string `json:"holder"`
+	CreatedAt string `json:"created_at"`
+}
+
+func instanceLockPath(cfg *config.Config) string {
+	return filepath.Join(cfg.DataPath(), "instance.lock")
+}
+
+// acquireInstanceLock takes the instance lock for holder ("gateway" or a
+// maintenance command). A lock left by a process that has exited is
+// reclaimed; one held by a live process is reported as an error.
+func acquireInstanceLock(cfg *config.Config, holder string) (func(), error) {
+	path := instanceLockPath(cfg)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			raw, _ := json.Marshal(instanceLockPayload{
+				PID:       os.Getpid(),
+				Holder:    holder,
+				CreatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+			})
+			_, writeErr := f.Write(raw)
+			closeErr := f.Close()
+			if writeErr != nil || closeErr != nil {
+				_ = os.Remove(path)
+				return nil, fmt.Errorf("write instance lock: %w", errors.Join(writeErr, closeErr))
+			}
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("create instance lock: %w", err)
+		}
+
+		var payload instanceLockPayload
+		raw, readErr := os.ReadFile(path)
+		if readErr == nil && json.Unmarshal(raw, &payload) == nil {
+			if processIsAlive(payload.PID) {
+				return nil, fmt.Errorf("instance is in use by %s (pid %d); stop it first", payload.Holder, payload.PID)
+			}
+		} else if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) < 10*time.Second {
+			// The holder may not have written its payload yet.
+			return nil, fmt.Errorf("instance lock %s is being taken by another process", path)
+		}
+		_ = os.Remove(path)
+	}
+	return nil, fmt.Errorf("could not take instance lock %s", path)
+}
+
+func processIsAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// os.FindProcess succeeds even for dead pids on windows and signal(0) is unsupported.
+		return true
+	}
+	err = proc.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
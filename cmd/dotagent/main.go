@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/chzyer/readline"
+	"github.com/dotsetgreg/dotagent/pkg/admin"
 	"github.com/dotsetgreg/dotagent/pkg/agent"
 	"github.com/dotsetgreg/dotagent/pkg/bus"
 	"github.com/dotsetgreg/dotagent/pkg/channels"
@@ -32,6 +33,7 @@ import (
 	"github.com/dotsetgreg/dotagent/pkg/health"
 	"github.com/dotsetgreg/dotagent/pkg/heartbeat"
 	"github.com/dotsetgreg/dotagent/pkg/logger"
+	"github.com/dotsetgreg/dotagent/pkg/observability"
 	"github.com/dotsetgreg/dotagent/pkg/providers"
 	"github.com/dotsetgreg/dotagent/pkg/skills"
 	"github.com/dotsetgreg/dotagent/pkg/toolpacks"
@@ -204,6 +206,24 @@ func createWorkspaceTemplates(workspace string) error {
 	return copyEmbeddedToTarget(workspace)
 }
 
+// setupTracing starts OpenTelemetry export when observability is enabled and
+// returns a function that flushes pending spans. Export failures are logged,
+// never fatal.
+func setupTracing(cfg *config.Config) func() {
+	shutdown, err := observability.Setup(context.Background(), cfg.Observability, version)
+	if err != nil {
+		logger.WarnCF("observability", "Tracing disabled", map[string]interface{}{"error": err.Error()})
+		return func() {}
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			logger.WarnCF("observability", "Failed to flush traces", map[string]interface{}{"error": err.Error()})
+		}
+	}
+}
+
 func validateRuntimeConfig(cfg *config.Config, requireDiscord bool) error {
 	if err := providers.ValidateProviderConfig(cfg); err != nil {
 		return fmt.Errorf("provider configuration error: %w", err)
@@ -218,6 +238,8 @@ func validateRuntimeConfig(cfg *config.Config, requireDiscord bool) error {
 func agentCmd() {
 	message := ""
 	sessionKey := "cli:default"
+	stream := false
+	profile := false
 
 	args := os.Args[2:]
 	for i := 0; i < len(args); i++ {
@@ -235,6 +257,10 @@ func agentCmd() {
 				sessionKey = args[i+1]
 				i++
 			}
+		case "--stream":
+			stream = true
+		case "--profile-session":
+			profile = true
 		}
 	}
 
@@ -253,6 +279,8 @@ func agentCmd() {
 		fmt.Printf("Error creating provider: %v\n", err)
 		os.Exit(1)
 	}
+	stopTracing := setupTracing(cfg)
+	defer stopTracing()
 
 	msgBus := bus.NewMessageBus()
 	agentLoop, err := agent.NewAgentLoop(cfg, msgBus, provider)
@@ -270,21 +298,101 @@ func agentCmd() {
 			"skills_available": startupInfo["skills"].(map[string]interface{})["available"],
 		})
 
+	if resolved, err := agentLoop.ResolveSessionKey(context.Background(), sessionKey); err != nil {
+		fmt.Printf("Error resolving session: %v\n", err)
+		os.Exit(1)
+	} else {
+		sessionKey = resolved
+	}
+
+	if stream && !agentLoop.SupportsStreaming() {
+		logger.WarnCF("agent", "Provider does not support streaming; printing full responses", nil)
+		stream = false
+	}
+
 	if message != "" {
 		ctx := context.Background()
-		response, err := agentLoop.ProcessDirect(ctx, message, sessionKey)
-		if err != nil {
+		if err := runDirectTurn(ctx, os.Stdout, agentLoop, message, sessionKey, stream, profile); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("\n%s %s\n", appName, response)
 	} else {
 		fmt.Printf("%s Interactive mode (Ctrl+C to exit)\n\n", appName)
-		interactiveMode(agentLoop, sessionKey)
+		interactiveMode(agentLoop, sessionKey, stream, profile)
+	}
+}
+
+// runDirectTurn sends one message and prints the reply. When stream is set,
+// text deltas are written to w as they arrive; if none arrive the full
+// response is printed once the turn completes. When profile is set, a table
+// of phase timings follows the reply, or the error if the turn failed.
+func runDirectTurn(ctx context.Context, w io.Writer, agentLoop *agent.AgentLoop, input, sessionKey string, stream, profile bool) error {
+	if profile {
+		sessionProfile := agent.NewSessionProfile()
+		ctx = agent.WithSessionProfile(ctx, sessionProfile)
+		defer func() {
+			fmt.Fprintln(w)
+			_ = sessionProfile.WriteTable(w)
+		}()
+	}
+	if !stream {
+		response, err := agentLoop.ProcessDirect(ctx, input, sessionKey)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "\n%s %s\n", appName, response)
+		return nil
+	}
+
+	streamed := false
+	response, err := agentLoop.ProcessDirectStream(ctx, input, sessionKey, func(delta string) {
+		if !streamed {
+			fmt.Fprintf(w, "\n%s ", appName)
+			streamed = true
+		}
+		fmt.Fprint(w, delta)
+	})
+	if err != nil {
+		if streamed {
+			fmt.Fprintln(w)
+		}
+		return err
 	}
+	if !streamed {
+		fmt.Fprintf(w, "\n%s %s\n", appName, response)
+		return nil
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+// handleSessionCommand handles "/session" (show the current session) and
+// "/session switch <session_key|alias>" in interactive mode. It reports
+// whether input was a session command.
+func handleSessionCommand(ctx context.Context, w io.Writer, agentLoop *agent.AgentLoop, input string, sessionKey *string) bool {
+	fields := strings.Fields(input)
+	if len(fields) == 0 || fields[0] != "/session" {
+		return false
+	}
+	switch {
+	case len(fields) == 1:
+		fmt.Fprintf(w, "Current session: %s\n\n", *sessionKey)
+	case fields[1] == "switch" && len(fields) == 3:
+		resolved, err := agentLoop.ResolveSessionKey(ctx, fields[2])
+		if err != nil {
+			fmt.Fprintf(w, "Error: %v\n\n", err)
+			return true
+		}
+		*sessionKey = resolved
+		fmt.Fprintf(w, "Switched to session %s\n\n", resolved)
+	default:
+		fmt.Fprintln(w, "Usage: /session [switch <session_key|alias>]")
+		fmt.Fprintln(w)
+	}
+	return true
 }
 
-func interactiveMode(agentLoop *agent.AgentLoop, sessionKey string) {
+func interactiveMode(agentLoop *agent.AgentLoop, sessionKey string, stream, profile bool) {
 	prompt := fmt.Sprintf("%s You: ", appName)
 
 	rl, err := readline.NewEx(&readline.Config{
@@ -298,7 +406,7 @@ func interactiveMode(agentLoop *agent.AgentLoop, sessionKey string) {
 	if err != nil {
 		fmt.Printf("Error initializing readline: %v\n", err)
 		fmt.Println("Falling back to simple input mode...")
-		simpleInteractiveMode(agentLoop, sessionKey)
+		simpleInteractiveMode(agentLoop, sessionKey, stream, profile)
 		return
 	}
 	defer rl.Close()
@@ -325,17 +433,18 @@ func interactiveMode(agentLoop *agent.AgentLoop, sessionKey string) {
 		}
 
 		ctx := context.Background()
-		response, err := agentLoop.ProcessDirect(ctx, input, sessionKey)
-		if err != nil {
+		if handleSessionCommand(ctx, os.Stdout, agentLoop, input, &sessionKey) {
+			continue
+		}
+		if err := runDirectTurn(ctx, os.Stdout, agentLoop, input, sessionKey, stream, profile); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			continue
 		}
-
-		fmt.Printf("\n%s %s\n\n", appName, response)
+		fmt.Println()
 	}
 }
 
-func simpleInteractiveMode(agentLoop *agent.AgentLoop, sessionKey string) {
+func simpleInteractiveMode(agentLoop *agent.AgentLoop, sessionKey string, stream, profile bool) {
 	reader := bufio.NewReader(os.Stdin)
 	for {
 		fmt.Print(fmt.Sprintf("%s You: ", appName))
@@ -360,25 +469,49 @@ func simpleInteractiveMode(agentLoop *agent.AgentLoop, sessionKey string) {
 		}
 
 		ctx := context.Background()
-		response, err := agentLoop.ProcessDirect(ctx, input, sessionKey)
-		if err != nil {
+		if handleSessionCommand(ctx, os.Stdout, agentLoop, input, &sessionKey) {
+			continue
+		}
+		if err := runDirectTurn(ctx, os.Stdout, agentLoop, input, sessionKey, stream, profile); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			continue
 		}
-
-		fmt.Printf("\n%s %s\n\n", appName, response)
+		fmt.Println()
 	}
 }
 
 func gatewayCmd() {
-	// Check for --debug flag
+	// Check for --debug and --channel flags
 	args := os.Args[2:]
-	for _, arg := range args {
-		if arg == "--debug" || arg == "-d" {
-			logger.SetLevel(logger.DEBUG)
-			fmt.Println("🔍 Debug mode enabled")
-			break
+	channelMode := ""
+	debug := false
+	for i, arg := range args {
+		switch {
+		case arg == "--debug" || arg == "-d":
+			debug = true
+		case arg == "--channel" && i+1 < len(args):
+			channelMode = strings.ToLower(strings.TrimSpace(args[i+1]))
+		case strings.HasPrefix(arg, "--channel="):
+			channelMode = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(arg, "--channel=")))
+		}
+	}
+	stdinMode := channelMode == "stdin"
+	// In stdin mode stdout carries replies only, so status output goes to
+	// stderr through the logger instead.
+	statusf := func(format string, args ...interface{}) {
+		if stdinMode {
+			logger.InfoC("gateway", strings.TrimSpace(fmt.Sprintf(format, args...)))
+			return
 		}
+		fmt.Printf(format, args...)
+	}
+	if debug {
+		logger.SetLevel(logger.DEBUG)
+		statusf("🔍 Debug mode enabled\n")
+	}
+	if channelMode != "" && !stdinMode {
+		statusf("Unsupported --channel %q (supported: stdin)\n", channelMode)
+		os.Exit(1)
 	}
 
 	instanceID := resolveInstanceID(os.Getenv("DOTAGENT_INSTANCE"))
@@ -388,8 +521,8 @@ func gatewayCmd() {
 	if err != nil {
 		recovered, recoverErr := maybeRollbackPendingConfigOnLoadFailure(instanceID, configPath, err)
 		if recoverErr != nil {
-			fmt.Printf("Error loading config: %v\n", err)
-			fmt.Printf("Error rolling back pending config apply: %v\n", recoverErr)
+			statusf("Error loading config: %v\n", err)
+			statusf("Error rolling back pending config apply: %v\n", recoverErr)
 			os.Exit(1)
 		}
 		if recovered {
@@ -397,34 +530,49 @@ func gatewayCmd() {
 		}
 	}
 	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
+		statusf("Error loading config: %v\n", err)
 		os.Exit(1)
 	}
-	if err := validateRuntimeConfig(cfg, true); err != nil {
-		fmt.Printf("Configuration error: %v\n", err)
+	if err := validateRuntimeConfig(cfg, !stdinMode); err != nil {
+		statusf("Configuration error: %v\n", err)
 		os.Exit(1)
 	}
+	if level, ok := logger.ParseLevel(cfg.Gateway.LogLevel); ok && !debug {
+		logger.SetLevel(level)
+	}
+	releaseLock, err := acquireInstanceLock(cfg, "gateway")
+	if err != nil {
+		statusf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer releaseLock()
 
 	provider, err := providers.CreateProvider(cfg)
 	if err != nil {
-		fmt.Printf("Error creating provider: %v\n", err)
+		statusf("Error creating provider: %v\n", err)
 		os.Exit(1)
 	}
+	stopTracing := setupTracing(cfg)
+	defer stopTracing()
 
-	msgBus := bus.NewMessageBus()
+	busOptions := bus.MessageBusOptions{DedupWindow: time.Duration(cfg.Gateway.DedupWindowSeconds) * time.Second}
+	msgBus := bus.NewMessageBusWithOptions(busOptions)
+	if cfg.Gateway.PriorityBus {
+		msgBus = bus.NewPriorityMessageBus(busOptions)
+	}
 	agentLoop, err := agent.NewAgentLoop(cfg, msgBus, provider)
 	if err != nil {
-		fmt.Printf("Error initializing memory subsystem: %v\n", err)
+		statusf("Error initializing memory subsystem: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Print agent startup info
-	fmt.Println("\n📦 Agent Status:")
+	statusf("\n📦 Agent Status:\n")
 	startupInfo := agentLoop.GetStartupInfo()
 	toolsInfo := startupInfo["tools"].(map[string]interface{})
 	skillsInfo := startupInfo["skills"].(map[string]interface{})
-	fmt.Printf("  • Tools: %d loaded\n", toolsInfo["count"])
-	fmt.Printf("  • Skills: %d/%d available\n",
+	statusf("  • Tools: %d loaded\n", toolsInfo["count"])
+	statusf("  • Skills: %d/%d available\n",
 		skillsInfo["available"],
 		skillsInfo["total"])
 
@@ -439,9 +587,10 @@ func gatewayCmd() {
 	// Setup cron tool and service
 	cronService, err := setupCronTool(agentLoop, msgBus, cfg.DataPath(), cfg.WorkspacePath(), cfg.Agents.Defaults.RestrictToWorkspace)
 	if err != nil {
-		fmt.Printf("Failed to setup cron tool: %v\n", err)
+		statusf("Failed to setup cron tool: %v\n", err)
 		os.Exit(1)
 	}
+	cronService.SetJitter(time.Duration(cfg.Agents.Defaults.CronJitterSeconds) * time.Second)
 
 	heartbeatService := heartbeat.NewHeartbeatService(
 		cfg.WorkspacePath(),
@@ -451,11 +600,9 @@ func gatewayCmd() {
 		cfg.Heartbeat.Enabled,
 	)
 	heartbeatService.SetBus(msgBus)
+	heartbeatService.SetLastChannelSource(agentLoop.LastChannel)
+	heartbeatService.SetFallbackChannel(cfg.Heartbeat.FallbackChannel)
 	heartbeatService.SetHandler(func(prompt, channel, chatID string) *tools.ToolResult {
-		// Use cli:direct as fallback if no valid channel
-		if channel == "" || chatID == "" {
-			channel, chatID = "cli", "direct"
-		}
 		// Use ProcessHeartbeat - no session history, each heartbeat is independent
 		response, err := agentLoop.ProcessHeartbeat(context.Background(), prompt, channel, chatID)
 		if err != nil {
@@ -469,36 +616,47 @@ func gatewayCmd() {
 		return tools.SilentResult(response)
 	})
 
-	channelManager, err := channels.NewManager(cfg, msgBus)
-	if err != nil {
-		fmt.Printf("Error creating channel manager: %v\n", err)
-		os.Exit(1)
+	var (
+		channelManager *channels.Manager
+		stdinChannel   *channels.StdinChannel
+	)
+	if stdinMode {
+		stdinChannel = channels.NewStdinChannel(msgBus, os.Stdin, os.Stdout)
+		channelManager = channels.NewManagerWithChannels(cfg, msgBus, map[string]channels.Channel{
+			stdinChannel.Name(): stdinChannel,
+		})
+	} else {
+		channelManager, err = channels.NewManager(cfg, msgBus)
+		if err != nil {
+			statusf("Error creating channel manager: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
 	// Inject channel manager into agent loop for command handling
 	agentLoop.SetChannelManager(channelManager)
 
 	enabledChannels := channelManager.GetEnabledChannels()
-	fmt.Printf("✓ Channels enabled: %s\n", strings.Join(enabledChannels, ", "))
+	statusf("✓ Channels enabled: %s\n", strings.Join(enabledChannels, ", "))
 
-	fmt.Printf("✓ Gateway started on %s:%d\n", cfg.Gateway.Host, cfg.Gateway.Port)
-	fmt.Println("Press Ctrl+C to stop")
+	statusf("✓ Gateway started on %s:%d\n", cfg.Gateway.Host, cfg.Gateway.Port)
+	statusf("Press Ctrl+C to stop\n")
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	if err := cronService.Start(); err != nil {
-		fmt.Printf("Error starting cron service: %v\n", err)
+		statusf("Error starting cron service: %v\n", err)
 	}
-	fmt.Println("✓ Cron service started")
+	statusf("✓ Cron service started\n")
 
 	if err := heartbeatService.Start(); err != nil {
-		fmt.Printf("Error starting heartbeat service: %v\n", err)
+		statusf("Error starting heartbeat service: %v\n", err)
 	}
-	fmt.Println("✓ Heartbeat service started")
+	statusf("✓ Heartbeat service started\n")
 
 	if err := channelManager.StartAll(ctx); err != nil {
-		fmt.Printf("Error starting channels: %v\n", err)
+		statusf("Error starting channels: %v\n", err)
 		cancel()
 		heartbeatService.Stop()
 		cronService.Stop()
@@ -528,12 +686,35 @@ func gatewayCmd() {
 			logger.ErrorCF("health", "Health server error", map[string]interface{}{"error": err.Error()})
 		}
 	}()
-	fmt.Printf("✓ Health endpoints available at http://%s:%d/health and /ready\n", cfg.Gateway.Host, cfg.Gateway.Port)
+	statusf("✓ Health endpoints available at http://%s:%d/health and /ready\n", cfg.Gateway.Host, cfg.Gateway.Port)
+
+	var adminServer *admin.Server
+	if cfg.Gateway.Admin.Enabled {
+		adminCfg := cfg.Gateway.Admin
+		adminServer = admin.NewServer(adminCfg.Host, adminCfg.Port, adminCfg.Token, agentLoop)
+		scheme := "http"
+		if strings.TrimSpace(adminCfg.TLSCertFile) != "" {
+			adminServer.SetTLS(strings.TrimSpace(adminCfg.TLSCertFile), strings.TrimSpace(adminCfg.TLSKeyFile))
+			scheme = "https"
+		}
+		go func() {
+			if err := adminServer.Start(); err != nil && err != http.ErrServerClosed {
+				logger.ErrorCF("admin", "Admin server error", map[string]interface{}{"error": err.Error()})
+			}
+		}()
+		statusf("✓ Admin API available at %s://%s:%d/admin/\n", scheme, adminCfg.Host, adminCfg.Port)
+	}
+	stopAdminServer := func() {
+		if adminServer != nil {
+			adminServer.Stop(context.Background())
+		}
+	}
 
 	if err := finalizePendingConfigApply(instanceID, configPath); err != nil {
-		fmt.Printf("Pending config apply validation failed: %v\n", err)
+		statusf("Pending config apply validation failed: %v\n", err)
 		cancel()
 		healthServer.Stop(context.Background())
+		stopAdminServer()
 		heartbeatService.Stop()
 		cronService.Stop()
 		agentLoop.Stop()
@@ -542,19 +723,39 @@ func gatewayCmd() {
 	}
 
 	go agentLoop.Run(ctx)
+	watchDebugSignals(ctx, agentLoop)
+	watchReloadSignal(ctx, &gatewayConfigReloader{
+		path:           configPath,
+		requireDiscord: !stdinMode,
+		debug:          debug,
+		agent:          agentLoop,
+		heartbeat:      heartbeatService,
+		cron:           cronService,
+		started:        cfg,
+		applied:        cfg,
+	})
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	<-sigChan
+	var inputDone <-chan struct{}
+	if stdinChannel != nil {
+		inputDone = stdinChannel.Done()
+	}
+	select {
+	case <-sigChan:
+	case <-inputDone:
+		statusf("\nInput closed; all lines answered.\n")
+	}
 
-	fmt.Println("\nShutting down...")
+	statusf("\nShutting down...\n")
 	cancel()
 	healthServer.Stop(context.Background())
+	stopAdminServer()
 	heartbeatService.Stop()
 	cronService.Stop()
 	agentLoop.Stop()
 	channelManager.StopAll(ctx)
-	fmt.Println("✓ Gateway stopped")
+	statusf("✓ Gateway stopped\n")
 }
 
 func maybeRollbackPendingConfigOnLoadFailure(instanceID, configPath string, loadErr error) (bool, error) {
@@ -683,13 +884,6 @@ func registerGatewayHealthChecks(healthServer *health.Server, cfg *config.Config
 		}
 		return true, "configured"
 	})
-	healthServer.RegisterCheck("memory_db", func() (bool, string) {
-		path := filepath.Join(cfg.DataPath(), "state", "memory.db")
-		if err := ensureFileAccessible(path); err != nil {
-			return false, err.Error()
-		}
-		return true, path
-	})
 	healthServer.RegisterCheck("cron_service", func() (bool, string) {
 		status := cronService.Status()
 		running, _ := status["enabled"].(bool)
@@ -804,11 +998,16 @@ func statusCmd() {
 }
 
 func getConfigPath() string {
-	if explicit := strings.TrimSpace(os.Getenv("DOTAGENT_CONFIG")); explicit != "" {
-		return explicit
+	path := strings.TrimSpace(os.Getenv("DOTAGENT_CONFIG"))
+	if path == "" {
+		instanceID := resolveInstanceID(strings.TrimSpace(os.Getenv("DOTAGENT_INSTANCE")))
+		path = instanceConfigPath(instanceID)
+	}
+	if config.IsTOMLPath(path) {
+		return path
 	}
-	instanceID := resolveInstanceID(strings.TrimSpace(os.Getenv("DOTAGENT_INSTANCE")))
-	return instanceConfigPath(instanceID)
+	resolved, _ := config.ResolveConfigPath(path)
+	return resolved
 }
 
 func setupCronTool(agentLoop *agent.AgentLoop, msgBus *bus.MessageBus, storeRoot string, workspace string, restrict bool) (*cron.CronService, error) {
@@ -823,6 +1022,10 @@ func setupCronTool(agentLoop *agent.AgentLoop, msgBus *bus.MessageBus, storeRoot
 	// Create and register CronTool
 	cronTool := tools.NewCronTool(cronService, agentLoop, msgBus, workspace, restrict)
 	agentLoop.RegisterTool(cronTool)
+	agentLoop.RegisterTool(tools.NewSetReminderTool(cronService))
+	agentLoop.RegisterTool(tools.NewListRemindersTool(cronService))
+	agentLoop.RegisterTool(tools.NewCancelReminderTool(cronService))
+	agentLoop.SetCronService(cronService)
 
 	// Set the onJob handler
 	cronService.SetOnJob(func(job *cron.CronJob) (string, error) {
@@ -834,7 +1037,12 @@ func setupCronTool(agentLoop *agent.AgentLoop, msgBus *bus.MessageBus, storeRoot
 }
 
 func loadConfig() (*config.Config, error) {
-	return config.LoadConfig(getConfigPath())
+	path := getConfigPath()
+	if _, bothExist := config.ResolveConfigPath(path); bothExist && !config.IsTOMLPath(path) {
+		fmt.Fprintf(os.Stderr, "Note: both %s and %s exist; using the JSON file. Remove one to silence this message.\n",
+			filepath.Base(path), filepath.Base(config.TOMLSiblingPath(path)))
+	}
+	return config.LoadConfig(path)
 }
 
 func cronCmd() {
@@ -1082,6 +1290,9 @@ func skillsHelp() {
 	fmt.Println("  remove <name>   Remove installed skill")
 	fmt.Println("  search          Search available skills")
 	fmt.Println("  show <name>     Show skill details")
+	fmt.Println("  create <name>   Scaffold a new workspace skill")
+	fmt.Println("  validate <name> Validate a workspace skill")
+	fmt.Println("  deps            Show tools each skill requires")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  dotagent skills list")
@@ -1203,10 +1414,20 @@ func toolpacksCmd() {
 		toolpacksListCmd(manager)
 	case "install":
 		if len(os.Args) < 4 {
-			fmt.Println("Usage: dotagent toolpacks install <path|owner/repo[@ref]>")
+			fmt.Println("Usage: dotagent toolpacks install <path|file.zip|owner/repo[@ref]>")
 			return
 		}
 		toolpacksInstallCmd(manager, os.Args[3])
+	case "export":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: dotagent toolpacks export <id> [output.zip]")
+			return
+		}
+		output := os.Args[3] + ".zip"
+		if len(os.Args) >= 5 {
+			output = os.Args[4]
+		}
+		toolpacksExportCmd(manager, os.Args[3], output)
 	case "enable":
 		if len(os.Args) < 4 {
 			fmt.Println("Usage: dotagent toolpacks enable <id>")
@@ -1237,6 +1458,12 @@ func toolpacksCmd() {
 			id = os.Args[3]
 		}
 		toolpacksValidateCmd(manager, id)
+	case "lint":
+		id := ""
+		if len(os.Args) >= 4 {
+			id = os.Args[3]
+		}
+		toolpacksLintCmd(manager, id)
 	case "doctor":
 		id := ""
 		if len(os.Args) >= 4 {
@@ -1252,18 +1479,21 @@ func toolpacksCmd() {
 func toolpacksHelp() {
 	fmt.Println("\nToolpacks commands:")
 	fmt.Println("  list                  List installed toolpacks")
-	fmt.Println("  install <src>         Install from local path or GitHub repo")
+	fmt.Println("  install <src>         Install from local path, exported ZIP, or GitHub repo")
+	fmt.Println("  export <id> [out]     Package a toolpack as a ZIP")
 	fmt.Println("  enable <id>           Enable a toolpack")
 	fmt.Println("  disable <id>          Disable a toolpack")
 	fmt.Println("  remove <id>           Remove a toolpack")
 	fmt.Println("  show <id>             Show toolpack manifest details")
 	fmt.Println("  validate [id]         Validate manifests and connector configs")
+	fmt.Println("  lint [id]             Check command templates for risky shell usage")
 	fmt.Println("  doctor [id]           Run connector health checks")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  dotagent toolpacks list")
 	fmt.Println("  dotagent toolpacks install ./examples/toolpacks/github-cli")
 	fmt.Println("  dotagent toolpacks install owner/repo@v1.0.0")
+	fmt.Println("  dotagent toolpacks export github-cli github-cli.zip")
 }
 
 func toolpacksListCmd(manager *toolpacks.Manager) {
@@ -1302,7 +1532,7 @@ func toolpacksInstallCmd(manager *toolpacks.Manager, source string) {
 		err  error
 	)
 
-	if fi, statErr := os.Stat(source); statErr == nil && fi.IsDir() {
+	if fi, statErr := os.Stat(source); statErr == nil && (fi.IsDir() || fi.Mode().IsRegular()) {
 		pack, err = manager.InstallFromPath(source)
 	} else {
 		pack, err = manager.InstallFromGitHub(ctx, source)
@@ -1314,6 +1544,15 @@ func toolpacksInstallCmd(manager *toolpacks.Manager, source string) {
 	fmt.Printf("✓ Installed toolpack %s (%s)\n", pack.ID, pack.Version)
 }
 
+func toolpacksExportCmd(manager *toolpacks.Manager, id, output string) {
+	pack, err := manager.Export(id, output)
+	if err != nil {
+		fmt.Printf("✗ Failed to export toolpack %s: %v\n", id, err)
+		return
+	}
+	fmt.Printf("✓ Exported toolpack %s (%s) to %s\n", pack.ID, pack.Version, output)
+}
+
 func toolpacksEnableCmd(manager *toolpacks.Manager, id string, enabled bool) {
 	if err := manager.Enable(id, enabled); err != nil {
 		fmt.Printf("✗ Failed to update toolpack %s: %v\n", id, err)
@@ -1372,6 +1611,22 @@ func toolpacksValidateCmd(manager *toolpacks.Manager, id string) {
 	}
 }
 
+func toolpacksLintCmd(manager *toolpacks.Manager, id string) {
+	warnings, err := manager.Lint(id)
+	if err != nil {
+		fmt.Printf("✗ Lint failed: %v\n", err)
+		return
+	}
+	if len(warnings) == 0 {
+		fmt.Println("✓ Lint passed with no warnings.")
+		return
+	}
+	fmt.Printf("Lint warnings (%d):\n", len(warnings))
+	for _, w := range warnings {
+		fmt.Printf("  - %s\n", w)
+	}
+}
+
 func toolpacksDoctorCmd(manager *toolpacks.Manager, id string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
 	defer cancel()
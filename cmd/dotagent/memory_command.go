@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+	"github.com/spf13/cobra"
+)
+
+func newMemoryCommand(instanceID *string) *cobra.Command {
+	root := &cobra.Command{
+		Use:   "memory",
+		Short: "Inspect stored long-term memory",
+	}
+
+	var (
+		userID string
+		limit  int
+	)
+	timeline := &cobra.Command{
+		Use:   "timeline <item_key>",
+		Short: "Show how a memory item was observed, changed and gained confidence over time",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return printMemoryTimeline(cmd.OutOrStdout(), resolveInstanceID(*instanceID), args[0], strings.TrimSpace(userID), limit)
+		},
+	}
+	timeline.Flags().StringVar(&userID, "user", "", "Only items belonging to this user ID")
+	timeline.Flags().IntVar(&limit, "limit", 100, "Maximum observations to show per item")
+	root.AddCommand(timeline)
+
+	return root
+}
+
+func printMemoryTimeline(w io.Writer, instanceID, key, userID string, limit int) error {
+	store, err := openMemoryStore(instanceID)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	items, err := store.FindMemoryItemsByKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	if userID != "" {
+		filtered := items[:0]
+		for _, item := range items {
+			if item.UserID == userID {
+				filtered = append(filtered, item)
+			}
+		}
+		items = filtered
+	}
+	if len(items) == 0 {
+		fmt.Fprintf(w, "No memory items with key %q\n", key)
+		return nil
+	}
+
+	for i, item := range items {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		observations, err := store.GetObservationTimeline(ctx, item.ID, limit)
+		if err != nil {
+			return err
+		}
+		writeMemoryTimeline(w, item, observations)
+	}
+	return nil
+}
+
+func writeMemoryTimeline(w io.Writer, item memory.MemoryItem, observations []memory.MemoryObservation) {
+	header := fmt.Sprintf("%s/%s (user %s, scope %s)", item.Kind, item.Key, valueOrDash(item.UserID), item.ScopeType)
+	if item.DeletedAtMS > 0 {
+		header += fmt.Sprintf(" [deleted %s]", formatTimelineTime(item.DeletedAtMS))
+	}
+	fmt.Fprintln(w, header)
+	fmt.Fprintf(w, "  Current: %s (confidence %.2f)\n", item.Content, item.Confidence)
+	if len(observations) == 0 {
+		fmt.Fprintf(w, "  First seen %s; no observations recorded\n", formatTimelineTime(item.FirstSeenAtMS))
+		return
+	}
+	first, last := observations[0], observations[len(observations)-1]
+	fmt.Fprintf(w, "  First observed %s; %d observation(s); confidence %.2f -> %.2f\n",
+		formatTimelineTime(first.ObservedAt), len(observations), first.Confidence, last.Confidence)
+
+	for _, cluster := range memory.BuildObservationTimeline(observations) {
+		fmt.Fprintf(w, "\n  event %s (session %s)\n", valueOrDash(cluster.EventID), valueOrDash(cluster.SessionKey))
+		for _, step := range cluster.Steps {
+			confidence := fmt.Sprintf("%.2f", step.Confidence)
+			if step.ConfidenceDelta != 0 {
+				confidence += fmt.Sprintf(" (%+.2f)", step.ConfidenceDelta)
+			}
+			fmt.Fprintf(w, "    %s  %-6s  %-13s  %s\n", formatTimelineTime(step.ObservedAt), step.Action, confidence, step.Extractor)
+			if step.ID == first.ID || step.ContentChanged {
+				fmt.Fprintf(w, "      %s\n", step.Content)
+			}
+		}
+	}
+}
+
+func formatTimelineTime(ms int64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	return time.UnixMilli(ms).Format("2006-01-02 15:04")
+}
+
+func valueOrDash(v string) string {
+	if strings.TrimSpace(v) == "" {
+		return "-"
+	}
+	return v
+}
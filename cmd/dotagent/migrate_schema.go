@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+)
+
+func instanceMemoryDBPath(instanceID string) (string, error) {
+	cfg, _, err := loadInstanceConfig(instanceID)
+	if err != nil {
+		return "", fmt.Errorf("load config: %w", err)
+	}
+	return filepath.Join(cfg.DataPath(), "state", "memory.db"), nil
+}
+
+// openMemoryStore opens the store memory.backend selects for the instance. A
+// SQLite database must already exist; commands never create an empty one.
+func openMemoryStore(instanceID string) (memory.Store, error) {
+	cfg, _, err := loadInstanceConfig(instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	mcfg := memory.Config{
+		DataDir:     cfg.DataPath(),
+		Backend:     cfg.Memory.Backend,
+		PostgresDSN: strings.TrimSpace(cfg.Memory.Postgres.DSN),
+	}
+	switch strings.ToLower(strings.TrimSpace(mcfg.Backend)) {
+	case "", "sqlite":
+		path := filepath.Join(mcfg.DataDir, "state", "memory.db")
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("memory database not found at %s", path)
+		}
+	}
+	return memory.OpenStore(mcfg)
+}
+
+// openSQLiteMemoryStore opens the instance store for commands only SQLite
+// supports, returning unsupported when memory.backend is anything else.
+func openSQLiteMemoryStore(instanceID string, unsupported error) (*memory.SQLiteStore, error) {
+	store, err := openMemoryStore(instanceID)
+	if err != nil {
+		return nil, err
+	}
+	sqliteStore, ok := store.(*memory.SQLiteStore)
+	if !ok {
+		_ = store.Close()
+		return nil, unsupported
+	}
+	return sqliteStore, nil
+}
+
+// openInstanceMemoryStore opens the instance database without applying
+// migrations so status and rollback see the schema as it is on disk.
+func openInstanceMemoryStore(instanceID string) (*memory.SQLiteStore, string, error) {
+	path, err := instanceMemoryDBPath(instanceID)
+	if err != nil {
+		return nil, "", err
+	}
+	store, err := memory.NewSQLiteStoreWithOptions(path, memory.SQLiteStoreOptions{SkipMigrations: true})
+	if err != nil {
+		return nil, path, err
+	}
+	return store, path, nil
+}
+
+func runSchemaMigrate(instanceID string) error {
+	store, path, err := openInstanceMemoryStore(instanceID)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	applied, err := store.Migrate(context.Background())
+	for _, id := range applied {
+		fmt.Printf("Applied migration %04d\n", id)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Printf("Schema is up to date: %s\n", path)
+	}
+	return nil
+}
+
+func runSchemaMigrationStatus(instanceID, format string) error {
+	store, path, err := openInstanceMemoryStore(instanceID)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	statuses, err := store.MigrationStatus(context.Background())
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "text":
+		fmt.Printf("Database: %s\n", path)
+		for _, st := range statuses {
+			state := "pending"
+			if st.Applied {
+				state = "applied " + time.UnixMilli(st.AppliedAtMS).UTC().Format(time.RFC3339)
+			}
+			fmt.Printf("  %04d_%s  %s\n", st.ID, st.Name, state)
+		}
+		return nil
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(statuses)
+	default:
+		return fmt.Errorf("unsupported format %q (expected text or json)", format)
+	}
+}
+
+func runSchemaRollback(instanceID string, id int) error {
+	store, _, err := openInstanceMemoryStore(instanceID)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	rolledBack, err := store.RollbackMigration(context.Background(), id)
+	for _, rid := range rolledBack {
+		fmt.Printf("Rolled back migration %04d\n", rid)
+	}
+	return err
+}
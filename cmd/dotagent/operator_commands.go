@@ -16,6 +16,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dotsetgreg/dotagent/pkg/agent"
 	"github.com/dotsetgreg/dotagent/pkg/config"
 	"github.com/dotsetgreg/dotagent/pkg/providers"
 	"github.com/dotsetgreg/dotagent/pkg/tools"
@@ -46,7 +47,15 @@ func newInitCommand(instanceID *string) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Initialize an instance-scoped DotAgent installation",
-		Long:  "Create instance directories, config v2, workspace templates, and runtime compose artifacts.",
+		Long: "Create instance directories, config v2, workspace templates, and runtime compose artifacts.\n\n" +
+			"With --non-interactive, init never prompts, always overwrites an existing config, and fills the new config from DOTAGENT_* " +
+			"environment variables, which suits container entrypoints. It exits non-zero without writing anything if the active provider's " +
+			"credentials (for example DOTAGENT_PROVIDERS_OPENROUTER_API_KEY) are not set. Credentials are never written to the config; " +
+			"keep them in the environment the gateway runs with.",
+		Example: strings.Join([]string{
+			"  dotagent init",
+			"  DOTAGENT_PROVIDERS_OPENROUTER_API_KEY=sk-or-... DOTAGENT_CHANNELS_DISCORD_TOKEN=... dotagent init --non-interactive",
+		}, "\n"),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			id := resolveInstanceID(*instanceID)
 			if err := validateInstanceID(id); err != nil {
@@ -56,10 +65,7 @@ func newInitCommand(instanceID *string) *cobra.Command {
 			_, statErr := os.Stat(cfgPath)
 			exists := statErr == nil
 
-			if exists && !force && !yes {
-				if nonInteractive {
-					return fmt.Errorf("instance config already exists at %s (use --force or --yes)", cfgPath)
-				}
+			if exists && !force && !yes && !nonInteractive {
 				if !confirmPrompt(os.Stdin, os.Stdout, fmt.Sprintf("Config exists at %s. Overwrite?", cfgPath)) {
 					return fmt.Errorf("aborted")
 				}
@@ -78,10 +84,15 @@ func newInitCommand(instanceID *string) *cobra.Command {
 				}
 			}
 
+			cfg := config.DefaultConfigForInstance(id)
+			if nonInteractive {
+				if err := applyInitEnv(id, cfg); err != nil {
+					return err
+				}
+			}
 			if err := ensureInstanceLayout(id); err != nil {
 				return err
 			}
-			cfg := config.DefaultConfigForInstance(id)
 			if err := saveInstanceConfig(id, cfg, configMutationOptions{SkipHistory: true}); err != nil {
 				return err
 			}
@@ -94,6 +105,10 @@ func newInitCommand(instanceID *string) *cobra.Command {
 
 			fmt.Printf("dotagent instance %q is ready.\n", id)
 			fmt.Printf("Config: %s\n", cfgPath)
+			if nonInteractive {
+				fmt.Println("Credentials from the environment were not saved; keep them set wherever the gateway runs.")
+				return nil
+			}
 			fmt.Println("Next steps:")
 			fmt.Println("  1. dotagent config set providers.openrouter.api_key '\"<key>\"'")
 			fmt.Println("     or use local Ollama:")
@@ -108,12 +123,31 @@ func newInitCommand(instanceID *string) *cobra.Command {
 		},
 	}
 	cmd.Flags().BoolVar(&force, "force", false, "Overwrite existing config and runtime artifacts")
-	cmd.Flags().BoolVar(&nonInteractive, "non-interactive", false, "Fail instead of prompting when user input is required")
+	cmd.Flags().BoolVar(&nonInteractive, "non-interactive", false, "Never prompt: overwrite existing config and read provider credentials and other settings from DOTAGENT_* environment variables")
 	cmd.Flags().BoolVar(&yes, "yes", false, "Assume yes for overwrite prompts")
 	cmd.Flags().BoolVar(&migrateLegacy, "migrate-legacy", true, "Import legacy ~/.dotagent layout when present")
 	return cmd
 }
 
+// applyInitEnv fills an unattended init's config from DOTAGENT_* environment
+// variables and checks that the active provider's credentials were among
+// them, so a container entrypoint fails fast instead of writing a config the
+// gateway cannot start with. Credentials are checked but not copied into cfg:
+// they stay in the environment, which LoadConfig reads on every start.
+func applyInitEnv(id string, cfg *config.Config) error {
+	full := config.DefaultConfigForInstance(id)
+	if err := config.ApplyEnv(full); err != nil {
+		return fmt.Errorf("read config from environment: %w", err)
+	}
+	if err := full.Validate(); err != nil {
+		return fmt.Errorf("config from environment is invalid: %w", err)
+	}
+	if err := providers.ValidateProviderConfig(full); err != nil {
+		return fmt.Errorf("--non-interactive needs %s provider credentials from the environment: %w", providers.ActiveProviderName(full), err)
+	}
+	return config.ApplyEnvExceptSecrets(cfg)
+}
+
 func newMigrateCommand(instanceID *string) *cobra.Command {
 	var force bool
 	cmd := &cobra.Command{
@@ -301,8 +335,7 @@ func newConfigCommand(instanceID *string) *cobra.Command {
 		Use:   "path",
 		Short: "Print active config path",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			id := resolveInstanceID(*instanceID)
-			fmt.Println(instanceConfigPath(id))
+			fmt.Println(getConfigPath())
 			return nil
 		},
 	})
@@ -311,8 +344,7 @@ func newConfigCommand(instanceID *string) *cobra.Command {
 		Use:   "show",
 		Short: "Print resolved config",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfgPath := instanceConfigPath(resolveInstanceID(*instanceID))
-			raw, err := os.ReadFile(cfgPath)
+			raw, err := os.ReadFile(getConfigPath())
 			if err != nil {
 				return err
 			}
@@ -326,8 +358,8 @@ func newConfigCommand(instanceID *string) *cobra.Command {
 		Short: "Set a config key using dot-path syntax",
 		Args:  cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			id := resolveInstanceID(*instanceID)
-			rawMap, err := readConfigMap(instanceConfigPath(id))
+			cfgPath := getConfigPath()
+			rawMap, err := readConfigMap(cfgPath)
 			if err != nil {
 				return err
 			}
@@ -335,7 +367,7 @@ func newConfigCommand(instanceID *string) *cobra.Command {
 			if err := setDotPath(rawMap, args[0], value); err != nil {
 				return err
 			}
-			return writeConfigMap(id, rawMap)
+			return writeConfigMap(resolveInstanceID(*instanceID), cfgPath, rawMap)
 		},
 	}
 	root.AddCommand(setCmd)
@@ -345,15 +377,15 @@ func newConfigCommand(instanceID *string) *cobra.Command {
 		Short: "Unset/remove a config key",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			id := resolveInstanceID(*instanceID)
-			rawMap, err := readConfigMap(instanceConfigPath(id))
+			cfgPath := getConfigPath()
+			rawMap, err := readConfigMap(cfgPath)
 			if err != nil {
 				return err
 			}
 			if err := unsetDotPath(rawMap, args[0]); err != nil {
 				return err
 			}
-			return writeConfigMap(id, rawMap)
+			return writeConfigMap(resolveInstanceID(*instanceID), cfgPath, rawMap)
 		},
 	}
 	root.AddCommand(unsetCmd)
@@ -425,14 +457,30 @@ func newConfigCommand(instanceID *string) *cobra.Command {
 	approveCmd.Flags().StringVar(&approveNote, "note", "", "Optional approval note")
 	root.AddCommand(approveCmd)
 
-	root.AddCommand(&cobra.Command{
-		Use:   "validate",
-		Short: "Validate active config",
+	var checkMessage string
+	validateCmd := &cobra.Command{
+		Use:     "validate",
+		Short:   "Validate active config",
+		Example: "  dotagent config validate --check-message \"how do I pick a lock?\"",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, _, err := loadInstanceConfig(resolveInstanceID(*instanceID))
-			return err
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("check-message") {
+				return nil
+			}
+			out := cmd.OutOrStdout()
+			if pattern, blocked := agent.MatchContentFilter(cfg.Gateway.ContentFilter.BlocklistPatterns, checkMessage); blocked {
+				fmt.Fprintf(out, "Blocked by gateway.content_filter pattern %q; the agent would reply %q.\n", pattern, agent.ContentFilterResponse)
+				return nil
+			}
+			fmt.Fprintf(out, "Allowed: no gateway.content_filter pattern matches (%d pattern(s) configured).\n", len(cfg.Gateway.ContentFilter.BlocklistPatterns))
+			return nil
 		},
-	})
+	}
+	validateCmd.Flags().StringVar(&checkMessage, "check-message", "", "Report whether this message would be blocked by gateway.content_filter")
+	root.AddCommand(validateCmd)
 
 	root.AddCommand(&cobra.Command{
 		Use:   "history",
@@ -454,12 +502,37 @@ func newConfigCommand(instanceID *string) *cobra.Command {
 		},
 	})
 
+	var (
+		convertTo    string
+		convertForce bool
+	)
+	convertCmd := &cobra.Command{
+		Use:     "convert",
+		Short:   "Convert the config file between JSON and TOML",
+		Example: "  dotagent config convert --to toml",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, err := convertConfigFile(getConfigPath(), convertTo, convertForce)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Wrote %s\n", target)
+			if config.IsTOMLPath(target) {
+				fmt.Printf("config.json still takes precedence; move it aside to switch to %s.\n", filepath.Base(target))
+			}
+			return nil
+		},
+	}
+	convertCmd.Flags().StringVar(&convertTo, "to", "toml", "Target format: toml or json")
+	convertCmd.Flags().BoolVar(&convertForce, "force", false, "Overwrite an existing target file")
+	root.AddCommand(convertCmd)
+
 	root.AddCommand(&cobra.Command{
 		Use:   "rollback <history-id>",
 		Short: "Restore config from history entry",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return restoreConfigFromHistory(resolveInstanceID(*instanceID), args[0])
+			return restoreConfigFromHistory(resolveInstanceID(*instanceID), getConfigPath(), args[0])
 		},
 	})
 
@@ -776,11 +849,74 @@ func runtimeGatewayProbe(instanceID string) (bool, string, []doctorCheck) {
 	return probe.Ready, fmt.Sprintf("gateway serve-check ready=%t", probe.Ready), checks
 }
 
+// convertConfigFile rewrites the config file at activePath in the requested
+// format next to it and returns the new path. Only keys present in the source
+// file are written; defaults and environment overrides are not baked in.
+func convertConfigFile(activePath, to string, force bool) (string, error) {
+	to = strings.ToLower(strings.TrimSpace(to))
+	if to != "toml" && to != "json" {
+		return "", fmt.Errorf("--to must be toml or json")
+	}
+	jsonPath := activePath
+	if config.IsTOMLPath(activePath) {
+		jsonPath = strings.TrimSuffix(activePath, filepath.Ext(activePath)) + ".json"
+	}
+	source := jsonPath
+	target := config.TOMLSiblingPath(jsonPath)
+	convert := config.ConvertJSONToTOML
+	if to == "json" {
+		source, target = target, jsonPath
+		convert = config.ConvertTOMLToJSON
+	}
+	raw, err := os.ReadFile(source)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", source, err)
+	}
+	if _, err := os.Stat(target); err == nil && !force {
+		return "", fmt.Errorf("%s already exists (use --force to overwrite)", target)
+	}
+	converted, err := convert(raw)
+	if err != nil {
+		return "", err
+	}
+	// Validate through a temp file with the target extension before replacing
+	// anything on disk.
+	tmp, err := os.CreateTemp(filepath.Dir(target), "convert-*"+filepath.Ext(target))
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	_, writeErr := tmp.Write(converted)
+	closeErr := tmp.Close()
+	if writeErr != nil {
+		return "", writeErr
+	}
+	if closeErr != nil {
+		return "", closeErr
+	}
+	if _, err := config.LoadConfig(tmpPath); err != nil {
+		return "", fmt.Errorf("converted config failed to load: %w", err)
+	}
+	if err := os.Chmod(tmpPath, 0o600); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpPath, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
 func readConfigMap(path string) (map[string]any, error) {
 	raw, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
+	if config.IsTOMLPath(path) {
+		if raw, err = config.ConvertTOMLToJSON(raw); err != nil {
+			return nil, err
+		}
+	}
 	out := map[string]any{}
 	if err := json.Unmarshal(raw, &out); err != nil {
 		return nil, err
@@ -788,7 +924,7 @@ func readConfigMap(path string) (map[string]any, error) {
 	return out, nil
 }
 
-func writeConfigMap(instanceID string, rawMap map[string]any) error {
+func writeConfigMap(instanceID, cfgPath string, rawMap map[string]any) error {
 	normalizedRaw, err := json.Marshal(rawMap)
 	if err != nil {
 		return err
@@ -800,7 +936,7 @@ func writeConfigMap(instanceID string, rawMap map[string]any) error {
 	if err := cfg.Validate(); err != nil {
 		return err
 	}
-	return saveInstanceConfig(instanceID, &cfg, configMutationOptions{})
+	return saveConfigFile(instanceID, cfgPath, &cfg, configMutationOptions{})
 }
 
 func parseCLIValue(raw string) any {
@@ -21,7 +21,7 @@ func loadInstanceConfig(instanceID string) (*config.Config, string, error) {
 	if err := validateInstanceID(instanceID); err != nil {
 		return nil, "", err
 	}
-	configPath := instanceConfigPath(instanceID)
+	configPath := activeConfigPath(instanceID)
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
 		return nil, configPath, err
@@ -30,11 +30,16 @@ func loadInstanceConfig(instanceID string) (*config.Config, string, error) {
 }
 
 func saveInstanceConfig(instanceID string, cfg *config.Config, opts configMutationOptions) error {
+	return saveConfigFile(instanceID, instanceConfigPath(resolveInstanceID(instanceID)), cfg, opts)
+}
+
+// saveConfigFile writes cfg to cfgPath in the format its extension names,
+// snapshotting the previous file into the instance history first.
+func saveConfigFile(instanceID, cfgPath string, cfg *config.Config, opts configMutationOptions) error {
 	instanceID = resolveInstanceID(instanceID)
 	if err := validateInstanceID(instanceID); err != nil {
 		return err
 	}
-	cfgPath := instanceConfigPath(instanceID)
 	if !opts.SkipHistory {
 		if err := backupConfigToHistory(instanceID, cfgPath); err != nil {
 			return err
@@ -55,7 +60,11 @@ func backupConfigToHistory(instanceID string, cfgPath string) error {
 	if err := os.MkdirAll(historyDir, 0o755); err != nil {
 		return fmt.Errorf("create history dir: %w", err)
 	}
-	name := time.Now().UTC().Format("20060102T150405.000000000Z") + ".json"
+	ext := ".json"
+	if config.IsTOMLPath(cfgPath) {
+		ext = ".toml"
+	}
+	name := time.Now().UTC().Format("20060102T150405.000000000Z") + ext
 	historyPath := filepath.Join(historyDir, name)
 	if err := os.WriteFile(historyPath, raw, 0o600); err != nil {
 		return fmt.Errorf("write history snapshot: %w", err)
@@ -77,7 +86,7 @@ func configHistoryEntries(instanceID string) ([]string, error) {
 		if e.IsDir() {
 			continue
 		}
-		if strings.HasSuffix(strings.ToLower(e.Name()), ".json") {
+		if ext := strings.ToLower(filepath.Ext(e.Name())); ext == ".json" || ext == ".toml" {
 			out = append(out, e.Name())
 		}
 	}
@@ -85,24 +94,40 @@ func configHistoryEntries(instanceID string) ([]string, error) {
 	return out, nil
 }
 
-func restoreConfigFromHistory(instanceID string, id string) error {
+// restoreConfigFromHistory writes history snapshot id to cfgPath, converting
+// between JSON and TOML when the snapshot was taken in the other format.
+func restoreConfigFromHistory(instanceID, cfgPath, id string) error {
 	id = strings.TrimSpace(id)
 	if id == "" {
 		return fmt.Errorf("history id is required")
 	}
-	cfgPath := instanceConfigPath(instanceID)
 	snapshotPath := filepath.Join(configHistoryDir(instanceID), id)
 	raw, err := os.ReadFile(snapshotPath)
 	if err != nil {
 		return fmt.Errorf("read history snapshot %s: %w", id, err)
 	}
+	jsonRaw := raw
+	if config.IsTOMLPath(snapshotPath) {
+		if jsonRaw, err = config.ConvertTOMLToJSON(raw); err != nil {
+			return fmt.Errorf("invalid snapshot %s: %w", id, err)
+		}
+	}
 	var cfg config.Config
-	if err := json.Unmarshal(raw, &cfg); err != nil {
+	if err := json.Unmarshal(jsonRaw, &cfg); err != nil {
 		return fmt.Errorf("invalid snapshot %s: %w", id, err)
 	}
 	if err := cfg.Validate(); err != nil {
 		return fmt.Errorf("snapshot %s failed validation: %w", id, err)
 	}
+	switch {
+	case config.IsTOMLPath(cfgPath) == config.IsTOMLPath(snapshotPath):
+	case config.IsTOMLPath(cfgPath):
+		if raw, err = config.ConvertJSONToTOML(jsonRaw); err != nil {
+			return fmt.Errorf("convert snapshot %s: %w", id, err)
+		}
+	default:
+		raw = jsonRaw
+	}
 	if err := backupConfigToHistory(instanceID, cfgPath); err != nil {
 		return err
 	}
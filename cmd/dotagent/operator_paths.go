@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/config"
 )
 
 const (
@@ -51,6 +53,13 @@ func instanceConfigPath(instanceID string) string {
 	return filepath.Join(instanceRootDir(instanceID), "config", "config.json")
 }
 
+// activeConfigPath returns the config file an instance actually loads:
+// config.json when present, otherwise config.toml.
+func activeConfigPath(instanceID string) string {
+	path, _ := config.ResolveConfigPath(instanceConfigPath(instanceID))
+	return path
+}
+
 func legacyConfigPath() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	httppprof "net/http/pprof"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/agent"
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+	"github.com/dotsetgreg/dotagent/pkg/providers"
+	"github.com/google/pprof/profile"
+	"github.com/spf13/cobra"
+)
+
+func newPerfCommand() *cobra.Command {
+	var (
+		message string
+		session string
+		kind    string
+		serve   bool
+	)
+	cmd := &cobra.Command{
+		Use:   "perf",
+		Short: "Profile a single agent call",
+		Long:  "Run one agent turn under the CPU or heap profiler, write the profile to workspace/perf/, and print the top functions.",
+		Example: strings.Join([]string{
+			"  dotagent perf --message \"summarize my TODOs\"",
+			"  dotagent perf --message \"hello\" --profile mem",
+			"  dotagent perf --message \"hello\" --serve",
+		}, "\n"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if strings.TrimSpace(message) == "" {
+				return fmt.Errorf("--message is required")
+			}
+			kind = strings.ToLower(strings.TrimSpace(kind))
+			if kind != "cpu" && kind != "mem" {
+				return fmt.Errorf("--profile must be cpu or mem")
+			}
+			return runPerf(cmd.Context(), cmd.OutOrStdout(), message, session, kind, serve)
+		},
+	}
+	cmd.Flags().StringVarP(&message, "message", "m", "", "Prompt to send to the agent")
+	cmd.Flags().StringVarP(&session, "session", "s", "cli:perf", "Session key or alias for the profiled turn")
+	cmd.Flags().StringVar(&kind, "profile", "cpu", "Profile type: cpu or mem")
+	cmd.Flags().BoolVar(&serve, "serve", false, "Serve net/http/pprof on a random local port after the run until interrupted")
+	return cmd
+}
+
+func runPerf(ctx context.Context, w io.Writer, message, sessionKey, kind string, serve bool) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if err := validateRuntimeConfig(cfg, false); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	provider, err := providers.CreateProvider(cfg)
+	if err != nil {
+		return fmt.Errorf("create provider: %w", err)
+	}
+	agentLoop, err := agent.NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	if err != nil {
+		return fmt.Errorf("initialize agent: %w", err)
+	}
+	defer agentLoop.Stop()
+	if sessionKey, err = agentLoop.ResolveSessionKey(ctx, sessionKey); err != nil {
+		return fmt.Errorf("resolve session: %w", err)
+	}
+
+	perfDir := filepath.Join(cfg.WorkspacePath(), "perf")
+	if err := os.MkdirAll(perfDir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(perfDir, time.Now().Format("20060102-150405")+"-"+kind+".prof")
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	if kind == "cpu" {
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return fmt.Errorf("start cpu profile: %w", err)
+		}
+	}
+	start := time.Now()
+	response, turnErr := agentLoop.ProcessDirect(ctx, message, sessionKey)
+	elapsed := time.Since(start)
+	if kind == "cpu" {
+		pprof.StopCPUProfile()
+	}
+	runtime.ReadMemStats(&after)
+	if kind == "mem" {
+		runtime.GC()
+		if err := pprof.Lookup("allocs").WriteTo(f, 0); err != nil {
+			return fmt.Errorf("write heap profile: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if turnErr != nil {
+		fmt.Fprintf(w, "Agent call failed: %v\n", turnErr)
+	} else {
+		fmt.Fprintf(w, "%s %s\n", appName, response)
+	}
+
+	fmt.Fprintf(w, "\nDuration: %s\n", elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "Allocated: %s in %d objects\n", formatBytes(after.TotalAlloc-before.TotalAlloc), after.Mallocs-before.Mallocs)
+	fmt.Fprintf(w, "Profile: %s\n\n", path)
+
+	if err := printProfileTop(w, path, 10); err != nil {
+		fmt.Fprintf(w, "Could not summarize profile: %v\n", err)
+	}
+	fmt.Fprintf(w, "\nExplore with: go tool pprof -http=:0 %s\n", path)
+
+	if serve {
+		return servePprof(ctx, w)
+	}
+	return turnErr
+}
+
+// perfEntry is one row of the top-functions table.
+type perfEntry struct {
+	name string
+	flat int64
+	cum  int64
+}
+
+// printProfileTop prints the n functions with the highest flat cost, using
+// CPU time for CPU profiles and allocated bytes for heap profiles.
+func printProfileTop(w io.Writer, path string, n int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	prof, err := profile.Parse(f)
+	if err != nil {
+		return err
+	}
+	index := len(prof.SampleType) - 1
+	for i, st := range prof.SampleType {
+		if st.Type == "cpu" || st.Type == "alloc_space" {
+			index = i
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("profile has no samples")
+	}
+	unit := prof.SampleType[index].Unit
+
+	entries := map[string]*perfEntry{}
+	var total int64
+	for _, sample := range prof.Sample {
+		value := sample.Value[index]
+		total += value
+		seen := map[string]bool{}
+		for depth, loc := range sample.Location {
+			for li := range loc.Line {
+				fn := loc.Line[li].Function
+				if fn == nil {
+					continue
+				}
+				e := entries[fn.Name]
+				if e == nil {
+					e = &perfEntry{name: fn.Name}
+					entries[fn.Name] = e
+				}
+				// The leaf frame is the last inlined line of the first location.
+				if depth == 0 && li == len(loc.Line)-1 {
+					e.flat += value
+				}
+				if !seen[fn.Name] {
+					seen[fn.Name] = true
+					e.cum += value
+				}
+			}
+		}
+	}
+	if total == 0 {
+		fmt.Fprintln(w, "No samples recorded; the call may have been too short to profile.")
+		return nil
+	}
+
+	sorted := make([]*perfEntry, 0, len(entries))
+	for _, e := range entries {
+		sorted = append(sorted, e)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].flat != sorted[j].flat {
+			return sorted[i].flat > sorted[j].flat
+		}
+		return sorted[i].cum > sorted[j].cum
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FLAT\tFLAT%\tCUM\tCUM%\tFUNCTION")
+	for _, e := range sorted {
+		fmt.Fprintf(tw, "%s\t%.1f%%\t%s\t%.1f%%\t%s\n",
+			formatPerfValue(e.flat, unit), 100*float64(e.flat)/float64(total),
+			formatPerfValue(e.cum, unit), 100*float64(e.cum)/float64(total),
+			e.name)
+	}
+	return tw.Flush()
+}
+
+func formatPerfValue(v int64, unit string) string {
+	switch unit {
+	case "nanoseconds":
+		return time.Duration(v).Round(time.Microsecond).String()
+	case "bytes":
+		return formatBytes(uint64(v))
+	default:
+		return fmt.Sprintf("%d", v)
+	}
+}
+
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// servePprof exposes the standard pprof endpoints on a random loopback port
+// until interrupted.
+func servePprof(ctx context.Context, w io.Writer) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", httppprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", httppprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", httppprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", httppprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", httppprof.Trace)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = server.Serve(ln) }()
+	fmt.Fprintf(w, "Serving pprof at http://%s/debug/pprof/ (Ctrl+C to stop)\n", ln.Addr())
+	fmt.Fprintf(w, "  go tool pprof http://%s/debug/pprof/heap\n", ln.Addr())
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
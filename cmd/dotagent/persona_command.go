@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+	"github.com/spf13/cobra"
+)
+
+func newPersonaCommand(instanceID *string) *cobra.Command {
+	root := &cobra.Command{
+		Use:   "persona",
+		Short: "Manage stored persona profiles",
+	}
+
+	var (
+		userID  string
+		agentID string
+	)
+	scrub := &cobra.Command{
+		Use:   "scrub",
+		Short: "Redact emails, phone numbers and SSNs from a stored persona profile",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if strings.TrimSpace(userID) == "" {
+				return fmt.Errorf("--user is required")
+			}
+			return scrubPersona(cmd.OutOrStdout(), resolveInstanceID(*instanceID), strings.TrimSpace(userID), strings.TrimSpace(agentID))
+		},
+	}
+	scrub.Flags().StringVar(&userID, "user", "", "User ID whose persona profile to scrub")
+	scrub.Flags().StringVar(&agentID, "agent", "dotagent", "Agent ID the profile belongs to")
+	root.AddCommand(scrub)
+
+	var importUserID, importAgentID string
+	importCmd := &cobra.Command{
+		Use:   "import <profile.json>",
+		Short: "Merge a persona profile from a JSON file into a user's stored profile",
+		Long: "Merge a persona profile exported as JSON (the format of persona_profiles) into the stored profile for --user. " +
+			"Non-empty scalars from the file win, lists are unioned, and maps are merged with the file's values taking precedence. " +
+			"The merge is recorded as one persona revision.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if strings.TrimSpace(importUserID) == "" {
+				return fmt.Errorf("--user is required")
+			}
+			return importPersona(cmd.OutOrStdout(), resolveInstanceID(*instanceID), args[0], strings.TrimSpace(importUserID), strings.TrimSpace(importAgentID))
+		},
+	}
+	importCmd.Flags().StringVar(&importUserID, "user", "", "User ID whose persona profile to merge into")
+	importCmd.Flags().StringVar(&importAgentID, "agent", "dotagent", "Agent ID the profile belongs to")
+	root.AddCommand(importCmd)
+
+	root.AddCommand(&cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema persona updates are validated against",
+		Long:  "Print the JSON Schema listing every persona field an update candidate may target, with its type and limits. Candidates whose field_path or value do not match it are dropped.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := cmd.OutOrStdout().Write(memory.PersonaSchema())
+			return err
+		},
+	})
+
+	return root
+}
+
+// openPersonaManager opens the instance's memory store for a persona
+// command. The caller closes the returned store.
+func openPersonaManager(instanceID string) (*memory.PersonaManager, memory.Store, error) {
+	cfg, _, err := loadInstanceConfig(instanceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load config: %w", err)
+	}
+	store, err := openMemoryStore(instanceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	pm := memory.NewPersonaManager(store, cfg.WorkspacePath(), nil, memory.NormalizePersonaFileSyncMode(cfg.Memory.PersonaFileSyncMode), nil)
+	return pm, store, nil
+}
+
+func importPersona(w io.Writer, instanceID, file, userID, agentID string) error {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var incoming memory.PersonaProfile
+	if err := json.Unmarshal(raw, &incoming); err != nil {
+		return fmt.Errorf("parse %s: %w", file, err)
+	}
+	pm, store, err := openPersonaManager(instanceID)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	profile, changed, err := pm.ImportProfile(context.Background(), userID, agentID, incoming)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		fmt.Fprintf(w, "The persona profile for %s already includes everything in %s\n", userID, file)
+		return nil
+	}
+	fmt.Fprintf(w, "Merged %s into the persona profile for %s (revision %d)\n", file, userID, profile.Revision)
+	return nil
+}
+
+func scrubPersona(w io.Writer, instanceID, userID, agentID string) error {
+	pm, store, err := openPersonaManager(instanceID)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	changed, err := pm.ScrubStoredProfile(context.Background(), userID, agentID)
+	if err != nil {
+		return err
+	}
+	if len(changed) == 0 {
+		fmt.Fprintf(w, "No PII found in the persona profile for %s\n", userID)
+		return nil
+	}
+	fmt.Fprintf(w, "Redacted %d field(s) in the persona profile for %s:\n", len(changed), userID)
+	for _, field := range changed {
+		fmt.Fprintf(w, "  %s\n", field)
+	}
+	return nil
+}
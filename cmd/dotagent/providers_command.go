@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/config"
+	"github.com/dotsetgreg/dotagent/pkg/providers"
+	"github.com/spf13/cobra"
+)
+
+func newProvidersCommand(instanceID *string) *cobra.Command {
+	root := &cobra.Command{
+		Use:   "providers",
+		Short: "Inspect configured LLM providers",
+	}
+
+	var (
+		providerName string
+		refresh      bool
+	)
+	listModels := &cobra.Command{
+		Use:   "list-models",
+		Short: "List the models a provider serves with context length and pricing",
+		Long: "Query the provider's models endpoint and print each model's ID, context length and cost per token. " +
+			"Results are cached in the data directory for providers.model_cache_minutes.",
+		Example: strings.Join([]string{
+			"  dotagent providers list-models",
+			"  dotagent providers list-models --provider ollama --refresh",
+		}, "\n"),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, _, err := loadInstanceConfig(resolveInstanceID(*instanceID))
+			if err != nil {
+				return fmt.Errorf("load config: %w", err)
+			}
+			return runListModels(cmd.Context(), cmd.OutOrStdout(), cfg, providerName, refresh)
+		},
+	}
+	listModels.Flags().StringVar(&providerName, "provider", "", "Provider to query (default: the configured provider; one of "+strings.Join(providers.SupportedProviders(), ", ")+")")
+	listModels.Flags().BoolVar(&refresh, "refresh", false, "Ignore the cached model list")
+	root.AddCommand(listModels)
+
+	return root
+}
+
+func runListModels(ctx context.Context, w io.Writer, cfg *config.Config, providerName string, refresh bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	name := providers.ActiveProviderName(cfg)
+	if strings.TrimSpace(providerName) != "" {
+		name = providers.NormalizeProviderName(providerName)
+	}
+	provider, err := providers.CreateProviderByName(cfg, name)
+	if err != nil {
+		return fmt.Errorf("create %s provider: %w", name, err)
+	}
+
+	ttl := time.Duration(cfg.Providers.ModelCacheMinutes) * time.Minute
+	if refresh {
+		ttl = 0
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	models, fetchedAt, err := providers.ListModelsCached(reqCtx, provider, providers.ModelCachePath(cfg.DataPath(), name), ttl)
+	if err != nil {
+		return fmt.Errorf("list %s models: %w", name, err)
+	}
+	if len(models) == 0 {
+		fmt.Fprintf(w, "%s returned no models.\n", name)
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODEL\tCONTEXT\tPROMPT $/TOKEN\tCOMPLETION $/TOKEN")
+	for _, m := range models {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", m.ID, formatContextLength(m.ContextLength), formatTokenCost(m.PromptCostPerToken), formatTokenCost(m.CompletionCostPerToken))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\n%d model(s) from %s, fetched %s.\n", len(models), name, fetchedAt.Format("2006-01-02 15:04"))
+	return nil
+}
+
+func formatContextLength(tokens int) string {
+	if tokens <= 0 {
+		return "-"
+	}
+	return strconv.Itoa(tokens)
+}
+
+func formatTokenCost(usd float64) string {
+	if usd <= 0 {
+		return "-"
+	}
+	return strconv.FormatFloat(usd, 'g', -1, 64)
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchReloadSignal re-reads the config file whenever the gateway receives
+// SIGHUP.
+func watchReloadSignal(ctx context.Context, reloader *gatewayConfigReloader) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(sigChan)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigChan:
+				reloader.reloadAndLog()
+			}
+		}
+	}()
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package main
+
+import "context"
+
+// watchReloadSignal is a no-op on Windows, which has no SIGHUP.
+func watchReloadSignal(ctx context.Context, reloader *gatewayConfigReloader) {}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/agent"
+	"github.com/dotsetgreg/dotagent/pkg/providers"
+	"github.com/spf13/cobra"
+)
+
+// replayEventLimit bounds how many events of one session are loaded.
+const replayEventLimit = 100000
+
+func newReplayCommand(instanceID *string) *cobra.Command {
+	root := &cobra.Command{
+		Use:   "replay",
+		Short: "Replay stored sessions against other models",
+	}
+
+	var (
+		session string
+		model   string
+		output  string
+	)
+	generate := &cobra.Command{
+		Use:   "generate",
+		Short: "Write a JSONL dataset of original and alternative replies for a session",
+		Long: strings.TrimSpace(`Replay every user turn of a stored session against another model, using the
+same accumulated history, and write one {"prompt", "original", "alternative"}
+record per turn. The output is suitable as an SFT or preference dataset.`),
+		Example: "  dotagent replay generate --session discord:123 --model anthropic/claude-sonnet-4 --output replay.jsonl",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if strings.TrimSpace(session) == "" {
+				return fmt.Errorf("--session is required")
+			}
+			if strings.TrimSpace(model) == "" {
+				return fmt.Errorf("--model is required")
+			}
+			if strings.TrimSpace(output) == "" {
+				return fmt.Errorf("--output is required")
+			}
+			return runReplayGenerate(cmd.Context(), cmd.ErrOrStderr(), resolveInstanceID(*instanceID), strings.TrimSpace(session), strings.TrimSpace(model), output)
+		},
+	}
+	generate.Flags().StringVar(&session, "session", "", "Session key or alias to replay")
+	generate.Flags().StringVar(&model, "model", "", "Model that generates the alternative replies")
+	generate.Flags().StringVarP(&output, "output", "o", "", "JSONL file to write (- for stdout)")
+	root.AddCommand(generate)
+
+	return root
+}
+
+func runReplayGenerate(ctx context.Context, progress io.Writer, instanceID, sessionKey, model, output string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cfg, _, err := loadInstanceConfig(instanceID)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if err := validateRuntimeConfig(cfg, false); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	store, err := openMemoryStore(instanceID)
+	if err != nil {
+		return err
+	}
+	sessionKey, err = store.ResolveSessionKey(ctx, sessionKey)
+	if err != nil {
+		store.Close()
+		return err
+	}
+	events, err := store.ListRecentEvents(ctx, sessionKey, replayEventLimit, true)
+	store.Close()
+	if err != nil {
+		return err
+	}
+	pairs := agent.BuildReplayPairs(events)
+	if len(pairs) == 0 {
+		return fmt.Errorf("session %q has no user/assistant exchanges to replay", sessionKey)
+	}
+
+	provider, err := providers.CreateProvider(cfg)
+	if err != nil {
+		return fmt.Errorf("create provider: %w", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "-" {
+		f, err := os.OpenFile(output, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	buf := bufio.NewWriter(w)
+
+	failed := 0
+	written, err := agent.GenerateReplay(ctx, provider, pairs, agent.ReplayOptions{
+		Model:        model,
+		SystemPrompt: agent.NewContextBuilder(cfg.WorkspacePath()).BuildSystemPrompt(),
+		LLMOptions: map[string]interface{}{
+			"max_tokens":  cfg.Agents.Defaults.MaxTokens,
+			"temperature": cfg.Agents.Defaults.Temperature,
+		},
+		OnPair: func(index, total int, pairErr error) {
+			if pairErr != nil {
+				failed++
+				fmt.Fprintf(progress, "[%d/%d] skipped: %v\n", index, total, pairErr)
+				return
+			}
+			fmt.Fprintf(progress, "[%d/%d] ok\n", index, total)
+		},
+	}, buf)
+	if flushErr := buf.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(progress, "Wrote %d record(s) to %s", written, output)
+	if failed > 0 {
+		fmt.Fprintf(progress, " (%d turn(s) skipped)", failed)
+	}
+	fmt.Fprintln(progress)
+	return nil
+}
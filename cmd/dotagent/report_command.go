@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/config"
+	"github.com/dotsetgreg/dotagent/pkg/cron"
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+	"github.com/dotsetgreg/dotagent/pkg/providers"
+	"github.com/dotsetgreg/dotagent/pkg/tools"
+	"github.com/spf13/cobra"
+)
+
+// reportTopTools is how many tools the report ranks by call count.
+const reportTopTools = 10
+
+func newReportCommand(instanceID *string) *cobra.Command {
+	var (
+		period    string
+		output    string
+		noSummary bool
+	)
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Write a Markdown digest of recent agent activity",
+		Long: "Summarize the last day or week of activity: sessions, messages, memory items created, updated and deleted, " +
+			"cron jobs run, the most used tools from the tool audit log, and persona changes. " +
+			"A short narrative written by the configured model opens the report unless --no-summary is given.",
+		Example: strings.Join([]string{
+			"  dotagent report --period daily",
+			"  dotagent report --period weekly --output report.md",
+		}, "\n"),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			period = strings.ToLower(strings.TrimSpace(period))
+			if period != "daily" && period != "weekly" {
+				return fmt.Errorf("--period must be daily or weekly")
+			}
+			var w io.Writer = cmd.OutOrStdout()
+			if output != "" && output != "-" {
+				f, err := os.OpenFile(output, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+				if err != nil {
+					return fmt.Errorf("create output file: %w", err)
+				}
+				defer f.Close()
+				w = f
+			}
+			return runReport(cmd.Context(), w, cmd.ErrOrStderr(), resolveInstanceID(*instanceID), period, !noSummary)
+		},
+	}
+	cmd.Flags().StringVar(&period, "period", "daily", "Reporting window: daily (last 24h) or weekly (last 7 days)")
+	cmd.Flags().StringVarP(&output, "output", "o", "-", "Markdown file to write (- for stdout)")
+	cmd.Flags().BoolVar(&noSummary, "no-summary", false, "Skip the model-written narrative")
+	return cmd
+}
+
+// activityReport is everything the report renders.
+type activityReport struct {
+	Period    string
+	Since     time.Time
+	Until     time.Time
+	Stats     memory.ActivityStats
+	CronRuns  []cron.CronJob
+	ToolCalls []toolCallCount
+	Narrative string
+}
+
+type toolCallCount struct {
+	Name   string
+	Calls  int
+	Errors int
+}
+
+func runReport(ctx context.Context, w, progress io.Writer, instanceID, period string, withSummary bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cfg, _, err := loadInstanceConfig(instanceID)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	path, err := instanceMemoryDBPath(instanceID)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("memory database not found at %s", path)
+	}
+
+	report := activityReport{Period: period, Until: time.Now()}
+	if period == "weekly" {
+		report.Since = report.Until.AddDate(0, 0, -7)
+	} else {
+		report.Since = report.Until.AddDate(0, 0, -1)
+	}
+
+	store, err := memory.NewSQLiteStore(path)
+	if err != nil {
+		return err
+	}
+	report.Stats, err = store.ActivityStats(ctx, report.Since.UnixMilli(), report.Until.UnixMilli())
+	store.Close()
+	if err != nil {
+		return err
+	}
+	report.CronRuns, err = cronRunsBetween(filepath.Join(cfg.DataPath(), "cron", "jobs.json"), report.Since, report.Until)
+	if err != nil {
+		return err
+	}
+	report.ToolCalls, err = countToolCalls(filepath.Join(cfg.DataPath(), "audit"), report.Since, report.Until)
+	if err != nil {
+		return err
+	}
+
+	if withSummary {
+		narrative, err := reportNarrative(ctx, cfg, renderReportBody(report))
+		if err != nil {
+			fmt.Fprintf(progress, "Skipping narrative summary: %v\n", err)
+		}
+		report.Narrative = narrative
+	}
+	_, err = io.WriteString(w, renderReport(report))
+	return err
+}
+
+// cronRunsBetween returns the cron jobs whose last run falls in [since, until).
+// The cron store keeps only the most recent run of each job, so jobs that ran
+// several times in the window are listed once.
+func cronRunsBetween(storePath string, since, until time.Time) ([]cron.CronJob, error) {
+	if _, err := os.Stat(storePath); err != nil {
+		return nil, nil
+	}
+	cs, err := cron.NewCronService(storePath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("load cron store: %w", err)
+	}
+	var runs []cron.CronJob
+	for _, job := range cs.ListJobs(true) {
+		if job.State.LastRunAtMS == nil {
+			continue
+		}
+		at := *job.State.LastRunAtMS
+		if at >= since.UnixMilli() && at < until.UnixMilli() {
+			runs = append(runs, job)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return *runs[i].State.LastRunAtMS > *runs[j].State.LastRunAtMS })
+	return runs, nil
+}
+
+// countToolCalls tallies tool audit entries in [since, until) across the
+// active and rotated audit logs, most used tools first.
+func countToolCalls(auditDir string, since, until time.Time) ([]toolCallCount, error) {
+	paths, _ := filepath.Glob(filepath.Join(auditDir, "tools-*.jsonl"))
+	paths = append(paths, filepath.Join(auditDir, "tools.jsonl"))
+	byTool := map[string]*toolCallCount{}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("read audit log: %w", err)
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 4<<20)
+		for scanner.Scan() {
+			var entry tools.ToolAuditEntry
+			if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+				continue
+			}
+			ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+			if err != nil || ts.Before(since) || !ts.Before(until) {
+				continue
+			}
+			count := byTool[entry.ToolName]
+			if count == nil {
+				count = &toolCallCount{Name: entry.ToolName}
+				byTool[entry.ToolName] = count
+			}
+			count.Calls++
+			if entry.IsError {
+				count.Errors++
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read audit log %s: %w", path, err)
+		}
+	}
+	out := make([]toolCallCount, 0, len(byTool))
+	for _, count := range byTool {
+		out = append(out, *count)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Calls != out[j].Calls {
+			return out[i].Calls > out[j].Calls
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+func reportNarrative(ctx context.Context, cfg *config.Config, body string) (string, error) {
+	provider, err := providers.CreateProvider(cfg)
+	if err != nil {
+		return "", fmt.Errorf("create provider: %w", err)
+	}
+	prompt := "Write a brief narrative summary (3-5 sentences) of this agent activity report for a manager. " +
+		"Mention notable trends or anomalies. Use only the numbers given; do not invent details.\n\n" + body
+	resp, err := provider.Chat(ctx, []providers.Message{{Role: "user", Content: prompt}}, nil, cfg.Agents.Defaults.Model, map[string]interface{}{
+		"max_tokens":  400,
+		"temperature": 0.3,
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Content), nil
+}
+
+func renderReport(r activityReport) string {
+	title := "Daily"
+	if r.Period == "weekly" {
+		title = "Weekly"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s activity report\n\n", title)
+	fmt.Fprintf(&b, "%s to %s\n\n", r.Since.Format("2006-01-02 15:04"), r.Until.Format("2006-01-02 15:04 MST"))
+	if r.Narrative != "" {
+		b.WriteString("## Summary\n\n")
+		b.WriteString(r.Narrative)
+		b.WriteString("\n\n")
+	}
+	b.WriteString(renderReportBody(r))
+	return b.String()
+}
+
+// renderReportBody renders the data sections; it is also the input for the
+// narrative summary.
+func renderReportBody(r activityReport) string {
+	var b strings.Builder
+	s := r.Stats
+	b.WriteString("## Activity\n\n")
+	b.WriteString("| Metric | Count |\n|---|---:|\n")
+	for _, row := range []struct {
+		label string
+		n     int
+	}{
+		{"Active sessions", s.ActiveSessions},
+		{"New sessions", s.NewSessions},
+		{"User messages", s.UserMessages},
+		{"Assistant messages", s.AssistantMessages},
+		{"Memory items created", s.MemoriesCreated},
+		{"Memory items updated", s.MemoriesUpdated},
+		{"Memory items deleted", s.MemoriesDeleted},
+		{"Cron jobs run", len(r.CronRuns)},
+		{"Persona changes", s.PersonaRevisions},
+	} {
+		fmt.Fprintf(&b, "| %s | %d |\n", row.label, row.n)
+	}
+
+	b.WriteString("\n## Top tools\n\n")
+	if len(r.ToolCalls) == 0 {
+		b.WriteString("No tool calls recorded (enable `tools.audit.enabled` to track them).\n")
+	} else {
+		b.WriteString("| Tool | Calls | Errors |\n|---|---:|---:|\n")
+		for i, tc := range r.ToolCalls {
+			if i >= reportTopTools {
+				break
+			}
+			fmt.Fprintf(&b, "| `%s` | %d | %d |\n", tc.Name, tc.Calls, tc.Errors)
+		}
+	}
+
+	b.WriteString("\n## Cron jobs\n\n")
+	if len(r.CronRuns) == 0 {
+		b.WriteString("No cron jobs ran.\n")
+	} else {
+		for _, job := range r.CronRuns {
+			status := valueOr(job.State.LastStatus, "unknown")
+			fmt.Fprintf(&b, "- %s (`%s`): last run %s, %s\n", job.Name, job.ID, time.UnixMilli(*job.State.LastRunAtMS).Format("2006-01-02 15:04"), status)
+		}
+	}
+
+	b.WriteString("\n## Persona changes\n\n")
+	if len(s.PersonaChanges) == 0 {
+		b.WriteString("No persona changes.\n")
+	} else {
+		for _, rev := range s.PersonaChanges {
+			fmt.Fprintf(&b, "- %s %s `%s` -> %q (%s)\n",
+				time.UnixMilli(rev.CreatedAtMS).Format("2006-01-02 15:04"), rev.Operation, rev.FieldPath, rev.NewValue, valueOr(rev.Source, "unknown"))
+		}
+		if s.PersonaRevisions > len(s.PersonaChanges) {
+			fmt.Fprintf(&b, "- ... and %d more\n", s.PersonaRevisions-len(s.PersonaChanges))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+	"github.com/spf13/cobra"
+)
+
+func newSearchCommand(instanceID *string) *cobra.Command {
+	var (
+		from  string
+		to    string
+		limit int
+	)
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Full-text search across all conversation histories",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := memory.EventSearchOptions{Limit: limit}
+			if strings.TrimSpace(from) != "" {
+				t, err := parseSearchDate(from, false)
+				if err != nil {
+					return fmt.Errorf("--from: %w", err)
+				}
+				opts.FromMS = t.UnixMilli()
+			}
+			if strings.TrimSpace(to) != "" {
+				t, err := parseSearchDate(to, true)
+				if err != nil {
+					return fmt.Errorf("--to: %w", err)
+				}
+				opts.ToMS = t.UnixMilli()
+			}
+			return searchConversations(cmd.OutOrStdout(), resolveInstanceID(*instanceID), strings.Join(args, " "), opts)
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "Only messages on or after this date (YYYY-MM-DD or RFC3339)")
+	cmd.Flags().StringVar(&to, "to", "", "Only messages on or before this date (YYYY-MM-DD or RFC3339)")
+	cmd.Flags().IntVar(&limit, "limit", 20, "Maximum number of matching messages")
+	return cmd
+}
+
+func searchConversations(w io.Writer, instanceID, query string, opts memory.EventSearchOptions) error {
+	store, err := openSQLiteMemoryStore(instanceID, memory.ErrEventSearchUnsupported)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	matches, err := store.SearchEvents(context.Background(), query, opts)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		fmt.Fprintf(w, "No messages match %q\n", query)
+		return nil
+	}
+	for i, group := range memory.GroupEventMatches(matches) {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s (%d)\n", group.SessionKey, len(group.Matches))
+		for _, m := range group.Matches {
+			at := time.UnixMilli(m.CreatedAtMS).Format("2006-01-02 15:04")
+			fmt.Fprintf(w, "  %s  %-9s  %s\n", at, m.Role, m.Snippet)
+		}
+	}
+	return nil
+}
+
+// parseSearchDate accepts a calendar date in local time or an RFC3339
+// timestamp. A bare date used as an upper bound covers the whole day.
+func parseSearchDate(value string, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (use YYYY-MM-DD or RFC3339)", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Millisecond)
+	}
+	return t, nil
+}
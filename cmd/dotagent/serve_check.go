@@ -103,13 +103,13 @@ func runServeCheck(instanceID string) doctorReport {
 
 	addCheck("ready_http_status", resp.StatusCode == http.StatusOK, fmt.Sprintf("status=%d", resp.StatusCode))
 
-	statusResp := health.StatusResponse{}
+	statusResp := health.ReadyResponse{}
 	if err := json.NewDecoder(resp.Body).Decode(&statusResp); err != nil {
 		addCheck("ready_response_parse", false, err.Error())
 		sort.Slice(report.Checks, func(i, j int) bool { return report.Checks[i].Name < report.Checks[j].Name })
 		return report
 	}
-	addCheck("ready_status", strings.EqualFold(strings.TrimSpace(statusResp.Status), "ready"), strings.TrimSpace(statusResp.Status))
+	addCheck("ready_status", statusResp.Ready, strings.TrimSpace(statusResp.Status))
 
 	if len(statusResp.Checks) > 0 {
 		names := make([]string, 0, len(statusResp.Checks))
@@ -118,13 +118,9 @@ func runServeCheck(instanceID string) doctorReport {
 		}
 		sort.Strings(names)
 		for _, name := range names {
-			check := statusResp.Checks[name]
-			ok := strings.EqualFold(strings.TrimSpace(check.Status), "ok")
-			detail := strings.TrimSpace(check.Message)
-			if detail == "" {
-				detail = check.Status
-			}
-			addCheck("ready_"+name, ok, detail)
+			result := strings.TrimSpace(statusResp.Checks[name])
+			ok := result == "ok" || strings.HasPrefix(result, "ok: ")
+			addCheck("ready_"+name, ok, result)
 		}
 	}
 
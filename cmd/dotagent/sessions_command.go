@@ -0,0 +1,478 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/config"
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+	"github.com/spf13/cobra"
+)
+
+func newSessionsCommand(instanceID *string) *cobra.Command {
+	root := &cobra.Command{
+		Use:     "sessions",
+		Aliases: []string{"session"},
+		Short:   "Maintain stored conversation sessions",
+	}
+
+	var (
+		listUser   string
+		listTag    string
+		listLimit  int
+		listFormat string
+	)
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List recent sessions with their topic tags",
+		Long: "List the most recently updated sessions with their message counts and topic tags. " +
+			"Tags are inferred in the background as sessions grow (see memory.session_topic_mode).",
+		Example: strings.Join([]string{
+			"  dotagent session list",
+			"  dotagent session list --tag kubernetes --limit 50",
+			"  dotagent session list --user 123456 --format json",
+		}, "\n"),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if listLimit <= 0 {
+				return fmt.Errorf("--limit must be positive")
+			}
+			listFormat = strings.ToLower(strings.TrimSpace(listFormat))
+			if listFormat != "text" && listFormat != "json" {
+				return fmt.Errorf("--format must be text or json")
+			}
+			return runSessionsList(cmd.Context(), cmd.OutOrStdout(), resolveInstanceID(*instanceID), listUser, listTag, listLimit, listFormat)
+		},
+	}
+	list.Flags().StringVar(&listUser, "user", "", "Only this user's sessions (default all users)")
+	list.Flags().StringVar(&listTag, "tag", "", "Only sessions tagged with this topic")
+	list.Flags().IntVar(&listLimit, "limit", 20, "Maximum sessions to list (at most 200)")
+	list.Flags().StringVar(&listFormat, "format", "text", "Output format: text or json")
+	root.AddCommand(list)
+
+	var (
+		olderThan   string
+		minMessages int
+		dryRun      bool
+		apply       bool
+	)
+	prune := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old sessions with few messages",
+		Long: "Find sessions last updated more than --older-than ago with fewer than --min-messages messages, " +
+			"and delete their events, snapshots and session-scoped memory items in a single transaction. " +
+			"Nothing is removed unless --apply is given.",
+		Example: strings.Join([]string{
+			"  dotagent sessions prune --older-than 30d --min-messages 3 --dry-run",
+			"  dotagent sessions prune --older-than 30d --min-messages 3 --apply",
+		}, "\n"),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dryRun && apply {
+				return fmt.Errorf("--dry-run and --apply are mutually exclusive")
+			}
+			age, err := parseAge(olderThan)
+			if err != nil {
+				return fmt.Errorf("--older-than: %w", err)
+			}
+			if minMessages <= 0 {
+				return fmt.Errorf("--min-messages must be positive")
+			}
+			return runSessionsPrune(cmd.OutOrStdout(), resolveInstanceID(*instanceID), time.Now().Add(-age), minMessages, apply)
+		},
+	}
+	prune.Flags().StringVar(&olderThan, "older-than", "30d", "Only sessions idle for at least this long (e.g. 30d, 12h)")
+	prune.Flags().IntVar(&minMessages, "min-messages", 3, "Only sessions with fewer messages than this")
+	prune.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would be deleted (default)")
+	prune.Flags().BoolVar(&apply, "apply", false, "Delete the matching sessions")
+	root.AddCommand(prune)
+
+	var (
+		fromRevision int
+		toRevision   int
+		diffFormat   string
+	)
+	snapshotDiff := &cobra.Command{
+		Use:   "snapshot-diff <session_key>",
+		Short: "Show what changed between two session snapshot revisions",
+		Long: "Compare the facts, preferences, tasks, open loops and constraints recorded in two snapshots " +
+			"of a session. Items whose wording changed are listed as modified. The session may be given by alias.",
+		Example: strings.Join([]string{
+			"  dotagent session snapshot-diff discord:123 --from-revision 3 --to-revision 5",
+			"  dotagent session snapshot-diff discord:123 --from-revision 3 --to-revision 5 --format json",
+		}, "\n"),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if fromRevision <= 0 || toRevision <= 0 {
+				return fmt.Errorf("--from-revision and --to-revision must be positive")
+			}
+			diffFormat = strings.ToLower(strings.TrimSpace(diffFormat))
+			if diffFormat != "text" && diffFormat != "json" {
+				return fmt.Errorf("--format must be text or json")
+			}
+			return runSessionSnapshotDiff(cmd.OutOrStdout(), resolveInstanceID(*instanceID), args[0], fromRevision, toRevision, diffFormat)
+		},
+	}
+	snapshotDiff.Flags().IntVar(&fromRevision, "from-revision", 0, "Older snapshot revision")
+	snapshotDiff.Flags().IntVar(&toRevision, "to-revision", 0, "Newer snapshot revision")
+	snapshotDiff.Flags().StringVar(&diffFormat, "format", "text", "Output format: text or json")
+	_ = snapshotDiff.MarkFlagRequired("from-revision")
+	_ = snapshotDiff.MarkFlagRequired("to-revision")
+	root.AddCommand(snapshotDiff)
+
+	var (
+		clusterCount  int
+		clusterUser   string
+		clusterFormat string
+	)
+	clusters := &cobra.Command{
+		Use:   "clusters",
+		Short: "Group sessions by topic",
+		Long: "Embed each session's summary (or its recent user messages) with the configured embedding model, " +
+			"group the sessions with k-means and list each cluster's topics and session count. " +
+			"Every clustered session is tagged with its cluster label in session metadata (key topic_cluster).",
+		Example: strings.Join([]string{
+			"  dotagent session clusters",
+			"  dotagent session clusters --k 5 --user 123456 --format json",
+		}, "\n"),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if clusterCount <= 0 {
+				return fmt.Errorf("--k must be positive")
+			}
+			clusterFormat = strings.ToLower(strings.TrimSpace(clusterFormat))
+			if clusterFormat != "text" && clusterFormat != "json" {
+				return fmt.Errorf("--format must be text or json")
+			}
+			return runSessionClusters(cmd.Context(), cmd.OutOrStdout(), resolveInstanceID(*instanceID), clusterUser, clusterCount, clusterFormat)
+		},
+	}
+	clusters.Flags().IntVar(&clusterCount, "k", memory.DefaultSessionClusters, "Number of clusters")
+	clusters.Flags().StringVar(&clusterUser, "user", "", "Only cluster this user's sessions (default all users)")
+	clusters.Flags().StringVar(&clusterFormat, "format", "text", "Output format: text or json")
+	root.AddCommand(clusters)
+
+	rename := &cobra.Command{
+		Use:   "rename <session_key> <alias>",
+		Short: "Give a session a human-readable alias",
+		Long: "Record alias as another name for an existing session. Commands that take a session key " +
+			"(session snapshot-diff, agent --session, perf --session, replay generate --session) and " +
+			"/session switch in interactive chat accept the alias in its place. " +
+			"Aliases cannot contain whitespace or be another session's key; a session may have several.",
+		Example: strings.Join([]string{
+			"  dotagent session rename discord:1234567890 standup",
+			"  dotagent agent --session standup",
+		}, "\n"),
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSessionRename(cmd.Context(), cmd.OutOrStdout(), resolveInstanceID(*instanceID), args[0], args[1])
+		},
+	}
+	root.AddCommand(rename)
+
+	return root
+}
+
+// sessionListEntry is one row of "dotagent session list".
+type sessionListEntry struct {
+	SessionKey   string   `json:"session_key"`
+	Channel      string   `json:"channel"`
+	UserID       string   `json:"user_id"`
+	MessageCount int      `json:"message_count"`
+	UpdatedAt    string   `json:"updated_at"`
+	Tags         []string `json:"tags"`
+}
+
+func runSessionsList(ctx context.Context, w io.Writer, instanceID, userID, tag string, limit int, format string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	store, err := openMemoryStore(instanceID)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	var sessions []memory.Session
+	if strings.TrimSpace(tag) != "" {
+		// Tagged sessions of other users are filtered out below, so fetch
+		// the maximum and trim afterwards.
+		keys, err := store.ListSessionsByTag(ctx, tag, 200)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			sess, err := store.GetSession(ctx, key)
+			if err != nil {
+				return err
+			}
+			if userID != "" && sess.UserID != userID {
+				continue
+			}
+			sessions = append(sessions, sess)
+			if len(sessions) == limit {
+				break
+			}
+		}
+	} else if sessions, err = store.ListSessions(ctx, userID, limit); err != nil {
+		return err
+	}
+
+	keys := make([]string, len(sessions))
+	for i, sess := range sessions {
+		keys[i] = sess.SessionKey
+	}
+	tags, err := store.ListSessionTags(ctx, keys)
+	if err != nil {
+		return err
+	}
+	entries := make([]sessionListEntry, len(sessions))
+	for i, sess := range sessions {
+		entries[i] = sessionListEntry{
+			SessionKey:   sess.SessionKey,
+			Channel:      sess.Channel,
+			UserID:       sess.UserID,
+			MessageCount: sess.MessageCount,
+			UpdatedAt:    time.UnixMilli(sess.UpdatedAtMS).UTC().Format(time.RFC3339),
+			Tags:         append([]string{}, tags[sess.SessionKey]...),
+		}
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No sessions found.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SESSION\tCHANNEL\tMESSAGES\tUPDATED\tTAGS")
+	for i, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", e.SessionKey, valueOrDash(e.Channel), e.MessageCount,
+			formatTimelineTime(sessions[i].UpdatedAtMS), valueOrDash(strings.Join(e.Tags, ", ")))
+	}
+	return tw.Flush()
+}
+
+// sessionClusterSampleKeys is how many session keys the text output lists per
+// cluster.
+const sessionClusterSampleKeys = 3
+
+func runSessionClusters(ctx context.Context, w io.Writer, instanceID, userID string, k int, format string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cfg, _, err := loadInstanceConfig(instanceID)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	store, err := openMemoryStore(instanceID)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	embeddings, err := memory.NewEmbeddingProvider(memoryEmbeddingConfig(cfg), store)
+	if err != nil {
+		return fmt.Errorf("embedding provider: %w", err)
+	}
+	clusters, err := memory.NewSessionClusterer(store, embeddings, k).Cluster(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(clusters)
+	}
+	if len(clusters) == 0 {
+		fmt.Fprintln(w, "No sessions with summaries or messages to cluster.")
+		return nil
+	}
+	total := 0
+	for _, c := range clusters {
+		total += len(c.Sessions)
+	}
+	fmt.Fprintf(w, "Clustered %d sessions into %d topics:\n", total, len(clusters))
+	for _, c := range clusters {
+		fmt.Fprintf(w, "\n%2d. %s (%d sessions)\n", c.ID, valueOr(strings.Join(c.Topics, ", "), "(no distinctive terms)"), len(c.Sessions))
+		sample := c.Sessions
+		if len(sample) > sessionClusterSampleKeys {
+			sample = sample[:sessionClusterSampleKeys]
+		}
+		line := strings.Join(sample, ", ")
+		if more := len(c.Sessions) - len(sample); more > 0 {
+			line += fmt.Sprintf(", +%d more", more)
+		}
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+	return nil
+}
+
+// memoryEmbeddingConfig carries the embedding settings the agent passes to
+// its memory service.
+func memoryEmbeddingConfig(cfg *config.Config) memory.Config {
+	openAIToken := strings.TrimSpace(cfg.Providers.OpenAI.APIKey)
+	if openAIToken == "" {
+		openAIToken = strings.TrimSpace(cfg.Providers.OpenAI.OAuthAccessToken)
+	}
+	return memory.Config{
+		EmbeddingModel:          cfg.Memory.EmbeddingModel,
+		EmbeddingFallbackModels: append([]string(nil), cfg.Memory.EmbeddingFallbackModels...),
+		EmbeddingBackend:        cfg.Memory.EmbeddingBackend,
+		EmbeddingOpenAIToken:    openAIToken,
+		EmbeddingOpenAIAPIBase:  strings.TrimSpace(cfg.Providers.OpenAI.APIBase),
+		EmbeddingOpenRouterKey:  strings.TrimSpace(cfg.Providers.OpenRouter.APIKey),
+		EmbeddingOpenRouterBase: strings.TrimSpace(cfg.Providers.OpenRouter.APIBase),
+		EmbeddingOllamaAPIBase:  strings.TrimSpace(cfg.Memory.EmbeddingOllamaAPIBase),
+		EmbeddingBatchSize:      cfg.Memory.EmbeddingBatchSize,
+		EmbeddingConcurrency:    cfg.Memory.EmbeddingConcurrency,
+	}
+}
+
+func runSessionRename(ctx context.Context, w io.Writer, instanceID, sessionKey, alias string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	store, err := openMemoryStore(instanceID)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if sessionKey, err = store.ResolveSessionKey(ctx, sessionKey); err != nil {
+		return err
+	}
+	alias = strings.TrimSpace(alias)
+	if err := store.SetSessionAlias(ctx, alias, sessionKey); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Session %s is now also known as %s.\n", sessionKey, alias)
+	return nil
+}
+
+func runSessionSnapshotDiff(w io.Writer, instanceID, sessionKey string, fromRevision, toRevision int, format string) error {
+	store, err := openMemoryStore(instanceID)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	if sessionKey, err = store.ResolveSessionKey(ctx, sessionKey); err != nil {
+		return err
+	}
+	from, err := store.GetSessionSnapshot(ctx, sessionKey, fromRevision)
+	if err != nil {
+		return err
+	}
+	to, err := store.GetSessionSnapshot(ctx, sessionKey, toRevision)
+	if err != nil {
+		return err
+	}
+	changes := memory.SnapshotDiff(from, to)
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(changes)
+	}
+	writeSnapshotChanges(w, changes)
+	return nil
+}
+
+func writeSnapshotChanges(w io.Writer, changes memory.SnapshotChanges) {
+	fmt.Fprintf(w, "Session %s: revision %d -> %d\n", changes.SessionKey, changes.FromRevision, changes.ToRevision)
+	if changes.Empty() {
+		fmt.Fprintln(w, "\nNo changes.")
+		return
+	}
+	for _, cat := range changes.Categories() {
+		if cat.Changes.Empty() {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", cat.Name)
+		for _, item := range cat.Changes.Added {
+			fmt.Fprintf(w, "  + %s\n", item)
+		}
+		for _, item := range cat.Changes.Removed {
+			fmt.Fprintf(w, "  - %s\n", item)
+		}
+		for _, item := range cat.Changes.Modified {
+			fmt.Fprintf(w, "  ~ %s\n    -> %s\n", item.Before, item.After)
+		}
+	}
+	if changes.SummaryChanged {
+		fmt.Fprintln(w, "\nsummary: changed")
+	}
+}
+
+func runSessionsPrune(w io.Writer, instanceID string, cutoff time.Time, minMessages int, apply bool) error {
+	store, err := openMemoryStore(instanceID)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	sessions, err := store.ListPrunableSessions(ctx, cutoff.UnixMilli(), minMessages)
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		fmt.Fprintf(w, "No sessions idle since %s with fewer than %d messages.\n", cutoff.Format("2006-01-02 15:04"), minMessages)
+		return nil
+	}
+	messages := 0
+	for _, sess := range sessions {
+		messages += sess.MessageCount
+	}
+	fmt.Fprintf(w, "%d session(s) idle since %s with fewer than %d messages (%d message(s) total).\n",
+		len(sessions), cutoff.Format("2006-01-02 15:04"), minMessages, messages)
+
+	counts, err := store.PruneSessions(ctx, sessions, apply)
+	if err != nil {
+		return err
+	}
+	verb := "Would delete"
+	if apply {
+		verb = "Deleted"
+	}
+	fmt.Fprintf(w, "\n%s:\n", verb)
+	for _, c := range counts {
+		if c.Rows > 0 {
+			fmt.Fprintf(w, "  %-24s %d\n", c.Table, c.Rows)
+		}
+	}
+	if !apply {
+		fmt.Fprintln(w, "\nRe-run with --apply to delete.")
+	}
+	return nil
+}
+
+// parseAge parses a duration that may also be given in whole days ("30d").
+func parseAge(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	var (
+		age time.Duration
+		err error
+	)
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		age = time.Duration(n) * 24 * time.Hour
+	} else {
+		age, err = time.ParseDuration(raw)
+	}
+	if err != nil || age <= 0 {
+		return 0, fmt.Errorf("invalid age %q", raw)
+	}
+	return age, nil
+}
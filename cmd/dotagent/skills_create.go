@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/skills"
+)
+
+// skillsCreateOptions holds the values given on the command line; empty
+// fields are asked for interactively.
+type skillsCreateOptions struct {
+	description string
+	triggers    string
+	version     string
+	template    string
+	validate    bool
+}
+
+func runSkillsCreate(in io.Reader, out io.Writer, name string, opts skillsCreateOptions) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	workspace := cfg.WorkspacePath()
+
+	reader := bufio.NewReader(in)
+	ask := func(label, current, fallback string) (string, error) {
+		if strings.TrimSpace(current) != "" {
+			return strings.TrimSpace(current), nil
+		}
+		if fallback != "" {
+			fmt.Fprintf(out, "%s [%s]: ", label, fallback)
+		} else {
+			fmt.Fprintf(out, "%s: ", label)
+		}
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		if line = strings.TrimSpace(line); line == "" {
+			return fallback, nil
+		}
+		return line, nil
+	}
+
+	scaffold := skills.SkillScaffold{Name: name}
+	if scaffold.Description, err = ask("Description", opts.description, ""); err != nil {
+		return err
+	}
+	triggers, err := ask("Triggers (comma-separated)", opts.triggers, "")
+	if err != nil {
+		return err
+	}
+	scaffold.Triggers = strings.Split(triggers, ",")
+	if scaffold.Version, err = ask("Version", opts.version, "0.1.0"); err != nil {
+		return err
+	}
+	templateLabel := fmt.Sprintf("Template (%s)", strings.Join(skills.SkillTemplateNames(), ", "))
+	if scaffold.Template, err = ask(templateLabel, opts.template, "basic"); err != nil {
+		return err
+	}
+
+	path, err := skills.CreateWorkspaceSkill(workspace, scaffold)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "✓ Skill '%s' created at %s\n", name, path)
+
+	if opts.validate {
+		return printSkillValidation(out, workspace, name)
+	}
+	return nil
+}
+
+func printSkillValidation(out io.Writer, workspace, name string) error {
+	globalDir := filepath.Dir(filepath.Dir(getConfigPath()))
+	loader := skills.NewSkillsLoader(workspace, filepath.Join(globalDir, "skills"), filepath.Join(globalDir, "dotagent", "skills"))
+	if err := loader.ValidateSkill(name); err != nil {
+		fmt.Fprintf(out, "✗ Skill '%s' is invalid:\n", name)
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(out, "  - %s\n", line)
+		}
+		return fmt.Errorf("skill '%s' failed validation", name)
+	}
+	fmt.Fprintf(out, "✓ Skill '%s' is valid\n", name)
+	return nil
+}
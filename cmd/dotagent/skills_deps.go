@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/skills"
+	"github.com/dotsetgreg/dotagent/pkg/tools"
+)
+
+// runSkillsDeps prints each installed skill's required tools, marking the
+// ones the current config does not register.
+func runSkillsDeps(out io.Writer) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	globalDir := filepath.Dir(filepath.Dir(getConfigPath()))
+	loader := skills.NewSkillsLoader(cfg.WorkspacePath(), filepath.Join(globalDir, "skills"), filepath.Join(globalDir, "dotagent", "skills"))
+
+	registry, cleanup, err := loadToolRegistry()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	writeSkillDeps(out, loader.ListSkills(), registry)
+	return nil
+}
+
+func writeSkillDeps(w io.Writer, installed []skills.SkillInfo, registry *tools.ToolRegistry) {
+	if len(installed) == 0 {
+		fmt.Fprintln(w, "No skills installed.")
+		return
+	}
+
+	nameWidth := len("SKILL")
+	for _, s := range installed {
+		nameWidth = max(nameWidth, len(s.Name))
+	}
+	unmet := 0
+	fmt.Fprintf(w, "%-*s  %s\n", nameWidth, "SKILL", "REQUIRES")
+	for _, s := range installed {
+		cells := make([]string, 0, len(s.Requires))
+		missing := false
+		for _, name := range s.Requires {
+			if _, ok := registry.Get(name); ok {
+				cells = append(cells, name)
+				continue
+			}
+			cells = append(cells, name+" ✗")
+			missing = true
+		}
+		if missing {
+			unmet++
+		}
+		requires := strings.Join(cells, ", ")
+		if requires == "" {
+			requires = "-"
+		}
+		fmt.Fprintf(w, "%-*s  %s\n", nameWidth, s.Name, requires)
+	}
+
+	if unmet == 0 {
+		fmt.Fprintf(w, "\nAll skill dependencies are met.\n")
+		return
+	}
+	fmt.Fprintf(w, "\n%d skill(s) need tools that are not registered (✗). Run 'dotagent tools list' to see registered tools.\n", unmet)
+}
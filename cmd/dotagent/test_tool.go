@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/tools"
+	"github.com/spf13/cobra"
+)
+
+func newTestToolCommand() *cobra.Command {
+	var rawArgs, workspace string
+	cmd := &cobra.Command{
+		Use:   "test-tool <tool-name>",
+		Short: "Run one registered tool with JSON arguments",
+		Long: "Build the agent's tool registry from the current config and execute a single tool, " +
+			"printing its result for the model, its message for the user, and any error. " +
+			"No model is called and memory lives in a throwaway directory, so toolpack authors can exercise a tool in isolation.",
+		Example: "  dotagent test-tool read_file --args '{\"path\":\"notes.md\"}'\n" +
+			"  dotagent test-tool mypack_lookup --args '{\"query\":\"status\"}' --workspace ./scratch",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTestTool(cmd.Context(), cmd.OutOrStdout(), args[0], rawArgs, workspace)
+		},
+	}
+	cmd.Flags().StringVar(&rawArgs, "args", "{}", "Tool arguments as a JSON object")
+	cmd.Flags().StringVar(&workspace, "workspace", "", "Workspace directory to run the tool in (default: the configured workspace)")
+	return cmd
+}
+
+// runTestTool executes name with the JSON object rawArgs in a dry agent: the
+// loop is never started, the provider is offline, and the memory store is
+// created in a temporary data directory that is removed afterwards.
+func runTestTool(ctx context.Context, out io.Writer, name, rawArgs, workspace string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	toolArgs := map[string]interface{}{}
+	if strings.TrimSpace(rawArgs) != "" {
+		if err := json.Unmarshal([]byte(rawArgs), &toolArgs); err != nil {
+			return fmt.Errorf("--args must be a JSON object: %w", err)
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if workspace = strings.TrimSpace(workspace); workspace != "" {
+		// WorkspacePath expands a leading ~; other relative paths are
+		// relative to the current directory.
+		if !strings.HasPrefix(workspace, "~") {
+			if workspace, err = filepath.Abs(workspace); err != nil {
+				return fmt.Errorf("resolve workspace: %w", err)
+			}
+		}
+		cfg.Paths.Workspace = workspace
+	}
+	dataDir, err := os.MkdirTemp("", "dotagent-test-tool-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dataDir)
+	cfg.Paths.Data = dataDir
+	cfg.Memory.Backend = "sqlite"
+
+	registry, cleanup, err := loadToolRegistryFor(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if _, ok := registry.Get(name); !ok {
+		return fmt.Errorf("unknown tool %q; run 'dotagent tools list' to see registered tools", name)
+	}
+
+	result := registry.ExecuteWithContext(ctx, name, toolArgs, "cli", "test-tool", nil)
+	writeTestToolResult(out, result)
+	if result.IsError {
+		return fmt.Errorf("tool %s returned an error", name)
+	}
+	return nil
+}
+
+func writeTestToolResult(w io.Writer, result *tools.ToolResult) {
+	fmt.Fprintln(w, "ForLLM:")
+	fmt.Fprintln(w, indentBlock(result.ForLLM))
+	fmt.Fprintln(w, "ForUser:")
+	fmt.Fprintln(w, indentBlock(result.ForUser))
+	errText := ""
+	if result.Err != nil {
+		errText = result.Err.Error()
+	} else if result.IsError {
+		errText = result.ForLLM
+	}
+	fmt.Fprintln(w, "Error:")
+	fmt.Fprintln(w, indentBlock(errText))
+	if result.Async {
+		fmt.Fprintln(w, "\n(the tool started asynchronously; its final result is delivered later and is not shown here)")
+	}
+}
+
+func indentBlock(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return "  (none)"
+	}
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = "  " + line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/agent"
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+	"github.com/dotsetgreg/dotagent/pkg/config"
+	"github.com/dotsetgreg/dotagent/pkg/tools"
+	"github.com/dotsetgreg/dotagent/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+func newToolsCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:   "tools",
+		Short: "Inspect tools available to the agent",
+		Long:  "List built-in, scheduling, and toolpack tools registered for the current config and print their parameter schemas.",
+	}
+
+	root.AddCommand(&cobra.Command{
+		Use:     "list",
+		Short:   "List registered tools",
+		Example: "  dotagent tools list",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registry, cleanup, err := loadToolRegistry()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			writeToolsTable(cmd.OutOrStdout(), registry)
+			return nil
+		},
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:     "show <name>",
+		Short:   "Print a tool's parameter JSON schema",
+		Example: "  dotagent tools show web_fetch",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registry, cleanup, err := loadToolRegistry()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			return writeToolSchema(cmd.OutOrStdout(), registry, args[0])
+		},
+	})
+
+	return root
+}
+
+// loadToolRegistry builds an AgentLoop from the current config without
+// starting it, so the registry matches what the gateway would expose.
+func loadToolRegistry() (*tools.ToolRegistry, func(), error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("load config: %w", err)
+	}
+	return loadToolRegistryFor(cfg)
+}
+
+func loadToolRegistryFor(cfg *config.Config) (*tools.ToolRegistry, func(), error) {
+	msgBus := bus.NewMessageBus()
+	agentLoop, err := agent.NewAgentLoop(cfg, msgBus, offlineProvider{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("initialize agent: %w", err)
+	}
+	cronRoot, err := os.MkdirTemp("", "dotagent-tools-*")
+	if err != nil {
+		agentLoop.Stop()
+		return nil, nil, err
+	}
+	cleanup := func() {
+		agentLoop.Stop()
+		_ = os.RemoveAll(cronRoot)
+	}
+	// Scheduling tools are registered by the gateway; use a throwaway store so
+	// listing never touches real jobs.
+	if _, err := setupCronTool(agentLoop, msgBus, cronRoot, cfg.WorkspacePath(), cfg.Agents.Defaults.RestrictToWorkspace); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("initialize cron tools: %w", err)
+	}
+	return agentLoop.ToolRegistry(), cleanup, nil
+}
+
+func writeToolsTable(w io.Writer, registry *tools.ToolRegistry) {
+	names := registry.List()
+	rows := make([][3]string, 0, len(names))
+	nameWidth := len("NAME")
+	for _, name := range names {
+		tool, ok := registry.Get(name)
+		if !ok {
+			continue
+		}
+		if len(name) > nameWidth {
+			nameWidth = len(name)
+		}
+		rows = append(rows, [3]string{
+			name,
+			fmt.Sprintf("%d", toolParameterCount(tool)),
+			utils.Truncate(strings.Join(strings.Fields(tool.Description()), " "), 80),
+		})
+	}
+
+	fmt.Fprintf(w, "%-*s  %6s  %s\n", nameWidth, "NAME", "PARAMS", "DESCRIPTION")
+	for _, row := range rows {
+		fmt.Fprintf(w, "%-*s  %6s  %s\n", nameWidth, row[0], row[1], row[2])
+	}
+	fmt.Fprintf(w, "\n%d tools registered. Run 'dotagent tools show <name>' for a parameter schema.\n", len(rows))
+}
+
+func writeToolSchema(w io.Writer, registry *tools.ToolRegistry, name string) error {
+	tool, ok := registry.Get(strings.TrimSpace(name))
+	if !ok {
+		return fmt.Errorf("tool %q not found; run 'dotagent tools list' to see registered tools", name)
+	}
+	raw, err := json.MarshalIndent(tool.Parameters(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode schema for %s: %w", name, err)
+	}
+	fmt.Fprintf(w, "%s\n\n%s\n\n%s\n", tool.Name(), tool.Description(), raw)
+	return nil
+}
+
+func toolParameterCount(tool tools.Tool) int {
+	props, _ := tool.Parameters()["properties"].(map[string]interface{})
+	return len(props)
+}
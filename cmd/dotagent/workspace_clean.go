@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/config"
+	"github.com/dotsetgreg/dotagent/pkg/cron"
+	"github.com/dotsetgreg/dotagent/pkg/toolpacks"
+	"github.com/dotsetgreg/dotagent/pkg/tools"
+	"github.com/spf13/cobra"
+)
+
+func newWorkspaceCommand(instanceID *string) *cobra.Command {
+	root := &cobra.Command{
+		Use:   "workspace",
+		Short: "Maintain the agent workspace",
+	}
+
+	var (
+		dryRun   bool
+		apply    bool
+		cronDays int
+	)
+	clean := &cobra.Command{
+		Use:   "clean",
+		Short: "Quarantine orphaned skills and toolpacks, remove stale cron jobs and expired audit logs",
+		Long: "Scan the workspace for skill directories without a SKILL.md, toolpack directories without a valid manifest, " +
+			"disabled cron jobs idle for --cron-days, and tool audit entries older than tools.audit.retention_days. " +
+			"Nothing is changed unless --apply is given. Orphaned directories are moved under <data>/quarantine rather than deleted, " +
+			"and --apply refuses to run while the gateway holds the instance.",
+		Example: strings.Join([]string{
+			"  dotagent workspace clean --dry-run",
+			"  dotagent workspace clean --apply --cron-days 14",
+		}, "\n"),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dryRun && apply {
+				return fmt.Errorf("--dry-run and --apply are mutually exclusive")
+			}
+			if cronDays <= 0 {
+				return fmt.Errorf("--cron-days must be positive")
+			}
+			cfg, _, err := loadInstanceConfig(resolveInstanceID(*instanceID))
+			if err != nil {
+				return fmt.Errorf("load config: %w", err)
+			}
+			if apply {
+				release, err := acquireInstanceLock(cfg, "workspace clean")
+				if err != nil {
+					return err
+				}
+				defer release()
+			}
+			now := time.Now()
+			items, err := scanWorkspaceClean(cfg, now, cronDays)
+			if err != nil {
+				return err
+			}
+			return runWorkspaceClean(cmd.OutOrStdout(), items, apply)
+		},
+	}
+	clean.Flags().BoolVar(&dryRun, "dry-run", false, "List what would be removed (default)")
+	clean.Flags().BoolVar(&apply, "apply", false, "Quarantine or remove the listed items")
+	clean.Flags().IntVar(&cronDays, "cron-days", 30, "Remove disabled cron jobs with no activity for this many days")
+	root.AddCommand(clean)
+
+	return root
+}
+
+// cleanItem is one thing workspace clean can remove. Items with a
+// quarantine path are moved there instead of deleted.
+type cleanItem struct {
+	kind       string
+	path       string
+	detail     string
+	bytes      int64
+	quarantine string
+	remove     func() error
+}
+
+func scanWorkspaceClean(cfg *config.Config, now time.Time, cronDays int) ([]cleanItem, error) {
+	workspace := cfg.WorkspacePath()
+	quarantine := filepath.Join(cfg.DataPath(), "quarantine", now.UTC().Format("20060102-150405"))
+	var items []cleanItem
+	for _, scan := range []func() ([]cleanItem, error){
+		func() ([]cleanItem, error) { return scanOrphanedSkills(workspace, filepath.Join(quarantine, "skills")) },
+		func() ([]cleanItem, error) {
+			return scanOrphanedToolpacks(workspace, cfg.Agents.Defaults.RestrictToWorkspace, filepath.Join(quarantine, "toolpacks"))
+		},
+		func() ([]cleanItem, error) {
+			return scanStaleCron(filepath.Join(cfg.DataPath(), "cron", "jobs.json"), now.AddDate(0, 0, -cronDays))
+		},
+		func() ([]cleanItem, error) {
+			if cfg.Tools.Audit.RetentionDays <= 0 {
+				return nil, nil
+			}
+			return scanExpiredAudit(filepath.Join(cfg.DataPath(), "audit"), now.AddDate(0, 0, -cfg.Tools.Audit.RetentionDays))
+		},
+	} {
+		found, err := scan()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, found...)
+	}
+	return items, nil
+}
+
+func runWorkspaceClean(w io.Writer, items []cleanItem, apply bool) error {
+	if len(items) == 0 {
+		fmt.Fprintln(w, "Workspace is clean; nothing to remove.")
+		return nil
+	}
+	var (
+		total       int64
+		quarantined int
+		lastKnd     string
+		failed      int
+	)
+	for _, item := range items {
+		if item.kind != lastKnd {
+			if lastKnd != "" {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "%s:\n", item.kind)
+			lastKnd = item.kind
+		}
+		line := fmt.Sprintf("  %s (%s)", item.path, formatBytes(uint64(item.bytes)))
+		if item.detail != "" {
+			line += " - " + item.detail
+		}
+		if apply {
+			if err := item.remove(); err != nil {
+				fmt.Fprintf(w, "%s: failed: %v\n", line, err)
+				failed++
+				continue
+			}
+		}
+		if item.quarantine != "" {
+			line += " - quarantine"
+			if apply {
+				line = fmt.Sprintf("%s: moved to %s", line, item.quarantine)
+			}
+			quarantined++
+		} else {
+			total += item.bytes
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+	if !apply {
+		fmt.Fprintf(w, "Would free %s and quarantine %d item(s). Re-run with --apply to proceed.\n", formatBytes(uint64(total)), quarantined)
+		return nil
+	}
+	fmt.Fprintf(w, "Freed %s and quarantined %d item(s).\n", formatBytes(uint64(total)), quarantined)
+	if failed > 0 {
+		return fmt.Errorf("%d item(s) could not be removed", failed)
+	}
+	return nil
+}
+
+// scanOrphanedSkills finds skill directories the loader ignores because they
+// have no SKILL.md. They are moved into quarantine, not deleted, since a
+// half-written skill may still hold work.
+func scanOrphanedSkills(workspace, quarantine string) ([]cleanItem, error) {
+	root := filepath.Join(workspace, "skills")
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read skills dir: %w", err)
+	}
+	var items []cleanItem
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, "SKILL.md")); err == nil {
+			continue
+		}
+		items = append(items, quarantineDirItem("Orphaned skills", dir, quarantine, "no SKILL.md"))
+	}
+	return items, nil
+}
+
+// scanOrphanedToolpacks finds toolpack directories that do not hold a valid
+// manifest for a pack of the same ID. Like orphaned skills they are
+// quarantined rather than deleted.
+func scanOrphanedToolpacks(workspace string, restrict bool, quarantine string) ([]cleanItem, error) {
+	manager := toolpacks.NewManager(workspace, restrict)
+	root := manager.RootDir()
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read toolpacks dir: %w", err)
+	}
+	manifests, err := manager.List()
+	if err != nil {
+		return nil, err
+	}
+	valid := map[string]bool{}
+	for _, manifest := range manifests {
+		valid[manifest.ID] = true
+	}
+	var items []cleanItem
+	for _, entry := range entries {
+		if !entry.IsDir() || valid[entry.Name()] {
+			continue
+		}
+		items = append(items, quarantineDirItem("Orphaned toolpacks", filepath.Join(root, entry.Name()), quarantine, "missing or invalid manifest"))
+	}
+	return items, nil
+}
+
+// scanStaleCron finds disabled jobs with no run or update since cutoff, plus
+// leftover corrupt-store backups and temp files.
+func scanStaleCron(storePath string, cutoff time.Time) ([]cleanItem, error) {
+	var items []cleanItem
+	if _, err := os.Stat(storePath); err == nil {
+		cs, err := cron.NewCronService(storePath, nil)
+		if err != nil {
+			return nil, fmt.Errorf("load cron store: %w", err)
+		}
+		for _, job := range cs.ListJobs(true) {
+			lastActive := job.UpdatedAtMS
+			if job.State.LastRunAtMS != nil && *job.State.LastRunAtMS > lastActive {
+				lastActive = *job.State.LastRunAtMS
+			}
+			if job.Enabled || lastActive > cutoff.UnixMilli() {
+				continue
+			}
+			raw, _ := json.Marshal(job)
+			id := job.ID
+			items = append(items, cleanItem{
+				kind:   "Stale cron jobs",
+				path:   fmt.Sprintf("%s#%s", storePath, id),
+				detail: fmt.Sprintf("%q disabled, last active %s", job.Name, time.UnixMilli(lastActive).Format("2006-01-02")),
+				bytes:  int64(len(raw)),
+				remove: func() error {
+					if !cs.RemoveJob(id) {
+						return fmt.Errorf("job %s not found", id)
+					}
+					return nil
+				},
+			})
+		}
+	}
+
+	leftovers, _ := filepath.Glob(storePath + ".corrupt.*")
+	temps, _ := filepath.Glob(storePath + ".tmp-*")
+	for _, path := range append(leftovers, temps...) {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		items = append(items, removeFileItem("Stale cron jobs", path, info.Size(), "leftover store file"))
+	}
+	return items, nil
+}
+
+// scanExpiredAudit finds rotated audit files last written before cutoff and
+// entries in the active log older than cutoff.
+func scanExpiredAudit(dir string, cutoff time.Time) ([]cleanItem, error) {
+	var items []cleanItem
+	rotated, _ := filepath.Glob(filepath.Join(dir, "tools-*.jsonl"))
+	for _, path := range rotated {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		items = append(items, removeFileItem("Expired audit logs", path, info.Size(), "rotated log"))
+	}
+
+	active := filepath.Join(dir, "tools.jsonl")
+	keep, removed, removedBytes, err := splitAuditLog(active, cutoff)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return items, nil
+		}
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+	if removed > 0 {
+		items = append(items, cleanItem{
+			kind:   "Expired audit logs",
+			path:   active,
+			detail: fmt.Sprintf("%d entry(ies) before %s", removed, cutoff.Format("2006-01-02")),
+			bytes:  removedBytes,
+			remove: func() error {
+				tmp := active + ".tmp"
+				if err := os.WriteFile(tmp, keep, 0o600); err != nil {
+					return err
+				}
+				return os.Rename(tmp, active)
+			},
+		})
+	}
+	return items, nil
+}
+
+// splitAuditLog returns the lines of path to keep and how many lines and
+// bytes are older than cutoff. Lines that cannot be parsed are kept.
+func splitAuditLog(path string, cutoff time.Time) ([]byte, int, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer f.Close()
+
+	var (
+		keep         bytes.Buffer
+		removed      int
+		removedBytes int64
+	)
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var entry tools.ToolAuditEntry
+			if json.Unmarshal(line, &entry) == nil {
+				if ts, parseErr := time.Parse(time.RFC3339Nano, entry.Timestamp); parseErr == nil && ts.Before(cutoff) {
+					removed++
+					removedBytes += int64(len(line))
+					line = nil
+				}
+			}
+			keep.Write(line)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	}
+	return keep.Bytes(), removed, removedBytes, nil
+}
+
+// quarantineDirItem moves dir into the quarantine directory under its own
+// name, leaving it for the operator to restore or delete.
+func quarantineDirItem(kind, dir, quarantine, detail string) cleanItem {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, infoErr := d.Info(); infoErr == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	dest := filepath.Join(quarantine, filepath.Base(dir))
+	return cleanItem{
+		kind:       kind,
+		path:       dir,
+		detail:     detail,
+		bytes:      size,
+		quarantine: dest,
+		remove: func() error {
+			if err := os.MkdirAll(quarantine, 0o700); err != nil {
+				return err
+			}
+			return os.Rename(dir, dest)
+		},
+	}
+}
+
+func removeFileItem(kind, path string, size int64, detail string) cleanItem {
+	return cleanItem{
+		kind:   kind,
+		path:   path,
+		detail: detail,
+		bytes:  size,
+		remove: func() error { return os.Remove(path) },
+	}
+}
@@ -0,0 +1,297 @@
+// Package admin serves the authenticated gateway admin API used to inspect
+// and manage a running gateway.
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+)
+
+// Backend is the gateway state the admin API reads and manages.
+type Backend interface {
+	ListJobs(ctx context.Context, status string, limit int) ([]memory.Job, error)
+	CancelJob(ctx context.Context, id string) (bool, error)
+	ListSessions(ctx context.Context, limit int) ([]memory.Session, error)
+	CompactSession(ctx context.Context, sessionKey string) error
+	MetricSummaries(ctx context.Context, sinceMS int64) ([]memory.MetricSummary, error)
+	ListAuditByTrace(ctx context.Context, traceID string) ([]memory.AuditEntry, error)
+}
+
+// defaultMetricsWindow is how far back GET /admin/metrics looks when no
+// window is given.
+const defaultMetricsWindow = time.Hour
+
+// Handler routes the admin API. Every request must carry
+// "Authorization: Bearer <token>".
+//
+//	GET  /admin/jobs?status=&limit=
+//	POST /admin/jobs/<id>/cancel
+//	GET  /admin/sessions?limit=
+//	POST /admin/sessions/<key>/compact
+//	GET  /admin/metrics?window=
+//	GET  /admin/traces/<id>
+type Handler struct {
+	token   string
+	backend Backend
+	now     func() time.Time
+}
+
+// Job is the JSON form of a memory job.
+type Job struct {
+	ID            string            `json:"id"`
+	Type          string            `json:"type"`
+	SessionKey    string            `json:"session_key,omitempty"`
+	Status        string            `json:"status"`
+	Priority      int               `json:"priority"`
+	Payload       map[string]string `json:"payload,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	RunAfterMS    int64             `json:"run_after_ms"`
+	CreatedAtMS   int64             `json:"created_at_ms"`
+	UpdatedAtMS   int64             `json:"updated_at_ms"`
+	CompletedAtMS int64             `json:"completed_at_ms,omitempty"`
+}
+
+// Session is the JSON form of a conversation session.
+type Session struct {
+	SessionKey         string `json:"session_key"`
+	Channel            string `json:"channel"`
+	ChatID             string `json:"chat_id"`
+	UserID             string `json:"user_id"`
+	MessageCount       int    `json:"message_count"`
+	CreatedAtMS        int64  `json:"created_at_ms"`
+	UpdatedAtMS        int64  `json:"updated_at_ms"`
+	LastConsolidatedMS int64  `json:"last_consolidated_ms,omitempty"`
+}
+
+// TraceResponse is the GET /admin/traces/<id> payload: the memory audit
+// entries recorded while handling the request with that trace ID.
+type TraceResponse struct {
+	TraceID string              `json:"trace_id"`
+	Entries []memory.AuditEntry `json:"entries"`
+}
+
+// MetricsResponse is the GET /admin/metrics payload.
+type MetricsResponse struct {
+	Window  string                 `json:"window"`
+	SinceMS int64                  `json:"since_ms"`
+	Metrics []memory.MetricSummary `json:"metrics"`
+}
+
+func NewHandler(token string, backend Backend) *Handler {
+	return &Handler{
+		token:   strings.TrimSpace(token),
+		backend: backend,
+		now:     time.Now,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="dotagent-admin"`)
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.EscapedPath(), "/admin"), "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "jobs":
+		h.requireMethod(w, r, http.MethodGet, h.listJobs)
+	case len(parts) == 3 && parts[0] == "jobs" && parts[2] == "cancel":
+		h.requireMethod(w, r, http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+			h.cancelJob(w, r, parts[1])
+		})
+	case len(parts) == 1 && parts[0] == "sessions":
+		h.requireMethod(w, r, http.MethodGet, h.listSessions)
+	case len(parts) == 3 && parts[0] == "sessions" && parts[2] == "compact":
+		h.requireMethod(w, r, http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+			h.compactSession(w, r, parts[1])
+		})
+	case len(parts) == 1 && parts[0] == "metrics":
+		h.requireMethod(w, r, http.MethodGet, h.metrics)
+	case len(parts) == 2 && parts[0] == "traces":
+		h.requireMethod(w, r, http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			h.trace(w, r, parts[1])
+		})
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// authorized compares the bearer token in constant time. An empty configured
+// token rejects every request.
+func (h *Handler) authorized(r *http.Request) bool {
+	if h.token == "" {
+		return false
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(h.token)) == 1
+}
+
+func (h *Handler) requireMethod(w http.ResponseWriter, r *http.Request, method string, next http.HandlerFunc) {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	next(w, r)
+}
+
+func (h *Handler) listJobs(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	jobs, err := h.backend.ListJobs(r.Context(), r.URL.Query().Get("status"), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	out := make([]Job, 0, len(jobs))
+	for _, job := range jobs {
+		out = append(out, Job{
+			ID:            job.ID,
+			Type:          job.JobType,
+			SessionKey:    job.SessionKey,
+			Status:        job.Status,
+			Priority:      job.Priority,
+			Payload:       job.Payload,
+			Error:         job.Error,
+			RunAfterMS:    job.RunAfterMS,
+			CreatedAtMS:   job.CreatedAtMS,
+			UpdatedAtMS:   job.UpdatedAtMS,
+			CompletedAtMS: job.CompletedAtMS,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"jobs": out})
+}
+
+func (h *Handler) cancelJob(w http.ResponseWriter, r *http.Request, rawID string) {
+	id, err := url.PathUnescape(rawID)
+	if err != nil || strings.TrimSpace(id) == "" {
+		writeError(w, http.StatusBadRequest, "job id is required")
+		return
+	}
+	canceled, err := h.backend.CancelJob(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !canceled {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("job %s not found or already finished", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": id, "status": memory.JobCanceled})
+}
+
+func (h *Handler) listSessions(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sessions, err := h.backend.ListSessions(r.Context(), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	out := make([]Session, 0, len(sessions))
+	for _, sess := range sessions {
+		out = append(out, Session{
+			SessionKey:         sess.SessionKey,
+			Channel:            sess.Channel,
+			ChatID:             sess.ChatID,
+			UserID:             sess.UserID,
+			MessageCount:       sess.MessageCount,
+			CreatedAtMS:        sess.CreatedAtMS,
+			UpdatedAtMS:        sess.UpdatedAtMS,
+			LastConsolidatedMS: sess.LastConsolidatedMS,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sessions": out})
+}
+
+func (h *Handler) compactSession(w http.ResponseWriter, r *http.Request, rawKey string) {
+	key, err := url.PathUnescape(rawKey)
+	if err != nil || strings.TrimSpace(key) == "" {
+		writeError(w, http.StatusBadRequest, "session key is required")
+		return
+	}
+	if err := h.backend.CompactSession(r.Context(), key); err != nil {
+		if errors.Is(err, memory.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"session_key": key, "compacted": true})
+}
+
+func (h *Handler) metrics(w http.ResponseWriter, r *http.Request) {
+	window := defaultMetricsWindow
+	if raw := strings.TrimSpace(r.URL.Query().Get("window")); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid window %q", raw))
+			return
+		}
+		window = parsed
+	}
+	since := h.now().Add(-window).UnixMilli()
+	summaries, err := h.backend.MetricSummaries(r.Context(), since)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, MetricsResponse{Window: window.String(), SinceMS: since, Metrics: summaries})
+}
+
+func (h *Handler) trace(w http.ResponseWriter, r *http.Request, rawID string) {
+	id, err := url.PathUnescape(rawID)
+	if err != nil || strings.TrimSpace(id) == "" {
+		writeError(w, http.StatusBadRequest, "trace id is required")
+		return
+	}
+	entries, err := h.backend.ListAuditByTrace(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, TraceResponse{TraceID: id, Entries: entries})
+}
+
+func queryLimit(r *http.Request) (int, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get("limit"))
+	if raw == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("invalid limit %q", raw)
+	}
+	return limit, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,250 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+)
+
+type fakeBackend struct {
+	jobs        []memory.Job
+	sessions    []memory.Session
+	canceled    []string
+	compacted   []string
+	statusQuery string
+	limitQuery  int
+	sinceMS     int64
+	audit       []memory.AuditEntry
+}
+
+func (f *fakeBackend) ListJobs(_ context.Context, status string, limit int) ([]memory.Job, error) {
+	f.statusQuery, f.limitQuery = status, limit
+	return f.jobs, nil
+}
+
+func (f *fakeBackend) CancelJob(_ context.Context, id string) (bool, error) {
+	for _, job := range f.jobs {
+		if job.ID == id && job.Status == memory.JobPending {
+			f.canceled = append(f.canceled, id)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeBackend) ListSessions(_ context.Context, limit int) ([]memory.Session, error) {
+	f.limitQuery = limit
+	return f.sessions, nil
+}
+
+func (f *fakeBackend) CompactSession(_ context.Context, sessionKey string) error {
+	for _, sess := range f.sessions {
+		if sess.SessionKey == sessionKey {
+			f.compacted = append(f.compacted, sessionKey)
+			return nil
+		}
+	}
+	return memory.ErrSessionNotFound
+}
+
+func (f *fakeBackend) MetricSummaries(_ context.Context, sinceMS int64) ([]memory.MetricSummary, error) {
+	f.sinceMS = sinceMS
+	return []memory.MetricSummary{{Metric: "memory.job.completed", Count: 2, Sum: 2, LastValue: 1}}, nil
+}
+
+func (f *fakeBackend) ListAuditByTrace(_ context.Context, traceID string) ([]memory.AuditEntry, error) {
+	var out []memory.AuditEntry
+	for _, entry := range f.audit {
+		if entry.TraceID == traceID {
+			out = append(out, entry)
+		}
+	}
+	return out, nil
+}
+
+func newTestHandler() (*Handler, *fakeBackend) {
+	backend := &fakeBackend{
+		jobs: []memory.Job{
+			{ID: "job-1", JobType: memory.JobConsolidate, SessionKey: "discord:1", Status: memory.JobPending},
+			{ID: "job-2", JobType: memory.JobCompact, SessionKey: "discord:1", Status: memory.JobCompleted},
+		},
+		sessions: []memory.Session{{SessionKey: "discord:1", Channel: "discord", ChatID: "1", UserID: "u1", MessageCount: 4}},
+	}
+	h := NewHandler("secret", backend)
+	h.now = func() time.Time { return time.UnixMilli(10_000_000) }
+	return h, backend
+}
+
+func serve(t *testing.T, h http.Handler, method, target, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler_RequiresBearerToken(t *testing.T) {
+	h, _ := newTestHandler()
+	for _, token := range []string{"", "wrong"} {
+		rec := serve(t, h, http.MethodGet, "/admin/jobs", token)
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("token %q: expected 401, got %d", token, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/jobs", nil)
+	req.Header.Set("Authorization", "Basic secret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for non-bearer scheme, got %d", rec.Code)
+	}
+
+	empty := NewHandler("", &fakeBackend{})
+	if rec := serve(t, empty, http.MethodGet, "/admin/jobs", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 when no token is configured, got %d", rec.Code)
+	}
+}
+
+func TestHandler_ListJobs(t *testing.T) {
+	h, backend := newTestHandler()
+	rec := serve(t, h, http.MethodGet, "/admin/jobs?status=pending&limit=5", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if backend.statusQuery != "pending" || backend.limitQuery != 5 {
+		t.Fatalf("unexpected query forwarded: status=%q limit=%d", backend.statusQuery, backend.limitQuery)
+	}
+	var resp struct {
+		Jobs []Job `json:"jobs"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Jobs) != 2 || resp.Jobs[0].ID != "job-1" || resp.Jobs[0].Type != memory.JobConsolidate {
+		t.Fatalf("unexpected jobs: %+v", resp.Jobs)
+	}
+
+	if rec := serve(t, h, http.MethodGet, "/admin/jobs?limit=abc", "secret"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for bad limit, got %d", rec.Code)
+	}
+	if rec := serve(t, h, http.MethodPost, "/admin/jobs", "secret"); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestHandler_CancelJob(t *testing.T) {
+	h, backend := newTestHandler()
+	rec := serve(t, h, http.MethodPost, "/admin/jobs/job-1/cancel", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(backend.canceled) != 1 || backend.canceled[0] != "job-1" {
+		t.Fatalf("expected job-1 canceled, got %v", backend.canceled)
+	}
+	if rec := serve(t, h, http.MethodPost, "/admin/jobs/job-2/cancel", "secret"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for finished job, got %d", rec.Code)
+	}
+	if rec := serve(t, h, http.MethodGet, "/admin/jobs/job-1/cancel", "secret"); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestHandler_SessionsAndCompact(t *testing.T) {
+	h, backend := newTestHandler()
+	rec := serve(t, h, http.MethodGet, "/admin/sessions", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var resp struct {
+		Sessions []Session `json:"sessions"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Sessions) != 1 || resp.Sessions[0].SessionKey != "discord:1" || resp.Sessions[0].MessageCount != 4 {
+		t.Fatalf("unexpected sessions: %+v", resp.Sessions)
+	}
+
+	if rec := serve(t, h, http.MethodPost, "/admin/sessions/discord:1/compact", "secret"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(backend.compacted) != 1 || backend.compacted[0] != "discord:1" {
+		t.Fatalf("expected discord:1 compacted, got %v", backend.compacted)
+	}
+	if rec := serve(t, h, http.MethodPost, "/admin/sessions/missing/compact", "secret"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown session, got %d", rec.Code)
+	}
+}
+
+func TestHandler_Metrics(t *testing.T) {
+	h, backend := newTestHandler()
+	rec := serve(t, h, http.MethodGet, "/admin/metrics?window=10m", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if want := int64(10_000_000 - 600_000); backend.sinceMS != want {
+		t.Fatalf("expected since %d, got %d", want, backend.sinceMS)
+	}
+	var resp MetricsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Metrics) != 1 || resp.Metrics[0].Metric != "memory.job.completed" {
+		t.Fatalf("unexpected metrics: %+v", resp.Metrics)
+	}
+
+	if rec := serve(t, h, http.MethodGet, "/admin/metrics?window=soon", "secret"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for bad window, got %d", rec.Code)
+	}
+	if rec := serve(t, h, http.MethodGet, "/admin/unknown", "secret"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestHandler_BackendErrors(t *testing.T) {
+	h := NewHandler("secret", &errBackend{})
+	if rec := serve(t, h, http.MethodGet, "/admin/sessions", "secret"); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rec.Code)
+	}
+}
+
+type errBackend struct{ fakeBackend }
+
+func (*errBackend) ListSessions(context.Context, int) ([]memory.Session, error) {
+	return nil, errors.New("store closed")
+}
+
+func TestHandler_Trace(t *testing.T) {
+	h, backend := newTestHandler()
+	backend.audit = []memory.AuditEntry{
+		{Action: "memory_upsert", TraceID: "abc"},
+		{Action: "memory_delete", TraceID: "other"},
+	}
+	if rec := serve(t, h, http.MethodGet, "/admin/traces/abc", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected traces to require the admin token, got %d", rec.Code)
+	}
+	rec := serve(t, h, http.MethodGet, "/admin/traces/abc", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp TraceResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.TraceID != "abc" || len(resp.Entries) != 1 || resp.Entries[0].Action != "memory_upsert" {
+		t.Fatalf("unexpected trace response: %+v", resp)
+	}
+	if rec := serve(t, h, http.MethodPost, "/admin/traces/abc", "secret"); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Server serves the admin Handler on its own listener, separate from the
+// public health endpoints.
+type Server struct {
+	server   *http.Server
+	certFile string
+	keyFile  string
+}
+
+func NewServer(host string, port int, token string, backend Backend) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/admin/", NewHandler(token, backend))
+
+	return &Server{
+		server: &http.Server{
+			Addr:              fmt.Sprintf("%s:%d", host, port),
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       10 * time.Second,
+			// Compaction can take a while on long sessions.
+			WriteTimeout: 2 * time.Minute,
+		},
+	}
+}
+
+// SetTLS serves over TLS with the given certificate and key files.
+func (s *Server) SetTLS(certFile, keyFile string) {
+	s.certFile = certFile
+	s.keyFile = keyFile
+}
+
+func (s *Server) Start() error {
+	if s.certFile != "" {
+		return s.server.ListenAndServeTLS(s.certFile, s.keyFile)
+	}
+	return s.server.ListenAndServe()
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
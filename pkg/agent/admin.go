@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+)
+
+// The methods below back the gateway admin API.
+
+// ListJobs returns background memory jobs, newest first, optionally filtered
+// by status.
+func (al *AgentLoop) ListJobs(ctx context.Context, status string, limit int) ([]memory.Job, error) {
+	if al.memory == nil {
+		return nil, fmt.Errorf("memory service is not configured")
+	}
+	return al.memory.ListJobs(ctx, status, limit)
+}
+
+// CancelJob cancels a pending or running memory job.
+func (al *AgentLoop) CancelJob(ctx context.Context, id string) (bool, error) {
+	if al.memory == nil {
+		return false, fmt.Errorf("memory service is not configured")
+	}
+	return al.memory.CancelJob(ctx, id)
+}
+
+// ListSessions returns the most recently updated sessions across all users.
+func (al *AgentLoop) ListSessions(ctx context.Context, limit int) ([]memory.Session, error) {
+	if al.memory == nil {
+		return nil, fmt.Errorf("memory service is not configured")
+	}
+	return al.memory.ListSessions(ctx, "", limit)
+}
+
+// CompactSession force-compacts one session using the loop's context window.
+func (al *AgentLoop) CompactSession(ctx context.Context, sessionKey string) error {
+	if al.memory == nil {
+		return fmt.Errorf("memory service is not configured")
+	}
+	return al.memory.CompactSession(ctx, sessionKey, al.contextWindow)
+}
+
+// MetricSummaries aggregates memory metrics recorded since sinceMS.
+func (al *AgentLoop) MetricSummaries(ctx context.Context, sinceMS int64) ([]memory.MetricSummary, error) {
+	if al.memory == nil {
+		return nil, fmt.Errorf("memory service is not configured")
+	}
+	return al.memory.MetricSummaries(ctx, sinceMS)
+}
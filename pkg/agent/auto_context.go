@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/logger"
+	"github.com/dotsetgreg/dotagent/pkg/tools"
+)
+
+const autoContextHeading = "## Auto Context"
+
+// SetAutoContext configures workspace files whose contents are appended to the
+// system prompt on every turn. maxTokens caps the whole section; 0 disables
+// the cap.
+func (cb *ContextBuilder) SetAutoContext(files []string, maxTokens int) {
+	cb.autoContextFiles = append([]string(nil), files...)
+	cb.autoContextMaxTokens = maxTokens
+}
+
+// buildAutoContextSection reads the configured files fresh each time so edits
+// show up on the next turn. Missing and empty files are skipped silently;
+// paths outside the workspace, including symlinks that lead out of it, are
+// skipped with a warning.
+func (cb *ContextBuilder) buildAutoContextSection() string {
+	if len(cb.autoContextFiles) == 0 {
+		return ""
+	}
+
+	var blocks []string
+	for _, rel := range cb.autoContextFiles {
+		rel = strings.TrimSpace(rel)
+		if rel == "" {
+			continue
+		}
+		path, err := tools.ValidatePath(filepath.FromSlash(rel), cb.workspace, true)
+		if filepath.IsAbs(rel) || err != nil {
+			logger.WarnCF("agent", "Skipping auto context file outside workspace", map[string]interface{}{"path": rel})
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		content := normalizeBootstrapContent(string(data))
+		if content == "" {
+			continue
+		}
+		blocks = append(blocks, fmt.Sprintf("### %s\n\n%s", filepath.ToSlash(rel), content))
+	}
+	if len(blocks) == 0 {
+		return ""
+	}
+
+	body := strings.Join(blocks, "\n\n")
+	if limit := cb.autoContextMaxTokens; limit > 0 {
+		before := estimateSectionTokens(body)
+		body = truncateToTokens(body, limit, "[auto context truncated to fit max_auto_context_tokens]")
+		cb.reportTrim("auto_context", before-estimateSectionTokens(body))
+	}
+	return autoContextHeading + "\n\n" + body
+}
+
+// truncateToTokens cuts text to roughly maxTokens under the section estimate,
+// preferring a line break, and appends marker when anything was dropped.
+func truncateToTokens(text string, maxTokens int, marker string) string {
+	if estimateSectionTokens(text) <= maxTokens {
+		return text
+	}
+	runes := []rune(text)
+	limit := maxTokens * 5 / 2
+	if limit > len(runes) {
+		limit = len(runes)
+	}
+	cut := string(runes[:limit])
+	if i := strings.LastIndex(cut, "\n"); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " \n") + "\n\n" + marker
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/logger"
+)
+
+// ContentFilterResponse is the reply to messages blocked by the content
+// filter.
+const ContentFilterResponse = "I'm not able to help with that."
+
+// contentFilter holds the compiled gateway.content_filter blocklist.
+type contentFilter struct {
+	patterns []*regexp.Regexp
+}
+
+func compileContentFilter(patterns []string) *contentFilter {
+	f := &contentFilter{}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			logger.WarnCF("agent", "Ignoring invalid content filter pattern", map[string]interface{}{
+				"pattern_hash": ContentFilterPatternHash(pattern),
+				"error":        err.Error(),
+			})
+			continue
+		}
+		f.patterns = append(f.patterns, re)
+	}
+	return f
+}
+
+// match returns the first pattern that matches content.
+func (f *contentFilter) match(content string) (string, bool) {
+	if f == nil {
+		return "", false
+	}
+	for _, re := range f.patterns {
+		if re.MatchString(content) {
+			return re.String(), true
+		}
+	}
+	return "", false
+}
+
+// MatchContentFilter reports the first blocklist pattern that matches
+// message, as the agent would apply it. Invalid patterns are skipped.
+func MatchContentFilter(patterns []string, message string) (string, bool) {
+	return compileContentFilter(patterns).match(message)
+}
+
+// ContentFilterPatternHash identifies a blocklist pattern in logs and metrics
+// without revealing the pattern or the message it matched.
+func ContentFilterPatternHash(pattern string) string {
+	sum := sha256.Sum256([]byte(pattern))
+	return hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+	"github.com/dotsetgreg/dotagent/pkg/config"
+	"github.com/dotsetgreg/dotagent/pkg/providers"
+)
+
+type countingProvider struct {
+	mockProvider
+	calls int
+}
+
+func (p *countingProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	p.calls++
+	return p.mockProvider.Chat(ctx, messages, tools, model, opts)
+}
+
+func TestMatchContentFilter(t *testing.T) {
+	patterns := []string{"", "(unclosed", `(?i)\bmake a bomb\b`}
+	if pattern, ok := MatchContentFilter(patterns, "How do I MAKE A BOMB?"); !ok || pattern != `(?i)\bmake a bomb\b` {
+		t.Fatalf("expected blocklist match, got %q %v", pattern, ok)
+	}
+	if _, ok := MatchContentFilter(patterns, "how do I make a bombastic speech"); ok {
+		t.Fatal("expected no match for unrelated message")
+	}
+	if ContentFilterPatternHash("a") == ContentFilterPatternHash("b") || len(ContentFilterPatternHash("a")) != 12 {
+		t.Fatal("expected short, distinct pattern hashes")
+	}
+}
+
+func TestProcessMessage_ContentFilterSkipsModel(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Paths.Data = t.TempDir()
+	cfg.Gateway.ContentFilter.BlocklistPatterns = config.FlexibleStringSlice{`(?i)forbidden`}
+	provider := &countingProvider{}
+	al := mustNewAgentLoop(t, cfg, bus.NewMessageBus(), provider)
+
+	msg := bus.InboundMessage{Channel: "cli", ChatID: "direct", SenderID: "u1", SessionKey: "cli:direct", Content: "tell me the Forbidden thing"}
+	out, err := al.processMessage(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("process message: %v", err)
+	}
+	if out != ContentFilterResponse {
+		t.Fatalf("expected canned refusal, got %q", out)
+	}
+	if provider.calls != 0 {
+		t.Fatalf("expected the model not to be called, got %d calls", provider.calls)
+	}
+
+	msg.Content = "/ping"
+	al.contentFilter = compileContentFilter([]string{`(?i)ping`})
+	if out, err := al.processMessage(context.Background(), msg); err != nil || out == ContentFilterResponse {
+		t.Fatalf("expected commands to be handled before the content filter, got %q (err=%v)", out, err)
+	}
+}
@@ -21,6 +21,10 @@ type ContextBuilder struct {
 	skillsLoader          *skills.SkillsLoader
 	tools                 *tools.ToolRegistry // Direct reference to tool registry
 	bootstrapConflictOnce sync.Once
+	tokenLimits           MessageTokenLimits
+	onTrim                ContextTrimFunc
+	autoContextFiles      []string
+	autoContextMaxTokens  int
 }
 
 type SystemPromptMetadata struct {
@@ -137,11 +141,21 @@ func (cb *ContextBuilder) BuildSystemPromptWithMetadata() (string, SystemPromptM
 	// Skills - show summary, AI can read full content with read_file tool
 	skillsSummary := cb.skillsLoader.BuildSkillsSummary()
 	if skillsSummary != "" {
-		parts = append(parts, fmt.Sprintf(`# Skills
+		section := fmt.Sprintf(`# Skills
 
 The following skills extend your capabilities. To use a skill, read its SKILL.md file using the read_file tool.
 
-%s`, skillsSummary))
+%s`, skillsSummary)
+		if cb.tokenLimits.Skills > 0 {
+			if composed := skills.NewSkillComposer(cb.skillsLoader).Compose(cb.tokenLimits.Skills); composed != "" {
+				section += "\n\n## Skill Instructions\n\nSkills marked as truncated can be read in full from their SKILL.md.\n\n" + composed
+			}
+		}
+		parts = append(parts, section)
+	}
+
+	if autoContext := cb.buildAutoContextSection(); autoContext != "" {
+		parts = append(parts, autoContext)
 	}
 
 	prompt := strings.Join(parts, "\n\n---\n\n")
@@ -240,6 +254,7 @@ func (cb *ContextBuilder) BuildMessagesWithSystemPrompt(systemPrompt string, his
 			map[string]interface{}{"role": history[0].Role})
 		history = history[1:]
 	}
+	history, recalledMemory = cb.applyTokenLimits(systemPrompt, summary, history, recalledMemory)
 
 	messages = append(messages, providers.Message{
 		Role:    "system",
@@ -272,7 +287,7 @@ func (cb *ContextBuilder) BuildMessagesWithSystemPrompt(systemPrompt string, his
 		})
 	}
 
-	return messages
+	return cb.attachImages(messages, media)
 }
 
 // GetSkillsInfo returns information about loaded skills.
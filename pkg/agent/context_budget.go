@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/logger"
+	"github.com/dotsetgreg/dotagent/pkg/providers"
+)
+
+// MessageTokenLimits caps the estimated token size of each prompt section
+// assembled by ContextBuilder. A zero limit leaves that section uncapped.
+type MessageTokenLimits struct {
+	// System bounds all system-role content: the base prompt plus dynamic
+	// context (summary, recall and persona card).
+	System int
+	// Recall bounds the recalled-memory block, persona card included.
+	Recall int
+	// History bounds prior conversation messages.
+	History int
+	// Skills bounds skill instructions inlined into the system prompt. Zero
+	// lists skills by summary only, leaving the model to read SKILL.md.
+	Skills int
+}
+
+// ContextTrimFunc is notified whenever a section is trimmed to fit its limit.
+type ContextTrimFunc func(section string, droppedTokens int)
+
+const personaSectionHeading = "## Active Persona"
+
+// SetTokenLimits configures per-section token caps applied by
+// BuildMessagesWithSystemPrompt. onTrim may be nil.
+func (cb *ContextBuilder) SetTokenLimits(limits MessageTokenLimits, onTrim ContextTrimFunc) {
+	cb.tokenLimits = limits
+	cb.onTrim = onTrim
+}
+
+// applyTokenLimits trims history and recall so each stays within its cap, then
+// trims recall further if system-role content still exceeds the system cap.
+// Trimming order is oldest history first, then recall sections, then the
+// persona card. The base system prompt itself is never trimmed.
+func (cb *ContextBuilder) applyTokenLimits(systemPrompt, summary string, history []providers.Message, recall string) ([]providers.Message, string) {
+	limits := cb.tokenLimits
+
+	if limits.History > 0 {
+		before := historyTokens(history)
+		// Always keep the newest message; it may be the current user turn.
+		for len(history) > 1 && historyTokens(history) > limits.History {
+			history = dropOldestHistoryTurn(history)
+		}
+		cb.reportTrim("history", before-historyTokens(history))
+	}
+
+	sections := splitRecallSections(recall)
+	sectionCount := len(sections)
+	dropRecallUntil := func(section string, fits func() bool) {
+		before := estimateSectionTokens(joinRecallSections(sections))
+		for len(sections) > 0 && !fits() {
+			sections = dropLowestPriorityRecallSection(sections)
+		}
+		cb.reportTrim(section, before-estimateSectionTokens(joinRecallSections(sections)))
+	}
+	if limits.Recall > 0 {
+		dropRecallUntil("recall", func() bool {
+			return estimateSectionTokens(joinRecallSections(sections)) <= limits.Recall
+		})
+	}
+	if limits.System > 0 {
+		fixed := estimateSectionTokens(systemPrompt) + estimateSectionTokens(limitSummaryForContext(summary, 3200))
+		dropRecallUntil("system", func() bool {
+			return fixed+estimateSectionTokens(joinRecallSections(sections)) <= limits.System
+		})
+		if fixed > limits.System {
+			logger.WarnCF("agent", "System prompt exceeds max_system_tokens without recall", map[string]interface{}{
+				"estimated_tokens":  fixed,
+				"max_system_tokens": limits.System,
+			})
+		}
+	}
+
+	if len(sections) == sectionCount {
+		return history, recall
+	}
+	return history, joinRecallSections(sections)
+}
+
+func (cb *ContextBuilder) reportTrim(section string, droppedTokens int) {
+	if droppedTokens <= 0 {
+		return
+	}
+	logger.InfoCF("agent", "Trimmed prompt section to fit token limit", map[string]interface{}{
+		"section":        section,
+		"dropped_tokens": droppedTokens,
+	})
+	if cb.onTrim != nil {
+		cb.onTrim(section, droppedTokens)
+	}
+}
+
+// estimateSectionTokens mirrors the memory service's fallback heuristic so
+// limits line up with the budgets memory already applies.
+func estimateSectionTokens(text string) int {
+	runes := len([]rune(text))
+	if runes == 0 {
+		return 0
+	}
+	tokens := runes * 2 / 5
+	if tokens < 8 {
+		return 8
+	}
+	return tokens
+}
+
+func historyTokens(history []providers.Message) int {
+	total := 0
+	for _, msg := range history {
+		total += estimateSectionTokens(msg.Content)
+		for _, tc := range msg.ToolCalls {
+			if tc.Function != nil {
+				total += estimateSectionTokens(tc.Function.Arguments)
+			}
+		}
+	}
+	return total
+}
+
+// dropOldestHistoryTurn removes the oldest message plus any tool results that
+// would be orphaned by removing their assistant tool call.
+func dropOldestHistoryTurn(history []providers.Message) []providers.Message {
+	history = history[1:]
+	for len(history) > 0 && history[0].Role == "tool" {
+		history = history[1:]
+	}
+	return history
+}
+
+// splitRecallSections splits a recall prompt on its "## " headings.
+func splitRecallSections(recall string) []string {
+	recall = strings.TrimSpace(recall)
+	if recall == "" {
+		return nil
+	}
+	var (
+		sections []string
+		current  []string
+	)
+	for _, line := range strings.Split(recall, "\n") {
+		if strings.HasPrefix(line, "## ") && len(current) > 0 {
+			sections = append(sections, strings.TrimSpace(strings.Join(current, "\n")))
+			current = nil
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		sections = append(sections, strings.TrimSpace(strings.Join(current, "\n")))
+	}
+	return sections
+}
+
+func joinRecallSections(sections []string) string {
+	return strings.Join(sections, "\n\n")
+}
+
+// dropLowestPriorityRecallSection removes the last non-persona section, or the
+// persona card once nothing else remains.
+func dropLowestPriorityRecallSection(sections []string) []string {
+	for i := len(sections) - 1; i >= 0; i-- {
+		if !strings.HasPrefix(sections[i], personaSectionHeading) {
+			return append(sections[:i:i], sections[i+1:]...)
+		}
+	}
+	return sections[:len(sections)-1]
+}
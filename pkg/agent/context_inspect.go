@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+	"github.com/dotsetgreg/dotagent/pkg/providers"
+)
+
+// contextWarnRatio is the share of the context window at which /context
+// warns that the next request is close to the limit.
+const contextWarnRatio = 0.8
+
+type contextSection struct {
+	Label   string
+	Tokens  int
+	Content string
+}
+
+// inspectContext assembles the messages the next turn in this session would
+// send, without calling the provider. Any text after the flags stands in for
+// the next user message, which also drives memory recall.
+func (al *AgentLoop) inspectContext(ctx context.Context, msg bus.InboundMessage, args []string) string {
+	compact := false
+	var words []string
+	for _, arg := range args {
+		if arg == "--compact" {
+			compact = true
+			continue
+		}
+		words = append(words, arg)
+	}
+	probe := strings.Join(words, " ")
+
+	userID := strings.TrimSpace(msg.SenderID)
+	if userID == "" {
+		userID = "local-user"
+	}
+	sessionKey := al.resolveCommandSessionKey(msg, userID)
+
+	promptCtx, err := al.memory.BuildPromptContext(ctx, sessionKey, userID, probe, al.contextWindow)
+	if err != nil {
+		return fmt.Sprintf("Failed to build context: %v", err)
+	}
+	systemPrompt, _ := al.contextBuilder.BuildSystemPromptWithMetadata()
+	messages := al.contextBuilder.BuildMessagesWithSystemPrompt(
+		systemPrompt,
+		toProviderMessages(promptCtx.History),
+		promptCtx.Summary,
+		promptCtx.RecallPrompt,
+		probe,
+		nil,
+		msg.Channel,
+		msg.ChatID,
+	)
+
+	var toolDefs []providers.ToolDefinition
+	if al.tools != nil {
+		toolDefs = al.tools.ToProviderDefs()
+	}
+	return formatContextReport(splitContextSections(messages, probe, toolDefs), al.contextWindow, compact)
+}
+
+// splitContextSections groups assembled messages into the sections shown by
+// /context: base system prompt, dynamic context (session, summary, recall),
+// history, the probe message, and tool schemas.
+func splitContextSections(messages []providers.Message, probe string, toolDefs []providers.ToolDefinition) []contextSection {
+	var sections []contextSection
+	i := 0
+	if i < len(messages) && messages[i].Role == "system" {
+		sections = append(sections, contextSection{Label: "System prompt", Tokens: estimateSectionTokens(messages[i].Content), Content: messages[i].Content})
+		i++
+	}
+	if i < len(messages) && messages[i].Role == "system" {
+		sections = append(sections, contextSection{Label: "Dynamic context (session, summary, recall)", Tokens: estimateSectionTokens(messages[i].Content), Content: messages[i].Content})
+		i++
+	}
+
+	history := messages[i:]
+	var next *providers.Message
+	if strings.TrimSpace(probe) != "" && len(history) > 0 {
+		next = &history[len(history)-1]
+		history = history[:len(history)-1]
+	}
+	lines := make([]string, 0, len(history))
+	for _, m := range history {
+		role := m.Role
+		if m.ToolCallID != "" {
+			role += " " + m.ToolCallID
+		}
+		line := fmt.Sprintf("[%s] %s", role, m.Content)
+		for _, tc := range m.ToolCalls {
+			if tc.Function != nil {
+				line += fmt.Sprintf("\n  -> %s(%s)", tc.Function.Name, tc.Function.Arguments)
+			}
+		}
+		lines = append(lines, line)
+	}
+	sections = append(sections, contextSection{
+		Label:   fmt.Sprintf("History (%d messages)", len(history)),
+		Tokens:  historyTokens(history),
+		Content: strings.Join(lines, "\n"),
+	})
+	if next != nil {
+		sections = append(sections, contextSection{Label: "Next user message", Tokens: estimateSectionTokens(next.Content), Content: next.Content})
+	}
+
+	if len(toolDefs) > 0 {
+		names := make([]string, 0, len(toolDefs))
+		for _, def := range toolDefs {
+			names = append(names, def.Function.Name)
+		}
+		schema, _ := json.Marshal(toolDefs)
+		sections = append(sections, contextSection{
+			Label:   fmt.Sprintf("Tools (%d definitions)", len(toolDefs)),
+			Tokens:  estimateSectionTokens(string(schema)),
+			Content: strings.Join(names, ", "),
+		})
+	}
+	return sections
+}
+
+func formatContextReport(sections []contextSection, contextWindow int, compact bool) string {
+	total := 0
+	for _, s := range sections {
+		total += s.Tokens
+	}
+
+	var b strings.Builder
+	if compact {
+		b.WriteString("Context for the next request (estimated tokens):\n")
+		for _, s := range sections {
+			fmt.Fprintf(&b, "- %s: ~%d\n", s.Label, s.Tokens)
+		}
+	} else {
+		b.WriteString("````\n")
+		for _, s := range sections {
+			fmt.Fprintf(&b, "=== %s (~%d tokens) ===\n", s.Label, s.Tokens)
+			if content := strings.TrimSpace(s.Content); content != "" {
+				b.WriteString(content)
+				b.WriteString("\n")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("````\n")
+	}
+	if contextWindow > 0 {
+		fmt.Fprintf(&b, "Total: ~%d of %d tokens (%d%%)", total, contextWindow, total*100/contextWindow)
+		if float64(total) >= float64(contextWindow)*contextWarnRatio {
+			b.WriteString("\nWarning: the next request is close to the context limit; older history will be compacted.")
+		}
+	} else {
+		fmt.Fprintf(&b, "Total: ~%d tokens", total)
+	}
+	return b.String()
+}
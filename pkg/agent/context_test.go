@@ -90,3 +90,152 @@ func TestBuildMessagesWithSystemPrompt_TruncatesLargeSummary(t *testing.T) {
 		t.Fatalf("expected dynamic context framing")
 	}
 }
+
+func TestBuildMessagesWithSystemPrompt_TrimsHistoryOldestFirst(t *testing.T) {
+	cb := NewContextBuilder(t.TempDir())
+	var trimmed []string
+	cb.SetTokenLimits(MessageTokenLimits{History: 60}, func(section string, _ int) {
+		trimmed = append(trimmed, section)
+	})
+	history := []providers.Message{
+		{Role: "user", Content: strings.Repeat("old ", 40)},
+		{Role: "assistant", Content: strings.Repeat("reply ", 30)},
+		{Role: "user", Content: "latest question"},
+	}
+	msgs := cb.BuildMessagesWithSystemPrompt("system", history, "", "", "", nil, "", "")
+	if len(msgs) != 2 || msgs[1].Content != "latest question" {
+		t.Fatalf("expected only the newest history message to remain, got %#v", msgs)
+	}
+	if len(trimmed) != 1 || trimmed[0] != "history" {
+		t.Fatalf("expected history trim to be reported, got %v", trimmed)
+	}
+}
+
+func TestBuildMessagesWithSystemPrompt_TrimsRecallBeforePersona(t *testing.T) {
+	cb := NewContextBuilder(t.TempDir())
+	persona := "## Active Persona\n### Core Identity\n- Agent name: Dot"
+	recall := persona + "\n\n## Recalled Memory\n- [fact] " + strings.Repeat("detail ", 60) + "\n\n## Continuation Handoff\n- keep going"
+
+	cb.SetTokenLimits(MessageTokenLimits{Recall: estimateSectionTokens(persona) + 10}, nil)
+	msgs := cb.BuildMessagesWithSystemPrompt("system", nil, "", recall, "hi", nil, "", "")
+	dynamic := msgs[1].Content
+	if !strings.Contains(dynamic, "## Active Persona") {
+		t.Fatalf("expected persona card to survive recall trimming:\n%s", dynamic)
+	}
+	if strings.Contains(dynamic, "## Recalled Memory") || strings.Contains(dynamic, "## Continuation Handoff") {
+		t.Fatalf("expected recall sections to be trimmed first:\n%s", dynamic)
+	}
+
+	cb.SetTokenLimits(MessageTokenLimits{System: estimateSectionTokens("system") + 1}, nil)
+	msgs = cb.BuildMessagesWithSystemPrompt("system", nil, "", recall, "hi", nil, "", "")
+	if len(msgs) != 2 || msgs[1].Role != "user" {
+		t.Fatalf("expected system budget to drop all recall, got %#v", msgs)
+	}
+}
+
+func TestBuildSystemPrompt_AppendsAutoContextFiles(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(ws, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "context.md"), []byte("Project uses Postgres 16."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "docs", "style.md"), []byte(strings.Repeat("Prefer short answers.\n", 200)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	secret := filepath.Join(t.TempDir(), "secret.md")
+	if err := os.WriteFile(secret, []byte("outside secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	links := []string{}
+	if err := os.Symlink(secret, filepath.Join(ws, "linked.md")); err == nil {
+		links = append(links, "linked.md")
+	}
+
+	cb := NewContextBuilder(ws)
+	var trimmed []string
+	cb.SetTokenLimits(MessageTokenLimits{}, func(section string, _ int) { trimmed = append(trimmed, section) })
+	cb.SetAutoContext(append([]string{"context.md", "missing.md", "../outside.md", "docs/style.md"}, links...), 200)
+
+	prompt := cb.BuildSystemPrompt()
+	if !strings.Contains(prompt, "## Auto Context\n\n### context.md\n\nProject uses Postgres 16.") {
+		t.Fatalf("expected context.md under Auto Context, got:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "### docs/style.md") || !strings.Contains(prompt, "[auto context truncated") {
+		t.Fatalf("expected docs/style.md to be included and truncated, got:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "missing.md") || strings.Contains(prompt, "outside.md") || strings.Contains(prompt, "outside secret") {
+		t.Fatal("expected missing, out-of-workspace and symlinked-out files to be skipped")
+	}
+	section := prompt[strings.Index(prompt, autoContextHeading):]
+	if got := estimateSectionTokens(section); got > 240 {
+		t.Fatalf("expected auto context near the 200 token cap, got %d", got)
+	}
+	if len(trimmed) != 1 || trimmed[0] != "auto_context" {
+		t.Fatalf("expected one auto_context trim report, got %v", trimmed)
+	}
+
+	cb.SetAutoContext(nil, 0)
+	if strings.Contains(cb.BuildSystemPrompt(), autoContextHeading) {
+		t.Fatal("expected no Auto Context section without configured files")
+	}
+}
+
+func TestDetectImagePaths(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ws, "shot.png"), []byte("png"), 0o644); err != nil {
+		t.Fatalf("write image: %v", err)
+	}
+	outside := filepath.Join(t.TempDir(), "other.jpg")
+	if err := os.WriteFile(outside, []byte("jpg"), 0o644); err != nil {
+		t.Fatalf("write image: %v", err)
+	}
+
+	content := "compare shot.png, missing.gif and https://example.com/a.png with " + outside
+	got := detectImagePaths(content, ws, false)
+	if len(got) != 2 || got[0] != "shot.png" || got[1] != outside {
+		t.Fatalf("unexpected paths without restriction: %v", got)
+	}
+	got = detectImagePaths(content, ws, true)
+	if len(got) != 1 || got[0] != "shot.png" {
+		t.Fatalf("expected only the workspace image with restriction, got %v", got)
+	}
+
+	if err := os.Symlink(outside, filepath.Join(ws, "link.jpg")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if got := detectImagePaths("look at link.jpg", ws, true); len(got) != 0 {
+		t.Fatalf("expected a symlink out of the workspace to be ignored with restriction, got %v", got)
+	}
+	if got := detectImagePaths("look at link.jpg", ws, false); len(got) != 1 {
+		t.Fatalf("expected the symlinked image without restriction, got %v", got)
+	}
+}
+
+func TestBuildMessagesWithSystemPrompt_AttachesImages(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ws, "shot.png"), []byte("png-bytes"), 0o644); err != nil {
+		t.Fatalf("write image: %v", err)
+	}
+	cb := NewContextBuilder(ws)
+	msgs := cb.BuildMessagesWithSystemPrompt("system", nil, "", "", "what is in shot.png?", []string{"shot.png"}, "cli", "direct")
+	last := msgs[len(msgs)-1]
+	if last.Role != "user" {
+		t.Fatalf("expected last message to be the user turn, got %q", last.Role)
+	}
+	if last.Content != "what is in [image: shot.png]?" {
+		t.Fatalf("expected path replaced by placeholder, got %q", last.Content)
+	}
+	if len(last.ContentBlocks) != 2 {
+		t.Fatalf("expected text and image blocks, got %d", len(last.ContentBlocks))
+	}
+	image := last.ContentBlocks[1]
+	if image.Type != "image_url" || image.ImageURL == nil {
+		t.Fatalf("expected image_url block, got %+v", image)
+	}
+	if image.ImageURL.URL != "data:image/png;base64,cG5nLWJ5dGVz" {
+		t.Fatalf("unexpected data URL %q", image.ImageURL.URL)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -26,14 +27,18 @@ import (
 	"github.com/dotsetgreg/dotagent/pkg/channels"
 	"github.com/dotsetgreg/dotagent/pkg/config"
 	"github.com/dotsetgreg/dotagent/pkg/constants"
+	"github.com/dotsetgreg/dotagent/pkg/cron"
 	"github.com/dotsetgreg/dotagent/pkg/logger"
 	"github.com/dotsetgreg/dotagent/pkg/memory"
+	"github.com/dotsetgreg/dotagent/pkg/observability"
 	"github.com/dotsetgreg/dotagent/pkg/providers"
 	"github.com/dotsetgreg/dotagent/pkg/state"
 	"github.com/dotsetgreg/dotagent/pkg/toolpacks"
 	"github.com/dotsetgreg/dotagent/pkg/tools"
+	"github.com/dotsetgreg/dotagent/pkg/trace"
 	"github.com/dotsetgreg/dotagent/pkg/utils"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type AgentLoop struct {
@@ -42,6 +47,7 @@ type AgentLoop struct {
 	providerName           string
 	workspace              string
 	workspaceID            string
+	settingsMu             sync.RWMutex // guards model and completionMax, which change at runtime
 	model                  string
 	temperature            float64
 	completionMax          int
@@ -52,6 +58,7 @@ type AgentLoop struct {
 	maxIterations          int
 	maxConcurrent          int
 	memory                 *memory.Service
+	memoryGate             *memoryAvailability
 	state                  *state.Manager
 	contextBuilder         *ContextBuilder
 	tools                  *tools.ToolRegistry
@@ -66,20 +73,32 @@ type AgentLoop struct {
 	personaSyncTimeout     time.Duration
 	running                atomic.Bool
 	channelManager         *channels.Manager
+	cronService            *cron.CronService
+	responseFilters        []responseFilter
+	contentFilter          *contentFilter
+	approvalTool           *tools.ApprovalTool
+	personaRewinds         *personaRewindOffers
+	toolAudit              *tools.AuditLogger
+	restrictToWorkspace    bool
+	modelCacheTTL          time.Duration
+	modelCacheDir          string
 }
 
 // processOptions configures how a message is processed
 type processOptions struct {
-	SessionKey      string // Session identifier for history/context
-	Channel         string // Target channel for tool execution
-	ChatID          string // Target chat ID for tool execution
-	UserID          string // User identifier for memory namespace
-	UserMessage     string // User message content (may include prefix)
-	DefaultResponse string // Response when LLM returns empty
-	EnableSummary   bool   // Whether to trigger summarization
-	SendResponse    bool   // Whether to send response via bus
-	StreamResponse  bool   // Whether to stream partial LLM output via bus
-	NoHistory       bool   // If true, don't load session history (for heartbeat)
+	SessionKey      string   // Session identifier for history/context
+	Channel         string   // Target channel for tool execution
+	ChatID          string   // Target chat ID for tool execution
+	UserID          string   // User identifier for memory namespace
+	UserMessage     string   // User message content (may include prefix)
+	Images          []string // Local image paths from UserMessage to send inline
+	DefaultResponse string   // Response when LLM returns empty
+	EnableSummary   bool     // Whether to trigger summarization
+	SendResponse    bool     // Whether to send response via bus
+	StreamResponse  bool     // Whether to stream partial LLM output via bus
+	NoHistory       bool     // If true, don't load session history (for heartbeat)
+
+	StreamDelta func(string) // Receives raw LLM text deltas as they arrive (direct CLI streaming)
 }
 
 // createToolRegistry creates a tool registry with common tools.
@@ -109,6 +128,54 @@ func createToolRegistry(workspace string, restrict bool, cfg *config.Config, msg
 	if err := register(tools.NewAppendFileTool(workspace, restrict)); err != nil {
 		return nil, err
 	}
+	if err := register(tools.NewTemplateTool(workspace, restrict)); err != nil {
+		return nil, err
+	}
+	if err := register(tools.NewTableQueryTool(workspace, restrict)); err != nil {
+		return nil, err
+	}
+	if err := register(tools.NewTableDescribeTool(workspace, restrict)); err != nil {
+		return nil, err
+	}
+	if err := register(tools.NewFileDiffTool(workspace, restrict)); err != nil {
+		return nil, err
+	}
+	if err := register(tools.NewTextDiffTool()); err != nil {
+		return nil, err
+	}
+	if err := register(tools.NewCodeSearchTool(workspace, restrict)); err != nil {
+		return nil, err
+	}
+	if err := register(tools.NewQRCodeTool(workspace, restrict)); err != nil {
+		return nil, err
+	}
+	if err := register(tools.NewPDFTool(workspace, restrict, cfg.Tools.PDF.MaxChars)); err != nil {
+		return nil, err
+	}
+	if err := register(tools.NewContactAddTool(workspace, restrict)); err != nil {
+		return nil, err
+	}
+	if err := register(tools.NewContactSearchTool(workspace, restrict)); err != nil {
+		return nil, err
+	}
+	if err := register(tools.NewContactGetTool(workspace, restrict)); err != nil {
+		return nil, err
+	}
+	if err := register(tools.NewArchiveCreateTool(workspace, restrict, cfg.Tools.Archive.MaxSizeMB)); err != nil {
+		return nil, err
+	}
+	if err := register(tools.NewArchiveExtractTool(workspace, restrict, cfg.Tools.Archive.MaxSizeMB)); err != nil {
+		return nil, err
+	}
+	if err := register(tools.NewCryptoHashTool()); err != nil {
+		return nil, err
+	}
+	if err := register(tools.NewCryptoBase64Tool()); err != nil {
+		return nil, err
+	}
+	if err := register(tools.NewCryptoUUIDTool()); err != nil {
+		return nil, err
+	}
 
 	// Shell execution
 	if err := register(tools.NewExecTool(workspace, restrict)); err != nil {
@@ -117,6 +184,72 @@ func createToolRegistry(workspace string, restrict bool, cfg *config.Config, msg
 	if err := register(tools.NewProcessTool(workspace, restrict)); err != nil {
 		return nil, err
 	}
+	if err := register(tools.NewCodeRunnerTool(workspace, tools.CodeRunnerToolOptions{
+		TimeoutSeconds:   cfg.Tools.CodeRunner.TimeoutSeconds,
+		AllowedLanguages: cfg.Tools.CodeRunner.AllowedLanguages,
+		UseSandbox:       cfg.Tools.CodeRunner.UseSandbox,
+		Restrict:         restrict,
+	})); err != nil {
+		return nil, err
+	}
+	if cfg.Tools.SSH.Enabled {
+		if err := register(tools.NewSSHTool(tools.SSHToolOptions{
+			KeyPath:        cfg.Tools.SSH.KeyPath,
+			KnownHostsPath: cfg.Tools.SSH.KnownHostsPath,
+			AllowedHosts:   cfg.Tools.SSH.AllowedHosts,
+			TimeoutSeconds: cfg.Tools.SSH.TimeoutSeconds,
+		})); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Tools.Kubernetes.Enabled {
+		kube := tools.NewKubernetesClient(tools.KubernetesToolOptions{
+			KubeconfigPath:    cfg.Tools.Kubernetes.KubeconfigPath,
+			InCluster:         cfg.Tools.Kubernetes.InCluster,
+			AllowedNamespaces: cfg.Tools.Kubernetes.AllowedNamespaces,
+			Restrict:          restrict,
+		})
+		if err := register(tools.NewK8sGetTool(kube)); err != nil {
+			return nil, err
+		}
+		if err := register(tools.NewK8sLogsTool(kube)); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Tools.Voice.APIKey != "" || cfg.Tools.Voice.WhisperBinary != "" {
+		if err := register(tools.NewVoiceTool(workspace, restrict, tools.VoiceToolOptions{
+			APIKey:        cfg.Tools.Voice.APIKey,
+			APIBase:       cfg.Tools.Voice.APIBase,
+			Model:         cfg.Tools.Voice.Model,
+			Language:      cfg.Tools.Voice.Language,
+			WhisperBinary: cfg.Tools.Voice.WhisperBinary,
+			MaxFileMB:     cfg.Tools.Voice.MaxFileMB,
+		})); err != nil {
+			return nil, err
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.Tools.IssueTracker.Backend)) {
+	case "jira":
+		tracker := tools.NewJiraIssueTracker(tools.JiraOptions{
+			BaseURL:    cfg.Tools.Jira.BaseURL,
+			User:       cfg.Tools.Jira.User,
+			APIToken:   cfg.Tools.Jira.APIToken,
+			ProjectKey: cfg.Tools.Jira.ProjectKey,
+			IssueType:  cfg.Tools.Jira.IssueType,
+		})
+		if err := register(tools.NewIssueCreateTool(tracker)); err != nil {
+			return nil, err
+		}
+		if err := register(tools.NewIssueListTool(tracker)); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Tools.Screenshot.Enabled || !restrict {
+		if err := register(tools.NewScreenshotTool(workspace)); err != nil {
+			return nil, err
+		}
+	}
 
 	if searchTool := tools.NewWebSearchTool(tools.WebSearchToolOptions{
 		BraveAPIKey:          cfg.Tools.Web.Brave.APIKey,
@@ -237,6 +370,7 @@ func NewAgentLoop(cfg *config.Config, msgBus *bus.MessageBus, provider providers
 		return nil, fmt.Errorf("create main tool registry: %w", err)
 	}
 	packManager := toolpacks.NewManager(workspace, restrict)
+	packManager.SetSchemaCacheTTL(time.Duration(cfg.Toolpacks.SchemaCacheMinutes) * time.Minute)
 	packTools, err := packManager.LoadEnabledTools()
 	for _, t := range packTools {
 		if regErr := toolsRegistry.Register(t); regErr != nil {
@@ -268,12 +402,19 @@ func NewAgentLoop(cfg *config.Config, msgBus *bus.MessageBus, provider providers
 		return nil, fmt.Errorf("register subagent tool: %w", err)
 	}
 
+	// Register delegate tool (named agents from workspace/agents)
+	delegateTool := tools.NewDelegateTool(subagentManager, workspace)
+	if err := toolsRegistry.Register(delegateTool); err != nil {
+		return nil, fmt.Errorf("register delegate tool: %w", err)
+	}
+
 	// Create state manager for atomic state persistence
 	stateManager := state.NewManager(dataRoot)
 
 	// Create context builder and set tools registry
 	contextBuilder := NewContextBuilder(workspace)
 	contextBuilder.SetToolsRegistry(toolsRegistry)
+	delegateTool.SetSkillLoader(contextBuilder.skillsLoader.LoadSkillsForContext)
 	subagentWorkspaceContext := strings.TrimSpace(contextBuilder.getIdentity())
 	if subagentWorkspaceContext != "" {
 		subagentManager.SetWorkspaceContext(subagentWorkspaceContext)
@@ -343,6 +484,27 @@ TURN TRANSCRIPT:
 		return candidates, nil
 	}
 
+	var topicClassifyFn memory.TopicClassifyFunc
+	topicMode := strings.ToLower(strings.TrimSpace(cfg.Memory.SessionTopicMode))
+	if topicMode == "llm" {
+		topicClassifyFn = func(ctx context.Context, transcript string) ([]string, error) {
+			prompt := strings.TrimSpace(`Name the 1-3 main topics of this conversation as short lowercase tags, such as "cooking", "kubernetes" or "python".
+
+Return the tags as a JSON array of strings only. No prose.
+
+TRANSCRIPT:
+` + transcript)
+			resp, err := provider.Chat(ctx, []providers.Message{{Role: "user", Content: prompt}}, nil, cfg.Agents.Defaults.Model, map[string]interface{}{
+				"max_tokens":  60,
+				"temperature": 0.0,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return memory.ParseTopicTags(resp.Content), nil
+		}
+	}
+
 	resolvedContextWindow := resolveRuntimeContextWindow(provider, cfg.Agents.Defaults.Model, cfg.Agents.Defaults.MaxTokens)
 	subagentRetryCfg := providers.DefaultRetryConfig()
 	subagentRetryCfg.MaxAttempts = 3
@@ -353,6 +515,7 @@ TURN TRANSCRIPT:
 		ContextPruningMode:     strings.TrimSpace(cfg.Memory.ContextPruningMode),
 		ContextPruningKeepLast: cfg.Memory.ContextPruningKeepLastToolResults,
 		MaxOverflowCompactions: 3,
+		MaxDepth:               cfg.Agents.Defaults.MaxSubagentDepth,
 		Retry:                  subagentRetryCfg,
 		LoopDetection: tools.ToolLoopDetectionConfig{
 			Enabled:                     cfg.Memory.ToolLoopDetectionEnabled,
@@ -413,6 +576,24 @@ TURN TRANSCRIPT:
 		},
 	}
 
+	var personaExperiment *memory.PersonaExperiment
+	if cfg.Memory.PersonaExperiment.Enabled {
+		resolve := func(path string) string {
+			path = strings.TrimSpace(path)
+			if path != "" && !filepath.IsAbs(path) {
+				path = filepath.Join(workspace, path)
+			}
+			return path
+		}
+		personaExperiment, err = memory.LoadPersonaExperiment(
+			resolve(cfg.Memory.PersonaExperiment.ControlProfile),
+			resolve(cfg.Memory.PersonaExperiment.TreatmentProfile),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("load persona experiment: %w", err)
+		}
+	}
+
 	memSvc, err := memory.NewService(memory.Config{
 		Workspace:               workspace,
 		DataDir:                 dataRoot,
@@ -420,6 +601,7 @@ TURN TRANSCRIPT:
 		ContextModel:            cfg.Agents.Defaults.Model,
 		EmbeddingModel:          cfg.Memory.EmbeddingModel,
 		EmbeddingFallbackModels: append([]string(nil), cfg.Memory.EmbeddingFallbackModels...),
+		EmbeddingBackend:        cfg.Memory.EmbeddingBackend,
 		EmbeddingOpenAIToken: firstNonEmpty(
 			strings.TrimSpace(cfg.Providers.OpenAI.APIKey),
 			strings.TrimSpace(cfg.Providers.OpenAI.OAuthAccessToken),
@@ -442,9 +624,12 @@ TURN TRANSCRIPT:
 		PersonaExtractor:             personaExtractFn,
 		PersonaSyncApply:             cfg.Memory.PersonaSyncApply,
 		PersonaFileSync:              memory.NormalizePersonaFileSyncMode(cfg.Memory.PersonaFileSyncMode),
+		PersonaPrivacy:               memory.NormalizePersonaPrivacyMode(cfg.Memory.PersonaPrivacyMode),
 		PersonaPolicyMode:            cfg.Memory.PersonaPolicyMode,
 		PersonaMinConfidence:         cfg.Memory.PersonaMinConfidence,
+		PersonaExtractionTriggers:    cfg.Memory.PersonaExtractionTriggers,
 		CompactionSummaryTimeout:     time.Duration(cfg.Memory.CompactionSummaryTimeoutSeconds) * time.Second,
+		CompactionTimeout:            time.Duration(cfg.Memory.CompactionTimeoutMinutes) * time.Minute,
 		CompactionChunkChars:         cfg.Memory.CompactionChunkChars,
 		CompactionMaxTranscriptChars: cfg.Memory.CompactionMaxTranscriptChars,
 		CompactionPartialSkipChars:   cfg.Memory.CompactionPartialSkipChars,
@@ -455,6 +640,14 @@ TURN TRANSCRIPT:
 		FileMemoryWatchEnabled:       cfg.Memory.FileMemoryWatchEnabled,
 		FileMemoryWatchDebounce:      time.Duration(cfg.Memory.FileMemoryWatchDebounceMS) * time.Millisecond,
 		FileMemoryMaxFileBytes:       cfg.Memory.FileMemoryMaxFileBytes,
+		MaxConsolidationRate:         cfg.Memory.MaxConsolidationRate,
+		RestoreHistoryFromSnapshot:   cfg.Memory.RestoreHistoryFromSnapshot,
+		Backend:                      cfg.Memory.Backend,
+		PostgresDSN:                  strings.TrimSpace(cfg.Memory.Postgres.DSN),
+		PersonaExperiment:            personaExperiment,
+		AutoRepair:                   cfg.Memory.AutoRepair,
+		TopicClassifier:              topicClassifyFn,
+		DisableSessionTags:           topicMode == "off",
 	}, summarizeFn)
 	if err != nil {
 		return nil, fmt.Errorf("initialize memory service: %w", err)
@@ -469,6 +662,18 @@ TURN TRANSCRIPT:
 		temperature = 0
 	}
 
+	contextBuilder.SetTokenLimits(MessageTokenLimits{
+		System:  cfg.Agents.Defaults.MaxSystemTokens,
+		Recall:  cfg.Agents.Defaults.MaxRecallTokens,
+		History: cfg.Agents.Defaults.MaxHistoryTokens,
+		Skills:  cfg.Agents.Defaults.MaxSkillTokens,
+	}, func(section string, droppedTokens int) {
+		_ = memSvc.AddMetric(context.Background(), "agent.context.trimmed_tokens", float64(droppedTokens), map[string]string{
+			"section": section,
+		})
+	})
+	contextBuilder.SetAutoContext(cfg.Agents.Defaults.AutoContextFiles, cfg.Agents.Defaults.MaxAutoContextTokens)
+
 	agentLoop := &AgentLoop{
 		bus:                    msgBus,
 		provider:               provider,
@@ -481,6 +686,9 @@ TURN TRANSCRIPT:
 		contextWindow:          resolvedContextWindow,
 		contextPruningMode:     strings.TrimSpace(cfg.Memory.ContextPruningMode),
 		contextPruningKeepLast: cfg.Memory.ContextPruningKeepLastToolResults,
+		restrictToWorkspace:    cfg.Agents.Defaults.RestrictToWorkspace,
+		modelCacheTTL:          time.Duration(cfg.Providers.ModelCacheMinutes) * time.Minute,
+		modelCacheDir:          dataRoot,
 		loopDetectionCfg: tools.ToolLoopDetectionConfig{
 			Enabled:                     cfg.Memory.ToolLoopDetectionEnabled,
 			WarningsEnabled:             cfg.Memory.ToolLoopWarningsEnabled,
@@ -499,6 +707,7 @@ TURN TRANSCRIPT:
 		maxIterations:  cfg.Agents.Defaults.MaxToolIterations,
 		maxConcurrent:  cfg.Agents.Defaults.MaxConcurrentRuns,
 		memory:         memSvc,
+		memoryGate:     newMemoryAvailability(memSvc.IsAvailable, memoryAvailabilityProbeInterval),
 		state:          stateManager,
 		contextBuilder: contextBuilder,
 		tools:          toolsRegistry,
@@ -514,7 +723,24 @@ TURN TRANSCRIPT:
 		inboundDedupeTTL:   30 * time.Second,
 		sessionPromptHash:  map[string]string{},
 		personaSyncTimeout: time.Duration(cfg.Memory.PersonaSyncTimeoutMS) * time.Millisecond,
+		responseFilters:    compileResponseFilters(cfg.Agents.Defaults.ResponseFilters),
+		contentFilter:      compileContentFilter(cfg.Gateway.ContentFilter.BlocklistPatterns),
+		approvalTool:       tools.NewApprovalTool(time.Duration(cfg.Tools.Approval.TimeoutSeconds) * time.Second),
+		personaRewinds:     newPersonaRewindOffers(),
 	}
+	if cfg.Tools.Audit.Enabled {
+		agentLoop.toolAudit = tools.NewAuditLogger(dataRoot, cfg.Tools.Audit.MaxFileSizeMB)
+	}
+	agentLoop.approvalTool.SetSendCallback(func(channel, chatID, content string) error {
+		if msgBus == nil {
+			return fmt.Errorf("message bus not configured")
+		}
+		return msgBus.PublishOutbound(bus.OutboundMessage{
+			Channel: channel,
+			ChatID:  chatID,
+			Content: content,
+		})
+	})
 
 	sessionTool := tools.NewSessionTool(
 		agentLoop.memory,
@@ -526,6 +752,23 @@ TURN TRANSCRIPT:
 	if err := toolsRegistry.Register(sessionTool); err != nil {
 		return nil, fmt.Errorf("register session tool: %w", err)
 	}
+	memorySessionResolver := func(channel, chatID, userID string) (string, error) {
+		return resolveSessionKey("", agentLoop.workspaceID, channel, chatID, valueOr(userID, "local-user"))
+	}
+	fileWatcher := tools.NewFileWatcher(workspace, restrict, cfg.Tools.FileWatch.MaxWatchers, msgBus)
+	for _, tool := range []tools.Tool{
+		tools.NewMemorySearchTool(agentLoop.memory, memorySessionResolver),
+		tools.NewRememberTool(agentLoop.memory, memorySessionResolver),
+		tools.NewForgetTool(agentLoop.memory),
+		tools.NewWatchFileTool(fileWatcher),
+		tools.NewListWatchesTool(fileWatcher),
+		tools.NewUnwatchFileTool(fileWatcher),
+		agentLoop.approvalTool,
+	} {
+		if err := toolsRegistry.Register(tool); err != nil {
+			return nil, fmt.Errorf("register %s tool: %w", tool.Name(), err)
+		}
+	}
 	if agentLoop.maxIterations <= 0 {
 		agentLoop.maxIterations = 50
 	}
@@ -570,9 +813,15 @@ func (al *AgentLoop) Run(ctx context.Context) error {
 					"chat_id":    incoming.ChatID,
 					"sender_id":  incoming.SenderID,
 					"message_id": incoming.MessageID,
+					"trace_id":   incoming.TraceID,
 				})
 				continue
 			}
+			// A turn waiting on request_approval holds this chat's lane, so
+			// the reply has to be handed over before scheduling.
+			if al.approvalTool != nil && al.approvalTool.HandleReply(incoming.Channel, incoming.ChatID, incoming.SenderID, incoming.Content) {
+				continue
+			}
 			laneKey := al.resolveLaneKey(incoming)
 			runTask := func() {
 				roundState := tools.NewExecutionRoundState()
@@ -596,6 +845,7 @@ func (al *AgentLoop) Run(ctx context.Context) error {
 					"session_key": incoming.SessionKey,
 					"lane_key":    laneKey,
 					"error":       submitErr.Error(),
+					"trace_id":    incoming.TraceID,
 				})
 				if errors.Is(submitErr, ErrSchedulerLaneFull) {
 					if !constants.IsInternalChannel(incoming.Channel) {
@@ -612,6 +862,7 @@ func (al *AgentLoop) Run(ctx context.Context) error {
 						"session_key": incoming.SessionKey,
 						"lane_key":    laneKey,
 						"error":       retryErr.Error(),
+						"trace_id":    incoming.TraceID,
 					})
 					if !constants.IsInternalChannel(incoming.Channel) {
 						al.publishOutbound(bus.OutboundMessage{
@@ -693,6 +944,15 @@ func (al *AgentLoop) detectPromptBaselineChange(sessionKey, promptHash string) b
 	return prev != "" && prev != promptHash
 }
 
+// CompactAllSessions force-compacts recent sessions using the loop's context
+// window and returns how many were compacted.
+func (al *AgentLoop) CompactAllSessions(ctx context.Context) (int, error) {
+	if al.memory == nil {
+		return 0, fmt.Errorf("memory service is not configured")
+	}
+	return al.memory.ForceCompactAll(ctx, al.contextWindow)
+}
+
 func (al *AgentLoop) Stop() {
 	al.running.Store(false)
 	if al.scheduler != nil {
@@ -703,6 +963,7 @@ func (al *AgentLoop) Stop() {
 }
 
 func (al *AgentLoop) closeResources() {
+	al.memoryGate.Stop()
 	if al.tools != nil {
 		if err := al.tools.Close(); err != nil {
 			logger.WarnCF("agent", "Tool teardown reported errors", map[string]interface{}{"error": err.Error()})
@@ -746,18 +1007,78 @@ func (al *AgentLoop) SetChannelManager(cm *channels.Manager) {
 	al.channelManager = cm
 }
 
+// ToolRegistry returns the registry of tools available to the agent.
+func (al *AgentLoop) ToolRegistry() *tools.ToolRegistry {
+	return al.tools
+}
+
+// SetCronService lets commands such as /stats report scheduled jobs.
+func (al *AgentLoop) SetCronService(cs *cron.CronService) {
+	al.cronService = cs
+}
+
+// Model returns the model used for new LLM calls.
+func (al *AgentLoop) Model() string {
+	model, _ := al.modelSettings()
+	return model
+}
+
+// SetModelSettings changes the model and completion token cap used by turns
+// that start afterwards. An empty model or a non-positive maxTokens keeps the
+// current value.
+func (al *AgentLoop) SetModelSettings(model string, maxTokens int) {
+	al.settingsMu.Lock()
+	defer al.settingsMu.Unlock()
+	if model = strings.TrimSpace(model); model != "" {
+		al.model = model
+	}
+	if maxTokens > 0 {
+		al.completionMax = maxTokens
+	}
+}
+
+func (al *AgentLoop) modelSettings() (string, int) {
+	al.settingsMu.RLock()
+	defer al.settingsMu.RUnlock()
+	return al.model, al.completionMax
+}
+
 // RecordLastChannel records the last active channel for this workspace.
 // This uses the atomic state save mechanism to prevent data loss on crash.
 func (al *AgentLoop) RecordLastChannel(channel string) error {
 	return al.state.SetLastChannel(channel)
 }
 
+// LastChannel returns the last active "channel:chat_id" recorded for this
+// workspace, or "" if no user channel has been seen yet.
+func (al *AgentLoop) LastChannel() string {
+	return al.state.GetLastChannel()
+}
+
 // RecordLastChatID records the last active chat ID for this workspace.
 // This uses the atomic state save mechanism to prevent data loss on crash.
 func (al *AgentLoop) RecordLastChatID(chatID string) error {
 	return al.state.SetLastChatID(chatID)
 }
 
+// ListAuditByTrace returns memory audit entries recorded while handling the
+// request with the given trace ID.
+func (al *AgentLoop) ListAuditByTrace(ctx context.Context, traceID string) ([]memory.AuditEntry, error) {
+	if al.memory == nil {
+		return nil, memory.ErrAuditUnsupported
+	}
+	return al.memory.ListAuditByTrace(ctx, traceID, 0)
+}
+
+// ResolveSessionKey maps a session alias to its session key; keys and
+// unknown names are returned unchanged.
+func (al *AgentLoop) ResolveSessionKey(ctx context.Context, keyOrAlias string) (string, error) {
+	if al.memory == nil {
+		return strings.TrimSpace(keyOrAlias), nil
+	}
+	return al.memory.ResolveSessionKey(ctx, keyOrAlias)
+}
+
 func (al *AgentLoop) ProcessDirect(ctx context.Context, content, sessionKey string) (string, error) {
 	return al.ProcessDirectWithChannel(ctx, content, sessionKey, "cli", "direct")
 }
@@ -769,11 +1090,35 @@ func (al *AgentLoop) ProcessDirectWithChannel(ctx context.Context, content, sess
 		ChatID:     chatID,
 		Content:    content,
 		SessionKey: sessionKey,
+		ReceivedAt: time.Now(),
 	}
 
 	return al.processMessage(ctx, msg)
 }
 
+// ProcessDirectStream is ProcessDirect with onDelta receiving each LLM text
+// delta as soon as the provider emits it. Providers without streaming support
+// never call onDelta; the full response is still returned either way.
+func (al *AgentLoop) ProcessDirectStream(ctx context.Context, content, sessionKey string, onDelta func(string)) (string, error) {
+	msg := bus.InboundMessage{
+		Channel:    "cli",
+		SenderID:   "local-user",
+		ChatID:     "direct",
+		Content:    content,
+		SessionKey: sessionKey,
+		ReceivedAt: time.Now(),
+	}
+
+	return al.processMessageWithStream(ctx, msg, onDelta)
+}
+
+// SupportsStreaming reports whether the configured provider can stream text
+// deltas.
+func (al *AgentLoop) SupportsStreaming() bool {
+	streaming, ok := al.provider.(providers.StreamingLLMProvider)
+	return ok && streaming.SupportsStreaming()
+}
+
 // ProcessHeartbeat processes a heartbeat request without session history.
 // Each heartbeat is independent and doesn't accumulate context.
 func (al *AgentLoop) ProcessHeartbeat(ctx context.Context, content, channel, chatID string) (string, error) {
@@ -792,6 +1137,24 @@ func (al *AgentLoop) ProcessHeartbeat(ctx context.Context, content, channel, cha
 }
 
 func (al *AgentLoop) processMessage(ctx context.Context, msg bus.InboundMessage) (string, error) {
+	return al.processMessageWithStream(ctx, msg, nil)
+}
+
+func (al *AgentLoop) processMessageWithStream(ctx context.Context, msg bus.InboundMessage, onDelta func(string)) (response string, err error) {
+	if msg.TraceID == "" {
+		msg.TraceID = trace.FromContext(ctx)
+	}
+	if msg.TraceID == "" {
+		msg.TraceID = trace.NewID()
+	}
+	ctx = trace.WithID(ctx, msg.TraceID)
+	ctx, span := observability.StartSpan(ctx, "agent.process_message",
+		attribute.String("dotagent.trace_id", msg.TraceID),
+		attribute.String("dotagent.channel", msg.Channel),
+		attribute.String("dotagent.session_key", msg.SessionKey),
+	)
+	defer func() { observability.EndSpan(span, err) }()
+
 	// Add message preview to log (show full content for error messages)
 	var logContent string
 	if strings.Contains(msg.Content, "Error:") || strings.Contains(msg.Content, "error") {
@@ -800,23 +1163,45 @@ func (al *AgentLoop) processMessage(ctx context.Context, msg bus.InboundMessage)
 		logContent = utils.Truncate(msg.Content, 80)
 	}
 	logger.InfoCF("agent", fmt.Sprintf("Processing message from %s:%s: %s", msg.Channel, msg.SenderID, logContent),
-		map[string]interface{}{
+		trace.Fields(ctx, map[string]interface{}{
 			"channel":     msg.Channel,
 			"chat_id":     msg.ChatID,
 			"sender_id":   msg.SenderID,
 			"session_key": msg.SessionKey,
-		})
+		}))
 
 	// Route system messages to processSystemMessage
 	if msg.Channel == "system" {
 		return al.processSystemMessage(ctx, msg)
 	}
 
+	// Answer a pending offer to rewind history after a persona rollback
+	if response, handled := al.handlePersonaRewindReply(ctx, msg); handled {
+		return response, nil
+	}
+
 	// Check for commands
 	if response, handled := al.handleCommand(ctx, msg); handled {
 		return response, nil
 	}
 
+	// Refuse blocklisted messages without calling the model. Commands are
+	// answered above without the model, so the filter does not lock users out
+	// of them.
+	if pattern, blocked := al.contentFilter.match(msg.Content); blocked {
+		hash := ContentFilterPatternHash(pattern)
+		logger.InfoCF("agent", "Message blocked by content filter", map[string]interface{}{
+			"channel":      msg.Channel,
+			"session_key":  msg.SessionKey,
+			"pattern_hash": hash,
+		})
+		_ = al.memory.AddMetric(ctx, "agent.content_filter.blocked", 1, map[string]string{
+			"channel":      msg.Channel,
+			"pattern_hash": hash,
+		})
+		return ContentFilterResponse, nil
+	}
+
 	// Process as user message
 	return al.runAgentLoop(ctx, processOptions{
 		SessionKey:      msg.SessionKey,
@@ -824,10 +1209,12 @@ func (al *AgentLoop) processMessage(ctx context.Context, msg bus.InboundMessage)
 		ChatID:          msg.ChatID,
 		UserID:          msg.SenderID,
 		UserMessage:     msg.Content,
+		Images:          detectImagePaths(msg.Content, al.workspace, al.restrictToWorkspace),
 		DefaultResponse: "I've completed processing but have no response to give.",
 		EnableSummary:   true,
 		SendResponse:    false,
 		StreamResponse:  true,
+		StreamDelta:     onDelta,
 	})
 }
 
@@ -838,10 +1225,10 @@ func (al *AgentLoop) processSystemMessage(ctx context.Context, msg bus.InboundMe
 	}
 
 	logger.InfoCF("agent", "Processing system message",
-		map[string]interface{}{
+		trace.Fields(ctx, map[string]interface{}{
 			"sender_id": msg.SenderID,
 			"chat_id":   msg.ChatID,
-		})
+		}))
 
 	// Parse origin channel from chat_id (format: "channel:chat_id")
 	var originChannel string
@@ -865,22 +1252,22 @@ func (al *AgentLoop) processSystemMessage(ctx context.Context, msg bus.InboundMe
 	// Skip internal channels - only log, don't send to user
 	if constants.IsInternalChannel(originChannel) {
 		logger.InfoCF("agent", "Subagent completed (internal channel)",
-			map[string]interface{}{
+			trace.Fields(ctx, map[string]interface{}{
 				"sender_id":   msg.SenderID,
 				"content_len": len(content),
 				"channel":     originChannel,
-			})
+			}))
 		return "", nil
 	}
 
 	// Agent acts as dispatcher only - subagent handles user interaction via message tool
 	// Don't forward result here, subagent should use message tool to communicate with user
 	logger.InfoCF("agent", "Subagent completed",
-		map[string]interface{}{
+		trace.Fields(ctx, map[string]interface{}{
 			"sender_id":   msg.SenderID,
 			"channel":     originChannel,
 			"content_len": len(content),
-		})
+		}))
 
 	if strings.TrimSpace(content) != "" && !constants.IsInternalChannel(originChannel) {
 		al.publishOutbound(bus.OutboundMessage{
@@ -896,13 +1283,18 @@ func (al *AgentLoop) processSystemMessage(ctx context.Context, msg bus.InboundMe
 // runAgentLoop is the core message processing logic.
 // It handles context building, LLM calls, tool execution, and response handling.
 func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (string, error) {
+	if trace.FromContext(ctx) == "" {
+		ctx = trace.WithID(ctx, trace.NewID())
+	}
+	profile := sessionProfileFromContext(ctx)
+
 	// 0. Record last channel for heartbeat notifications (skip internal channels)
 	if opts.Channel != "" && opts.ChatID != "" {
 		// Don't record internal channels (cli, system, subagent)
 		if !constants.IsInternalChannel(opts.Channel) {
 			channelKey := fmt.Sprintf("%s:%s", opts.Channel, opts.ChatID)
 			if err := al.RecordLastChannel(channelKey); err != nil {
-				logger.WarnCF("agent", "Failed to record last channel: %v", map[string]interface{}{"error": err.Error()})
+				logger.WarnCF("agent", "Failed to record last channel: %v", trace.Fields(ctx, map[string]interface{}{"error": err.Error()}))
 			}
 		}
 	}
@@ -921,6 +1313,13 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 	} else if strings.TrimSpace(opts.SessionKey) == "" {
 		opts.SessionKey = "ephemeral:no_history"
 	}
+	// Degrade to a history-free turn rather than failing when the memory
+	// store is locked or unreachable.
+	memoryDegraded := false
+	if !opts.NoHistory && !al.memoryGate.Available(ctx) {
+		opts.NoHistory = true
+		memoryDegraded = true
+	}
 	if al.sessionLocks != nil {
 		unlock, lockErr := al.sessionLocks.Acquire(ctx, opts.SessionKey)
 		if lockErr != nil {
@@ -931,8 +1330,11 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 
 	// 1. Ensure memory session exists
 	if !opts.NoHistory {
-		if err := al.memory.EnsureSession(ctx, opts.SessionKey, opts.Channel, opts.ChatID, opts.UserID); err != nil {
-			logger.WarnCF("agent", "Failed to ensure memory session", map[string]interface{}{"error": err.Error(), "session_key": opts.SessionKey})
+		endPhase := profile.begin("session.ensure")
+		err := al.memory.EnsureSession(ctx, opts.SessionKey, opts.Channel, opts.ChatID, opts.UserID)
+		endPhase()
+		if err != nil {
+			logger.WarnCF("agent", "Failed to ensure memory session", trace.Fields(ctx, map[string]interface{}{"error": err.Error(), "session_key": opts.SessionKey}))
 		}
 	}
 
@@ -943,7 +1345,9 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 	recordedUserTurn := false
 	var syncPersonaReport memory.PersonaApplyReport
 	if !opts.NoHistory {
-		if _, _, err := al.memory.RecordUserTurn(ctx, memory.Event{
+		recordCtx, recordSpan := observability.StartSpan(ctx, "memory.record_turn", attribute.String("dotagent.role", "user"))
+		endPhase := profile.begin("memory.record_user_turn")
+		_, _, err := al.memory.RecordUserTurn(recordCtx, memory.Event{
 			SessionKey: opts.SessionKey,
 			TurnID:     turnID,
 			Seq:        seq,
@@ -954,12 +1358,15 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 				"chat_id": opts.ChatID,
 				"user_id": opts.UserID,
 			},
-		}, opts.UserID); err != nil {
-			logger.ErrorCF("agent", "Failed to record user turn", map[string]interface{}{
+		}, opts.UserID)
+		endPhase()
+		observability.EndSpan(recordSpan, err)
+		if err != nil {
+			logger.ErrorCF("agent", "Failed to record user turn", trace.Fields(ctx, map[string]interface{}{
 				"error":       err.Error(),
 				"session_key": opts.SessionKey,
 				"turn_id":     turnID,
-			})
+			}))
 		} else {
 			recordedUserTurn = true
 		}
@@ -984,11 +1391,11 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 					default:
 						syncPersonaOutcome = "llm_error"
 					}
-					logger.WarnCF("agent", "Synchronous persona apply failed", map[string]interface{}{
+					logger.WarnCF("agent", "Synchronous persona apply failed", trace.Fields(ctx, map[string]interface{}{
 						"error":       applyErr.Error(),
 						"session_key": opts.SessionKey,
 						"turn_id":     turnID,
-					})
+					}))
 				} else {
 					syncPersonaReport = report
 					if len(report.Decisions) > 0 {
@@ -1002,10 +1409,10 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 					"user_id":     opts.UserID,
 					"reason":      "no_directive_signal",
 				})
-				logger.DebugCF("agent", "Skipping synchronous persona apply for non-directive turn", map[string]interface{}{
+				logger.DebugCF("agent", "Skipping synchronous persona apply for non-directive turn", trace.Fields(ctx, map[string]interface{}{
 					"session_key": opts.SessionKey,
 					"turn_id":     turnID,
-				})
+				}))
 			}
 		}
 		_ = al.memory.AddMetric(ctx, "memory.persona.apply_sync.outcome", 1, map[string]string{
@@ -1022,9 +1429,13 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 	var recall string
 	continuityNotes := []string{}
 	if !opts.NoHistory {
-		promptCtx, err := al.memory.BuildPromptContext(ctx, opts.SessionKey, opts.UserID, opts.UserMessage, al.contextWindow)
+		buildCtx, buildSpan := observability.StartSpan(ctx, "memory.build_context")
+		endPhase := profile.begin("memory.build_context")
+		promptCtx, err := al.memory.BuildPromptContext(buildCtx, opts.SessionKey, opts.UserID, opts.UserMessage, al.contextWindow)
+		endPhase()
+		observability.EndSpan(buildSpan, err)
 		if err != nil {
-			logger.WarnCF("agent", "Failed to build memory prompt context", map[string]interface{}{"error": err.Error(), "session_key": opts.SessionKey})
+			logger.WarnCF("agent", "Failed to build memory prompt context", trace.Fields(ctx, map[string]interface{}{"error": err.Error(), "session_key": opts.SessionKey}))
 		} else {
 			history = toProviderMessages(promptCtx.History)
 			summary = promptCtx.Summary
@@ -1050,7 +1461,7 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 		summary,
 		recall,
 		currentUserPrompt,
-		nil,
+		opts.Images,
 		opts.Channel,
 		opts.ChatID,
 	)
@@ -1101,14 +1512,38 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 	if !opts.StreamResponse || opts.NoHistory || constants.IsInternalChannel(opts.Channel) || strings.TrimSpace(opts.ChatID) == "" {
 		streamForwarder = nil
 	}
+	// Keep delta delivery separate from the tool loop: the provider only sees
+	// one callback, which fans out to the bus forwarder and any direct sink.
+	var onDelta func(string)
+	if streamForwarder != nil {
+		onDelta = streamForwarder.Push
+	}
+	if opts.StreamDelta != nil && al.SupportsStreaming() {
+		forward := onDelta
+		onDelta = func(delta string) {
+			opts.StreamDelta(delta)
+			if forward != nil {
+				forward(delta)
+			}
+		}
+	}
 	overflowNoticeSent := false
+	llmCalls := 0
+	endToolPhase := noopProfileEnd
+	var onToolStart func(context.Context, providers.ToolCall, int)
+	if profile != nil {
+		onToolStart = func(_ context.Context, call providers.ToolCall, _ int) {
+			endToolPhase = profile.begin("tool " + call.Name)
+		}
+	}
 	toolLoopCtx := tools.WithToolExecutionActor(ctx, opts.UserID)
+	model, completionMax := al.modelSettings()
 	loopResult, err := tools.RunToolLoop(toolLoopCtx, tools.ToolLoopConfig{
 		Provider:               al.provider,
-		Model:                  al.model,
+		Model:                  model,
 		Tools:                  al.tools,
 		MaxIterations:          al.maxIterations,
-		LLMOptions:             map[string]any{"max_tokens": al.completionMax, "temperature": al.temperature},
+		LLMOptions:             map[string]any{"max_tokens": completionMax, "temperature": al.temperature},
 		ContextWindowTokens:    al.contextWindow,
 		Retry:                  retryCfg,
 		MaxOverflowCompactions: 3,
@@ -1116,12 +1551,14 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 		ContextPruningKeepLast: al.contextPruningKeepLast,
 		LoopDetection:          al.loopDetectionCfg,
 		CallLLM: func(callCtx context.Context, loopMessages []providers.Message, toolDefs []providers.ToolDefinition, model string, callOpts map[string]interface{}) (*providers.LLMResponse, error) {
+			llmCalls++
+			if profile != nil {
+				defer profile.begin(fmt.Sprintf("llm.call #%d", llmCalls))()
+			}
 			effectiveOpts := cloneLLMCallOptions(callOpts)
-			if streamForwarder != nil {
+			if onDelta != nil {
 				effectiveOpts["stream"] = true
-				effectiveOpts["stream_callback"] = func(delta string) {
-					streamForwarder.Push(delta)
-				}
+				effectiveOpts["stream_callback"] = onDelta
 			}
 			if stateful, ok := al.provider.(providers.StatefulLLMProvider); ok && !opts.NoHistory {
 				stateID := strings.TrimSpace(providerStateID)
@@ -1139,10 +1576,10 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 		},
 		RebuildContext: func(rebuildCtx context.Context) ([]providers.Message, error) {
 			if compactErr := al.memory.ForceCompact(rebuildCtx, opts.SessionKey, opts.UserID, al.contextWindow); compactErr != nil {
-				logger.WarnCF("agent", "Memory force compaction failed", map[string]interface{}{
+				logger.WarnCF("agent", "Memory force compaction failed", trace.Fields(ctx, map[string]interface{}{
 					"error":       compactErr.Error(),
 					"session_key": opts.SessionKey,
-				})
+				}))
 				return nil, compactErr
 			}
 			rebuilt, rebuildErr := al.memory.BuildPromptContext(rebuildCtx, opts.SessionKey, opts.UserID, opts.UserMessage, al.contextWindow)
@@ -1156,7 +1593,7 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 				rebuilt.Summary,
 				rebuilt.RecallPrompt,
 				currentUserPrompt,
-				nil,
+				opts.Images,
 				opts.Channel,
 				opts.ChatID,
 			)
@@ -1177,11 +1614,11 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 		},
 		Callbacks: tools.LoopCallbacks{
 			OnTransientRetry: func(_ context.Context, info providers.RetryInfo) {
-				logger.WarnCF("agent", "Transient LLM error detected, retrying", map[string]interface{}{
+				logger.WarnCF("agent", "Transient LLM error detected, retrying", trace.Fields(ctx, map[string]interface{}{
 					"error":      info.Err.Error(),
 					"retry":      info.Attempt,
 					"backoff_ms": info.Delay.Milliseconds(),
-				})
+				}))
 				if info.Attempt == 1 && !constants.IsInternalChannel(opts.Channel) && opts.SendResponse {
 					al.publishOutbound(bus.OutboundMessage{
 						Channel: opts.Channel,
@@ -1191,7 +1628,7 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 				}
 			},
 			OnOverflowStage: func(_ context.Context, stage string, attempt int, maxAttempts int, stageErr error) {
-				logger.WarnCF("agent", "Context overflow recovery stage", map[string]interface{}{
+				logger.WarnCF("agent", "Context overflow recovery stage", trace.Fields(ctx, map[string]interface{}{
 					"stage":          stage,
 					"attempt":        attempt,
 					"max_attempts":   maxAttempts,
@@ -1200,7 +1637,7 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 					"channel":        opts.Channel,
 					"chat_id":        opts.ChatID,
 					"context_tokens": al.contextWindow,
-				})
+				}))
 				if stage == "compact" && !overflowNoticeSent && !constants.IsInternalChannel(opts.Channel) && opts.SendResponse {
 					overflowNoticeSent = true
 					al.publishOutbound(bus.OutboundMessage{
@@ -1215,8 +1652,10 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 					return nil
 				}
 				if response != nil && response.Usage != nil && response.Usage.PromptTokens > 0 {
-					al.memory.ObservePromptUsage(writeCtx, al.model, promptEstimateTokens, response.Usage.PromptTokens)
+					al.memory.ObservePromptUsage(writeCtx, model, promptEstimateTokens, response.Usage.PromptTokens)
 				}
+				endPhase := profile.begin("memory.append_event")
+				defer endPhase()
 				if err := al.memory.AppendEvent(writeCtx, memory.Event{
 					ID:         "evt-" + uuid.NewString(),
 					SessionKey: opts.SessionKey,
@@ -1231,19 +1670,32 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 						"tool_call_count": fmt.Sprintf("%d", len(response.ToolCalls)),
 					},
 				}); err != nil {
-					logger.ErrorCF("agent", "Failed to append assistant tool-call event", map[string]interface{}{
+					logger.ErrorCF("agent", "Failed to append assistant tool-call event", trace.Fields(ctx, map[string]interface{}{
 						"error":       err.Error(),
 						"session_key": opts.SessionKey,
 						"turn_id":     turnID,
-					})
+					}))
 				}
 				seq++
 				return nil
 			},
-			OnToolResult: func(writeCtx context.Context, call providers.ToolCall, _ *tools.ToolResult, contentForLLM string, _ int) error {
+			OnToolStart: onToolStart,
+			OnToolResult: func(writeCtx context.Context, call providers.ToolCall, result *tools.ToolResult, contentForLLM string, _ int) error {
+				endToolPhase()
+				endToolPhase = noopProfileEnd
+				if al.toolAudit != nil {
+					if err := al.toolAudit.LogToolCall(opts.SessionKey, turnID, call.Name, call.Arguments, result); err != nil {
+						logger.WarnCF("agent", "Failed to write tool audit entry", trace.Fields(ctx, map[string]interface{}{
+							"error":     err.Error(),
+							"tool_name": call.Name,
+						}))
+					}
+				}
 				if opts.NoHistory {
 					return nil
 				}
+				endPhase := profile.begin("memory.append_event")
+				defer endPhase()
 				if err := al.memory.AppendEvent(writeCtx, memory.Event{
 					ID:         "evt-" + uuid.NewString(),
 					SessionKey: opts.SessionKey,
@@ -1259,12 +1711,12 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 						"user_id": opts.UserID,
 					},
 				}); err != nil {
-					logger.ErrorCF("agent", "Failed to append tool event", map[string]interface{}{
+					logger.ErrorCF("agent", "Failed to append tool event", trace.Fields(ctx, map[string]interface{}{
 						"error":       err.Error(),
 						"session_key": opts.SessionKey,
 						"turn_id":     turnID,
 						"tool_name":   call.Name,
-					})
+					}))
 				}
 				seq++
 				return nil
@@ -1278,10 +1730,10 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 					ChatID:  opts.ChatID,
 					Content: result.ForUser,
 				}, "tool_result")
-				logger.DebugCF("agent", "Sent tool result to user", map[string]interface{}{
+				logger.DebugCF("agent", "Sent tool result to user", trace.Fields(ctx, map[string]interface{}{
 					"tool":        call.Name,
 					"content_len": len(result.ForUser),
-				})
+				}))
 			},
 			OnLoopBreak: func(metricCtx context.Context, reason string, _ int) {
 				if opts.NoHistory {
@@ -1303,13 +1755,13 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 					"reason":      reason,
 					"level":       level,
 				})
-				logger.WarnCF("agent", "Tool loop warning", map[string]interface{}{
+				logger.WarnCF("agent", "Tool loop warning", trace.Fields(ctx, map[string]interface{}{
 					"session_key": opts.SessionKey,
 					"reason":      reason,
 					"level":       level,
 					"count":       count,
 					"message":     message,
-				})
+				}))
 			},
 		},
 	}, messages, opts.Channel, opts.ChatID)
@@ -1319,6 +1771,16 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 	finalContent := loopResult.Content
 	iteration := loopResult.Iterations
 
+	// A response that is nothing but filler is kept as-is rather than emptied.
+	if filtered, applied := applyResponseFilters(finalContent, al.responseFilters); len(applied) > 0 && strings.TrimSpace(filtered) != "" {
+		logger.InfoCF("agent", "Response filters applied", trace.Fields(ctx, map[string]interface{}{
+			"session_key":   opts.SessionKey,
+			"patterns":      applied,
+			"removed_chars": len(finalContent) - len(filtered),
+		}))
+		finalContent = filtered
+	}
+
 	// If last tool had ForUser content and we already sent it, we might not need to send final response
 	// This is controlled by the tool's Silent flag and ForUser content
 
@@ -1326,13 +1788,18 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 	if finalContent == "" {
 		finalContent = opts.DefaultResponse
 	}
+	if memoryDegraded {
+		finalContent = memoryUnavailableNotice + "\n\n" + finalContent
+	}
 	if streamForwarder != nil && streamForwarder.FlushFinal(finalContent) {
 		tools.MarkRoundMessageSent(ctx)
 	}
 
 	// 6. Save final assistant event and schedule memory maintenance
 	if !opts.NoHistory {
-		if err := al.memory.AppendEvent(ctx, memory.Event{
+		recordCtx, recordSpan := observability.StartSpan(ctx, "memory.record_turn", attribute.String("dotagent.role", "assistant"))
+		endPhase := profile.begin("memory.append_event")
+		err := al.memory.AppendEvent(recordCtx, memory.Event{
 			ID:         "evt-" + uuid.NewString(),
 			SessionKey: opts.SessionKey,
 			TurnID:     turnID,
@@ -1344,16 +1811,21 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 				"chat_id": opts.ChatID,
 				"user_id": opts.UserID,
 			},
-		}); err != nil {
-			logger.ErrorCF("agent", "Failed to append final assistant event", map[string]interface{}{
+		})
+		endPhase()
+		observability.EndSpan(recordSpan, err)
+		if err != nil {
+			logger.ErrorCF("agent", "Failed to append final assistant event", trace.Fields(ctx, map[string]interface{}{
 				"error":       err.Error(),
 				"session_key": opts.SessionKey,
 				"turn_id":     turnID,
-			})
+			}))
 		}
 		seq++
 		if opts.EnableSummary {
+			endPhase := profile.begin("memory.schedule_maintenance")
 			al.memory.ScheduleTurnMaintenance(ctx, opts.SessionKey, turnID, opts.UserID)
+			endPhase()
 		}
 	}
 
@@ -1380,11 +1852,11 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, opts processOptions) (str
 	// 8. Log response
 	responsePreview := utils.Truncate(finalContent, 120)
 	logger.InfoCF("agent", fmt.Sprintf("Response: %s", responsePreview),
-		map[string]interface{}{
+		trace.Fields(ctx, map[string]interface{}{
 			"session_key":  opts.SessionKey,
 			"iterations":   iteration,
 			"final_length": len(finalContent),
-		})
+		}))
 
 	return finalContent, nil
 }
@@ -1837,6 +2309,28 @@ func historyEndsWithUserMessage(history []providers.Message, content string) boo
 	return false
 }
 
+// listModels answers /list models from the provider's models endpoint,
+// falling back to the configured model when the provider cannot list them.
+func (al *AgentLoop) listModels(ctx context.Context) string {
+	const maxListedModels = 25
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	models, _, err := providers.ListModelsCached(reqCtx, al.provider, providers.ModelCachePath(al.modelCacheDir, al.providerName), al.modelCacheTTL)
+	if err != nil || len(models) == 0 {
+		return fmt.Sprintf("Model is configured via config/env. Provider: %s. Provider default: %s", al.providerName, al.provider.GetDefaultModel())
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d models available from %s (current: %s):\n", len(models), al.providerName, al.Model())
+	for i, m := range models {
+		if i == maxListedModels {
+			fmt.Fprintf(&sb, "... and %d more; run `dotagent providers list-models` for the full list", len(models)-maxListedModels)
+			break
+		}
+		sb.WriteString("- " + m.ID + "\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
 func (al *AgentLoop) handleCommand(ctx context.Context, msg bus.InboundMessage) (string, bool) {
 	content := strings.TrimSpace(msg.Content)
 	if !strings.HasPrefix(content, "/") {
@@ -1858,7 +2352,7 @@ func (al *AgentLoop) handleCommand(ctx context.Context, msg bus.InboundMessage)
 		}
 		switch args[0] {
 		case "model":
-			return fmt.Sprintf("Current model: %s", al.model), true
+			return fmt.Sprintf("Current model: %s", al.Model()), true
 		case "channel":
 			return fmt.Sprintf("Current channel: %s", msg.Channel), true
 		default:
@@ -1871,7 +2365,7 @@ func (al *AgentLoop) handleCommand(ctx context.Context, msg bus.InboundMessage)
 		}
 		switch args[0] {
 		case "models":
-			return fmt.Sprintf("Model is configured via config/env. Provider: %s. Provider default: %s", al.providerName, al.provider.GetDefaultModel()), true
+			return al.listModels(ctx), true
 		case "channels":
 			if al.channelManager == nil {
 				return "Channel manager not initialized", true
@@ -1894,8 +2388,8 @@ func (al *AgentLoop) handleCommand(ctx context.Context, msg bus.InboundMessage)
 
 		switch target {
 		case "model":
-			oldModel := al.model
-			al.model = value
+			oldModel := al.Model()
+			al.SetModelSettings(value, 0)
 			return fmt.Sprintf("Switched model from %s to %s", oldModel, value), true
 		case "channel":
 			// This changes the 'default' channel for some operations, or effectively redirects output?
@@ -1975,14 +2469,162 @@ func (al *AgentLoop) handleCommand(ctx context.Context, msg bus.InboundMessage)
 			}
 			return strings.Join(lines, "\n"), true
 		case "rollback":
-			if err := al.memory.RollbackPersona(ctx, userID); err != nil {
+			rev, err := al.memory.RollbackPersona(ctx, userID)
+			if err != nil {
 				return fmt.Sprintf("Failed to rollback persona: %v", err), true
 			}
+			if rev.ID == "" {
+				return "No persona revisions to roll back.", true
+			}
+			if al.personaRewinds.offer(resolvedSessionKey, rev) {
+				return fmt.Sprintf("Persona rolled back. Would you like to also archive events since turn %s? (yes/no)", rev.TurnID), true
+			}
 			return "Rolled back the most recent persona revision.", true
 		default:
 			return "Usage: /persona [show|revisions|candidates|rollback]", true
 		}
+
+	case "/stats":
+		userID := strings.TrimSpace(msg.SenderID)
+		if userID == "" {
+			userID = "local-user"
+		}
+		stats, err := al.memory.UserStats(ctx, userID)
+		if err != nil {
+			return fmt.Sprintf("Failed to load stats: %v", err), true
+		}
+		return formatUserStats(stats, al.countCronJobs(userID, msg.Channel, msg.ChatID)), true
+
+	case "/search":
+		query := strings.TrimSpace(strings.TrimPrefix(content, cmd))
+		if query == "" {
+			return "Usage: /search <query>", true
+		}
+		userID := strings.TrimSpace(msg.SenderID)
+		if userID == "" {
+			userID = "local-user"
+		}
+		matches, err := al.memory.SearchEvents(ctx, userID, query, memory.EventSearchOptions{Limit: 10})
+		if err != nil {
+			return fmt.Sprintf("Search failed: %v", err), true
+		}
+		return formatSearchResults(query, matches), true
+
+	case "/context":
+		return al.inspectContext(ctx, msg, args), true
+
+	case "/ping":
+		var latency time.Duration
+		if !msg.ReceivedAt.IsZero() {
+			latency = time.Since(msg.ReceivedAt)
+		}
+		return fmt.Sprintf("pong in %dms", latency.Milliseconds()), true
+
+	case "/feedback":
+		usage := fmt.Sprintf("Usage: /feedback <%d-%d|up|down> [comment]", memory.MinFeedbackRating, memory.MaxFeedbackRating)
+		if len(args) < 1 {
+			return usage, true
+		}
+		rating, ok := parseFeedbackRating(args[0])
+		if !ok {
+			return usage, true
+		}
+		userID := strings.TrimSpace(msg.SenderID)
+		if userID == "" {
+			userID = "local-user"
+		}
+		comment := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(content, cmd)), args[0]))
+		fb, err := al.memory.RecordFeedback(ctx, al.resolveCommandSessionKey(msg, userID), userID, rating, comment)
+		if err != nil {
+			return fmt.Sprintf("Failed to record feedback: %v", err), true
+		}
+		if fb.TurnID == "" {
+			return fmt.Sprintf("Thanks! Recorded a %d/%d rating for this session.", rating, memory.MaxFeedbackRating), true
+		}
+		return fmt.Sprintf("Thanks! Recorded a %d/%d rating for the last response.", rating, memory.MaxFeedbackRating), true
 	}
 
 	return "", false
 }
+
+// parseFeedbackRating accepts a numeric rating or up/down, which map to the
+// top and bottom of the scale.
+func parseFeedbackRating(raw string) (int, bool) {
+	switch strings.ToLower(raw) {
+	case "up":
+		return memory.MaxFeedbackRating, true
+	case "down":
+		return memory.MinFeedbackRating, true
+	}
+	rating, err := strconv.Atoi(raw)
+	if err != nil || rating < memory.MinFeedbackRating || rating > memory.MaxFeedbackRating {
+		return 0, false
+	}
+	return rating, true
+}
+
+func formatSearchResults(query string, matches []memory.EventMatch) string {
+	if len(matches) == 0 {
+		return fmt.Sprintf("No messages match %q.", query)
+	}
+	lines := []string{fmt.Sprintf("Messages matching %q:", query)}
+	for _, group := range memory.GroupEventMatches(matches) {
+		lines = append(lines, "", group.SessionKey)
+		for _, m := range group.Matches {
+			at := time.UnixMilli(m.CreatedAtMS).Format("2006-01-02 15:04")
+			lines = append(lines, fmt.Sprintf("- %s %s: %s", at, m.Role, m.Snippet))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// countCronJobs counts enabled cron jobs created by userID or delivering to
+// the given chat. It returns -1 when no cron service is attached.
+func (al *AgentLoop) countCronJobs(userID, channel, chatID string) int {
+	if al.cronService == nil {
+		return -1
+	}
+	count := 0
+	for _, job := range al.cronService.ListJobs(false) {
+		if job.Payload.Actor == userID || (job.Payload.Channel == channel && job.Payload.To == chatID) {
+			count++
+		}
+	}
+	return count
+}
+
+func formatUserStats(stats memory.UserStats, cronJobs int) string {
+	rows := [][2]string{
+		{"Sessions", strconv.Itoa(stats.Sessions)},
+		{"Session messages", strconv.Itoa(stats.Messages)},
+		{"Total events", strconv.Itoa(stats.Events)},
+		{"Memory items", strconv.Itoa(stats.MemoryItems())},
+	}
+	kinds := make([]string, 0, len(stats.MemoryItemsByKind))
+	for kind := range stats.MemoryItemsByKind {
+		kinds = append(kinds, string(kind))
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		rows = append(rows, [2]string{"  " + kind, strconv.Itoa(stats.MemoryItemsByKind[memory.MemoryItemKind(kind)])})
+	}
+	rows = append(rows, [2]string{"Persona revisions", strconv.Itoa(stats.PersonaRevisions)})
+	cronValue := "n/a"
+	if cronJobs >= 0 {
+		cronValue = strconv.Itoa(cronJobs)
+	}
+	rows = append(rows, [2]string{"Scheduled jobs", cronValue})
+
+	width := 0
+	for _, row := range rows {
+		if len(row[0]) > width {
+			width = len(row[0])
+		}
+	}
+	lines := []string{fmt.Sprintf("Stats for %s:", stats.UserID), "```"}
+	for _, row := range rows {
+		lines = append(lines, fmt.Sprintf("%-*s  %s", width, row[0], row[1]))
+	}
+	lines = append(lines, "```")
+	return strings.Join(lines, "\n")
+}
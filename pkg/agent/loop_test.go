@@ -11,6 +11,7 @@ import (
 
 	"github.com/dotsetgreg/dotagent/pkg/bus"
 	"github.com/dotsetgreg/dotagent/pkg/config"
+	"github.com/dotsetgreg/dotagent/pkg/memory"
 	"github.com/dotsetgreg/dotagent/pkg/providers"
 	"github.com/dotsetgreg/dotagent/pkg/tools"
 )
@@ -409,6 +410,24 @@ func (m *optionCaptureProvider) GetDefaultModel() string {
 	return "mock-model"
 }
 
+type streamingMockProvider struct{}
+
+func (m *streamingMockProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	if cb, ok := opts["stream_callback"].(func(string)); ok {
+		cb("Hello, ")
+		cb("world")
+	}
+	return &providers.LLMResponse{Content: "Hello, world"}, nil
+}
+
+func (m *streamingMockProvider) SupportsStreaming() bool {
+	return true
+}
+
+func (m *streamingMockProvider) GetDefaultModel() string {
+	return "mock-model"
+}
+
 // mockCustomTool is a simple mock tool for registration testing
 type mockCustomTool struct{}
 
@@ -882,6 +901,44 @@ func TestAgentLoop_AlwaysExposesLocalTools(t *testing.T) {
 	}
 }
 
+func TestAgentLoop_ReplaysScriptedToolCalls(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	provider := &gateScriptProvider{responses: []*providers.LLMResponse{
+		{ToolCalls: []providers.ToolCall{{ID: "call-1", Name: "list_dir", Arguments: map[string]interface{}{"path": "notes"}}}},
+		{ToolCalls: []providers.ToolCall{{ID: "call-2", Name: "read_file", Arguments: map[string]interface{}{"path": "notes/todo.md"}}}},
+		{Content: "You have one TODO: ship the release."},
+	}}
+	al := mustNewAgentLoop(t, cfg, bus.NewMessageBus(), provider)
+	mock := tools.NewMockToolRegistry([]tools.MockInteraction{
+		{Name: "list_dir", Args: map[string]interface{}{"path": "notes"}, Result: tools.NewToolResult("todo.md")},
+		{Name: "read_file", Args: map[string]interface{}{"path": "notes/todo.md"}, Result: tools.NewToolResult("- ship the release")},
+	})
+	al.tools = mock.ToolRegistry
+
+	response, err := al.ProcessDirect(context.Background(), "what is on my todo list?", "cli:mock-tools")
+	if err != nil {
+		t.Fatalf("process failed: %v", err)
+	}
+	if response != "You have one TODO: ship the release." {
+		t.Fatalf("unexpected response %q", response)
+	}
+	if err := mock.Verify(); err != nil {
+		t.Fatalf("tool calls did not match the script: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Agents.Defaults.Workspace, "notes")); !os.IsNotExist(err) {
+		t.Fatalf("expected no filesystem access, stat err=%v", err)
+	}
+}
+
 func TestAgentLoop_AppliesPersonaSyncBeforeResponseGeneration(t *testing.T) {
 	tmpDir := t.TempDir()
 	cfg := &config.Config{
@@ -1099,6 +1156,49 @@ func TestAgentLoop_UsesConfiguredLLMCallOptions(t *testing.T) {
 	}
 }
 
+func TestAgentLoop_ProcessDirectStream(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         2048,
+				MaxToolIterations: 2,
+			},
+		},
+	}
+
+	al := mustNewAgentLoop(t, cfg, bus.NewMessageBus(), &streamingMockProvider{})
+	var deltas []string
+	response, err := al.ProcessDirectStream(context.Background(), "Hi", "cli:stream", func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("process direct stream: %v", err)
+	}
+	if response != "Hello, world" {
+		t.Fatalf("unexpected response %q", response)
+	}
+	if strings.Join(deltas, "|") != "Hello, |world" {
+		t.Fatalf("expected unbuffered deltas, got %q", deltas)
+	}
+
+	// Providers without streaming support must not receive a callback.
+	capture := &optionCaptureProvider{}
+	al = mustNewAgentLoop(t, cfg, bus.NewMessageBus(), capture)
+	if al.SupportsStreaming() {
+		t.Fatalf("expected non-streaming provider to be detected")
+	}
+	if _, err := al.ProcessDirectStream(context.Background(), "Hi", "cli:stream", func(string) {
+		t.Fatalf("unexpected delta from non-streaming provider")
+	}); err != nil {
+		t.Fatalf("process direct stream fallback: %v", err)
+	}
+	if _, ok := capture.lastOpts["stream_callback"]; ok {
+		t.Fatalf("expected no stream_callback for non-streaming provider")
+	}
+}
+
 func TestInboundDedupeKey_UsesMessageIDWhenPresent(t *testing.T) {
 	msg := bus.InboundMessage{
 		Channel:   "discord",
@@ -1164,3 +1264,153 @@ func TestAgentLoop_DetectPromptBaselineChange(t *testing.T) {
 		t.Fatalf("hash change should be detected")
 	}
 }
+
+func TestFormatUserStats(t *testing.T) {
+	out := formatUserStats(memory.UserStats{
+		UserID:   "u1",
+		Sessions: 2,
+		Messages: 14,
+		Events:   20,
+		MemoryItemsByKind: map[memory.MemoryItemKind]int{
+			memory.MemoryUserPreference: 3,
+			memory.MemorySemanticFact:   1,
+		},
+		PersonaRevisions: 5,
+	}, -1)
+	for _, want := range []string{
+		"Stats for u1:",
+		"Session messages   14",
+		"Memory items       4",
+		"  semantic_fact    1",
+		"  user_preference  3",
+		"Persona revisions  5",
+		"Scheduled jobs     n/a",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected stats output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "semantic_fact") > strings.Index(out, "user_preference") {
+		t.Fatalf("expected memory kinds sorted, got:\n%s", out)
+	}
+}
+
+func TestFormatSearchResults(t *testing.T) {
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.Local)
+	out := formatSearchResults("docker", []memory.EventMatch{
+		{SessionKey: "discord:infra", Role: "user", Snippet: "prune old **Docker** images", CreatedAtMS: at.UnixMilli()},
+		{SessionKey: "telegram:home", Role: "assistant", Snippet: "**docker** ps", CreatedAtMS: at.UnixMilli()},
+		{SessionKey: "discord:infra", Role: "assistant", Snippet: "run **docker** image prune", CreatedAtMS: at.UnixMilli()},
+	})
+	want := strings.Join([]string{
+		`Messages matching "docker":`,
+		"",
+		"discord:infra",
+		"- 2026-03-01 09:30 user: prune old **Docker** images",
+		"- 2026-03-01 09:30 assistant: run **docker** image prune",
+		"",
+		"telegram:home",
+		"- 2026-03-01 09:30 assistant: **docker** ps",
+	}, "\n")
+	if out != want {
+		t.Fatalf("unexpected search output:\n%s", out)
+	}
+	if got := formatSearchResults("nothing", nil); got != `No messages match "nothing".` {
+		t.Fatalf("unexpected empty output: %q", got)
+	}
+}
+
+func TestHandleCommand_Context(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	al := mustNewAgentLoop(t, cfg, bus.NewMessageBus(), &mockProvider{})
+	msg := bus.InboundMessage{Channel: "cli", ChatID: "direct", SenderID: "u1", SessionKey: "cli:direct"}
+
+	msg.Content = "/context what is next?"
+	out, handled := al.handleCommand(context.Background(), msg)
+	if !handled {
+		t.Fatal("expected /context to be handled")
+	}
+	for _, want := range []string{"````", "=== System prompt (~", "=== History (0 messages)", "=== Next user message (~", "what is next?", "=== Tools (", "Total: ~"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected /context output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	msg.Content = "/context --compact"
+	out, _ = al.handleCommand(context.Background(), msg)
+	if strings.Contains(out, "````") || !strings.Contains(out, "- System prompt: ~") || strings.Contains(out, "Next user message") {
+		t.Fatalf("unexpected compact output:\n%s", out)
+	}
+}
+
+func TestHandleCommand_Feedback(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Paths.Data = t.TempDir()
+	al := mustNewAgentLoop(t, cfg, bus.NewMessageBus(), &mockProvider{})
+	msg := bus.InboundMessage{Channel: "cli", ChatID: "direct", SenderID: "u1", SessionKey: "cli:direct"}
+
+	for _, bad := range []string{"/feedback", "/feedback 9", "/feedback great"} {
+		msg.Content = bad
+		if out, handled := al.handleCommand(context.Background(), msg); !handled || !strings.HasPrefix(out, "Usage: /feedback") {
+			t.Fatalf("expected usage for %q, got %q", bad, out)
+		}
+	}
+
+	msg.Content = "/feedback down missed the point"
+	out, handled := al.handleCommand(context.Background(), msg)
+	if !handled || !strings.Contains(out, "1/5") {
+		t.Fatalf("unexpected /feedback reply %q", out)
+	}
+	rows, err := al.memory.ListFeedback(context.Background(), 10)
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("expected one feedback row, got %+v (err=%v)", rows, err)
+	}
+	if rows[0].Rating != 1 || rows[0].Comment != "missed the point" || rows[0].UserID != "u1" {
+		t.Fatalf("unexpected feedback row %+v", rows[0])
+	}
+}
+
+func TestHandleCommand_Ping(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	provider := &gateScriptProvider{responses: []*providers.LLMResponse{{Content: "model reply"}}}
+	al := mustNewAgentLoop(t, cfg, bus.NewMessageBus(), provider)
+	msg := bus.InboundMessage{
+		Channel:    "discord",
+		ChatID:     "c1",
+		SenderID:   "u1",
+		Content:    "/ping",
+		ReceivedAt: time.Now().Add(-25 * time.Millisecond),
+	}
+
+	out, err := al.processMessage(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("process /ping: %v", err)
+	}
+	var ms int64
+	if _, err := fmt.Sscanf(out, "pong in %dms", &ms); err != nil {
+		t.Fatalf("unexpected /ping reply %q", out)
+	}
+	if ms < 25 {
+		t.Fatalf("expected latency of at least 25ms, got %q", out)
+	}
+	if provider.idx != 0 {
+		t.Fatalf("expected /ping not to call the provider")
+	}
+}
+
+func TestFormatContextReport_WarnsNearLimit(t *testing.T) {
+	sections := []contextSection{
+		{Label: "System prompt", Tokens: 700, Content: "sys"},
+		{Label: "History (2 messages)", Tokens: 150, Content: "[user] hi"},
+	}
+	out := formatContextReport(sections, 1000, true)
+	if !strings.Contains(out, "Total: ~850 of 1000 tokens (85%)") || !strings.Contains(out, "Warning:") {
+		t.Fatalf("expected near-limit warning, got:\n%s", out)
+	}
+	if out := formatContextReport(sections, 10000, false); strings.Contains(out, "Warning:") || !strings.Contains(out, "[user] hi") {
+		t.Fatalf("unexpected full output:\n%s", out)
+	}
+}
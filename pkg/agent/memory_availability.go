@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/logger"
+)
+
+// memoryAvailabilityProbeInterval is how often a degraded loop re-checks the
+// memory store in the background.
+const memoryAvailabilityProbeInterval = 5 * time.Second
+
+const memoryUnavailableNotice = "⚠️ Memory is temporarily unavailable, so this reply doesn't use or save our conversation history."
+
+// memoryAvailability gates per-turn memory use. The first failed check marks
+// memory degraded and starts a background probe; degraded turns skip the check
+// entirely until the probe sees the store recover.
+type memoryAvailability struct {
+	check    func(context.Context) bool
+	interval time.Duration
+
+	mu       sync.Mutex
+	degraded bool
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+func newMemoryAvailability(check func(context.Context) bool, interval time.Duration) *memoryAvailability {
+	if interval <= 0 {
+		interval = memoryAvailabilityProbeInterval
+	}
+	return &memoryAvailability{
+		check:    check,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Available reports whether the current turn may use memory.
+func (m *memoryAvailability) Available(ctx context.Context) bool {
+	if m == nil || m.check == nil {
+		return true
+	}
+	m.mu.Lock()
+	degraded := m.degraded
+	m.mu.Unlock()
+	if degraded {
+		return false
+	}
+	if m.check(ctx) {
+		return true
+	}
+	if ctx.Err() != nil {
+		// The caller gave up; that says nothing about the store.
+		return true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.degraded {
+		m.degraded = true
+		logger.WarnCF("agent", "Memory store unavailable; continuing without history", map[string]interface{}{
+			"probe_interval_ms": m.interval.Milliseconds(),
+		})
+		go m.probe()
+	}
+	return false
+}
+
+func (m *memoryAvailability) probe() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			if !m.check(context.Background()) {
+				continue
+			}
+			m.mu.Lock()
+			m.degraded = false
+			m.mu.Unlock()
+			logger.InfoCF("agent", "Memory store available again; restoring history", nil)
+			return
+		}
+	}
+}
+
+func (m *memoryAvailability) Stop() {
+	if m == nil {
+		return
+	}
+	m.stopOnce.Do(func() {
+		close(m.stopCh)
+	})
+}
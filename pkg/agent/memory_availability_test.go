@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+	"github.com/dotsetgreg/dotagent/pkg/config"
+)
+
+func TestMemoryAvailability_DegradesAndRecovers(t *testing.T) {
+	var healthy atomic.Bool
+	var checks atomic.Int32
+	gate := newMemoryAvailability(func(context.Context) bool {
+		checks.Add(1)
+		return healthy.Load()
+	}, 10*time.Millisecond)
+	defer gate.Stop()
+
+	if gate.Available(context.Background()) {
+		t.Fatalf("expected unavailable store to degrade the gate")
+	}
+	before := checks.Load()
+	if gate.Available(context.Background()) {
+		t.Fatalf("expected gate to stay degraded until the probe succeeds")
+	}
+	if checks.Load() > before+1 {
+		t.Fatalf("expected degraded turns to skip the inline check")
+	}
+
+	healthy.Store(true)
+	deadline := time.Now().Add(2 * time.Second)
+	for !gate.Available(context.Background()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected background probe to restore availability")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAgentLoop_ContinuesWithoutMemoryWhenUnavailable(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         2048,
+				MaxToolIterations: 2,
+			},
+		},
+	}
+	provider := &historyCaptureProvider{}
+	al := mustNewAgentLoop(t, cfg, bus.NewMessageBus(), provider)
+	al.memoryGate.Stop()
+	al.memoryGate = newMemoryAvailability(func(context.Context) bool { return false }, time.Hour)
+
+	response, err := al.ProcessDirectWithChannel(context.Background(), "hello while locked", "", "cli", "direct")
+	if err != nil {
+		t.Fatalf("process direct: %v", err)
+	}
+	if !strings.HasPrefix(response, memoryUnavailableNotice) {
+		t.Fatalf("expected memory notice prefix, got %q", response)
+	}
+	sessions, err := al.memory.ListSessions(context.Background(), "", 10)
+	if err != nil {
+		t.Fatalf("list sessions: %v", err)
+	}
+	if len(sessions) != 0 {
+		t.Fatalf("expected degraded turn to skip persistence, got %d sessions", len(sessions))
+	}
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+)
+
+// personaRewindOfferTTL bounds how long a rollback's rewind offer waits for a
+// yes/no reply before later messages are treated normally again.
+const personaRewindOfferTTL = 10 * time.Minute
+
+// personaRewindOffer is a pending offer, made after /persona rollback, to
+// archive the session turns since the one that produced the revision.
+type personaRewindOffer struct {
+	sessionKey string
+	turnID     string
+	expiresAt  time.Time
+}
+
+// personaRewindOffers holds at most one offer per chat session.
+type personaRewindOffers struct {
+	mu     sync.Mutex
+	offers map[string]personaRewindOffer
+}
+
+func newPersonaRewindOffers() *personaRewindOffers {
+	return &personaRewindOffers{offers: map[string]personaRewindOffer{}}
+}
+
+func (o *personaRewindOffers) offer(chatSessionKey string, rev memory.PersonaRevision) bool {
+	if strings.TrimSpace(rev.TurnID) == "" || strings.TrimSpace(rev.SessionKey) == "" {
+		return false
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.offers[chatSessionKey] = personaRewindOffer{
+		sessionKey: rev.SessionKey,
+		turnID:     rev.TurnID,
+		expiresAt:  time.Now().Add(personaRewindOfferTTL),
+	}
+	return true
+}
+
+// take removes and returns the live offer for chatSessionKey, if any.
+func (o *personaRewindOffers) take(chatSessionKey string) (personaRewindOffer, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	offer, ok := o.offers[chatSessionKey]
+	if !ok {
+		return personaRewindOffer{}, false
+	}
+	delete(o.offers, chatSessionKey)
+	if time.Now().After(offer.expiresAt) {
+		return personaRewindOffer{}, false
+	}
+	return offer, true
+}
+
+// handlePersonaRewindReply answers a pending rewind offer. Any message
+// resolves the offer; only a yes or no reply is consumed, anything else is
+// processed as a normal message.
+func (al *AgentLoop) handlePersonaRewindReply(ctx context.Context, msg bus.InboundMessage) (string, bool) {
+	if al.personaRewinds == nil {
+		return "", false
+	}
+	userID := valueOr(strings.TrimSpace(msg.SenderID), "local-user")
+	offer, ok := al.personaRewinds.take(al.resolveCommandSessionKey(msg, userID))
+	if !ok {
+		return "", false
+	}
+	switch strings.ToLower(strings.Trim(strings.TrimSpace(msg.Content), ".!")) {
+	case "yes", "y":
+		archived, err := al.memory.ArchiveEventsSinceTurn(ctx, offer.sessionKey, offer.turnID)
+		if err != nil {
+			return fmt.Sprintf("Failed to archive session history: %v", err), true
+		}
+		return fmt.Sprintf("Archived %d event(s) since turn %s.", archived, offer.turnID), true
+	case "no", "n":
+		return "Kept the session history.", true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+	"github.com/dotsetgreg/dotagent/pkg/config"
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+)
+
+func TestHandlePersonaRewindReply(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Paths.Data = t.TempDir()
+	al := mustNewAgentLoop(t, cfg, bus.NewMessageBus(), &mockProvider{})
+	ctx := context.Background()
+	msg := bus.InboundMessage{Channel: "cli", ChatID: "direct", SenderID: "u1", SessionKey: "cli:direct"}
+	sessionKey := al.resolveCommandSessionKey(msg, "u1")
+
+	for i, turn := range []string{"turn-1", "turn-2", "turn-3"} {
+		if err := al.memory.AppendEvent(ctx, memory.Event{SessionKey: sessionKey, TurnID: turn, Seq: i + 1, Role: "user", Content: "message " + turn}); err != nil {
+			t.Fatalf("append event: %v", err)
+		}
+	}
+	rev := memory.PersonaRevision{ID: "rev-1", SessionKey: sessionKey, TurnID: "turn-2"}
+
+	// Unrelated replies drop the offer and are processed normally.
+	al.personaRewinds.offer(sessionKey, rev)
+	msg.Content = "what's the weather?"
+	if _, handled := al.handlePersonaRewindReply(ctx, msg); handled {
+		t.Fatal("expected non yes/no reply to pass through")
+	}
+	msg.Content = "yes"
+	if _, handled := al.handlePersonaRewindReply(ctx, msg); handled {
+		t.Fatal("expected the offer to be gone after an unrelated reply")
+	}
+
+	al.personaRewinds.offer(sessionKey, rev)
+	out, handled := al.handlePersonaRewindReply(ctx, msg)
+	if !handled || !strings.Contains(out, "Archived 2 event(s) since turn turn-2") {
+		t.Fatalf("unexpected rewind reply %q (handled=%v)", out, handled)
+	}
+	events, err := al.memory.ListSessionEvents(ctx, sessionKey, 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 1 || events[0].TurnID != "turn-1" {
+		t.Fatalf("expected only turn-1 to remain, got %+v", events)
+	}
+}
@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+	"github.com/dotsetgreg/dotagent/pkg/providers"
+)
+
+// ReplayPair is one user turn from a stored session together with the reply
+// the agent originally gave and the conversation that preceded it.
+type ReplayPair struct {
+	History  []providers.Message
+	Prompt   string
+	Original string
+}
+
+// ReplayRecord is one JSONL line of a replay dataset.
+type ReplayRecord struct {
+	Prompt      string `json:"prompt"`
+	Original    string `json:"original"`
+	Alternative string `json:"alternative"`
+}
+
+type ReplayOptions struct {
+	Model        string
+	SystemPrompt string
+	LLMOptions   map[string]interface{}
+	// OnPair is called after each pair is answered; err is the provider
+	// error for that pair, if any. May be nil.
+	OnPair func(index, total int, err error)
+}
+
+// BuildReplayPairs turns stored session events (oldest first) into
+// user/assistant pairs. The original reply is the last non-empty assistant
+// message before the next user message; tool traffic is dropped. History
+// accumulates earlier prompts and original replies, so each pair sees what
+// the original model saw.
+func BuildReplayPairs(events []memory.Event) []ReplayPair {
+	var (
+		pairs   []ReplayPair
+		history []providers.Message
+		current *ReplayPair
+	)
+	flush := func() {
+		if current == nil {
+			return
+		}
+		if current.Original != "" {
+			pairs = append(pairs, *current)
+			history = append(history,
+				providers.Message{Role: "user", Content: current.Prompt},
+				providers.Message{Role: "assistant", Content: current.Original},
+			)
+		}
+		current = nil
+	}
+	for _, ev := range events {
+		content := strings.TrimSpace(ev.Content)
+		switch ev.Role {
+		case "user":
+			flush()
+			if content == "" {
+				continue
+			}
+			current = &ReplayPair{
+				History: append([]providers.Message(nil), history...),
+				Prompt:  content,
+			}
+		case "assistant":
+			if current != nil && content != "" {
+				current.Original = content
+			}
+		}
+	}
+	flush()
+	return pairs
+}
+
+// GenerateReplay sends every pair to provider with its history and writes one
+// ReplayRecord per answered pair to w. Pairs the provider fails on are
+// skipped and reported through OnPair; only write errors and context
+// cancellation stop the run. It returns the number of records written.
+func GenerateReplay(ctx context.Context, provider providers.LLMProvider, pairs []ReplayPair, opts ReplayOptions, w io.Writer) (int, error) {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	written := 0
+	for i, pair := range pairs {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		messages := make([]providers.Message, 0, len(pair.History)+2)
+		if strings.TrimSpace(opts.SystemPrompt) != "" {
+			messages = append(messages, providers.Message{Role: "system", Content: opts.SystemPrompt})
+		}
+		messages = append(messages, pair.History...)
+		messages = append(messages, providers.Message{Role: "user", Content: pair.Prompt})
+
+		resp, err := provider.Chat(ctx, messages, nil, opts.Model, opts.LLMOptions)
+		if err == nil && strings.TrimSpace(resp.Content) == "" {
+			err = fmt.Errorf("empty response")
+		}
+		if err == nil {
+			err = enc.Encode(ReplayRecord{
+				Prompt:      pair.Prompt,
+				Original:    pair.Original,
+				Alternative: strings.TrimSpace(resp.Content),
+			})
+			if err != nil {
+				return written, fmt.Errorf("write replay record: %w", err)
+			}
+			written++
+		}
+		if opts.OnPair != nil {
+			opts.OnPair(i+1, len(pairs), err)
+		}
+	}
+	return written, nil
+}
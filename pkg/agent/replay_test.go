@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+	"github.com/dotsetgreg/dotagent/pkg/providers"
+)
+
+type replayRecordingProvider struct {
+	calls [][]providers.Message
+	model string
+}
+
+func (p *replayRecordingProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, options map[string]interface{}) (*providers.LLMResponse, error) {
+	p.calls = append(p.calls, messages)
+	p.model = model
+	last := messages[len(messages)-1].Content
+	if last == "fail" {
+		return nil, errors.New("boom")
+	}
+	return &providers.LLMResponse{Content: "alt: " + last}, nil
+}
+
+func (p *replayRecordingProvider) GetDefaultModel() string { return "default" }
+
+func TestBuildReplayPairs(t *testing.T) {
+	events := []memory.Event{
+		{Role: "user", Content: "hi"},
+		{Role: "assistant", Content: ""},
+		{Role: "tool", Content: "tool output"},
+		{Role: "assistant", Content: "hello!"},
+		{Role: "user", Content: "unanswered"},
+		{Role: "user", Content: "what's 2+2?"},
+		{Role: "assistant", Content: "4"},
+	}
+	pairs := BuildReplayPairs(events)
+	if len(pairs) != 2 {
+		t.Fatalf("expected 2 pairs, got %#v", pairs)
+	}
+	if pairs[0].Prompt != "hi" || pairs[0].Original != "hello!" || len(pairs[0].History) != 0 {
+		t.Fatalf("unexpected first pair: %#v", pairs[0])
+	}
+	if pairs[1].Prompt != "what's 2+2?" || pairs[1].Original != "4" || len(pairs[1].History) != 2 || pairs[1].History[1].Content != "hello!" {
+		t.Fatalf("unexpected second pair: %#v", pairs[1])
+	}
+}
+
+func TestGenerateReplay(t *testing.T) {
+	pairs := []ReplayPair{
+		{Prompt: "hi", Original: "hello!"},
+		{Prompt: "fail", Original: "x", History: []providers.Message{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello!"}}},
+		{Prompt: "bye", Original: "see you", History: []providers.Message{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello!"}}},
+	}
+	provider := &replayRecordingProvider{}
+	var out bytes.Buffer
+	var failures int
+	written, err := GenerateReplay(context.Background(), provider, pairs, ReplayOptions{
+		Model:        "alt-model",
+		SystemPrompt: "be brief",
+		OnPair: func(index, total int, err error) {
+			if err != nil {
+				failures++
+			}
+		},
+	}, &out)
+	if err != nil {
+		t.Fatalf("GenerateReplay: %v", err)
+	}
+	if written != 2 || failures != 1 || provider.model != "alt-model" {
+		t.Fatalf("unexpected run: written=%d failures=%d model=%q", written, failures, provider.model)
+	}
+	third := provider.calls[2]
+	if len(third) != 4 || third[0].Role != "system" || third[2].Content != "hello!" || third[3].Content != "bye" {
+		t.Fatalf("expected system prompt + history + prompt, got %#v", third)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSONL lines, got %q", out.String())
+	}
+	var rec ReplayRecord
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatalf("decode record: %v", err)
+	}
+	if rec != (ReplayRecord{Prompt: "bye", Original: "see you", Alternative: "alt: bye"}) {
+		t.Fatalf("unexpected record: %#v", rec)
+	}
+}
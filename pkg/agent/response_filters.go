@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/logger"
+)
+
+// responseFilter strips one configured pattern from the edges of a response.
+// Trailing matches must start on their own line so a closer like "Sure." is
+// never cut out of the middle of a sentence.
+type responseFilter struct {
+	pattern string
+	start   *regexp.Regexp
+	end     *regexp.Regexp
+}
+
+func compileResponseFilters(patterns []string) []responseFilter {
+	filters := make([]responseFilter, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		start, err := regexp.Compile(`\A\s*(?:` + pattern + `)\s*`)
+		if err != nil {
+			logger.WarnCF("agent", "Ignoring invalid response filter", map[string]interface{}{
+				"pattern": pattern,
+				"error":   err.Error(),
+			})
+			continue
+		}
+		end := regexp.MustCompile(`\s*(?:\A|\n)[ \t]*(?:` + pattern + `)\s*\z`)
+		filters = append(filters, responseFilter{pattern: pattern, start: start, end: end})
+	}
+	return filters
+}
+
+// applyResponseFilters repeatedly strips matching prefixes and suffixes until
+// nothing changes, so stacked preambles are all removed. It returns the
+// filtered content and the patterns that matched.
+func applyResponseFilters(content string, filters []responseFilter) (string, []string) {
+	var applied []string
+	matched := map[string]bool{}
+	record := func(pattern string) {
+		if !matched[pattern] {
+			matched[pattern] = true
+			applied = append(applied, pattern)
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, f := range filters {
+			if loc := f.start.FindStringIndex(content); loc != nil && loc[1] > 0 {
+				content = content[loc[1]:]
+				record(f.pattern)
+				changed = true
+			}
+			if loc := f.end.FindStringIndex(content); loc != nil && loc[0] < len(content) {
+				content = content[:loc[0]]
+				record(f.pattern)
+				changed = true
+			}
+		}
+	}
+	return content, applied
+}
@@ -0,0 +1,40 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/dotsetgreg/dotagent/pkg/config"
+)
+
+func TestApplyResponseFilters_Defaults(t *testing.T) {
+	filters := compileResponseFilters(config.DefaultResponseFilters)
+
+	tests := []struct {
+		in      string
+		want    string
+		applied int
+	}{
+		{"Certainly! Here is your answer: The port is 8080.", "The port is 8080.", 2},
+		{"Sure. The port is 8080.\n\nI hope this helps!", "The port is 8080.", 2},
+		{"The port is 8080.\nLet me know if you have any other questions!", "The port is 8080.", 1},
+		{"Are you sure. The port is 8080.", "Are you sure. The port is 8080.", 0},
+		{"Sure thing, the port is 8080. Are you sure.", "Sure thing, the port is 8080. Are you sure.", 0},
+	}
+	for _, tt := range tests {
+		got, applied := applyResponseFilters(tt.in, filters)
+		if got != tt.want || len(applied) != tt.applied {
+			t.Fatalf("applyResponseFilters(%q) = %q (%d filters), want %q (%d filters)", tt.in, got, len(applied), tt.want, tt.applied)
+		}
+	}
+}
+
+func TestCompileResponseFilters_SkipsInvalidPatterns(t *testing.T) {
+	filters := compileResponseFilters([]string{"", "(unclosed", `(?i)ok[.!]`})
+	if len(filters) != 1 || filters[0].pattern != `(?i)ok[.!]` {
+		t.Fatalf("unexpected filters: %+v", filters)
+	}
+	got, applied := applyResponseFilters("OK! Done.", filters)
+	if got != "Done." || len(applied) != 1 {
+		t.Fatalf("unexpected filtered content %q (%v)", got, applied)
+	}
+}
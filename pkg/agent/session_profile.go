@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// SessionProfile collects wall-clock timings for the phases of agent turns
+// run with a context from WithSessionProfile. It is safe for concurrent use.
+type SessionProfile struct {
+	mu     sync.Mutex
+	phases []ProfilePhase
+}
+
+// ProfilePhase is one timed phase of a turn.
+type ProfilePhase struct {
+	Name     string
+	Start    time.Time
+	Duration time.Duration
+}
+
+type sessionProfileKey struct{}
+
+// NewSessionProfile returns an empty profile.
+func NewSessionProfile() *SessionProfile {
+	return &SessionProfile{}
+}
+
+// WithSessionProfile makes turns run with ctx record their phases in p.
+func WithSessionProfile(ctx context.Context, p *SessionProfile) context.Context {
+	return context.WithValue(ctx, sessionProfileKey{}, p)
+}
+
+// sessionProfileFromContext returns the profile attached to ctx, or nil when
+// profiling is off.
+func sessionProfileFromContext(ctx context.Context) *SessionProfile {
+	p, _ := ctx.Value(sessionProfileKey{}).(*SessionProfile)
+	return p
+}
+
+func noopProfileEnd() {}
+
+// begin starts timing a phase and returns the function that ends it. On a
+// nil profile it returns a no-op, so call sites need no checks.
+func (p *SessionProfile) begin(name string) func() {
+	if p == nil {
+		return noopProfileEnd
+	}
+	start := time.Now()
+	return func() {
+		p.mu.Lock()
+		p.phases = append(p.phases, ProfilePhase{Name: name, Start: start, Duration: time.Since(start)})
+		p.mu.Unlock()
+	}
+}
+
+// Phases returns the recorded phases in start order.
+func (p *SessionProfile) Phases() []ProfilePhase {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]ProfilePhase, len(p.phases))
+	copy(out, p.phases)
+	// Phases are appended when they end, which is not start order when they
+	// overlap.
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// Reset drops all recorded phases.
+func (p *SessionProfile) Reset() {
+	p.mu.Lock()
+	p.phases = nil
+	p.mu.Unlock()
+}
+
+// WriteTable prints the recorded phases as a PHASE/DURATION table.
+func (p *SessionProfile) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PHASE\tDURATION")
+	for _, phase := range p.Phases() {
+		fmt.Fprintf(tw, "%s\t%s\n", phase.Name, phase.Duration.Round(time.Microsecond))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+	"github.com/dotsetgreg/dotagent/pkg/config"
+	"github.com/dotsetgreg/dotagent/pkg/providers"
+	"github.com/dotsetgreg/dotagent/pkg/tools"
+)
+
+func TestAgentLoop_SessionProfileRecordsPhases(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	provider := &gateScriptProvider{responses: []*providers.LLMResponse{
+		{ToolCalls: []providers.ToolCall{{ID: "call-1", Name: "list_dir", Arguments: map[string]interface{}{"path": "notes"}}}},
+		{Content: "Nothing in notes."},
+	}}
+	al := mustNewAgentLoop(t, cfg, bus.NewMessageBus(), provider)
+	mock := tools.NewMockToolRegistry([]tools.MockInteraction{
+		{Name: "list_dir", Args: map[string]interface{}{"path": "notes"}, Result: tools.NewToolResult("")},
+	})
+	al.tools = mock.ToolRegistry
+
+	profile := NewSessionProfile()
+	ctx := WithSessionProfile(context.Background(), profile)
+	if _, err := al.ProcessDirect(ctx, "what is in notes?", "cli:profile"); err != nil {
+		t.Fatalf("process failed: %v", err)
+	}
+
+	var names []string
+	for _, phase := range profile.Phases() {
+		names = append(names, phase.Name)
+	}
+	got := strings.Join(names, ",")
+	for _, want := range []string{
+		"session.ensure",
+		"memory.build_context",
+		"llm.call #1",
+		"tool list_dir",
+		"llm.call #2",
+		"memory.append_event",
+		"memory.schedule_maintenance",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected phase %q, got %s", want, got)
+		}
+	}
+	if strings.Index(got, "llm.call #1") > strings.Index(got, "tool list_dir") ||
+		strings.Index(got, "tool list_dir") > strings.Index(got, "llm.call #2") {
+		t.Fatalf("expected phases in start order, got %s", got)
+	}
+
+	var buf bytes.Buffer
+	if err := profile.WriteTable(&buf); err != nil {
+		t.Fatalf("write table: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "PHASE") || !strings.Contains(buf.String(), "tool list_dir") {
+		t.Fatalf("unexpected table:\n%s", buf.String())
+	}
+
+	// Without a profile in the context nothing is recorded.
+	profile.Reset()
+	if _, err := al.ProcessDirect(context.Background(), "again", "cli:profile"); err != nil {
+		t.Fatalf("process failed: %v", err)
+	}
+	if phases := profile.Phases(); len(phases) != 0 {
+		t.Fatalf("expected no phases without a profiled context, got %d", len(phases))
+	}
+}
@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/logger"
+	"github.com/dotsetgreg/dotagent/pkg/providers"
+	"github.com/dotsetgreg/dotagent/pkg/tools"
+)
+
+// maxInlineImageBytes caps each image sent inline; provider limits sit
+// around 20 MB per image.
+const maxInlineImageBytes = 20 << 20
+
+var visionImageTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+// detectImagePaths returns the tokens in content that name existing local
+// image files, as written, so they can be replaced in the message text.
+// Relative paths resolve against the workspace; with restrict set, paths
+// outside the workspace are ignored.
+func detectImagePaths(content, workspace string, restrict bool) []string {
+	var found []string
+	seen := map[string]bool{}
+	for _, field := range strings.Fields(content) {
+		token := strings.Trim(field, "\"'`()[]<>{},;!?")
+		token = strings.TrimRight(token, ".:")
+		if token == "" || seen[token] || strings.Contains(token, "://") {
+			continue
+		}
+		if _, ok := visionImageTypes[strings.ToLower(filepath.Ext(token))]; !ok {
+			continue
+		}
+		path, err := resolveImagePath(token, workspace, restrict)
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		seen[token] = true
+		found = append(found, token)
+	}
+	return found
+}
+
+// resolveImagePath expands a leading ~ and resolves token the way the file
+// tools do, so with restrict set a symlink out of the workspace is rejected.
+func resolveImagePath(token, workspace string, restrict bool) (string, error) {
+	if token == "~" || strings.HasPrefix(token, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			token = filepath.Join(home, token[1:])
+		}
+	}
+	return tools.ValidatePath(token, workspace, restrict)
+}
+
+// attachImages turns the last user message into a multi-part message: the
+// text with each image path replaced by a short placeholder, followed by one
+// inline image block per path. Images that cannot be loaded stay as text.
+func (cb *ContextBuilder) attachImages(messages []providers.Message, images []string) []providers.Message {
+	if len(images) == 0 {
+		return messages
+	}
+	target := -1
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			target = i
+			break
+		}
+	}
+	if target < 0 {
+		return messages
+	}
+
+	text := messages[target].Content
+	var imageBlocks []providers.ContentBlock
+	for _, token := range images {
+		var block providers.ContentBlock
+		path, err := resolveImagePath(token, cb.workspace, false)
+		if err == nil {
+			block, err = loadImageBlock(path)
+		}
+		if err != nil {
+			logger.WarnCF("agent", "Skipping image attachment", map[string]interface{}{"path": token, "error": err.Error()})
+			continue
+		}
+		text = strings.ReplaceAll(text, token, fmt.Sprintf("[image: %s]", filepath.Base(token)))
+		imageBlocks = append(imageBlocks, block)
+	}
+	if len(imageBlocks) == 0 {
+		return messages
+	}
+
+	out := append([]providers.Message(nil), messages...)
+	out[target].Content = text
+	out[target].ContentBlocks = append([]providers.ContentBlock{{Type: "text", Text: text}}, imageBlocks...)
+	return out
+}
+
+func loadImageBlock(path string) (providers.ContentBlock, error) {
+	mimeType, ok := visionImageTypes[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return providers.ContentBlock{}, fmt.Errorf("unsupported image type")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return providers.ContentBlock{}, err
+	}
+	if info.Size() > maxInlineImageBytes {
+		return providers.ContentBlock{}, fmt.Errorf("image is %d bytes, over the %d byte limit", info.Size(), maxInlineImageBytes)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return providers.ContentBlock{}, err
+	}
+	return providers.ContentBlock{
+		Type: "image_url",
+		ImageURL: &providers.ImageURL{
+			URL: "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data),
+		},
+	}, nil
+}
@@ -6,10 +6,14 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/logger"
+	"github.com/dotsetgreg/dotagent/pkg/trace"
 )
 
 type MessageBus struct {
 	inbound         chan InboundMessage
+	priority        *[3]chan InboundMessage // per-Priority inbound queues; nil for FIFO
 	outbound        chan OutboundMessage
 	events          chan EventMessage
 	handlers        map[string]MessageHandler
@@ -18,13 +22,15 @@ type MessageBus struct {
 	inboundPublish  PublishConfig
 	outboundPublish PublishConfig
 	eventsPublish   PublishConfig
+	dedup           *dedupCache // nil when deduplication is off
 	mu              sync.RWMutex
 }
 
 type droppedCounters struct {
-	inbound  atomic.Uint64
-	outbound atomic.Uint64
-	events   atomic.Uint64
+	inbound    atomic.Uint64
+	outbound   atomic.Uint64
+	events     atomic.Uint64
+	duplicates atomic.Uint64
 }
 
 type PublishConfig struct {
@@ -39,6 +45,12 @@ type MessageBusOptions struct {
 	InboundPublish  PublishConfig
 	OutboundPublish PublishConfig
 	EventsPublish   PublishConfig
+	// DedupWindow drops an inbound message whose channel, chat and content
+	// match one published less than this long ago; 0 disables it.
+	DedupWindow time.Duration
+	// DedupCacheSize caps how many recent messages are remembered
+	// (default 1024).
+	DedupCacheSize int
 }
 
 const (
@@ -70,7 +82,7 @@ func NewMessageBusWithOptions(opts MessageBusOptions) *MessageBus {
 		eventsBuffer = defaultEventsBufferSize
 	}
 
-	return &MessageBus{
+	mb := &MessageBus{
 		inbound:         make(chan InboundMessage, inboundBuffer),
 		outbound:        make(chan OutboundMessage, outboundBuffer),
 		events:          make(chan EventMessage, eventsBuffer),
@@ -79,6 +91,10 @@ func NewMessageBusWithOptions(opts MessageBusOptions) *MessageBus {
 		outboundPublish: normalizePublishConfig(opts.OutboundPublish),
 		eventsPublish:   normalizePublishConfig(opts.EventsPublish),
 	}
+	if opts.DedupWindow > 0 {
+		mb.dedup = newDedupCache(opts.DedupWindow, opts.DedupCacheSize)
+	}
+	return mb
 }
 
 func normalizePublishConfig(cfg PublishConfig) PublishConfig {
@@ -91,16 +107,47 @@ func normalizePublishConfig(cfg PublishConfig) PublishConfig {
 	return cfg
 }
 
+// PublishInbound queues msg for the agent. With a dedup window configured,
+// a repeat of a recent message is logged and discarded without an error.
 func (mb *MessageBus) PublishInbound(msg InboundMessage) error {
 	mb.mu.RLock()
 	defer mb.mu.RUnlock()
 	if mb.closed {
 		return ErrBusClosed
 	}
+	if msg.TraceID == "" {
+		msg.TraceID = trace.NewID()
+	}
+	if msg.ReceivedAt.IsZero() {
+		msg.ReceivedAt = time.Now()
+	}
+	dedup := mb.dedup != nil && msg.Content != "" && !dedupExemptChannels[msg.Channel]
+	if dedup && mb.dedup.duplicate(msg, msg.ReceivedAt) {
+		mb.dropped.duplicates.Add(1)
+		logger.InfoCF("bus", "Dropped duplicate inbound message", map[string]interface{}{
+			"channel":  msg.Channel,
+			"chat_id":  msg.ChatID,
+			"trace_id": msg.TraceID,
+		})
+		return nil
+	}
+	if err := mb.enqueueInbound(msg); err != nil {
+		return err
+	}
+	// Record only delivered messages, so a redelivery of one that was dropped
+	// here is not mistaken for a duplicate.
+	if dedup {
+		mb.dedup.record(msg, msg.ReceivedAt)
+	}
+	return nil
+}
+
+func (mb *MessageBus) enqueueInbound(msg InboundMessage) error {
+	inbound := mb.inboundQueue(msg)
 
 	for attempt := 0; attempt < mb.inboundPublish.MaxAttempts; attempt++ {
 		select {
-		case mb.inbound <- msg:
+		case inbound <- msg:
 			return nil
 		default:
 		}
@@ -110,7 +157,7 @@ func (mb *MessageBus) PublishInbound(msg InboundMessage) error {
 		}
 		timer := time.NewTimer(mb.inboundPublish.Timeout)
 		select {
-		case mb.inbound <- msg:
+		case inbound <- msg:
 			timer.Stop()
 			return nil
 		case <-timer.C:
@@ -121,6 +168,9 @@ func (mb *MessageBus) PublishInbound(msg InboundMessage) error {
 }
 
 func (mb *MessageBus) ConsumeInbound(ctx context.Context) (InboundMessage, bool) {
+	if mb.priority != nil {
+		return mb.consumePriority(ctx)
+	}
 	select {
 	case msg, ok := <-mb.inbound:
 		if !ok {
@@ -194,6 +244,10 @@ func (mb *MessageBus) Close() {
 	}
 	mb.closed = true
 	close(mb.inbound)
+	if mb.priority != nil {
+		close(mb.priority[PriorityHigh])
+		close(mb.priority[PriorityLow])
+	}
 	close(mb.outbound)
 	close(mb.events)
 }
@@ -202,6 +256,12 @@ func (mb *MessageBus) DroppedInbound() uint64 {
 	return mb.dropped.inbound.Load()
 }
 
+// DroppedDuplicates is the number of inbound messages discarded as repeats
+// within the dedup window.
+func (mb *MessageBus) DroppedDuplicates() uint64 {
+	return mb.dropped.duplicates.Load()
+}
+
 func (mb *MessageBus) DroppedOutbound() uint64 {
 	return mb.dropped.outbound.Load()
 }
@@ -3,6 +3,7 @@ package bus
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 )
@@ -72,3 +73,150 @@ func TestMessageBus_ClosedChannelsReturnFalse(t *testing.T) {
 		t.Fatalf("expected closed outbound subscribe to return ok=false")
 	}
 }
+
+func TestMessageBus_PublishInboundAssignsTraceID(t *testing.T) {
+	mb := NewMessageBus()
+	defer mb.Close()
+
+	if err := mb.PublishInbound(InboundMessage{Channel: "test", ChatID: "c", Content: "a"}); err != nil {
+		t.Fatalf("publish inbound: %v", err)
+	}
+	if err := mb.PublishInbound(InboundMessage{Channel: "test", ChatID: "c", Content: "b", TraceID: "keep"}); err != nil {
+		t.Fatalf("publish inbound: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	first, ok := mb.ConsumeInbound(ctx)
+	if !ok || first.TraceID == "" {
+		t.Fatalf("expected generated trace ID, got %+v", first)
+	}
+	second, ok := mb.ConsumeInbound(ctx)
+	if !ok || second.TraceID != "keep" {
+		t.Fatalf("expected caller trace ID to be preserved, got %+v", second)
+	}
+}
+
+func TestMessageBus_PublishInboundStampsReceivedAt(t *testing.T) {
+	mb := NewMessageBus()
+	defer mb.Close()
+
+	before := time.Now()
+	earlier := before.Add(-time.Minute)
+	if err := mb.PublishInbound(InboundMessage{Channel: "test", ChatID: "c", Content: "a"}); err != nil {
+		t.Fatalf("publish inbound: %v", err)
+	}
+	if err := mb.PublishInbound(InboundMessage{Channel: "test", ChatID: "c", Content: "b", ReceivedAt: earlier}); err != nil {
+		t.Fatalf("publish inbound: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	first, ok := mb.ConsumeInbound(ctx)
+	if !ok || first.ReceivedAt.Before(before) {
+		t.Fatalf("expected ReceivedAt to be stamped on publish, got %v", first.ReceivedAt)
+	}
+	second, ok := mb.ConsumeInbound(ctx)
+	if !ok || !second.ReceivedAt.Equal(earlier) {
+		t.Fatalf("expected caller ReceivedAt to be preserved, got %v", second.ReceivedAt)
+	}
+}
+
+func TestMessageBus_PublishInboundDropsDuplicatesWithinWindow(t *testing.T) {
+	mb := NewMessageBusWithOptions(MessageBusOptions{DedupWindow: 5 * time.Second})
+	defer mb.Close()
+
+	start := time.Now()
+	publishAs := func(channel, senderID, messageID, chatID, content string, at time.Time) {
+		t.Helper()
+		msg := InboundMessage{Channel: channel, SenderID: senderID, MessageID: messageID, ChatID: chatID, Content: content, ReceivedAt: at}
+		if err := mb.PublishInbound(msg); err != nil {
+			t.Fatalf("publish inbound: %v", err)
+		}
+	}
+	publish := func(chatID, content string, at time.Time) {
+		t.Helper()
+		publishAs("discord", "u", "m1", chatID, content, at)
+	}
+	publish("c1", "hello", start)
+	publish("c1", "hello", start.Add(2*time.Second)) // duplicate
+	publish("c2", "hello", start.Add(2*time.Second)) // other chat
+	publish("c1", "hello!", start.Add(3*time.Second))
+	publish("c1", "hello", start.Add(6*time.Second)) // window elapsed
+	publishAs("stdin", "stdin", "", "stdin", "hi", start)
+	publishAs("stdin", "stdin", "", "stdin", "hi", start) // stdin is never deduplicated
+
+	if got := len(mb.inbound); got != 6 {
+		t.Fatalf("expected 6 queued messages, got %d", got)
+	}
+	if mb.DroppedDuplicates() != 1 {
+		t.Fatalf("expected 1 dropped duplicate, got %d", mb.DroppedDuplicates())
+	}
+}
+
+func TestMessageBus_PublishInboundDropsRepostsWithNewMessageIDs(t *testing.T) {
+	mb := NewMessageBusWithOptions(MessageBusOptions{DedupWindow: 5 * time.Second})
+	defer mb.Close()
+
+	start := time.Now()
+	for i, messageID := range []string{"webhook-1", "webhook-2"} {
+		msg := InboundMessage{Channel: "discord", SenderID: "hook", MessageID: messageID, ChatID: "c1", Content: "deploy finished", ReceivedAt: start.Add(time.Duration(i) * time.Second)}
+		if err := mb.PublishInbound(msg); err != nil {
+			t.Fatalf("publish inbound %s: %v", messageID, err)
+		}
+	}
+	if got := len(mb.inbound); got != 1 {
+		t.Fatalf("expected the repost to be dropped, got %d queued messages", got)
+	}
+	if mb.DroppedDuplicates() != 1 {
+		t.Fatalf("expected 1 dropped duplicate, got %d", mb.DroppedDuplicates())
+	}
+}
+
+func TestMessageBus_PublishInboundDoesNotRecordDroppedMessages(t *testing.T) {
+	mb := NewMessageBusWithOptions(MessageBusOptions{
+		DedupWindow:    5 * time.Second,
+		InboundPublish: PublishConfig{Timeout: 5 * time.Millisecond, MaxAttempts: 1},
+	})
+	defer mb.Close()
+
+	for i := 0; i < cap(mb.inbound); i++ {
+		if err := mb.PublishInbound(InboundMessage{Channel: "test", ChatID: "c", Content: fmt.Sprintf("seed %d", i)}); err != nil {
+			t.Fatalf("publish inbound seed %d: %v", i, err)
+		}
+	}
+	msg := InboundMessage{Channel: "test", ChatID: "c", Content: "retry me"}
+	if err := mb.PublishInbound(msg); !errors.Is(err, ErrPublishDropped) {
+		t.Fatalf("expected ErrPublishDropped, got %v", err)
+	}
+	<-mb.inbound
+	if err := mb.PublishInbound(msg); err != nil {
+		t.Fatalf("expected the redelivery to be queued, got %v", err)
+	}
+	if mb.DroppedDuplicates() != 0 {
+		t.Fatalf("expected no dropped duplicates, got %d", mb.DroppedDuplicates())
+	}
+}
+
+func TestDedupCache_EvictsLeastRecentlySeen(t *testing.T) {
+	c := newDedupCache(time.Minute, 2)
+	now := time.Now()
+	msg := func(content string) InboundMessage {
+		return InboundMessage{Channel: "discord", ChatID: "c", Content: content}
+	}
+	c.record(msg("a"), now)
+	c.record(msg("b"), now)
+	if !c.duplicate(msg("a"), now) { // a becomes most recent
+		t.Fatalf("expected a to be a duplicate")
+	}
+	c.record(msg("c"), now) // evicts b
+	if c.order.Len() != 2 {
+		t.Fatalf("expected the cache to stay at 2 entries, got %d", c.order.Len())
+	}
+	if c.duplicate(msg("b"), now) {
+		t.Fatalf("expected b to have been evicted")
+	}
+	if !c.duplicate(msg("c"), now) {
+		t.Fatalf("expected c to still be cached")
+	}
+}
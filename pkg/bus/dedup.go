@@ -0,0 +1,95 @@
+package bus
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// defaultDedupCacheSize bounds how many recent inbound messages the
+// deduplication cache remembers.
+const defaultDedupCacheSize = 1024
+
+type dedupKey [sha256.Size]byte
+
+type dedupEntry struct {
+	key    dedupKey
+	seenAt time.Time
+}
+
+// dedupCache is a fixed-size LRU of recently published inbound messages.
+type dedupCache struct {
+	window time.Duration
+	size   int
+
+	mu      sync.Mutex
+	order   *list.List // front is most recently seen
+	entries map[dedupKey]*list.Element
+}
+
+func newDedupCache(window time.Duration, size int) *dedupCache {
+	if size <= 0 {
+		size = defaultDedupCacheSize
+	}
+	return &dedupCache{
+		window:  window,
+		size:    size,
+		order:   list.New(),
+		entries: make(map[dedupKey]*list.Element, size),
+	}
+}
+
+// dedupExemptChannels are never deduplicated. The stdin channel waits for a
+// reply to every line it read and exits once each has been answered, so a
+// silently dropped line would keep it running after EOF.
+var dedupExemptChannels = map[string]bool{"stdin": true}
+
+// inboundDedupKey identifies a message by the channel and chat it arrived
+// in and its content. A misconfigured webhook that posts the same text several
+// times produces a new platform message ID for each post, so the ID is not
+// part of the key.
+func inboundDedupKey(msg InboundMessage) dedupKey {
+	h := sha256.New()
+	for _, part := range []string{msg.Channel, msg.ChatID} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte(msg.Content))
+	var key dedupKey
+	h.Sum(key[:0])
+	return key
+}
+
+// duplicate reports whether an identical message was recorded less than the
+// window before at. It does not record msg; call record once it is delivered.
+func (c *dedupCache) duplicate(msg InboundMessage, at time.Time) bool {
+	key := inboundDedupKey(msg)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+	c.order.MoveToFront(el)
+	return at.Sub(el.Value.(*dedupEntry).seenAt) < c.window
+}
+
+// record remembers msg as seen at. The window runs from the first delivered
+// copy, so a message repeated forever still gets through once per window.
+func (c *dedupCache) record(msg InboundMessage, at time.Time) {
+	key := inboundDedupKey(msg)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		el.Value.(*dedupEntry).seenAt = at
+		return
+	}
+	c.entries[key] = c.order.PushFront(&dedupEntry{key: key, seenAt: at})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*dedupEntry).key)
+	}
+}
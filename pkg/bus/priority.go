@@ -0,0 +1,125 @@
+package bus
+
+import (
+	"context"
+	"strings"
+)
+
+// Priority orders inbound messages on a priority bus.
+type Priority int
+
+const (
+	// PriorityHigh is for system messages such as subagent results.
+	PriorityHigh Priority = iota
+	// PriorityNormal is for user messages.
+	PriorityNormal
+	// PriorityLow is for background work such as cron jobs and file watches.
+	PriorityLow
+)
+
+// PriorityMetadataKey overrides a message's priority when set to "high",
+// "normal" or "low".
+const PriorityMetadataKey = "priority"
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityHigh:
+		return "high"
+	case PriorityLow:
+		return "low"
+	default:
+		return "normal"
+	}
+}
+
+// InboundPriority classifies a message for the priority bus.
+func InboundPriority(msg InboundMessage) Priority {
+	switch strings.ToLower(strings.TrimSpace(msg.Metadata[PriorityMetadataKey])) {
+	case "high":
+		return PriorityHigh
+	case "normal":
+		return PriorityNormal
+	case "low":
+		return PriorityLow
+	}
+	if msg.Channel == "system" {
+		return PriorityHigh
+	}
+	switch msg.Metadata["source"] {
+	case "cron", "file_watch":
+		return PriorityLow
+	}
+	return PriorityNormal
+}
+
+// NewPriorityMessageBus returns a bus whose inbound side keeps one queue per
+// Priority, each sized like the FIFO inbound buffer. ConsumeInbound drains
+// high before normal before low, so system messages are not stuck behind
+// queued user messages.
+func NewPriorityMessageBus(opts MessageBusOptions) *MessageBus {
+	mb := NewMessageBusWithOptions(opts)
+	size := cap(mb.inbound)
+	mb.priority = &[3]chan InboundMessage{
+		PriorityHigh:   make(chan InboundMessage, size),
+		PriorityNormal: mb.inbound,
+		PriorityLow:    make(chan InboundMessage, size),
+	}
+	return mb
+}
+
+// inboundQueue is the channel msg is published to.
+func (mb *MessageBus) inboundQueue(msg InboundMessage) chan InboundMessage {
+	if mb.priority == nil {
+		return mb.inbound
+	}
+	return mb.priority[InboundPriority(msg)]
+}
+
+// consumePriority returns the next message from the highest-priority queue
+// that has one, waiting if all are empty. A queue is skipped once it is
+// closed and drained; ok is false when all of them are.
+func (mb *MessageBus) consumePriority(ctx context.Context) (InboundMessage, bool) {
+	queues := *mb.priority
+	for {
+		open := false
+		for i, q := range queues {
+			if q == nil {
+				continue
+			}
+			select {
+			case msg, ok := <-q:
+				if ok {
+					return msg, true
+				}
+				queues[i] = nil
+				continue
+			default:
+			}
+			open = true
+		}
+		if !open {
+			return InboundMessage{}, false
+		}
+		// Nothing queued: take whichever message arrives first. A nil queue
+		// (closed and drained) never fires.
+		select {
+		case msg, ok := <-queues[PriorityHigh]:
+			if ok {
+				return msg, true
+			}
+			queues[PriorityHigh] = nil
+		case msg, ok := <-queues[PriorityNormal]:
+			if ok {
+				return msg, true
+			}
+			queues[PriorityNormal] = nil
+		case msg, ok := <-queues[PriorityLow]:
+			if ok {
+				return msg, true
+			}
+			queues[PriorityLow] = nil
+		case <-ctx.Done():
+			return InboundMessage{}, false
+		}
+	}
+}
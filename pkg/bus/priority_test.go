@@ -0,0 +1,73 @@
+package bus
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInboundPriority(t *testing.T) {
+	cases := []struct {
+		msg  InboundMessage
+		want Priority
+	}{
+		{InboundMessage{Channel: "telegram", Content: "hi"}, PriorityNormal},
+		{InboundMessage{Channel: "system", SenderID: "subagent:1"}, PriorityHigh},
+		{InboundMessage{Channel: "telegram", Metadata: map[string]string{"source": "cron"}}, PriorityLow},
+		{InboundMessage{Channel: "telegram", Metadata: map[string]string{"source": "file_watch"}}, PriorityLow},
+		{InboundMessage{Channel: "system", Metadata: map[string]string{PriorityMetadataKey: "low"}}, PriorityLow},
+	}
+	for _, tc := range cases {
+		if got := InboundPriority(tc.msg); got != tc.want {
+			t.Errorf("InboundPriority(%+v) = %s, want %s", tc.msg, got, tc.want)
+		}
+	}
+}
+
+func TestPriorityMessageBus_DrainsHighFirst(t *testing.T) {
+	mb := NewPriorityMessageBus(MessageBusOptions{})
+	defer mb.Close()
+
+	for _, msg := range []InboundMessage{
+		{Channel: "telegram", Content: "cron", Metadata: map[string]string{"source": "cron"}},
+		{Channel: "telegram", Content: "user-1"},
+		{Channel: "system", Content: "subagent"},
+		{Channel: "telegram", Content: "user-2"},
+	} {
+		if err := mb.PublishInbound(msg); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, want := range []string{"subagent", "user-1", "user-2", "cron"} {
+		msg, ok := mb.ConsumeInbound(ctx)
+		if !ok || msg.Content != want {
+			t.Fatalf("expected %q, got %q (ok=%v)", want, msg.Content, ok)
+		}
+	}
+}
+
+func TestPriorityMessageBus_WaitsAndCloses(t *testing.T) {
+	mb := NewPriorityMessageBus(MessageBusOptions{})
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = mb.PublishInbound(InboundMessage{Channel: "telegram", Content: "late", Metadata: map[string]string{"source": "cron"}})
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if msg, ok := mb.ConsumeInbound(ctx); !ok || msg.Content != "late" {
+		t.Fatalf("expected to wait for the low-priority message, got %q (ok=%v)", msg.Content, ok)
+	}
+
+	_ = mb.PublishInbound(InboundMessage{Channel: "system", Content: "queued"})
+	mb.Close()
+	if msg, ok := mb.ConsumeInbound(ctx); !ok || msg.Content != "queued" {
+		t.Fatalf("expected queued message after close, got %q (ok=%v)", msg.Content, ok)
+	}
+	if _, ok := mb.ConsumeInbound(ctx); ok {
+		t.Fatal("expected closed bus to return false")
+	}
+}
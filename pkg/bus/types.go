@@ -1,5 +1,7 @@
 package bus
 
+import "time"
+
 type InboundMessage struct {
 	Channel         string            `json:"channel"`
 	SenderID        string            `json:"sender_id"`
@@ -9,7 +11,11 @@ type InboundMessage struct {
 	SessionKey      string            `json:"session_key"`
 	MessageID       string            `json:"message_id,omitempty"`
 	DeliveryAttempt int               `json:"delivery_attempt,omitempty"`
+	TraceID         string            `json:"trace_id,omitempty"`
 	Metadata        map[string]string `json:"metadata,omitempty"`
+	// ReceivedAt is when the message entered the bus; PublishInbound sets it
+	// if the channel did not.
+	ReceivedAt time.Time `json:"received_at,omitzero"`
 }
 
 type OutboundMessage struct {
@@ -2,10 +2,20 @@ package channels
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/dotsetgreg/dotagent/pkg/bus"
@@ -20,8 +30,30 @@ const (
 	streamPreviewLimit    = 1600
 	streamEditMinInterval = 900 * time.Millisecond
 	discordAPIMaxWorkers  = 16
+	discordRetryMaxDelay  = 30 * time.Second
+
+	// discordMaxMessageLength is Discord's limit on a message's characters.
+	discordMaxMessageLength = 2000
 )
 
+// discordTokenPattern matches a bot token: the base64 bot ID, a timestamp and
+// an HMAC, separated by dots.
+var discordTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{17,}\.[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]{20,}$`)
+
+// ValidateDiscordToken checks that token is shaped like a Discord bot token,
+// with or without the "Bot " prefix. It does not contact Discord.
+func ValidateDiscordToken(token string) error {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return fmt.Errorf("channels.discord.token is required")
+	}
+	token = strings.TrimSpace(strings.TrimPrefix(token, "Bot "))
+	if !discordTokenPattern.MatchString(token) {
+		return fmt.Errorf("channels.discord.token is not a Discord bot token (expected three dot-separated segments)")
+	}
+	return nil
+}
+
 type DiscordChannel struct {
 	*BaseChannel
 	session  *discordgo.Session
@@ -31,6 +63,11 @@ type DiscordChannel struct {
 	stream   map[string]*streamDraft
 	streamMu sync.Mutex
 	apiSlots chan struct{}
+	// sendAPI posts one message; it is the session's ChannelMessageSend
+	// outside tests.
+	sendAPI       func(channelID, content string) (*discordgo.Message, error)
+	failedLogPath string
+	failedLogMu   sync.Mutex
 }
 
 type typingSession struct {
@@ -53,14 +90,30 @@ func NewDiscordChannel(cfg config.DiscordConfig, bus *bus.MessageBus) (*DiscordC
 
 	base := NewBaseChannel("discord", cfg, bus, cfg.AllowFrom)
 
-	return &DiscordChannel{
+	c := &DiscordChannel{
 		BaseChannel: base,
 		session:     session,
 		config:      cfg,
 		typing:      make(map[string]*typingSession),
 		stream:      make(map[string]*streamDraft),
 		apiSlots:    make(chan struct{}, discordAPIMaxWorkers),
-	}, nil
+	}
+	c.sendAPI = func(channelID, content string) (*discordgo.Message, error) {
+		return c.session.ChannelMessageSend(channelID, content)
+	}
+	return c, nil
+}
+
+// splitContent splits an outgoing message per channels.discord
+// max_message_length and split_strategy.
+func (c *DiscordChannel) splitContent(content string) []string {
+	return splitDiscordMessage(content, c.config.MaxMessageLength, strings.ToLower(strings.TrimSpace(c.config.SplitStrategy)))
+}
+
+// SetFailedMessageLog sets the file that messages are appended to when every
+// delivery attempt fails, so a reply is never silently lost.
+func (c *DiscordChannel) SetFailedMessageLog(path string) {
+	c.failedLogPath = path
 }
 
 func (c *DiscordChannel) Start(ctx context.Context) error {
@@ -118,10 +171,11 @@ func (c *DiscordChannel) Send(ctx context.Context, msg bus.OutboundMessage) erro
 		return nil
 	}
 
-	chunks := splitMessage(msg.Content, 1500) // Discord has a limit of 2000 characters per message, leave 500 for natural split e.g. code blocks
+	chunks := c.splitContent(msg.Content)
 
 	for _, chunk := range chunks {
 		if err := c.sendChunk(ctx, channelID, chunk); err != nil {
+			c.recordFailedMessage(channelID, msg.Content, err)
 			return err
 		}
 	}
@@ -129,132 +183,146 @@ func (c *DiscordChannel) Send(ctx context.Context, msg bus.OutboundMessage) erro
 	return nil
 }
 
-// splitMessage splits long messages into chunks, preserving code block integrity
-// Uses natural boundaries (newlines, spaces) and extends messages slightly to avoid breaking code blocks
-func splitMessage(content string, limit int) []string {
-	var messages []string
-
-	for len(content) > 0 {
-		if len(content) <= limit {
-			messages = append(messages, content)
-			break
-		}
-
-		msgEnd := limit
-
-		// Find natural split point within the limit
-		msgEnd = findLastNewline(content[:limit], 200)
-		if msgEnd <= 0 {
-			msgEnd = findLastSpace(content[:limit], 100)
-		}
-		if msgEnd <= 0 {
-			msgEnd = limit
+// splitDiscordMessage splits content into messages of at most limit
+// characters. Content that fits is returned as is; otherwise each part is
+// prefixed with "(i/N) " and cut at the last boundary the strategy allows:
+// paragraph (blank line, then line, sentence, word), sentence (sentence end
+// or line break, then word) or word (whitespace). A code block that spans a
+// cut is closed at the end of one part and reopened with its language at the
+// start of the next.
+func splitDiscordMessage(content string, limit int, strategy string) []string {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil
+	}
+	if limit <= 0 {
+		limit = discordMaxMessageLength
+	}
+	if utf8.RuneCountInString(content) <= limit {
+		return []string{content}
+	}
+	// Reserve room for the widest prefix; retry with more digits if the
+	// part count outgrows the guess.
+	for digits := 1; ; digits++ {
+		parts := splitDiscordParts(content, limit-len("(/) ")-2*digits, strategy)
+		if len(strconv.Itoa(len(parts))) > digits {
+			continue
 		}
-
-		// Check if this would end with an incomplete code block
-		candidate := content[:msgEnd]
-		unclosedIdx := findLastUnclosedCodeBlock(candidate)
-
-		if unclosedIdx >= 0 {
-			// Message would end with incomplete code block
-			// Try to extend to include the closing ``` (with some buffer)
-			extendedLimit := limit + 500 // Allow 500 char buffer for code blocks
-			if len(content) > extendedLimit {
-				closingIdx := findNextClosingCodeBlock(content, msgEnd)
-				if closingIdx > 0 && closingIdx <= extendedLimit {
-					// Extend to include the closing ```
-					msgEnd = closingIdx
-				} else {
-					// Can't find closing, split before the code block
-					msgEnd = findLastNewline(content[:unclosedIdx], 200)
-					if msgEnd <= 0 {
-						msgEnd = findLastSpace(content[:unclosedIdx], 100)
-					}
-					if msgEnd <= 0 {
-						msgEnd = unclosedIdx
-					}
-				}
-			} else {
-				// Remaining content fits within extended limit
-				msgEnd = len(content)
+		for i, part := range parts {
+			// A code fence only renders at the start of a line.
+			sep := " "
+			if strings.HasPrefix(part, "```") {
+				sep = "\n"
 			}
+			parts[i] = fmt.Sprintf("(%d/%d)%s%s", i+1, len(parts), sep, part)
 		}
+		return parts
+	}
+}
 
-		if msgEnd <= 0 {
-			msgEnd = limit
+func splitDiscordParts(content string, limit int, strategy string) []string {
+	const fenceClose = "\n```"
+	var parts []string
+	rest := []rune(content)
+	for len(rest) > 0 {
+		if len(rest) <= limit {
+			parts = append(parts, string(rest))
+			break
 		}
-
-		messages = append(messages, content[:msgEnd])
-		content = strings.TrimSpace(content[msgEnd:])
+		window := rest[:limit-len(fenceClose)]
+		cut := discordSplitPoint(window, strategy)
+		part := strings.TrimRightFunc(string(rest[:cut]), unicode.IsSpace)
+		next := strings.TrimLeftFunc(string(rest[cut:]), unicode.IsSpace)
+		if opener, open := unclosedCodeFence(part); open {
+			part += fenceClose
+			next = opener + "\n" + next
+		}
+		parts = append(parts, part)
+		rest = []rune(next)
 	}
-
-	return messages
+	return parts
 }
 
-// findLastUnclosedCodeBlock finds the last opening ``` that doesn't have a closing ```
-// Returns the position of the opening ``` or -1 if all code blocks are complete
-func findLastUnclosedCodeBlock(text string) int {
-	count := 0
-	lastOpenIdx := -1
-
-	for i := 0; i < len(text); i++ {
-		if i+2 < len(text) && text[i] == '`' && text[i+1] == '`' && text[i+2] == '`' {
-			if count == 0 {
-				lastOpenIdx = i
-			}
-			count++
-			i += 2
+// discordSplitPoint returns the index to cut window at. Boundaries in the
+// first third of the window are skipped so parts do not end up tiny; when no
+// boundary qualifies the window is cut at its end.
+func discordSplitPoint(window []rune, strategy string) int {
+	minCut := len(window) / 3
+	var finders []func([]rune, int) int
+	switch strategy {
+	case "word":
+		finders = []func([]rune, int) int{lastWordBreak}
+	case "sentence":
+		finders = []func([]rune, int) int{lastSentenceBreak, lastWordBreak}
+	default:
+		finders = []func([]rune, int) int{lastParagraphBreak, lastLineBreak, lastSentenceBreak, lastWordBreak}
+	}
+	for _, find := range finders {
+		if cut := find(window, minCut); cut > 0 {
+			return cut
 		}
 	}
+	return len(window)
+}
 
-	// If odd number of ``` markers, last one is unclosed
-	if count%2 == 1 {
-		return lastOpenIdx
+func lastParagraphBreak(window []rune, minCut int) int {
+	for i := len(window) - 1; i > minCut; i-- {
+		if window[i] == '\n' && window[i-1] == '\n' {
+			return i - 1
+		}
 	}
 	return -1
 }
 
-// findNextClosingCodeBlock finds the next closing ``` starting from a position
-// Returns the position after the closing ``` or -1 if not found
-func findNextClosingCodeBlock(text string, startIdx int) int {
-	for i := startIdx; i < len(text); i++ {
-		if i+2 < len(text) && text[i] == '`' && text[i+1] == '`' && text[i+2] == '`' {
-			return i + 3
+func lastLineBreak(window []rune, minCut int) int {
+	for i := len(window) - 1; i > minCut; i-- {
+		if window[i] == '\n' {
+			return i
 		}
 	}
 	return -1
 }
 
-// findLastNewline finds the last newline character within the last N characters
-// Returns the position of the newline or -1 if not found
-func findLastNewline(s string, searchWindow int) int {
-	searchStart := len(s) - searchWindow
-	if searchStart < 0 {
-		searchStart = 0
-	}
-	for i := len(s) - 1; i >= searchStart; i-- {
-		if s[i] == '\n' {
+// lastSentenceBreak cuts after the last ".", "!" or "?" that is followed by
+// whitespace, or at the last line break, whichever is later.
+func lastSentenceBreak(window []rune, minCut int) int {
+	for i := len(window) - 2; i > minCut; i-- {
+		if window[i] == '\n' {
 			return i
 		}
+		if (window[i] == '.' || window[i] == '!' || window[i] == '?') && unicode.IsSpace(window[i+1]) {
+			return i + 1
+		}
 	}
 	return -1
 }
 
-// findLastSpace finds the last space character within the last N characters
-// Returns the position of the space or -1 if not found
-func findLastSpace(s string, searchWindow int) int {
-	searchStart := len(s) - searchWindow
-	if searchStart < 0 {
-		searchStart = 0
-	}
-	for i := len(s) - 1; i >= searchStart; i-- {
-		if s[i] == ' ' || s[i] == '\t' {
+func lastWordBreak(window []rune, minCut int) int {
+	for i := len(window) - 1; i > minCut; i-- {
+		if unicode.IsSpace(window[i]) {
 			return i
 		}
 	}
 	return -1
 }
 
+// unclosedCodeFence reports whether text leaves a ``` code block open and,
+// if so, returns the fence line (with its language) to reopen it with.
+func unclosedCodeFence(text string) (string, bool) {
+	if strings.Count(text, "```")%2 == 0 {
+		return "", false
+	}
+	line := text[strings.LastIndex(text, "```"):]
+	if nl := strings.IndexByte(line, '\n'); nl >= 0 {
+		line = line[:nl]
+	}
+	line = strings.TrimSpace(line)
+	if len(line) > 24 || strings.ContainsAny(line[3:], " `") {
+		line = "```"
+	}
+	return line, true
+}
+
 func streamDraftKey(channelID, streamID string) string {
 	channelID = strings.TrimSpace(channelID)
 	streamID = strings.TrimSpace(streamID)
@@ -276,9 +344,10 @@ func (c *DiscordChannel) sendStream(ctx context.Context, channelID string, msg b
 		if strings.TrimSpace(msg.Content) == "" {
 			return nil
 		}
-		chunks := splitMessage(msg.Content, 1500)
+		chunks := c.splitContent(msg.Content)
 		for _, chunk := range chunks {
 			if err := c.sendChunk(ctx, channelID, chunk); err != nil {
+				c.recordFailedMessage(channelID, msg.Content, err)
 				return err
 			}
 		}
@@ -347,7 +416,7 @@ func (c *DiscordChannel) finalizeStreamDraft(ctx context.Context, key, channelID
 		return nil
 	}
 
-	chunks := splitMessage(finalContent, 1500)
+	chunks := c.splitContent(finalContent)
 	if len(chunks) == 0 {
 		return nil
 	}
@@ -355,6 +424,7 @@ func (c *DiscordChannel) finalizeStreamDraft(ctx context.Context, key, channelID
 		if err := c.editMessage(ctx, channelID, draft.messageID, chunks[0]); err == nil {
 			for _, chunk := range chunks[1:] {
 				if err := c.sendChunk(ctx, channelID, chunk); err != nil {
+					c.recordFailedMessage(channelID, finalContent, err)
 					c.sendStreamFinalizeFailureNotice(ctx, channelID, err)
 					return err
 				}
@@ -364,6 +434,7 @@ func (c *DiscordChannel) finalizeStreamDraft(ctx context.Context, key, channelID
 	}
 	for _, chunk := range chunks {
 		if err := c.sendChunk(ctx, channelID, chunk); err != nil {
+			c.recordFailedMessage(channelID, finalContent, err)
 			c.sendStreamFinalizeFailureNotice(ctx, channelID, err)
 			return err
 		}
@@ -431,7 +502,132 @@ func ensureClosedMarkdownFence(content string) string {
 	return content
 }
 
+// sendMessage posts a message, retrying rate limits, server errors and
+// network failures up to channels.discord.retry.max_attempts times with exponential
+// backoff and jitter.
 func (c *DiscordChannel) sendMessage(ctx context.Context, channelID, content string) (*discordgo.Message, error) {
+	maxAttempts := max(c.config.Retry.MaxAttempts, 1)
+	baseDelay := time.Duration(c.config.Retry.BaseDelayMS) * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		msg, err := c.sendMessageOnce(ctx, channelID, content)
+		if err == nil {
+			return msg, nil
+		}
+		if attempt >= maxAttempts || !isRetryableDiscordError(err) || ctx.Err() != nil {
+			if attempt > 1 {
+				err = fmt.Errorf("%w (after %d attempts)", err, attempt)
+			}
+			return nil, err
+		}
+		delay := discordRetryDelay(err, baseDelay, attempt)
+		logger.WarnCF("discord", "Discord send failed; retrying", map[string]any{
+			"channel_id":   channelID,
+			"attempt":      attempt,
+			"max_attempts": maxAttempts,
+			"delay_ms":     delay.Milliseconds(),
+			"error":        err.Error(),
+		})
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		}
+	}
+}
+
+// isRetryableDiscordError reports whether a send may succeed if tried again:
+// rate limits, 5xx responses and network failures are; timeouts and other
+// 4xx responses (missing permissions, unknown channel, invalid body) are not.
+func isRetryableDiscordError(err error) bool {
+	// A send that timed out may still land; retrying could post it twice.
+	if errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var rateLimited *discordgo.RateLimitError
+	if errors.As(err, &rateLimited) {
+		return true
+	}
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Response != nil {
+		status := restErr.Response.StatusCode
+		return status == http.StatusTooManyRequests || status >= 500
+	}
+	return true
+}
+
+// discordRetryDelay doubles base for each attempt, caps it at
+// discordRetryMaxDelay and spreads it by ±50% so concurrent senders do not
+// retry in lockstep. A rate limit's retry-after is honored when it is longer.
+func discordRetryDelay(err error, base time.Duration, attempt int) time.Duration {
+	delay := base << (attempt - 1)
+	if delay > discordRetryMaxDelay || delay < 0 {
+		delay = discordRetryMaxDelay
+	}
+	if delay > 0 {
+		delay = delay/2 + rand.N(delay)
+	}
+	var rateLimited *discordgo.RateLimitError
+	if errors.As(err, &rateLimited) && rateLimited.RateLimit != nil && rateLimited.TooManyRequests != nil && rateLimited.RetryAfter > delay {
+		delay = rateLimited.RetryAfter
+	}
+	return delay
+}
+
+// failedDiscordMessage is one line of failed_messages.log.
+type failedDiscordMessage struct {
+	Time       string `json:"time"`
+	SessionKey string `json:"session_key"`
+	ChatID     string `json:"chat_id"`
+	Error      string `json:"error"`
+	Content    string `json:"content"`
+}
+
+// recordFailedMessage appends a message that could not be delivered to the
+// failed message log as a JSON line.
+func (c *DiscordChannel) recordFailedMessage(channelID, content string, sendErr error) {
+	if c.failedLogPath == "" {
+		return
+	}
+	line, err := json.Marshal(failedDiscordMessage{
+		Time:       time.Now().UTC().Format(time.RFC3339),
+		SessionKey: fmt.Sprintf("%s:%s", c.Name(), channelID),
+		ChatID:     channelID,
+		Error:      sendErr.Error(),
+		Content:    content,
+	})
+	if err != nil {
+		return
+	}
+	c.failedLogMu.Lock()
+	defer c.failedLogMu.Unlock()
+	err = os.MkdirAll(filepath.Dir(c.failedLogPath), 0o755)
+	var f *os.File
+	if err == nil {
+		f, err = os.OpenFile(c.failedLogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	}
+	if err == nil {
+		_, err = f.Write(append(line, '\n'))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		logger.ErrorCF("discord", "Failed to record undelivered message", map[string]any{
+			"channel_id": channelID,
+			"path":       c.failedLogPath,
+			"error":      err.Error(),
+		})
+		return
+	}
+	logger.WarnCF("discord", "Message delivery failed; saved to failed message log", map[string]any{
+		"channel_id": channelID,
+		"path":       c.failedLogPath,
+	})
+}
+
+func (c *DiscordChannel) sendMessageOnce(ctx context.Context, channelID, content string) (*discordgo.Message, error) {
 	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
 	defer cancel()
 	if err := c.acquireAPISlot(sendCtx); err != nil {
@@ -445,7 +641,7 @@ func (c *DiscordChannel) sendMessage(ctx context.Context, channelID, content str
 	done := make(chan result, 1)
 	go func() {
 		defer c.releaseAPISlot()
-		msg, err := c.session.ChannelMessageSend(channelID, content)
+		msg, err := c.sendAPI(channelID, content)
 		done <- result{msg: msg, err: err}
 	}()
 
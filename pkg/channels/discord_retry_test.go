@@ -0,0 +1,119 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+	"github.com/dotsetgreg/dotagent/pkg/config"
+)
+
+func newRetryTestDiscordChannel(t *testing.T, maxAttempts int, send func(attempt int) error) (*DiscordChannel, *int) {
+	t.Helper()
+	c, err := NewDiscordChannel(config.DiscordConfig{
+		Token: "test",
+		Retry: config.DiscordRetryConfig{MaxAttempts: maxAttempts, BaseDelayMS: 1},
+	}, bus.NewMessageBus())
+	if err != nil {
+		t.Fatalf("new channel: %v", err)
+	}
+	c.setRunning(true)
+	c.SetFailedMessageLog(filepath.Join(t.TempDir(), "workspace", "failed_messages.log"))
+	calls := 0
+	c.sendAPI = func(channelID, content string) (*discordgo.Message, error) {
+		calls++
+		if err := send(calls); err != nil {
+			return nil, err
+		}
+		return &discordgo.Message{ID: "m1", ChannelID: channelID, Content: content}, nil
+	}
+	return c, &calls
+}
+
+func discordHTTPError(status int) error {
+	return &discordgo.RESTError{Response: &http.Response{StatusCode: status, Status: http.StatusText(status)}}
+}
+
+func TestDiscordSend_RetriesTransientFailures(t *testing.T) {
+	c, calls := newRetryTestDiscordChannel(t, 3, func(attempt int) error {
+		switch attempt {
+		case 1:
+			return discordHTTPError(http.StatusBadGateway)
+		case 2:
+			return &discordgo.RateLimitError{RateLimit: &discordgo.RateLimit{TooManyRequests: &discordgo.TooManyRequests{RetryAfter: 5 * time.Millisecond}}}
+		}
+		return nil
+	})
+
+	if err := c.Send(context.Background(), bus.OutboundMessage{ChatID: "123", Content: "hello"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if *calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", *calls)
+	}
+	if _, err := os.Stat(c.failedLogPath); !os.IsNotExist(err) {
+		t.Fatalf("expected no failed message log, got %v", err)
+	}
+}
+
+func TestDiscordSend_LogsMessageAfterFinalFailure(t *testing.T) {
+	c, calls := newRetryTestDiscordChannel(t, 3, func(int) error {
+		return errors.New("connection reset by peer")
+	})
+
+	err := c.Send(context.Background(), bus.OutboundMessage{ChatID: "123", Content: "the answer is 42"})
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("expected failure after 3 attempts, got %v", err)
+	}
+	if *calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", *calls)
+	}
+
+	data, err := os.ReadFile(c.failedLogPath)
+	if err != nil {
+		t.Fatalf("read failed message log: %v", err)
+	}
+	var entry failedDiscordMessage
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("decode log line %q: %v", data, err)
+	}
+	if entry.SessionKey != "discord:123" || entry.Content != "the answer is 42" || entry.Time == "" || !strings.Contains(entry.Error, "connection reset") {
+		t.Fatalf("unexpected log entry: %+v", entry)
+	}
+}
+
+func TestDiscordSend_DoesNotRetryClientErrors(t *testing.T) {
+	c, calls := newRetryTestDiscordChannel(t, 3, func(int) error {
+		return discordHTTPError(http.StatusForbidden)
+	})
+
+	if err := c.Send(context.Background(), bus.OutboundMessage{ChatID: "123", Content: "hi"}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if *calls != 1 {
+		t.Fatalf("expected a single attempt for a 403, got %d", *calls)
+	}
+	if _, err := os.Stat(c.failedLogPath); err != nil {
+		t.Fatalf("expected the message in the failed message log: %v", err)
+	}
+}
+
+func TestDiscordRetryDelay_BacksOffWithJitter(t *testing.T) {
+	base := 100 * time.Millisecond
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 20: discordRetryMaxDelay} {
+		for i := 0; i < 20; i++ {
+			got := discordRetryDelay(errors.New("boom"), base, attempt)
+			if got < want/2 || got >= want*3/2 {
+				t.Fatalf("attempt %d: delay %v outside [%v, %v)", attempt, got, want/2, want*3/2)
+			}
+		}
+	}
+}
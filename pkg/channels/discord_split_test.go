@@ -0,0 +1,94 @@
+package channels
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitDiscordMessage_ShortContentIsUnchanged(t *testing.T) {
+	parts := splitDiscordMessage("  hello there  ", 2000, "paragraph")
+	if len(parts) != 1 || parts[0] != "hello there" {
+		t.Fatalf("unexpected parts: %q", parts)
+	}
+	if parts := splitDiscordMessage("   ", 2000, "paragraph"); len(parts) != 0 {
+		t.Fatalf("expected no parts for blank content, got %q", parts)
+	}
+}
+
+func TestSplitDiscordMessage_ParagraphStrategy(t *testing.T) {
+	para := strings.Repeat("word ", 30) + "end."
+	content := strings.Join([]string{para, para, para, para}, "\n\n")
+	parts := splitDiscordMessage(content, 350, "paragraph")
+
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 parts, got %d: %q", len(parts), parts)
+	}
+	for i, part := range parts {
+		if utf8.RuneCountInString(part) > 350 {
+			t.Fatalf("part %d is %d characters", i, utf8.RuneCountInString(part))
+		}
+	}
+	if want := "(1/2) " + para + "\n\n" + para; parts[0] != want {
+		t.Fatalf("expected the first part to end at a paragraph, got %q", parts[0])
+	}
+	if !strings.HasPrefix(parts[1], "(2/2) word") {
+		t.Fatalf("unexpected second part: %q", parts[1])
+	}
+}
+
+func TestSplitDiscordMessage_SentenceAndWordStrategies(t *testing.T) {
+	content := strings.Repeat("This is a sentence. ", 12)
+	for _, part := range splitDiscordMessage(content, 100, "sentence") {
+		if !strings.HasSuffix(part, ".") {
+			t.Fatalf("sentence split ended mid-sentence: %q", part)
+		}
+	}
+
+	content = strings.Repeat("naïve ", 60)
+	parts := splitDiscordMessage(content, 100, "word")
+	for i, part := range parts {
+		if utf8.RuneCountInString(part) > 100 || strings.HasSuffix(part, "naï") {
+			t.Fatalf("part %d split badly: %q", i, part)
+		}
+		if !utf8.ValidString(part) {
+			t.Fatalf("part %d is not valid UTF-8", i)
+		}
+	}
+	if !strings.HasPrefix(parts[len(parts)-1], "(4/4) ") {
+		t.Fatalf("expected 4 numbered parts, last is %q", parts[len(parts)-1])
+	}
+}
+
+func TestSplitDiscordMessage_ReopensCodeFences(t *testing.T) {
+	code := strings.Repeat("fmt.Println(\"line\")\n", 20)
+	content := "Here is the code:\n```go\n" + code + "```\nDone."
+	parts := splitDiscordMessage(content, 200, "paragraph")
+	if len(parts) < 2 {
+		t.Fatalf("expected several parts, got %q", parts)
+	}
+	for i, part := range parts {
+		if utf8.RuneCountInString(part) > 200 {
+			t.Fatalf("part %d is %d characters", i, utf8.RuneCountInString(part))
+		}
+		if strings.Count(part, "```")%2 != 0 {
+			t.Fatalf("part %d leaves a code fence open: %q", i, part)
+		}
+		if i > 0 && i < len(parts)-1 && !strings.Contains(part, ")\n```go\n") {
+			t.Fatalf("part %d should reopen the go fence on its own line: %q", i, part)
+		}
+	}
+}
+
+func TestSplitDiscordMessage_WidensPrefixForManyParts(t *testing.T) {
+	content := strings.Repeat("abcdefghi ", 150)
+	parts := splitDiscordMessage(content, 100, "word")
+	if len(parts) < 10 {
+		t.Fatalf("expected at least 10 parts, got %d", len(parts))
+	}
+	for i, part := range parts {
+		if utf8.RuneCountInString(part) > 100 {
+			t.Fatalf("part %d is %d characters: %q", i, utf8.RuneCountInString(part), part)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package channels
+
+import "testing"
+
+func TestValidateDiscordToken(t *testing.T) {
+	valid := "MTIzNDU2Nzg5MDEyMzQ1Njc4.GaBcDe.abcdefghijklmnopqrstuvwxyz0123456789_-"
+	for _, token := range []string{valid, "Bot " + valid, "  " + valid + "\n"} {
+		if err := ValidateDiscordToken(token); err != nil {
+			t.Fatalf("ValidateDiscordToken(%q): %v", token, err)
+		}
+	}
+	for _, token := range []string{"", "   ", "not-a-token", "abc.def.ghi", valid + ".extra", "MTIzNDU2Nzg5MDEyMzQ1Njc4.Ga Be.abcdefghijklmnopqrstuvwxyz"} {
+		if err := ValidateDiscordToken(token); err == nil {
+			t.Fatalf("expected %q to be rejected", token)
+		}
+	}
+}
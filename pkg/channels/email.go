@@ -0,0 +1,489 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+	"github.com/dotsetgreg/dotagent/pkg/config"
+	"github.com/dotsetgreg/dotagent/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	emailIOTimeout    = 30 * time.Second
+	emailMaxBatch     = 20
+	emailMaxBodyBytes = 64 * 1024
+)
+
+var (
+	htmlTagPattern = regexp.MustCompile(`(?s)<[^>]*>`)
+	// authResultsCommentPattern matches RFC 5322 comments such as
+	// "(p=none dis=none)" inside an Authentication-Results header.
+	authResultsCommentPattern = regexp.MustCompile(`\([^()]*\)`)
+)
+
+// EmailChannel polls an IMAP mailbox for unread mail and replies over SMTP.
+// Each sender address is its own chat, so the session key is "email:<address>".
+type EmailChannel struct {
+	*BaseChannel
+	config   config.EmailConfig
+	from     string
+	dial     func(ctx context.Context) (net.Conn, error)
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+	threadsMu sync.Mutex
+	threads   map[string]emailThread
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// emailThread remembers the last inbound subject and Message-ID per sender so
+// replies can thread correctly.
+type emailThread struct {
+	subject   string
+	messageID string
+}
+
+// emailMessage is the subset of a parsed inbound email the channel uses.
+type emailMessage struct {
+	From      string
+	Subject   string
+	MessageID string
+	Body      string
+	// AuthResults holds the Authentication-Results headers, topmost first.
+	AuthResults []string
+}
+
+func NewEmailChannel(cfg config.EmailConfig, bus *bus.MessageBus) (*EmailChannel, error) {
+	if strings.TrimSpace(cfg.IMAPHost) == "" || strings.TrimSpace(cfg.IMAPUser) == "" {
+		return nil, fmt.Errorf("channels.email.imap_host and channels.email.imap_user are required")
+	}
+	if strings.TrimSpace(cfg.SMTPHost) == "" {
+		return nil, fmt.Errorf("channels.email.smtp_host is required")
+	}
+	from := strings.TrimSpace(cfg.FromAddress)
+	if from == "" {
+		from = strings.TrimSpace(cfg.IMAPUser)
+	}
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("channels.email.from_address must be an email address when imap_user is not: %w", err)
+	}
+	if strings.TrimSpace(cfg.Mailbox) == "" {
+		cfg.Mailbox = "INBOX"
+	}
+	if cfg.PollIntervalSeconds <= 0 {
+		cfg.PollIntervalSeconds = 60
+	}
+
+	c := &EmailChannel{
+		BaseChannel: NewBaseChannel("email", cfg, bus, cfg.AllowFrom),
+		config:      cfg,
+		from:        strings.ToLower(addr.Address),
+		sendMail:    smtp.SendMail,
+		threads:     make(map[string]emailThread),
+	}
+	c.dial = c.dialIMAP
+	return c, nil
+}
+
+func (c *EmailChannel) Start(ctx context.Context) error {
+	logger.InfoCF("email", "Starting email channel", map[string]any{
+		"imap_host": c.config.IMAPHost,
+		"mailbox":   c.config.Mailbox,
+		"interval":  c.config.PollIntervalSeconds,
+	})
+
+	pollCtx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	c.setRunning(true)
+	go c.pollLoop(pollCtx)
+	return nil
+}
+
+func (c *EmailChannel) Stop(ctx context.Context) error {
+	logger.InfoC("email", "Stopping email channel")
+	c.setRunning(false)
+	if c.cancel != nil {
+		c.cancel()
+	}
+	if c.done != nil {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (c *EmailChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	if !c.IsRunning() {
+		return fmt.Errorf("email channel not running")
+	}
+	// Email cannot be edited after sending, so only complete responses go out.
+	if msg.Stream && !msg.StreamFinal {
+		return nil
+	}
+	content := strings.TrimSpace(msg.Content)
+	if content == "" {
+		return nil
+	}
+	to, err := mail.ParseAddress(msg.ChatID)
+	if err != nil {
+		return fmt.Errorf("invalid email recipient %q: %w", msg.ChatID, err)
+	}
+
+	c.threadsMu.Lock()
+	thread := c.threads[strings.ToLower(to.Address)]
+	c.threadsMu.Unlock()
+
+	body := buildEmailReply(c.from, to.Address, replySubject(thread.subject), thread.messageID, content)
+	if err := c.sendMail(hostWithDefaultPort(c.config.SMTPHost, "587"), c.smtpAuth(), c.from, []string{to.Address}, body); err != nil {
+		return fmt.Errorf("send email to %s: %w", to.Address, err)
+	}
+	return nil
+}
+
+func (c *EmailChannel) pollLoop(ctx context.Context) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(time.Duration(c.config.PollIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if err := c.pollOnce(ctx); err != nil && ctx.Err() == nil {
+			logger.WarnCF("email", "Mailbox poll failed", map[string]any{
+				"imap_host": c.config.IMAPHost,
+				"error":     err.Error(),
+			})
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollOnce fetches unread messages, publishes them, and marks them read.
+func (c *EmailChannel) pollOnce(ctx context.Context) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("connect imap: %w", err)
+	}
+	client, err := newIMAPClient(conn, emailIOTimeout)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Logout()
+
+	if err := client.Login(c.config.IMAPUser, c.config.IMAPPassword); err != nil {
+		return err
+	}
+	if err := client.Select(c.config.Mailbox); err != nil {
+		return err
+	}
+	uids, err := client.SearchUnseen()
+	if err != nil {
+		return err
+	}
+	if len(uids) > emailMaxBatch {
+		uids = uids[:emailMaxBatch]
+	}
+
+	for _, uid := range uids {
+		if ctx.Err() != nil {
+			return nil
+		}
+		raw, err := client.FetchRaw(uid)
+		if errors.Is(err, errIMAPLiteralTooLarge) {
+			logger.WarnCF("email", "Skipping oversized email", map[string]any{
+				"uid":   uid,
+				"limit": imapMaxLiteralBytes,
+			})
+		} else if err != nil {
+			return err
+		} else if err := c.handleRaw(raw); err != nil {
+			logger.WarnCF("email", "Skipping unparseable email", map[string]any{
+				"uid":   uid,
+				"error": err.Error(),
+			})
+		}
+		// Mark as read even when skipped so a bad message is not retried forever.
+		if err := client.MarkSeen(uid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *EmailChannel) handleRaw(raw []byte) error {
+	msg, err := parseEmail(raw)
+	if err != nil {
+		return err
+	}
+	if msg.From == c.from {
+		return nil
+	}
+	if !c.config.AllowUnauthenticated && !senderAuthenticated(msg.AuthResults, msg.From, c.config.AuthServID) {
+		logger.WarnCF("email", "Ignoring email without a passing DKIM or DMARC result for its sender", map[string]any{
+			"from": msg.From,
+		})
+		return nil
+	}
+	if !c.IsAllowed(msg.From) {
+		logger.DebugCF("email", "Ignoring email from sender not in allow_from", map[string]any{
+			"from": msg.From,
+		})
+		return nil
+	}
+
+	c.threadsMu.Lock()
+	c.threads[msg.From] = emailThread{subject: msg.Subject, messageID: msg.MessageID}
+	c.threadsMu.Unlock()
+
+	content := msg.Body
+	if msg.Subject != "" {
+		content = strings.TrimSpace(msg.Subject + "\n\n" + msg.Body)
+	}
+	if content == "" {
+		return nil
+	}
+	c.HandleMessage(msg.From, msg.From, msg.MessageID, content, nil, map[string]string{
+		"subject":    msg.Subject,
+		"message_id": msg.MessageID,
+	})
+	return nil
+}
+
+func (c *EmailChannel) dialIMAP(ctx context.Context) (net.Conn, error) {
+	addr := hostWithDefaultPort(c.config.IMAPHost, "993")
+	host, _, _ := net.SplitHostPort(addr)
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: emailIOTimeout},
+		Config:    &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12},
+	}
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+func (c *EmailChannel) smtpAuth() smtp.Auth {
+	user := strings.TrimSpace(c.config.SMTPUser)
+	password := c.config.SMTPPassword
+	if user == "" {
+		user = c.config.IMAPUser
+		password = c.config.IMAPPassword
+	}
+	host, _, err := net.SplitHostPort(hostWithDefaultPort(c.config.SMTPHost, "587"))
+	if err != nil {
+		host = c.config.SMTPHost
+	}
+	return smtp.PlainAuth("", user, password, host)
+}
+
+func hostWithDefaultPort(host, port string) string {
+	host = strings.TrimSpace(host)
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
+
+func parseEmail(raw []byte) (emailMessage, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return emailMessage{}, fmt.Errorf("parse email: %w", err)
+	}
+	from, err := mail.ParseAddress(msg.Header.Get("From"))
+	if err != nil {
+		return emailMessage{}, fmt.Errorf("parse From header: %w", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+	body, _, err := extractTextBody(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return emailMessage{}, err
+	}
+	return emailMessage{
+		From:        strings.ToLower(from.Address),
+		Subject:     strings.TrimSpace(subject),
+		MessageID:   strings.TrimSpace(msg.Header.Get("Message-Id")),
+		Body:        strings.TrimSpace(body),
+		AuthResults: msg.Header["Authentication-Results"],
+	}, nil
+}
+
+// senderAuthenticated reports whether the receiving server vouched for the
+// From domain (RFC 8601): dkim=pass with an aligned header.d, or dmarc=pass
+// with a matching header.from. Only the topmost Authentication-Results header
+// is considered unless authServID is set, in which case every header stamped
+// with that authserv-id is; headers added by the sender are never trusted.
+func senderAuthenticated(results []string, from, authServID string) bool {
+	_, domain, ok := strings.Cut(from, "@")
+	if !ok || domain == "" {
+		return false
+	}
+	domain = strings.ToLower(domain)
+	authServID = strings.ToLower(strings.TrimSpace(authServID))
+	if authServID == "" && len(results) > 1 {
+		results = results[:1]
+	}
+	for _, header := range results {
+		parts := strings.Split(authResultsCommentPattern.ReplaceAllString(header, " "), ";")
+		if authServID != "" {
+			id := strings.Fields(parts[0])
+			if len(id) == 0 || strings.ToLower(id[0]) != authServID {
+				continue
+			}
+		}
+		for _, part := range parts[1:] {
+			fields := strings.Fields(strings.ToLower(part))
+			if len(fields) == 0 {
+				continue
+			}
+			var want string
+			switch fields[0] {
+			case "dkim=pass":
+				want = "header.d="
+			case "dmarc=pass":
+				want = "header.from="
+			default:
+				continue
+			}
+			for _, prop := range fields[1:] {
+				value, ok := strings.CutPrefix(prop, want)
+				if ok && domainAligned(domain, strings.Trim(value, `"`)) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// domainAligned applies DMARC relaxed alignment: the From domain must equal
+// the authenticated domain or be a subdomain of it.
+func domainAligned(from, authenticated string) bool {
+	if authenticated == "" {
+		return false
+	}
+	return from == authenticated || strings.HasSuffix(from, "."+authenticated)
+}
+
+// extractTextBody returns the best text rendering of a MIME entity, preferring
+// text/plain over text/html. The bool reports whether the text came from HTML.
+func extractTextBody(contentType, encoding string, r io.Reader) (string, bool, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "" {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(r, params["boundary"])
+		var htmlFallback string
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", false, fmt.Errorf("read multipart email: %w", err)
+			}
+			partType := part.Header.Get("Content-Type")
+			if partType == "" {
+				partType = "text/plain"
+			}
+			text, fromHTML, err := extractTextBody(partType, part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return "", false, err
+			}
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+			if !fromHTML {
+				return text, false, nil
+			}
+			if htmlFallback == "" {
+				htmlFallback = text
+			}
+		}
+		return htmlFallback, htmlFallback != "", nil
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", false, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, emailMaxBodyBytes))
+	if err != nil {
+		return "", false, fmt.Errorf("read email body: %w", err)
+	}
+	text := string(data)
+	if mediaType == "text/html" {
+		return html.UnescapeString(htmlTagPattern.ReplaceAllString(text, "")), true, nil
+	}
+	return text, false, nil
+}
+
+func replySubject(subject string) string {
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		return "Re: (no subject)"
+	}
+	if strings.HasPrefix(strings.ToLower(subject), "re:") {
+		return subject
+	}
+	return "Re: " + subject
+}
+
+func buildEmailReply(from, to, subject, inReplyTo, body string) []byte {
+	clean := func(v string) string {
+		return strings.NewReplacer("\r", " ", "\n", " ").Replace(v)
+	}
+	domain := from[strings.LastIndex(from, "@")+1:]
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", clean(from))
+	fmt.Fprintf(&buf, "To: %s\r\n", clean(to))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", clean(subject)))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", uuid.NewString(), clean(domain))
+	if inReplyTo = clean(inReplyTo); inReplyTo != "" {
+		fmt.Fprintf(&buf, "In-Reply-To: %s\r\n", inReplyTo)
+		fmt.Fprintf(&buf, "References: %s\r\n", inReplyTo)
+	}
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&buf)
+	_, _ = qp.Write([]byte(body))
+	_ = qp.Close()
+	return buf.Bytes()
+}
@@ -0,0 +1,265 @@
+package channels
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+	"github.com/dotsetgreg/dotagent/pkg/config"
+)
+
+const testInboundEmail = "Authentication-Results: mx.example.com;\r\n" +
+	"\tdkim=pass header.d=example.com header.s=s1;\r\n" +
+	"\tdmarc=pass (p=reject) header.from=example.com\r\n" +
+	"From: Alice <Alice@Example.com>\r\n" +
+	"To: agent@example.com\r\n" +
+	"Subject: =?utf-8?q?Weekend_plans?=\r\n" +
+	"Message-Id: <m1@example.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/alternative; boundary=b1\r\n" +
+	"\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/html\r\n" +
+	"\r\n" +
+	"<p>ignored html</p>\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Can you book a table for =\r\ntwo?\r\n" +
+	"--b1--\r\n"
+
+// fakeIMAPServer answers the command sequence used by pollOnce on one side
+// of a net.Pipe and records the commands it received.
+func fakeIMAPServer(t *testing.T, conn net.Conn, message string) *[]string {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		commands []string
+	)
+	go func() {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		fmt.Fprint(conn, "* OK IMAP4rev1 ready\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			tag, cmd, _ := strings.Cut(strings.TrimSpace(line), " ")
+			mu.Lock()
+			commands = append(commands, cmd)
+			mu.Unlock()
+			switch {
+			case strings.HasPrefix(cmd, "UID SEARCH"):
+				fmt.Fprint(conn, "* SEARCH 7\r\n")
+			case strings.HasPrefix(cmd, "UID FETCH"):
+				fmt.Fprintf(conn, "* 1 FETCH (UID 7 BODY[] {%d}\r\n%s)\r\n", len(message), message)
+			case cmd == "LOGOUT":
+				fmt.Fprintf(conn, "* BYE\r\n%s OK LOGOUT completed\r\n", tag)
+				return
+			}
+			fmt.Fprintf(conn, "%s OK done\r\n", tag)
+		}
+	}()
+	return &commands
+}
+
+func newTestEmailChannel(t *testing.T, msgBus *bus.MessageBus) *EmailChannel {
+	t.Helper()
+	ch, err := NewEmailChannel(config.EmailConfig{
+		IMAPHost:     "imap.example.com",
+		IMAPUser:     "agent@example.com",
+		IMAPPassword: "secret",
+		SMTPHost:     "smtp.example.com",
+	}, msgBus)
+	if err != nil {
+		t.Fatalf("new email channel: %v", err)
+	}
+	return ch
+}
+
+func TestEmailChannel_PollPublishesAndMarksSeen(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	ch := newTestEmailChannel(t, msgBus)
+
+	client, server := net.Pipe()
+	commands := fakeIMAPServer(t, server, testInboundEmail)
+	ch.dial = func(context.Context) (net.Conn, error) { return client, nil }
+
+	if err := ch.pollOnce(context.Background()); err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, ok := msgBus.ConsumeInbound(ctx)
+	if !ok {
+		t.Fatalf("expected inbound message")
+	}
+	if msg.SessionKey != "email:alice@example.com" || msg.ChatID != "alice@example.com" {
+		t.Fatalf("unexpected routing: %+v", msg)
+	}
+	if msg.Content != "Weekend plans\n\nCan you book a table for two?" {
+		t.Fatalf("unexpected content: %q", msg.Content)
+	}
+	if msg.MessageID != "<m1@example.com>" {
+		t.Fatalf("unexpected message id: %q", msg.MessageID)
+	}
+
+	joined := strings.Join(*commands, "\n")
+	if !strings.Contains(joined, `UID STORE 7 +FLAGS.SILENT (\Seen)`) {
+		t.Fatalf("expected message to be marked seen, commands:\n%s", joined)
+	}
+}
+
+func TestEmailChannel_PollSkipsOversizedMessages(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	ch := newTestEmailChannel(t, msgBus)
+
+	client, server := net.Pipe()
+	oversized := testInboundEmail + strings.Repeat("x", imapMaxLiteralBytes)
+	commands := fakeIMAPServer(t, server, oversized)
+	ch.dial = func(context.Context) (net.Conn, error) { return client, nil }
+
+	if err := ch.pollOnce(context.Background()); err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if msg, ok := msgBus.ConsumeInbound(ctx); ok {
+		t.Fatalf("expected oversized email to be skipped, got %+v", msg)
+	}
+	joined := strings.Join(*commands, "\n")
+	if !strings.Contains(joined, `UID STORE 7 +FLAGS.SILENT (\Seen)`) {
+		t.Fatalf("expected oversized message to be marked seen, commands:\n%s", joined)
+	}
+}
+
+func TestEmailChannel_SendRepliesWithSubject(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	ch := newTestEmailChannel(t, msgBus)
+	ch.setRunning(true)
+	if err := ch.handleRaw([]byte(testInboundEmail)); err != nil {
+		t.Fatalf("handle raw: %v", err)
+	}
+
+	var (
+		sentTo   []string
+		sentBody string
+		sentAddr string
+	)
+	ch.sendMail = func(addr string, _ smtp.Auth, from string, to []string, msg []byte) error {
+		sentAddr, sentTo, sentBody = addr, to, string(msg)
+		return nil
+	}
+
+	if err := ch.Send(context.Background(), bus.OutboundMessage{Channel: "email", ChatID: "alice@example.com", Content: "partial", Stream: true}); err != nil {
+		t.Fatalf("send stream delta: %v", err)
+	}
+	if sentBody != "" {
+		t.Fatalf("expected stream deltas to be skipped")
+	}
+
+	if err := ch.Send(context.Background(), bus.OutboundMessage{Channel: "email", ChatID: "alice@example.com", Content: "Booked for 7pm."}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if sentAddr != "smtp.example.com:587" || len(sentTo) != 1 || sentTo[0] != "alice@example.com" {
+		t.Fatalf("unexpected envelope: addr=%s to=%v", sentAddr, sentTo)
+	}
+	for _, want := range []string{"Subject: Re: Weekend plans\r\n", "In-Reply-To: <m1@example.com>\r\n", "Booked for 7pm."} {
+		if !strings.Contains(sentBody, want) {
+			t.Fatalf("expected reply to contain %q, got:\n%s", want, sentBody)
+		}
+	}
+}
+
+func TestEmailChannel_DropsUnauthenticatedSenders(t *testing.T) {
+	// The sender stamps its own passing result below a failing one from the
+	// receiving server; only the topmost header may be trusted.
+	unsigned := testInboundEmail[strings.Index(testInboundEmail, "From: "):]
+	cases := map[string]string{
+		"no results": unsigned,
+		"forged below": "Authentication-Results: mx.example.com; dkim=none; dmarc=fail header.from=example.com\r\n" +
+			"Authentication-Results: mx.example.com; dkim=pass header.d=example.com\r\n" +
+			unsigned,
+	}
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			msgBus := bus.NewMessageBus()
+			defer msgBus.Close()
+			ch := newTestEmailChannel(t, msgBus)
+			if err := ch.handleRaw([]byte(raw)); err != nil {
+				t.Fatalf("handle raw: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			if msg, ok := msgBus.ConsumeInbound(ctx); ok {
+				t.Fatalf("expected unauthenticated email to be dropped, got %+v", msg)
+			}
+		})
+	}
+}
+
+func TestEmailChannel_AllowUnauthenticatedOptOut(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	ch := newTestEmailChannel(t, msgBus)
+	ch.config.AllowUnauthenticated = true
+	if err := ch.handleRaw([]byte(testInboundEmail[strings.Index(testInboundEmail, "From: "):])); err != nil {
+		t.Fatalf("handle raw: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, ok := msgBus.ConsumeInbound(ctx); !ok {
+		t.Fatalf("expected email to be accepted with allow_unauthenticated")
+	}
+}
+
+func TestSenderAuthenticated(t *testing.T) {
+	cases := []struct {
+		name       string
+		results    []string
+		from       string
+		authServID string
+		want       bool
+	}{
+		{"dkim pass", []string{"mx.example.net; dkim=pass header.d=example.com"}, "alice@example.com", "", true},
+		{"dkim subdomain aligned", []string{"mx.example.net; dkim=pass header.d=example.com"}, "alice@mail.example.com", "", true},
+		{"dmarc pass", []string{"mx.example.net; spf=fail; dmarc=pass (p=none) header.from=example.com"}, "alice@example.com", "", true},
+		{"dkim for other domain", []string{"mx.example.net; dkim=pass header.d=evil.test"}, "alice@example.com", "", false},
+		{"dkim fail", []string{"mx.example.net; dkim=fail header.d=example.com"}, "alice@example.com", "", false},
+		{"pass hidden in comment", []string{"mx.example.net; dkim=none (dkim=pass header.d=example.com)"}, "alice@example.com", "", false},
+		{"lower header ignored", []string{"mx.example.net; dkim=none", "mx.example.net; dkim=pass header.d=example.com"}, "alice@example.com", "", false},
+		{"authserv id match", []string{"other.test; dkim=pass header.d=example.com", "MX.example.net; dkim=pass header.d=example.com"}, "alice@example.com", "mx.example.net", true},
+		{"authserv id mismatch", []string{"other.test; dkim=pass header.d=example.com"}, "alice@example.com", "mx.example.net", false},
+	}
+	for _, tc := range cases {
+		if got := senderAuthenticated(tc.results, tc.from, tc.authServID); got != tc.want {
+			t.Fatalf("%s: senderAuthenticated = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestReplySubject(t *testing.T) {
+	cases := map[string]string{
+		"":            "Re: (no subject)",
+		"Hello":       "Re: Hello",
+		"RE: already": "RE: already",
+	}
+	for in, want := range cases {
+		if got := replySubject(in); got != want {
+			t.Fatalf("replySubject(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
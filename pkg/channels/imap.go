@@ -0,0 +1,213 @@
+package channels
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// imapMaxLiteralBytes caps a single server literal. Anything larger is
+	// discarded unread rather than buffered, so a hostile or confused server
+	// cannot make the client allocate an arbitrary {n}.
+	imapMaxLiteralBytes = 16 * emailMaxBodyBytes
+	// imapLiteralChunk is the initial buffer for an accepted literal; it
+	// grows only as bytes actually arrive.
+	imapLiteralChunk = 32 * 1024
+)
+
+// errIMAPLiteralTooLarge reports a fetched message over imapMaxLiteralBytes.
+var errIMAPLiteralTooLarge = errors.New("message exceeds the size limit")
+
+// imapClient implements the handful of IMAP4rev1 commands the email channel
+// needs: LOGIN, SELECT, UID SEARCH, UID FETCH, UID STORE and LOGOUT.
+type imapClient struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	tag     int
+	timeout time.Duration
+}
+
+// imapResponse is one untagged response line with any literals it carried.
+type imapResponse struct {
+	Text     string
+	Literals [][]byte
+}
+
+func newIMAPClient(conn net.Conn, timeout time.Duration) (*imapClient, error) {
+	c := &imapClient{conn: conn, r: bufio.NewReader(conn), timeout: timeout}
+	c.setDeadline()
+	greeting, _, err := c.readLine()
+	if err != nil {
+		return nil, fmt.Errorf("read imap greeting: %w", err)
+	}
+	if !strings.HasPrefix(greeting, "* OK") && !strings.HasPrefix(greeting, "* PREAUTH") {
+		return nil, fmt.Errorf("unexpected imap greeting: %s", greeting)
+	}
+	return c, nil
+}
+
+func (c *imapClient) Login(user, password string) error {
+	_, err := c.command("LOGIN " + imapQuote(user) + " " + imapQuote(password))
+	if err != nil {
+		return fmt.Errorf("imap login: %w", err)
+	}
+	return nil
+}
+
+func (c *imapClient) Select(mailbox string) error {
+	if _, err := c.command("SELECT " + imapQuote(mailbox)); err != nil {
+		return fmt.Errorf("imap select %s: %w", mailbox, err)
+	}
+	return nil
+}
+
+// SearchUnseen returns the UIDs of messages without the \Seen flag.
+func (c *imapClient) SearchUnseen() ([]uint32, error) {
+	responses, err := c.command("UID SEARCH UNSEEN")
+	if err != nil {
+		return nil, fmt.Errorf("imap search: %w", err)
+	}
+	var uids []uint32
+	for _, resp := range responses {
+		fields := strings.Fields(resp.Text)
+		if len(fields) < 2 || fields[0] != "*" || !strings.EqualFold(fields[1], "SEARCH") {
+			continue
+		}
+		for _, field := range fields[2:] {
+			uid, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				continue
+			}
+			uids = append(uids, uint32(uid))
+		}
+	}
+	return uids, nil
+}
+
+// FetchRaw returns the full RFC 822 message without setting \Seen.
+func (c *imapClient) FetchRaw(uid uint32) ([]byte, error) {
+	responses, err := c.command(fmt.Sprintf("UID FETCH %d (BODY.PEEK[])", uid))
+	if err != nil {
+		return nil, fmt.Errorf("imap fetch %d: %w", uid, err)
+	}
+	for _, resp := range responses {
+		if strings.Contains(strings.ToUpper(resp.Text), "FETCH") && len(resp.Literals) > 0 {
+			if resp.Literals[0] == nil {
+				return nil, fmt.Errorf("imap fetch %d: %w", uid, errIMAPLiteralTooLarge)
+			}
+			return resp.Literals[0], nil
+		}
+	}
+	return nil, fmt.Errorf("imap fetch %d: message body not returned", uid)
+}
+
+func (c *imapClient) MarkSeen(uid uint32) error {
+	if _, err := c.command(fmt.Sprintf(`UID STORE %d +FLAGS.SILENT (\Seen)`, uid)); err != nil {
+		return fmt.Errorf("imap store %d: %w", uid, err)
+	}
+	return nil
+}
+
+func (c *imapClient) Logout() error {
+	_, err := c.command("LOGOUT")
+	closeErr := c.conn.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+func (c *imapClient) command(cmd string) ([]imapResponse, error) {
+	c.tag++
+	tag := fmt.Sprintf("A%03d", c.tag)
+	c.setDeadline()
+	if _, err := io.WriteString(c.conn, tag+" "+cmd+"\r\n"); err != nil {
+		return nil, err
+	}
+
+	var responses []imapResponse
+	for {
+		line, literals, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, tag+" ") {
+			status := strings.TrimPrefix(line, tag+" ")
+			if strings.HasPrefix(strings.ToUpper(status), "OK") {
+				return responses, nil
+			}
+			return nil, fmt.Errorf("%s", status)
+		}
+		responses = append(responses, imapResponse{Text: line, Literals: literals})
+	}
+}
+
+// readLine reads one logical response line, consuming any {n} literals it
+// announces. The returned text has literals elided. Literals over
+// imapMaxLiteralBytes are skipped and returned as nil so the stream stays in
+// sync.
+func (c *imapClient) readLine() (string, [][]byte, error) {
+	var (
+		text     strings.Builder
+		literals [][]byte
+	)
+	for {
+		raw, err := c.r.ReadString('\n')
+		if err != nil {
+			return "", nil, err
+		}
+		raw = strings.TrimRight(raw, "\r\n")
+		size, ok := imapLiteralSize(raw)
+		if !ok {
+			text.WriteString(raw)
+			return text.String(), literals, nil
+		}
+		text.WriteString(raw[:strings.LastIndex(raw, "{")])
+		if size > imapMaxLiteralBytes {
+			if _, err := io.CopyN(io.Discard, c.r, int64(size)); err != nil {
+				return "", nil, err
+			}
+			literals = append(literals, nil)
+			continue
+		}
+		buf := bytes.NewBuffer(make([]byte, 0, min(size, imapLiteralChunk)))
+		if _, err := io.CopyN(buf, c.r, int64(size)); err != nil {
+			return "", nil, err
+		}
+		literals = append(literals, buf.Bytes())
+	}
+}
+
+func (c *imapClient) setDeadline() {
+	if c.timeout > 0 {
+		_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+}
+
+func imapLiteralSize(line string) (int, bool) {
+	if !strings.HasSuffix(line, "}") {
+		return 0, false
+	}
+	open := strings.LastIndex(line, "{")
+	if open < 0 {
+		return 0, false
+	}
+	size, err := strconv.Atoi(strings.TrimSuffix(line[open+1:len(line)-1], "+"))
+	if err != nil || size < 0 {
+		return 0, false
+	}
+	return size, true
+}
+
+func imapQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
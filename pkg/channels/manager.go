@@ -9,6 +9,7 @@ package channels
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -50,6 +51,20 @@ func NewManager(cfg *config.Config, messageBus *bus.MessageBus) (*Manager, error
 	return m, nil
 }
 
+// NewManagerWithChannels builds a manager around the given channels instead
+// of the ones enabled in config, for runs that should not connect to Discord.
+func NewManagerWithChannels(cfg *config.Config, messageBus *bus.MessageBus, channels map[string]Channel) *Manager {
+	m := &Manager{
+		channels: make(map[string]Channel, len(channels)),
+		bus:      messageBus,
+		config:   cfg,
+	}
+	for name, channel := range channels {
+		m.channels[name] = channel
+	}
+	return m
+}
+
 func (m *Manager) initChannels() error {
 	logger.InfoC("channels", "Initializing channel manager")
 
@@ -62,9 +77,33 @@ func (m *Manager) initChannels() error {
 	if err != nil {
 		return fmt.Errorf("initialize Discord channel: %w", err)
 	}
+	discord.SetFailedMessageLog(filepath.Join(m.config.WorkspacePath(), "failed_messages.log"))
 	m.channels["discord"] = discord
 	logger.InfoC("channels", "Discord channel initialized successfully")
 
+	if m.config.Channels.Email.Enabled {
+		email, err := NewEmailChannel(m.config.Channels.Email, m.bus)
+		if err != nil {
+			return fmt.Errorf("initialize email channel: %w", err)
+		}
+		m.channels["email"] = email
+		logger.InfoC("channels", "Email channel initialized successfully")
+	}
+
+	if m.config.Channels.Matrix.Enabled {
+		matrix, err := NewMatrixChannel(m.config.Channels.Matrix, m.bus)
+		if err != nil {
+			return fmt.Errorf("initialize Matrix channel: %w", err)
+		}
+		if m.config.Channels.Matrix.E2EE {
+			if err := matrix.EnableE2EE(filepath.Join(m.config.WorkspacePath(), "matrix")); err != nil {
+				return fmt.Errorf("initialize Matrix encryption: %w", err)
+			}
+		}
+		m.channels["matrix"] = matrix
+		logger.InfoC("channels", "Matrix channel initialized successfully")
+	}
+
 	logger.InfoCF("channels", "Channel initialization completed", map[string]interface{}{
 		"enabled_channels": len(m.channels),
 	})
@@ -0,0 +1,423 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+	"github.com/dotsetgreg/dotagent/pkg/config"
+	"github.com/dotsetgreg/dotagent/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	matrixSyncTimeout  = 30 * time.Second
+	matrixRetryDelay   = 5 * time.Second
+	matrixTimelineSize = 20
+)
+
+// MatrixChannel long-polls the Matrix Client-Server sync API for text messages
+// in the configured rooms and replies with m.room.message events. Each room is
+// its own chat, so the session key is "matrix:<room_id>".
+type MatrixChannel struct {
+	*BaseChannel
+	config     config.MatrixConfig
+	homeserver string
+	rooms      map[string]struct{}
+	client     *http.Client
+	userID     string
+
+	// e2ee is set by EnableE2EE when channels.matrix.e2ee is on.
+	e2ee *matrixE2EE
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// matrixSyncResponse is the subset of /sync the channel reads.
+type matrixSyncResponse struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join map[string]struct {
+			Timeline struct {
+				Events []matrixEvent `json:"events"`
+			} `json:"timeline"`
+		} `json:"join"`
+	} `json:"rooms"`
+	ToDevice struct {
+		Events []matrixEvent `json:"events"`
+	} `json:"to_device"`
+	DeviceLists struct {
+		Changed []string `json:"changed"`
+		Left    []string `json:"left"`
+	} `json:"device_lists"`
+	DeviceOneTimeKeysCount map[string]int `json:"device_one_time_keys_count"`
+}
+
+type matrixEvent struct {
+	Type    string             `json:"type"`
+	Sender  string             `json:"sender"`
+	EventID string             `json:"event_id"`
+	Content matrixEventContent `json:"content"`
+}
+
+// matrixEventContent covers m.room.message and m.room.encrypted content.
+type matrixEventContent struct {
+	MsgType string `json:"msgtype"`
+	Body    string `json:"body"`
+
+	Algorithm  string          `json:"algorithm"`
+	SenderKey  string          `json:"sender_key"`
+	SessionID  string          `json:"session_id"`
+	DeviceID   string          `json:"device_id"`
+	Ciphertext json.RawMessage `json:"ciphertext"`
+}
+
+// matrixAPIError is a non-2xx Client-Server API response.
+type matrixAPIError struct {
+	Method  string
+	Path    string
+	Status  int
+	ErrCode string
+	Message string
+}
+
+func (e *matrixAPIError) Error() string {
+	if e.ErrCode != "" {
+		return fmt.Sprintf("%s %s: %d %s: %s", e.Method, e.Path, e.Status, e.ErrCode, e.Message)
+	}
+	return fmt.Sprintf("%s %s: status %d", e.Method, e.Path, e.Status)
+}
+
+func NewMatrixChannel(cfg config.MatrixConfig, bus *bus.MessageBus) (*MatrixChannel, error) {
+	homeserver := strings.TrimRight(strings.TrimSpace(cfg.HomeserverURL), "/")
+	if homeserver == "" || strings.TrimSpace(cfg.AccessToken) == "" {
+		return nil, fmt.Errorf("channels.matrix.homeserver_url and channels.matrix.access_token are required")
+	}
+	if _, err := url.ParseRequestURI(homeserver); err != nil {
+		return nil, fmt.Errorf("channels.matrix.homeserver_url is invalid: %w", err)
+	}
+	rooms := make(map[string]struct{}, len(cfg.RoomIDs))
+	for _, id := range cfg.RoomIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			rooms[id] = struct{}{}
+		}
+	}
+	if len(rooms) == 0 {
+		return nil, fmt.Errorf("channels.matrix.room_ids must list at least one room")
+	}
+
+	return &MatrixChannel{
+		BaseChannel: NewBaseChannel("matrix", cfg, bus, cfg.AllowFrom),
+		config:      cfg,
+		homeserver:  homeserver,
+		rooms:       rooms,
+		// Long-poll requests stay open for matrixSyncTimeout; leave headroom.
+		client: &http.Client{Timeout: matrixSyncTimeout + 15*time.Second},
+	}, nil
+}
+
+// EnableE2EE turns on end-to-end encryption, keeping the device's keys and
+// ratchet state under dir. Keys are generated and published on Start.
+func (c *MatrixChannel) EnableE2EE(dir string) error {
+	e2ee, err := newMatrixE2EE(dir)
+	if err != nil {
+		return err
+	}
+	e2ee.api = c.do
+	c.e2ee = e2ee
+	return nil
+}
+
+func (c *MatrixChannel) Start(ctx context.Context) error {
+	logger.InfoCF("matrix", "Starting Matrix channel", map[string]any{
+		"homeserver": c.homeserver,
+		"rooms":      len(c.rooms),
+	})
+
+	userID, deviceID, err := c.whoami(ctx)
+	if err != nil {
+		return fmt.Errorf("matrix whoami: %w", err)
+	}
+	c.userID = userID
+
+	if c.e2ee != nil {
+		if err := c.e2ee.setup(ctx, userID, deviceID); err != nil {
+			return fmt.Errorf("matrix e2ee: %w", err)
+		}
+	}
+
+	syncCtx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	c.setRunning(true)
+	go c.syncLoop(syncCtx)
+	return nil
+}
+
+func (c *MatrixChannel) Stop(ctx context.Context) error {
+	logger.InfoC("matrix", "Stopping Matrix channel")
+	c.setRunning(false)
+	if c.cancel != nil {
+		c.cancel()
+	}
+	if c.done != nil {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (c *MatrixChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	if !c.IsRunning() {
+		return fmt.Errorf("matrix channel not running")
+	}
+	// Edits would need m.replace relations; only complete responses go out.
+	if msg.Stream && !msg.StreamFinal {
+		return nil
+	}
+	content := strings.TrimSpace(msg.Content)
+	if content == "" {
+		return nil
+	}
+	roomID := strings.TrimSpace(msg.ChatID)
+	if _, ok := c.rooms[roomID]; !ok {
+		return fmt.Errorf("matrix room %q is not in channels.matrix.room_ids", roomID)
+	}
+
+	eventType := "m.room.message"
+	var payload any = map[string]string{"msgtype": "m.text", "body": content}
+	if c.e2ee != nil {
+		encrypted, err := c.e2ee.roomEncrypted(ctx, roomID)
+		if err != nil {
+			return fmt.Errorf("check matrix room encryption for %s: %w", roomID, err)
+		}
+		if encrypted {
+			if payload, err = c.e2ee.encryptRoomMessage(ctx, roomID, payload.(map[string]string)); err != nil {
+				return fmt.Errorf("encrypt matrix message for %s: %w", roomID, err)
+			}
+			eventType = "m.room.encrypted"
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("/_matrix/client/v3/rooms/%s/send/%s/%s", url.PathEscape(roomID), eventType, url.PathEscape(uuid.NewString()))
+	if err := c.do(ctx, http.MethodPut, path, nil, body, nil); err != nil {
+		return fmt.Errorf("send matrix message to %s: %w", roomID, err)
+	}
+	return nil
+}
+
+func (c *MatrixChannel) syncLoop(ctx context.Context) {
+	defer close(c.done)
+
+	// The first sync only establishes a position so backlog from before
+	// startup is not answered.
+	since := ""
+	for ctx.Err() == nil {
+		timeout := matrixSyncTimeout
+		if since == "" {
+			timeout = 0
+		}
+		next, err := c.syncOnce(ctx, since, timeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.WarnCF("matrix", "Matrix sync failed", map[string]any{
+				"homeserver": c.homeserver,
+				"error":      err.Error(),
+			})
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(matrixRetryDelay):
+			}
+			continue
+		}
+		since = next
+	}
+}
+
+// syncOnce runs one /sync request and publishes new messages. When since is
+// empty the response only seeds the next batch token.
+func (c *MatrixChannel) syncOnce(ctx context.Context, since string, timeout time.Duration) (string, error) {
+	query := url.Values{}
+	query.Set("timeout", fmt.Sprintf("%d", timeout.Milliseconds()))
+	query.Set("filter", c.syncFilter())
+	if since != "" {
+		query.Set("since", since)
+	}
+
+	var resp matrixSyncResponse
+	if err := c.do(ctx, http.MethodGet, "/_matrix/client/v3/sync", query, nil, &resp); err != nil {
+		return since, err
+	}
+	if resp.NextBatch == "" {
+		return since, fmt.Errorf("sync response missing next_batch")
+	}
+	if c.e2ee != nil {
+		// Room keys arrive over to-device messages, which are not part of
+		// the skipped backlog, so handle them even on the first sync.
+		for _, pending := range c.e2ee.handleSync(ctx, resp) {
+			c.handleEvent(pending.roomID, pending.event)
+		}
+	}
+	if since == "" {
+		return resp.NextBatch, nil
+	}
+
+	for roomID, room := range resp.Rooms.Join {
+		if _, ok := c.rooms[roomID]; !ok {
+			continue
+		}
+		for _, event := range room.Timeline.Events {
+			c.handleEvent(roomID, event)
+		}
+	}
+	return resp.NextBatch, nil
+}
+
+func (c *MatrixChannel) handleEvent(roomID string, event matrixEvent) {
+	if event.Type == "m.room.encrypted" && event.Sender != c.userID {
+		if c.e2ee == nil {
+			return
+		}
+		decrypted, err := c.e2ee.decryptRoomEvent(roomID, event)
+		if err != nil {
+			if err != errMatrixMissingRoomKey {
+				logger.WarnCF("matrix", "Failed to decrypt Matrix message", map[string]any{
+					"room":     roomID,
+					"event_id": event.EventID,
+					"error":    err.Error(),
+				})
+			}
+			return
+		}
+		event = decrypted
+	}
+	if event.Type != "m.room.message" || event.Content.MsgType != "m.text" {
+		return
+	}
+	if event.Sender == c.userID {
+		return
+	}
+	content := strings.TrimSpace(event.Content.Body)
+	if content == "" {
+		return
+	}
+	if !c.IsAllowed(event.Sender) {
+		logger.DebugCF("matrix", "Ignoring message from sender not in allow_from", map[string]any{
+			"sender": event.Sender,
+			"room":   roomID,
+		})
+		return
+	}
+	c.HandleMessage(event.Sender, roomID, event.EventID, content, nil, map[string]string{
+		"event_id": event.EventID,
+		"room_id":  roomID,
+	})
+}
+
+func (c *MatrixChannel) syncFilter() string {
+	rooms := make([]string, 0, len(c.rooms))
+	for id := range c.rooms {
+		rooms = append(rooms, id)
+	}
+	types := []string{"m.room.message"}
+	if c.e2ee != nil {
+		types = append(types, "m.room.encrypted")
+	}
+	filter, _ := json.Marshal(map[string]any{
+		"presence":     map[string]any{"not_types": []string{"*"}},
+		"account_data": map[string]any{"not_types": []string{"*"}},
+		"room": map[string]any{
+			"rooms":     rooms,
+			"state":     map[string]any{"lazy_load_members": true},
+			"ephemeral": map[string]any{"not_types": []string{"*"}},
+			"timeline": map[string]any{
+				"types": types,
+				"limit": matrixTimelineSize,
+			},
+		},
+	})
+	return string(filter)
+}
+
+// whoami returns the token's user ID and, when the token was issued to a
+// device, its device ID.
+func (c *MatrixChannel) whoami(ctx context.Context) (string, string, error) {
+	var resp struct {
+		UserID   string `json:"user_id"`
+		DeviceID string `json:"device_id"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/_matrix/client/v3/account/whoami", nil, nil, &resp); err != nil {
+		return "", "", err
+	}
+	if resp.UserID == "" {
+		return "", "", fmt.Errorf("whoami response missing user_id")
+	}
+	return resp.UserID, resp.DeviceID, nil
+}
+
+// do sends an authenticated Client-Server API request and decodes a JSON
+// response into out when out is non-nil.
+func (c *MatrixChannel) do(ctx context.Context, method, path string, query url.Values, body []byte, out any) error {
+	endpoint := c.homeserver + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		apiErr := &matrixAPIError{Method: method, Path: path, Status: resp.StatusCode}
+		var payload struct {
+			ErrCode string `json:"errcode"`
+			Error   string `json:"error"`
+		}
+		if json.Unmarshal(raw, &payload) == nil && payload.ErrCode != "" {
+			apiErr.ErrCode, apiErr.Message = payload.ErrCode, payload.Error
+		}
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("decode %s response: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,1183 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/logger"
+	"github.com/google/uuid"
+	olmed25519 "maunium.net/go/mautrix/crypto/ed25519"
+	"maunium.net/go/mautrix/crypto/goolm/account"
+)
+
+const (
+	matrixOlmAlgorithm    = "m.olm.v1.curve25519-aes-sha2"
+	matrixMegolmAlgorithm = "m.megolm.v1.aes-sha2"
+	matrixSignedCurveKey  = "signed_curve25519"
+
+	matrixDeviceKeysFile = "device_keys.json"
+	matrixSessionsFile   = "sessions.json"
+
+	// matrixOneTimeKeyTarget is how many one-time keys we keep published;
+	// the account keeps at most account.MaxOneTimeKeys private halves.
+	matrixOneTimeKeyTarget = 50
+
+	matrixMegolmRotateMessages = 100
+	matrixMegolmRotatePeriod   = 7 * 24 * time.Hour
+	matrixMaxOlmSessions       = 5
+	matrixMaxPendingEvents     = 100
+)
+
+// errMatrixMissingRoomKey marks room events whose Megolm key has not arrived.
+var errMatrixMissingRoomKey = errors.New("matrix: room key not received yet")
+
+// matrixAccount is our device's identity, persisted in device_keys.json.
+type matrixAccount struct {
+	UserID     string           `json:"user_id"`
+	DeviceID   string           `json:"device_id"`
+	Olm        *account.Account `json:"olm"`
+	KeysUpload bool             `json:"device_keys_uploaded"`
+
+	CrossSigning *matrixCrossSigningKeys `json:"cross_signing,omitempty"`
+}
+
+// matrixCrossSigningKeys are the account's master, self-signing and
+// user-signing keys. Uploaded records whether the homeserver accepted them
+// and our device was signed.
+type matrixCrossSigningKeys struct {
+	Master      ed25519.PrivateKey `json:"master"`
+	SelfSigning ed25519.PrivateKey `json:"self_signing"`
+	UserSigning ed25519.PrivateKey `json:"user_signing"`
+	Uploaded    bool               `json:"uploaded"`
+}
+
+// matrixDevice is another device's published keys.
+type matrixDevice struct {
+	UserID   string `json:"user_id"`
+	DeviceID string `json:"device_id"`
+	Curve    string `json:"curve25519"`
+	Ed25519  string `json:"ed25519"`
+}
+
+// matrixSessionState is the ratchet state persisted in sessions.json.
+type matrixSessionState struct {
+	// Olm maps a device's Curve25519 key to its sessions, most recently used
+	// first.
+	Olm map[string][]*olmSession `json:"olm"`
+	// Inbound maps a Megolm session ID to the session.
+	Inbound map[string]*megolmInboundSession `json:"inbound"`
+	// Outbound maps a room ID to our current Megolm session there.
+	Outbound map[string]*megolmOutboundSession `json:"outbound"`
+	// Devices pins the first keys seen for each user's devices.
+	Devices map[string]map[string]matrixDevice `json:"devices"`
+}
+
+func newMatrixSessionState() matrixSessionState {
+	return matrixSessionState{
+		Olm:      map[string][]*olmSession{},
+		Inbound:  map[string]*megolmInboundSession{},
+		Outbound: map[string]*megolmOutboundSession{},
+		Devices:  map[string]map[string]matrixDevice{},
+	}
+}
+
+type matrixPendingEvent struct {
+	roomID string
+	event  matrixEvent
+}
+
+// matrixE2EE holds a Matrix device's end-to-end encryption state: the Olm
+// account, Olm sessions with other devices, and Megolm sessions per room.
+// Key material lives unencrypted under dir, like the access token in the
+// config file, so dir must be private to the agent.
+//
+// mu guards the state but is never held across a homeserver request, so a
+// slow keys/query or sendToDevice does not stall the sync loop.
+type matrixE2EE struct {
+	mu  sync.Mutex
+	dir string
+	api func(ctx context.Context, method, path string, query url.Values, body []byte, out any) error
+
+	account  *matrixAccount
+	state    matrixSessionState
+	stale    map[string]bool
+	rooms    map[string]bool
+	pending  map[string][]matrixPendingEvent
+	nPending int
+}
+
+func newMatrixE2EE(dir string) (*matrixE2EE, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create matrix key dir: %w", err)
+	}
+	e := &matrixE2EE{
+		dir:     dir,
+		state:   newMatrixSessionState(),
+		stale:   map[string]bool{},
+		rooms:   map[string]bool{},
+		pending: map[string][]matrixPendingEvent{},
+	}
+	var acct matrixAccount
+	if err := readMatrixJSON(filepath.Join(dir, matrixDeviceKeysFile), &acct); err == nil {
+		// Files without an Olm account predate goolm; setup starts over.
+		if acct.Olm == nil {
+			return e, nil
+		}
+		e.account = &acct
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := readMatrixJSON(filepath.Join(dir, matrixSessionsFile), &e.state); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if e.state.Olm == nil {
+		e.state.Olm = map[string][]*olmSession{}
+	}
+	if e.state.Inbound == nil {
+		e.state.Inbound = map[string]*megolmInboundSession{}
+	}
+	if e.state.Outbound == nil {
+		e.state.Outbound = map[string]*megolmOutboundSession{}
+	}
+	if e.state.Devices == nil {
+		e.state.Devices = map[string]map[string]matrixDevice{}
+	}
+	return e, nil
+}
+
+func readMatrixJSON(path string, out any) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
+
+func writeMatrixJSON(path string, v any) error {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (e *matrixE2EE) saveAccount() error {
+	return writeMatrixJSON(filepath.Join(e.dir, matrixDeviceKeysFile), e.account)
+}
+
+func (e *matrixE2EE) saveSessions() {
+	if err := writeMatrixJSON(filepath.Join(e.dir, matrixSessionsFile), e.state); err != nil {
+		logger.WarnCF("matrix", "Failed to save Matrix encryption sessions", map[string]any{"error": err.Error()})
+	}
+}
+
+// setup loads or creates the device identity for deviceID, publishes device
+// and one-time keys, and cross-signs the device when possible.
+//
+// The homeserver binds an access token to one device, so the device ID is the
+// one whoami reports. A new identity is generated on first run and whenever
+// the token's device changes. setup runs before the sync loop starts, so
+// nothing else waits on the lock it holds.
+func (e *matrixE2EE) setup(ctx context.Context, userID, deviceID string) error {
+	if deviceID == "" {
+		return fmt.Errorf("whoami returned no device_id; end-to-end encryption needs an access token bound to a device")
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.account == nil || e.account.UserID != userID || e.account.DeviceID != deviceID {
+		acct, err := newMatrixAccount(userID, deviceID)
+		if err != nil {
+			return err
+		}
+		// The cross-signing identity belongs to the user, not the device, so
+		// it carries over to the new device.
+		if e.account != nil && e.account.UserID == userID && e.account.CrossSigning != nil {
+			acct.CrossSigning = e.account.CrossSigning
+			acct.CrossSigning.Uploaded = false
+		}
+		e.account = acct
+		e.state = newMatrixSessionState()
+		if err := e.saveAccount(); err != nil {
+			return fmt.Errorf("save device keys: %w", err)
+		}
+		e.saveSessions()
+		logger.InfoCF("matrix", "Generated Matrix device keys", map[string]any{
+			"device_id": deviceID,
+			"path":      filepath.Join(e.dir, matrixDeviceKeysFile),
+		})
+	}
+
+	if !e.account.KeysUpload {
+		var resp struct {
+			Counts map[string]int `json:"one_time_key_counts"`
+		}
+		body, err := json.Marshal(map[string]any{"device_keys": e.signedDeviceKeys()})
+		if err != nil {
+			return err
+		}
+		if err := e.api(ctx, http.MethodPost, "/_matrix/client/v3/keys/upload", nil, body, &resp); err != nil {
+			return fmt.Errorf("upload device keys: %w", err)
+		}
+		e.account.KeysUpload = true
+		if err := e.saveAccount(); err != nil {
+			return fmt.Errorf("save device keys: %w", err)
+		}
+		upload, err := e.prepareOneTimeKeys(resp.Counts[matrixSignedCurveKey])
+		if err != nil {
+			return err
+		}
+		if err := e.uploadOneTimeKeys(ctx, upload); err != nil {
+			return err
+		}
+	}
+
+	if err := e.crossSign(ctx); err != nil {
+		logger.WarnCF("matrix", "Matrix cross-signing skipped", map[string]any{"error": err.Error()})
+	}
+	return nil
+}
+
+func newMatrixAccount(userID, deviceID string) (*matrixAccount, error) {
+	olmAccount, err := account.NewAccount()
+	if err != nil {
+		return nil, err
+	}
+	return &matrixAccount{UserID: userID, DeviceID: deviceID, Olm: olmAccount}, nil
+}
+
+func (e *matrixE2EE) curveKey() string {
+	_, curve, _ := e.account.Olm.IdentityKeys()
+	return string(curve)
+}
+
+func (e *matrixE2EE) ed25519Key() string {
+	ed, _, _ := e.account.Olm.IdentityKeys()
+	return string(ed)
+}
+
+// signingKey is the device's Ed25519 key. goolm keeps it in libolm's
+// expanded form, so it signs through its own ed25519 package.
+func (e *matrixE2EE) signingKey() crypto.Signer {
+	return olmed25519.PrivateKey(e.account.Olm.IdKeys.Ed25519.PrivateKey)
+}
+
+func (e *matrixE2EE) deviceKeysObject() map[string]any {
+	id := e.account.DeviceID
+	return map[string]any{
+		"user_id":    e.account.UserID,
+		"device_id":  id,
+		"algorithms": []string{matrixOlmAlgorithm, matrixMegolmAlgorithm},
+		"keys": map[string]string{
+			"curve25519:" + id: e.curveKey(),
+			"ed25519:" + id:    e.ed25519Key(),
+		},
+	}
+}
+
+func (e *matrixE2EE) signedDeviceKeys() map[string]any {
+	keys := e.deviceKeysObject()
+	signMatrixJSON(keys, e.account.UserID, "ed25519:"+e.account.DeviceID, e.signingKey())
+	return keys
+}
+
+// prepareOneTimeKeys generates enough signed_curve25519 keys to top the
+// published ones up to matrixOneTimeKeyTarget once fewer than half remain,
+// and returns the keys/upload body, or nil when none are needed. The private
+// halves are saved first so a key claimed right after the upload can be used.
+func (e *matrixE2EE) prepareOneTimeKeys(published int) ([]byte, error) {
+	if published >= matrixOneTimeKeyTarget/2 {
+		return nil, nil
+	}
+	if err := e.account.Olm.GenOneTimeKeys(uint(matrixOneTimeKeyTarget - published)); err != nil {
+		return nil, err
+	}
+	fresh, err := e.account.Olm.OneTimeKeys()
+	if err != nil {
+		return nil, err
+	}
+	upload := map[string]any{}
+	for keyID, public := range fresh {
+		key := map[string]any{"key": string(public)}
+		signMatrixJSON(key, e.account.UserID, "ed25519:"+e.account.DeviceID, e.signingKey())
+		upload[matrixSignedCurveKey+":"+keyID] = key
+	}
+	e.account.Olm.MarkKeysAsPublished()
+	if err := e.saveAccount(); err != nil {
+		return nil, fmt.Errorf("save one-time keys: %w", err)
+	}
+	return json.Marshal(map[string]any{"one_time_keys": upload})
+}
+
+func (e *matrixE2EE) uploadOneTimeKeys(ctx context.Context, body []byte) error {
+	if body == nil {
+		return nil
+	}
+	if err := e.api(ctx, http.MethodPost, "/_matrix/client/v3/keys/upload", nil, body, nil); err != nil {
+		return fmt.Errorf("upload one-time keys: %w", err)
+	}
+	return nil
+}
+
+// crossSign publishes cross-signing keys and signs our device with the
+// self-signing key. The homeserver accepts the first upload of cross-signing
+// keys with just the access token; if the account already has cross-signing
+// keys, or the server insists on interactive auth, the device stays
+// unsigned and the user can verify it from another client.
+func (e *matrixE2EE) crossSign(ctx context.Context) error {
+	if e.account.CrossSigning != nil && e.account.CrossSigning.Uploaded {
+		return nil
+	}
+	userID := e.account.UserID
+	if e.account.CrossSigning == nil {
+		keys := &matrixCrossSigningKeys{}
+		for _, dst := range []*ed25519.PrivateKey{&keys.Master, &keys.SelfSigning, &keys.UserSigning} {
+			_, priv, err := ed25519.GenerateKey(rand.Reader)
+			if err != nil {
+				return err
+			}
+			*dst = priv
+		}
+		e.account.CrossSigning = keys
+		if err := e.saveAccount(); err != nil {
+			return err
+		}
+	}
+	keys := e.account.CrossSigning
+
+	var existing struct {
+		MasterKeys map[string]struct {
+			Keys map[string]string `json:"keys"`
+		} `json:"master_keys"`
+	}
+	query, _ := json.Marshal(map[string]any{"device_keys": map[string][]string{userID: {}}})
+	if err := e.api(ctx, http.MethodPost, "/_matrix/client/v3/keys/query", nil, query, &existing); err != nil {
+		return fmt.Errorf("query own keys: %w", err)
+	}
+	masterPub := matrixB64.EncodeToString(keys.Master.Public().(ed25519.PublicKey))
+	if current, ok := existing.MasterKeys[userID]; ok && current.Keys["ed25519:"+masterPub] == "" {
+		return fmt.Errorf("%s already has cross-signing keys from another client; verify this device from there", userID)
+	}
+
+	crossSigningKey := func(usage string, key ed25519.PrivateKey) map[string]any {
+		pub := matrixB64.EncodeToString(key.Public().(ed25519.PublicKey))
+		return map[string]any{
+			"user_id": userID,
+			"usage":   []string{usage},
+			"keys":    map[string]string{"ed25519:" + pub: pub},
+		}
+	}
+	master := crossSigningKey("master", keys.Master)
+	selfSigning := crossSigningKey("self_signing", keys.SelfSigning)
+	userSigning := crossSigningKey("user_signing", keys.UserSigning)
+	signMatrixJSON(selfSigning, userID, "ed25519:"+masterPub, keys.Master)
+	signMatrixJSON(userSigning, userID, "ed25519:"+masterPub, keys.Master)
+	body, err := json.Marshal(map[string]any{
+		"master_key":       master,
+		"self_signing_key": selfSigning,
+		"user_signing_key": userSigning,
+	})
+	if err != nil {
+		return err
+	}
+	if err := e.api(ctx, http.MethodPost, "/_matrix/client/v3/keys/device_signing/upload", nil, body, nil); err != nil {
+		var apiErr *matrixAPIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusUnauthorized {
+			return fmt.Errorf("homeserver requires interactive auth to upload cross-signing keys")
+		}
+		return fmt.Errorf("upload cross-signing keys: %w", err)
+	}
+
+	device := e.deviceKeysObject()
+	selfPub := matrixB64.EncodeToString(keys.SelfSigning.Public().(ed25519.PublicKey))
+	signMatrixJSON(device, userID, "ed25519:"+selfPub, keys.SelfSigning)
+	body, err = json.Marshal(map[string]any{userID: map[string]any{e.account.DeviceID: device}})
+	if err != nil {
+		return err
+	}
+	if err := e.api(ctx, http.MethodPost, "/_matrix/client/v3/keys/signatures/upload", nil, body, nil); err != nil {
+		return fmt.Errorf("upload device signature: %w", err)
+	}
+	keys.Uploaded = true
+	logger.InfoCF("matrix", "Cross-signed Matrix device", map[string]any{"device_id": e.account.DeviceID})
+	return e.saveAccount()
+}
+
+// handleSync processes the encryption-related parts of a sync response:
+// to-device room keys, one-time key counts and device list changes. It
+// returns previously undecryptable room events whose keys just arrived.
+func (e *matrixE2EE) handleSync(ctx context.Context, resp matrixSyncResponse) []matrixPendingEvent {
+	e.mu.Lock()
+	for _, userID := range resp.DeviceLists.Changed {
+		e.stale[userID] = true
+	}
+	for _, userID := range resp.DeviceLists.Left {
+		delete(e.state.Devices, userID)
+	}
+	// Room keys are only accepted from devices whose keys we have verified,
+	// so look up senders whose sender_key we have not seen yet.
+	var lookup []string
+	for _, event := range resp.ToDevice.Events {
+		if event.Type != "m.room.encrypted" {
+			continue
+		}
+		if _, ok := e.senderDevice(event.Sender, event.Content.SenderKey); !ok && !slices.Contains(lookup, event.Sender) {
+			e.stale[event.Sender] = true
+			lookup = append(lookup, event.Sender)
+		}
+	}
+	e.mu.Unlock()
+
+	if len(lookup) > 0 {
+		if err := e.refreshDevices(ctx, lookup); err != nil {
+			logger.WarnCF("matrix", "Failed to query Matrix device keys", map[string]any{"error": err.Error()})
+		}
+	}
+
+	e.mu.Lock()
+	var ready []matrixPendingEvent
+	changed := false
+	for _, event := range resp.ToDevice.Events {
+		if event.Type != "m.room.encrypted" {
+			continue
+		}
+		sessionID, err := e.handleToDevice(event)
+		if err != nil {
+			logger.WarnCF("matrix", "Failed to decrypt Matrix to-device event", map[string]any{
+				"sender": event.Sender,
+				"error":  err.Error(),
+			})
+			continue
+		}
+		changed = true
+		if events := e.pending[sessionID]; len(events) > 0 {
+			ready = append(ready, events...)
+			e.nPending -= len(events)
+			delete(e.pending, sessionID)
+		}
+	}
+	if changed {
+		e.saveSessions()
+		if err := e.saveAccount(); err != nil {
+			logger.WarnCF("matrix", "Failed to save Matrix device keys", map[string]any{"error": err.Error()})
+		}
+	}
+	var upload []byte
+	if count, ok := resp.DeviceOneTimeKeysCount[matrixSignedCurveKey]; ok {
+		var err error
+		if upload, err = e.prepareOneTimeKeys(count); err != nil {
+			logger.WarnCF("matrix", "Failed to generate Matrix one-time keys", map[string]any{"error": err.Error()})
+		}
+	}
+	e.mu.Unlock()
+
+	if err := e.uploadOneTimeKeys(ctx, upload); err != nil {
+		logger.WarnCF("matrix", "Failed to replenish Matrix one-time keys", map[string]any{"error": err.Error()})
+	}
+	return ready
+}
+
+// senderDevice returns the pinned device of userID whose Curve25519 identity
+// key is curveKey.
+func (e *matrixE2EE) senderDevice(userID, curveKey string) (matrixDevice, bool) {
+	if curveKey == "" {
+		return matrixDevice{}, false
+	}
+	for _, device := range e.state.Devices[userID] {
+		if device.Curve == curveKey {
+			return device, true
+		}
+	}
+	return matrixDevice{}, false
+}
+
+// olmPayload is the plaintext of an Olm-encrypted to-device event.
+type olmPayload struct {
+	Type          string            `json:"type"`
+	Content       json.RawMessage   `json:"content"`
+	Sender        string            `json:"sender"`
+	SenderDevice  string            `json:"sender_device,omitempty"`
+	Recipient     string            `json:"recipient"`
+	RecipientKeys map[string]string `json:"recipient_keys"`
+	Keys          map[string]string `json:"keys"`
+}
+
+// handleToDevice decrypts an Olm to-device event and stores the room key it
+// carries, returning the Megolm session ID. The event must come from one of
+// the sender's verified devices: Olm authenticates the sender_key, which has
+// to be that device's Curve25519 key, and the payload must name the same
+// device's Ed25519 key.
+func (e *matrixE2EE) handleToDevice(event matrixEvent) (string, error) {
+	if event.Content.Algorithm != matrixOlmAlgorithm {
+		return "", fmt.Errorf("unsupported algorithm %q", event.Content.Algorithm)
+	}
+	device, ok := e.senderDevice(event.Sender, event.Content.SenderKey)
+	if !ok {
+		return "", fmt.Errorf("sender_key %q is not a verified device of %s", event.Content.SenderKey, event.Sender)
+	}
+	var ciphertexts map[string]struct {
+		Type int    `json:"type"`
+		Body string `json:"body"`
+	}
+	if err := json.Unmarshal(event.Content.Ciphertext, &ciphertexts); err != nil {
+		return "", fmt.Errorf("decode ciphertext: %w", err)
+	}
+	ours, ok := ciphertexts[e.curveKey()]
+	if !ok {
+		return "", fmt.Errorf("event not encrypted for this device")
+	}
+	plaintext, err := e.decryptOlm(device.Curve, ours.Type, ours.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var payload olmPayload
+	if err := json.Unmarshal(plaintext, &payload); err != nil {
+		return "", fmt.Errorf("decode olm payload: %w", err)
+	}
+	if payload.Sender != event.Sender || payload.Recipient != e.account.UserID || payload.RecipientKeys["ed25519"] != e.ed25519Key() {
+		return "", fmt.Errorf("olm payload is not addressed from %s to this device", event.Sender)
+	}
+	if payload.Keys["ed25519"] != device.Ed25519 {
+		return "", fmt.Errorf("olm payload ed25519 key does not match device %s of %s", device.DeviceID, event.Sender)
+	}
+	if payload.SenderDevice != "" && payload.SenderDevice != device.DeviceID {
+		return "", fmt.Errorf("olm payload names device %s but sender_key belongs to %s", payload.SenderDevice, device.DeviceID)
+	}
+	if payload.Type != "m.room_key" {
+		return "", fmt.Errorf("ignoring to-device %s", payload.Type)
+	}
+	var roomKey struct {
+		Algorithm  string `json:"algorithm"`
+		RoomID     string `json:"room_id"`
+		SessionID  string `json:"session_id"`
+		SessionKey string `json:"session_key"`
+	}
+	if err := json.Unmarshal(payload.Content, &roomKey); err != nil {
+		return "", fmt.Errorf("decode room key: %w", err)
+	}
+	if roomKey.Algorithm != matrixMegolmAlgorithm {
+		return "", fmt.Errorf("unsupported room key algorithm %q", roomKey.Algorithm)
+	}
+	session, err := newMegolmInboundSession(roomKey.SessionKey)
+	if err != nil {
+		return "", err
+	}
+	if session.id() != roomKey.SessionID {
+		return "", fmt.Errorf("room key session_id does not match its key")
+	}
+	session.SenderKey = device.Curve
+	session.SenderUser = event.Sender
+	session.RoomID = roomKey.RoomID
+	if existing, ok := e.state.Inbound[roomKey.SessionID]; ok {
+		if existing.SenderKey != session.SenderKey || existing.RoomID != session.RoomID {
+			return "", fmt.Errorf("room key %s was already shared by another device", roomKey.SessionID)
+		}
+		// Keep an existing copy that can decrypt from an earlier index.
+		if existing.firstKnownIndex() <= session.firstKnownIndex() {
+			return roomKey.SessionID, nil
+		}
+	}
+	e.state.Inbound[roomKey.SessionID] = session
+	logger.DebugCF("matrix", "Received Matrix room key", map[string]any{
+		"room":       roomKey.RoomID,
+		"sender":     event.Sender,
+		"session_id": roomKey.SessionID,
+	})
+	return roomKey.SessionID, nil
+}
+
+// decryptOlm decrypts an Olm message from the device with Curve25519 key
+// senderKey, creating an inbound session for a new pre-key message.
+func (e *matrixE2EE) decryptOlm(senderKey string, msgType int, body string) ([]byte, error) {
+	sessions := e.state.Olm[senderKey]
+
+	switch msgType {
+	case olmMessageNormal:
+		for _, s := range sessions {
+			if plaintext, err := s.decrypt(msgType, body); err == nil {
+				e.sortOlmSessions(senderKey)
+				return plaintext, nil
+			}
+		}
+		return nil, fmt.Errorf("no olm session with %s could decrypt the message", senderKey)
+
+	case olmMessagePreKey:
+		for _, s := range sessions {
+			if ok, _ := s.Session.MatchesInboundSessionFrom(senderKey, body); ok {
+				plaintext, err := s.decrypt(msgType, body)
+				if err != nil {
+					return nil, err
+				}
+				e.sortOlmSessions(senderKey)
+				return plaintext, nil
+			}
+		}
+		session, err := newInboundOlmSession(e.account.Olm, senderKey, body)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err := session.decrypt(msgType, body)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.account.Olm.RemoveOneTimeKeys(session.Session); err != nil {
+			return nil, err
+		}
+		e.addOlmSession(senderKey, session)
+		return plaintext, nil
+	}
+	return nil, fmt.Errorf("unknown olm message type %d", msgType)
+}
+
+func (e *matrixE2EE) addOlmSession(curveKey string, s *olmSession) {
+	e.state.Olm[curveKey] = append([]*olmSession{s}, e.state.Olm[curveKey]...)
+	e.sortOlmSessions(curveKey)
+}
+
+func (e *matrixE2EE) sortOlmSessions(curveKey string) {
+	sessions := e.state.Olm[curveKey]
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].LastUsedMS > sessions[j].LastUsedMS })
+	if len(sessions) > matrixMaxOlmSessions {
+		sessions = sessions[:matrixMaxOlmSessions]
+	}
+	e.state.Olm[curveKey] = sessions
+}
+
+// decryptRoomEvent decrypts an m.room.encrypted timeline event into the event
+// it wraps. Events whose key has not arrived yet are held back and returned
+// from handleSync once it does; errMatrixMissingRoomKey reports that case.
+func (e *matrixE2EE) decryptRoomEvent(roomID string, event matrixEvent) (matrixEvent, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if event.Content.Algorithm != matrixMegolmAlgorithm {
+		return matrixEvent{}, fmt.Errorf("unsupported algorithm %q", event.Content.Algorithm)
+	}
+	session, ok := e.state.Inbound[event.Content.SessionID]
+	if !ok {
+		if e.nPending < matrixMaxPendingEvents {
+			e.pending[event.Content.SessionID] = append(e.pending[event.Content.SessionID], matrixPendingEvent{roomID: roomID, event: event})
+			e.nPending++
+		}
+		return matrixEvent{}, errMatrixMissingRoomKey
+	}
+	if session.RoomID != roomID {
+		return matrixEvent{}, fmt.Errorf("room key for %s used in %s", session.RoomID, roomID)
+	}
+	if session.SenderUser != event.Sender {
+		return matrixEvent{}, fmt.Errorf("room key shared by %s used by %s", session.SenderUser, event.Sender)
+	}
+	var ciphertext string
+	if err := json.Unmarshal(event.Content.Ciphertext, &ciphertext); err != nil {
+		return matrixEvent{}, fmt.Errorf("decode ciphertext: %w", err)
+	}
+	plaintext, index, err := session.decrypt(ciphertext)
+	if err != nil {
+		return matrixEvent{}, err
+	}
+	if session.seen == nil {
+		session.seen = map[uint32]string{}
+	}
+	if prev, ok := session.seen[index]; ok && prev != event.EventID {
+		return matrixEvent{}, fmt.Errorf("message index %d replayed by %s", index, event.EventID)
+	}
+	session.seen[index] = event.EventID
+
+	var inner struct {
+		Type    string             `json:"type"`
+		Content matrixEventContent `json:"content"`
+		RoomID  string             `json:"room_id"`
+	}
+	if err := json.Unmarshal(plaintext, &inner); err != nil {
+		return matrixEvent{}, fmt.Errorf("decode decrypted event: %w", err)
+	}
+	if inner.RoomID != roomID {
+		return matrixEvent{}, fmt.Errorf("decrypted event belongs to room %s", inner.RoomID)
+	}
+	return matrixEvent{Type: inner.Type, Sender: event.Sender, EventID: event.EventID, Content: inner.Content}, nil
+}
+
+// roomEncrypted reports whether roomID has encryption enabled. Positive
+// answers are cached since encryption cannot be turned off again.
+func (e *matrixE2EE) roomEncrypted(ctx context.Context, roomID string) (bool, error) {
+	e.mu.Lock()
+	encrypted := e.rooms[roomID]
+	e.mu.Unlock()
+	if encrypted {
+		return true, nil
+	}
+	var state struct {
+		Algorithm string `json:"algorithm"`
+	}
+	path := fmt.Sprintf("/_matrix/client/v3/rooms/%s/state/m.room.encryption/", url.PathEscape(roomID))
+	if err := e.api(ctx, http.MethodGet, path, nil, nil, &state); err != nil {
+		var apiErr *matrixAPIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	if state.Algorithm != matrixMegolmAlgorithm {
+		return false, fmt.Errorf("room %s uses unsupported encryption %q", roomID, state.Algorithm)
+	}
+	e.mu.Lock()
+	e.rooms[roomID] = true
+	e.mu.Unlock()
+	return true, nil
+}
+
+// encryptRoomMessage shares our Megolm session for roomID with every device
+// of the room's members that lacks it and returns the m.room.encrypted
+// content for a message event with content.
+func (e *matrixE2EE) encryptRoomMessage(ctx context.Context, roomID string, content map[string]string) (map[string]any, error) {
+	var members struct {
+		Joined map[string]json.RawMessage `json:"joined"`
+	}
+	path := fmt.Sprintf("/_matrix/client/v3/rooms/%s/joined_members", url.PathEscape(roomID))
+	if err := e.api(ctx, http.MethodGet, path, nil, nil, &members); err != nil {
+		return nil, fmt.Errorf("list room members: %w", err)
+	}
+	users := make([]string, 0, len(members.Joined))
+	for userID := range members.Joined {
+		users = append(users, userID)
+	}
+	sort.Strings(users)
+	if err := e.refreshDevices(ctx, users); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	session, targets, err := e.outboundSession(roomID, users)
+	e.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	claimed, err := e.claimOneTimeKeys(ctx, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	messages, err := e.roomKeyMessages(roomID, session, targets, claimed)
+	var encrypted map[string]any
+	if err == nil {
+		encrypted, err = e.encryptWithSession(roomID, session, content)
+	}
+	// Persist the advanced ratchets before anything leaves.
+	e.saveSessions()
+	e.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.sendToDevice(ctx, messages); err != nil {
+		e.mu.Lock()
+		for userID, devices := range messages {
+			for deviceID := range devices {
+				delete(session.SharedWith, userID+"|"+deviceID)
+			}
+		}
+		e.saveSessions()
+		e.mu.Unlock()
+		return nil, fmt.Errorf("send room key: %w", err)
+	}
+	return encrypted, nil
+}
+
+// outboundSession returns our Megolm session for roomID, rotating it when it
+// is old, heavily used, or held by a device no longer in the room, and the
+// member devices that do not have it yet.
+func (e *matrixE2EE) outboundSession(roomID string, users []string) (*megolmOutboundSession, []matrixDevice, error) {
+	recipients := map[string]matrixDevice{}
+	for _, userID := range users {
+		for deviceID, device := range e.state.Devices[userID] {
+			if userID == e.account.UserID && deviceID == e.account.DeviceID {
+				continue
+			}
+			recipients[userID+"|"+deviceID] = device
+		}
+	}
+
+	now := time.Now()
+	session := e.state.Outbound[roomID]
+	if session != nil {
+		rotate := session.messageIndex() >= matrixMegolmRotateMessages ||
+			now.Sub(time.UnixMilli(session.CreatedMS)) > matrixMegolmRotatePeriod
+		// Someone who held the key has left or dropped the device.
+		for holder := range session.SharedWith {
+			if _, ok := recipients[holder]; !ok {
+				rotate = true
+				break
+			}
+		}
+		if rotate {
+			session = nil
+		}
+	}
+	if session == nil {
+		var err error
+		if session, err = newMegolmOutboundSession(now.UnixMilli()); err != nil {
+			return nil, nil, err
+		}
+		e.state.Outbound[roomID] = session
+	}
+
+	var targets []matrixDevice
+	for holder, device := range recipients {
+		if !session.SharedWith[holder] {
+			targets = append(targets, device)
+		}
+	}
+	return session, targets, nil
+}
+
+// refreshDevices queries device keys for users we have not seen or whose
+// device list changed, keeping only devices whose self-signature checks out
+// and whose keys match the ones first seen.
+func (e *matrixE2EE) refreshDevices(ctx context.Context, users []string) error {
+	e.mu.Lock()
+	query := map[string][]string{}
+	for _, userID := range users {
+		if _, known := e.state.Devices[userID]; !known || e.stale[userID] {
+			query[userID] = []string{}
+		}
+	}
+	e.mu.Unlock()
+	if len(query) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(map[string]any{"device_keys": query})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		DeviceKeys map[string]map[string]json.RawMessage `json:"device_keys"`
+	}
+	if err := e.api(ctx, http.MethodPost, "/_matrix/client/v3/keys/query", nil, body, &resp); err != nil {
+		return fmt.Errorf("query device keys: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for userID := range query {
+		previous := e.state.Devices[userID]
+		devices := map[string]matrixDevice{}
+		for deviceID, raw := range resp.DeviceKeys[userID] {
+			device, err := verifyMatrixDeviceKeys(userID, deviceID, raw)
+			if err != nil {
+				logger.WarnCF("matrix", "Ignoring Matrix device with invalid keys", map[string]any{
+					"user": userID, "device": deviceID, "error": err.Error(),
+				})
+				continue
+			}
+			if pinned, ok := previous[deviceID]; ok && (pinned.Ed25519 != device.Ed25519 || pinned.Curve != device.Curve) {
+				logger.WarnCF("matrix", "Ignoring Matrix device whose keys changed", map[string]any{
+					"user": userID, "device": deviceID,
+				})
+				devices[deviceID] = pinned
+				continue
+			}
+			devices[deviceID] = device
+		}
+		e.state.Devices[userID] = devices
+		delete(e.stale, userID)
+	}
+	return nil
+}
+
+func verifyMatrixDeviceKeys(userID, deviceID string, raw json.RawMessage) (matrixDevice, error) {
+	var keys map[string]any
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return matrixDevice{}, err
+	}
+	if keys["user_id"] != userID || keys["device_id"] != deviceID {
+		return matrixDevice{}, fmt.Errorf("device keys name a different user or device")
+	}
+	keyMap, _ := keys["keys"].(map[string]any)
+	curve, _ := keyMap["curve25519:"+deviceID].(string)
+	ed, _ := keyMap["ed25519:"+deviceID].(string)
+	if curve == "" || ed == "" {
+		return matrixDevice{}, fmt.Errorf("device keys lack curve25519 or ed25519")
+	}
+	if err := verifyMatrixJSON(keys, userID, "ed25519:"+deviceID, ed); err != nil {
+		return matrixDevice{}, err
+	}
+	return matrixDevice{UserID: userID, DeviceID: deviceID, Curve: curve, Ed25519: ed}, nil
+}
+
+// claimOneTimeKeys claims a one-time key for each target we have no Olm
+// session with, returning them by user and device.
+func (e *matrixE2EE) claimOneTimeKeys(ctx context.Context, targets []matrixDevice) (map[string]map[string]map[string]json.RawMessage, error) {
+	claim := map[string]map[string]string{}
+	e.mu.Lock()
+	for _, device := range targets {
+		if len(e.state.Olm[device.Curve]) > 0 {
+			continue
+		}
+		if claim[device.UserID] == nil {
+			claim[device.UserID] = map[string]string{}
+		}
+		claim[device.UserID][device.DeviceID] = matrixSignedCurveKey
+	}
+	e.mu.Unlock()
+	if len(claim) == 0 {
+		return nil, nil
+	}
+	body, err := json.Marshal(map[string]any{"one_time_keys": claim})
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		OneTimeKeys map[string]map[string]map[string]json.RawMessage `json:"one_time_keys"`
+	}
+	if err := e.api(ctx, http.MethodPost, "/_matrix/client/v3/keys/claim", nil, body, &resp); err != nil {
+		return nil, fmt.Errorf("claim one-time keys: %w", err)
+	}
+	return resp.OneTimeKeys, nil
+}
+
+// roomKeyMessages opens Olm sessions from the claimed one-time keys and
+// returns the sendToDevice messages carrying session's key to each target
+// that still lacks it, marking them as holders.
+func (e *matrixE2EE) roomKeyMessages(roomID string, session *megolmOutboundSession, targets []matrixDevice, claimed map[string]map[string]map[string]json.RawMessage) (map[string]map[string]any, error) {
+	for _, device := range targets {
+		if len(e.state.Olm[device.Curve]) > 0 {
+			continue
+		}
+		for _, raw := range claimed[device.UserID][device.DeviceID] {
+			if err := e.startOlmSession(device, raw); err != nil {
+				logger.WarnCF("matrix", "Failed to start Olm session", map[string]any{
+					"user": device.UserID, "device": device.DeviceID, "error": err.Error(),
+				})
+			}
+			break
+		}
+	}
+
+	roomKey, err := json.Marshal(map[string]string{
+		"algorithm":   matrixMegolmAlgorithm,
+		"room_id":     roomID,
+		"session_id":  session.id(),
+		"session_key": session.sessionKey(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	messages := map[string]map[string]any{}
+	for _, device := range targets {
+		holder := device.UserID + "|" + device.DeviceID
+		if session.SharedWith[holder] {
+			continue
+		}
+		sessions := e.state.Olm[device.Curve]
+		if len(sessions) == 0 {
+			logger.WarnCF("matrix", "No Olm session with device; it will not be able to read this message", map[string]any{
+				"user": device.UserID, "device": device.DeviceID,
+			})
+			continue
+		}
+		plaintext, err := json.Marshal(olmPayload{
+			Type:          "m.room_key",
+			Content:       roomKey,
+			Sender:        e.account.UserID,
+			SenderDevice:  e.account.DeviceID,
+			Recipient:     device.UserID,
+			RecipientKeys: map[string]string{"ed25519": device.Ed25519},
+			Keys:          map[string]string{"ed25519": e.ed25519Key()},
+		})
+		if err != nil {
+			return nil, err
+		}
+		msgType, body, err := sessions[0].encrypt(plaintext)
+		if err != nil {
+			return nil, err
+		}
+		if messages[device.UserID] == nil {
+			messages[device.UserID] = map[string]any{}
+		}
+		messages[device.UserID][device.DeviceID] = map[string]any{
+			"algorithm":  matrixOlmAlgorithm,
+			"sender_key": e.curveKey(),
+			"ciphertext": map[string]any{
+				device.Curve: map[string]any{"type": msgType, "body": body},
+			},
+		}
+		session.SharedWith[holder] = true
+	}
+	return messages, nil
+}
+
+// encryptWithSession returns the m.room.encrypted content for a message
+// event with content, encrypted with session.
+func (e *matrixE2EE) encryptWithSession(roomID string, session *megolmOutboundSession, content map[string]string) (map[string]any, error) {
+	plaintext, err := json.Marshal(map[string]any{"type": "m.room.message", "content": content, "room_id": roomID})
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := session.encrypt(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"algorithm":  matrixMegolmAlgorithm,
+		"sender_key": e.curveKey(),
+		"ciphertext": ciphertext,
+		"session_id": session.id(),
+		"device_id":  e.account.DeviceID,
+	}, nil
+}
+
+func (e *matrixE2EE) sendToDevice(ctx context.Context, messages map[string]map[string]any) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(map[string]any{"messages": messages})
+	if err != nil {
+		return err
+	}
+	path := "/_matrix/client/v3/sendToDevice/m.room.encrypted/" + url.PathEscape(uuid.NewString())
+	return e.api(ctx, http.MethodPut, path, nil, body, nil)
+}
+
+// startOlmSession checks a claimed one-time key's signature and opens an
+// outbound Olm session with device.
+func (e *matrixE2EE) startOlmSession(device matrixDevice, raw json.RawMessage) error {
+	var key map[string]any
+	if err := json.Unmarshal(raw, &key); err != nil {
+		return err
+	}
+	if err := verifyMatrixJSON(key, device.UserID, "ed25519:"+device.DeviceID, device.Ed25519); err != nil {
+		return fmt.Errorf("one-time key: %w", err)
+	}
+	oneTime, _ := key["key"].(string)
+	if oneTime == "" {
+		return fmt.Errorf("one-time key has no key")
+	}
+	session, err := newOutboundOlmSession(e.account.Olm, device.Curve, oneTime)
+	if err != nil {
+		return err
+	}
+	e.addOlmSession(device.Curve, session)
+	return nil
+}
+
+// canonicalMatrixJSON encodes v as Matrix canonical JSON: sorted keys, no
+// insignificant whitespace, and no HTML escaping.
+func canonicalMatrixJSON(v any) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(generic); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// signMatrixJSON adds a signature by the Ed25519 key under
+// signatures[userID][keyID], covering obj without its signatures and
+// unsigned fields.
+func signMatrixJSON(obj map[string]any, userID, keyID string, key crypto.Signer) {
+	signatures, _ := obj["signatures"].(map[string]any)
+	unsigned, hasUnsigned := obj["unsigned"]
+	delete(obj, "signatures")
+	delete(obj, "unsigned")
+	canonical, err := canonicalMatrixJSON(obj)
+	if err != nil {
+		panic(fmt.Sprintf("canonical json: %v", err))
+	}
+	if signatures == nil {
+		signatures = map[string]any{}
+	}
+	userSigs, _ := signatures[userID].(map[string]any)
+	if userSigs == nil {
+		userSigs = map[string]any{}
+	}
+	sig, err := key.Sign(nil, canonical, crypto.Hash(0))
+	if err != nil {
+		panic(fmt.Sprintf("ed25519 sign: %v", err))
+	}
+	userSigs[keyID] = matrixB64.EncodeToString(sig)
+	signatures[userID] = userSigs
+	obj["signatures"] = signatures
+	if hasUnsigned {
+		obj["unsigned"] = unsigned
+	}
+}
+
+// verifyMatrixJSON checks the signature by keyID over obj against the base64
+// Ed25519 public key.
+func verifyMatrixJSON(obj map[string]any, userID, keyID, publicKey string) error {
+	signatures, _ := obj["signatures"].(map[string]any)
+	userSigs, _ := signatures[userID].(map[string]any)
+	sigB64, _ := userSigs[keyID].(string)
+	if sigB64 == "" {
+		return fmt.Errorf("missing signature %s", keyID)
+	}
+	sig, err := matrixB64.DecodeString(sigB64)
+	if err != nil {
+		return fmt.Errorf("decode signature: %w", err)
+	}
+	pub, err := matrixB64.DecodeString(publicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid ed25519 key")
+	}
+	unsignedObj := make(map[string]any, len(obj))
+	for k, v := range obj {
+		if k != "signatures" && k != "unsigned" {
+			unsignedObj[k] = v
+		}
+	}
+	canonical, err := canonicalMatrixJSON(unsignedObj)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, canonical, sig) {
+		return fmt.Errorf("bad signature %s", keyID)
+	}
+	return nil
+}
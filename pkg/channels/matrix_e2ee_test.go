@@ -0,0 +1,298 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeKeyServer is an in-memory homeserver for the key-management endpoints
+// end-to-end encryption uses. Every device shares one encrypted room.
+type fakeKeyServer struct {
+	mu          sync.Mutex
+	deviceKeys  map[string]map[string]json.RawMessage
+	oneTimeKeys map[string]map[string]map[string]json.RawMessage
+	inbox       map[string][]matrixEvent
+	crossSigned []string
+}
+
+func newFakeKeyServer() *fakeKeyServer {
+	return &fakeKeyServer{
+		deviceKeys:  map[string]map[string]json.RawMessage{},
+		oneTimeKeys: map[string]map[string]map[string]json.RawMessage{},
+		inbox:       map[string][]matrixEvent{},
+	}
+}
+
+// api returns the request function for userID's device.
+func (f *fakeKeyServer) api(userID, deviceID string) func(context.Context, string, string, url.Values, []byte, any) error {
+	return func(_ context.Context, method, path string, _ url.Values, body []byte, out any) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		var resp any = map[string]any{}
+		switch {
+		case path == "/_matrix/client/v3/keys/upload":
+			var req struct {
+				DeviceKeys  json.RawMessage            `json:"device_keys"`
+				OneTimeKeys map[string]json.RawMessage `json:"one_time_keys"`
+			}
+			if err := json.Unmarshal(body, &req); err != nil {
+				return err
+			}
+			if len(req.DeviceKeys) > 0 {
+				if f.deviceKeys[userID] == nil {
+					f.deviceKeys[userID] = map[string]json.RawMessage{}
+				}
+				f.deviceKeys[userID][deviceID] = req.DeviceKeys
+			}
+			if f.oneTimeKeys[userID] == nil {
+				f.oneTimeKeys[userID] = map[string]map[string]json.RawMessage{}
+			}
+			if f.oneTimeKeys[userID][deviceID] == nil {
+				f.oneTimeKeys[userID][deviceID] = map[string]json.RawMessage{}
+			}
+			for id, key := range req.OneTimeKeys {
+				f.oneTimeKeys[userID][deviceID][id] = key
+			}
+			resp = map[string]any{"one_time_key_counts": map[string]int{matrixSignedCurveKey: len(f.oneTimeKeys[userID][deviceID])}}
+		case path == "/_matrix/client/v3/keys/query":
+			var req struct {
+				DeviceKeys map[string][]string `json:"device_keys"`
+			}
+			if err := json.Unmarshal(body, &req); err != nil {
+				return err
+			}
+			found := map[string]map[string]json.RawMessage{}
+			for user := range req.DeviceKeys {
+				found[user] = f.deviceKeys[user]
+			}
+			resp = map[string]any{"device_keys": found}
+		case path == "/_matrix/client/v3/keys/claim":
+			var req struct {
+				OneTimeKeys map[string]map[string]string `json:"one_time_keys"`
+			}
+			if err := json.Unmarshal(body, &req); err != nil {
+				return err
+			}
+			claimed := map[string]map[string]map[string]json.RawMessage{}
+			for user, devices := range req.OneTimeKeys {
+				claimed[user] = map[string]map[string]json.RawMessage{}
+				for device := range devices {
+					for id, key := range f.oneTimeKeys[user][device] {
+						claimed[user][device] = map[string]json.RawMessage{id: key}
+						delete(f.oneTimeKeys[user][device], id)
+						break
+					}
+				}
+			}
+			resp = map[string]any{"one_time_keys": claimed}
+		case strings.HasPrefix(path, "/_matrix/client/v3/sendToDevice/m.room.encrypted/"):
+			var req struct {
+				Messages map[string]map[string]matrixEventContent `json:"messages"`
+			}
+			if err := json.Unmarshal(body, &req); err != nil {
+				return err
+			}
+			for user, devices := range req.Messages {
+				for device, content := range devices {
+					f.inbox[user+"|"+device] = append(f.inbox[user+"|"+device], matrixEvent{
+						Type: "m.room.encrypted", Sender: userID, Content: content,
+					})
+				}
+			}
+		case path == "/_matrix/client/v3/keys/device_signing/upload":
+			f.crossSigned = append(f.crossSigned, userID)
+		case path == "/_matrix/client/v3/keys/signatures/upload":
+		case strings.HasSuffix(path, "/state/m.room.encryption/"):
+			resp = map[string]string{"algorithm": matrixMegolmAlgorithm}
+		case strings.HasSuffix(path, "/joined_members"):
+			joined := map[string]any{}
+			for user := range f.deviceKeys {
+				joined[user] = map[string]any{}
+			}
+			resp = map[string]any{"joined": joined}
+		default:
+			return &matrixAPIError{Method: method, Path: path, Status: http.StatusNotFound}
+		}
+		if out == nil {
+			return nil
+		}
+		raw, _ := json.Marshal(resp)
+		return json.Unmarshal(raw, out)
+	}
+}
+
+// toDevice drains userID's device inbox into a sync response.
+func (f *fakeKeyServer) toDevice(userID, deviceID string) matrixSyncResponse {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var resp matrixSyncResponse
+	resp.ToDevice.Events = f.inbox[userID+"|"+deviceID]
+	delete(f.inbox, userID+"|"+deviceID)
+	return resp
+}
+
+func newTestE2EE(t *testing.T, server *fakeKeyServer, dir, userID, deviceID string) *matrixE2EE {
+	t.Helper()
+	e, err := newMatrixE2EE(dir)
+	if err != nil {
+		t.Fatalf("new e2ee: %v", err)
+	}
+	e.api = server.api(userID, deviceID)
+	if err := e.setup(context.Background(), userID, deviceID); err != nil {
+		t.Fatalf("setup %s: %v", userID, err)
+	}
+	return e
+}
+
+func encryptedEvent(t *testing.T, sender, eventID string, content map[string]any) matrixEvent {
+	t.Helper()
+	raw, _ := json.Marshal(content)
+	event := matrixEvent{Type: "m.room.encrypted", Sender: sender, EventID: eventID}
+	if err := json.Unmarshal(raw, &event.Content); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestMatrixE2EE_ExchangesRoomMessages(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeKeyServer()
+	agentDir := filepath.Join(t.TempDir(), "matrix")
+	agent := newTestE2EE(t, server, agentDir, "@agent:example.org", "AGENTDEV")
+	alice := newTestE2EE(t, server, t.TempDir(), "@alice:example.org", "ALICEDEV")
+
+	info, err := os.Stat(filepath.Join(agentDir, matrixDeviceKeysFile))
+	if err != nil {
+		t.Fatalf("expected device keys in the workspace: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Fatalf("device keys mode = %v, want 0600", info.Mode().Perm())
+	}
+	if len(server.crossSigned) != 2 {
+		t.Fatalf("expected both devices to upload cross-signing keys, got %v", server.crossSigned)
+	}
+
+	// Alice writes to the room; the event arrives before her room key.
+	content, err := alice.encryptRoomMessage(ctx, testMatrixRoom, map[string]string{"msgtype": "m.text", "body": "secret plans"})
+	if err != nil {
+		t.Fatalf("alice encrypt: %v", err)
+	}
+	event := encryptedEvent(t, "@alice:example.org", "$a1", content)
+	if _, err := agent.decryptRoomEvent(testMatrixRoom, event); !errors.Is(err, errMatrixMissingRoomKey) {
+		t.Fatalf("expected missing room key, got %v", err)
+	}
+	ready := agent.handleSync(ctx, server.toDevice("@agent:example.org", "AGENTDEV"))
+	if len(ready) != 1 || ready[0].event.EventID != "$a1" {
+		t.Fatalf("expected the held event once the key arrived, got %+v", ready)
+	}
+	decrypted, err := agent.decryptRoomEvent(testMatrixRoom, ready[0].event)
+	if err != nil {
+		t.Fatalf("agent decrypt: %v", err)
+	}
+	if decrypted.Type != "m.room.message" || decrypted.Content.Body != "secret plans" {
+		t.Fatalf("unexpected decrypted event: %+v", decrypted)
+	}
+	if _, err := agent.decryptRoomEvent("!other:example.org", event); err == nil {
+		t.Fatalf("expected a room key to be rejected in another room")
+	}
+
+	// The agent answers over its own Megolm session.
+	content, err = agent.encryptRoomMessage(ctx, testMatrixRoom, map[string]string{"msgtype": "m.text", "body": "noted"})
+	if err != nil {
+		t.Fatalf("agent encrypt: %v", err)
+	}
+	alice.handleSync(ctx, server.toDevice("@alice:example.org", "ALICEDEV"))
+	decrypted, err = alice.decryptRoomEvent(testMatrixRoom, encryptedEvent(t, "@agent:example.org", "$b1", content))
+	if err != nil || decrypted.Content.Body != "noted" {
+		t.Fatalf("alice decrypt: %+v %v", decrypted, err)
+	}
+
+	// A restarted agent keeps its identity and room keys.
+	restarted := newTestE2EE(t, server, agentDir, "@agent:example.org", "AGENTDEV")
+	if restarted.curveKey() != agent.curveKey() {
+		t.Fatalf("expected the persisted identity key to be reused")
+	}
+	content, err = alice.encryptRoomMessage(ctx, testMatrixRoom, map[string]string{"msgtype": "m.text", "body": "still there?"})
+	if err != nil {
+		t.Fatalf("alice encrypt: %v", err)
+	}
+	decrypted, err = restarted.decryptRoomEvent(testMatrixRoom, encryptedEvent(t, "@alice:example.org", "$a2", content))
+	if err != nil || decrypted.Content.Body != "still there?" {
+		t.Fatalf("restarted decrypt: %+v %v", decrypted, err)
+	}
+
+	// A token for a different device gets a fresh identity.
+	moved := newTestE2EE(t, server, agentDir, "@agent:example.org", "NEWDEV")
+	if moved.curveKey() == agent.curveKey() {
+		t.Fatalf("expected new keys for a new device ID")
+	}
+}
+
+func TestMatrixE2EE_SignatureRoundTrip(t *testing.T) {
+	account, err := newMatrixAccount("@agent:example.org", "DEV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &matrixE2EE{account: account}
+	keys := e.signedDeviceKeys()
+	raw, _ := json.Marshal(keys)
+	device, err := verifyMatrixDeviceKeys("@agent:example.org", "DEV", raw)
+	if err != nil {
+		t.Fatalf("verify own device keys: %v", err)
+	}
+	if device.Curve != e.curveKey() || device.Ed25519 != e.ed25519Key() {
+		t.Fatalf("unexpected device: %+v", device)
+	}
+	keys["device_id"] = "OTHER"
+	raw, _ = json.Marshal(keys)
+	if _, err := verifyMatrixDeviceKeys("@agent:example.org", "OTHER", raw); err == nil {
+		t.Fatalf("expected tampered device keys to fail verification")
+	}
+}
+
+func TestMatrixE2EE_RejectsRoomKeyFromUnverifiedSenderKey(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeKeyServer()
+	agent := newTestE2EE(t, server, t.TempDir(), "@agent:example.org", "AGENTDEV")
+	newTestE2EE(t, server, t.TempDir(), "@alice:example.org", "ALICEDEV")
+	mallory := newTestE2EE(t, server, t.TempDir(), "@mallory:example.org", "MALLORYDEV")
+
+	content, err := mallory.encryptRoomMessage(ctx, testMatrixRoom, map[string]string{"msgtype": "m.text", "body": "from alice, honest"})
+	if err != nil {
+		t.Fatalf("mallory encrypt: %v", err)
+	}
+	// Mallory's room key is relabelled as coming from Alice: the Olm identity
+	// is not one of Alice's devices, so the key must be dropped.
+	sync := server.toDevice("@agent:example.org", "AGENTDEV")
+	for i := range sync.ToDevice.Events {
+		sync.ToDevice.Events[i].Sender = "@alice:example.org"
+	}
+	agent.handleSync(ctx, sync)
+	if _, err := agent.decryptRoomEvent(testMatrixRoom, encryptedEvent(t, "@alice:example.org", "$m1", content)); !errors.Is(err, errMatrixMissingRoomKey) {
+		t.Fatalf("expected the spoofed room key to be rejected, got %v", err)
+	}
+
+	// A pinned device's Curve25519 key does not vouch for a payload naming
+	// another device's Ed25519 key.
+	agent.mu.Lock()
+	alice := agent.state.Devices["@alice:example.org"]["ALICEDEV"]
+	agent.mu.Unlock()
+	event := matrixEvent{Sender: "@alice:example.org"}
+	event.Content.Algorithm = matrixOlmAlgorithm
+	event.Content.SenderKey = mallory.curveKey()
+	if _, err := agent.handleToDevice(event); err == nil || !strings.Contains(err.Error(), "not a verified device") {
+		t.Fatalf("expected mallory's sender_key to be refused for alice, got %v", err)
+	}
+	if alice.Curve == "" {
+		t.Fatalf("expected alice's device to be pinned after the lookup")
+	}
+}
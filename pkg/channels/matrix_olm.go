@@ -0,0 +1,157 @@
+package channels
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"maunium.net/go/mautrix/crypto/goolm/account"
+	"maunium.net/go/mautrix/crypto/goolm/session"
+	"maunium.net/go/mautrix/id"
+)
+
+// The Olm and Megolm ratchets behind Matrix end-to-end encryption
+// (m.olm.v1.curve25519-aes-sha2 and m.megolm.v1.aes-sha2) come from goolm,
+// the pure-Go libolm port maintained with mautrix. This file wraps its
+// sessions with the bookkeeping the channel persists alongside them.
+
+// olmMessageType values in m.room.encrypted to-device ciphertext.
+const (
+	olmMessagePreKey = int(id.OlmMsgTypePreKey)
+	olmMessageNormal = int(id.OlmMsgTypeMsg)
+)
+
+// matrixB64 is the unpadded base64 Matrix uses for keys and ciphertext.
+var matrixB64 = base64.RawStdEncoding
+
+// olmSession is an Olm session with one other device.
+type olmSession struct {
+	Session    *session.OlmSession `json:"session"`
+	LastUsedMS int64               `json:"last_used_ms"`
+}
+
+func (s *olmSession) id() string {
+	return string(s.Session.ID())
+}
+
+// newOutboundOlmSession opens a session to the device with Curve25519
+// identity key theirIdentity using one of its claimed one-time keys.
+func newOutboundOlmSession(acct *account.Account, theirIdentity, theirOneTimeKey string) (*olmSession, error) {
+	s, err := acct.NewOutboundSession(id.Curve25519(theirIdentity), id.Curve25519(theirOneTimeKey))
+	if err != nil {
+		return nil, fmt.Errorf("olm: outbound session: %w", err)
+	}
+	return &olmSession{Session: s.(*session.OlmSession), LastUsedMS: time.Now().UnixMilli()}, nil
+}
+
+// newInboundOlmSession opens a session from a pre-key message sent by the
+// device with Curve25519 identity key theirIdentity. The caller removes the
+// one-time key it used once the message decrypts.
+func newInboundOlmSession(acct *account.Account, theirIdentity, body string) (*olmSession, error) {
+	key := id.Curve25519(theirIdentity)
+	s, err := acct.NewInboundSessionFrom(&key, body)
+	if err != nil {
+		return nil, fmt.Errorf("olm: inbound session: %w", err)
+	}
+	return &olmSession{Session: s.(*session.OlmSession), LastUsedMS: time.Now().UnixMilli()}, nil
+}
+
+// encrypt returns the message type and base64 body of plaintext.
+func (s *olmSession) encrypt(plaintext []byte) (int, string, error) {
+	msgType, body, err := s.Session.Encrypt(plaintext)
+	if err != nil {
+		return 0, "", err
+	}
+	s.LastUsedMS = time.Now().UnixMilli()
+	return int(msgType), string(body), nil
+}
+
+func (s *olmSession) decrypt(msgType int, body string) ([]byte, error) {
+	plaintext, err := s.Session.Decrypt(body, id.OlmMsgType(msgType))
+	if err != nil {
+		return nil, err
+	}
+	s.LastUsedMS = time.Now().UnixMilli()
+	return plaintext, nil
+}
+
+// megolmOutboundSession encrypts our messages in one room.
+type megolmOutboundSession struct {
+	Session   *session.MegolmOutboundSession `json:"session"`
+	CreatedMS int64                          `json:"created_ms"`
+	// SharedWith lists the "user_id|device_id" pairs that hold the key.
+	SharedWith map[string]bool `json:"shared_with"`
+}
+
+func newMegolmOutboundSession(nowMS int64) (*megolmOutboundSession, error) {
+	s, err := session.NewMegolmOutboundSession()
+	if err != nil {
+		return nil, fmt.Errorf("megolm: outbound session: %w", err)
+	}
+	return &megolmOutboundSession{Session: s, CreatedMS: nowMS, SharedWith: map[string]bool{}}, nil
+}
+
+func (s *megolmOutboundSession) id() string {
+	return string(s.Session.ID())
+}
+
+// messageIndex is the index the next message will be sent at.
+func (s *megolmOutboundSession) messageIndex() uint32 {
+	return uint32(s.Session.MessageIndex())
+}
+
+// sessionKey exports the session at its current index for an m.room_key
+// event.
+func (s *megolmOutboundSession) sessionKey() string {
+	return s.Session.Key()
+}
+
+// encrypt returns the base64 ciphertext of plaintext at the current index and
+// advances the ratchet.
+func (s *megolmOutboundSession) encrypt(plaintext []byte) (string, error) {
+	ciphertext, err := s.Session.Encrypt(plaintext)
+	if err != nil {
+		return "", err
+	}
+	return string(ciphertext), nil
+}
+
+// megolmInboundSession decrypts another device's messages in one room.
+type megolmInboundSession struct {
+	Session *session.MegolmInboundSession `json:"session"`
+	// SenderKey and SenderUser are the Olm identity and user that shared the
+	// key; RoomID is the room it was shared for.
+	SenderKey  string `json:"sender_key"`
+	SenderUser string `json:"sender_user"`
+	RoomID     string `json:"room_id"`
+
+	// seen maps message indexes to event IDs to catch replays.
+	seen map[uint32]string
+}
+
+func newMegolmInboundSession(sessionKey string) (*megolmInboundSession, error) {
+	s, err := session.NewMegolmInboundSession([]byte(sessionKey))
+	if err != nil {
+		return nil, fmt.Errorf("megolm: inbound session: %w", err)
+	}
+	return &megolmInboundSession{Session: s}, nil
+}
+
+func (s *megolmInboundSession) id() string {
+	return string(s.Session.ID())
+}
+
+// firstKnownIndex is the earliest message index the session can decrypt.
+func (s *megolmInboundSession) firstKnownIndex() uint32 {
+	return s.Session.FirstKnownIndex()
+}
+
+// decrypt verifies and decrypts a base64 Megolm message, returning the
+// plaintext and its message index.
+func (s *megolmInboundSession) decrypt(ciphertext string) ([]byte, uint32, error) {
+	plaintext, index, err := s.Session.Decrypt([]byte(ciphertext))
+	if err != nil {
+		return nil, 0, err
+	}
+	return plaintext, uint32(index), nil
+}
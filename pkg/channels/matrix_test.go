@@ -0,0 +1,157 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+	"github.com/dotsetgreg/dotagent/pkg/config"
+)
+
+const testMatrixRoom = "!room:example.org"
+
+// fakeMatrixServer answers whoami, sync, and send requests and records the
+// bodies of sent messages keyed by request path.
+type fakeMatrixServer struct {
+	mu    sync.Mutex
+	syncs int
+	sent  map[string]string
+}
+
+func (f *fakeMatrixServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer secret-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `{"errcode":"M_UNKNOWN_TOKEN","error":"bad token"}`)
+		return
+	}
+	switch {
+	case r.URL.Path == "/_matrix/client/v3/account/whoami":
+		_, _ = io.WriteString(w, `{"user_id":"@agent:example.org"}`)
+	case r.URL.Path == "/_matrix/client/v3/sync":
+		f.mu.Lock()
+		f.syncs++
+		f.mu.Unlock()
+		if r.URL.Query().Get("since") == "" {
+			_, _ = io.WriteString(w, `{"next_batch":"s1","rooms":{"join":{"!room:example.org":{"timeline":{"events":[
+				{"type":"m.room.message","sender":"@alice:example.org","event_id":"$old","content":{"msgtype":"m.text","body":"backlog"}}]}}}}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"next_batch":"s2","rooms":{"join":{
+			"!room:example.org":{"timeline":{"events":[
+				{"type":"m.room.message","sender":"@agent:example.org","event_id":"$self","content":{"msgtype":"m.text","body":"my own reply"}},
+				{"type":"m.room.message","sender":"@alice:example.org","event_id":"$img","content":{"msgtype":"m.image","body":"cat.png"}},
+				{"type":"m.room.message","sender":"@alice:example.org","event_id":"$e1","content":{"msgtype":"m.text","body":"What's on my calendar?"}}]}},
+			"!other:example.org":{"timeline":{"events":[
+				{"type":"m.room.message","sender":"@bob:example.org","event_id":"$e2","content":{"msgtype":"m.text","body":"not configured"}}]}}}}}`)
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.EscapedPath(), "/_matrix/client/v3/rooms/"):
+		body, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		f.sent[r.URL.EscapedPath()] = string(body)
+		f.mu.Unlock()
+		_, _ = io.WriteString(w, `{"event_id":"$reply"}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestMatrixChannel(t *testing.T, msgBus *bus.MessageBus) (*MatrixChannel, *fakeMatrixServer) {
+	t.Helper()
+	fake := &fakeMatrixServer{sent: map[string]string{}}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	ch, err := NewMatrixChannel(config.MatrixConfig{
+		Enabled:       true,
+		HomeserverURL: srv.URL + "/",
+		AccessToken:   "secret-token",
+		RoomIDs:       config.FlexibleStringSlice{testMatrixRoom},
+	}, msgBus)
+	if err != nil {
+		t.Fatalf("new matrix channel: %v", err)
+	}
+	return ch, fake
+}
+
+func TestMatrixChannel_SyncPublishesRoomMessages(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	ch, _ := newTestMatrixChannel(t, msgBus)
+
+	userID, _, err := ch.whoami(context.Background())
+	if err != nil {
+		t.Fatalf("whoami: %v", err)
+	}
+	ch.userID = userID
+
+	since, err := ch.syncOnce(context.Background(), "", 0)
+	if err != nil || since != "s1" {
+		t.Fatalf("initial sync: since=%q err=%v", since, err)
+	}
+	since, err = ch.syncOnce(context.Background(), since, 0)
+	if err != nil || since != "s2" {
+		t.Fatalf("sync: since=%q err=%v", since, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, ok := msgBus.ConsumeInbound(ctx)
+	if !ok {
+		t.Fatalf("expected inbound message")
+	}
+	if msg.SessionKey != "matrix:"+testMatrixRoom || msg.ChatID != testMatrixRoom || msg.SenderID != "@alice:example.org" {
+		t.Fatalf("unexpected routing: %+v", msg)
+	}
+	if msg.Content != "What's on my calendar?" || msg.MessageID != "$e1" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if extra, ok := msgBus.ConsumeInbound(ctx); ok {
+		t.Fatalf("expected backlog, own, non-text, and unconfigured room events to be skipped, got %+v", extra)
+	}
+}
+
+func TestMatrixChannel_SendPutsTextMessage(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	ch, fake := newTestMatrixChannel(t, msgBus)
+	ch.setRunning(true)
+
+	if err := ch.Send(context.Background(), bus.OutboundMessage{Channel: "matrix", ChatID: testMatrixRoom, Content: "partial", Stream: true}); err != nil {
+		t.Fatalf("send stream delta: %v", err)
+	}
+	if len(fake.sent) != 0 {
+		t.Fatalf("expected stream deltas to be skipped")
+	}
+
+	if err := ch.Send(context.Background(), bus.OutboundMessage{Channel: "matrix", ChatID: testMatrixRoom, Content: "Two meetings today."}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if len(fake.sent) != 1 {
+		t.Fatalf("expected one sent message, got %d", len(fake.sent))
+	}
+	for path, body := range fake.sent {
+		if !strings.HasPrefix(path, "/_matrix/client/v3/rooms/%21room:example.org/send/m.room.message/") {
+			t.Fatalf("unexpected send path: %s", path)
+		}
+		var content map[string]string
+		if err := json.Unmarshal([]byte(body), &content); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		if content["msgtype"] != "m.text" || content["body"] != "Two meetings today." {
+			t.Fatalf("unexpected body: %s", body)
+		}
+	}
+
+	if err := ch.Send(context.Background(), bus.OutboundMessage{Channel: "matrix", ChatID: "!other:example.org", Content: "hi"}); err == nil {
+		t.Fatalf("expected send to an unconfigured room to fail")
+	}
+}
@@ -0,0 +1,146 @@
+package channels
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+	"github.com/dotsetgreg/dotagent/pkg/logger"
+)
+
+const (
+	stdinChannelName = "stdin"
+	stdinSenderID    = "stdin"
+	stdinMaxLineSize = 1 << 20
+)
+
+// StdinChannel turns the gateway into a line-oriented Unix filter: every
+// non-empty line read from the input is one inbound message, and every final
+// reply is written to the output followed by a newline. Stream deltas are not
+// written, so the output holds exactly one reply per answered line.
+//
+// Each line is sent from its own chat, so a reply names the line it answers
+// and a line is only counted as answered by a reply to that line.
+type StdinChannel struct {
+	*BaseChannel
+	in    io.Reader
+	out   io.Writer
+	runID string
+
+	mu      sync.Mutex
+	seq     int
+	pending map[string]bool
+	eof     bool
+	done    chan struct{}
+	closed  bool
+	cancel  context.CancelFunc
+	writeMu sync.Mutex
+}
+
+func NewStdinChannel(messageBus *bus.MessageBus, in io.Reader, out io.Writer) *StdinChannel {
+	return &StdinChannel{
+		BaseChannel: NewBaseChannel(stdinChannelName, nil, messageBus, nil),
+		in:          in,
+		out:         out,
+		runID:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		pending:     map[string]bool{},
+		done:        make(chan struct{}),
+	}
+}
+
+func (c *StdinChannel) Start(ctx context.Context) error {
+	c.mu.Lock()
+	if c.running {
+		c.mu.Unlock()
+		return nil
+	}
+	readCtx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	c.setRunning(true)
+	c.mu.Unlock()
+
+	go c.readLoop(readCtx)
+	return nil
+}
+
+func (c *StdinChannel) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+	c.setRunning(false)
+	return nil
+}
+
+// Done is closed once the input has reached EOF and every line read before
+// it has been answered.
+func (c *StdinChannel) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *StdinChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	if msg.Stream && !msg.StreamFinal && strings.TrimSpace(msg.StreamID) != "" {
+		return nil
+	}
+	content := strings.TrimRight(msg.Content, "\n")
+	if strings.TrimSpace(content) == "" {
+		return nil
+	}
+
+	c.writeMu.Lock()
+	_, err := fmt.Fprintln(c.out, content)
+	c.writeMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("write stdout: %w", err)
+	}
+
+	c.mu.Lock()
+	delete(c.pending, msg.ChatID)
+	c.closeIfDrainedLocked()
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *StdinChannel) readLoop(ctx context.Context) {
+	scanner := bufio.NewScanner(c.in)
+	scanner.Buffer(make([]byte, 0, 64<<10), stdinMaxLineSize)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		c.mu.Lock()
+		c.seq++
+		// The run ID keeps line chats from continuing an earlier run's.
+		chatID := fmt.Sprintf("stdin-%s-%d", c.runID, c.seq)
+		c.pending[chatID] = true
+		c.mu.Unlock()
+		c.HandleMessage(stdinSenderID, chatID, chatID, line, nil, nil)
+	}
+	if err := scanner.Err(); err != nil {
+		logger.WarnCF("stdin", "Stopped reading input", map[string]interface{}{"error": err.Error()})
+	}
+
+	c.mu.Lock()
+	c.eof = true
+	c.closeIfDrainedLocked()
+	c.mu.Unlock()
+}
+
+func (c *StdinChannel) closeIfDrainedLocked() {
+	if c.eof && len(c.pending) == 0 && !c.closed {
+		c.closed = true
+		close(c.done)
+	}
+}
@@ -0,0 +1,69 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+)
+
+func TestStdinChannel_LinesInRepliesOut(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	msgBus := bus.NewMessageBus()
+	var out bytes.Buffer
+	ch := NewStdinChannel(msgBus, strings.NewReader("first\n\n  second  \n"), &out)
+	if err := ch.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ch.Stop(ctx)
+
+	var got []bus.InboundMessage
+	for i := 0; i < 2; i++ {
+		msg, ok := msgBus.ConsumeInbound(ctx)
+		if !ok {
+			t.Fatalf("expected inbound message %d", i+1)
+		}
+		got = append(got, msg)
+	}
+	if got[0].Content != "first" || got[1].Content != "second" {
+		t.Fatalf("unexpected inbound contents: %q, %q", got[0].Content, got[1].Content)
+	}
+	if got[0].Channel != "stdin" || got[0].ChatID == "stdin" || got[0].ChatID == got[1].ChatID {
+		t.Fatalf("expected each line in its own chat, got %q and %q", got[0].ChatID, got[1].ChatID)
+	}
+
+	select {
+	case <-ch.Done():
+		t.Fatal("Done closed before lines were answered")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	first, second := got[0].ChatID, got[1].ChatID
+	_ = ch.Send(ctx, bus.OutboundMessage{Channel: "stdin", ChatID: first, Content: "partial", Stream: true, StreamID: "t1"})
+	_ = ch.Send(ctx, bus.OutboundMessage{Channel: "stdin", ChatID: first, Content: "reply one", Stream: true, StreamID: "t1", StreamFinal: true})
+	// A second reply to an answered line is written but does not stand in
+	// for the line still waiting.
+	_ = ch.Send(ctx, bus.OutboundMessage{Channel: "stdin", ChatID: first, Content: "notice"})
+
+	select {
+	case <-ch.Done():
+		t.Fatal("Done closed before the second line was answered")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	_ = ch.Send(ctx, bus.OutboundMessage{Channel: "stdin", ChatID: second, Content: "reply two\n"})
+
+	select {
+	case <-ch.Done():
+	case <-ctx.Done():
+		t.Fatal("expected Done after EOF and all replies")
+	}
+	if out.String() != "reply one\nnotice\nreply two\n" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
@@ -3,8 +3,11 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -45,18 +48,20 @@ func (f *FlexibleStringSlice) UnmarshalJSON(data []byte) error {
 }
 
 type Config struct {
-	SchemaVersion int             `json:"schema_version"`
-	Instance      InstanceConfig  `json:"instance"`
-	Paths         PathsConfig     `json:"paths"`
-	Runtime       RuntimeConfig   `json:"runtime"`
-	Admin         AdminConfig     `json:"admin"`
-	Agents        AgentsConfig    `json:"agents"`
-	Channels      ChannelsConfig  `json:"channels"`
-	Providers     ProvidersConfig `json:"providers"`
-	Gateway       GatewayConfig   `json:"gateway"`
-	Tools         ToolsConfig     `json:"tools"`
-	Memory        MemoryConfig    `json:"memory"`
-	Heartbeat     HeartbeatConfig `json:"heartbeat"`
+	SchemaVersion int                 `json:"schema_version"`
+	Instance      InstanceConfig      `json:"instance"`
+	Paths         PathsConfig         `json:"paths"`
+	Runtime       RuntimeConfig       `json:"runtime"`
+	Admin         AdminConfig         `json:"admin"`
+	Agents        AgentsConfig        `json:"agents"`
+	Channels      ChannelsConfig      `json:"channels"`
+	Providers     ProvidersConfig     `json:"providers"`
+	Gateway       GatewayConfig       `json:"gateway"`
+	Tools         ToolsConfig         `json:"tools"`
+	Memory        MemoryConfig        `json:"memory"`
+	Heartbeat     HeartbeatConfig     `json:"heartbeat"`
+	Observability ObservabilityConfig `json:"observability"`
+	Toolpacks     ToolpacksConfig     `json:"toolpacks"`
 	mu            sync.RWMutex
 }
 
@@ -98,38 +103,159 @@ type AgentDefaults struct {
 	MaxTokens                 int     `json:"max_tokens" env:"DOTAGENT_AGENTS_DEFAULTS_MAX_TOKENS"`
 	Temperature               float64 `json:"temperature" env:"DOTAGENT_AGENTS_DEFAULTS_TEMPERATURE"`
 	MaxToolIterations         int     `json:"max_tool_iterations" env:"DOTAGENT_AGENTS_DEFAULTS_MAX_TOOL_ITERATIONS"`
+	MaxSubagentDepth          int     `json:"max_subagent_depth" env:"DOTAGENT_AGENTS_DEFAULTS_MAX_SUBAGENT_DEPTH"`
 	MaxConcurrentRuns         int     `json:"max_concurrent_runs" env:"DOTAGENT_AGENTS_DEFAULTS_MAX_CONCURRENT_RUNS"`
 	SessionFileLockEnabled    bool    `json:"session_file_lock_enabled" env:"DOTAGENT_AGENTS_DEFAULTS_SESSION_FILE_LOCK_ENABLED"`
 	SessionLockTimeoutMS      int     `json:"session_lock_timeout_ms" env:"DOTAGENT_AGENTS_DEFAULTS_SESSION_LOCK_TIMEOUT_MS"`
 	SessionLockStaleSeconds   int     `json:"session_lock_stale_seconds" env:"DOTAGENT_AGENTS_DEFAULTS_SESSION_LOCK_STALE_SECONDS"`
 	SessionLockMaxHoldSeconds int     `json:"session_lock_max_hold_seconds" env:"DOTAGENT_AGENTS_DEFAULTS_SESSION_LOCK_MAX_HOLD_SECONDS"`
+	// Per-section prompt token caps, off by default; 0 disables a cap.
+	MaxSystemTokens  int `json:"max_system_tokens" env:"DOTAGENT_AGENTS_DEFAULTS_MAX_SYSTEM_TOKENS"`
+	MaxRecallTokens  int `json:"max_recall_tokens" env:"DOTAGENT_AGENTS_DEFAULTS_MAX_RECALL_TOKENS"`
+	MaxHistoryTokens int `json:"max_history_tokens" env:"DOTAGENT_AGENTS_DEFAULTS_MAX_HISTORY_TOKENS"`
+	// Inlines skill instructions into the system prompt within this budget;
+	// 0 keeps the summary-only skill listing.
+	MaxSkillTokens int `json:"max_skill_tokens" env:"DOTAGENT_AGENTS_DEFAULTS_MAX_SKILL_TOKENS"`
+	// Workspace-relative files appended to the system prompt under
+	// "## Auto Context" on every turn, capped at MaxAutoContextTokens (0 means
+	// no cap). Missing files are skipped.
+	AutoContextFiles     FlexibleStringSlice `json:"auto_context_files" env:"DOTAGENT_AGENTS_DEFAULTS_AUTO_CONTEXT_FILES"`
+	MaxAutoContextTokens int                 `json:"max_auto_context_tokens" env:"DOTAGENT_AGENTS_DEFAULTS_MAX_AUTO_CONTEXT_TOKENS"`
+	// Regex patterns stripped from the start or end of final responses. The
+	// env var takes one pattern per line since patterns may contain commas.
+	ResponseFilters FlexibleStringSlice `json:"response_filters" env:"DOTAGENT_AGENTS_DEFAULTS_RESPONSE_FILTERS" envSeparator:"\n"`
+	// Spreads cron-expression jobs due at the same time over up to this many
+	// seconds; each job keeps the same offset across restarts. 0 disables.
+	CronJitterSeconds int `json:"cron_jitter_seconds" env:"DOTAGENT_AGENTS_DEFAULTS_CRON_JITTER_SECONDS"`
+}
+
+// DefaultResponseFilters strip filler openers and closers such as
+// "Certainly! Here is your answer:" and "I hope this helps!".
+var DefaultResponseFilters = []string{
+	`(?i)(?:certainly|sure|of course|absolutely|great question)[!.]`,
+	`(?i)here(?:'s| is) (?:your|the|my) (?:answer|response)[^\n]{0,40}?:`,
+	`(?i)(?:i hope (?:this|that) helps|let me know if you have any (?:other |more |further )?questions)[^\n]{0,40}?[.!]`,
 }
 
 type ChannelsConfig struct {
 	Discord DiscordConfig `json:"discord"`
+	Email   EmailConfig   `json:"email"`
+	Matrix  MatrixConfig  `json:"matrix"`
 }
 
 type DiscordConfig struct {
 	Token     string              `json:"token" env:"DOTAGENT_CHANNELS_DISCORD_TOKEN"`
 	AllowFrom FlexibleStringSlice `json:"allow_from" env:"DOTAGENT_CHANNELS_DISCORD_ALLOW_FROM"`
+	Retry     DiscordRetryConfig  `json:"retry"`
+	// MaxMessageLength caps each sent message; longer replies are split
+	// into numbered parts at SplitStrategy boundaries (paragraph, sentence
+	// or word).
+	MaxMessageLength int    `json:"max_message_length" env:"DOTAGENT_CHANNELS_DISCORD_MAX_MESSAGE_LENGTH"`
+	SplitStrategy    string `json:"split_strategy" env:"DOTAGENT_CHANNELS_DISCORD_SPLIT_STRATEGY"`
+}
+
+// DiscordRetryConfig controls how often a failed Discord send is retried.
+// Attempts back off exponentially from BaseDelayMS with jitter; messages that
+// still fail are written to failed_messages.log in the workspace.
+type DiscordRetryConfig struct {
+	MaxAttempts int `json:"max_attempts" env:"DOTAGENT_CHANNELS_DISCORD_RETRY_MAX_ATTEMPTS"`
+	BaseDelayMS int `json:"base_delay_ms" env:"DOTAGENT_CHANNELS_DISCORD_RETRY_BASE_DELAY_MS"`
+}
+
+// EmailConfig configures the IMAP-polling email channel. Replies are sent over
+// SMTP; smtp_user/smtp_password fall back to the IMAP credentials when empty.
+//
+// The From header is trivially forged, so allow_from alone does not
+// authenticate anyone. Inbound mail is only accepted when the receiving
+// server's Authentication-Results header reports dkim=pass or dmarc=pass for
+// the From domain. auth_serv_id names that server (the first token of its
+// Authentication-Results header); when empty only the topmost header, the one
+// the receiving server prepends, is trusted. allow_unauthenticated turns the
+// check off for servers that do not stamp results, leaving allow_from as a
+// filter that any sender can satisfy by spoofing an allowed address.
+type EmailConfig struct {
+	Enabled              bool                `json:"enabled" env:"DOTAGENT_CHANNELS_EMAIL_ENABLED"`
+	IMAPHost             string              `json:"imap_host" env:"DOTAGENT_CHANNELS_EMAIL_IMAP_HOST"`
+	IMAPUser             string              `json:"imap_user" env:"DOTAGENT_CHANNELS_EMAIL_IMAP_USER"`
+	IMAPPassword         string              `json:"imap_password" env:"DOTAGENT_CHANNELS_EMAIL_IMAP_PASSWORD"`
+	Mailbox              string              `json:"mailbox" env:"DOTAGENT_CHANNELS_EMAIL_MAILBOX"`
+	SMTPHost             string              `json:"smtp_host" env:"DOTAGENT_CHANNELS_EMAIL_SMTP_HOST"`
+	SMTPUser             string              `json:"smtp_user" env:"DOTAGENT_CHANNELS_EMAIL_SMTP_USER"`
+	SMTPPassword         string              `json:"smtp_password" env:"DOTAGENT_CHANNELS_EMAIL_SMTP_PASSWORD"`
+	FromAddress          string              `json:"from_address" env:"DOTAGENT_CHANNELS_EMAIL_FROM_ADDRESS"`
+	PollIntervalSeconds  int                 `json:"poll_interval_seconds" env:"DOTAGENT_CHANNELS_EMAIL_POLL_INTERVAL_SECONDS"`
+	AllowFrom            FlexibleStringSlice `json:"allow_from" env:"DOTAGENT_CHANNELS_EMAIL_ALLOW_FROM"`
+	AuthServID           string              `json:"auth_serv_id" env:"DOTAGENT_CHANNELS_EMAIL_AUTH_SERV_ID"`
+	AllowUnauthenticated bool                `json:"allow_unauthenticated" env:"DOTAGENT_CHANNELS_EMAIL_ALLOW_UNAUTHENTICATED"`
+}
+
+// MatrixConfig configures the Matrix channel, which long-polls the
+// Client-Server sync API and only serves the listed rooms.
+type MatrixConfig struct {
+	Enabled       bool                `json:"enabled" env:"DOTAGENT_CHANNELS_MATRIX_ENABLED"`
+	HomeserverURL string              `json:"homeserver_url" env:"DOTAGENT_CHANNELS_MATRIX_HOMESERVER_URL"`
+	AccessToken   string              `json:"access_token" env:"DOTAGENT_CHANNELS_MATRIX_ACCESS_TOKEN"`
+	RoomIDs       FlexibleStringSlice `json:"room_ids" env:"DOTAGENT_CHANNELS_MATRIX_ROOM_IDS"`
+	AllowFrom     FlexibleStringSlice `json:"allow_from" env:"DOTAGENT_CHANNELS_MATRIX_ALLOW_FROM"`
+	// E2EE enables Olm/Megolm encryption. Device keys are kept in the
+	// workspace under matrix/device_keys.json.
+	E2EE bool `json:"e2ee" env:"DOTAGENT_CHANNELS_MATRIX_E2EE"`
 }
 
 type HeartbeatConfig struct {
 	Enabled  bool `json:"enabled" env:"DOTAGENT_HEARTBEAT_ENABLED"`
 	Interval int  `json:"interval" env:"DOTAGENT_HEARTBEAT_INTERVAL"` // minutes, min 5
+	// FallbackChannel ("channel:chat_id") receives heartbeats until a user
+	// message has been seen on a real channel.
+	FallbackChannel string `json:"fallback_channel" env:"DOTAGENT_HEARTBEAT_FALLBACK_CHANNEL"`
+}
+
+// ObservabilityConfig controls OpenTelemetry tracing of the agent request
+// path. Spans are exported over OTLP/HTTP.
+type ObservabilityConfig struct {
+	Enabled bool             `json:"enabled" env:"DOTAGENT_OBSERVABILITY_ENABLED"`
+	OTLP    OTLPExportConfig `json:"otlp"`
+}
+
+type OTLPExportConfig struct {
+	// Endpoint is the collector base URL, e.g. http://localhost:4318; spans
+	// are posted to <endpoint>/v1/traces unless the URL already has a path.
+	Endpoint string `json:"endpoint" env:"DOTAGENT_OBSERVABILITY_OTLP_ENDPOINT"`
+}
+
+type ToolpacksConfig struct {
+	// SchemaCacheMinutes is how long tool schemas discovered from MCP
+	// connectors are reused before the server is asked again; 0 disables the
+	// cache.
+	SchemaCacheMinutes int `json:"schema_cache_minutes" env:"DOTAGENT_TOOLPACKS_SCHEMA_CACHE_MINUTES"`
 }
 
 type ProvidersConfig struct {
-	OpenRouter  OpenRouterProviderConfig  `json:"openrouter"`
-	OpenAI      OpenAIProviderConfig      `json:"openai"`
-	OpenAICodex OpenAICodexProviderConfig `json:"openai_codex"`
-	Ollama      OllamaProviderConfig      `json:"ollama"`
+	OpenRouter   OpenRouterProviderConfig  `json:"openrouter"`
+	OpenAI       OpenAIProviderConfig      `json:"openai"`
+	OpenAICodex  OpenAICodexProviderConfig `json:"openai_codex"`
+	Ollama       OllamaProviderConfig      `json:"ollama"`
+	LoadBalancer LoadBalancerConfig        `json:"load_balancer"`
+	// ModelCacheMinutes is how long a provider's model list is cached under
+	// paths.data; 0 always queries the provider.
+	ModelCacheMinutes int `json:"model_cache_minutes" env:"DOTAGENT_PROVIDERS_MODEL_CACHE_MINUTES"`
 }
 
 type OpenRouterProviderConfig struct {
-	APIKey  string `json:"api_key" env:"DOTAGENT_PROVIDERS_OPENROUTER_API_KEY"`
-	APIBase string `json:"api_base" env:"DOTAGENT_PROVIDERS_OPENROUTER_API_BASE"`
-	Proxy   string `json:"proxy,omitempty" env:"DOTAGENT_PROVIDERS_OPENROUTER_PROXY"`
+	APIKey string `json:"api_key" env:"DOTAGENT_PROVIDERS_OPENROUTER_API_KEY"`
+	// APIKeys adds keys that share load round-robin with APIKey.
+	APIKeys FlexibleStringSlice `json:"api_keys,omitempty" env:"DOTAGENT_PROVIDERS_OPENROUTER_API_KEYS"`
+	APIBase string              `json:"api_base" env:"DOTAGENT_PROVIDERS_OPENROUTER_API_BASE"`
+	Proxy   string              `json:"proxy,omitempty" env:"DOTAGENT_PROVIDERS_OPENROUTER_PROXY"`
+	// ResponsesAPI keeps conversation state server-side through OpenRouter's
+	// Responses API (previous_response_id) instead of re-sending history.
+	ResponsesAPI bool `json:"responses_api,omitempty" env:"DOTAGENT_PROVIDERS_OPENROUTER_RESPONSES_API"`
+}
+
+// LoadBalancerConfig applies when a provider is configured with several API
+// keys: a key that hits a rate limit is skipped for CooldownSeconds.
+type LoadBalancerConfig struct {
+	CooldownSeconds int `json:"cooldown_seconds" env:"DOTAGENT_PROVIDERS_LOAD_BALANCER_COOLDOWN_SECONDS"`
 }
 
 type OpenAIProviderConfig struct {
@@ -156,8 +282,52 @@ type OllamaProviderConfig struct {
 }
 
 type GatewayConfig struct {
-	Host string `json:"host" env:"DOTAGENT_GATEWAY_HOST"`
-	Port int    `json:"port" env:"DOTAGENT_GATEWAY_PORT"`
+	Host          string              `json:"host" env:"DOTAGENT_GATEWAY_HOST"`
+	Port          int                 `json:"port" env:"DOTAGENT_GATEWAY_PORT"`
+	Admin         GatewayAdminConfig  `json:"admin"`
+	ContentFilter ContentFilterConfig `json:"content_filter"`
+	// PriorityBus processes system messages (subagent results) before
+	// queued user messages, and background messages (cron, file watches)
+	// last.
+	PriorityBus bool `json:"priority_bus" env:"DOTAGENT_GATEWAY_PRIORITY_BUS"`
+	// LogLevel is debug, info, warn or error; --debug overrides it. A running
+	// gateway picks up changes on SIGHUP.
+	LogLevel string `json:"log_level" env:"DOTAGENT_GATEWAY_LOG_LEVEL"`
+	// DedupWindowSeconds drops an inbound message with the same channel,
+	// chat and content as one delivered less than this many seconds earlier,
+	// as when a misconfigured webhook posts it twice; 0 disables it. The
+	// stdin channel is never deduplicated.
+	DedupWindowSeconds int `json:"dedup_window_seconds" env:"DOTAGENT_GATEWAY_DEDUP_WINDOW_SECONDS"`
+}
+
+// ContentFilterConfig screens inbound messages before they reach the model.
+// A message matching any blocklist regex gets a canned refusal instead.
+type ContentFilterConfig struct {
+	BlocklistPatterns FlexibleStringSlice `json:"blocklist_patterns" env:"DOTAGENT_GATEWAY_CONTENT_FILTER_BLOCKLIST_PATTERNS" envSeparator:"\n"`
+}
+
+// GatewayAdminConfig controls the authenticated admin API, served on its own
+// host and port next to the health endpoints. It listens on loopback by
+// default; any other address also needs a TLS certificate and key so the
+// bearer token is never sent in the clear.
+type GatewayAdminConfig struct {
+	Enabled     bool   `json:"enabled" env:"DOTAGENT_GATEWAY_ADMIN_ENABLED"`
+	Host        string `json:"host" env:"DOTAGENT_GATEWAY_ADMIN_HOST"`
+	Port        int    `json:"port" env:"DOTAGENT_GATEWAY_ADMIN_PORT"`
+	Token       string `json:"token,omitempty" env:"DOTAGENT_GATEWAY_ADMIN_TOKEN"`
+	TLSCertFile string `json:"tls_cert_file,omitempty" env:"DOTAGENT_GATEWAY_ADMIN_TLS_CERT_FILE"`
+	TLSKeyFile  string `json:"tls_key_file,omitempty" env:"DOTAGENT_GATEWAY_ADMIN_TLS_KEY_FILE"`
+}
+
+// IsLoopbackHost reports whether host only accepts connections from this
+// machine.
+func IsLoopbackHost(host string) bool {
+	host = strings.Trim(strings.TrimSpace(host), "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
 
 type BraveConfig struct {
@@ -176,8 +346,112 @@ type WebToolsConfig struct {
 	DuckDuckGo DuckDuckGoConfig `json:"duckduckgo"`
 }
 
+type CodeRunnerConfig struct {
+	TimeoutSeconds   int      `json:"timeout_seconds" env:"DOTAGENT_TOOLS_CODE_RUNNER_TIMEOUT_SECONDS"`
+	AllowedLanguages []string `json:"allowed_languages" env:"DOTAGENT_TOOLS_CODE_RUNNER_ALLOWED_LANGUAGES"`
+	// UseSandbox runs snippets in a throwaway Docker container. It is
+	// implied when agents.defaults.restrict_to_workspace is set.
+	UseSandbox bool `json:"use_sandbox" env:"DOTAGENT_TOOLS_CODE_RUNNER_USE_SANDBOX"`
+}
+
+// ApprovalConfig controls the request_approval tool, which waits for the user
+// to confirm a destructive action before the agent proceeds.
+type ApprovalConfig struct {
+	TimeoutSeconds int `json:"timeout_seconds" env:"DOTAGENT_TOOLS_APPROVAL_TIMEOUT_SECONDS"`
+}
+
+// ToolAuditConfig enables the append-only tool call log at
+// <paths.data>/audit/tools.jsonl.
+type ToolAuditConfig struct {
+	Enabled       bool `json:"enabled" env:"DOTAGENT_TOOLS_AUDIT_ENABLED"`
+	MaxFileSizeMB int  `json:"max_file_size_mb" env:"DOTAGENT_TOOLS_AUDIT_MAX_FILE_SIZE_MB"`
+	// RetentionDays is how long `dotagent workspace clean` keeps audit entries.
+	RetentionDays int `json:"retention_days" env:"DOTAGENT_TOOLS_AUDIT_RETENTION_DAYS"`
+}
+
+type FileWatchConfig struct {
+	MaxWatchers int `json:"max_watchers" env:"DOTAGENT_TOOLS_FILE_WATCH_MAX_WATCHERS"`
+}
+
+// ArchiveConfig bounds the archive_create and archive_extract tools. MaxSizeMB
+// caps both the archive file and the total size of the files it holds.
+type ArchiveConfig struct {
+	MaxSizeMB int `json:"max_size_mb" env:"DOTAGENT_TOOLS_ARCHIVE_MAX_SIZE_MB"`
+}
+
+// SSHConfig enables the ssh_exec tool. The private key is read from KeyPath
+// at call time so it never appears in prompts or config dumps.
+type SSHConfig struct {
+	Enabled        bool                `json:"enabled" env:"DOTAGENT_TOOLS_SSH_ENABLED"`
+	KeyPath        string              `json:"key_path" env:"DOTAGENT_TOOLS_SSH_KEY_PATH"`
+	KnownHostsPath string              `json:"known_hosts_path" env:"DOTAGENT_TOOLS_SSH_KNOWN_HOSTS_PATH"`
+	AllowedHosts   FlexibleStringSlice `json:"allowed_hosts" env:"DOTAGENT_TOOLS_SSH_ALLOWED_HOSTS"`
+	TimeoutSeconds int                 `json:"timeout_seconds" env:"DOTAGENT_TOOLS_SSH_TIMEOUT_SECONDS"`
+}
+
+// KubernetesConfig enables the read-only kubernetes tool. When the agent is
+// restricted to its workspace, only AllowedNamespaces are reachable.
+type KubernetesConfig struct {
+	Enabled           bool                `json:"enabled" env:"DOTAGENT_TOOLS_KUBERNETES_ENABLED"`
+	KubeconfigPath    string              `json:"kubeconfig_path" env:"DOTAGENT_TOOLS_KUBERNETES_KUBECONFIG_PATH"`
+	InCluster         bool                `json:"in_cluster" env:"DOTAGENT_TOOLS_KUBERNETES_IN_CLUSTER"`
+	AllowedNamespaces FlexibleStringSlice `json:"allowed_namespaces" env:"DOTAGENT_TOOLS_KUBERNETES_ALLOWED_NAMESPACES"`
+}
+
+// VoiceConfig configures the voice_transcribe tool. The tool is registered
+// when either an API key or a local Whisper binary is set; the binary wins
+// when both are.
+type VoiceConfig struct {
+	APIKey        string `json:"api_key" env:"DOTAGENT_TOOLS_VOICE_API_KEY"`
+	APIBase       string `json:"api_base" env:"DOTAGENT_TOOLS_VOICE_API_BASE"`
+	Model         string `json:"model" env:"DOTAGENT_TOOLS_VOICE_MODEL"`
+	Language      string `json:"language" env:"DOTAGENT_TOOLS_VOICE_LANGUAGE"`
+	WhisperBinary string `json:"whisper_binary" env:"DOTAGENT_TOOLS_VOICE_WHISPER_BINARY"`
+	MaxFileMB     int    `json:"max_file_mb" env:"DOTAGENT_TOOLS_VOICE_MAX_FILE_MB"`
+}
+
+// IssueTrackerConfig selects the backend for the issue_create and issue_list
+// tools. An empty backend leaves them unregistered.
+type IssueTrackerConfig struct {
+	Backend string `json:"backend" env:"DOTAGENT_TOOLS_ISSUE_TRACKER_BACKEND"`
+}
+
+// JiraConfig holds Jira Cloud credentials for the "jira" issue tracker
+// backend. User is the account email the API token belongs to.
+type JiraConfig struct {
+	BaseURL    string `json:"base_url" env:"DOTAGENT_TOOLS_JIRA_BASE_URL"`
+	User       string `json:"user" env:"DOTAGENT_TOOLS_JIRA_USER"`
+	APIToken   string `json:"api_token" env:"DOTAGENT_TOOLS_JIRA_API_TOKEN"`
+	ProjectKey string `json:"project_key" env:"DOTAGENT_TOOLS_JIRA_PROJECT_KEY"`
+	IssueType  string `json:"issue_type" env:"DOTAGENT_TOOLS_JIRA_ISSUE_TYPE"`
+}
+
+// ScreenshotConfig controls the screenshot tool. The tool is always
+// available to unrestricted agents; workspace-restricted agents need Enabled.
+type ScreenshotConfig struct {
+	Enabled bool `json:"enabled" env:"DOTAGENT_TOOLS_SCREENSHOT_ENABLED"`
+}
+
+// PDFConfig bounds the pdf_read tool. MaxChars caps the text returned by a
+// single call.
+type PDFConfig struct {
+	MaxChars int `json:"max_chars" env:"DOTAGENT_TOOLS_PDF_MAX_CHARS"`
+}
+
 type ToolsConfig struct {
-	Web WebToolsConfig `json:"web"`
+	Web          WebToolsConfig     `json:"web"`
+	CodeRunner   CodeRunnerConfig   `json:"code_runner"`
+	FileWatch    FileWatchConfig    `json:"file_watch"`
+	Archive      ArchiveConfig      `json:"archive"`
+	Approval     ApprovalConfig     `json:"approval"`
+	Audit        ToolAuditConfig    `json:"audit"`
+	SSH          SSHConfig          `json:"ssh"`
+	Kubernetes   KubernetesConfig   `json:"kubernetes"`
+	Voice        VoiceConfig        `json:"voice"`
+	IssueTracker IssueTrackerConfig `json:"issue_tracker"`
+	Jira         JiraConfig         `json:"jira"`
+	Screenshot   ScreenshotConfig   `json:"screenshot"`
+	PDF          PDFConfig          `json:"pdf"`
 }
 
 type MemoryConfig struct {
@@ -188,6 +462,7 @@ type MemoryConfig struct {
 	WorkerLeaseSeconds                  int      `json:"worker_lease_seconds" env:"DOTAGENT_MEMORY_WORKER_LEASE_SECONDS"`
 	EmbeddingModel                      string   `json:"embedding_model" env:"DOTAGENT_MEMORY_EMBEDDING_MODEL"`
 	EmbeddingFallbackModels             []string `json:"embedding_fallback_models" env:"DOTAGENT_MEMORY_EMBEDDING_FALLBACK_MODELS"`
+	EmbeddingBackend                    string   `json:"embedding_backend" env:"DOTAGENT_MEMORY_EMBEDDING_BACKEND"`
 	EmbeddingOllamaAPIBase              string   `json:"embedding_ollama_api_base" env:"DOTAGENT_MEMORY_EMBEDDING_OLLAMA_API_BASE"`
 	EmbeddingBatchSize                  int      `json:"embedding_batch_size" env:"DOTAGENT_MEMORY_EMBEDDING_BATCH_SIZE"`
 	EmbeddingConcurrency                int      `json:"embedding_concurrency" env:"DOTAGENT_MEMORY_EMBEDDING_CONCURRENCY"`
@@ -211,18 +486,62 @@ type MemoryConfig struct {
 	PersonaSyncApply                    bool     `json:"persona_sync_apply" env:"DOTAGENT_MEMORY_PERSONA_SYNC_APPLY"`
 	PersonaFileSyncMode                 string   `json:"persona_file_sync_mode" env:"DOTAGENT_MEMORY_PERSONA_FILE_SYNC_MODE"`
 	PersonaPolicyMode                   string   `json:"persona_policy_mode" env:"DOTAGENT_MEMORY_PERSONA_POLICY_MODE"`
+	PersonaPrivacyMode                  string   `json:"persona_privacy_mode" env:"DOTAGENT_MEMORY_PERSONA_PRIVACY_MODE"`
 	PersonaMinConfidence                float64  `json:"persona_min_confidence" env:"DOTAGENT_MEMORY_PERSONA_MIN_CONFIDENCE"`
 	PersonaSyncTimeoutMS                int      `json:"persona_sync_timeout_ms" env:"DOTAGENT_MEMORY_PERSONA_SYNC_TIMEOUT_MS"`
 	CompactionSummaryTimeoutSeconds     int      `json:"compaction_summary_timeout_seconds" env:"DOTAGENT_MEMORY_COMPACTION_SUMMARY_TIMEOUT_SECONDS"`
-	CompactionChunkChars                int      `json:"compaction_chunk_chars" env:"DOTAGENT_MEMORY_COMPACTION_CHUNK_CHARS"`
-	CompactionMaxTranscriptChars        int      `json:"compaction_max_transcript_chars" env:"DOTAGENT_MEMORY_COMPACTION_MAX_TRANSCRIPT_CHARS"`
-	CompactionPartialSkipChars          int      `json:"compaction_partial_skip_chars" env:"DOTAGENT_MEMORY_COMPACTION_PARTIAL_SKIP_CHARS"`
-	FileMemoryEnabled                   bool     `json:"file_memory_enabled" env:"DOTAGENT_MEMORY_FILE_MEMORY_ENABLED"`
-	FileMemoryDir                       string   `json:"file_memory_dir" env:"DOTAGENT_MEMORY_FILE_MEMORY_DIR"`
-	FileMemoryPollSeconds               int      `json:"file_memory_poll_seconds" env:"DOTAGENT_MEMORY_FILE_MEMORY_POLL_SECONDS"`
-	FileMemoryWatchEnabled              bool     `json:"file_memory_watch_enabled" env:"DOTAGENT_MEMORY_FILE_MEMORY_WATCH_ENABLED"`
-	FileMemoryWatchDebounceMS           int      `json:"file_memory_watch_debounce_ms" env:"DOTAGENT_MEMORY_FILE_MEMORY_WATCH_DEBOUNCE_MS"`
-	FileMemoryMaxFileBytes              int      `json:"file_memory_max_file_bytes" env:"DOTAGENT_MEMORY_FILE_MEMORY_MAX_FILE_BYTES"`
+	// Age after which a running compaction is recovered at startup when its
+	// process cannot be checked (another host, or a row with no owner).
+	CompactionTimeoutMinutes     int     `json:"compaction_timeout_minutes" env:"DOTAGENT_MEMORY_COMPACTION_TIMEOUT_MINUTES"`
+	CompactionChunkChars         int     `json:"compaction_chunk_chars" env:"DOTAGENT_MEMORY_COMPACTION_CHUNK_CHARS"`
+	CompactionMaxTranscriptChars int     `json:"compaction_max_transcript_chars" env:"DOTAGENT_MEMORY_COMPACTION_MAX_TRANSCRIPT_CHARS"`
+	CompactionPartialSkipChars   int     `json:"compaction_partial_skip_chars" env:"DOTAGENT_MEMORY_COMPACTION_PARTIAL_SKIP_CHARS"`
+	FileMemoryEnabled            bool    `json:"file_memory_enabled" env:"DOTAGENT_MEMORY_FILE_MEMORY_ENABLED"`
+	FileMemoryDir                string  `json:"file_memory_dir" env:"DOTAGENT_MEMORY_FILE_MEMORY_DIR"`
+	FileMemoryPollSeconds        int     `json:"file_memory_poll_seconds" env:"DOTAGENT_MEMORY_FILE_MEMORY_POLL_SECONDS"`
+	FileMemoryWatchEnabled       bool    `json:"file_memory_watch_enabled" env:"DOTAGENT_MEMORY_FILE_MEMORY_WATCH_ENABLED"`
+	FileMemoryWatchDebounceMS    int     `json:"file_memory_watch_debounce_ms" env:"DOTAGENT_MEMORY_FILE_MEMORY_WATCH_DEBOUNCE_MS"`
+	FileMemoryMaxFileBytes       int     `json:"file_memory_max_file_bytes" env:"DOTAGENT_MEMORY_FILE_MEMORY_MAX_FILE_BYTES"`
+	MaxConsolidationRate         float64 `json:"max_consolidation_rate" env:"DOTAGENT_MEMORY_MAX_CONSOLIDATION_RATE"` // messages/minute; 0 disables
+	// RestoreHistoryFromSnapshot opens the prompt history with a recap of the
+	// latest session snapshot once compaction has archived every earlier event.
+	RestoreHistoryFromSnapshot bool `json:"restore_history_from_snapshot" env:"DOTAGENT_MEMORY_RESTORE_HISTORY_FROM_SNAPSHOT"`
+	// Backend is "sqlite" (a file under the instance data dir) or "postgres",
+	// which lets several instances share one memory database.
+	Backend           string                  `json:"backend" env:"DOTAGENT_MEMORY_BACKEND"`
+	Postgres          MemoryPostgresConfig    `json:"postgres"`
+	PersonaExperiment PersonaExperimentConfig `json:"persona_experiment"`
+	// AutoRepair lets the weekly consistency check delete orphaned links,
+	// embeddings and observations instead of only reporting them.
+	AutoRepair bool `json:"auto_repair" env:"DOTAGENT_MEMORY_AUTO_REPAIR"`
+	// SessionTopicMode picks how session topic tags are derived: "keywords"
+	// (word frequency, no model calls), "llm" (a short classification call
+	// as sessions grow) or "off".
+	SessionTopicMode string `json:"session_topic_mode" env:"DOTAGENT_MEMORY_SESSION_TOPIC_MODE"`
+	// Regex patterns gating LLM persona extraction: a turn is sent to the
+	// extractor only when a user message matches one of them (heuristic
+	// extraction always runs). An empty list extracts on every turn. The env
+	// var takes one pattern per line.
+	PersonaExtractionTriggers FlexibleStringSlice `json:"persona_extraction_triggers" env:"DOTAGENT_MEMORY_PERSONA_EXTRACTION_TRIGGERS" envSeparator:"\n"`
+}
+
+// DefaultPersonaExtractionTriggers match turns where the user names
+// themselves or the agent, or states a standing preference.
+var DefaultPersonaExtractionTriggers = []string{
+	`(?i)(call me|my name|i prefer|always|from now on|you are|your name)`,
+}
+
+type MemoryPostgresConfig struct {
+	DSN string `json:"dsn" env:"DOTAGENT_MEMORY_POSTGRES_DSN"`
+}
+
+// PersonaExperimentConfig splits sessions evenly between two agent personas.
+// Each profile is a persona profile JSON file, relative paths resolving
+// against the workspace; an empty control_profile keeps the current persona.
+type PersonaExperimentConfig struct {
+	Enabled          bool   `json:"enabled" env:"DOTAGENT_MEMORY_PERSONA_EXPERIMENT_ENABLED"`
+	ControlProfile   string `json:"control_profile" env:"DOTAGENT_MEMORY_PERSONA_EXPERIMENT_CONTROL_PROFILE"`
+	TreatmentProfile string `json:"treatment_profile" env:"DOTAGENT_MEMORY_PERSONA_EXPERIMENT_TREATMENT_PROFILE"`
 }
 
 func DefaultConfig() *Config {
@@ -293,17 +612,37 @@ func DefaultConfigForInstance(instanceID string) *Config {
 				MaxTokens:                 16384,
 				Temperature:               0.7,
 				MaxToolIterations:         50,
+				MaxSubagentDepth:          3,
 				MaxConcurrentRuns:         4,
 				SessionFileLockEnabled:    true,
 				SessionLockTimeoutMS:      15000,
 				SessionLockStaleSeconds:   1800,
 				SessionLockMaxHoldSeconds: 420,
+				AutoContextFiles:          FlexibleStringSlice{},
+				MaxAutoContextTokens:      2000,
+				ResponseFilters:           append(FlexibleStringSlice(nil), DefaultResponseFilters...),
+				CronJitterSeconds:         30,
 			},
 		},
 		Channels: ChannelsConfig{
 			Discord: DiscordConfig{
 				Token:     "",
 				AllowFrom: FlexibleStringSlice{},
+				Retry: DiscordRetryConfig{
+					MaxAttempts: 3,
+					BaseDelayMS: 500,
+				},
+				MaxMessageLength: 2000,
+				SplitStrategy:    "paragraph",
+			},
+			Email: EmailConfig{
+				Mailbox:             "INBOX",
+				PollIntervalSeconds: 60,
+				AllowFrom:           FlexibleStringSlice{},
+			},
+			Matrix: MatrixConfig{
+				RoomIDs:   FlexibleStringSlice{},
+				AllowFrom: FlexibleStringSlice{},
 			},
 		},
 		Providers: ProvidersConfig{
@@ -319,10 +658,21 @@ func DefaultConfigForInstance(instanceID string) *Config {
 			Ollama: OllamaProviderConfig{
 				APIBase: "http://127.0.0.1:11434/v1",
 			},
+			LoadBalancer: LoadBalancerConfig{
+				CooldownSeconds: 60,
+			},
+			ModelCacheMinutes: 60,
 		},
 		Gateway: GatewayConfig{
 			Host: "0.0.0.0",
 			Port: 18790,
+			Admin: GatewayAdminConfig{
+				Enabled: false,
+				Host:    "127.0.0.1",
+				Port:    18791,
+			},
+			LogLevel:           "info",
+			DedupWindowSeconds: 5,
 		},
 		Tools: ToolsConfig{
 			Web: WebToolsConfig{
@@ -336,6 +686,45 @@ func DefaultConfigForInstance(instanceID string) *Config {
 					MaxResults: 5,
 				},
 			},
+			CodeRunner: CodeRunnerConfig{
+				TimeoutSeconds:   30,
+				AllowedLanguages: []string{"python", "javascript", "bash"},
+				UseSandbox:       false,
+			},
+			FileWatch: FileWatchConfig{
+				MaxWatchers: 10,
+			},
+			Archive: ArchiveConfig{
+				MaxSizeMB: 100,
+			},
+			Approval: ApprovalConfig{
+				TimeoutSeconds: 60,
+			},
+			Audit: ToolAuditConfig{
+				Enabled:       false,
+				MaxFileSizeMB: 10,
+				RetentionDays: 90,
+			},
+			SSH: SSHConfig{
+				KnownHostsPath: "~/.ssh/known_hosts",
+				AllowedHosts:   FlexibleStringSlice{},
+				TimeoutSeconds: 60,
+			},
+			Kubernetes: KubernetesConfig{
+				KubeconfigPath:    "~/.kube/config",
+				AllowedNamespaces: FlexibleStringSlice{"default"},
+			},
+			Voice: VoiceConfig{
+				APIBase:   "https://api.openai.com/v1",
+				Model:     "whisper-1",
+				MaxFileMB: 25,
+			},
+			Jira: JiraConfig{
+				IssueType: "Task",
+			},
+			PDF: PDFConfig{
+				MaxChars: 50000,
+			},
 		},
 		Memory: MemoryConfig{
 			MaxRecallItems:                      8,
@@ -368,9 +757,11 @@ func DefaultConfigForInstance(instanceID string) *Config {
 			PersonaSyncApply:                    true,
 			PersonaFileSyncMode:                 "export_only",
 			PersonaPolicyMode:                   "balanced",
+			PersonaPrivacyMode:                  "off",
 			PersonaMinConfidence:                0.52,
 			PersonaSyncTimeoutMS:                2200,
 			CompactionSummaryTimeoutSeconds:     60,
+			CompactionTimeoutMinutes:            30,
 			CompactionChunkChars:                9000,
 			CompactionMaxTranscriptChars:        48000,
 			CompactionPartialSkipChars:          2600,
@@ -380,10 +771,25 @@ func DefaultConfigForInstance(instanceID string) *Config {
 			FileMemoryWatchEnabled:              true,
 			FileMemoryWatchDebounceMS:           1200,
 			FileMemoryMaxFileBytes:              262144,
+			MaxConsolidationRate:                3,
+			RestoreHistoryFromSnapshot:          true,
+			Backend:                             "sqlite",
+			SessionTopicMode:                    "keywords",
+			PersonaExtractionTriggers:           append(FlexibleStringSlice(nil), DefaultPersonaExtractionTriggers...),
 		},
 		Heartbeat: HeartbeatConfig{
-			Enabled:  true,
-			Interval: 30, // default 30 minutes
+			Enabled:         true,
+			Interval:        30, // default 30 minutes
+			FallbackChannel: "cli:direct",
+		},
+		Observability: ObservabilityConfig{
+			Enabled: false,
+			OTLP: OTLPExportConfig{
+				Endpoint: "http://localhost:4318",
+			},
+		},
+		Toolpacks: ToolpacksConfig{
+			SchemaCacheMinutes: 60,
 		},
 	}
 }
@@ -400,13 +806,17 @@ func LoadConfig(path string) (*Config, error) {
 		if !os.IsNotExist(err) {
 			return nil, err
 		}
+	} else if IsTOMLPath(path) {
+		if err := decodeTOMLConfig(data, cfg); err != nil {
+			return nil, err
+		}
 	} else {
 		if err := json.Unmarshal(data, cfg); err != nil {
 			return nil, err
 		}
 	}
 
-	if err := env.Parse(cfg); err != nil {
+	if err := ApplyEnv(cfg); err != nil {
 		return nil, err
 	}
 	if err := cfg.Validate(); err != nil {
@@ -416,11 +826,52 @@ func LoadConfig(path string) (*Config, error) {
 	return cfg, nil
 }
 
+// ApplyEnv overrides cfg fields whose DOTAGENT_* environment variables are
+// set.
+func ApplyEnv(cfg *Config) error {
+	return env.Parse(cfg)
+}
+
+// secretEnvSuffixes mark the DOTAGENT_* variables that carry credentials.
+var secretEnvSuffixes = []string{"_API_KEY", "_API_KEYS", "_TOKEN", "_PASSWORD", "_SECRET", "_DSN"}
+
+// IsSecretEnvVar reports whether the environment variable name carries a
+// credential (API key, token, password or connection string).
+func IsSecretEnvVar(name string) bool {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	for _, suffix := range secretEnvSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyEnvExceptSecrets is ApplyEnv without the credential variables, for
+// configs written to disk; LoadConfig still applies those at load time.
+func ApplyEnvExceptSecrets(cfg *Config) error {
+	environ := map[string]string{}
+	for _, kv := range os.Environ() {
+		if name, value, ok := strings.Cut(kv, "="); ok && !IsSecretEnvVar(name) {
+			environ[name] = value
+		}
+	}
+	return env.ParseWithOptions(cfg, env.Options{Environment: environ})
+}
+
 func SaveConfig(path string, cfg *Config) error {
 	cfg.mu.RLock()
 	defer cfg.mu.RUnlock()
 
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	var (
+		data []byte
+		err  error
+	)
+	if IsTOMLPath(path) {
+		data, err = encodeTOMLConfig(cfg)
+	} else {
+		data, err = json.MarshalIndent(cfg, "", "  ")
+	}
 	if err != nil {
 		return err
 	}
@@ -512,6 +963,11 @@ func (c *Config) Validate() error {
 			addErr("%s must be > 0 (got %d)", name, value)
 		}
 	}
+	nonNegativeInt := func(name string, value int) {
+		if value < 0 {
+			addErr("%s must be >= 0 (got %d)", name, value)
+		}
+	}
 	validateThresholdPair := func(warnName string, warn int, criticalName string, critical int) {
 		positiveInt(warnName, warn)
 		positiveInt(criticalName, critical)
@@ -554,7 +1010,19 @@ func (c *Config) Validate() error {
 	}
 	positiveInt("agents.defaults.max_tokens", c.Agents.Defaults.MaxTokens)
 	positiveInt("agents.defaults.max_tool_iterations", c.Agents.Defaults.MaxToolIterations)
+	positiveInt("agents.defaults.max_subagent_depth", c.Agents.Defaults.MaxSubagentDepth)
 	positiveInt("agents.defaults.max_concurrent_runs", c.Agents.Defaults.MaxConcurrentRuns)
+	nonNegativeInt("agents.defaults.max_system_tokens", c.Agents.Defaults.MaxSystemTokens)
+	nonNegativeInt("agents.defaults.max_recall_tokens", c.Agents.Defaults.MaxRecallTokens)
+	nonNegativeInt("agents.defaults.max_history_tokens", c.Agents.Defaults.MaxHistoryTokens)
+	nonNegativeInt("agents.defaults.max_skill_tokens", c.Agents.Defaults.MaxSkillTokens)
+	nonNegativeInt("agents.defaults.max_auto_context_tokens", c.Agents.Defaults.MaxAutoContextTokens)
+	inRangeInt("agents.defaults.cron_jitter_seconds", c.Agents.Defaults.CronJitterSeconds, 0, 3600)
+	for i, pattern := range c.Agents.Defaults.ResponseFilters {
+		if _, err := regexp.Compile(pattern); err != nil {
+			addErr("agents.defaults.response_filters[%d] is not a valid regex: %v", i, err)
+		}
+	}
 	if c.Agents.Defaults.Temperature < 0 || c.Agents.Defaults.Temperature > 2 {
 		addErr("agents.defaults.temperature must be between 0 and 2 (got %.3f)", c.Agents.Defaults.Temperature)
 	}
@@ -572,13 +1040,140 @@ func (c *Config) Validate() error {
 	if strings.TrimSpace(c.Gateway.Host) == "" {
 		addErr("gateway.host is required")
 	}
+	if c.Gateway.Admin.Enabled {
+		inRangeInt("gateway.admin.port", c.Gateway.Admin.Port, 1, 65535)
+		if c.Gateway.Admin.Port == c.Gateway.Port {
+			addErr("gateway.admin.port must differ from gateway.port (%d)", c.Gateway.Port)
+		}
+		if strings.TrimSpace(c.Gateway.Admin.Token) == "" {
+			addErr("gateway.admin.token is required when gateway.admin.enabled is true")
+		}
+		certFile, keyFile := strings.TrimSpace(c.Gateway.Admin.TLSCertFile), strings.TrimSpace(c.Gateway.Admin.TLSKeyFile)
+		switch {
+		case strings.TrimSpace(c.Gateway.Admin.Host) == "":
+			addErr("gateway.admin.host is required when gateway.admin.enabled is true")
+		case (certFile == "") != (keyFile == ""):
+			addErr("gateway.admin.tls_cert_file and gateway.admin.tls_key_file must be set together")
+		case certFile == "" && !IsLoopbackHost(c.Gateway.Admin.Host):
+			addErr("gateway.admin.host %q is not a loopback address; set gateway.admin.tls_cert_file and gateway.admin.tls_key_file to serve the admin API over TLS", c.Gateway.Admin.Host)
+		}
+	}
+	inRangeInt("gateway.dedup_window_seconds", c.Gateway.DedupWindowSeconds, 0, 3600)
+	switch strings.ToLower(strings.TrimSpace(c.Gateway.LogLevel)) {
+	case "", "debug", "info", "warn", "warning", "error":
+	default:
+		addErr("gateway.log_level must be one of debug|info|warn|error (got %q)", c.Gateway.LogLevel)
+	}
+	for i, pattern := range c.Gateway.ContentFilter.BlocklistPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			addErr("gateway.content_filter.blocklist_patterns[%d] is not a valid regex: %v", i, err)
+		}
+	}
+
+	inRangeInt("channels.discord.retry.max_attempts", c.Channels.Discord.Retry.MaxAttempts, 1, 10)
+	inRangeInt("channels.discord.retry.base_delay_ms", c.Channels.Discord.Retry.BaseDelayMS, 0, 60000)
+	inRangeInt("channels.discord.max_message_length", c.Channels.Discord.MaxMessageLength, 100, 2000)
+	switch strings.ToLower(strings.TrimSpace(c.Channels.Discord.SplitStrategy)) {
+	case "paragraph", "sentence", "word":
+	default:
+		addErr("channels.discord.split_strategy must be one of: paragraph, sentence, word (got %q)", c.Channels.Discord.SplitStrategy)
+	}
+	if c.Channels.Email.Enabled {
+		required := []struct{ name, value string }{
+			{"channels.email.imap_host", c.Channels.Email.IMAPHost},
+			{"channels.email.imap_user", c.Channels.Email.IMAPUser},
+			{"channels.email.imap_password", c.Channels.Email.IMAPPassword},
+			{"channels.email.smtp_host", c.Channels.Email.SMTPHost},
+		}
+		for _, field := range required {
+			if strings.TrimSpace(field.value) == "" {
+				addErr("%s is required when channels.email.enabled is true", field.name)
+			}
+		}
+		inRangeInt("channels.email.poll_interval_seconds", c.Channels.Email.PollIntervalSeconds, 10, 24*60*60)
+	}
+
+	if c.Channels.Matrix.Enabled {
+		if strings.TrimSpace(c.Channels.Matrix.HomeserverURL) == "" {
+			addErr("channels.matrix.homeserver_url is required when channels.matrix.enabled is true")
+		}
+		if strings.TrimSpace(c.Channels.Matrix.AccessToken) == "" {
+			addErr("channels.matrix.access_token is required when channels.matrix.enabled is true")
+		}
+		if len(c.Channels.Matrix.RoomIDs) == 0 {
+			addErr("channels.matrix.room_ids must list at least one room when channels.matrix.enabled is true")
+		}
+	}
 
 	if c.Heartbeat.Enabled {
 		inRangeInt("heartbeat.interval", c.Heartbeat.Interval, 5, 24*60)
+		if fallback := strings.TrimSpace(c.Heartbeat.FallbackChannel); fallback != "" {
+			if parts := strings.SplitN(fallback, ":", 2); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				addErr("heartbeat.fallback_channel must be of the form channel:chat_id, got %q", fallback)
+			}
+		}
+	}
+
+	if c.Observability.Enabled {
+		endpoint := strings.TrimSpace(c.Observability.OTLP.Endpoint)
+		if u, err := url.Parse(endpoint); endpoint == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			addErr("observability.otlp.endpoint must be an http(s) URL when observability.enabled is true")
+		}
 	}
 
+	inRangeInt("providers.load_balancer.cooldown_seconds", c.Providers.LoadBalancer.CooldownSeconds, 1, 3600)
+	inRangeInt("providers.model_cache_minutes", c.Providers.ModelCacheMinutes, 0, 10080)
+	inRangeInt("toolpacks.schema_cache_minutes", c.Toolpacks.SchemaCacheMinutes, 0, 10080)
+
 	positiveInt("tools.web.brave.max_results", c.Tools.Web.Brave.MaxResults)
 	positiveInt("tools.web.duckduckgo.max_results", c.Tools.Web.DuckDuckGo.MaxResults)
+	inRangeInt("tools.code_runner.timeout_seconds", c.Tools.CodeRunner.TimeoutSeconds, 1, 600)
+	inRangeInt("tools.file_watch.max_watchers", c.Tools.FileWatch.MaxWatchers, 1, 100)
+	positiveInt("tools.archive.max_size_mb", c.Tools.Archive.MaxSizeMB)
+	inRangeInt("tools.approval.timeout_seconds", c.Tools.Approval.TimeoutSeconds, 1, 3600)
+	if c.Tools.Audit.Enabled {
+		positiveInt("tools.audit.max_file_size_mb", c.Tools.Audit.MaxFileSizeMB)
+	}
+	nonNegativeInt("tools.audit.retention_days", c.Tools.Audit.RetentionDays)
+	if c.Tools.SSH.Enabled {
+		inRangeInt("tools.ssh.timeout_seconds", c.Tools.SSH.TimeoutSeconds, 1, 3600)
+		if strings.TrimSpace(c.Tools.SSH.KeyPath) == "" {
+			addErr("tools.ssh.key_path is required when tools.ssh.enabled is true")
+		}
+		if len(c.Tools.SSH.AllowedHosts) == 0 {
+			addErr("tools.ssh.allowed_hosts must list at least one host when tools.ssh.enabled is true")
+		}
+	}
+	if c.Tools.Kubernetes.Enabled && !c.Tools.Kubernetes.InCluster && strings.TrimSpace(c.Tools.Kubernetes.KubeconfigPath) == "" {
+		addErr("tools.kubernetes.kubeconfig_path is required when tools.kubernetes.enabled is true and in_cluster is false")
+	}
+	inRangeInt("tools.voice.max_file_mb", c.Tools.Voice.MaxFileMB, 1, 500)
+	positiveInt("tools.pdf.max_chars", c.Tools.PDF.MaxChars)
+	switch strings.ToLower(strings.TrimSpace(c.Tools.IssueTracker.Backend)) {
+	case "":
+	case "jira":
+		if u, err := url.Parse(strings.TrimSpace(c.Tools.Jira.BaseURL)); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			addErr("tools.jira.base_url must be an http(s) URL when tools.issue_tracker.backend is jira")
+		}
+		for _, field := range [][2]string{
+			{"tools.jira.user", c.Tools.Jira.User},
+			{"tools.jira.api_token", c.Tools.Jira.APIToken},
+			{"tools.jira.project_key", c.Tools.Jira.ProjectKey},
+		} {
+			if strings.TrimSpace(field[1]) == "" {
+				addErr("%s is required when tools.issue_tracker.backend is jira", field[0])
+			}
+		}
+	default:
+		addErr("tools.issue_tracker.backend must be jira or empty (got %q)", c.Tools.IssueTracker.Backend)
+	}
+	for _, lang := range c.Tools.CodeRunner.AllowedLanguages {
+		switch strings.ToLower(strings.TrimSpace(lang)) {
+		case "python", "javascript", "bash":
+		default:
+			addErr("tools.code_runner.allowed_languages contains unsupported language %q (supported: python, javascript, bash)", lang)
+		}
+	}
 
 	positiveInt("memory.max_recall_items", c.Memory.MaxRecallItems)
 	positiveInt("memory.candidate_limit", c.Memory.CandidateLimit)
@@ -590,6 +1185,11 @@ func (c *Config) Validate() error {
 	positiveInt("memory.worker_lease_seconds", c.Memory.WorkerLeaseSeconds)
 	positiveInt("memory.embedding_batch_size", c.Memory.EmbeddingBatchSize)
 	positiveInt("memory.embedding_concurrency", c.Memory.EmbeddingConcurrency)
+	switch strings.ToLower(strings.TrimSpace(c.Memory.EmbeddingBackend)) {
+	case "", "bow", "openai", "openrouter", "ollama":
+	default:
+		addErr("memory.embedding_backend must be one of bow|openai|openrouter|ollama (got %q)", c.Memory.EmbeddingBackend)
+	}
 	validateThresholdPair(
 		"memory.tool_loop_signature_warn_threshold", c.Memory.ToolLoopSignatureWarnThreshold,
 		"memory.tool_loop_signature_critical_threshold", c.Memory.ToolLoopSignatureCriticalThreshold,
@@ -631,6 +1231,7 @@ func (c *Config) Validate() error {
 	positiveInt("memory.event_retention_days", c.Memory.EventRetentionDays)
 	positiveInt("memory.audit_retention_days", c.Memory.AuditRetentionDays)
 	positiveInt("memory.compaction_summary_timeout_seconds", c.Memory.CompactionSummaryTimeoutSeconds)
+	positiveInt("memory.compaction_timeout_minutes", c.Memory.CompactionTimeoutMinutes)
 	positiveInt("memory.compaction_chunk_chars", c.Memory.CompactionChunkChars)
 	positiveInt("memory.compaction_max_transcript_chars", c.Memory.CompactionMaxTranscriptChars)
 	positiveInt("memory.compaction_partial_skip_chars", c.Memory.CompactionPartialSkipChars)
@@ -656,10 +1257,40 @@ func (c *Config) Validate() error {
 	default:
 		addErr("memory.persona_file_sync_mode must be one of export_only|import_export|disabled (got %q)", c.Memory.PersonaFileSyncMode)
 	}
+	switch strings.ToLower(strings.TrimSpace(c.Memory.SessionTopicMode)) {
+	case "", "keywords", "llm", "off":
+	default:
+		addErr("memory.session_topic_mode must be one of keywords|llm|off (got %q)", c.Memory.SessionTopicMode)
+	}
+	switch strings.ToLower(strings.TrimSpace(c.Memory.PersonaPrivacyMode)) {
+	case "", "off", "scrub":
+	default:
+		addErr("memory.persona_privacy_mode must be one of off|scrub (got %q)", c.Memory.PersonaPrivacyMode)
+	}
+	for i, pattern := range c.Memory.PersonaExtractionTriggers {
+		if _, err := regexp.Compile(pattern); err != nil {
+			addErr("memory.persona_extraction_triggers[%d] is not a valid regex: %v", i, err)
+		}
+	}
 
 	positiveInt("memory.file_memory_poll_seconds", c.Memory.FileMemoryPollSeconds)
 	positiveInt("memory.file_memory_watch_debounce_ms", c.Memory.FileMemoryWatchDebounceMS)
 	positiveInt("memory.file_memory_max_file_bytes", c.Memory.FileMemoryMaxFileBytes)
+	if c.Memory.MaxConsolidationRate < 0 {
+		addErr("memory.max_consolidation_rate must be >= 0 (got %.2f)", c.Memory.MaxConsolidationRate)
+	}
+	switch strings.ToLower(strings.TrimSpace(c.Memory.Backend)) {
+	case "", "sqlite":
+	case "postgres":
+		if strings.TrimSpace(c.Memory.Postgres.DSN) == "" {
+			addErr("memory.postgres.dsn is required when memory.backend is postgres")
+		}
+	default:
+		addErr("memory.backend must be one of sqlite|postgres (got %q)", c.Memory.Backend)
+	}
+	if c.Memory.PersonaExperiment.Enabled && strings.TrimSpace(c.Memory.PersonaExperiment.TreatmentProfile) == "" {
+		addErr("memory.persona_experiment.treatment_profile is required when memory.persona_experiment.enabled is true")
+	}
 
 	if len(errs) > 0 {
 		return fmt.Errorf("invalid configuration: %s", strings.Join(errs, "; "))
@@ -68,6 +68,53 @@ func TestDefaultConfig_Temperature(t *testing.T) {
 }
 
 // TestDefaultConfig_Gateway verifies gateway defaults
+func TestDefaultConfig_ResponseFilters(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if len(cfg.Agents.Defaults.ResponseFilters) != len(DefaultResponseFilters) {
+		t.Fatalf("expected default response filters, got %v", cfg.Agents.Defaults.ResponseFilters)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected default filters to validate, got %v", err)
+	}
+
+	cfg.Agents.Defaults.ResponseFilters = FlexibleStringSlice{`(?i)^sure`, `(unclosed`}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "agents.defaults.response_filters[1]") {
+		t.Fatalf("expected invalid regex error, got %v", err)
+	}
+}
+
+func TestDefaultConfig_PersonaExtractionTriggers(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if len(cfg.Memory.PersonaExtractionTriggers) != len(DefaultPersonaExtractionTriggers) {
+		t.Fatalf("expected default persona extraction triggers, got %v", cfg.Memory.PersonaExtractionTriggers)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected default triggers to validate, got %v", err)
+	}
+
+	cfg.Memory.PersonaExtractionTriggers = FlexibleStringSlice{`(?i)my name`, `(unclosed`}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "memory.persona_extraction_triggers[1]") {
+		t.Fatalf("expected invalid regex error, got %v", err)
+	}
+}
+
+func TestDefaultConfig_ContentFilter(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if len(cfg.Gateway.ContentFilter.BlocklistPatterns) != 0 {
+		t.Fatalf("expected no blocklist patterns by default, got %v", cfg.Gateway.ContentFilter.BlocklistPatterns)
+	}
+	cfg.Gateway.ContentFilter.BlocklistPatterns = FlexibleStringSlice{`(?i)\bexploit\b`, `[unclosed`}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "gateway.content_filter.blocklist_patterns[1]") {
+		t.Fatalf("expected invalid regex error, got %v", err)
+	}
+}
+
 func TestDefaultConfig_Gateway(t *testing.T) {
 	cfg := DefaultConfig()
 
@@ -77,6 +124,28 @@ func TestDefaultConfig_Gateway(t *testing.T) {
 	if cfg.Gateway.Port == 0 {
 		t.Error("Gateway port should have default value")
 	}
+	if cfg.Gateway.LogLevel != "info" {
+		t.Errorf("Gateway log level should default to info, got %q", cfg.Gateway.LogLevel)
+	}
+	cfg.Gateway.LogLevel = "verbose"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "gateway.log_level") {
+		t.Errorf("expected invalid log level error, got %v", err)
+	}
+}
+
+func TestDefaultConfig_GatewayDedupWindow(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Gateway.DedupWindowSeconds != 5 {
+		t.Errorf("Gateway dedup window should default to 5 seconds, got %d", cfg.Gateway.DedupWindowSeconds)
+	}
+	cfg.Gateway.DedupWindowSeconds = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("a zero dedup window should disable deduplication, got %v", err)
+	}
+	cfg.Gateway.DedupWindowSeconds = -1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "gateway.dedup_window_seconds") {
+		t.Errorf("expected invalid dedup window error, got %v", err)
+	}
 }
 
 // TestDefaultConfig_Providers verifies provider structure
@@ -132,6 +201,510 @@ func TestDefaultConfig_WebTools(t *testing.T) {
 	}
 }
 
+// TestDefaultConfig_CodeRunner verifies code runner defaults and validation
+func TestDefaultConfig_CodeRunner(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Tools.CodeRunner.TimeoutSeconds != 30 {
+		t.Error("Expected code runner timeout 30, got ", cfg.Tools.CodeRunner.TimeoutSeconds)
+	}
+	if cfg.Tools.CodeRunner.UseSandbox {
+		t.Error("Code runner sandbox should be disabled by default")
+	}
+	if len(cfg.Tools.CodeRunner.AllowedLanguages) != 3 {
+		t.Errorf("Expected 3 allowed languages, got %v", cfg.Tools.CodeRunner.AllowedLanguages)
+	}
+
+	cfg.Tools.CodeRunner.AllowedLanguages = []string{"python", "ruby"}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "tools.code_runner.allowed_languages") {
+		t.Fatalf("expected allowed_languages validation error, got %v", err)
+	}
+}
+
+func TestDefaultConfig_FileWatch(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Tools.FileWatch.MaxWatchers != 10 {
+		t.Error("Expected file watch max_watchers 10, got ", cfg.Tools.FileWatch.MaxWatchers)
+	}
+
+	cfg.Tools.FileWatch.MaxWatchers = 0
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "tools.file_watch.max_watchers") {
+		t.Fatalf("expected max_watchers validation error, got %v", err)
+	}
+}
+
+func TestDefaultConfig_Archive(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Tools.Archive.MaxSizeMB != 100 {
+		t.Error("Expected archive max_size_mb 100, got ", cfg.Tools.Archive.MaxSizeMB)
+	}
+
+	cfg.Tools.Archive.MaxSizeMB = 0
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "tools.archive.max_size_mb") {
+		t.Fatalf("expected max_size_mb validation error, got %v", err)
+	}
+}
+
+func TestDefaultConfig_DiscordRetry(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Channels.Discord.Retry.MaxAttempts != 3 || cfg.Channels.Discord.Retry.BaseDelayMS != 500 {
+		t.Fatalf("unexpected discord retry defaults: %+v", cfg.Channels.Discord.Retry)
+	}
+
+	cfg.Channels.Discord.Retry.MaxAttempts = 0
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "channels.discord.retry.max_attempts") {
+		t.Fatalf("expected max_attempts validation error, got %v", err)
+	}
+}
+
+func TestDefaultConfig_DiscordSplitting(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Channels.Discord.MaxMessageLength != 2000 || cfg.Channels.Discord.SplitStrategy != "paragraph" {
+		t.Fatalf("unexpected discord splitting defaults: %d %q", cfg.Channels.Discord.MaxMessageLength, cfg.Channels.Discord.SplitStrategy)
+	}
+
+	cfg.Channels.Discord.MaxMessageLength = 4000
+	cfg.Channels.Discord.SplitStrategy = "line"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "channels.discord.max_message_length") || !strings.Contains(err.Error(), "channels.discord.split_strategy") {
+		t.Fatalf("expected max_message_length and split_strategy validation errors, got %v", err)
+	}
+}
+
+func TestDefaultConfig_PDF(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Tools.PDF.MaxChars != 50000 {
+		t.Error("Expected pdf max_chars 50000, got ", cfg.Tools.PDF.MaxChars)
+	}
+
+	cfg.Tools.PDF.MaxChars = 0
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "tools.pdf.max_chars") {
+		t.Fatalf("expected max_chars validation error, got %v", err)
+	}
+}
+
+func TestDefaultConfig_MaxSubagentDepth(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Agents.Defaults.MaxSubagentDepth != 3 {
+		t.Error("Expected max_subagent_depth 3, got ", cfg.Agents.Defaults.MaxSubagentDepth)
+	}
+
+	cfg.Agents.Defaults.MaxSubagentDepth = 0
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "agents.defaults.max_subagent_depth") {
+		t.Fatalf("expected max_subagent_depth validation error, got %v", err)
+	}
+}
+
+func TestDefaultConfig_RestoreHistoryFromSnapshot(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if !cfg.Memory.RestoreHistoryFromSnapshot {
+		t.Error("Expected memory restore_history_from_snapshot to default to true")
+	}
+}
+
+func TestDefaultConfig_SessionTopicMode(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Memory.SessionTopicMode != "keywords" {
+		t.Errorf("Expected memory session_topic_mode keywords, got %q", cfg.Memory.SessionTopicMode)
+	}
+
+	cfg.Memory.SessionTopicMode = "magic"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "memory.session_topic_mode") {
+		t.Fatalf("expected session_topic_mode validation error, got %v", err)
+	}
+}
+
+func TestDefaultConfig_Approval(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Tools.Approval.TimeoutSeconds != 60 {
+		t.Error("Expected approval timeout 60, got ", cfg.Tools.Approval.TimeoutSeconds)
+	}
+
+	cfg.Tools.Approval.TimeoutSeconds = 0
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "tools.approval.timeout_seconds") {
+		t.Fatalf("expected approval timeout validation error, got %v", err)
+	}
+}
+
+func TestDefaultConfig_ToolAudit(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Tools.Audit.Enabled {
+		t.Error("Tool audit log should be disabled by default")
+	}
+	if cfg.Tools.Audit.MaxFileSizeMB != 10 {
+		t.Error("Expected audit max_file_size_mb 10, got ", cfg.Tools.Audit.MaxFileSizeMB)
+	}
+	if cfg.Tools.Audit.RetentionDays != 90 {
+		t.Error("Expected audit retention_days 90, got ", cfg.Tools.Audit.RetentionDays)
+	}
+
+	cfg.Tools.Audit.Enabled = true
+	cfg.Tools.Audit.MaxFileSizeMB = 0
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "tools.audit.max_file_size_mb") {
+		t.Fatalf("expected audit max_file_size_mb validation error, got %v", err)
+	}
+}
+
+func TestDefaultConfig_PersonaPrivacyMode(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Memory.PersonaPrivacyMode != "off" {
+		t.Error("Expected persona privacy mode off, got ", cfg.Memory.PersonaPrivacyMode)
+	}
+
+	cfg.Memory.PersonaPrivacyMode = "hide"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "memory.persona_privacy_mode") {
+		t.Fatalf("expected persona privacy mode validation error, got %v", err)
+	}
+}
+
+func TestDefaultConfig_AutoContext(t *testing.T) {
+	cfg := DefaultConfig()
+	if len(cfg.Agents.Defaults.AutoContextFiles) != 0 {
+		t.Fatalf("expected no auto context files by default, got %v", cfg.Agents.Defaults.AutoContextFiles)
+	}
+	if cfg.Agents.Defaults.MaxAutoContextTokens != 2000 {
+		t.Fatalf("expected default max_auto_context_tokens 2000, got %d", cfg.Agents.Defaults.MaxAutoContextTokens)
+	}
+	cfg.Agents.Defaults.MaxAutoContextTokens = -1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "max_auto_context_tokens") {
+		t.Fatalf("expected validation error for negative max_auto_context_tokens, got %v", err)
+	}
+}
+
+func TestDefaultConfig_CronJitter(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Agents.Defaults.CronJitterSeconds != 30 {
+		t.Fatalf("expected default cron_jitter_seconds 30, got %d", cfg.Agents.Defaults.CronJitterSeconds)
+	}
+	cfg.Agents.Defaults.CronJitterSeconds = -1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "cron_jitter_seconds") {
+		t.Fatalf("expected validation error for negative cron_jitter_seconds, got %v", err)
+	}
+}
+
+func TestDefaultConfig_Observability(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Observability.Enabled {
+		t.Fatal("expected observability disabled by default")
+	}
+	if cfg.Observability.OTLP.Endpoint != "http://localhost:4318" {
+		t.Fatalf("unexpected default OTLP endpoint %q", cfg.Observability.OTLP.Endpoint)
+	}
+	cfg.Observability.Enabled = true
+	cfg.Observability.OTLP.Endpoint = "localhost:4318"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "observability.otlp.endpoint") {
+		t.Fatalf("expected validation error for endpoint without scheme, got %v", err)
+	}
+}
+
+func TestDefaultConfig_LoadBalancer(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Providers.LoadBalancer.CooldownSeconds != 60 {
+		t.Fatalf("expected load balancer cooldown 60, got %d", cfg.Providers.LoadBalancer.CooldownSeconds)
+	}
+	cfg.Providers.LoadBalancer.CooldownSeconds = 0
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "providers.load_balancer.cooldown_seconds") {
+		t.Fatalf("expected validation error for zero cooldown, got %v", err)
+	}
+}
+
+func TestDefaultConfig_ModelCacheMinutes(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Providers.ModelCacheMinutes != 60 {
+		t.Fatalf("expected model cache of 60 minutes, got %d", cfg.Providers.ModelCacheMinutes)
+	}
+	cfg.Providers.ModelCacheMinutes = 0
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected a zero model cache to be valid, got %v", err)
+	}
+	cfg.Providers.ModelCacheMinutes = -1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "providers.model_cache_minutes") {
+		t.Fatalf("expected validation error for negative model cache, got %v", err)
+	}
+}
+
+func TestDefaultConfig_ToolpacksSchemaCache(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Toolpacks.SchemaCacheMinutes != 60 {
+		t.Fatalf("expected schema cache of 60 minutes, got %d", cfg.Toolpacks.SchemaCacheMinutes)
+	}
+	cfg.Toolpacks.SchemaCacheMinutes = -1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "toolpacks.schema_cache_minutes") {
+		t.Fatalf("expected validation error for negative schema cache, got %v", err)
+	}
+}
+
+func TestDefaultConfig_VoiceTool(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Tools.Voice.Model != "whisper-1" || cfg.Tools.Voice.MaxFileMB != 25 {
+		t.Fatalf("unexpected voice defaults: %+v", cfg.Tools.Voice)
+	}
+	if cfg.Tools.Voice.APIKey != "" || cfg.Tools.Voice.WhisperBinary != "" {
+		t.Fatal("expected voice tool unconfigured by default")
+	}
+	cfg.Tools.Voice.MaxFileMB = 0
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "tools.voice.max_file_mb") {
+		t.Fatalf("expected validation error for zero max_file_mb, got %v", err)
+	}
+}
+
+func TestDefaultConfig_IssueTracker(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Tools.IssueTracker.Backend != "" || cfg.Tools.Jira.IssueType != "Task" {
+		t.Fatalf("unexpected issue tracker defaults: %+v %+v", cfg.Tools.IssueTracker, cfg.Tools.Jira)
+	}
+	cfg.Tools.IssueTracker.Backend = "jira"
+	cfg.Tools.Jira.BaseURL = "example.atlassian.net"
+	err := cfg.Validate()
+	for _, want := range []string{"tools.jira.base_url", "tools.jira.api_token", "tools.jira.project_key"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected validation error mentioning %s, got %v", want, err)
+		}
+	}
+	cfg.Tools.IssueTracker.Backend = "trello"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "tools.issue_tracker.backend") {
+		t.Fatalf("expected unknown backend error, got %v", err)
+	}
+}
+
+func TestDefaultConfig_HeartbeatFallbackChannel(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Heartbeat.FallbackChannel != "cli:direct" {
+		t.Fatalf("unexpected heartbeat fallback channel %q", cfg.Heartbeat.FallbackChannel)
+	}
+	cfg.Heartbeat.FallbackChannel = "telegram"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "heartbeat.fallback_channel") {
+		t.Fatalf("expected validation error for malformed fallback channel, got %v", err)
+	}
+}
+
+func TestDefaultConfig_MaxConsolidationRate(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Memory.MaxConsolidationRate != 3 {
+		t.Fatalf("unexpected max_consolidation_rate default %v", cfg.Memory.MaxConsolidationRate)
+	}
+	cfg.Memory.MaxConsolidationRate = -1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "memory.max_consolidation_rate") {
+		t.Fatalf("expected validation error for negative rate, got %v", err)
+	}
+}
+
+func TestDefaultConfig_MemoryBackend(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Memory.Backend != "sqlite" {
+		t.Fatalf("unexpected memory backend default %q", cfg.Memory.Backend)
+	}
+	cfg.Memory.Backend = "postgres"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "memory.postgres.dsn") {
+		t.Fatalf("expected validation error for missing postgres dsn, got %v", err)
+	}
+	cfg.Memory.Postgres.DSN = "postgres://dotagent@localhost/dotagent"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected postgres backend with dsn to validate, got %v", err)
+	}
+	cfg.Memory.Backend = "mysql"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "memory.backend") {
+		t.Fatalf("expected validation error for unknown backend, got %v", err)
+	}
+}
+
+func TestDefaultConfig_EmbeddingBackend(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Memory.EmbeddingBackend != "" {
+		t.Fatalf("expected embedding backend to follow the model chain by default, got %q", cfg.Memory.EmbeddingBackend)
+	}
+	cfg.Memory.EmbeddingBackend = "ollama"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected ollama embedding backend to validate, got %v", err)
+	}
+	cfg.Memory.EmbeddingBackend = "cohere"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "memory.embedding_backend") {
+		t.Fatalf("expected validation error for unknown embedding backend, got %v", err)
+	}
+}
+
+func TestDefaultConfig_PersonaExperiment(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Memory.PersonaExperiment.Enabled {
+		t.Fatalf("expected persona experiment to be disabled by default")
+	}
+	cfg.Memory.PersonaExperiment.Enabled = true
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "memory.persona_experiment.treatment_profile") {
+		t.Fatalf("expected validation error for missing treatment profile, got %v", err)
+	}
+	cfg.Memory.PersonaExperiment.TreatmentProfile = "personas/playful.json"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected experiment with a treatment profile to validate, got %v", err)
+	}
+}
+
+func TestDefaultConfig_GatewayAdmin(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Gateway.Admin.Enabled || cfg.Gateway.Admin.Port != 18791 {
+		t.Fatalf("unexpected gateway admin defaults %+v", cfg.Gateway.Admin)
+	}
+	cfg.Gateway.Admin.Enabled = true
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "gateway.admin.token") {
+		t.Fatalf("expected validation error for missing admin token, got %v", err)
+	}
+	cfg.Gateway.Admin.Token = "secret"
+	cfg.Gateway.Admin.Port = cfg.Gateway.Port
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "gateway.admin.port") {
+		t.Fatalf("expected validation error for shared admin port, got %v", err)
+	}
+	cfg.Gateway.Admin.Port = 18791
+	if cfg.Gateway.Admin.Host != "127.0.0.1" {
+		t.Fatalf("expected admin API to default to loopback, got %q", cfg.Gateway.Admin.Host)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected loopback admin API to validate, got %v", err)
+	}
+	cfg.Gateway.Admin.Host = "0.0.0.0"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "gateway.admin.tls_cert_file") {
+		t.Fatalf("expected validation error for a non-loopback admin API without TLS, got %v", err)
+	}
+	cfg.Gateway.Admin.TLSCertFile = "/etc/dotagent/admin.crt"
+	cfg.Gateway.Admin.TLSKeyFile = "/etc/dotagent/admin.key"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected non-loopback admin API with TLS to validate, got %v", err)
+	}
+}
+
+func TestDefaultConfig_SSHTool(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Tools.SSH.Enabled {
+		t.Error("SSH tool should be disabled by default")
+	}
+	if cfg.Tools.SSH.TimeoutSeconds != 60 {
+		t.Error("Expected ssh timeout 60, got ", cfg.Tools.SSH.TimeoutSeconds)
+	}
+
+	cfg.Tools.SSH.Enabled = true
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "tools.ssh.key_path") || !strings.Contains(err.Error(), "tools.ssh.allowed_hosts") {
+		t.Fatalf("expected ssh validation errors, got %v", err)
+	}
+
+	cfg.Tools.SSH.KeyPath = "~/.ssh/id_ed25519"
+	cfg.Tools.SSH.AllowedHosts = FlexibleStringSlice{"web1.example.com"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid ssh config, got %v", err)
+	}
+}
+
+func TestDefaultConfig_KubernetesTool(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Tools.Kubernetes.Enabled {
+		t.Error("Kubernetes tool should be disabled by default")
+	}
+	if cfg.Tools.Kubernetes.KubeconfigPath != "~/.kube/config" {
+		t.Error("Expected default kubeconfig ~/.kube/config, got ", cfg.Tools.Kubernetes.KubeconfigPath)
+	}
+	if len(cfg.Tools.Kubernetes.AllowedNamespaces) != 1 || cfg.Tools.Kubernetes.AllowedNamespaces[0] != "default" {
+		t.Errorf("Expected allowed namespaces [default], got %v", cfg.Tools.Kubernetes.AllowedNamespaces)
+	}
+
+	cfg.Tools.Kubernetes.Enabled = true
+	cfg.Tools.Kubernetes.KubeconfigPath = ""
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "tools.kubernetes.kubeconfig_path") {
+		t.Fatalf("expected kubeconfig_path validation error, got %v", err)
+	}
+
+	cfg.Tools.Kubernetes.InCluster = true
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected in-cluster config to be valid, got %v", err)
+	}
+}
+
+func TestDefaultConfig_MessageTokenLimits(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Agents.Defaults.MaxSystemTokens != 0 {
+		t.Error("Expected max_system_tokens to be uncapped by default, got ", cfg.Agents.Defaults.MaxSystemTokens)
+	}
+	if cfg.Agents.Defaults.MaxRecallTokens != 0 {
+		t.Error("Expected max_recall_tokens to be uncapped by default, got ", cfg.Agents.Defaults.MaxRecallTokens)
+	}
+	if cfg.Agents.Defaults.MaxHistoryTokens != 0 {
+		t.Error("Expected max_history_tokens to be uncapped by default, got ", cfg.Agents.Defaults.MaxHistoryTokens)
+	}
+
+	cfg.Agents.Defaults.MaxRecallTokens = -1
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "agents.defaults.max_recall_tokens") {
+		t.Fatalf("expected max_recall_tokens validation error, got %v", err)
+	}
+}
+
+func TestDefaultConfig_EmailChannel(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Channels.Email.Enabled {
+		t.Error("Email channel should be disabled by default")
+	}
+	if cfg.Channels.Email.Mailbox != "INBOX" {
+		t.Error("Expected default mailbox INBOX, got ", cfg.Channels.Email.Mailbox)
+	}
+	if cfg.Channels.Email.PollIntervalSeconds != 60 {
+		t.Error("Expected email poll interval 60, got ", cfg.Channels.Email.PollIntervalSeconds)
+	}
+
+	cfg.Channels.Email.Enabled = true
+	cfg.Channels.Email.IMAPHost = "imap.example.com"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "channels.email.imap_user") || !strings.Contains(err.Error(), "channels.email.smtp_host") {
+		t.Fatalf("expected email credential validation errors, got %v", err)
+	}
+}
+
+func TestDefaultConfig_MatrixChannel(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if cfg.Channels.Matrix.Enabled {
+		t.Error("Matrix channel should be disabled by default")
+	}
+	if cfg.Channels.Matrix.E2EE {
+		t.Error("Matrix end-to-end encryption should be off by default")
+	}
+
+	cfg.Channels.Matrix.Enabled = true
+	cfg.Channels.Matrix.HomeserverURL = "https://matrix.example.org"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "channels.matrix.access_token") || !strings.Contains(err.Error(), "channels.matrix.room_ids") {
+		t.Fatalf("expected matrix validation errors, got %v", err)
+	}
+
+	cfg.Channels.Matrix.AccessToken = "token"
+	cfg.Channels.Matrix.RoomIDs = FlexibleStringSlice{"!room:example.org"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid matrix config, got %v", err)
+	}
+}
+
 func TestSaveConfig_FilePermissions(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("file permission bits are not enforced on Windows")
@@ -307,3 +880,100 @@ func containsAll(haystack string, needles []string) bool {
 	}
 	return true
 }
+
+func TestLoadConfig_TOMLUsesJSONKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	raw := `
+[agents.defaults]
+model = "toml/model"
+max_tokens = 4096
+
+[channels.discord]
+token = "discord-token"
+allow_from = ["123", 456]
+`
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Agents.Defaults.Model != "toml/model" || cfg.Agents.Defaults.MaxTokens != 4096 {
+		t.Fatalf("expected TOML agent defaults, got model=%q max_tokens=%d", cfg.Agents.Defaults.Model, cfg.Agents.Defaults.MaxTokens)
+	}
+	if got := strings.Join(cfg.Channels.Discord.AllowFrom, ","); got != "123,456" {
+		t.Fatalf("expected mixed allow_from to decode, got %q", got)
+	}
+}
+
+func TestSaveConfig_TOMLRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	cfg := DefaultConfig()
+	cfg.Agents.Defaults.Model = "round/trip"
+	cfg.Agents.Defaults.MaxTokens = 2048
+	if err := SaveConfig(path, cfg); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if !strings.Contains(string(raw), "max_tokens = 2048\n") {
+		t.Fatalf("expected snake_case integer key in TOML output, got:\n%s", raw)
+	}
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if loaded.Agents.Defaults.Model != "round/trip" || loaded.Agents.Defaults.MaxTokens != 2048 {
+		t.Fatalf("expected round-tripped values, got model=%q max_tokens=%d", loaded.Agents.Defaults.Model, loaded.Agents.Defaults.MaxTokens)
+	}
+}
+
+func TestResolveConfigPath_PrefersJSON(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "config.json")
+	tomlPath := filepath.Join(dir, "config.toml")
+
+	if got, both := ResolveConfigPath(jsonPath); got != jsonPath || both {
+		t.Fatalf("expected JSON path when neither exists, got %q both=%v", got, both)
+	}
+	if err := os.WriteFile(tomlPath, []byte(""), 0o600); err != nil {
+		t.Fatalf("write toml: %v", err)
+	}
+	if got, both := ResolveConfigPath(jsonPath); got != tomlPath || both {
+		t.Fatalf("expected TOML fallback, got %q both=%v", got, both)
+	}
+	if err := os.WriteFile(jsonPath, []byte("{}"), 0o600); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	if got, both := ResolveConfigPath(jsonPath); got != jsonPath || !both {
+		t.Fatalf("expected JSON preferred with both present, got %q both=%v", got, both)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("DOTAGENT_PROVIDERS_OPENROUTER_API_KEY", "sk-env")
+	t.Setenv("DOTAGENT_AGENTS_DEFAULTS_MAX_TOKENS", "2048")
+	cfg := DefaultConfig()
+	if err := ApplyEnv(cfg); err != nil {
+		t.Fatalf("apply env: %v", err)
+	}
+	if cfg.Providers.OpenRouter.APIKey != "sk-env" || cfg.Agents.Defaults.MaxTokens != 2048 {
+		t.Fatalf("expected env overrides, got key=%q max_tokens=%d", cfg.Providers.OpenRouter.APIKey, cfg.Agents.Defaults.MaxTokens)
+	}
+
+	cfg = DefaultConfig()
+	t.Setenv("DOTAGENT_CHANNELS_DISCORD_TOKEN", "discord-env")
+	t.Setenv("DOTAGENT_PROVIDERS_OPENAI_OAUTH_TOKEN_FILE", "/run/secrets/oauth.json")
+	if err := ApplyEnvExceptSecrets(cfg); err != nil {
+		t.Fatalf("apply env except secrets: %v", err)
+	}
+	if cfg.Providers.OpenRouter.APIKey != "" || cfg.Channels.Discord.Token != "" {
+		t.Fatalf("expected credentials to be left out, got key=%q token=%q", cfg.Providers.OpenRouter.APIKey, cfg.Channels.Discord.Token)
+	}
+	if cfg.Agents.Defaults.MaxTokens != 2048 || cfg.Providers.OpenAI.OAuthTokenFile != "/run/secrets/oauth.json" {
+		t.Fatalf("expected non-secret env overrides, got max_tokens=%d token_file=%q", cfg.Agents.Defaults.MaxTokens, cfg.Providers.OpenAI.OAuthTokenFile)
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// IsTOMLPath reports whether path names a TOML config file.
+func IsTOMLPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
+// TOMLSiblingPath returns the config.toml path that sits next to jsonPath.
+func TOMLSiblingPath(jsonPath string) string {
+	return strings.TrimSuffix(jsonPath, filepath.Ext(jsonPath)) + ".toml"
+}
+
+// ResolveConfigPath picks the config file to load for jsonPath. JSON wins when
+// it exists; otherwise a sibling config.toml is used if present. bothExist is
+// set when the TOML file is being ignored in favor of JSON.
+func ResolveConfigPath(jsonPath string) (path string, bothExist bool) {
+	tomlPath := TOMLSiblingPath(jsonPath)
+	_, jsonErr := os.Stat(jsonPath)
+	_, tomlErr := os.Stat(tomlPath)
+	switch {
+	case jsonErr == nil:
+		return jsonPath, tomlErr == nil
+	case tomlErr == nil:
+		return tomlPath, false
+	default:
+		return jsonPath, false
+	}
+}
+
+// decodeTOMLConfig applies TOML data to cfg. The document is routed through
+// JSON so TOML keys match the existing snake_case json struct tags and custom
+// JSON unmarshalers (e.g. FlexibleStringSlice) keep working.
+func decodeTOMLConfig(data []byte, cfg *Config) error {
+	raw, err := ConvertTOMLToJSON(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, cfg)
+}
+
+// encodeTOMLConfig renders cfg as TOML using the same keys as its JSON form.
+func encodeTOMLConfig(cfg *Config) ([]byte, error) {
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ConvertJSONToTOML(raw)
+}
+
+// ConvertTOMLToJSON rewrites a TOML config document as indented JSON without
+// applying defaults or environment overrides.
+func ConvertTOMLToJSON(data []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse toml config: %w", err)
+	}
+	raw, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("convert toml config: %w", err)
+	}
+	return raw, nil
+}
+
+// ConvertJSONToTOML rewrites a JSON config document as TOML without applying
+// defaults or environment overrides.
+func ConvertJSONToTOML(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse json config: %w", err)
+	}
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(tomlValue(doc)); err != nil {
+		return nil, fmt.Errorf("encode toml config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// tomlValue drops JSON nulls, which TOML cannot represent, and turns
+// json.Number into integers where possible so 2048 is not written as 2048.0.
+func tomlValue(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(vv))
+		for k, item := range vv {
+			if item == nil {
+				continue
+			}
+			out[k] = tomlValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, 0, len(vv))
+		for _, item := range vv {
+			if item == nil {
+				continue
+			}
+			out = append(out, tomlValue(item))
+		}
+		return out
+	case json.Number:
+		if n, err := vv.Int64(); err == nil {
+			return n
+		}
+		f, _ := vv.Float64()
+		return f
+	default:
+		return v
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"log"
+	mathrand "math/rand"
 	"os"
 	"path/filepath"
 	"runtime/debug"
@@ -68,6 +70,7 @@ type CronService struct {
 	running   bool
 	stopChan  chan struct{}
 	gronx     *gronx.Gronx
+	jitterMS  int64
 }
 
 const maxEveryIntervalMS = int64(365 * 24 * 60 * 60 * 1000)
@@ -191,24 +194,15 @@ func (cs *CronService) checkJobs() {
 	}
 
 	now := time.Now().UnixMilli()
-	var dueJobIDs []string
+	var due []dueJob
 
 	// Collect jobs that are due (we need to copy them to execute outside lock)
 	for i := range cs.store.Jobs {
 		job := &cs.store.Jobs[i]
 		if job.Enabled && job.State.NextRunAtMS != nil && *job.State.NextRunAtMS <= now {
-			dueJobIDs = append(dueJobIDs, job.ID)
-		}
-	}
-
-	// Reset next run for due jobs before unlocking to avoid duplicate execution.
-	dueMap := make(map[string]bool, len(dueJobIDs))
-	for _, jobID := range dueJobIDs {
-		dueMap[jobID] = true
-	}
-	for i := range cs.store.Jobs {
-		if dueMap[cs.store.Jobs[i].ID] {
-			cs.store.Jobs[i].State.NextRunAtMS = nil
+			due = append(due, dueJob{id: job.ID, tickMS: *job.State.NextRunAtMS - cs.jobOffsetMS(job)})
+			// Reset next run before unlocking to avoid duplicate execution.
+			job.State.NextRunAtMS = nil
 		}
 	}
 
@@ -219,12 +213,22 @@ func (cs *CronService) checkJobs() {
 	cs.mu.Unlock()
 
 	// Execute jobs outside lock.
-	for _, jobID := range dueJobIDs {
-		cs.executeJobByID(jobID)
+	for _, d := range due {
+		cs.executeJobByID(d.id, d.tickMS)
 	}
 }
 
-func (cs *CronService) executeJobByID(jobID string) {
+// dueJob is a job picked up by checkJobs and the schedule tick it runs for,
+// without jitter.
+type dueJob struct {
+	id     string
+	tickMS int64
+}
+
+// executeJobByID runs a job and schedules its next run after tickMS, the
+// tick it ran for, so a jitter change while it runs cannot bring that tick
+// back around.
+func (cs *CronService) executeJobByID(jobID string, tickMS int64) {
 	startTime := time.Now().UnixMilli()
 
 	cs.mu.RLock()
@@ -291,7 +295,7 @@ func (cs *CronService) executeJobByID(jobID string) {
 			job.State.NextRunAtMS = nil
 		}
 	} else {
-		nextRun := cs.computeNextRun(&job.Schedule, time.Now().UnixMilli())
+		nextRun := cs.computeNextRun(job.ID, &job.Schedule, max(time.Now().UnixMilli(), tickMS+cs.jobOffsetMS(job)))
 		job.State.NextRunAtMS = nextRun
 		if nextRun == nil {
 			job.Enabled = false
@@ -309,7 +313,7 @@ func (cs *CronService) executeJobByID(jobID string) {
 	}
 }
 
-func (cs *CronService) computeNextRun(schedule *CronSchedule, nowMS int64) *int64 {
+func (cs *CronService) computeNextRun(jobID string, schedule *CronSchedule, nowMS int64) *int64 {
 	if schedule.Kind == "at" {
 		if schedule.AtMS != nil && *schedule.AtMS > nowMS {
 			return schedule.AtMS
@@ -330,35 +334,47 @@ func (cs *CronService) computeNextRun(schedule *CronSchedule, nowMS int64) *int6
 			return nil
 		}
 
-		// Use gronx to calculate next run time
-		now := time.UnixMilli(nowMS)
-		if schedule.TZ != "" {
-			if loc, err := time.LoadLocation(schedule.TZ); err == nil {
-				now = now.In(loc)
-			} else {
-				log.Printf("[cron] invalid timezone %q for expr '%s': %v", schedule.TZ, schedule.Expr, err)
-				return nil
-			}
-		}
-		nextTime, err := gronx.NextTickAfter(schedule.Expr, now, false)
-		if err != nil {
-			log.Printf("[cron] failed to compute next run for expr '%s': %v", schedule.Expr, err)
+		// Look for the next tick after now shifted back by the job's offset,
+		// so a restart inside the jitter window does not skip that run.
+		offsetMS := jitterOffsetMS(jobID, cs.jitterMS)
+		tick := cs.nextCronTick(schedule, nowMS-offsetMS)
+		if tick == nil {
 			return nil
 		}
-
-		nextMS := nextTime.UnixMilli()
+		nextMS := *tick + offsetMS
 		return &nextMS
 	}
 
 	return nil
 }
 
+// nextCronTick returns the first tick of a cron-expression schedule after
+// afterMS, before any jitter.
+func (cs *CronService) nextCronTick(schedule *CronSchedule, afterMS int64) *int64 {
+	after := time.UnixMilli(afterMS)
+	if schedule.TZ != "" {
+		loc, err := time.LoadLocation(schedule.TZ)
+		if err != nil {
+			log.Printf("[cron] invalid timezone %q for expr '%s': %v", schedule.TZ, schedule.Expr, err)
+			return nil
+		}
+		after = after.In(loc)
+	}
+	nextTime, err := gronx.NextTickAfter(schedule.Expr, after, false)
+	if err != nil {
+		log.Printf("[cron] failed to compute next run for expr '%s': %v", schedule.Expr, err)
+		return nil
+	}
+	tickMS := nextTime.UnixMilli()
+	return &tickMS
+}
+
 func (cs *CronService) recomputeNextRuns() {
 	now := time.Now().UnixMilli()
 	for i := range cs.store.Jobs {
 		job := &cs.store.Jobs[i]
 		if job.Enabled {
-			job.State.NextRunAtMS = cs.computeNextRun(&job.Schedule, now)
+			job.State.NextRunAtMS = cs.computeNextRun(job.ID, &job.Schedule, now)
 			if job.State.NextRunAtMS == nil {
 				job.Enabled = false
 				job.State.LastStatus = "error"
@@ -400,6 +416,62 @@ func (cs *CronService) SetOnJob(handler JobHandler) {
 	cs.onJob = handler
 }
 
+// SetJitter delays each cron-expression job by a stable per-job offset below
+// jitter, so jobs sharing a schedule do not all fire at once. On a running
+// service each pending run moves to the new offset from the same schedule
+// tick, so changing the jitter neither repeats nor skips a run.
+func (cs *CronService) SetJitter(jitter time.Duration) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if jitter < 0 {
+		jitter = 0
+	}
+	if jitter.Milliseconds() == cs.jitterMS {
+		return
+	}
+	ticks := make(map[string]int64)
+	for i := range cs.store.Jobs {
+		job := &cs.store.Jobs[i]
+		if job.Enabled && job.State.NextRunAtMS != nil {
+			ticks[job.ID] = *job.State.NextRunAtMS - cs.jobOffsetMS(job)
+		}
+	}
+	cs.jitterMS = jitter.Milliseconds()
+	if len(ticks) == 0 {
+		return
+	}
+	for i := range cs.store.Jobs {
+		job := &cs.store.Jobs[i]
+		if tick, ok := ticks[job.ID]; ok {
+			next := tick + cs.jobOffsetMS(job)
+			job.State.NextRunAtMS = &next
+		}
+	}
+	if err := cs.saveStoreUnsafe(); err != nil {
+		log.Printf("[cron] failed to save store: %v", err)
+	}
+}
+
+// jobOffsetMS is the jitter offset currently applied to a job's runs; only
+// cron-expression jobs are jittered.
+func (cs *CronService) jobOffsetMS(job *CronJob) int64 {
+	if job.Schedule.Kind != "cron" {
+		return 0
+	}
+	return jitterOffsetMS(job.ID, cs.jitterMS)
+}
+
+// jitterOffsetMS returns the job's delay in [0, jitterMS). It is seeded from
+// the job ID so it stays the same across restarts.
+func jitterOffsetMS(jobID string, jitterMS int64) int64 {
+	if jitterMS <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(jobID))
+	return mathrand.New(mathrand.NewSource(int64(h.Sum64()))).Int63n(jitterMS)
+}
+
 func (cs *CronService) loadStore() error {
 	cs.store = &CronStore{
 		Version: 1,
@@ -488,8 +560,9 @@ func (cs *CronService) AddJob(name string, schedule CronSchedule, message string
 	// One-time tasks (at) should be deleted after execution
 	deleteAfterRun := (schedule.Kind == "at")
 
+	id := generateID()
 	job := CronJob{
-		ID:       generateID(),
+		ID:       id,
 		Name:     name,
 		Enabled:  true,
 		Schedule: schedule,
@@ -501,7 +574,7 @@ func (cs *CronService) AddJob(name string, schedule CronSchedule, message string
 			To:      to,
 		},
 		State: CronJobState{
-			NextRunAtMS: cs.computeNextRun(&schedule, now),
+			NextRunAtMS: cs.computeNextRun(id, &schedule, now),
 		},
 		CreatedAtMS:    now,
 		UpdatedAtMS:    now,
@@ -539,7 +612,7 @@ func (cs *CronService) UpdateJob(job *CronJob) error {
 			nextJob.CreatedAtMS = cs.store.Jobs[i].CreatedAtMS
 			nextJob.UpdatedAtMS = now
 			if nextJob.Enabled {
-				nextJob.State.NextRunAtMS = cs.computeNextRun(&nextJob.Schedule, now)
+				nextJob.State.NextRunAtMS = cs.computeNextRun(nextJob.ID, &nextJob.Schedule, now)
 				if nextJob.State.NextRunAtMS == nil {
 					nextJob.Enabled = false
 					nextJob.State.LastStatus = "error"
@@ -593,7 +666,7 @@ func (cs *CronService) EnableJob(jobID string, enabled bool) *CronJob {
 			job.UpdatedAtMS = time.Now().UnixMilli()
 
 			if enabled {
-				job.State.NextRunAtMS = cs.computeNextRun(&job.Schedule, time.Now().UnixMilli())
+				job.State.NextRunAtMS = cs.computeNextRun(job.ID, &job.Schedule, time.Now().UnixMilli())
 				if job.State.NextRunAtMS == nil {
 					job.Enabled = false
 					job.State.LastStatus = "error"
@@ -191,7 +191,7 @@ func TestCronService_ExecuteJobRecoversFromPanic(t *testing.T) {
 		t.Fatalf("AddJob failed: %v", err)
 	}
 
-	cs.executeJobByID(job.ID)
+	cs.executeJobByID(job.ID, 0)
 	var found *CronJob
 	for _, candidate := range cs.ListJobs(true) {
 		if candidate.ID == job.ID {
@@ -225,7 +225,7 @@ func TestCronService_OneTimeJobNotDeletedOnHandlerError(t *testing.T) {
 		t.Fatalf("AddJob failed: %v", err)
 	}
 
-	cs.executeJobByID(job.ID)
+	cs.executeJobByID(job.ID, 0)
 	var found *CronJob
 	for _, candidate := range cs.ListJobs(true) {
 		if candidate.ID == job.ID {
@@ -302,3 +302,83 @@ func TestCronService_RecomputeNextRunsDisablesInvalidTimezoneJobs(t *testing.T)
 		t.Fatalf("expected invalid timezone error to be recorded, got %q", found[0].State.LastError)
 	}
 }
+
+func TestCronService_JitterIsStablePerJob(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "cron", "jobs.json")
+	cs := mustNewCronService(t, storePath)
+	cs.SetJitter(30 * time.Second)
+
+	schedule := &CronSchedule{Kind: "cron", Expr: "0 9 * * *", TZ: "UTC"}
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	tick := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC).UnixMilli()
+
+	offsets := map[int64]bool{}
+	for _, id := range []string{"job-a", "job-b", "job-c", "job-d"} {
+		next := cs.computeNextRun(id, schedule, now.UnixMilli())
+		if next == nil {
+			t.Fatalf("expected next run for %s", id)
+		}
+		offset := *next - tick
+		if offset < 0 || offset >= 30_000 {
+			t.Fatalf("offset for %s out of range: %dms", id, offset)
+		}
+		if again := cs.computeNextRun(id, schedule, now.UnixMilli()); *again != *next {
+			t.Fatalf("expected stable jitter for %s, got %d then %d", id, *next, *again)
+		}
+		// Restarting inside the jitter window must not skip today's run.
+		if inside := cs.computeNextRun(id, schedule, tick+offset-1); *inside != *next {
+			t.Fatalf("expected run at %d for %s inside jitter window, got %d", *next, id, *inside)
+		}
+		offsets[offset] = true
+	}
+	if len(offsets) < 2 {
+		t.Fatalf("expected jobs to be spread out, got offsets %v", offsets)
+	}
+
+	cs.SetJitter(0)
+	if next := cs.computeNextRun("job-a", schedule, now.UnixMilli()); *next != tick {
+		t.Fatalf("expected no jitter when disabled, got %d want %d", *next, tick)
+	}
+}
+
+func TestCronService_SetJitterBetweenTicksKeepsEachTickOnce(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "cron", "jobs.json")
+	cs := mustNewCronService(t, storePath)
+
+	job, err := cs.AddJob("minutely", CronSchedule{Kind: "cron", Expr: "* * * * *", TZ: "UTC"}, "hello", false, "cli", "direct")
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	nextRun := func() int64 {
+		t.Helper()
+		for _, candidate := range cs.ListJobs(true) {
+			if candidate.ID == job.ID && candidate.State.NextRunAtMS != nil {
+				return *candidate.State.NextRunAtMS
+			}
+		}
+		t.Fatalf("expected a next run for %s", job.ID)
+		return 0
+	}
+	tick := nextRun()
+	offset := jitterOffsetMS(job.ID, 50_000)
+
+	// Raising the jitter moves the pending run within the same tick.
+	cs.SetJitter(50 * time.Second)
+	if got := nextRun(); got != tick+offset {
+		t.Fatalf("expected pending run at %d after raising jitter, got %d", tick+offset, got)
+	}
+
+	// A run for that tick schedules the following one, even though now minus
+	// the new offset still falls before the tick that just ran.
+	cs.executeJobByID(job.ID, tick)
+	if got := nextRun(); got != tick+60_000+offset {
+		t.Fatalf("expected next run at %d, got %d (tick %d ran twice)", tick+60_000+offset, got, tick)
+	}
+
+	cs.SetJitter(0)
+	if got := nextRun(); got != tick+60_000 {
+		t.Fatalf("expected pending run at %d after removing jitter, got %d", tick+60_000, got)
+	}
+}
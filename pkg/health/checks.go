@@ -0,0 +1,76 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// CheckFunc probes a dependency. A nil error means the dependency is healthy.
+type CheckFunc func(ctx context.Context) error
+
+type registeredCheck struct {
+	id uint64
+	fn CheckFunc
+}
+
+var (
+	checkRegistryMu  sync.RWMutex
+	checkRegistry    = map[string]registeredCheck{}
+	checkRegistrySeq uint64
+)
+
+// Register adds a named probe to the process-wide registry evaluated on every
+// /ready request. Registering an existing name replaces the previous probe.
+// The returned function removes the registration, unless it has since been
+// replaced by a newer one.
+func Register(name string, fn CheckFunc) (unregister func()) {
+	if name == "" || fn == nil {
+		return func() {}
+	}
+	checkRegistryMu.Lock()
+	checkRegistrySeq++
+	id := checkRegistrySeq
+	checkRegistry[name] = registeredCheck{id: id, fn: fn}
+	checkRegistryMu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			checkRegistryMu.Lock()
+			defer checkRegistryMu.Unlock()
+			if current, ok := checkRegistry[name]; ok && current.id == id {
+				delete(checkRegistry, name)
+			}
+		})
+	}
+}
+
+// RegisteredChecks returns the names of all registered probes, sorted.
+func RegisteredChecks() []string {
+	checkRegistryMu.RLock()
+	defer checkRegistryMu.RUnlock()
+	names := make([]string, 0, len(checkRegistry))
+	for name := range checkRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RunChecks evaluates every registered probe and returns its result keyed by
+// name. Healthy probes map to a nil error.
+func RunChecks(ctx context.Context) map[string]error {
+	checkRegistryMu.RLock()
+	snapshot := make(map[string]CheckFunc, len(checkRegistry))
+	for name, entry := range checkRegistry {
+		snapshot[name] = entry.fn
+	}
+	checkRegistryMu.RUnlock()
+
+	results := make(map[string]error, len(snapshot))
+	for name, fn := range snapshot {
+		results[name] = fn(ctx)
+	}
+	return results
+}
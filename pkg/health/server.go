@@ -30,6 +30,18 @@ type StatusResponse struct {
 	Checks map[string]Check `json:"checks,omitempty"`
 }
 
+// ReadyResponse is the /ready payload. Each check maps to "ok", "ok: <detail>"
+// or "error: <detail>".
+type ReadyResponse struct {
+	Ready  bool              `json:"ready"`
+	Status string            `json:"status"`
+	Uptime string            `json:"uptime,omitempty"`
+	Checks map[string]string `json:"checks"`
+}
+
+// probeTimeout bounds how long registered probes may run per /ready request.
+const probeTimeout = 3 * time.Second
+
 func NewServer(host string, port int) *Server {
 	mux := http.NewServeMux()
 	s := &Server{
@@ -121,39 +133,52 @@ func (s *Server) readyHandler(w http.ResponseWriter, r *http.Request) {
 
 	s.mu.RLock()
 	ready := s.ready
-	checks := make(map[string]Check)
+	checks := make(map[string]string, len(s.checks))
 	for k, v := range s.checks {
-		checks[k] = v
+		checks[k] = checkResultString(v.Status == "ok", v.Message)
+		if v.Status != "ok" {
+			ready = false
+		}
 	}
 	s.mu.RUnlock()
 
-	if !ready {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(StatusResponse{
-			Status: "not ready",
-			Checks: checks,
-		})
-		return
-	}
-
-	for _, check := range checks {
-		if check.Status == "fail" {
-			w.WriteHeader(http.StatusServiceUnavailable)
-			json.NewEncoder(w).Encode(StatusResponse{
-				Status: "not ready",
-				Checks: checks,
-			})
-			return
+	ctx, cancel := context.WithTimeout(r.Context(), probeTimeout)
+	defer cancel()
+	for name, err := range RunChecks(ctx) {
+		if err != nil {
+			checks[name] = checkResultString(false, err.Error())
+			ready = false
+			continue
 		}
+		checks[name] = checkResultString(true, "")
 	}
 
-	w.WriteHeader(http.StatusOK)
-	uptime := time.Since(s.startTime)
-	json.NewEncoder(w).Encode(StatusResponse{
+	resp := ReadyResponse{
+		Ready:  ready,
 		Status: "ready",
-		Uptime: uptime.String(),
+		Uptime: time.Since(s.startTime).String(),
 		Checks: checks,
-	})
+	}
+	if !ready {
+		resp.Status = "not ready"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// checkResultString renders a check outcome as "ok", "ok: <detail>" or
+// "error: <detail>".
+func checkResultString(ok bool, detail string) string {
+	prefix := "ok"
+	if !ok {
+		prefix = "error"
+	}
+	if detail == "" {
+		return prefix
+	}
+	return prefix + ": " + detail
 }
 
 func statusString(ok bool) string {
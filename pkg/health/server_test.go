@@ -0,0 +1,72 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadyHandler_FailingProbeReturns503(t *testing.T) {
+	s := NewServer("127.0.0.1", 0)
+	s.SetReady(true)
+
+	unregister := Register("memory_db", func(context.Context) error {
+		return errors.New("database disk image is malformed")
+	})
+	defer unregister()
+
+	rec := httptest.NewRecorder()
+	s.readyHandler(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+	var resp ReadyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Ready {
+		t.Fatalf("expected ready=false")
+	}
+	if got := resp.Checks["memory_db"]; got != "error: database disk image is malformed" {
+		t.Fatalf("unexpected memory_db result: %q", got)
+	}
+}
+
+func TestReadyHandler_HealthyProbes(t *testing.T) {
+	s := NewServer("127.0.0.1", 0)
+	s.SetReady(true)
+	s.RegisterCheck("provider_config", func() (bool, string) { return true, "openai" })
+
+	unregister := Register("memory_db", func(context.Context) error { return nil })
+	defer unregister()
+
+	rec := httptest.NewRecorder()
+	s.readyHandler(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp ReadyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !resp.Ready || resp.Checks["memory_db"] != "ok" || resp.Checks["provider_config"] != "ok: openai" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestRegister_UnregisterKeepsNewerRegistration(t *testing.T) {
+	first := Register("probe_replace", func(context.Context) error { return nil })
+	second := Register("probe_replace", func(context.Context) error { return errors.New("newer") })
+	defer second()
+
+	first()
+	results := RunChecks(context.Background())
+	if err, ok := results["probe_replace"]; !ok || err == nil || err.Error() != "newer" {
+		t.Fatalf("expected newer registration to survive, got %v (present=%v)", err, ok)
+	}
+}
@@ -28,7 +28,8 @@ const (
 
 // HeartbeatHandler is the function type for handling heartbeat.
 // It returns a ToolResult that can indicate async operations.
-// channel and chatID are derived from the last active user channel.
+// channel and chatID are derived from the last active user channel, or the
+// fallback channel when none has been recorded; both are empty otherwise.
 type HeartbeatHandler func(prompt, channel, chatID string) *tools.ToolResult
 
 // HeartbeatService manages periodic heartbeat checks
@@ -43,19 +44,16 @@ type HeartbeatService struct {
 	enabled   bool
 	mu        sync.RWMutex
 	stopChan  chan struct{}
+	// intervalChanged wakes the run loop to reset its ticker.
+	intervalChanged chan struct{}
+
+	// lastChannel reads the last active "channel:chat_id"; nil uses state.
+	lastChannel     func() string
+	fallbackChannel string
 }
 
 // NewHeartbeatService creates a new heartbeat service
 func NewHeartbeatService(workspace string, dataRoot string, logsRoot string, intervalMinutes int, enabled bool) *HeartbeatService {
-	// Apply minimum interval
-	if intervalMinutes < minIntervalMinutes && intervalMinutes != 0 {
-		intervalMinutes = minIntervalMinutes
-	}
-
-	if intervalMinutes == 0 {
-		intervalMinutes = defaultIntervalMinutes
-	}
-
 	dataRoot = strings.TrimSpace(dataRoot)
 	if dataRoot == "" {
 		dataRoot = workspace
@@ -69,10 +67,42 @@ func NewHeartbeatService(workspace string, dataRoot string, logsRoot string, int
 		workspace: workspace,
 		dataRoot:  dataRoot,
 		logsRoot:  logsRoot,
-		interval:  time.Duration(intervalMinutes) * time.Minute,
+		interval:  heartbeatInterval(intervalMinutes),
 		enabled:   enabled,
 		state:     state.NewManager(dataRoot),
+
+		intervalChanged: make(chan struct{}, 1),
+	}
+}
+
+// heartbeatInterval applies the minimum interval and the default for 0.
+func heartbeatInterval(minutes int) time.Duration {
+	if minutes < minIntervalMinutes && minutes != 0 {
+		minutes = minIntervalMinutes
+	}
+	if minutes == 0 {
+		minutes = defaultIntervalMinutes
 	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// SetInterval changes the time between heartbeats. A running service starts
+// the new interval from now.
+func (hs *HeartbeatService) SetInterval(intervalMinutes int) {
+	hs.mu.Lock()
+	hs.interval = heartbeatInterval(intervalMinutes)
+	hs.mu.Unlock()
+	select {
+	case hs.intervalChanged <- struct{}{}:
+	default:
+	}
+}
+
+// Interval returns the time between heartbeats.
+func (hs *HeartbeatService) Interval() time.Duration {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	return hs.interval
 }
 
 // SetBus sets the message bus for delivering heartbeat results.
@@ -89,6 +119,23 @@ func (hs *HeartbeatService) SetHandler(handler HeartbeatHandler) {
 	hs.handler = handler
 }
 
+// SetLastChannelSource makes the service read the last active channel from
+// fn, normally the agent loop's state manager, instead of the copy of the
+// state file it loaded at startup.
+func (hs *HeartbeatService) SetLastChannelSource(fn func() string) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.lastChannel = fn
+}
+
+// SetFallbackChannel sets the "channel:chat_id" used when no user channel
+// has been recorded yet.
+func (hs *HeartbeatService) SetFallbackChannel(target string) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.fallbackChannel = strings.TrimSpace(target)
+}
+
 // Start begins the heartbeat service
 func (hs *HeartbeatService) Start() error {
 	hs.mu.Lock()
@@ -137,7 +184,7 @@ func (hs *HeartbeatService) IsRunning() bool {
 
 // runLoop runs the heartbeat ticker
 func (hs *HeartbeatService) runLoop(stopChan chan struct{}) {
-	ticker := time.NewTicker(hs.interval)
+	ticker := time.NewTicker(hs.Interval())
 	defer ticker.Stop()
 
 	// Run first heartbeat after initial delay
@@ -149,6 +196,8 @@ func (hs *HeartbeatService) runLoop(stopChan chan struct{}) {
 		select {
 		case <-stopChan:
 			return
+		case <-hs.intervalChanged:
+			ticker.Reset(hs.Interval())
 		case <-ticker.C:
 			hs.executeHeartbeat()
 		}
@@ -183,12 +232,9 @@ func (hs *HeartbeatService) executeHeartbeat() {
 		return
 	}
 
-	// Get last channel info for context
-	lastChannel := hs.state.GetLastChannel()
-	channel, chatID := hs.parseLastChannel(lastChannel)
-
-	// Debug log for channel resolution
-	hs.logInfo("Resolved channel: %s, chatID: %s (from lastChannel: %s)", channel, chatID, lastChannel)
+	// Last active user channel, or heartbeat.fallback_channel
+	channel, chatID := hs.deliveryTarget()
+	hs.logInfo("Resolved channel: %s, chatID: %s", channel, chatID)
 
 	result := handler(prompt, channel, chatID)
 
@@ -306,17 +352,11 @@ func (hs *HeartbeatService) sendResponse(response string) {
 		return
 	}
 
-	// Get last channel from state
-	lastChannel := hs.state.GetLastChannel()
-	if lastChannel == "" {
-		hs.logInfo("No last channel recorded, heartbeat result not sent")
-		return
-	}
-
-	platform, userID := hs.parseLastChannel(lastChannel)
+	platform, userID := hs.deliveryTarget()
 
 	// Skip internal channels that can't receive messages
-	if platform == "" || userID == "" {
+	if platform == "" || userID == "" || constants.IsInternalChannel(platform) {
+		hs.logInfo("No deliverable channel recorded, heartbeat result not sent")
 		return
 	}
 
@@ -332,6 +372,28 @@ func (hs *HeartbeatService) sendResponse(response string) {
 	hs.logInfo("Heartbeat result sent to %s", platform)
 }
 
+// deliveryTarget returns the last active user channel, or the fallback
+// channel when none has been recorded yet.
+func (hs *HeartbeatService) deliveryTarget() (channel, chatID string) {
+	hs.mu.RLock()
+	source, fallback := hs.lastChannel, hs.fallbackChannel
+	hs.mu.RUnlock()
+
+	lastChannel := ""
+	if source != nil {
+		lastChannel = source()
+	} else {
+		lastChannel = hs.state.GetLastChannel()
+	}
+	if channel, chatID = hs.parseLastChannel(lastChannel); channel != "" {
+		return channel, chatID
+	}
+	if parts := strings.SplitN(fallback, ":", 2); len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+		return parts[0], parts[1]
+	}
+	return "", ""
+}
+
 // parseLastChannel parses the last channel string into platform and userID.
 // Returns empty strings for invalid or internal channels.
 func (hs *HeartbeatService) parseLastChannel(lastChannel string) (platform, userID string) {
@@ -1,11 +1,13 @@
 package heartbeat
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/dotsetgreg/dotagent/pkg/bus"
 	"github.com/dotsetgreg/dotagent/pkg/tools"
 )
 
@@ -142,6 +144,31 @@ func TestHeartbeatService_StartStop(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 }
 
+func TestHeartbeatService_SetInterval(t *testing.T) {
+	tmpDir := t.TempDir()
+	hs := NewHeartbeatService(tmpDir, tmpDir, tmpDir, 30, true)
+	hs.SetHandler(func(prompt, channel, chatID string) *tools.ToolResult { return tools.SilentResult("ok") })
+	if err := hs.Start(); err != nil {
+		t.Fatalf("Failed to start heartbeat service: %v", err)
+	}
+	defer hs.Stop()
+
+	for _, tc := range []struct {
+		minutes int
+		want    time.Duration
+	}{
+		{minutes: 60, want: time.Hour},
+		{minutes: 1, want: 5 * time.Minute},
+		{minutes: 0, want: 30 * time.Minute},
+	} {
+		hs.SetInterval(tc.minutes)
+		hs.SetInterval(tc.minutes) // a second change before the loop wakes must not block
+		if got := hs.Interval(); got != tc.want {
+			t.Errorf("SetInterval(%d): interval = %v, want %v", tc.minutes, got, tc.want)
+		}
+	}
+}
+
 func TestHeartbeatService_Disabled(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "heartbeat-test-*")
 	if err != nil {
@@ -219,3 +246,59 @@ func TestHeartbeatFilePath(t *testing.T) {
 		t.Errorf("Expected HEARTBEAT.md at %s, but it doesn't exist", expectedPath)
 	}
 }
+
+func TestExecuteHeartbeat_UsesLastChannelSourceThenFallback(t *testing.T) {
+	tmpDir := t.TempDir()
+	hs := NewHeartbeatService(tmpDir, tmpDir, tmpDir, 30, true)
+	hs.stopChan = make(chan struct{}) // Enable for testing
+	os.WriteFile(filepath.Join(tmpDir, "HEARTBEAT.md"), []byte("Test task"), 0644)
+
+	var gotChannel, gotChatID string
+	hs.SetHandler(func(prompt, channel, chatID string) *tools.ToolResult {
+		gotChannel, gotChatID = channel, chatID
+		return tools.SilentResult("HEARTBEAT_OK")
+	})
+
+	lastChannel := ""
+	hs.SetLastChannelSource(func() string { return lastChannel })
+	hs.SetFallbackChannel("telegram:42")
+
+	hs.executeHeartbeat()
+	if gotChannel != "telegram" || gotChatID != "42" {
+		t.Fatalf("expected fallback target telegram:42, got %s:%s", gotChannel, gotChatID)
+	}
+
+	// Internal channels are never recorded as delivery targets.
+	lastChannel = "cli:direct"
+	hs.executeHeartbeat()
+	if gotChannel != "telegram" {
+		t.Fatalf("expected internal last channel to fall back, got %s:%s", gotChannel, gotChatID)
+	}
+
+	lastChannel = "discord:123"
+	hs.executeHeartbeat()
+	if gotChannel != "discord" || gotChatID != "123" {
+		t.Fatalf("expected recorded target discord:123, got %s:%s", gotChannel, gotChatID)
+	}
+}
+
+func TestSendResponse_DeliversToLastChannel(t *testing.T) {
+	tmpDir := t.TempDir()
+	hs := NewHeartbeatService(tmpDir, tmpDir, tmpDir, 30, true)
+	msgBus := bus.NewMessageBus()
+	hs.SetBus(msgBus)
+	hs.SetLastChannelSource(func() string { return "discord:123" })
+	hs.SetFallbackChannel("cli:direct")
+
+	hs.sendResponse("reminder")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, ok := msgBus.SubscribeOutbound(ctx)
+	if !ok {
+		t.Fatal("expected heartbeat result on the outbound bus")
+	}
+	if msg.Channel != "discord" || msg.ChatID != "123" || msg.Content != "reminder" {
+		t.Fatalf("unexpected outbound message %+v", msg)
+	}
+}
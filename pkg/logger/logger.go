@@ -67,6 +67,29 @@ func GetLevel() LogLevel {
 	return currentLevel
 }
 
+// ParseLevel maps a level name such as "debug" or "WARN" to its LogLevel;
+// "warning" is accepted for WARN.
+func ParseLevel(name string) (LogLevel, bool) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "WARNING" {
+		name = "WARN"
+	}
+	for level, levelName := range logLevelNames {
+		if levelName == name {
+			return level, true
+		}
+	}
+	return INFO, false
+}
+
+// String returns the level's name, e.g. "INFO".
+func (l LogLevel) String() string {
+	if name, ok := logLevelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("LogLevel(%d)", int(l))
+}
+
 func EnableFileLogging(filePath string) error {
 	mu.Lock()
 	defer mu.Unlock()
@@ -137,3 +137,15 @@ func TestLoggerHelperFunctions(t *testing.T) {
 	DebugC("test", "Debug with component")
 	WarnF("Warning with fields", map[string]interface{}{"key": "value"})
 }
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]LogLevel{"debug": DEBUG, " Info ": INFO, "warning": WARN, "WARN": WARN, "error": ERROR}
+	for name, want := range tests {
+		if got, ok := ParseLevel(name); !ok || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", name, got, ok, want)
+		}
+	}
+	if _, ok := ParseLevel("verbose"); ok {
+		t.Errorf("expected unknown level to be rejected")
+	}
+}
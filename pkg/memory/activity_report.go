@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+)
+
+// activityPersonaChangeLimit bounds the persona revisions listed in an
+// activity summary; PersonaRevisions still counts all of them.
+const activityPersonaChangeLimit = 20
+
+// ActivityStats summarizes what happened in the memory database between
+// SinceMS (inclusive) and UntilMS (exclusive).
+type ActivityStats struct {
+	SinceMS int64
+	UntilMS int64
+
+	ActiveSessions    int
+	NewSessions       int
+	UserMessages      int
+	AssistantMessages int
+
+	MemoriesCreated int
+	MemoriesUpdated int
+	MemoriesDeleted int
+
+	PersonaRevisions int
+	// PersonaChanges holds the most recent revisions in the window, newest
+	// first.
+	PersonaChanges []PersonaRevision
+}
+
+// ActivityStats counts sessions, messages, memory changes and persona
+// revisions recorded in [sinceMS, untilMS).
+func (s *SQLiteStore) ActivityStats(ctx context.Context, sinceMS, untilMS int64) (ActivityStats, error) {
+	stats := ActivityStats{SinceMS: sinceMS, UntilMS: untilMS}
+	counts := []struct {
+		dst   *int
+		name  string
+		query string
+	}{
+		{&stats.ActiveSessions, "active sessions", `SELECT COUNT(*) FROM sessions WHERE updated_at_ms >= ? AND updated_at_ms < ?`},
+		{&stats.NewSessions, "new sessions", `SELECT COUNT(*) FROM sessions WHERE created_at_ms >= ? AND created_at_ms < ?`},
+		{&stats.UserMessages, "user messages", `SELECT COUNT(*) FROM events WHERE role = 'user' AND created_at_ms >= ? AND created_at_ms < ?`},
+		{&stats.AssistantMessages, "assistant messages", `SELECT COUNT(*) FROM events WHERE role = 'assistant' AND created_at_ms >= ? AND created_at_ms < ?`},
+		{&stats.MemoriesCreated, "created memories", `SELECT COUNT(*) FROM memory_items WHERE first_seen_at_ms >= ? AND first_seen_at_ms < ?`},
+		{&stats.MemoriesDeleted, "deleted memories", `SELECT COUNT(*) FROM memory_items WHERE deleted_at_ms > 0 AND deleted_at_ms >= ? AND deleted_at_ms < ?`},
+		{&stats.PersonaRevisions, "persona revisions", `SELECT COUNT(*) FROM persona_revisions WHERE created_at_ms >= ? AND created_at_ms < ?`},
+	}
+	for _, c := range counts {
+		if err := s.db.QueryRowContext(ctx, c.query, sinceMS, untilMS).Scan(c.dst); err != nil {
+			return ActivityStats{}, fmt.Errorf("count %s: %w", c.name, err)
+		}
+	}
+	// Items first seen earlier but touched again in the window were updated.
+	if err := s.db.QueryRowContext(ctx, `
+SELECT COUNT(*) FROM memory_items
+WHERE first_seen_at_ms < ? AND last_seen_at_ms >= ? AND last_seen_at_ms < ? AND deleted_at_ms = 0`,
+		sinceMS, sinceMS, untilMS).Scan(&stats.MemoriesUpdated); err != nil {
+		return ActivityStats{}, fmt.Errorf("count updated memories: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, user_id, agent_id, session_key, turn_id, field_path, operation, old_value, new_value, source, created_at_ms
+FROM persona_revisions
+WHERE created_at_ms >= ? AND created_at_ms < ?
+ORDER BY created_at_ms DESC
+LIMIT ?`, sinceMS, untilMS, activityPersonaChangeLimit)
+	if err != nil {
+		return ActivityStats{}, fmt.Errorf("list persona changes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rev PersonaRevision
+		if err := rows.Scan(&rev.ID, &rev.UserID, &rev.AgentID, &rev.SessionKey, &rev.TurnID, &rev.FieldPath, &rev.Operation, &rev.OldValue, &rev.NewValue, &rev.Source, &rev.CreatedAtMS); err != nil {
+			return ActivityStats{}, fmt.Errorf("scan persona change: %w", err)
+		}
+		stats.PersonaChanges = append(stats.PersonaChanges, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return ActivityStats{}, fmt.Errorf("iterate persona changes: %w", err)
+	}
+	return stats, nil
+}
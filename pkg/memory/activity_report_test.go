@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteStore_ActivityStats(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state", "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+
+	since := nowMS() - 60_000
+	for _, ev := range []Event{
+		{SessionKey: "cli:a", TurnID: "t1", Seq: 1, Role: "user", Content: "hi"},
+		{SessionKey: "cli:a", TurnID: "t1", Seq: 2, Role: "assistant", Content: "hello"},
+		{SessionKey: "cli:b", TurnID: "t2", Seq: 1, Role: "user", Content: "hey"},
+	} {
+		if err := store.AppendEvent(ctx, ev); err != nil {
+			t.Fatalf("append event: %v", err)
+		}
+	}
+	for _, key := range []string{"fact/new", "fact/old", "fact/gone"} {
+		if _, err := store.UpsertMemoryItem(ctx, MemoryItem{
+			UserID: "u1", AgentID: "dotagent", ScopeType: MemoryScopeUser, Kind: MemorySemanticFact,
+			Key: key, Content: "content " + key, Confidence: 0.9,
+		}); err != nil {
+			t.Fatalf("upsert memory: %v", err)
+		}
+	}
+	if _, err := store.db.ExecContext(ctx, `UPDATE memory_items SET first_seen_at_ms = 1000 WHERE item_key IN ('fact/old', 'fact/gone')`); err != nil {
+		t.Fatalf("age memories: %v", err)
+	}
+	if err := store.DeleteMemoryByKey(ctx, "u1", "dotagent", MemorySemanticFact, "fact/gone"); err != nil {
+		t.Fatalf("delete memory: %v", err)
+	}
+	if err := store.InsertPersonaRevision(ctx, PersonaRevision{
+		ID: "rev-1", UserID: "u1", AgentID: "dotagent", FieldPath: "user.name", Operation: "set", NewValue: "Riley", CreatedAtMS: nowMS(),
+	}); err != nil {
+		t.Fatalf("insert persona revision: %v", err)
+	}
+
+	stats, err := store.ActivityStats(ctx, since, nowMS()+1)
+	if err != nil {
+		t.Fatalf("activity stats: %v", err)
+	}
+	if stats.ActiveSessions != 2 || stats.NewSessions != 2 {
+		t.Fatalf("unexpected session counts: %+v", stats)
+	}
+	if stats.UserMessages != 2 || stats.AssistantMessages != 1 {
+		t.Fatalf("unexpected message counts: %+v", stats)
+	}
+	if stats.MemoriesCreated != 1 || stats.MemoriesUpdated != 1 || stats.MemoriesDeleted != 1 {
+		t.Fatalf("unexpected memory counts: %+v", stats)
+	}
+	if stats.PersonaRevisions != 1 || len(stats.PersonaChanges) != 1 || stats.PersonaChanges[0].NewValue != "Riley" {
+		t.Fatalf("unexpected persona changes: %+v", stats)
+	}
+
+	empty, err := store.ActivityStats(ctx, 0, since)
+	if err != nil {
+		t.Fatalf("activity stats before window: %v", err)
+	}
+	if empty.UserMessages != 0 || empty.PersonaRevisions != 0 || empty.MemoriesDeleted != 0 {
+		t.Fatalf("expected no activity before the window, got %+v", empty)
+	}
+}
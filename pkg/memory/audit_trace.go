@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AuditEntry is one row of the memory audit log.
+type AuditEntry struct {
+	ID          string            `json:"id"`
+	Action      string            `json:"action"`
+	Entity      string            `json:"entity"`
+	EntityID    string            `json:"entity_id,omitempty"`
+	SessionKey  string            `json:"session_key,omitempty"`
+	UserID      string            `json:"user_id,omitempty"`
+	AgentID     string            `json:"agent_id,omitempty"`
+	Reason      string            `json:"reason,omitempty"`
+	Payload     map[string]string `json:"payload,omitempty"`
+	TraceID     string            `json:"trace_id"`
+	CreatedAtMS int64             `json:"created_at_ms"`
+}
+
+// ErrAuditUnsupported is returned when the configured store cannot query the
+// audit log.
+var ErrAuditUnsupported = errors.New("memory store does not support audit queries")
+
+// ListAuditByTrace returns audit entries recorded while processing the request
+// with the given trace ID, oldest first.
+func (s *Service) ListAuditByTrace(ctx context.Context, traceID string, limit int) ([]AuditEntry, error) {
+	store, ok := s.store.(*SQLiteStore)
+	if !ok {
+		return nil, ErrAuditUnsupported
+	}
+	return store.ListAuditByTrace(ctx, traceID, limit)
+}
+
+// ListAuditByTrace returns up to limit audit entries tagged with traceID.
+func (s *SQLiteStore) ListAuditByTrace(ctx context.Context, traceID string, limit int) ([]AuditEntry, error) {
+	traceID = strings.TrimSpace(traceID)
+	if traceID == "" {
+		return nil, fmt.Errorf("trace id is required")
+	}
+	if limit <= 0 {
+		limit = 200
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, action, entity, entity_id, session_key, user_id, agent_id, reason, payload_json, trace_id, created_at_ms
+FROM memory_audit_log
+WHERE trace_id = ?
+ORDER BY created_at_ms ASC, id ASC
+LIMIT ?`, traceID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list audit by trace: %w", err)
+	}
+	defer rows.Close()
+
+	out := []AuditEntry{}
+	for rows.Next() {
+		var (
+			entry   AuditEntry
+			payload string
+		)
+		if err := rows.Scan(&entry.ID, &entry.Action, &entry.Entity, &entry.EntityID, &entry.SessionKey, &entry.UserID, &entry.AgentID, &entry.Reason, &payload, &entry.TraceID, &entry.CreatedAtMS); err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+		entry.Payload = decodeMap(payload)
+		out = append(out, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list audit by trace: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// RelationSemanticallyRelated links memory items whose content overlaps.
+	RelationSemanticallyRelated = "semantically_related"
+
+	autoLinkThreshold      = 0.4
+	autoLinkCandidateLimit = 500
+	autoLinkMaxPerItem     = 10
+	// autoLinkDelayMS lets the turn's other writes land before linking.
+	autoLinkDelayMS = 500
+)
+
+// AutoLinker connects newly written memory items to existing items with
+// similar content, so recall can follow memory_links between related facts.
+type AutoLinker struct {
+	store          Store
+	threshold      float64
+	candidateLimit int
+	maxPerItem     int
+}
+
+// NewAutoLinker creates an AutoLinker that links items whose token Jaccard
+// similarity exceeds 0.4.
+func NewAutoLinker(store Store) *AutoLinker {
+	return &AutoLinker{
+		store:          store,
+		threshold:      autoLinkThreshold,
+		candidateLimit: autoLinkCandidateLimit,
+		maxPerItem:     autoLinkMaxPerItem,
+	}
+}
+
+type autoLinkMatch struct {
+	item       MemoryItem
+	similarity float64
+}
+
+// LinkEvent links the memory items upserted from sourceEventID to the user's
+// other live items. Links are written in both directions with the similarity
+// as weight; re-running for the same event only refreshes the weights.
+// It returns the number of item pairs linked.
+func (l *AutoLinker) LinkEvent(ctx context.Context, userID, agentID, sourceEventID string) (int, error) {
+	sourceEventID = strings.TrimSpace(sourceEventID)
+	if sourceEventID == "" {
+		return 0, nil
+	}
+	candidates, err := l.store.ListMemoryCandidates(ctx, userID, agentID, "", l.candidateLimit)
+	if err != nil {
+		return 0, err
+	}
+	fresh := make([]MemoryItem, 0, 4)
+	for _, item := range candidates {
+		if item.SourceEventID == sourceEventID {
+			fresh = append(fresh, item)
+		}
+	}
+
+	linked := 0
+	seen := map[[2]string]struct{}{}
+	for _, item := range fresh {
+		matches := make([]autoLinkMatch, 0, 8)
+		for _, other := range candidates {
+			if other.ID == item.ID {
+				continue
+			}
+			if sim := textTokenJaccard(item.Content, other.Content); sim > l.threshold {
+				matches = append(matches, autoLinkMatch{item: other, similarity: sim})
+			}
+		}
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].similarity > matches[j].similarity })
+		if len(matches) > l.maxPerItem {
+			matches = matches[:l.maxPerItem]
+		}
+		for _, m := range matches {
+			pair := [2]string{item.ID, m.item.ID}
+			if pair[0] > pair[1] {
+				pair[0], pair[1] = pair[1], pair[0]
+			}
+			if _, ok := seen[pair]; ok {
+				continue
+			}
+			seen[pair] = struct{}{}
+			now := time.Now().UnixMilli()
+			for _, link := range []MemoryLink{
+				{FromItemID: item.ID, ToItemID: m.item.ID},
+				{FromItemID: m.item.ID, ToItemID: item.ID},
+			} {
+				link.Relation = RelationSemanticallyRelated
+				link.Weight = m.similarity
+				link.CreatedAtMS = now
+				if err := l.store.UpsertMemoryLink(ctx, link); err != nil {
+					return linked, err
+				}
+			}
+			linked++
+		}
+	}
+	return linked, nil
+}
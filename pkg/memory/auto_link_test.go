@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAutoLinker_LinksSimilarItemsBothWays(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+
+	upsert := func(key, content, eventID string) MemoryItem {
+		t.Helper()
+		item, err := store.UpsertMemoryItem(ctx, MemoryItem{
+			UserID: "u1", AgentID: "dotagent", ScopeType: MemoryScopeUser, Kind: MemorySemanticFact,
+			Key: key, Content: content, Confidence: 0.9, SourceEventID: eventID,
+		})
+		if err != nil {
+			t.Fatalf("upsert %s: %v", key, err)
+		}
+		return item
+	}
+	related := upsert("fact/garden", "user grows tomatoes in the backyard garden", "evt-old")
+	unrelated := upsert("fact/car", "user drives an electric car to work", "evt-old")
+	fresh := upsert("fact/tomatoes", "user grows cherry tomatoes in the garden", "evt-new")
+
+	linker := NewAutoLinker(store)
+	linked, err := linker.LinkEvent(ctx, "u1", "dotagent", "evt-new")
+	if err != nil {
+		t.Fatalf("link event: %v", err)
+	}
+	if linked != 1 {
+		t.Fatalf("expected one linked pair, got %d", linked)
+	}
+	want := textTokenJaccard(fresh.Content, related.Content)
+	for _, pair := range [][2]MemoryItem{{fresh, related}, {related, fresh}} {
+		links, err := store.ListMemoryLinks(ctx, pair[0].ID, 10)
+		if err != nil {
+			t.Fatalf("list links: %v", err)
+		}
+		if len(links) != 1 || links[0].ToItemID != pair[1].ID || links[0].Relation != RelationSemanticallyRelated || links[0].Weight != want {
+			t.Fatalf("unexpected links from %s: %+v", pair[0].Key, links)
+		}
+	}
+	if links, _ := store.ListMemoryLinks(ctx, unrelated.ID, 10); len(links) != 0 {
+		t.Fatalf("expected no links for unrelated item, got %+v", links)
+	}
+
+	// Re-running refreshes the existing links instead of duplicating them.
+	if _, err := linker.LinkEvent(ctx, "u1", "dotagent", "evt-new"); err != nil {
+		t.Fatalf("relink event: %v", err)
+	}
+	var n int
+	if err := store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM memory_links`).Scan(&n); err != nil {
+		t.Fatalf("count links: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 links after rerun, got %d", n)
+	}
+}
+
+func TestRecordUserTurn_SchedulesAutoLinkJob(t *testing.T) {
+	ctx := context.Background()
+	svc, err := NewService(Config{
+		Workspace:  t.TempDir(),
+		AgentID:    "dotagent",
+		WorkerPoll: 10 * time.Second, // keep jobs queued for assertion
+	}, nil)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	defer svc.Close()
+
+	sessionKey := "discord:autolink"
+	if err := svc.EnsureSession(ctx, sessionKey, "discord", "autolink", "u1"); err != nil {
+		t.Fatalf("ensure session: %v", err)
+	}
+	ev, inserted, err := svc.RecordUserTurn(ctx, Event{
+		SessionKey: sessionKey,
+		TurnID:     "turn-1",
+		Seq:        1,
+		Content:    "I really prefer pour-over coffee.",
+	}, "u1")
+	if err != nil || inserted == 0 {
+		t.Fatalf("record user turn: inserted=%d err=%v", inserted, err)
+	}
+
+	store := svc.store.(*SQLiteStore)
+	var payload string
+	if err := store.db.QueryRowContext(ctx, `SELECT payload_json FROM memory_jobs WHERE job_type = ? AND session_key = ?`, JobAutoLink, sessionKey).Scan(&payload); err != nil {
+		t.Fatalf("load auto link job: %v", err)
+	}
+	if !strings.Contains(payload, `"event_id":"`+ev.ID+`"`) {
+		t.Fatalf("unexpected auto link payload %s", payload)
+	}
+	job := Job{JobType: JobAutoLink, SessionKey: sessionKey, Payload: map[string]string{"event_id": ev.ID, "user_id": "u1"}}
+	if err := svc.handleJob(ctx, job); err != nil {
+		t.Fatalf("handle auto link job: %v", err)
+	}
+	if err := svc.handleJob(ctx, Job{JobType: JobAutoLink, SessionKey: sessionKey}); err == nil {
+		t.Fatalf("expected an error for an empty payload")
+	}
+}
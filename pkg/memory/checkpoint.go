@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Compaction is one row of the session_compactions table.
+type Compaction struct {
+	ID                 string
+	SessionKey         string
+	StartedAtMS        int64
+	CompletedAtMS      int64
+	Status             string
+	SourceEventCount   int
+	RetainedEventCount int
+	Summary            string
+	Checkpoint         map[string]string
+	Error              string
+	// Owner identifies the process that started the compaction as
+	// host:pid:token; empty for rows written before it was recorded.
+	Owner string
+}
+
+// compactionOwner is recorded on every compaction this process starts. The
+// random token tells a restarted process from its predecessor when the pid is
+// reused, as it always is for pid 1 in a container.
+var compactionOwner = func() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d:%s", host, os.Getpid(), uuid.NewString())
+}()
+
+// compactionInterruptedError is recorded on compactions that were still
+// running when the process that started them stopped.
+const compactionInterruptedError = "interrupted: the process running the compaction stopped"
+
+// ListStaleCompactions returns compactions still marked running that started
+// at or before staleThresholdMS, oldest first. These were left behind by a
+// process that stopped mid-compaction.
+func (s *SQLiteStore) ListStaleCompactions(ctx context.Context, staleThresholdMS int64) ([]Compaction, error) {
+	return listStaleCompactions(ctx, s.db, staleThresholdMS)
+}
+
+func (s *PostgreSQLStore) ListStaleCompactions(ctx context.Context, staleThresholdMS int64) ([]Compaction, error) {
+	return listStaleCompactions(ctx, s.db, staleThresholdMS)
+}
+
+func listStaleCompactions(ctx context.Context, db sqlQuerier, staleThresholdMS int64) ([]Compaction, error) {
+	rows, err := db.QueryContext(ctx, `
+SELECT id, session_key, started_at_ms, completed_at_ms, status, source_event_count, retained_event_count, summary, checkpoint_json, error, owner
+FROM session_compactions
+WHERE status = ? AND completed_at_ms = 0 AND started_at_ms <= ?
+ORDER BY started_at_ms ASC`, JobRunning, staleThresholdMS)
+	if err != nil {
+		return nil, fmt.Errorf("list stale compactions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Compaction
+	for rows.Next() {
+		var (
+			c          Compaction
+			checkpoint string
+		)
+		if err := rows.Scan(&c.ID, &c.SessionKey, &c.StartedAtMS, &c.CompletedAtMS, &c.Status, &c.SourceEventCount, &c.RetainedEventCount, &c.Summary, &checkpoint, &c.Error, &c.Owner); err != nil {
+			return nil, fmt.Errorf("scan stale compaction: %w", err)
+		}
+		c.Checkpoint = decodeMap(checkpoint)
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list stale compactions: %w", err)
+	}
+	return out, nil
+}
+
+// compactionOwnerAlive reports whether the process that started a compaction
+// is still running. known is false when that cannot be checked from here:
+// rows without an owner and rows started on another host.
+func compactionOwnerAlive(owner string) (alive, known bool) {
+	if owner == compactionOwner {
+		return true, true
+	}
+	parts := strings.Split(owner, ":")
+	if len(parts) != 3 {
+		return false, false
+	}
+	host, _ := os.Hostname()
+	pid, err := strconv.Atoi(parts[1])
+	if parts[0] != host || err != nil {
+		return false, false
+	}
+	if pid == os.Getpid() {
+		// Same pid, different token: an earlier process that has exited.
+		return false, true
+	}
+	if runtime.GOOS == "windows" {
+		// processIsAlive cannot tell on windows.
+		return false, false
+	}
+	return processIsAlive(pid), true
+}
+
+func processIsAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// os.FindProcess succeeds even for dead pids on windows and signal(0) is unsupported.
+		return true
+	}
+	err = proc.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// recoverStaleCompactions marks compactions abandoned by a stopped process as
+// failed and re-enqueues one compact job per affected session. A running
+// compaction is abandoned when its owner is no longer alive; when the owner
+// cannot be checked it is only treated as abandoned once it started at or
+// before staleThresholdMS. It runs once at startup, before the worker claims
+// any jobs.
+func (s *Service) recoverStaleCompactions(ctx context.Context, staleThresholdMS int64) int {
+	running, err := s.store.ListStaleCompactions(ctx, time.Now().UnixMilli())
+	if err != nil {
+		return 0
+	}
+	var stale []Compaction
+	for _, c := range running {
+		alive, known := compactionOwnerAlive(c.Owner)
+		if (known && !alive) || (!known && c.StartedAtMS <= staleThresholdMS) {
+			stale = append(stale, c)
+		}
+	}
+	if len(stale) == 0 {
+		return 0
+	}
+
+	now := time.Now().UnixMilli()
+	requeued := map[string]bool{}
+	for _, c := range stale {
+		if err := s.store.FailCompaction(ctx, c.ID, compactionInterruptedError); err != nil {
+			continue
+		}
+		if requeued[c.SessionKey] {
+			continue
+		}
+		sess, err := s.store.GetSession(ctx, c.SessionKey)
+		if err != nil || sess.UserID == "" {
+			continue
+		}
+		if err := s.store.EnqueueJob(ctx, Job{
+			ID:         maintenanceJobID(JobCompact, c.SessionKey, ""),
+			JobType:    JobCompact,
+			SessionKey: c.SessionKey,
+			Status:     JobPending,
+			Priority:   80,
+			Payload: map[string]string{
+				"user_id": sess.UserID,
+			},
+			RunAfterMS:  now,
+			CreatedAtMS: now,
+			UpdatedAtMS: now,
+		}); err != nil {
+			continue
+		}
+		requeued[c.SessionKey] = true
+	}
+	_ = s.store.AddMetric(ctx, "memory.compaction.recovered", float64(len(stale)), nil)
+	return len(requeued)
+}
@@ -0,0 +1,213 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// consistencyCheckInterval is how often the worker schedules a
+	// consistency check job.
+	consistencyCheckInterval = 7 * 24 * time.Hour
+	// consistencyCheckDelay holds a newly scheduled check back so it does
+	// not compete with the jobs queued at startup.
+	consistencyCheckDelay = 5 * time.Minute
+)
+
+// orphanChecks lists the rows that point at memory items which no longer
+// exist. Rows of soft-deleted items are kept, since the item can still be
+// restored.
+var orphanChecks = []struct {
+	kind  string
+	table string
+	where string
+}{
+	{
+		kind:  "links",
+		table: "memory_links",
+		where: `NOT EXISTS (SELECT 1 FROM memory_items m WHERE m.id = memory_links.from_item_id)
+	OR NOT EXISTS (SELECT 1 FROM memory_items m WHERE m.id = memory_links.to_item_id)`,
+	},
+	{
+		kind:  "embeddings",
+		table: "memory_embeddings",
+		where: `NOT EXISTS (SELECT 1 FROM memory_items m WHERE m.id = memory_embeddings.item_id)`,
+	},
+	{
+		kind:  "observations",
+		table: "memory_observations",
+		where: `NOT EXISTS (SELECT 1 FROM memory_items m WHERE m.id = memory_observations.item_id)`,
+	},
+}
+
+// ConsistencyReport counts orphaned rows found by a consistency check.
+type ConsistencyReport struct {
+	Orphans  map[string]int64 // by kind: links, embeddings, observations
+	Repaired bool
+}
+
+// Total returns the number of orphaned rows across all kinds.
+func (r ConsistencyReport) Total() int64 {
+	var total int64
+	for _, n := range r.Orphans {
+		total += n
+	}
+	return total
+}
+
+// ConsistencyChecker finds links, embeddings and observations that reference
+// missing memory items and, with autoRepair, deletes them.
+type ConsistencyChecker struct {
+	db         sqlTx
+	begin      func(context.Context) (repairTx, error)
+	autoRepair bool
+}
+
+// repairTx is the transaction a repair runs in.
+type repairTx interface {
+	sqlTx
+	Commit() error
+	Rollback() error
+}
+
+func NewConsistencyChecker(db sqlTx, begin func(context.Context) (repairTx, error), autoRepair bool) *ConsistencyChecker {
+	return &ConsistencyChecker{db: db, begin: begin, autoRepair: autoRepair}
+}
+
+// Check counts orphaned rows and deletes them when auto repair is enabled.
+func (c *ConsistencyChecker) Check(ctx context.Context) (ConsistencyReport, error) {
+	report := ConsistencyReport{Orphans: map[string]int64{}}
+	for _, check := range orphanChecks {
+		var n int64
+		if err := c.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+check.table+` WHERE `+check.where).Scan(&n); err != nil {
+			return report, fmt.Errorf("count orphaned %s: %w", check.kind, err)
+		}
+		report.Orphans[check.kind] = n
+	}
+	if !c.autoRepair || report.Total() == 0 {
+		return report, nil
+	}
+	if err := c.repair(ctx, report); err != nil {
+		return report, err
+	}
+	report.Repaired = true
+	return report, nil
+}
+
+// repair deletes the orphans report found and records the repair in the
+// audit log in one transaction, so a failure part way leaves nothing removed.
+func (c *ConsistencyChecker) repair(ctx context.Context, report ConsistencyReport) error {
+	tx, err := c.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("consistency repair begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	for _, check := range orphanChecks {
+		if report.Orphans[check.kind] == 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+check.table+` WHERE `+check.where); err != nil {
+			return fmt.Errorf("delete orphaned %s: %w", check.kind, err)
+		}
+	}
+	if err := invalidateRetrievalCacheTx(ctx, tx); err != nil {
+		return err
+	}
+	payload := map[string]string{}
+	for kind, n := range report.Orphans {
+		payload[kind] = strconv.FormatInt(n, 10)
+	}
+	if err := insertAuditLogTx(ctx, tx, "consistency_repair", "memory_graph", "", "", "", "", "orphaned_rows", payload); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("consistency repair commit: %w", err)
+	}
+	return nil
+}
+
+// consistencyChecker returns a checker over the store's database, or nil for
+// stores it cannot inspect.
+func (s *Service) consistencyChecker() *ConsistencyChecker {
+	switch store := s.store.(type) {
+	case *SQLiteStore:
+		return NewConsistencyChecker(store.db, func(ctx context.Context) (repairTx, error) {
+			tx, err := store.db.BeginTx(ctx, nil)
+			if err != nil {
+				return nil, err
+			}
+			return tx, nil
+		}, s.cfg.AutoRepair)
+	case *PostgreSQLStore:
+		return NewConsistencyChecker(store.db, func(ctx context.Context) (repairTx, error) {
+			tx, err := store.db.BeginTx(ctx, nil)
+			if err != nil {
+				return nil, err
+			}
+			return tx, nil
+		}, s.cfg.AutoRepair)
+	default:
+		return nil
+	}
+}
+
+// runConsistencyCheck checks the memory graph and records the orphans found
+// per kind as the memory.consistency.orphans metric.
+func (s *Service) runConsistencyCheck(ctx context.Context) (ConsistencyReport, error) {
+	checker := s.consistencyChecker()
+	if checker == nil {
+		return ConsistencyReport{}, nil
+	}
+	report, err := checker.Check(ctx)
+	if err != nil {
+		return report, err
+	}
+	for kind, n := range report.Orphans {
+		_ = s.store.AddMetric(ctx, "memory.consistency.orphans", float64(n), map[string]string{
+			"kind":     kind,
+			"repaired": strconv.FormatBool(report.Repaired),
+		})
+	}
+	return report, nil
+}
+
+// scheduleConsistencyCheckIfDue enqueues one consistency check job per week.
+// The job ID is derived from the week, so restarts within a week do not
+// schedule it again.
+func (s *Service) scheduleConsistencyCheckIfDue(ctx context.Context, nowMS int64) {
+	checker := s.consistencyChecker()
+	if checker == nil {
+		return
+	}
+	period := nowMS / consistencyCheckInterval.Milliseconds()
+	if period == s.lastConsistencyPeriod {
+		return
+	}
+	id := maintenanceJobID(JobConsistencyCheck, s.cfg.AgentID, strconv.FormatInt(period, 10))
+	var exists int
+	err := checker.db.QueryRowContext(ctx, `SELECT 1 FROM memory_jobs WHERE id = ?`, id).Scan(&exists)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+	if err == nil {
+		s.lastConsistencyPeriod = period
+		return
+	}
+	if err := s.store.EnqueueJob(ctx, Job{
+		ID:          id,
+		JobType:     JobConsistencyCheck,
+		SessionKey:  s.cfg.AgentID,
+		Status:      JobPending,
+		Priority:    90,
+		RunAfterMS:  nowMS + consistencyCheckDelay.Milliseconds(),
+		CreatedAtMS: nowMS,
+		UpdatedAtMS: nowMS,
+	}); err != nil {
+		return
+	}
+	s.lastConsistencyPeriod = period
+}
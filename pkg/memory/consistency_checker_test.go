@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConsistencyChecker_FindsAndRepairsOrphans(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state", "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+	// A bare service keeps the background worker from running checks too.
+	svc := &Service{cfg: Config{AgentID: "dotagent"}, store: store}
+
+	live, err := store.UpsertMemoryItem(ctx, MemoryItem{
+		UserID: "u1", AgentID: "dotagent", ScopeType: MemoryScopeUser,
+		Kind: MemorySemanticFact, Key: "editor", Content: "Uses Helix", Confidence: 0.9,
+	})
+	if err != nil {
+		t.Fatalf("upsert live item: %v", err)
+	}
+	gone, err := store.UpsertMemoryItem(ctx, MemoryItem{
+		UserID: "u1", AgentID: "dotagent", ScopeType: MemoryScopeUser,
+		Kind: MemorySemanticFact, Key: "shell", Content: "Uses fish", Confidence: 0.9,
+	})
+	if err != nil {
+		t.Fatalf("upsert deleted item: %v", err)
+	}
+	if err := store.DeleteMemoryByKey(ctx, "u1", "dotagent", MemorySemanticFact, "shell"); err != nil {
+		t.Fatalf("delete item: %v", err)
+	}
+	for _, stmt := range []string{
+		`INSERT INTO memory_links(id, from_item_id, to_item_id, relation, created_at_ms) VALUES('link-ok', '` + live.ID + `', '` + live.ID + `', 'self', 1)`,
+		`INSERT INTO memory_links(id, from_item_id, to_item_id, relation, created_at_ms) VALUES('link-deleted', '` + live.ID + `', '` + gone.ID + `', 'related', 1)`,
+		`INSERT INTO memory_links(id, from_item_id, to_item_id, relation, created_at_ms) VALUES('link-missing', '` + live.ID + `', 'mem-missing', 'related', 1)`,
+		`INSERT INTO memory_embeddings(item_id, model, vector_json, updated_at_ms) VALUES('` + gone.ID + `', 'm', '[1]', 1)`,
+		`INSERT INTO memory_embeddings(item_id, model, vector_json, updated_at_ms) VALUES('mem-missing', 'm', '[1]', 1)`,
+		`INSERT INTO memory_observations(id, item_id, observed_at_ms) VALUES('obs-missing', 'mem-missing', 1)`,
+	} {
+		if _, err := store.db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("seed %q: %v", stmt, err)
+		}
+	}
+
+	report, err := svc.runConsistencyCheck(ctx)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if report.Repaired || report.Orphans["links"] != 1 || report.Orphans["embeddings"] != 1 || report.Orphans["observations"] != 1 {
+		t.Fatalf("unexpected report without auto repair: %+v", report)
+	}
+	var metrics int
+	if err := store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM memory_metrics WHERE metric = 'memory.consistency.orphans'`).Scan(&metrics); err != nil || metrics != 3 {
+		t.Fatalf("expected one orphan metric per kind, got %d (err=%v)", metrics, err)
+	}
+
+	svc.cfg.AutoRepair = true
+	if report, err = svc.runConsistencyCheck(ctx); err != nil || !report.Repaired {
+		t.Fatalf("expected repair, got %+v (err=%v)", report, err)
+	}
+	report, err = svc.runConsistencyCheck(ctx)
+	if err != nil || report.Total() != 0 {
+		t.Fatalf("expected no orphans after repair, got %+v (err=%v)", report, err)
+	}
+	var links int
+	if err := store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM memory_links`).Scan(&links); err != nil || links != 2 {
+		t.Fatalf("expected the valid and soft-deleted links to be kept, got %d (err=%v)", links, err)
+	}
+	var embeddings int
+	if err := store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM memory_embeddings WHERE item_id = ?`, gone.ID).Scan(&embeddings); err != nil || embeddings != 1 {
+		t.Fatalf("expected the soft-deleted item's embedding to be kept for restore, got %d (err=%v)", embeddings, err)
+	}
+}
+
+func TestService_SchedulesConsistencyCheckOncePerWeek(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state", "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+	// A bare service keeps the background worker from running checks too.
+	svc := &Service{cfg: Config{AgentID: "dotagent"}, store: store}
+
+	countJobs := func() int {
+		t.Helper()
+		var n int
+		if err := store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM memory_jobs WHERE job_type = ?`, JobConsistencyCheck).Scan(&n); err != nil {
+			t.Fatalf("count jobs: %v", err)
+		}
+		return n
+	}
+	now := time.Now().UnixMilli()
+	svc.scheduleConsistencyCheckIfDue(ctx, now)
+	if got := countJobs(); got != 1 {
+		t.Fatalf("expected this week's check to be scheduled, got %d", got)
+	}
+	// A restart forgets the period but finds the week's job already queued.
+	svc.lastConsistencyPeriod = 0
+	svc.scheduleConsistencyCheckIfDue(ctx, now)
+	if got := countJobs(); got != 1 {
+		t.Fatalf("expected an existing job for this week to be reused, got %d", got)
+	}
+	svc.scheduleConsistencyCheckIfDue(ctx, now+consistencyCheckInterval.Milliseconds())
+	if got := countJobs(); got != 2 {
+		t.Fatalf("expected next week's check to be scheduled, got %d", got)
+	}
+}
@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	consolidationRateWindow  = time.Minute
+	consolidationMinInterval = 5 * time.Minute
+	// consolidationSchedulerMaxSessions bounds the per-session state; idle
+	// sessions are pruned once it is exceeded.
+	consolidationSchedulerMaxSessions = 1024
+)
+
+// ConsolidationScheduler throttles per-turn consolidation for busy sessions.
+// It counts messages per session over the last minute; while a session is
+// above maxRate messages per minute, consolidation runs at most once every
+// minInterval. Turns throttled this way are deferred to the end of the
+// window rather than dropped. A maxRate of zero or less disables throttling.
+type ConsolidationScheduler struct {
+	maxRate     float64
+	minInterval time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*sessionConsolidationState
+}
+
+type sessionConsolidationState struct {
+	messages          []time.Time
+	lastConsolidation time.Time
+}
+
+func NewConsolidationScheduler(maxRate float64, minInterval time.Duration) *ConsolidationScheduler {
+	if minInterval <= 0 {
+		minInterval = consolidationMinInterval
+	}
+	return &ConsolidationScheduler{
+		maxRate:     maxRate,
+		minInterval: minInterval,
+		sessions:    map[string]*sessionConsolidationState{},
+	}
+}
+
+// ConsolidateAt records a turn for sessionKey at now and returns when its
+// consolidation should run: now, or the end of the throttle window for a busy
+// session.
+func (cs *ConsolidationScheduler) ConsolidateAt(sessionKey string, now time.Time) time.Time {
+	if cs == nil {
+		return now
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	st := cs.sessions[sessionKey]
+	if st == nil {
+		if len(cs.sessions) >= consolidationSchedulerMaxSessions {
+			cs.pruneLocked(now)
+		}
+		st = &sessionConsolidationState{}
+		cs.sessions[sessionKey] = st
+	}
+	st.messages = append(trimBefore(st.messages, now.Add(-consolidationRateWindow)), now)
+
+	if cs.maxRate > 0 && float64(len(st.messages)) > cs.maxRate && now.Sub(st.lastConsolidation) < cs.minInterval {
+		return st.lastConsolidation.Add(cs.minInterval)
+	}
+	st.lastConsolidation = now
+	return now
+}
+
+// Rate returns the messages per minute recorded for sessionKey.
+func (cs *ConsolidationScheduler) Rate(sessionKey string, now time.Time) int {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	st := cs.sessions[sessionKey]
+	if st == nil {
+		return 0
+	}
+	st.messages = trimBefore(st.messages, now.Add(-consolidationRateWindow))
+	return len(st.messages)
+}
+
+// pruneLocked drops sessions whose state can no longer affect a decision.
+func (cs *ConsolidationScheduler) pruneLocked(now time.Time) {
+	for key, st := range cs.sessions {
+		idle := len(st.messages) == 0 || now.Sub(st.messages[len(st.messages)-1]) >= consolidationRateWindow
+		if idle && now.Sub(st.lastConsolidation) >= cs.minInterval {
+			delete(cs.sessions, key)
+		}
+	}
+}
+
+func trimBefore(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	return times[i:]
+}
@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConsolidationScheduler_ThrottlesBusySessions(t *testing.T) {
+	cs := NewConsolidationScheduler(3, 5*time.Minute)
+	start := time.Unix(1_700_000_000, 0)
+	immediate := func(sessionKey string, at time.Time) bool {
+		return !cs.ConsolidateAt(sessionKey, at).After(at)
+	}
+
+	// The first three messages in a minute stay under the rate.
+	for i := 0; i < 3; i++ {
+		if !immediate("s1", start.Add(time.Duration(i)*10*time.Second)) {
+			t.Fatalf("message %d: expected consolidation under the rate limit", i+1)
+		}
+	}
+	// The fourth exceeds 3/min and the last consolidation was seconds ago, so
+	// it waits for the window that started then to end.
+	if got, want := cs.ConsolidateAt("s1", start.Add(30*time.Second)), start.Add(5*time.Minute+20*time.Second); !got.Equal(want) {
+		t.Fatalf("expected consolidation deferred to %s, got %s", want, got)
+	}
+	if got := cs.Rate("s1", start.Add(30*time.Second)); got != 4 {
+		t.Fatalf("expected rate 4, got %d", got)
+	}
+	// Other sessions are tracked independently.
+	if !immediate("s2", start.Add(30*time.Second)) {
+		t.Fatal("expected a quiet session to consolidate")
+	}
+
+	// A steady message every 10s keeps the session busy; the next
+	// consolidation happens five minutes after the last one (at 20s).
+	for offset := 40 * time.Second; offset <= 5*time.Minute+30*time.Second; offset += 10 * time.Second {
+		got := immediate("s1", start.Add(offset))
+		if want := offset == 5*time.Minute+20*time.Second; got != want {
+			t.Fatalf("at %s: expected consolidate=%v, got %v", offset, want, got)
+		}
+	}
+
+	// Once the rate drops, every turn consolidates.
+	if !immediate("s1", start.Add(8*time.Minute)) {
+		t.Fatal("expected consolidation after the rate drops")
+	}
+}
+
+func TestConsolidationScheduler_DisabledWithZeroRate(t *testing.T) {
+	cs := NewConsolidationScheduler(0, 5*time.Minute)
+	now := time.Unix(1_700_000_000, 0)
+	for i := 0; i < 20; i++ {
+		at := now.Add(time.Duration(i) * time.Second)
+		if got := cs.ConsolidateAt("s1", at); !got.Equal(at) {
+			t.Fatalf("message %d: expected no throttling when disabled", i+1)
+		}
+	}
+}
@@ -13,12 +13,15 @@ import (
 
 // HeuristicConsolidator extracts durable memories from turns.
 type HeuristicConsolidator struct {
-	store  Store
-	policy Policy
+	store      Store
+	policy     Policy
+	embeddings EmbeddingProvider
 }
 
-func NewHeuristicConsolidator(store Store, policy Policy) *HeuristicConsolidator {
-	return &HeuristicConsolidator{store: store, policy: policy}
+// NewHeuristicConsolidator embeds captured memories with embeddings, or with
+// the local bag-of-words embedder when it is nil.
+func NewHeuristicConsolidator(store Store, policy Policy, embeddings EmbeddingProvider) *HeuristicConsolidator {
+	return &HeuristicConsolidator{store: store, policy: policy, embeddings: embeddingsOrDefault(embeddings)}
 }
 
 func (c *HeuristicConsolidator) ConsolidateTurn(ctx context.Context, sessionKey, turnID, userID, agentID string) error {
@@ -131,8 +134,11 @@ func (c *HeuristicConsolidator) ConsolidateTurn(ctx context.Context, sessionKey,
 		if err != nil {
 			return err
 		}
-		vec := embedText(item.Content)
-		if err := c.store.UpsertEmbedding(ctx, item.ID, currentEmbeddingModel(), vec); err != nil {
+		vec, model, err := c.embeddings.Embed(ctx, item.Content)
+		if err != nil {
+			return err
+		}
+		if err := c.store.UpsertEmbedding(ctx, item.ID, model, vec); err != nil {
 			return err
 		}
 		inserted = append(inserted, item)
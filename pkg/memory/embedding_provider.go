@@ -0,0 +1,184 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// EmbeddingProvider turns text into a vector and reports the model that
+// produced it, so stored vectors can be matched to the model used at query
+// time.
+type EmbeddingProvider interface {
+	Embed(ctx context.Context, text string) ([]float32, string, error)
+}
+
+// Embedding backends accepted by Config.EmbeddingBackend. The backend's model
+// is moved to the front of the embedding fallback chain; an empty backend
+// follows the chain's first model.
+const (
+	EmbeddingBackendBOW        = "bow"
+	EmbeddingBackendOpenAI     = embeddingProviderOpenAI
+	EmbeddingBackendOpenRouter = embeddingProviderOpenRouter
+	EmbeddingBackendOllama     = embeddingProviderOllama
+)
+
+// defaultBackendEmbeddingModels is used when a remote backend is selected but
+// the configured embedding model belongs to another provider.
+var defaultBackendEmbeddingModels = map[string]string{
+	EmbeddingBackendOpenAI:     "openai:text-embedding-3-small",
+	EmbeddingBackendOpenRouter: "openrouter:openai/text-embedding-3-small",
+	EmbeddingBackendOllama:     "ollama:nomic-embed-text",
+}
+
+// BOWEmbeddingProvider embeds text locally with the bag-of-words chargram or
+// hash embedders. It needs no network access and is the fallback for the
+// remote providers.
+type BOWEmbeddingProvider struct {
+	embedder Embedder
+}
+
+// NewBOWEmbeddingProvider returns a local provider for model, or for the
+// process-wide local embedder when model is not a local model.
+func NewBOWEmbeddingProvider(model string) *BOWEmbeddingProvider {
+	embedder, _, ok := newEmbedderByName(model)
+	if !ok {
+		embedder = nil
+	}
+	return &BOWEmbeddingProvider{embedder: embedder}
+}
+
+func (p *BOWEmbeddingProvider) Embed(ctx context.Context, text string) ([]float32, string, error) {
+	embedder := p.embedder
+	if embedder == nil {
+		embedder = currentEmbedder()
+	}
+	return embedder.Embed(text), embedder.ModelID(), nil
+}
+
+// remoteEmbeddingProvider embeds through the EmbeddingEngine, which batches,
+// truncates and caches requests, and falls back to local embeddings when the
+// remote call fails.
+type remoteEmbeddingProvider struct {
+	engine   *EmbeddingEngine
+	spec     embeddingModelSpec
+	fallback EmbeddingProvider
+}
+
+func (p *remoteEmbeddingProvider) Embed(ctx context.Context, text string) ([]float32, string, error) {
+	vectors, err := p.engine.embedBatchForModel(ctx, p.spec, []string{text})
+	if err == nil && len(vectors) == 1 && len(vectors[0]) > 0 {
+		return vectors[0], p.spec.Raw, nil
+	}
+	if p.fallback != nil {
+		return p.fallback.Embed(ctx, text)
+	}
+	if err == nil {
+		err = fmt.Errorf("embedding model %s returned no vector", p.spec.Raw)
+	}
+	return nil, "", err
+}
+
+// OpenAIEmbeddingProvider embeds with the OpenAI embeddings API.
+type OpenAIEmbeddingProvider struct{ remoteEmbeddingProvider }
+
+// OpenRouterEmbeddingProvider embeds with the OpenRouter embeddings API.
+type OpenRouterEmbeddingProvider struct{ remoteEmbeddingProvider }
+
+// OllamaEmbeddingProvider embeds with a local Ollama server.
+type OllamaEmbeddingProvider struct{ remoteEmbeddingProvider }
+
+func NewOpenAIEmbeddingProvider(engine *EmbeddingEngine, model string) *OpenAIEmbeddingProvider {
+	return &OpenAIEmbeddingProvider{newRemoteEmbeddingProvider(engine, embeddingProviderOpenAI, model)}
+}
+
+func NewOpenRouterEmbeddingProvider(engine *EmbeddingEngine, model string) *OpenRouterEmbeddingProvider {
+	return &OpenRouterEmbeddingProvider{newRemoteEmbeddingProvider(engine, embeddingProviderOpenRouter, model)}
+}
+
+func NewOllamaEmbeddingProvider(engine *EmbeddingEngine, model string) *OllamaEmbeddingProvider {
+	return &OllamaEmbeddingProvider{newRemoteEmbeddingProvider(engine, embeddingProviderOllama, model)}
+}
+
+func newRemoteEmbeddingProvider(engine *EmbeddingEngine, provider, model string) remoteEmbeddingProvider {
+	model = strings.TrimSpace(model)
+	return remoteEmbeddingProvider{
+		engine:   engine,
+		spec:     embeddingModelSpec{Provider: provider, Model: model, Raw: provider + ":" + model},
+		fallback: NewBOWEmbeddingProvider(""),
+	}
+}
+
+// embeddingModelForBackend returns the model an explicitly selected backend
+// embeds with: the configured model when it already belongs to the backend,
+// otherwise the backend's default model.
+func embeddingModelForBackend(backend, model string) (string, error) {
+	backend = strings.ToLower(strings.TrimSpace(backend))
+	spec, err := parseEmbeddingModelSpec(model)
+	switch backend {
+	case EmbeddingBackendBOW:
+		if err == nil && spec.Provider == embeddingProviderLocal {
+			return spec.Raw, nil
+		}
+		return defaultEmbeddingModel, nil
+	case EmbeddingBackendOpenAI, EmbeddingBackendOpenRouter, EmbeddingBackendOllama:
+		if err == nil && spec.Provider == backend {
+			return spec.Raw, nil
+		}
+		return defaultBackendEmbeddingModels[backend], nil
+	default:
+		return "", fmt.Errorf("unknown embedding backend %q (want bow, openai, openrouter or ollama)", backend)
+	}
+}
+
+// newEmbeddingProvider returns the provider for an embedding model spec.
+func newEmbeddingProvider(model string, engine *EmbeddingEngine) (EmbeddingProvider, error) {
+	spec, err := parseEmbeddingModelSpec(model)
+	if err != nil {
+		return nil, err
+	}
+	switch spec.Provider {
+	case embeddingProviderLocal:
+		return NewBOWEmbeddingProvider(spec.Model), nil
+	case embeddingProviderOpenAI:
+		return NewOpenAIEmbeddingProvider(engine, spec.Model), nil
+	case embeddingProviderOpenRouter:
+		return NewOpenRouterEmbeddingProvider(engine, spec.Model), nil
+	case embeddingProviderOllama:
+		return NewOllamaEmbeddingProvider(engine, spec.Model), nil
+	default:
+		return nil, fmt.Errorf("unsupported embedding provider %s", spec.Provider)
+	}
+}
+
+func embeddingsOrDefault(p EmbeddingProvider) EmbeddingProvider {
+	if p == nil {
+		return NewBOWEmbeddingProvider("")
+	}
+	return p
+}
+
+type opEmbedding struct {
+	vector []float32
+	model  string
+}
+
+// embedOps embeds the content of the ops that store a memory, indexed like
+// ops. Stores call it before opening a transaction so that a remote provider
+// is never awaited while the write lock is held.
+func embedOps(ctx context.Context, embeddings EmbeddingProvider, ops []ConsolidationOp) ([]opEmbedding, error) {
+	embeddings = embeddingsOrDefault(embeddings)
+	out := make([]opEmbedding, len(ops))
+	for i, op := range ops {
+		content := strings.TrimSpace(op.Content)
+		if strings.TrimSpace(op.Key) == "" || content == "" || strings.EqualFold(strings.TrimSpace(op.Action), "delete") {
+			continue
+		}
+		vec, model, err := embeddings.Embed(ctx, content)
+		if err != nil {
+			return nil, fmt.Errorf("embed memory %q: %w", op.Key, err)
+		}
+		out[i] = opEmbedding{vector: vec, model: model}
+	}
+	return out, nil
+}
@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEmbeddingModelForBackend(t *testing.T) {
+	cases := []struct {
+		backend, model, want string
+	}{
+		{backend: "bow", model: "openai:text-embedding-3-small", want: defaultEmbeddingModel},
+		{backend: "bow", model: hashEmbeddingModel, want: hashEmbeddingModel},
+		{backend: "ollama", model: "ollama:mxbai-embed-large", want: "ollama:mxbai-embed-large"},
+		{backend: "ollama", model: defaultEmbeddingModel, want: "ollama:nomic-embed-text"},
+		{backend: "OpenRouter", model: defaultEmbeddingModel, want: "openrouter:openai/text-embedding-3-small"},
+	}
+	for _, tc := range cases {
+		got, err := embeddingModelForBackend(tc.backend, tc.model)
+		if err != nil || got != tc.want {
+			t.Fatalf("embeddingModelForBackend(%q, %q) = %q, %v; want %q", tc.backend, tc.model, got, err, tc.want)
+		}
+	}
+	if _, err := embeddingModelForBackend("cohere", defaultEmbeddingModel); err == nil {
+		t.Fatalf("expected unknown backend to fail")
+	}
+}
+
+func TestRemoteEmbeddingProvider_FallsBackToBOW(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	engine := NewEmbeddingEngine(EmbeddingEngineConfig{OllamaAPIBase: server.URL})
+	provider := NewOllamaEmbeddingProvider(engine, "nomic-embed-text")
+	vec, model, err := provider.Embed(context.Background(), "prefers dark mode")
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if model != currentEmbeddingModel() || len(vec) == 0 {
+		t.Fatalf("expected local fallback embedding, got model %q with %d dims", model, len(vec))
+	}
+}
+
+func TestService_EmbeddingBackendSelectsProvider(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"embeddings":[[0.6,0.8]]}`))
+	}))
+	defer server.Close()
+
+	svc, err := NewService(Config{
+		Workspace:              t.TempDir(),
+		AgentID:                "dotagent",
+		EmbeddingBackend:       "ollama",
+		EmbeddingOllamaAPIBase: server.URL,
+		WorkerPoll:             time.Hour,
+	}, nil)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	defer svc.Close()
+
+	if _, ok := svc.embeddings.(*OllamaEmbeddingProvider); !ok {
+		t.Fatalf("expected ollama provider, got %T", svc.embeddings)
+	}
+	if got := svc.primaryEmbeddingModel(); got != "ollama:nomic-embed-text" {
+		t.Fatalf("expected the backend's model first in the chain, got %q", got)
+	}
+	vec, model, err := svc.embeddings.Embed(context.Background(), "uses helix")
+	if err != nil || model != "ollama:nomic-embed-text" || len(vec) != 2 || calls == 0 {
+		t.Fatalf("unexpected embedding %v from %q (calls=%d, err=%v)", vec, model, calls, err)
+	}
+
+	if _, err := NewService(Config{Workspace: t.TempDir(), EmbeddingBackend: "cohere"}, nil); err == nil {
+		t.Fatalf("expected unknown embedding backend to fail")
+	}
+}
+
+func TestEmbedOps_SkipsDeletesAndEmptyContent(t *testing.T) {
+	ops := []ConsolidationOp{
+		{Action: "upsert", Kind: MemorySemanticFact, Key: "editor", Content: "Uses Helix"},
+		{Action: "delete", Kind: MemorySemanticFact, Key: "shell", Content: "fish"},
+		{Action: "upsert", Kind: MemorySemanticFact, Key: "empty", Content: "  "},
+	}
+	vectors, err := embedOps(context.Background(), nil, ops)
+	if err != nil {
+		t.Fatalf("embed ops: %v", err)
+	}
+	if len(vectors[0].vector) == 0 || vectors[0].model != currentEmbeddingModel() {
+		t.Fatalf("expected the upsert to be embedded, got %+v", vectors[0])
+	}
+	if vectors[1].vector != nil || vectors[2].vector != nil {
+		t.Fatalf("expected delete and empty ops to be skipped, got %+v", vectors[1:])
+	}
+}
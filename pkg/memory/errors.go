@@ -6,4 +6,6 @@ var (
 	// ErrContinuityUnavailable indicates prompt context could not be assembled
 	// with enough prior state to answer safely for an existing conversation.
 	ErrContinuityUnavailable = errors.New("memory continuity unavailable")
+	// ErrSessionNotFound indicates the requested session key has no session.
+	ErrSessionNotFound = errors.New("memory session not found")
 )
@@ -0,0 +1,191 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// EventSearchOptions narrows a conversation search. Zero values mean no
+// filter; Limit defaults to 20.
+type EventSearchOptions struct {
+	UserID string
+	FromMS int64
+	ToMS   int64
+	Limit  int
+}
+
+// EventMatch is one user or assistant message that matched a search.
+type EventMatch struct {
+	EventID     string `json:"event_id"`
+	SessionKey  string `json:"session_key"`
+	Role        string `json:"role"`
+	Snippet     string `json:"snippet"`
+	CreatedAtMS int64  `json:"created_at_ms"`
+}
+
+// SessionMatches groups search hits from one session.
+type SessionMatches struct {
+	SessionKey string       `json:"session_key"`
+	Matches    []EventMatch `json:"matches"`
+}
+
+// ErrEventSearchUnsupported is returned when the configured store cannot
+// search conversation history.
+var ErrEventSearchUnsupported = errors.New("memory store does not support conversation search")
+
+// SearchEvents runs a conversation search limited to userID's sessions.
+func (s *Service) SearchEvents(ctx context.Context, userID, query string, opts EventSearchOptions) ([]EventMatch, error) {
+	store, ok := s.store.(*SQLiteStore)
+	if !ok {
+		return nil, ErrEventSearchUnsupported
+	}
+	opts.UserID = userID
+	return store.SearchEvents(ctx, query, opts)
+}
+
+// SearchEvents finds user and assistant messages matching query across all
+// sessions, archived history included, best matches first. Each query word
+// must appear in the message.
+func (s *SQLiteStore) SearchEvents(ctx context.Context, query string, opts EventSearchOptions) ([]EventMatch, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search query is required")
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 20
+	}
+	if !s.ftsEnabled {
+		return s.searchEventsLike(ctx, terms, opts)
+	}
+
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+	}
+	where, args := eventSearchFilters(opts)
+	rows, err := s.db.QueryContext(ctx, `
+SELECT e.id, e.session_key, e.role, snippet(memory_events_fts, 1, '**', '**', '...', 16), e.created_at_ms
+FROM memory_events_fts f
+JOIN events e ON e.id = f.event_id
+JOIN sessions s ON s.session_key = e.session_key
+WHERE memory_events_fts MATCH ?`+where+`
+ORDER BY bm25(memory_events_fts), e.created_at_ms DESC
+LIMIT ?`, append(append([]interface{}{strings.Join(quoted, " ")}, args...), opts.Limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("search events: %w", err)
+	}
+	defer rows.Close()
+	return scanEventMatches(rows)
+}
+
+// searchEventsLike is the substring fallback used when FTS5 is unavailable.
+func (s *SQLiteStore) searchEventsLike(ctx context.Context, terms []string, opts EventSearchOptions) ([]EventMatch, error) {
+	var conds strings.Builder
+	args := make([]interface{}, 0, len(terms)+4)
+	for _, term := range terms {
+		conds.WriteString(` AND LOWER(e.content) LIKE ?`)
+		args = append(args, "%"+strings.ToLower(term)+"%")
+	}
+	where, filterArgs := eventSearchFilters(opts)
+	args = append(append(args, filterArgs...), opts.Limit)
+	rows, err := s.db.QueryContext(ctx, `
+SELECT e.id, e.session_key, e.role, e.content, e.created_at_ms
+FROM events e
+JOIN sessions s ON s.session_key = e.session_key
+WHERE e.role IN ('user', 'assistant')`+conds.String()+where+`
+ORDER BY e.created_at_ms DESC
+LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search events: %w", err)
+	}
+	defer rows.Close()
+
+	matches, err := scanEventMatches(rows)
+	if err != nil {
+		return nil, err
+	}
+	for i := range matches {
+		matches[i].Snippet = eventPreview(matches[i].Snippet, terms[0])
+	}
+	return matches, nil
+}
+
+func eventSearchFilters(opts EventSearchOptions) (string, []interface{}) {
+	var (
+		where strings.Builder
+		args  []interface{}
+	)
+	if userID := strings.TrimSpace(opts.UserID); userID != "" {
+		where.WriteString(` AND s.user_id = ?`)
+		args = append(args, userID)
+	}
+	if opts.FromMS > 0 {
+		where.WriteString(` AND e.created_at_ms >= ?`)
+		args = append(args, opts.FromMS)
+	}
+	if opts.ToMS > 0 {
+		where.WriteString(` AND e.created_at_ms <= ?`)
+		args = append(args, opts.ToMS)
+	}
+	return where.String(), args
+}
+
+func scanEventMatches(rows *sql.Rows) ([]EventMatch, error) {
+	var out []EventMatch
+	for rows.Next() {
+		var m EventMatch
+		if err := rows.Scan(&m.EventID, &m.SessionKey, &m.Role, &m.Snippet, &m.CreatedAtMS); err != nil {
+			return nil, fmt.Errorf("scan event match: %w", err)
+		}
+		m.Snippet = strings.Join(strings.Fields(m.Snippet), " ")
+		out = append(out, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate event matches: %w", err)
+	}
+	return out, nil
+}
+
+// eventPreview cuts a window of content around the first occurrence of term.
+func eventPreview(content, term string) string {
+	const width = 120
+	runes := []rune(content)
+	if len(runes) <= width {
+		return content
+	}
+	pos := 0
+	if idx := strings.Index(strings.ToLower(content), strings.ToLower(term)); idx > 0 && idx <= len(content) {
+		pos = utf8.RuneCountInString(content[:idx])
+	}
+	start := max(pos-width/2, 0)
+	end := min(start+width, len(runes))
+	preview := string(runes[start:end])
+	if start > 0 {
+		preview = "..." + preview
+	}
+	if end < len(runes) {
+		preview += "..."
+	}
+	return preview
+}
+
+// GroupEventMatches groups matches by session, keeping sessions in the order
+// of their best match.
+func GroupEventMatches(matches []EventMatch) []SessionMatches {
+	var out []SessionMatches
+	index := map[string]int{}
+	for _, m := range matches {
+		i, ok := index[m.SessionKey]
+		if !ok {
+			i = len(out)
+			index[m.SessionKey] = i
+			out = append(out, SessionMatches{SessionKey: m.SessionKey})
+		}
+		out[i].Matches = append(out[i].Matches, m)
+	}
+	return out
+}
@@ -0,0 +1,319 @@
+package memory
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	snapshotFormat    = "dotagent-memory-snapshot"
+	snapshotVersion   = 1
+	snapshotMetaTable = "_meta"
+)
+
+// snapshotTables lists every table included in a snapshot, parents before
+// children. Import clears them in reverse order and inserts in this order.
+var snapshotTables = []string{
+	"sessions",
+	"session_provider_states",
+	"session_metadata",
+	"session_tags",
+	"session_aliases",
+	"events",
+	"session_compactions",
+	"session_snapshots",
+	"memory_items",
+	"memory_observations",
+	"memory_links",
+	"memory_embeddings",
+	"memory_embedding_cache",
+	"session_index_state",
+	"retrieval_cache",
+	"memory_jobs",
+	"memory_metrics",
+	"memory_audit_log",
+	"response_feedback",
+	"persona_profiles",
+	"persona_candidates",
+	"persona_revisions",
+	"persona_signals",
+	"persona_calibration",
+}
+
+// SnapshotRecord is one line of a memory snapshot stream.
+type SnapshotRecord struct {
+	Table string                 `json:"table"`
+	Row   map[string]interface{} `json:"row"`
+}
+
+// ErrSnapshotUnsupported is returned when the configured store cannot export
+// or import snapshots.
+var ErrSnapshotUnsupported = errors.New("memory store does not support snapshots")
+
+// ExportSnapshot writes every memory table to w as newline-delimited JSON.
+func (s *Service) ExportSnapshot(ctx context.Context, w io.Writer) error {
+	store, ok := s.store.(*SQLiteStore)
+	if !ok {
+		return ErrSnapshotUnsupported
+	}
+	return store.ExportSnapshot(ctx, w)
+}
+
+// ImportSnapshot replaces all memory data with the contents of a snapshot
+// produced by ExportSnapshot.
+func (s *Service) ImportSnapshot(ctx context.Context, r io.Reader) error {
+	store, ok := s.store.(*SQLiteStore)
+	if !ok {
+		return ErrSnapshotUnsupported
+	}
+	if err := store.ImportSnapshot(ctx, r); err != nil {
+		return err
+	}
+	s.snapshotMu.Lock()
+	s.snapshots = map[string][]Event{}
+	s.snapshotAccess = map[string]int64{}
+	s.snapshotMu.Unlock()
+	s.workingMemory.Reset()
+	return nil
+}
+
+// ExportSnapshot writes a header record followed by one record per row.
+func (s *SQLiteStore) ExportSnapshot(ctx context.Context, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	schemaVersion, err := s.currentSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if err := enc.Encode(SnapshotRecord{Table: snapshotMetaTable, Row: map[string]interface{}{
+		"format":         snapshotFormat,
+		"version":        snapshotVersion,
+		"schema_version": schemaVersion,
+		"exported_at_ms": nowMS(),
+	}}); err != nil {
+		return fmt.Errorf("write snapshot header: %w", err)
+	}
+
+	for _, table := range snapshotTables {
+		if err := s.exportTable(ctx, enc, table); err != nil {
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("flush snapshot: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) exportTable(ctx context.Context, enc *json.Encoder, table string) error {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`SELECT * FROM %s`, quoteIdent(table)))
+	if err != nil {
+		return fmt.Errorf("export %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("export %s columns: %w", table, err)
+	}
+	for rows.Next() {
+		values := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return fmt.Errorf("export %s row: %w", table, err)
+		}
+		row := make(map[string]interface{}, len(cols))
+		for i, col := range cols {
+			if b, ok := values[i].([]byte); ok {
+				row[col] = string(b)
+				continue
+			}
+			row[col] = values[i]
+		}
+		if err := enc.Encode(SnapshotRecord{Table: table, Row: row}); err != nil {
+			return fmt.Errorf("write %s row: %w", table, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("export %s: %w", table, err)
+	}
+	return nil
+}
+
+// ImportSnapshot clears every snapshot table and loads the rows from r inside a
+// single transaction. Nothing is changed if the stream is invalid.
+func (s *SQLiteStore) ImportSnapshot(ctx context.Context, r io.Reader) error {
+	columns := make(map[string]map[string]bool, len(snapshotTables))
+	order := make(map[string]int, len(snapshotTables))
+	for i, table := range snapshotTables {
+		cols, err := tableColumns(ctx, s.db, table)
+		if err != nil {
+			return err
+		}
+		columns[table] = cols
+		order[table] = i
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(r))
+	dec.UseNumber()
+
+	var header SnapshotRecord
+	if err := dec.Decode(&header); err != nil {
+		return fmt.Errorf("read snapshot header: %w", err)
+	}
+	if header.Table != snapshotMetaTable || fmt.Sprint(header.Row["format"]) != snapshotFormat {
+		return fmt.Errorf("not a memory snapshot (missing %s header)", snapshotFormat)
+	}
+	if v := fmt.Sprint(header.Row["version"]); v != fmt.Sprint(snapshotVersion) {
+		return fmt.Errorf("unsupported snapshot version %s", v)
+	}
+	currentSchema, err := s.currentSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if n, ok := header.Row["schema_version"].(json.Number); ok {
+		if v, err := n.Int64(); err == nil && int(v) > currentSchema {
+			return fmt.Errorf("snapshot schema version %d is newer than database schema version %d", v, currentSchema)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin snapshot import: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i := len(snapshotTables) - 1; i >= 0; i-- {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s`, quoteIdent(snapshotTables[i]))); err != nil {
+			return fmt.Errorf("clear %s: %w", snapshotTables[i], err)
+		}
+	}
+
+	lastOrder := -1
+	for line := 2; ; line++ {
+		var rec SnapshotRecord
+		if err := dec.Decode(&rec); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("read snapshot record %d: %w", line, err)
+		}
+		allowed, ok := columns[rec.Table]
+		if !ok {
+			return fmt.Errorf("snapshot record %d: unknown table %q", line, rec.Table)
+		}
+		if order[rec.Table] < lastOrder {
+			return fmt.Errorf("snapshot record %d: table %q is out of dependency order", line, rec.Table)
+		}
+		lastOrder = order[rec.Table]
+		if err := insertSnapshotRow(ctx, tx, rec, allowed); err != nil {
+			return fmt.Errorf("snapshot record %d: %w", line, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit snapshot import: %w", err)
+	}
+	return nil
+}
+
+func insertSnapshotRow(ctx context.Context, tx *sql.Tx, rec SnapshotRecord, allowed map[string]bool) error {
+	if len(rec.Row) == 0 {
+		return fmt.Errorf("empty %s row", rec.Table)
+	}
+	cols := make([]string, 0, len(rec.Row))
+	for col := range rec.Row {
+		if !allowed[col] {
+			return fmt.Errorf("unknown column %s.%s", rec.Table, col)
+		}
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	quoted := make([]string, len(cols))
+	args := make([]interface{}, len(cols))
+	for i, col := range cols {
+		quoted[i] = quoteIdent(col)
+		args[i] = snapshotValue(rec.Row[col])
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")
+	stmt := fmt.Sprintf(`INSERT INTO %s(%s) VALUES(%s)`, quoteIdent(rec.Table), strings.Join(quoted, ", "), placeholders)
+	if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+		return fmt.Errorf("insert %s: %w", rec.Table, err)
+	}
+	return nil
+}
+
+func snapshotValue(v interface{}) interface{} {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	if f, err := n.Float64(); err == nil {
+		return f
+	}
+	return n.String()
+}
+
+func tableColumns(ctx context.Context, db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`PRAGMA table_info(%s)`, quoteIdent(table)))
+	if err != nil {
+		return nil, fmt.Errorf("pragma table_info(%s): %w", table, err)
+	}
+	defer rows.Close()
+
+	out := map[string]bool{}
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return nil, fmt.Errorf("scan table info(%s): %w", table, err)
+		}
+		out[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("table %s does not exist", table)
+	}
+	return out, nil
+}
+
+func (s *SQLiteStore) currentSchemaVersion(ctx context.Context) (int, error) {
+	statuses, err := s.MigrationStatus(ctx)
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for _, st := range statuses {
+		if st.Applied && st.ID > version {
+			version = st.ID
+		}
+	}
+	return version, nil
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
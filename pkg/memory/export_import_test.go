@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSQLiteStore_SnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state", "memory.db"))
+	if err != nil {
+		t.Fatalf("new source store: %v", err)
+	}
+	defer src.Close()
+
+	sessionKey := "discord:snap"
+	if err := src.EnsureSession(ctx, sessionKey, "discord", "snap", "u1"); err != nil {
+		t.Fatalf("ensure session: %v", err)
+	}
+	if err := src.AppendEvent(ctx, Event{SessionKey: sessionKey, TurnID: "t1", Seq: 1, Role: "user", Content: "remember tea"}); err != nil {
+		t.Fatalf("append event: %v", err)
+	}
+	if _, err := src.UpsertMemoryItem(ctx, MemoryItem{
+		UserID:     "u1",
+		AgentID:    "dotagent",
+		SessionKey: sessionKey,
+		Kind:       MemoryUserPreference,
+		Key:        "drink",
+		Content:    "prefers green tea",
+		Confidence: 0.9,
+	}); err != nil {
+		t.Fatalf("upsert memory item: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.ExportSnapshot(ctx, &buf); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	firstLine, _, _ := strings.Cut(buf.String(), "\n")
+	if !strings.Contains(firstLine, `"table":"_meta"`) {
+		t.Fatalf("expected snapshot header first, got %s", firstLine)
+	}
+
+	dst, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state", "memory.db"))
+	if err != nil {
+		t.Fatalf("new destination store: %v", err)
+	}
+	defer dst.Close()
+	if err := dst.EnsureSession(ctx, "discord:stale", "discord", "stale", "u2"); err != nil {
+		t.Fatalf("ensure stale session: %v", err)
+	}
+
+	if err := dst.ImportSnapshot(ctx, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if _, err := dst.GetSession(ctx, "discord:stale"); err == nil {
+		t.Fatalf("expected import to clear existing sessions")
+	}
+	events, err := dst.ListRecentEvents(ctx, sessionKey, 10, false)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 1 || events[0].Content != "remember tea" {
+		t.Fatalf("unexpected imported events: %#v", events)
+	}
+	var content string
+	if err := dst.db.QueryRow(`SELECT content FROM memory_items WHERE item_key = 'drink'`).Scan(&content); err != nil {
+		t.Fatalf("query imported memory item: %v", err)
+	}
+	if content != "prefers green tea" {
+		t.Fatalf("unexpected imported memory content: %q", content)
+	}
+}
+
+func TestSQLiteStore_ImportSnapshotRejectsInvalidStream(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state", "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+	if err := store.EnsureSession(ctx, "discord:keep", "discord", "keep", "u1"); err != nil {
+		t.Fatalf("ensure session: %v", err)
+	}
+
+	header := `{"table":"_meta","row":{"format":"dotagent-memory-snapshot","version":1,"schema_version":1}}` + "\n"
+	cases := map[string]string{
+		"missing header": `{"table":"sessions","row":{"session_key":"x"}}` + "\n",
+		"unknown table":  header + `{"table":"users","row":{"id":"x"}}` + "\n",
+		"unknown column": header + `{"table":"sessions","row":{"session_key":"x","bogus":1}}` + "\n",
+	}
+	for name, stream := range cases {
+		if err := store.ImportSnapshot(ctx, strings.NewReader(stream)); err == nil {
+			t.Fatalf("%s: expected import error", name)
+		}
+	}
+	if _, err := store.GetSession(ctx, "discord:keep"); err != nil {
+		t.Fatalf("expected failed imports to leave data untouched: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MinFeedbackRating = 1
+	MaxFeedbackRating = 5
+)
+
+// Feedback is a user's rating of an agent response.
+type Feedback struct {
+	ID          string `json:"id"`
+	SessionKey  string `json:"session_key"`
+	TurnID      string `json:"turn_id"`
+	UserID      string `json:"user_id"`
+	Rating      int    `json:"rating"`
+	Comment     string `json:"comment"`
+	CreatedAtMS int64  `json:"created_at_ms"`
+}
+
+// ErrFeedbackUnsupported is returned when the configured store cannot record
+// response feedback.
+var ErrFeedbackUnsupported = errors.New("memory store does not support response feedback")
+
+// RecordFeedback rates the latest agent response in sessionKey. The returned
+// feedback carries the turn it was attached to, which is empty when the
+// session has no response yet.
+func (s *Service) RecordFeedback(ctx context.Context, sessionKey, userID string, rating int, comment string) (Feedback, error) {
+	store, ok := s.store.(*SQLiteStore)
+	if !ok {
+		return Feedback{}, ErrFeedbackUnsupported
+	}
+	fb := Feedback{
+		ID:          "fb-" + uuid.NewString(),
+		SessionKey:  sessionKey,
+		UserID:      userID,
+		Rating:      rating,
+		Comment:     strings.TrimSpace(comment),
+		CreatedAtMS: nowMS(),
+	}
+	events, err := s.store.ListRecentEvents(ctx, sessionKey, 50, false)
+	if err != nil {
+		return Feedback{}, err
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Role == "assistant" {
+			fb.TurnID = events[i].TurnID
+			break
+		}
+	}
+	if err := store.RecordFeedback(ctx, fb); err != nil {
+		return Feedback{}, err
+	}
+	_ = s.store.AddMetric(ctx, "feedback.rating", float64(rating), map[string]string{
+		"session_key": sessionKey,
+		"user_id":     userID,
+	})
+	return fb, nil
+}
+
+// ListFeedback returns the most recent feedback, newest first.
+func (s *Service) ListFeedback(ctx context.Context, limit int) ([]Feedback, error) {
+	store, ok := s.store.(*SQLiteStore)
+	if !ok {
+		return nil, ErrFeedbackUnsupported
+	}
+	return store.ListFeedback(ctx, limit)
+}
+
+// RecordFeedback stores fb. An empty ID or creation time is generated.
+func (s *SQLiteStore) RecordFeedback(ctx context.Context, fb Feedback) error {
+	if strings.TrimSpace(fb.SessionKey) == "" {
+		return fmt.Errorf("record feedback: empty session_key")
+	}
+	if fb.Rating < MinFeedbackRating || fb.Rating > MaxFeedbackRating {
+		return fmt.Errorf("record feedback: rating must be between %d and %d (got %d)", MinFeedbackRating, MaxFeedbackRating, fb.Rating)
+	}
+	if fb.ID == "" {
+		fb.ID = "fb-" + uuid.NewString()
+	}
+	if fb.CreatedAtMS == 0 {
+		fb.CreatedAtMS = nowMS()
+	}
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO response_feedback(id, session_key, turn_id, user_id, rating, comment, created_at_ms)
+VALUES(?, ?, ?, ?, ?, ?, ?)`, fb.ID, fb.SessionKey, fb.TurnID, fb.UserID, fb.Rating, fb.Comment, fb.CreatedAtMS)
+	if err != nil {
+		return fmt.Errorf("record feedback: %w", err)
+	}
+	return nil
+}
+
+// ListFeedback returns up to limit feedback rows, newest first. A limit of 0
+// or less returns everything.
+func (s *SQLiteStore) ListFeedback(ctx context.Context, limit int) ([]Feedback, error) {
+	query := `
+SELECT id, session_key, turn_id, user_id, rating, comment, created_at_ms
+FROM response_feedback
+ORDER BY created_at_ms DESC, id`
+	args := []interface{}{}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list feedback: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Feedback{}
+	for rows.Next() {
+		var fb Feedback
+		if err := rows.Scan(&fb.ID, &fb.SessionKey, &fb.TurnID, &fb.UserID, &fb.Rating, &fb.Comment, &fb.CreatedAtMS); err != nil {
+			return nil, fmt.Errorf("scan feedback row: %w", err)
+		}
+		out = append(out, fb)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate feedback: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestService_RecordFeedbackAttachesLatestResponse(t *testing.T) {
+	ctx := context.Background()
+	svc, err := NewService(Config{
+		Workspace:       t.TempDir(),
+		AgentID:         "dotagent",
+		WorkerPoll:      time.Hour,
+		PersonaFileSync: PersonaFileSyncDisabled,
+	}, nil)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	defer svc.Close()
+
+	sessionKey := "cli:feedback"
+	for _, ev := range []Event{
+		{SessionKey: sessionKey, TurnID: "turn-1", Role: "user", Content: "hi"},
+		{SessionKey: sessionKey, TurnID: "turn-1", Role: "assistant", Content: "hello"},
+		{SessionKey: sessionKey, TurnID: "turn-2", Role: "user", Content: "what time is it?"},
+	} {
+		if err := svc.store.AppendEvent(ctx, ev); err != nil {
+			t.Fatalf("append event: %v", err)
+		}
+	}
+
+	fb, err := svc.RecordFeedback(ctx, sessionKey, "u1", 4, "  helpful  ")
+	if err != nil {
+		t.Fatalf("record feedback: %v", err)
+	}
+	if fb.TurnID != "turn-1" || fb.Comment != "helpful" {
+		t.Fatalf("expected feedback on turn-1 with trimmed comment, got %+v", fb)
+	}
+	if _, err := svc.RecordFeedback(ctx, sessionKey, "u1", 6, ""); err == nil {
+		t.Fatal("expected out-of-range rating to be rejected")
+	}
+	if _, err := svc.RecordFeedback(ctx, "cli:empty", "u1", 1, ""); err != nil {
+		t.Fatalf("record feedback without a response: %v", err)
+	}
+
+	rows, err := svc.ListFeedback(ctx, 0)
+	if err != nil {
+		t.Fatalf("list feedback: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 feedback rows, got %+v", rows)
+	}
+	if limited, _ := svc.ListFeedback(ctx, 1); len(limited) != 1 {
+		t.Fatalf("expected limit to apply, got %d rows", len(limited))
+	}
+}
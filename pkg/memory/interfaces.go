@@ -5,18 +5,30 @@ import "context"
 // Store provides durable persistence for all memory state.
 type Store interface {
 	Close() error
+	Ping(ctx context.Context) error
 	EnsureSession(ctx context.Context, sessionKey, channel, chatID, userID string) error
 	GetSession(ctx context.Context, sessionKey string) (Session, error)
 	ListSessions(ctx context.Context, userID string, limit int) ([]Session, error)
+	ListPrunableSessions(ctx context.Context, olderThanMS int64, minMessages int) ([]Session, error)
+	PruneSessions(ctx context.Context, sessions []Session, apply bool) ([]PruneCount, error)
+	EraseUser(ctx context.Context, userID, agentID string) ([]ErasureCount, error)
 	MarkSessionConsolidated(ctx context.Context, sessionKey string, atMS int64) error
 	GetSessionSummary(ctx context.Context, sessionKey string) (string, error)
 	SetSessionSummary(ctx context.Context, sessionKey, summary string) error
 	GetSessionProviderState(ctx context.Context, sessionKey, provider string) (string, error)
 	SetSessionProviderState(ctx context.Context, sessionKey, provider, stateID string) error
+	GetSessionMetadata(ctx context.Context, sessionKey, key string) (string, error)
+	SetSessionMetadata(ctx context.Context, sessionKey, key, value string) error
+	SetSessionAlias(ctx context.Context, alias, sessionKey string) error
+	ResolveSessionKey(ctx context.Context, keyOrAlias string) (string, error)
+	SetSessionTags(ctx context.Context, sessionKey string, tags []string) error
+	ListSessionTags(ctx context.Context, sessionKeys []string) (map[string][]string, error)
+	ListSessionsByTag(ctx context.Context, tag string, limit int) ([]string, error)
 	GetLatestSessionSnapshot(ctx context.Context, sessionKey string) (SessionSnapshot, error)
 	UpsertSessionSnapshot(ctx context.Context, snap SessionSnapshot) error
+	GetSessionSnapshot(ctx context.Context, sessionKey string, revision int) (SessionSnapshot, error)
 	AppendEvent(ctx context.Context, ev Event) error
-	AppendUserEventAndMemories(ctx context.Context, ev Event, userID, agentID string, ops []ConsolidationOp) (memoryCount int, err error)
+	AppendUserEventAndMemories(ctx context.Context, ev Event, userID, agentID string, ops []ConsolidationOp, embeddings EmbeddingProvider) (memoryCount int, err error)
 	ListRecentEvents(ctx context.Context, sessionKey string, limit int, includeArchived bool) ([]Event, error)
 	ListEventsByTurn(ctx context.Context, sessionKey, turnID string, limit int) ([]Event, error)
 	ArchiveEventsBefore(ctx context.Context, sessionKey string, keepLatest int) (archivedCount int, err error)
@@ -25,17 +37,25 @@ type Store interface {
 	CheckpointCompaction(ctx context.Context, compactionID string, checkpoint map[string]string) error
 	CompleteCompaction(ctx context.Context, compactionID, summary string) error
 	FailCompaction(ctx context.Context, compactionID, errMsg string) error
+	ListStaleCompactions(ctx context.Context, staleThresholdMS int64) ([]Compaction, error)
 
 	UpsertMemoryItem(ctx context.Context, item MemoryItem) (MemoryItem, error)
 	DeleteMemoryByKey(ctx context.Context, userID, agentID string, kind MemoryItemKind, key string) error
+	ListMemoryKeysByPrefix(ctx context.Context, agentID, prefix string) ([]string, error)
 	ListMemoryCandidates(ctx context.Context, userID, agentID, sessionKey string, limit int) ([]MemoryItem, error)
 	SearchMemoryFTS(ctx context.Context, userID, agentID, sessionKey, query string, limit int) ([]MemoryItem, error)
 	UpsertMemoryLink(ctx context.Context, link MemoryLink) error
 	ListMemoryLinks(ctx context.Context, itemID string, limit int) ([]MemoryLink, error)
 	ListMemoryObservations(ctx context.Context, itemID string, limit int) ([]MemoryObservation, error)
+	FindMemoryItemsByKey(ctx context.Context, key string) ([]MemoryItem, error)
+	GetObservationTimeline(ctx context.Context, itemID string, limit int) ([]MemoryObservation, error)
 
 	UpsertEmbedding(ctx context.Context, itemID, model string, vector []float32) error
 	GetEmbeddings(ctx context.Context, itemIDs []string) (map[string][]float32, error)
+	GetSessionIndexCursor(ctx context.Context, sessionKey string) (int64, error)
+	SetSessionIndexCursor(ctx context.Context, sessionKey string, lastIndexedMS int64) error
+	ListSessionEmbeddingDeltas(ctx context.Context, sessionKey, agentID, model string, sinceMS int64, limit int) ([]MemoryItem, error)
+	embeddingCacheStore
 
 	GetRetrievalCache(ctx context.Context, key string, nowMS int64) (string, bool, error)
 	PutRetrievalCache(ctx context.Context, key, value string, expiresAtMS int64) error
@@ -59,6 +79,10 @@ type Store interface {
 	ListPersonaRevisions(ctx context.Context, userID, agentID string, limit int) ([]PersonaRevision, error)
 	ApplyPersonaMutation(ctx context.Context, profile PersonaProfile, candidate PersonaUpdateCandidate, revision PersonaRevision, memoryOps []ConsolidationOp) error
 	RollbackPersonaToRevision(ctx context.Context, userID, agentID, revisionID string) (PersonaProfile, error)
+
+	// Session clustering reads and labels sessions in bulk.
+	listClusterSessions(ctx context.Context, userID string) ([]clusterSession, error)
+	setSessionClusterLabels(ctx context.Context, sessions []clusterSession, labels map[string]string) error
 }
 
 // Retriever recalls memories for prompt construction.
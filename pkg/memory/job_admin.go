@@ -0,0 +1,166 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MetricSummary aggregates the samples recorded for one metric name.
+type MetricSummary struct {
+	Metric    string  `json:"metric"`
+	Count     int     `json:"count"`
+	Sum       float64 `json:"sum"`
+	LastValue float64 `json:"last_value"`
+	FirstAtMS int64   `json:"first_at_ms"`
+	LastAtMS  int64   `json:"last_at_ms"`
+}
+
+// ErrJobAdminUnsupported is returned when the configured store cannot list or
+// cancel jobs, or summarize metrics.
+var ErrJobAdminUnsupported = errors.New("memory store does not support job administration")
+
+// ListJobs returns up to limit background jobs, newest first. An empty status
+// lists jobs in every state.
+func (s *Service) ListJobs(ctx context.Context, status string, limit int) ([]Job, error) {
+	store, ok := s.store.(*SQLiteStore)
+	if !ok {
+		return nil, ErrJobAdminUnsupported
+	}
+	return store.ListJobs(ctx, status, limit)
+}
+
+// CancelJob marks a pending or running job as canceled so workers skip it,
+// and stops it if this process is running it. It reports false when no such
+// job exists or it has already finished.
+func (s *Service) CancelJob(ctx context.Context, id string) (bool, error) {
+	store, ok := s.store.(*SQLiteStore)
+	if !ok {
+		return false, ErrJobAdminUnsupported
+	}
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+	canceled, err := store.CancelJob(ctx, id)
+	if err != nil || !canceled {
+		return canceled, err
+	}
+	if stop, running := s.runningJobs[strings.TrimSpace(id)]; running {
+		stop()
+	}
+	_ = store.AddMetric(ctx, "memory.job.canceled", 1, nil)
+	return true, nil
+}
+
+// MetricSummaries aggregates the metrics recorded since sinceMS.
+func (s *Service) MetricSummaries(ctx context.Context, sinceMS int64) ([]MetricSummary, error) {
+	store, ok := s.store.(*SQLiteStore)
+	if !ok {
+		return nil, ErrJobAdminUnsupported
+	}
+	return store.MetricSummaries(ctx, sinceMS)
+}
+
+// CompactSession force-compacts one session with a budget derived from
+// maxTokens.
+func (s *Service) CompactSession(ctx context.Context, sessionKey string, maxTokens int) error {
+	sessionKey = strings.TrimSpace(sessionKey)
+	if sessionKey == "" {
+		return fmt.Errorf("session key is required")
+	}
+	sess, err := s.store.GetSession(ctx, sessionKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.compactSessionSerialized(ctx, sess.SessionKey, sess.UserID, DeriveContextBudget(maxTokens))
+}
+
+func (s *SQLiteStore) ListJobs(ctx context.Context, status string, limit int) ([]Job, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	query := `
+SELECT id, job_type, session_key, status, priority, payload_json, error, run_after_ms, lease_until_ms, created_at_ms, updated_at_ms, completed_at_ms
+FROM memory_jobs`
+	args := []interface{}{}
+	if status = strings.TrimSpace(status); status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at_ms DESC, id ASC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Job{}
+	for rows.Next() {
+		var (
+			job     Job
+			payload string
+		)
+		if err := rows.Scan(&job.ID, &job.JobType, &job.SessionKey, &job.Status, &job.Priority, &payload, &job.Error, &job.RunAfterMS, &job.LeaseUntilMS, &job.CreatedAtMS, &job.UpdatedAtMS, &job.CompletedAtMS); err != nil {
+			return nil, fmt.Errorf("scan job: %w", err)
+		}
+		job.Payload = decodeMap(payload)
+		out = append(out, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list jobs: %w", err)
+	}
+	return out, nil
+}
+
+func (s *SQLiteStore) CancelJob(ctx context.Context, id string) (bool, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return false, fmt.Errorf("job id is required")
+	}
+	now := nowMS()
+	res, err := s.db.ExecContext(ctx, `
+UPDATE memory_jobs
+SET status = ?, error = 'canceled', updated_at_ms = ?, completed_at_ms = ?, lease_until_ms = 0
+WHERE id = ? AND status IN (?, ?)`, JobCanceled, now, now, id, JobPending, JobRunning)
+	if err != nil {
+		return false, fmt.Errorf("cancel job: %w", err)
+	}
+	affected, _ := res.RowsAffected()
+	return affected > 0, nil
+}
+
+func (s *SQLiteStore) MetricSummaries(ctx context.Context, sinceMS int64) ([]MetricSummary, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT m.metric, COUNT(*), COALESCE(SUM(m.value), 0), MIN(m.created_at_ms), MAX(m.created_at_ms),
+	(SELECT l.value FROM memory_metrics l WHERE l.metric = m.metric AND l.created_at_ms >= ? ORDER BY l.created_at_ms DESC, l.id DESC LIMIT 1)
+FROM memory_metrics m
+WHERE m.created_at_ms >= ?
+GROUP BY m.metric
+ORDER BY m.metric ASC`, sinceMS, sinceMS)
+	if err != nil {
+		return nil, fmt.Errorf("summarize metrics: %w", err)
+	}
+	defer rows.Close()
+
+	out := []MetricSummary{}
+	for rows.Next() {
+		var summary MetricSummary
+		if err := rows.Scan(&summary.Metric, &summary.Count, &summary.Sum, &summary.FirstAtMS, &summary.LastAtMS, &summary.LastValue); err != nil {
+			return nil, fmt.Errorf("scan metric summary: %w", err)
+		}
+		out = append(out, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("summarize metrics: %w", err)
+	}
+	return out, nil
+}
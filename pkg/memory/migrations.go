@@ -0,0 +1,276 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is a numbered schema change loaded from the embedded migrations
+// directory. Files are named NNNN_name.up.sql with an optional NNNN_name.down.sql.
+type Migration struct {
+	ID   int
+	Name string
+	Up   string
+	Down string
+}
+
+// MigrationStatus reports whether a known migration has been applied.
+type MigrationStatus struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Applied     bool   `json:"applied"`
+	AppliedAtMS int64  `json:"applied_at_ms,omitempty"`
+	Reversible  bool   `json:"reversible"`
+}
+
+var loadedMigrations []Migration
+
+func init() {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		panic(fmt.Sprintf("load embedded memory migrations: %v", err))
+	}
+	loadedMigrations = migrations
+}
+
+// Migrations returns the embedded migrations in ascending ID order.
+func Migrations() []Migration {
+	return append([]Migration(nil), loadedMigrations...)
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[int]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		idPart, label, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name.%s.sql", name, direction)
+		}
+		id, err := strconv.Atoi(idPart)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid id %q", name, idPart)
+		}
+		raw, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		m, exists := byID[id]
+		if !exists {
+			m = &Migration{ID: id, Name: label}
+			byID[id] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", id, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(raw)
+		} else {
+			m.Down = string(raw)
+		}
+	}
+
+	out := make([]Migration, 0, len(byID))
+	for _, m := range byID {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %04d_%s is missing its up script", m.ID, m.Name)
+		}
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func ensureMigrationsTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at_ms INTEGER NOT NULL
+);`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func appliedMigrations(ctx context.Context, db *sql.DB) (map[int]int64, error) {
+	rows, err := db.QueryContext(ctx, `SELECT id, applied_at_ms FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+	out := map[int]int64{}
+	for rows.Next() {
+		var (
+			id        int
+			appliedAt int64
+		)
+		if err := rows.Scan(&id, &appliedAt); err != nil {
+			return nil, fmt.Errorf("scan schema_migrations: %w", err)
+		}
+		out[id] = appliedAt
+	}
+	return out, rows.Err()
+}
+
+// Migrate applies every pending embedded migration in order, each inside its
+// own transaction. It returns the IDs that were applied.
+func (s *SQLiteStore) Migrate(ctx context.Context) ([]int, error) {
+	if err := ensureMigrationsTable(ctx, s.db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	var ran []int
+	for _, m := range loadedMigrations {
+		if _, ok := applied[m.ID]; ok {
+			continue
+		}
+		if err := s.runMigration(ctx, m.ID, m.Name, m.Up, true); err != nil {
+			return ran, err
+		}
+		ran = append(ran, m.ID)
+	}
+	return ran, nil
+}
+
+// MigrationStatus lists every known migration and whether it has been applied.
+func (s *SQLiteStore) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	if err := ensureMigrationsTable(ctx, s.db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]MigrationStatus, 0, len(loadedMigrations))
+	for _, m := range loadedMigrations {
+		appliedAt, ok := applied[m.ID]
+		out = append(out, MigrationStatus{
+			ID:          m.ID,
+			Name:        m.Name,
+			Applied:     ok,
+			AppliedAtMS: appliedAt,
+			Reversible:  strings.TrimSpace(m.Down) != "",
+		})
+	}
+	return out, nil
+}
+
+// PendingMigrations returns the IDs of embedded migrations not yet applied,
+// without creating the schema_migrations table, so it is safe on a database
+// opened read-only.
+func (s *SQLiteStore) PendingMigrations(ctx context.Context) ([]int, error) {
+	exists, err := tableExists(s.db, "schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return pendingMigrationIDs(loadedMigrations, nil), nil
+	}
+	applied, err := appliedMigrations(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	return pendingMigrationIDs(loadedMigrations, applied), nil
+}
+
+// pendingMigrationIDs lists the IDs in all that are not in applied.
+func pendingMigrationIDs(all []Migration, applied map[int]int64) []int {
+	var ids []int
+	for _, m := range all {
+		if _, ok := applied[m.ID]; !ok {
+			ids = append(ids, m.ID)
+		}
+	}
+	return ids
+}
+
+// RollbackMigration runs down migrations for every applied migration with an
+// ID greater than or equal to id, newest first. It returns the IDs rolled back.
+func (s *SQLiteStore) RollbackMigration(ctx context.Context, id int) ([]int, error) {
+	if err := ensureMigrationsTable(ctx, s.db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := applied[id]; !ok {
+		return nil, fmt.Errorf("migration %d is not applied", id)
+	}
+
+	targets := make([]Migration, 0)
+	for _, m := range loadedMigrations {
+		if _, ok := applied[m.ID]; ok && m.ID >= id {
+			if strings.TrimSpace(m.Down) == "" {
+				return nil, fmt.Errorf("migration %04d_%s has no down script", m.ID, m.Name)
+			}
+			targets = append(targets, m)
+		}
+	}
+	var rolledBack []int
+	for i := len(targets) - 1; i >= 0; i-- {
+		m := targets[i]
+		if err := s.runMigration(ctx, m.ID, m.Name, m.Down, false); err != nil {
+			return rolledBack, err
+		}
+		rolledBack = append(rolledBack, m.ID)
+	}
+	return rolledBack, nil
+}
+
+func (s *SQLiteStore) runMigration(ctx context.Context, id int, name, script string, up bool) error {
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin migration %04d_%s (%s): %w", id, name, direction, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %04d_%s (%s) failed: %w", id, name, direction, err)
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations(id, name, applied_at_ms) VALUES(?, ?, ?)`, id, name, nowMS())
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE id = ?`, id)
+	}
+	if err != nil {
+		return fmt.Errorf("record migration %04d_%s (%s): %w", id, name, direction, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit migration %04d_%s (%s): %w", id, name, direction, err)
+	}
+	return nil
+}
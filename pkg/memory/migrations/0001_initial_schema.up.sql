@@ -0,0 +1,300 @@
+-- Baseline memory schema. Every statement is idempotent so databases created
+-- before schema_migrations existed can adopt this migration in place.
+
+CREATE TABLE IF NOT EXISTS sessions (
+	session_key TEXT PRIMARY KEY,
+	channel TEXT NOT NULL DEFAULT '',
+	chat_id TEXT NOT NULL DEFAULT '',
+	user_id TEXT NOT NULL DEFAULT '',
+	created_at_ms INTEGER NOT NULL,
+	updated_at_ms INTEGER NOT NULL,
+	message_count INTEGER NOT NULL DEFAULT 0,
+	summary TEXT NOT NULL DEFAULT '',
+	last_consolidated_ms INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS session_provider_states (
+	session_key TEXT NOT NULL,
+	provider TEXT NOT NULL,
+	state_id TEXT NOT NULL DEFAULT '',
+	updated_at_ms INTEGER NOT NULL,
+	PRIMARY KEY(session_key, provider)
+);
+
+CREATE TABLE IF NOT EXISTS events (
+	id TEXT PRIMARY KEY,
+	session_key TEXT NOT NULL,
+	turn_id TEXT NOT NULL,
+	seq INTEGER NOT NULL,
+	role TEXT NOT NULL,
+	content TEXT NOT NULL,
+	tool_call_id TEXT NOT NULL DEFAULT '',
+	tool_name TEXT NOT NULL DEFAULT '',
+	metadata_json TEXT NOT NULL DEFAULT '{}',
+	created_at_ms INTEGER NOT NULL,
+	archived INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS events_session_active_idx ON events(session_key, archived, created_at_ms DESC, seq DESC);
+
+CREATE INDEX IF NOT EXISTS events_session_turn_idx ON events(session_key, turn_id, seq);
+
+CREATE TABLE IF NOT EXISTS session_compactions (
+	id TEXT PRIMARY KEY,
+	session_key TEXT NOT NULL,
+	started_at_ms INTEGER NOT NULL,
+	completed_at_ms INTEGER NOT NULL DEFAULT 0,
+	status TEXT NOT NULL,
+	source_event_count INTEGER NOT NULL,
+	retained_event_count INTEGER NOT NULL,
+	summary TEXT NOT NULL DEFAULT '',
+	checkpoint_json TEXT NOT NULL DEFAULT '{}',
+	error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS compaction_session_idx ON session_compactions(session_key, started_at_ms DESC);
+
+CREATE TABLE IF NOT EXISTS session_snapshots (
+	session_key TEXT NOT NULL,
+	revision INTEGER NOT NULL,
+	created_at_ms INTEGER NOT NULL,
+	facts_json TEXT NOT NULL DEFAULT '[]',
+	preferences_json TEXT NOT NULL DEFAULT '[]',
+	tasks_json TEXT NOT NULL DEFAULT '[]',
+	open_loops_json TEXT NOT NULL DEFAULT '[]',
+	constraints_json TEXT NOT NULL DEFAULT '[]',
+	summary TEXT NOT NULL DEFAULT '',
+	compaction_id TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY(session_key, revision)
+);
+
+CREATE INDEX IF NOT EXISTS session_snapshots_latest_idx ON session_snapshots(session_key, revision DESC, created_at_ms DESC);
+
+CREATE TABLE IF NOT EXISTS memory_items (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL DEFAULT '',
+	agent_id TEXT NOT NULL DEFAULT '',
+	scope_type TEXT NOT NULL DEFAULT 'session',
+	scope_id TEXT NOT NULL DEFAULT '',
+	session_key TEXT NOT NULL DEFAULT '',
+	kind TEXT NOT NULL,
+	item_key TEXT NOT NULL,
+	content TEXT NOT NULL,
+	confidence REAL NOT NULL DEFAULT 0,
+	weight REAL NOT NULL DEFAULT 1,
+	source_event_id TEXT NOT NULL DEFAULT '',
+	first_seen_at_ms INTEGER NOT NULL,
+	last_seen_at_ms INTEGER NOT NULL,
+	expires_at_ms INTEGER NOT NULL DEFAULT 0,
+	deleted_at_ms INTEGER NOT NULL DEFAULT 0,
+	evergreen INTEGER NOT NULL DEFAULT 0,
+	metadata_json TEXT NOT NULL DEFAULT '{}'
+);
+
+CREATE TABLE IF NOT EXISTS memory_observations (
+	id TEXT PRIMARY KEY,
+	item_id TEXT NOT NULL,
+	session_key TEXT NOT NULL DEFAULT '',
+	event_id TEXT NOT NULL DEFAULT '',
+	observed_at_ms INTEGER NOT NULL,
+	confidence REAL NOT NULL DEFAULT 0,
+	content TEXT NOT NULL DEFAULT '',
+	extractor TEXT NOT NULL DEFAULT '',
+	action TEXT NOT NULL DEFAULT 'upsert',
+	metadata_json TEXT NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX IF NOT EXISTS memory_obs_item_idx ON memory_observations(item_id, observed_at_ms DESC);
+
+CREATE INDEX IF NOT EXISTS memory_obs_event_idx ON memory_observations(event_id, observed_at_ms DESC);
+
+CREATE TABLE IF NOT EXISTS memory_links (
+	id TEXT PRIMARY KEY,
+	from_item_id TEXT NOT NULL,
+	to_item_id TEXT NOT NULL,
+	relation TEXT NOT NULL,
+	weight REAL NOT NULL DEFAULT 1,
+	created_at_ms INTEGER NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS memory_links_unique ON memory_links(from_item_id, to_item_id, relation);
+
+CREATE INDEX IF NOT EXISTS memory_links_from_idx ON memory_links(from_item_id, created_at_ms DESC);
+
+CREATE TABLE IF NOT EXISTS memory_embeddings (
+	item_id TEXT PRIMARY KEY,
+	model TEXT NOT NULL,
+	vector_json TEXT NOT NULL,
+	norm REAL NOT NULL DEFAULT 0,
+	updated_at_ms INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS memory_embedding_cache (
+	provider TEXT NOT NULL,
+	model TEXT NOT NULL,
+	provider_key TEXT NOT NULL,
+	content_hash TEXT NOT NULL,
+	vector_json TEXT NOT NULL,
+	norm REAL NOT NULL DEFAULT 0,
+	updated_at_ms INTEGER NOT NULL,
+	PRIMARY KEY(provider, model, provider_key, content_hash)
+);
+
+CREATE INDEX IF NOT EXISTS memory_embedding_cache_updated_idx ON memory_embedding_cache(updated_at_ms DESC);
+
+CREATE TABLE IF NOT EXISTS session_index_state (
+	session_key TEXT PRIMARY KEY,
+	last_indexed_at_ms INTEGER NOT NULL DEFAULT 0,
+	updated_at_ms INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS session_index_state_updated_idx ON session_index_state(updated_at_ms DESC);
+
+CREATE TABLE IF NOT EXISTS retrieval_cache (
+	cache_key TEXT PRIMARY KEY,
+	result_json TEXT NOT NULL,
+	created_at_ms INTEGER NOT NULL,
+	expires_at_ms INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS retrieval_cache_exp_idx ON retrieval_cache(expires_at_ms);
+
+CREATE TABLE IF NOT EXISTS memory_jobs (
+	id TEXT PRIMARY KEY,
+	job_type TEXT NOT NULL,
+	session_key TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL,
+	priority INTEGER NOT NULL DEFAULT 100,
+	payload_json TEXT NOT NULL DEFAULT '{}',
+	error TEXT NOT NULL DEFAULT '',
+	run_after_ms INTEGER NOT NULL,
+	lease_until_ms INTEGER NOT NULL DEFAULT 0,
+	created_at_ms INTEGER NOT NULL,
+	updated_at_ms INTEGER NOT NULL,
+	completed_at_ms INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS memory_jobs_claim_idx ON memory_jobs(status, run_after_ms, lease_until_ms, priority, created_at_ms);
+
+CREATE TABLE IF NOT EXISTS memory_metrics (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	metric TEXT NOT NULL,
+	value REAL NOT NULL,
+	labels_json TEXT NOT NULL DEFAULT '{}',
+	created_at_ms INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS memory_metrics_metric_idx ON memory_metrics(metric, created_at_ms DESC);
+
+CREATE TABLE IF NOT EXISTS memory_audit_log (
+	id TEXT PRIMARY KEY,
+	action TEXT NOT NULL,
+	entity TEXT NOT NULL,
+	entity_id TEXT NOT NULL DEFAULT '',
+	session_key TEXT NOT NULL DEFAULT '',
+	user_id TEXT NOT NULL DEFAULT '',
+	agent_id TEXT NOT NULL DEFAULT '',
+	reason TEXT NOT NULL DEFAULT '',
+	payload_json TEXT NOT NULL DEFAULT '{}',
+	created_at_ms INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS memory_audit_created_idx ON memory_audit_log(created_at_ms DESC);
+
+CREATE TABLE IF NOT EXISTS persona_profiles (
+	user_id TEXT NOT NULL,
+	agent_id TEXT NOT NULL,
+	profile_json TEXT NOT NULL,
+	revision INTEGER NOT NULL DEFAULT 1,
+	updated_at_ms INTEGER NOT NULL,
+	PRIMARY KEY(user_id, agent_id)
+);
+
+CREATE TABLE IF NOT EXISTS persona_candidates (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	agent_id TEXT NOT NULL,
+	session_key TEXT NOT NULL DEFAULT '',
+	turn_id TEXT NOT NULL DEFAULT '',
+	source_event_id TEXT NOT NULL DEFAULT '',
+	field_path TEXT NOT NULL,
+	operation TEXT NOT NULL,
+	value TEXT NOT NULL DEFAULT '',
+	confidence REAL NOT NULL DEFAULT 0,
+	evidence TEXT NOT NULL DEFAULT '',
+	source TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL,
+	rejected_reason TEXT NOT NULL DEFAULT '',
+	applied_revision_id TEXT NOT NULL DEFAULT '',
+	created_at_ms INTEGER NOT NULL,
+	applied_at_ms INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS persona_candidates_status_idx ON persona_candidates(user_id, agent_id, status, created_at_ms DESC);
+
+CREATE INDEX IF NOT EXISTS persona_candidates_turn_idx ON persona_candidates(user_id, agent_id, session_key, turn_id, status, created_at_ms DESC);
+
+DELETE FROM persona_candidates
+WHERE rowid NOT IN (
+	SELECT MAX(rowid)
+	FROM persona_candidates
+	GROUP BY user_id, agent_id, session_key, turn_id, field_path, operation, value
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS persona_candidates_unique_key ON persona_candidates(user_id, agent_id, session_key, turn_id, field_path, operation, value);
+
+CREATE TABLE IF NOT EXISTS persona_revisions (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	agent_id TEXT NOT NULL,
+	session_key TEXT NOT NULL DEFAULT '',
+	turn_id TEXT NOT NULL DEFAULT '',
+	candidate_id TEXT NOT NULL DEFAULT '',
+	field_path TEXT NOT NULL,
+	operation TEXT NOT NULL,
+	old_value TEXT NOT NULL DEFAULT '',
+	new_value TEXT NOT NULL DEFAULT '',
+	confidence REAL NOT NULL DEFAULT 0,
+	evidence TEXT NOT NULL DEFAULT '',
+	reason TEXT NOT NULL DEFAULT '',
+	source TEXT NOT NULL DEFAULT '',
+	profile_before_json TEXT NOT NULL DEFAULT '{}',
+	profile_after_json TEXT NOT NULL DEFAULT '{}',
+	created_at_ms INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS persona_revisions_profile_idx ON persona_revisions(user_id, agent_id, created_at_ms DESC);
+
+CREATE TABLE IF NOT EXISTS persona_signals (
+	user_id TEXT NOT NULL,
+	agent_id TEXT NOT NULL,
+	field_path TEXT NOT NULL,
+	value_hash TEXT NOT NULL,
+	hits INTEGER NOT NULL DEFAULT 0,
+	last_seen_at_ms INTEGER NOT NULL,
+	PRIMARY KEY(user_id, agent_id, field_path, value_hash)
+);
+
+-- Scope columns were added after the first release; backfill rows written
+-- before they existed.
+UPDATE memory_items
+SET scope_type = CASE
+	WHEN TRIM(scope_type) = '' AND TRIM(session_key) = '' THEN 'global'
+	WHEN TRIM(scope_type) = '' THEN 'session'
+	ELSE scope_type
+END,
+scope_id = CASE
+	WHEN TRIM(scope_id) = '' AND TRIM(scope_type) = 'session' THEN session_key
+	WHEN TRIM(scope_id) = '' AND TRIM(scope_type) = 'user' THEN user_id
+	ELSE scope_id
+END;
+
+UPDATE memory_items
+SET scope_type = 'global'
+WHERE TRIM(scope_type) = 'session' AND TRIM(scope_id) = '' AND TRIM(session_key) = '';
+
+DROP INDEX IF EXISTS memory_items_unique_active;
+CREATE UNIQUE INDEX IF NOT EXISTS memory_items_unique_active ON memory_items(user_id, agent_id, scope_type, scope_id, kind, item_key);
+DROP INDEX IF EXISTS memory_items_scope_idx;
+CREATE INDEX IF NOT EXISTS memory_items_scope_idx ON memory_items(user_id, agent_id, scope_type, scope_id, deleted_at_ms, expires_at_ms, last_seen_at_ms DESC);
+DROP INDEX IF EXISTS memory_items_legacy_scope_idx;
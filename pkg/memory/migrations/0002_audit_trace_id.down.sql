@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS memory_audit_trace_idx;
+
+ALTER TABLE memory_audit_log DROP COLUMN trace_id;
@@ -0,0 +1,3 @@
+ALTER TABLE memory_audit_log ADD COLUMN trace_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS memory_audit_trace_idx ON memory_audit_log(trace_id, created_at_ms);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS response_feedback (
+	id TEXT PRIMARY KEY,
+	session_key TEXT NOT NULL,
+	turn_id TEXT NOT NULL DEFAULT '',
+	user_id TEXT NOT NULL DEFAULT '',
+	rating INTEGER NOT NULL,
+	comment TEXT NOT NULL DEFAULT '',
+	created_at_ms INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS response_feedback_created_idx ON response_feedback(created_at_ms DESC);
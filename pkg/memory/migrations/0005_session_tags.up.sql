@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS session_tags (
+	session_key TEXT NOT NULL,
+	tag TEXT NOT NULL,
+	created_at_ms INTEGER NOT NULL,
+	PRIMARY KEY(session_key, tag)
+);
+
+CREATE INDEX IF NOT EXISTS session_tags_tag_idx ON session_tags(tag);
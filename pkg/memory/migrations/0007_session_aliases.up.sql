@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS session_aliases (
+	alias TEXT PRIMARY KEY,
+	session_key TEXT NOT NULL,
+	created_at_ms INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS session_aliases_session_idx ON session_aliases(session_key);
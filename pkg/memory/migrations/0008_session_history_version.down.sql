@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS events_history_au;
+DROP TRIGGER IF EXISTS events_history_ad;
+
+ALTER TABLE sessions DROP COLUMN history_version;
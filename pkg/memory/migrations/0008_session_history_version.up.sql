@@ -0,0 +1,11 @@
+ALTER TABLE sessions ADD COLUMN history_version INTEGER NOT NULL DEFAULT 0;
+
+-- Any process that archives, rewrites or deletes stored events bumps the
+-- session's history_version, so a cache of its events can tell it is stale.
+CREATE TRIGGER IF NOT EXISTS events_history_au AFTER UPDATE OF archived, content ON events BEGIN
+	UPDATE sessions SET history_version = history_version + 1 WHERE session_key = new.session_key;
+END;
+
+CREATE TRIGGER IF NOT EXISTS events_history_ad AFTER DELETE ON events BEGIN
+	UPDATE sessions SET history_version = history_version + 1 WHERE session_key = old.session_key;
+END;
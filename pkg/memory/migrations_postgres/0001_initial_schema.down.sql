@@ -0,0 +1,21 @@
+-- Drops the baseline schema. This destroys all memory data.
+
+DROP TABLE IF EXISTS persona_signals;
+DROP TABLE IF EXISTS persona_revisions;
+DROP TABLE IF EXISTS persona_candidates;
+DROP TABLE IF EXISTS persona_profiles;
+DROP TABLE IF EXISTS memory_audit_log;
+DROP TABLE IF EXISTS memory_metrics;
+DROP TABLE IF EXISTS memory_jobs;
+DROP TABLE IF EXISTS retrieval_cache;
+DROP TABLE IF EXISTS session_index_state;
+DROP TABLE IF EXISTS memory_embedding_cache;
+DROP TABLE IF EXISTS memory_embeddings;
+DROP TABLE IF EXISTS memory_links;
+DROP TABLE IF EXISTS memory_observations;
+DROP TABLE IF EXISTS memory_items;
+DROP TABLE IF EXISTS session_snapshots;
+DROP TABLE IF EXISTS session_compactions;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS session_provider_states;
+DROP TABLE IF EXISTS sessions;
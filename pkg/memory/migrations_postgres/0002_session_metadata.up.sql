@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS session_metadata (
+	session_key TEXT NOT NULL,
+	key TEXT NOT NULL,
+	value TEXT NOT NULL DEFAULT '',
+	updated_at_ms BIGINT NOT NULL,
+	PRIMARY KEY(session_key, key)
+);
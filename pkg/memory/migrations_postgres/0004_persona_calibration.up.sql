@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS persona_calibration (
+	source TEXT NOT NULL,
+	field_path TEXT NOT NULL,
+	accept_rate DOUBLE PRECISION NOT NULL,
+	sample_count BIGINT NOT NULL,
+	updated_at_ms BIGINT NOT NULL,
+	PRIMARY KEY(source, field_path)
+);
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS events_history_au ON events;
+DROP TRIGGER IF EXISTS events_history_ad ON events;
+DROP FUNCTION IF EXISTS bump_session_history_version();
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS history_version;
@@ -0,0 +1,18 @@
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS history_version BIGINT NOT NULL DEFAULT 0;
+
+-- Any process that archives, rewrites or deletes stored events bumps the
+-- session's history_version, so a cache of its events can tell it is stale.
+CREATE OR REPLACE FUNCTION bump_session_history_version() RETURNS trigger AS $$
+BEGIN
+	UPDATE sessions SET history_version = history_version + 1 WHERE session_key = OLD.session_key;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS events_history_au ON events;
+CREATE TRIGGER events_history_au AFTER UPDATE OF archived, content ON events
+	FOR EACH ROW EXECUTE FUNCTION bump_session_history_version();
+
+DROP TRIGGER IF EXISTS events_history_ad ON events;
+CREATE TRIGGER events_history_ad AFTER DELETE ON events
+	FOR EACH ROW EXECUTE FUNCTION bump_session_history_version();
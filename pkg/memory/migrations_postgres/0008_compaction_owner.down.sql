@@ -0,0 +1 @@
+ALTER TABLE session_compactions DROP COLUMN IF EXISTS owner;
@@ -0,0 +1,3 @@
+-- The process that started a compaction, as host:pid:token, so startup
+-- recovery can tell an abandoned run from one still in progress.
+ALTER TABLE session_compactions ADD COLUMN IF NOT EXISTS owner TEXT NOT NULL DEFAULT '';
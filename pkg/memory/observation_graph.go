@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ObservationStep is one observation in an item's timeline, annotated with
+// how it differs from the observation before it.
+type ObservationStep struct {
+	MemoryObservation
+	ContentChanged  bool
+	ConfidenceDelta float64
+}
+
+// ObservationCluster groups consecutive observations that came from the same
+// source event, so repeated extractions of one message read as a single step.
+type ObservationCluster struct {
+	EventID    string
+	SessionKey string
+	Steps      []ObservationStep
+}
+
+// GetObservationTimeline returns up to limit observations for an item,
+// oldest first, so the first entry is when the item was first observed.
+func (s *SQLiteStore) GetObservationTimeline(ctx context.Context, itemID string, limit int) ([]MemoryObservation, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, item_id, session_key, event_id, observed_at_ms, confidence, content, extractor, action, metadata_json
+FROM memory_observations
+WHERE item_id = ?
+ORDER BY observed_at_ms ASC, rowid ASC
+LIMIT ?`, itemID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("observation timeline: %w", err)
+	}
+	defer rows.Close()
+	return scanObservationTimeline(rows)
+}
+
+// GetObservationTimeline returns up to limit observations for an item, oldest
+// first. Observations recorded in the same millisecond are ordered by ID, as
+// PostgreSQL has no insertion order to fall back on.
+func (s *PostgreSQLStore) GetObservationTimeline(ctx context.Context, itemID string, limit int) ([]MemoryObservation, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, item_id, session_key, event_id, observed_at_ms, confidence, content, extractor, action, metadata_json
+FROM memory_observations
+WHERE item_id = ?
+ORDER BY observed_at_ms ASC, id ASC
+LIMIT ?`, itemID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("observation timeline: %w", err)
+	}
+	defer rows.Close()
+	return scanObservationTimeline(rows)
+}
+
+func scanObservationTimeline(rows *sql.Rows) ([]MemoryObservation, error) {
+	out := []MemoryObservation{}
+	for rows.Next() {
+		var obs MemoryObservation
+		var rawMeta string
+		if err := rows.Scan(&obs.ID, &obs.ItemID, &obs.SessionKey, &obs.EventID, &obs.ObservedAt, &obs.Confidence, &obs.Content, &obs.Extractor, &obs.Action, &rawMeta); err != nil {
+			return nil, fmt.Errorf("scan memory observation: %w", err)
+		}
+		obs.Metadata = decodeMap(rawMeta)
+		out = append(out, obs)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate memory observations: %w", err)
+	}
+	return out, nil
+}
+
+// FindMemoryItemsByKey returns every item stored under key, across users,
+// kinds and scopes, including deleted ones so their history stays auditable.
+func (s *SQLiteStore) FindMemoryItemsByKey(ctx context.Context, key string) ([]MemoryItem, error) {
+	return findMemoryItemsByKey(ctx, s.db, key)
+}
+
+func (s *PostgreSQLStore) FindMemoryItemsByKey(ctx context.Context, key string) ([]MemoryItem, error) {
+	return findMemoryItemsByKey(ctx, s.db, key)
+}
+
+func findMemoryItemsByKey(ctx context.Context, db sqlQuerier, key string) ([]MemoryItem, error) {
+	rows, err := db.QueryContext(ctx, `
+SELECT id, user_id, agent_id, scope_type, scope_id, session_key, kind, item_key, content, confidence, weight, source_event_id, first_seen_at_ms, last_seen_at_ms, expires_at_ms, deleted_at_ms, evergreen, metadata_json
+FROM memory_items
+WHERE item_key = ?
+ORDER BY first_seen_at_ms ASC`, strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("find memory items by key: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMemoryItems(rows)
+}
+
+// BuildObservationTimeline annotates observations (oldest first) with content
+// and confidence changes and clusters consecutive ones by source event.
+func BuildObservationTimeline(observations []MemoryObservation) []ObservationCluster {
+	var clusters []ObservationCluster
+	var prev *MemoryObservation
+	for i := range observations {
+		obs := observations[i]
+		step := ObservationStep{MemoryObservation: obs}
+		if prev != nil {
+			step.ContentChanged = strings.TrimSpace(obs.Content) != strings.TrimSpace(prev.Content)
+			step.ConfidenceDelta = obs.Confidence - prev.Confidence
+		}
+		prev = &observations[i]
+
+		if n := len(clusters); n > 0 && obs.EventID != "" && clusters[n-1].EventID == obs.EventID {
+			clusters[n-1].Steps = append(clusters[n-1].Steps, step)
+			continue
+		}
+		clusters = append(clusters, ObservationCluster{
+			EventID:    obs.EventID,
+			SessionKey: obs.SessionKey,
+			Steps:      []ObservationStep{step},
+		})
+	}
+	return clusters
+}
@@ -0,0 +1,210 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// personaCalibrationInterval is how often acceptance history is
+	// recomputed.
+	personaCalibrationInterval = 7 * 24 * time.Hour
+	// personaCalibrationMinSamples is the number of decided candidates a
+	// source and field path need before their confidence is adjusted.
+	personaCalibrationMinSamples = 10
+	personaCalibrationMinFactor  = 0.6
+	personaCalibrationMaxFactor  = 1.2
+)
+
+// PersonaCalibration is the acceptance history of candidates from one source
+// ("heuristic", "llm", ...) for one field path.
+type PersonaCalibration struct {
+	Source      string
+	FieldPath   string
+	AcceptRate  float64
+	SampleCount int
+	UpdatedAtMS int64
+}
+
+// Factor is the multiplier applied to the raw confidence of new candidates:
+// 1 below personaCalibrationMinSamples, otherwise rising linearly from 0.6
+// for a field that is never accepted to 1.2 for one that always is. A field
+// accepted two times in three keeps its raw confidence.
+func (c PersonaCalibration) Factor() float64 {
+	if c.SampleCount < personaCalibrationMinSamples {
+		return 1
+	}
+	factor := personaCalibrationMinFactor + (personaCalibrationMaxFactor-personaCalibrationMinFactor)*c.AcceptRate
+	return clampFloat64(factor, personaCalibrationMinFactor, personaCalibrationMaxFactor)
+}
+
+func personaCalibrationKey(source, fieldPath string) string {
+	return source + "|" + fieldPath
+}
+
+// calibrationDB is the part of a store's database handle the calibrator
+// uses; *sql.DB and the Postgres wrapper both satisfy it.
+type calibrationDB interface {
+	sqlTx
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// PersonaConfidenceCalibrator derives per-source, per-field confidence
+// factors from which persona candidates were applied and which were
+// rejected, and stores them in persona_calibration.
+type PersonaConfidenceCalibrator struct {
+	db calibrationDB
+}
+
+func NewPersonaConfidenceCalibrator(db calibrationDB) *PersonaConfidenceCalibrator {
+	return &PersonaConfidenceCalibrator{db: db}
+}
+
+// Recompute rebuilds persona_calibration from the candidate history. A
+// candidate counts as accepted once applied and as inaccurate when the
+// policy rejected it; rejections for no change or low confidence say
+// nothing about accuracy and are skipped, as are pending and deferred
+// candidates.
+func (c *PersonaConfidenceCalibrator) Recompute(ctx context.Context, nowMS int64) ([]PersonaCalibration, error) {
+	rows, err := c.db.QueryContext(ctx, `
+SELECT source, field_path,
+	SUM(CASE WHEN status = ? THEN 1 ELSE 0 END),
+	COUNT(*)
+FROM persona_candidates
+WHERE status IN (?, ?)
+AND rejected_reason NOT IN (?, ?)
+GROUP BY source, field_path
+ORDER BY source, field_path`,
+		personaCandidateApplied, personaCandidateApplied, personaCandidateRejected, "no_change", PersonaReasonLowConfidence)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate persona candidates: %w", err)
+	}
+	var out []PersonaCalibration
+	for rows.Next() {
+		var (
+			cal      PersonaCalibration
+			accepted int
+		)
+		if err := rows.Scan(&cal.Source, &cal.FieldPath, &accepted, &cal.SampleCount); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan persona calibration: %w", err)
+		}
+		cal.AcceptRate = float64(accepted) / float64(cal.SampleCount)
+		cal.UpdatedAtMS = nowMS
+		out = append(out, cal)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("iterate persona candidates: %w", err)
+	}
+	rows.Close()
+
+	for _, cal := range out {
+		if _, err := c.db.ExecContext(ctx, `
+INSERT INTO persona_calibration(source, field_path, accept_rate, sample_count, updated_at_ms)
+VALUES(?, ?, ?, ?, ?)
+ON CONFLICT(source, field_path) DO UPDATE SET
+	accept_rate = excluded.accept_rate,
+	sample_count = excluded.sample_count,
+	updated_at_ms = excluded.updated_at_ms`,
+			cal.Source, cal.FieldPath, cal.AcceptRate, cal.SampleCount, cal.UpdatedAtMS); err != nil {
+			return nil, fmt.Errorf("store persona calibration: %w", err)
+		}
+	}
+	if _, err := c.db.ExecContext(ctx, `DELETE FROM persona_calibration WHERE updated_at_ms < ?`, nowMS); err != nil {
+		return nil, fmt.Errorf("prune persona calibration: %w", err)
+	}
+	return out, nil
+}
+
+// Load returns the stored calibration rows.
+func (c *PersonaConfidenceCalibrator) Load(ctx context.Context) ([]PersonaCalibration, error) {
+	rows, err := c.db.QueryContext(ctx, `
+SELECT source, field_path, accept_rate, sample_count, updated_at_ms
+FROM persona_calibration
+ORDER BY source, field_path`)
+	if err != nil {
+		return nil, fmt.Errorf("load persona calibration: %w", err)
+	}
+	defer rows.Close()
+	var out []PersonaCalibration
+	for rows.Next() {
+		var cal PersonaCalibration
+		if err := rows.Scan(&cal.Source, &cal.FieldPath, &cal.AcceptRate, &cal.SampleCount, &cal.UpdatedAtMS); err != nil {
+			return nil, fmt.Errorf("scan persona calibration: %w", err)
+		}
+		out = append(out, cal)
+	}
+	return out, rows.Err()
+}
+
+// personaCalibrationFactors keys the factors that differ from 1 by source
+// and field path.
+func personaCalibrationFactors(cals []PersonaCalibration) map[string]float64 {
+	factors := map[string]float64{}
+	for _, cal := range cals {
+		if f := cal.Factor(); f != 1 {
+			factors[personaCalibrationKey(cal.Source, cal.FieldPath)] = f
+		}
+	}
+	return factors
+}
+
+// personaCalibrator returns a calibrator over the store's database, or nil
+// for stores it cannot read.
+func (s *Service) personaCalibrator() *PersonaConfidenceCalibrator {
+	switch store := s.store.(type) {
+	case *SQLiteStore:
+		return NewPersonaConfidenceCalibrator(store.db)
+	case *PostgreSQLStore:
+		return NewPersonaConfidenceCalibrator(store.db)
+	default:
+		return nil
+	}
+}
+
+// runPersonaCalibrationIfDue hands the persona manager the stored
+// calibration, recomputing it first when it is a week old or missing.
+func (s *Service) runPersonaCalibrationIfDue(ctx context.Context, nowMS int64) {
+	intervalMS := personaCalibrationInterval.Milliseconds()
+	if s.persona == nil || (s.lastPersonaCalibration > 0 && nowMS-s.lastPersonaCalibration < intervalMS) {
+		return
+	}
+	calibrator := s.personaCalibrator()
+	if calibrator == nil {
+		return
+	}
+	cals, err := calibrator.Load(ctx)
+	if err != nil {
+		return
+	}
+	computedAt := int64(0)
+	for _, cal := range cals {
+		if cal.UpdatedAtMS > computedAt {
+			computedAt = cal.UpdatedAtMS
+		}
+	}
+	if computedAt == 0 || nowMS-computedAt >= intervalMS {
+		if cals, err = calibrator.Recompute(ctx, nowMS); err != nil {
+			_ = s.store.AddMetric(ctx, "memory.persona.calibration.error", 1, nil)
+			return
+		}
+		computedAt = nowMS
+		_ = s.store.AddMetric(ctx, "memory.persona.calibration.fields", float64(len(cals)), map[string]string{
+			"samples": strconv.Itoa(personaCalibrationSamples(cals)),
+		})
+	}
+	s.persona.SetCalibration(personaCalibrationFactors(cals))
+	s.lastPersonaCalibration = computedAt
+}
+
+func personaCalibrationSamples(cals []PersonaCalibration) int {
+	total := 0
+	for _, cal := range cals {
+		total += cal.SampleCount
+	}
+	return total
+}
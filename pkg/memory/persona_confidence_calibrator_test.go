@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPersonaCalibration_Factor(t *testing.T) {
+	cases := []struct {
+		cal  PersonaCalibration
+		want float64
+	}{
+		{PersonaCalibration{AcceptRate: 0, SampleCount: 3}, 1},
+		{PersonaCalibration{AcceptRate: 0, SampleCount: 10}, 0.6},
+		{PersonaCalibration{AcceptRate: 0.5, SampleCount: 10}, 0.9},
+		{PersonaCalibration{AcceptRate: 1, SampleCount: 40}, 1.2},
+	}
+	for _, tc := range cases {
+		if got := tc.cal.Factor(); got < tc.want-1e-9 || got > tc.want+1e-9 {
+			t.Fatalf("factor for %+v = %v, want %v", tc.cal, got, tc.want)
+		}
+	}
+}
+
+func TestPersonaConfidenceCalibrator_RecomputeAndApply(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+
+	add := func(source, fieldPath, status, reason string, n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			id := fmt.Sprintf("pcd-%s-%s-%s-%s-%d", source, fieldPath, status, reason, i)
+			if _, err := store.db.ExecContext(ctx, `
+INSERT INTO persona_candidates(id, user_id, agent_id, session_key, turn_id, field_path, operation, value, confidence, evidence, source, status, rejected_reason, created_at_ms)
+VALUES(?, 'u1', 'dotagent', 's1', 't1', ?, 'set', ?, 0.8, '', ?, ?, ?, 1)`,
+				id, fieldPath, id, source, status, reason); err != nil {
+				t.Fatalf("insert candidate: %v", err)
+			}
+		}
+	}
+	add("llm", "user.timezone", personaCandidateApplied, "", 3)
+	add("llm", "user.timezone", personaCandidateRejected, PersonaReasonStableFieldConflict, 9)
+	// Neither counts towards accuracy.
+	add("llm", "user.timezone", personaCandidateRejected, "no_change", 20)
+	add("llm", "user.timezone", personaCandidatePending, "", 5)
+	add("heuristic", "user.name", personaCandidateApplied, "", 2)
+
+	calibrator := NewPersonaConfidenceCalibrator(store.db)
+	cals, err := calibrator.Recompute(ctx, 1000)
+	if err != nil {
+		t.Fatalf("recompute: %v", err)
+	}
+	if len(cals) != 2 {
+		t.Fatalf("expected 2 calibration rows, got %+v", cals)
+	}
+	loaded, err := calibrator.Load(ctx)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(loaded) != 2 || loaded[1].Source != "llm" || loaded[1].SampleCount != 12 || loaded[1].AcceptRate != 0.25 || loaded[1].UpdatedAtMS != 1000 {
+		t.Fatalf("unexpected calibration rows %+v", loaded)
+	}
+	factors := personaCalibrationFactors(loaded)
+	if len(factors) != 1 || factors["llm|user.timezone"] < 0.749 || factors["llm|user.timezone"] > 0.751 {
+		t.Fatalf("unexpected factors %+v", factors)
+	}
+
+	pm := NewPersonaManager(store, t.TempDir(), nil, PersonaFileSyncExportOnly, nil)
+	pm.SetCalibration(factors)
+	out := pm.normalizeCandidates([]PersonaUpdateCandidate{
+		{FieldPath: "user.timezone", Operation: "set", Value: "UTC", Confidence: 0.8, Source: "llm"},
+		{FieldPath: "user.timezone", Operation: "set", Value: "Europe/Paris", Confidence: 0.8},
+	}, "s1", "t2", "u1", "dotagent")
+	if len(out) != 2 || out[0].Confidence < 0.599 || out[0].Confidence > 0.601 || out[1].Confidence != 0.8 {
+		t.Fatalf("unexpected calibrated candidates %+v", out)
+	}
+
+	// Rows no longer backed by history are dropped on the next recompute.
+	if _, err := store.db.ExecContext(ctx, `DELETE FROM persona_candidates WHERE source = 'heuristic'`); err != nil {
+		t.Fatalf("delete candidates: %v", err)
+	}
+	if _, err := calibrator.Recompute(ctx, 2000); err != nil {
+		t.Fatalf("recompute: %v", err)
+	}
+	if loaded, _ := calibrator.Load(ctx); len(loaded) != 1 {
+		t.Fatalf("expected stale row to be pruned, got %+v", loaded)
+	}
+}
+
+func TestRunPersonaCalibrationIfDue_RecomputesWeekly(t *testing.T) {
+	ctx := context.Background()
+	svc, err := NewService(Config{
+		Workspace:  t.TempDir(),
+		AgentID:    "dotagent",
+		WorkerPoll: 10 * time.Second,
+	}, nil)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	defer svc.Close()
+	store := svc.store.(*SQLiteStore)
+
+	start := time.Now().UnixMilli()
+	svc.runPersonaCalibrationIfDue(ctx, start)
+	if svc.lastPersonaCalibration != start {
+		t.Fatalf("expected calibration to run, last=%d", svc.lastPersonaCalibration)
+	}
+	if _, err := store.db.ExecContext(ctx, `
+INSERT INTO persona_calibration(source, field_path, accept_rate, sample_count, updated_at_ms)
+VALUES('llm', 'user.name', 0, 50, ?)`, start); err != nil {
+		t.Fatalf("seed calibration: %v", err)
+	}
+
+	// Within the week the stored rows are left alone.
+	svc.runPersonaCalibrationIfDue(ctx, start+time.Hour.Milliseconds())
+	svc.lastPersonaCalibration = 0
+	svc.runPersonaCalibrationIfDue(ctx, start+time.Hour.Milliseconds())
+	if svc.lastPersonaCalibration != start {
+		t.Fatalf("expected stored calibration to be reused, last=%d", svc.lastPersonaCalibration)
+	}
+	if got := svc.persona.calibration["llm|user.name"]; got != 0.6 {
+		t.Fatalf("expected stored factor to be loaded, got %v", got)
+	}
+
+	// A week later it is recomputed from the (empty) candidate history.
+	later := start + personaCalibrationInterval.Milliseconds()
+	svc.runPersonaCalibrationIfDue(ctx, later)
+	if svc.lastPersonaCalibration != later || len(svc.persona.calibration) != 0 {
+		t.Fatalf("expected weekly recompute, last=%d factors=%+v", svc.lastPersonaCalibration, svc.persona.calibration)
+	}
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strings"
+)
+
+const (
+	PersonaExperimentControl   = "control"
+	PersonaExperimentTreatment = "treatment"
+
+	// personaExperimentMetadataKey is the session_metadata key holding a
+	// session's experiment group.
+	personaExperimentMetadataKey = "persona_experiment_group"
+)
+
+// PersonaExperiment compares two agent personas across sessions. Each session
+// is assigned to a group by hashing its key, and that group's profile replaces
+// the agent identity and soul in the persona prompt. What has been learned
+// about the user is kept in both groups. A nil profile leaves the stored
+// persona unchanged, so a nil ControlProfile compares the current persona
+// against TreatmentProfile.
+type PersonaExperiment struct {
+	ControlProfile   *PersonaProfile `json:"control_profile,omitempty"`
+	TreatmentProfile *PersonaProfile `json:"treatment_profile,omitempty"`
+}
+
+// LoadPersonaExperiment reads the control and treatment profiles from JSON
+// files in the PersonaProfile format. An empty path leaves that group on the
+// stored persona.
+func LoadPersonaExperiment(controlPath, treatmentPath string) (*PersonaExperiment, error) {
+	control, err := loadExperimentProfile(controlPath)
+	if err != nil {
+		return nil, fmt.Errorf("load control profile: %w", err)
+	}
+	treatment, err := loadExperimentProfile(treatmentPath)
+	if err != nil {
+		return nil, fmt.Errorf("load treatment profile: %w", err)
+	}
+	return &PersonaExperiment{ControlProfile: control, TreatmentProfile: treatment}, nil
+}
+
+func loadExperimentProfile(path string) (*PersonaProfile, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var profile PersonaProfile
+	if err := json.Unmarshal(raw, &profile); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &profile, nil
+}
+
+// assignPersonaExperimentGroup deterministically assigns sessionKey to a group.
+func assignPersonaExperimentGroup(sessionKey string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(sessionKey))
+	if h.Sum32()%2 == 1 {
+		return PersonaExperimentTreatment
+	}
+	return PersonaExperimentControl
+}
+
+// profileFor returns the persona override for group, or nil when the group
+// uses the stored persona.
+func (e *PersonaExperiment) profileFor(group string) *PersonaProfile {
+	if e == nil {
+		return nil
+	}
+	switch group {
+	case PersonaExperimentControl:
+		return e.ControlProfile
+	case PersonaExperimentTreatment:
+		return e.TreatmentProfile
+	default:
+		return nil
+	}
+}
+
+// applyTo replaces the agent side of profile with the group's override.
+func (e *PersonaExperiment) applyTo(profile PersonaProfile, group string) PersonaProfile {
+	override := e.profileFor(group)
+	if override == nil {
+		return profile
+	}
+	profile.Identity = override.Identity
+	profile.Soul = override.Soul
+	return profile
+}
+
+// personaExperimentGroup returns the session's experiment group, assigning
+// and recording it on first use so later turns stay in the same group. It
+// returns "" when no persona experiment is running.
+func (s *Service) personaExperimentGroup(ctx context.Context, sessionKey string) string {
+	if s.cfg.PersonaExperiment == nil || strings.TrimSpace(sessionKey) == "" {
+		return ""
+	}
+	if stored, err := s.store.GetSessionMetadata(ctx, sessionKey, personaExperimentMetadataKey); err == nil {
+		switch stored {
+		case PersonaExperimentControl, PersonaExperimentTreatment:
+			return stored
+		}
+	}
+	group := assignPersonaExperimentGroup(sessionKey)
+	_ = s.store.SetSessionMetadata(ctx, sessionKey, personaExperimentMetadataKey, group)
+	return group
+}
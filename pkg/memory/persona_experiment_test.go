@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPersonaExperiment_AssignsSessionsAndSwapsPersona(t *testing.T) {
+	ctx := context.Background()
+	workspace := t.TempDir()
+	treatment := defaultPersonaProfile("", "")
+	treatment.Identity.AgentName = "Sparky"
+	treatment.Soul.Voice = "Playful and upbeat"
+	raw, _ := json.Marshal(treatment)
+	treatmentPath := filepath.Join(workspace, "treatment.json")
+	if err := os.WriteFile(treatmentPath, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	exp, err := LoadPersonaExperiment("", treatmentPath)
+	if err != nil {
+		t.Fatalf("load experiment: %v", err)
+	}
+
+	svc, err := NewService(Config{
+		Workspace:         workspace,
+		AgentID:           "dotagent",
+		WorkerPoll:        40 * time.Millisecond,
+		PersonaFileSync:   PersonaFileSyncDisabled,
+		PersonaExperiment: exp,
+	}, nil)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	defer svc.Close()
+
+	sessions := map[string]string{}
+	for i := 0; len(sessions) < 2 && i < 64; i++ {
+		key := "cli:experiment-" + string(rune('a'+i))
+		sessions[assignPersonaExperimentGroup(key)] = key
+	}
+	controlKey, treatmentKey := sessions[PersonaExperimentControl], sessions[PersonaExperimentTreatment]
+	if controlKey == "" || treatmentKey == "" {
+		t.Fatalf("expected sessions in both groups, got %v", sessions)
+	}
+
+	for key, want := range map[string]string{controlKey: "DotAgent", treatmentKey: "Sparky"} {
+		group := svc.personaExperimentGroup(ctx, key)
+		prompt, err := svc.persona.BuildExperimentPrompt(ctx, group, "u-exp", "dotagent", "", 2000)
+		if err != nil {
+			t.Fatalf("build prompt: %v", err)
+		}
+		if !strings.Contains(prompt, "Agent name: "+want) {
+			t.Fatalf("expected %s persona for %s group:\n%s", want, group, prompt)
+		}
+		stored, err := svc.store.GetSessionMetadata(ctx, key, personaExperimentMetadataKey)
+		if err != nil || stored != group {
+			t.Fatalf("expected assignment %q recorded for %s, got %q (%v)", group, key, stored, err)
+		}
+	}
+
+	// A recorded assignment wins over the hash.
+	if err := svc.store.SetSessionMetadata(ctx, controlKey, personaExperimentMetadataKey, PersonaExperimentTreatment); err != nil {
+		t.Fatalf("set metadata: %v", err)
+	}
+	if group := svc.personaExperimentGroup(ctx, controlKey); group != PersonaExperimentTreatment {
+		t.Fatalf("expected the stored group to be kept, got %q", group)
+	}
+
+	if _, _, err := svc.RecordUserTurn(ctx, Event{SessionKey: treatmentKey, Content: "hello there"}, "u-exp"); err != nil {
+		t.Fatalf("record user turn: %v", err)
+	}
+	var value float64
+	var labels string
+	row := svc.store.(*SQLiteStore).db.QueryRowContext(ctx, `SELECT value, labels_json FROM memory_metrics WHERE metric = 'persona.experiment.treatment'`)
+	if err := row.Scan(&value, &labels); err != nil {
+		t.Fatalf("read experiment metric: %v", err)
+	}
+	if value != 1 || !strings.Contains(labels, `"group":"treatment"`) {
+		t.Fatalf("unexpected experiment metric value=%v labels=%s", value, labels)
+	}
+}
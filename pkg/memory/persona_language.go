@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// languageDetectMinRunes skips detection for short messages such as
+	// "ok" or "merci", which say little about the user's language.
+	languageDetectMinRunes = 20
+	// languageDetectMinScore is the detection score below which no language
+	// is reported.
+	languageDetectMinScore = 0.5
+	// personaDetectedLanguageConfidence is below the explicit-declaration
+	// confidence so a stated language always wins. Detection only proposes a
+	// language while user.language is empty.
+	personaDetectedLanguageConfidence = 0.55
+)
+
+// languageScripts maps non-Latin scripts to the language they most likely
+// indicate. Han is handled separately because Japanese mixes it with kana.
+var languageScripts = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hangul, "korean"},
+	{unicode.Cyrillic, "russian"},
+	{unicode.Arabic, "arabic"},
+	{unicode.Devanagari, "hindi"},
+	{unicode.Bengali, "bengali"},
+	{unicode.Thai, "thai"},
+	{unicode.Greek, "greek"},
+}
+
+// latinStopwords are frequent function words that tell Latin-script languages
+// apart. Words shared by several languages are listed under each of them.
+var latinStopwords = map[string][]string{
+	"english":    {"the", "and", "is", "are", "you", "that", "with", "for", "this", "have", "not", "what", "can", "my", "it", "of", "to", "in", "be", "was"},
+	"spanish":    {"el", "la", "los", "las", "que", "de", "y", "es", "en", "un", "una", "por", "para", "con", "no", "mi", "pero", "como", "está", "muy"},
+	"french":     {"le", "la", "les", "et", "est", "de", "des", "un", "une", "je", "vous", "pas", "que", "pour", "avec", "mon", "ce", "dans", "sur", "qui"},
+	"german":     {"der", "die", "das", "und", "ist", "nicht", "ich", "du", "sie", "mit", "ein", "eine", "zu", "auf", "für", "mein", "auch", "wie", "den", "was"},
+	"italian":    {"il", "la", "che", "di", "e", "è", "non", "un", "una", "per", "con", "sono", "mi", "ho", "come", "questo", "della", "del", "gli", "anche"},
+	"portuguese": {"o", "a", "os", "que", "de", "e", "é", "não", "um", "uma", "para", "com", "do", "da", "meu", "em", "mas", "você", "está", "isso"},
+	"dutch":      {"de", "het", "een", "en", "is", "niet", "ik", "je", "van", "dat", "met", "voor", "op", "zijn", "mijn", "ook", "wat", "er", "maar", "hoe"},
+}
+
+// detectLanguage guesses the language of content from its script and, for
+// Latin text, from common function words. It returns a lowercase language
+// name and a score in [0, 1], or "" when the text is too short or ambiguous.
+func detectLanguage(content string) (string, float64) {
+	content = strings.TrimSpace(content)
+	if len([]rune(content)) < languageDetectMinRunes {
+		return "", 0
+	}
+
+	var letters, latin, han, kana int
+	scriptCounts := make([]int, len(languageScripts))
+	ukrainian := false
+	for _, r := range content {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		default:
+			for i, script := range languageScripts {
+				if unicode.Is(script.table, r) {
+					scriptCounts[i]++
+					break
+				}
+			}
+			if strings.ContainsRune("іїєґІЇЄҐ", r) {
+				ukrainian = true
+			}
+		}
+	}
+	if letters == 0 {
+		return "", 0
+	}
+
+	best, bestCount := "", 0
+	if kana > 0 && kana+han > bestCount {
+		best, bestCount = "japanese", kana+han
+	} else if han > bestCount {
+		best, bestCount = "chinese", han
+	}
+	for i, script := range languageScripts {
+		if scriptCounts[i] > bestCount {
+			best, bestCount = script.lang, scriptCounts[i]
+		}
+	}
+	if best == "russian" && ukrainian {
+		best = "ukrainian"
+	}
+	if bestCount > latin {
+		score := float64(bestCount) / float64(letters)
+		if score < languageDetectMinScore {
+			return "", 0
+		}
+		return best, score
+	}
+	return detectLatinLanguage(content)
+}
+
+// detectLatinLanguage scores content against latinStopwords. The score
+// compares the winner's hits with the runner-up's, so text that matches
+// several languages about equally is rejected.
+func detectLatinLanguage(content string) (string, float64) {
+	words := strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) < 3 {
+		return "", 0
+	}
+	hits := map[string]int{}
+	for _, word := range words {
+		for lang, stopwords := range latinStopwords {
+			for _, stopword := range stopwords {
+				if word == stopword {
+					hits[lang]++
+					break
+				}
+			}
+		}
+	}
+
+	best, bestHits := "", 0
+	for lang, n := range hits {
+		if n > bestHits || (n == bestHits && lang < best) {
+			best, bestHits = lang, n
+		}
+	}
+	runnerUp := 0
+	for lang, n := range hits {
+		if lang != best && n > runnerUp {
+			runnerUp = n
+		}
+	}
+	if bestHits < 2 || bestHits == runnerUp {
+		return "", 0
+	}
+	score := float64(bestHits) / float64(bestHits+runnerUp)
+	if score < languageDetectMinScore {
+		return "", 0
+	}
+	return best, score
+}
@@ -49,6 +49,15 @@ var personaKnownLanguages = map[string]struct{}{
 	"turkish": {}, "ukrainian": {}, "urdu": {}, "vietnamese": {},
 }
 
+// personaLanguageCodes maps ISO 639-1 codes to the names in
+// personaKnownLanguages, so "es" and "spanish" compare equal.
+var personaLanguageCodes = map[string]string{
+	"ar": "arabic", "bn": "bengali", "zh": "chinese", "cs": "czech", "da": "danish", "nl": "dutch", "en": "english", "fi": "finnish",
+	"fr": "french", "de": "german", "el": "greek", "hi": "hindi", "id": "indonesian", "it": "italian", "ja": "japanese", "ko": "korean",
+	"no": "norwegian", "nb": "norwegian", "pl": "polish", "pt": "portuguese", "ro": "romanian", "ru": "russian", "es": "spanish", "sv": "swedish",
+	"th": "thai", "tr": "turkish", "uk": "ukrainian", "ur": "urdu", "vi": "vietnamese",
+}
+
 var personaNonLocationTokens = map[string]struct{}{
 	"rush": {}, "hurry": {}, "middle": {}, "meeting": {}, "queue": {}, "process": {}, "trouble": {}, "mood": {}, "flow": {},
 	"pain": {}, "love": {}, "fear": {}, "sync": {}, "session": {}, "chat": {}, "call": {},
@@ -70,11 +79,18 @@ type promptCacheEntry struct {
 }
 
 type PersonaManager struct {
-	store     Store
-	workspace string
-	extractor PersonaExtractionFunc
-	fileSync  PersonaFileSyncMode
-	policy    *PersonaPolicyEngine
+	store      Store
+	workspace  string
+	extractor  PersonaExtractionFunc
+	fileSync   PersonaFileSyncMode
+	policy     *PersonaPolicyEngine
+	privacy    PersonaPrivacyMode
+	experiment *PersonaExperiment
+	// triggers gate LLM extraction; nil extracts on every turn.
+	triggers []*regexp.Regexp
+	// calibration scales candidate confidence by source and field path; see
+	// PersonaConfidenceCalibrator.
+	calibration map[string]float64
 
 	cacheTTL time.Duration
 
@@ -124,11 +140,13 @@ func (pm *PersonaManager) EmitCandidatesForTurn(ctx context.Context, sessionKey,
 		profile = defaultPersonaProfile(userID, agentID)
 	}
 
-	heuristics := pm.extractHeuristicCandidates(turnEvents, sessionKey, turnID, userID, agentID)
+	heuristics := pm.extractHeuristicCandidates(turnEvents, sessionKey, turnID, userID, agentID, profile.User.Language)
 
 	llmCandidates := []PersonaUpdateCandidate{}
 	extractionOutcome := "llm_empty"
-	if pm.extractor != nil {
+	if pm.extractor != nil && !pm.matchesExtractionTrigger(turnEvents) {
+		extractionOutcome = "llm_skipped"
+	} else if pm.extractor != nil {
 		req := PersonaExtractionRequest{
 			UserID:          userID,
 			AgentID:         agentID,
@@ -339,6 +357,71 @@ func (pm *PersonaManager) ApplyPendingForTurn(ctx context.Context, sessionKey, t
 }
 
 func (pm *PersonaManager) BuildPrompt(ctx context.Context, userID, agentID, sessionIntent string, budgetTokens int) (string, error) {
+	return pm.BuildExperimentPrompt(ctx, "", userID, agentID, sessionIntent, budgetTokens)
+}
+
+// SetExtractionTriggers limits LLM persona extraction to turns where a user
+// message matches one of the regex patterns. Invalid patterns are ignored;
+// an empty list extracts on every turn.
+func (pm *PersonaManager) SetExtractionTriggers(patterns []string) {
+	triggers := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+		if re, err := regexp.Compile(pattern); err == nil {
+			triggers = append(triggers, re)
+		}
+	}
+	if len(triggers) == 0 {
+		triggers = nil
+	}
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.triggers = triggers
+}
+
+// SetCalibration replaces the confidence factors applied to new candidates,
+// keyed by personaCalibrationKey(source, field path).
+func (pm *PersonaManager) SetCalibration(factors map[string]float64) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.calibration = factors
+}
+
+func (pm *PersonaManager) matchesExtractionTrigger(events []Event) bool {
+	pm.mu.RLock()
+	triggers := pm.triggers
+	pm.mu.RUnlock()
+	if triggers == nil {
+		return true
+	}
+	for _, ev := range events {
+		if ev.Role != "user" {
+			continue
+		}
+		for _, re := range triggers {
+			if re.MatchString(ev.Content) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetExperiment installs the persona experiment whose profiles
+// BuildExperimentPrompt renders; nil ends the experiment.
+func (pm *PersonaManager) SetExperiment(exp *PersonaExperiment) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.experiment = exp
+	pm.promptCache = map[string]promptCacheEntry{}
+}
+
+// BuildExperimentPrompt is BuildPrompt with the agent persona taken from the
+// given experiment group's profile. An empty group, or a group without a
+// profile, renders the stored persona.
+func (pm *PersonaManager) BuildExperimentPrompt(ctx context.Context, group, userID, agentID, sessionIntent string, budgetTokens int) (string, error) {
 	profile, err := pm.store.GetPersonaProfile(ctx, userID, agentID)
 	if err != nil {
 		return "", err
@@ -353,8 +436,17 @@ func (pm *PersonaManager) BuildPrompt(ctx context.Context, userID, agentID, sess
 		}
 	}
 
+	pm.mu.RLock()
+	experiment := pm.experiment
+	pm.mu.RUnlock()
+	profile = experiment.applyTo(profile, group)
+
+	if scrubber := pm.privacyScrubber(); scrubber != nil {
+		profile, _ = scrubber.ScrubProfile(profile)
+	}
+
 	intent := detectQueryIntent(sessionIntent)
-	cacheKey := fmt.Sprintf("%s|%s|%d|%s|%d", userID, agentID, profile.Revision, intent, budgetTokens)
+	cacheKey := fmt.Sprintf("%s|%s|%d|%s|%d|%s", userID, agentID, profile.Revision, intent, budgetTokens, group)
 	now := time.Now().UnixMilli()
 
 	pm.mu.RLock()
@@ -495,20 +587,22 @@ func (pm *PersonaManager) BuildPrompt(ctx context.Context, userID, agentID, sess
 	return prompt, nil
 }
 
-func (pm *PersonaManager) RollbackLastRevision(ctx context.Context, userID, agentID string) error {
+// RollbackLastRevision undoes the most recent persona revision and returns
+// it. A zero revision is returned when there is nothing to roll back.
+func (pm *PersonaManager) RollbackLastRevision(ctx context.Context, userID, agentID string) (PersonaRevision, error) {
 	revs, err := pm.store.ListPersonaRevisions(ctx, userID, agentID, 1)
 	if err != nil {
-		return err
+		return PersonaRevision{}, err
 	}
 	if len(revs) == 0 {
-		return nil
+		return PersonaRevision{}, nil
 	}
 	profile, err := pm.store.RollbackPersonaToRevision(ctx, userID, agentID, revs[0].ID)
 	if err != nil {
-		return err
+		return PersonaRevision{}, err
 	}
 	pm.invalidatePromptCache(userID, agentID)
-	return pm.renderProfileFiles(profile)
+	return revs[0], pm.renderProfileFiles(profile)
 }
 
 func (pm *PersonaManager) loadTurnEvents(ctx context.Context, sessionKey, turnID string) ([]Event, error) {
@@ -546,8 +640,13 @@ func buildTurnTranscript(events []Event) string {
 	return strings.TrimSpace(b.String())
 }
 
-func (pm *PersonaManager) extractHeuristicCandidates(events []Event, sessionKey, turnID, userID, agentID string) []PersonaUpdateCandidate {
+// extractHeuristicCandidates derives candidates from explicit statements in
+// the turn's user messages. currentLanguage is the profile's user.language:
+// the language detected from a message only fills it in when it is empty,
+// and a stated language equal to it is not proposed again.
+func (pm *PersonaManager) extractHeuristicCandidates(events []Event, sessionKey, turnID, userID, agentID, currentLanguage string) []PersonaUpdateCandidate {
 	out := []PersonaUpdateCandidate{}
+	languageDetected := normalizePersonaLanguage(currentLanguage) != ""
 	for _, ev := range events {
 		if ev.Role != "user" {
 			continue
@@ -556,6 +655,12 @@ func (pm *PersonaManager) extractHeuristicCandidates(events []Event, sessionKey,
 		if content == "" {
 			continue
 		}
+		if !languageDetected && !personaLanguageRegex.MatchString(content) {
+			if lang, _ := detectLanguage(content); lang != "" {
+				out = append(out, newCandidate(sessionKey, turnID, userID, agentID, ev.ID, "user.language", "set", lang, personaDetectedLanguageConfidence, content, "heuristic"))
+				languageDetected = true
+			}
+		}
 		if isLikelyQuestionContent(content) && !personaQuestionDirectiveCue.MatchString(content) {
 			continue
 		}
@@ -593,7 +698,7 @@ func (pm *PersonaManager) extractHeuristicCandidates(events []Event, sessionKey,
 				continue
 			}
 			lang := normalizePersonaLanguage(m[1])
-			if lang == "" {
+			if lang == "" || samePersonaLanguage(lang, currentLanguage) {
 				continue
 			}
 			out = append(out, newCandidate(sessionKey, turnID, userID, agentID, ev.ID, "user.language", "set", lang, 0.72, content, "heuristic"))
@@ -710,6 +815,22 @@ func normalizePersonaLanguage(raw string) string {
 	return ""
 }
 
+// samePersonaLanguage reports whether two user.language values name the same
+// language, treating an ISO code (with or without a region) as its name.
+func samePersonaLanguage(a, b string) bool {
+	a, b = personaLanguageName(a), personaLanguageName(b)
+	return a != "" && a == b
+}
+
+func personaLanguageName(raw string) string {
+	lang := normalizePersonaLanguage(raw)
+	base, _, _ := strings.Cut(lang, "-")
+	if name, ok := personaLanguageCodes[base]; ok {
+		return name
+	}
+	return lang
+}
+
 func normalizePersonaLocation(raw string) string {
 	location := strings.TrimSpace(raw)
 	location = strings.Trim(location, " .,!?:;\"'")
@@ -795,10 +916,13 @@ func newCandidate(sessionKey, turnID, userID, agentID, sourceEventID, fieldPath,
 func (pm *PersonaManager) normalizeCandidates(in []PersonaUpdateCandidate, sessionKey, turnID, userID, agentID string) []PersonaUpdateCandidate {
 	out := make([]PersonaUpdateCandidate, 0, len(in))
 	seen := map[string]struct{}{}
+	pm.mu.RLock()
+	calibration := pm.calibration
+	pm.mu.RUnlock()
 
 	for _, c := range in {
 		c.FieldPath = strings.ToLower(strings.TrimSpace(c.FieldPath))
-		if c.FieldPath == "" || !isAllowedPersonaPath(c.FieldPath) {
+		if c.FieldPath == "" {
 			continue
 		}
 		c.Operation = strings.ToLower(strings.TrimSpace(c.Operation))
@@ -808,9 +932,19 @@ func (pm *PersonaManager) normalizeCandidates(in []PersonaUpdateCandidate, sessi
 		if c.Operation != "set" && c.Operation != "append" && c.Operation != "delete" {
 			continue
 		}
+		c.Value = strings.TrimSpace(c.Value)
+		if err := validatePersonaCandidate(c); err != nil {
+			continue
+		}
 		if c.Confidence <= 0 {
 			c.Confidence = 0.6
 		}
+		if c.Source == "" {
+			c.Source = "heuristic"
+		}
+		if factor, ok := calibration[personaCalibrationKey(c.Source, c.FieldPath)]; ok {
+			c.Confidence *= factor
+		}
 		if c.Confidence > 1 {
 			c.Confidence = 1
 		}
@@ -829,16 +963,12 @@ func (pm *PersonaManager) normalizeCandidates(in []PersonaUpdateCandidate, sessi
 		if c.TurnID == "" {
 			c.TurnID = turnID
 		}
-		if c.Source == "" {
-			c.Source = "heuristic"
-		}
 		if c.Status == "" {
 			c.Status = personaCandidatePending
 		}
 		if c.CreatedAtMS == 0 {
 			c.CreatedAtMS = time.Now().UnixMilli()
 		}
-		c.Value = strings.TrimSpace(c.Value)
 		key := strings.ToLower(c.FieldPath + "|" + c.Operation + "|" + c.Value)
 		if _, ok := seen[key]; ok {
 			continue
@@ -927,41 +1057,6 @@ func isExplicitOverride(fieldPath, evidence string) bool {
 	}
 }
 
-func isAllowedPersonaPath(path string) bool {
-	if path == "" {
-		return false
-	}
-	allowedExact := map[string]struct{}{
-		"identity.agent_name":      {},
-		"identity.role":            {},
-		"identity.purpose":         {},
-		"identity.goals":           {},
-		"identity.boundaries":      {},
-		"soul.voice":               {},
-		"soul.communication_style": {},
-		"soul.values":              {},
-		"soul.behavioral_rules":    {},
-		"user.name":                {},
-		"user.timezone":            {},
-		"user.location":            {},
-		"user.language":            {},
-		"user.communication_style": {},
-		"user.goals":               {},
-		"user.session_intent":      {},
-		"user.preferences":         {},
-		"identity.attributes":      {},
-		"soul.attributes":          {},
-		"user.attributes":          {},
-	}
-	if _, ok := allowedExact[path]; ok {
-		return true
-	}
-	return strings.HasPrefix(path, "user.preferences.") ||
-		strings.HasPrefix(path, "identity.attributes.") ||
-		strings.HasPrefix(path, "soul.attributes.") ||
-		strings.HasPrefix(path, "user.attributes.")
-}
-
 func setField(profile *PersonaProfile, path, value string) {
 	value = strings.TrimSpace(value)
 	switch {
@@ -1475,18 +1570,14 @@ func (pm *PersonaManager) importProfileFromFiles(ctx context.Context, profile Pe
 		return profile, false, nil
 	}
 
+	// The files are rendered from the profile, so they are authoritative for
+	// every section they contain: a list edited down in USER.md replaces the
+	// stored list rather than being unioned with it. Sections missing from the
+	// files leave the profile alone.
 	updated := profile.clone()
-	changed := false
-
-	if mergeIdentityMarkdown(&updated, string(identityRaw)) {
-		changed = true
-	}
-	if mergeSoulMarkdown(&updated, string(soulRaw)) {
-		changed = true
-	}
-	if mergeUserMarkdown(&updated, string(userRaw)) {
-		changed = true
-	}
+	changed := mergeIdentityMarkdown(&updated, string(identityRaw))
+	changed = mergeSoulMarkdown(&updated, string(soulRaw)) || changed
+	changed = mergeUserMarkdown(&updated, string(userRaw)) || changed
 
 	pm.mu.Lock()
 	pm.fileHashes[key] = hash
@@ -1495,34 +1586,10 @@ func (pm *PersonaManager) importProfileFromFiles(ctx context.Context, profile Pe
 	if !changed {
 		return profile, false, nil
 	}
-	updated.Revision = profile.Revision + 1
-	updated.UpdatedAtMS = time.Now().UnixMilli()
-	rev := PersonaRevision{
-		ID:                "prv-" + uuid.NewString(),
-		UserID:            profile.UserID,
-		AgentID:           profile.AgentID,
-		SessionKey:        sessionKey,
-		TurnID:            turnID,
-		CandidateID:       "",
-		FieldPath:         "persona.files",
-		Operation:         "merge",
-		OldValue:          "(file import)",
-		NewValue:          "(file import)",
-		Confidence:        1.0,
-		Evidence:          "workspace markdown sync",
-		Reason:            "file_import",
-		Source:            "file_import",
-		ProfileBeforeJSON: profileToJSON(profile),
-		ProfileAfterJSON:  profileToJSON(updated),
-		CreatedAtMS:       time.Now().UnixMilli(),
-	}
-	if err := pm.store.UpsertPersonaProfile(ctx, updated); err != nil {
-		return profile, false, err
-	}
-	if err := pm.store.InsertPersonaRevision(ctx, rev); err != nil {
+	updated, err := pm.storeMergedProfile(ctx, profile, updated, sessionKey, turnID, "persona.files", "file_import", "workspace markdown sync")
+	if err != nil {
 		return profile, false, err
 	}
-	pm.invalidatePromptCache(profile.UserID, profile.AgentID)
 	return updated, true, nil
 }
 
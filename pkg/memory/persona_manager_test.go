@@ -1,6 +1,11 @@
 package memory
 
-import "testing"
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func TestDetectQueryIntentAvoidsSubstringFalsePositives(t *testing.T) {
 	tests := []struct {
@@ -33,6 +38,15 @@ func TestNormalizePersonaLanguage(t *testing.T) {
 			t.Fatalf("normalizePersonaLanguage(%q)=%q want %q", tt.input, got, tt.want)
 		}
 	}
+
+	for _, pair := range [][2]string{{"en", "English"}, {"en-US", "english"}, {"ES", "spanish"}, {"pt-BR", "Portuguese"}} {
+		if !samePersonaLanguage(pair[0], pair[1]) {
+			t.Fatalf("expected %q and %q to be the same language", pair[0], pair[1])
+		}
+	}
+	if samePersonaLanguage("en", "spanish") || samePersonaLanguage("", "") {
+		t.Fatal("expected different or empty languages not to match")
+	}
 }
 
 func TestMapForgetTargetPath(t *testing.T) {
@@ -51,3 +65,116 @@ func TestMapForgetTargetPath(t *testing.T) {
 		}
 	}
 }
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "Can you help me plan the trip for this weekend?", want: "english"},
+		{input: "Hola, necesito ayuda con mi proyecto de la universidad", want: "spanish"},
+		{input: "Je voudrais réserver une table pour ce soir avec vous", want: "french"},
+		{input: "Ich habe heute keine Zeit, aber morgen ist es mit mir gut", want: "german"},
+		{input: "Привет, мне нужна помощь с моим проектом", want: "russian"},
+		{input: "Привіт, мені потрібна допомога з моїм проєктом", want: "ukrainian"},
+		{input: "明日の天気はどうなりますか、教えてください", want: "japanese"},
+		{input: "请帮我把这个文件翻译成英文然后发给我的同事们看", want: "chinese"},
+		{input: "내일 회의 일정을 다시 확인해 주실 수 있나요", want: "korean"},
+		{input: "short msg", want: ""},
+		{input: "1234 5678 9012 3456 7890", want: ""},
+		{input: "https://example.com/path/to/resource", want: ""},
+	}
+	for _, tt := range tests {
+		got, score := detectLanguage(tt.input)
+		if got != tt.want {
+			t.Fatalf("detectLanguage(%q)=%q (score %.2f) want %q", tt.input, got, score, tt.want)
+		}
+		if got != "" && (score < languageDetectMinScore || score > 1) {
+			t.Fatalf("detectLanguage(%q) score %.2f out of range", tt.input, score)
+		}
+	}
+}
+
+func TestExtractHeuristicCandidates_DetectedLanguage(t *testing.T) {
+	pm := NewPersonaManager(nil, "", nil, PersonaFileSyncDisabled, nil)
+	spanish := []Event{{ID: "e1", Role: "user", Content: "Hola, necesito ayuda con mi proyecto de la universidad"}}
+
+	languageCandidates := func(cands []PersonaUpdateCandidate) []PersonaUpdateCandidate {
+		var out []PersonaUpdateCandidate
+		for _, c := range cands {
+			if c.FieldPath == "user.language" {
+				out = append(out, c)
+			}
+		}
+		return out
+	}
+
+	got := languageCandidates(pm.extractHeuristicCandidates(spanish, "s1", "t1", "u1", "dotagent", ""))
+	if len(got) != 1 || got[0].Value != "spanish" || got[0].Confidence != personaDetectedLanguageConfidence {
+		t.Fatalf("expected detected spanish candidate, got %+v", got)
+	}
+
+	for _, current := range []string{"Spanish", "es", "english", "en-US"} {
+		if got := languageCandidates(pm.extractHeuristicCandidates(spanish, "s1", "t1", "u1", "dotagent", current)); len(got) != 0 {
+			t.Fatalf("expected no detected candidate when the profile declares %q, got %+v", current, got)
+		}
+	}
+
+	explicit := []Event{{ID: "e2", Role: "user", Content: "Please note my preferred language is German."}}
+	got = languageCandidates(pm.extractHeuristicCandidates(explicit, "s1", "t2", "u1", "dotagent", ""))
+	if len(got) != 1 || got[0].Value != "german" || got[0].Confidence != 0.72 {
+		t.Fatalf("expected only the explicit german candidate, got %+v", got)
+	}
+	if got := languageCandidates(pm.extractHeuristicCandidates(explicit, "s1", "t2", "u1", "dotagent", "de")); len(got) != 0 {
+		t.Fatalf("expected no candidate restating the declared language, got %+v", got)
+	}
+}
+
+func TestEmitCandidatesForTurn_ExtractionTriggers(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+	if err := store.EnsureSession(ctx, "discord:triggers", "discord", "triggers", "u1"); err != nil {
+		t.Fatalf("ensure session: %v", err)
+	}
+
+	var transcripts []string
+	extractor := func(_ context.Context, req PersonaExtractionRequest) ([]PersonaUpdateCandidate, error) {
+		transcripts = append(transcripts, req.Transcript)
+		return nil, nil
+	}
+	pm := NewPersonaManager(store, t.TempDir(), extractor, PersonaFileSyncDisabled, nil)
+	pm.SetExtractionTriggers([]string{`(?i)(call me|my name)`, `(unclosed`})
+
+	turns := map[string][]Event{
+		"turn-plain":     {{Role: "user", Content: "What is the weather in Lisbon?"}, {Role: "assistant", Content: "Sunny. Should I call me a cab? My name is Bot."}},
+		"turn-triggered": {{Role: "user", Content: "Please call me Sam."}},
+	}
+	seq := 0
+	for _, turnID := range []string{"turn-plain", "turn-triggered"} {
+		for _, ev := range turns[turnID] {
+			seq++
+			ev.SessionKey, ev.TurnID, ev.Seq = "discord:triggers", turnID, seq
+			if err := store.AppendEvent(ctx, ev); err != nil {
+				t.Fatalf("append event: %v", err)
+			}
+		}
+		if err := pm.EmitCandidatesForTurn(ctx, "discord:triggers", turnID, "u1", "dotagent"); err != nil {
+			t.Fatalf("emit %s: %v", turnID, err)
+		}
+	}
+	if len(transcripts) != 1 || !strings.Contains(transcripts[0], "call me Sam") {
+		t.Fatalf("expected extraction only for the triggered turn, got %q", transcripts)
+	}
+
+	pm.SetExtractionTriggers(nil)
+	if err := pm.EmitCandidatesForTurn(ctx, "discord:triggers", "turn-plain", "u1", "dotagent"); err != nil {
+		t.Fatalf("emit without triggers: %v", err)
+	}
+	if len(transcripts) != 2 {
+		t.Fatalf("expected extraction on every turn without triggers, got %d calls", len(transcripts))
+	}
+}
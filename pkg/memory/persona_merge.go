@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MergeProfiles folds incoming into existing field by field instead of
+// overwriting the whole profile. Non-empty incoming scalars win, lists are
+// unioned (case-insensitive, existing order first), and maps are merged with
+// incoming values taking precedence for matching keys. Ownership and revision
+// bookkeeping always come from existing.
+func (pm *PersonaManager) MergeProfiles(existing, incoming PersonaProfile) PersonaProfile {
+	out := existing.clone()
+
+	mergePersonaScalar(&out.Identity.AgentName, incoming.Identity.AgentName)
+	mergePersonaScalar(&out.Identity.Role, incoming.Identity.Role)
+	mergePersonaScalar(&out.Identity.Purpose, incoming.Identity.Purpose)
+	out.Identity.Goals = mergePersonaList(out.Identity.Goals, incoming.Identity.Goals)
+	out.Identity.Boundaries = mergePersonaList(out.Identity.Boundaries, incoming.Identity.Boundaries)
+	mergePersonaMap(out.Identity.Attributes, incoming.Identity.Attributes)
+
+	mergePersonaScalar(&out.Soul.Voice, incoming.Soul.Voice)
+	mergePersonaScalar(&out.Soul.Communication, incoming.Soul.Communication)
+	out.Soul.Values = mergePersonaList(out.Soul.Values, incoming.Soul.Values)
+	out.Soul.BehavioralRules = mergePersonaList(out.Soul.BehavioralRules, incoming.Soul.BehavioralRules)
+	mergePersonaMap(out.Soul.Attributes, incoming.Soul.Attributes)
+
+	mergePersonaScalar(&out.User.Name, incoming.User.Name)
+	mergePersonaScalar(&out.User.Timezone, incoming.User.Timezone)
+	mergePersonaScalar(&out.User.Location, incoming.User.Location)
+	mergePersonaScalar(&out.User.Language, incoming.User.Language)
+	mergePersonaScalar(&out.User.CommunicationStyle, incoming.User.CommunicationStyle)
+	mergePersonaScalar(&out.User.SessionIntent, incoming.User.SessionIntent)
+	out.User.Goals = mergePersonaList(out.User.Goals, incoming.User.Goals)
+	mergePersonaMap(out.User.Preferences, incoming.User.Preferences)
+	mergePersonaMap(out.User.Attributes, incoming.User.Attributes)
+
+	return out
+}
+
+// ImportProfile merges incoming into the stored profile for userID/agentID and
+// records the result as a single revision. It reports false when the merge
+// leaves the profile unchanged.
+func (pm *PersonaManager) ImportProfile(ctx context.Context, userID, agentID string, incoming PersonaProfile) (PersonaProfile, bool, error) {
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return PersonaProfile{}, false, fmt.Errorf("user id is required")
+	}
+	profile, err := pm.store.GetPersonaProfile(ctx, userID, agentID)
+	if err != nil {
+		return PersonaProfile{}, false, err
+	}
+	if profile.UserID == "" {
+		profile = defaultPersonaProfile(userID, agentID)
+	}
+	merged := pm.MergeProfiles(profile, incoming)
+	if !personaProfileChanged(profile, merged) {
+		return profile, false, nil
+	}
+	merged, err = pm.storeMergedProfile(ctx, profile, merged, "", "", "persona.profile", "profile_import", "persona profile import")
+	if err != nil {
+		return profile, false, err
+	}
+	return merged, true, pm.renderProfileFiles(merged)
+}
+
+// storeMergedProfile bumps the revision of updated, persists it and records a
+// whole-profile merge revision so the change can be rolled back.
+func (pm *PersonaManager) storeMergedProfile(ctx context.Context, before, updated PersonaProfile, sessionKey, turnID, fieldPath, source, evidence string) (PersonaProfile, error) {
+	now := time.Now().UnixMilli()
+	label := "(" + strings.ReplaceAll(source, "_", " ") + ")"
+	updated.Revision = before.Revision + 1
+	updated.UpdatedAtMS = now
+	rev := PersonaRevision{
+		ID:                "prv-" + uuid.NewString(),
+		UserID:            before.UserID,
+		AgentID:           before.AgentID,
+		SessionKey:        sessionKey,
+		TurnID:            turnID,
+		FieldPath:         fieldPath,
+		Operation:         "merge",
+		OldValue:          label,
+		NewValue:          label,
+		Confidence:        1.0,
+		Evidence:          evidence,
+		Reason:            source,
+		Source:            source,
+		ProfileBeforeJSON: profileToJSON(before),
+		ProfileAfterJSON:  profileToJSON(updated),
+		CreatedAtMS:       now,
+	}
+	if err := pm.store.UpsertPersonaProfile(ctx, updated); err != nil {
+		return before, err
+	}
+	if err := pm.store.InsertPersonaRevision(ctx, rev); err != nil {
+		return before, err
+	}
+	pm.invalidatePromptCache(before.UserID, before.AgentID)
+	return updated, nil
+}
+
+func personaProfileChanged(before, after PersonaProfile) bool {
+	return profileToJSON(before.clone()) != profileToJSON(after.clone())
+}
+
+func mergePersonaScalar(dst *string, incoming string) {
+	if v := strings.TrimSpace(incoming); v != "" {
+		*dst = v
+	}
+}
+
+func mergePersonaList(existing, incoming []string) []string {
+	return dedupeNonEmpty(append(append([]string{}, existing...), incoming...))
+}
+
+func mergePersonaMap(dst, incoming map[string]string) {
+	for k, v := range incoming {
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if k == "" || v == "" {
+			continue
+		}
+		dst[k] = v
+	}
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPersonaManager_MergeProfiles(t *testing.T) {
+	pm := NewPersonaManager(nil, "", nil, PersonaFileSyncDisabled, nil)
+	existing := PersonaProfile{
+		UserID:   "u1",
+		AgentID:  "dotagent",
+		Revision: 4,
+		Identity: PersonaIdentity{
+			AgentName:  "Dot",
+			Role:       "assistant",
+			Goals:      []string{"Be helpful", "Stay concise"},
+			Attributes: map[string]string{"tone": "warm", "emoji": "no"},
+		},
+		User: PersonaUser{
+			Name:        "Alex",
+			Timezone:    "America/Los_Angeles",
+			Preferences: map[string]string{"coffee": "pour-over"},
+		},
+	}
+	incoming := PersonaProfile{
+		UserID:   "someone-else",
+		Revision: 99,
+		Identity: PersonaIdentity{
+			AgentName:  "",
+			Role:       "research assistant",
+			Goals:      []string{"stay concise", "Cite sources"},
+			Attributes: map[string]string{"emoji": "yes"},
+		},
+		User: PersonaUser{
+			Timezone:    "  ",
+			Location:    "Seattle",
+			Preferences: map[string]string{"tea": "green"},
+		},
+	}
+
+	merged := pm.MergeProfiles(existing, incoming)
+
+	if merged.UserID != "u1" || merged.AgentID != "dotagent" || merged.Revision != 4 {
+		t.Fatalf("expected ownership and revision from existing, got %+v", merged)
+	}
+	if merged.Identity.AgentName != "Dot" || merged.User.Timezone != "America/Los_Angeles" {
+		t.Fatalf("expected empty incoming scalars to keep existing values, got %+v", merged)
+	}
+	if merged.Identity.Role != "research assistant" || merged.User.Location != "Seattle" {
+		t.Fatalf("expected non-empty incoming scalars to win, got %+v", merged)
+	}
+	if want := []string{"Be helpful", "Stay concise", "Cite sources"}; !slices.Equal(merged.Identity.Goals, want) {
+		t.Fatalf("expected union-deduplicated goals %v, got %v", want, merged.Identity.Goals)
+	}
+	if merged.Identity.Attributes["tone"] != "warm" || merged.Identity.Attributes["emoji"] != "yes" {
+		t.Fatalf("expected map merge with incoming precedence, got %v", merged.Identity.Attributes)
+	}
+	if merged.User.Preferences["coffee"] != "pour-over" || merged.User.Preferences["tea"] != "green" {
+		t.Fatalf("expected preferences from both profiles, got %v", merged.User.Preferences)
+	}
+	if existing.Identity.Attributes["emoji"] != "no" || len(existing.Identity.Goals) != 2 {
+		t.Fatalf("merge must not mutate existing profile, got %+v", existing.Identity)
+	}
+}
+
+func TestService_ImportPersonaProfileMergesAndRecordsRevision(t *testing.T) {
+	ctx := context.Background()
+	svc, err := NewService(Config{
+		Workspace:       t.TempDir(),
+		AgentID:         "dotagent",
+		WorkerPoll:      40 * time.Millisecond,
+		PersonaFileSync: PersonaFileSyncDisabled,
+	}, nil)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	defer svc.Close()
+
+	userID := "u-persona-import"
+	first, err := svc.ImportPersonaProfile(ctx, userID, PersonaProfile{User: PersonaUser{Name: "Alex", Goals: []string{"ship v2"}}})
+	if err != nil {
+		t.Fatalf("first import: %v", err)
+	}
+	second, err := svc.ImportPersonaProfile(ctx, userID, PersonaProfile{User: PersonaUser{Location: "Seattle", Goals: []string{"Ship v2", "learn Go"}}})
+	if err != nil {
+		t.Fatalf("second import: %v", err)
+	}
+	if second.Revision != first.Revision+1 {
+		t.Fatalf("expected revision to advance from %d, got %d", first.Revision, second.Revision)
+	}
+	if second.User.Name != "Alex" || second.User.Location != "Seattle" {
+		t.Fatalf("expected scalars from both imports, got %+v", second.User)
+	}
+	if want := []string{"ship v2", "learn Go"}; !slices.Equal(second.User.Goals, want) {
+		t.Fatalf("expected goals %v, got %v", want, second.User.Goals)
+	}
+
+	revs, err := svc.ListPersonaRevisions(ctx, userID, 10)
+	if err != nil {
+		t.Fatalf("list revisions: %v", err)
+	}
+	if len(revs) != 2 || revs[0].Source != "profile_import" {
+		t.Fatalf("expected two profile_import revisions, got %+v", revs)
+	}
+
+	if _, err := svc.ImportPersonaProfile(ctx, userID, PersonaProfile{User: PersonaUser{Name: "Alex"}}); err != nil {
+		t.Fatalf("no-op import: %v", err)
+	}
+	if revs, _ := svc.ListPersonaRevisions(ctx, userID, 10); len(revs) != 2 {
+		t.Fatalf("expected no-op import to skip revision, got %d revisions", len(revs))
+	}
+}
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PersonaPrivacyMode string
+
+const (
+	PersonaPrivacyOff   PersonaPrivacyMode = "off"
+	PersonaPrivacyScrub PersonaPrivacyMode = "scrub"
+)
+
+func NormalizePersonaPrivacyMode(raw string) PersonaPrivacyMode {
+	if PersonaPrivacyMode(strings.ToLower(strings.TrimSpace(raw))) == PersonaPrivacyScrub {
+		return PersonaPrivacyScrub
+	}
+	return PersonaPrivacyOff
+}
+
+const personaRedacted = "[redacted]"
+
+// personaPIIPatterns match values that identify a person outright. SSNs are
+// checked before phone numbers so they are not half-matched as one.
+var personaPIIPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}`),
+	regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+	regexp.MustCompile(`(?:\+\d{1,3}[\s.\-]?)?(?:\(\d{3}\)|\b\d{3})[\s.\-]?\d{3}[\s.\-]?\d{4}\b`),
+}
+
+// PersonaPrivacyScrubber replaces emails, phone numbers and SSNs in persona
+// profile values with [redacted].
+type PersonaPrivacyScrubber struct {
+	patterns []*regexp.Regexp
+}
+
+func NewPersonaPrivacyScrubber() *PersonaPrivacyScrubber {
+	return &PersonaPrivacyScrubber{patterns: personaPIIPatterns}
+}
+
+// ScrubText redacts every PII match in s.
+func (sc *PersonaPrivacyScrubber) ScrubText(s string) string {
+	for _, re := range sc.patterns {
+		s = re.ReplaceAllString(s, personaRedacted)
+	}
+	return s
+}
+
+// ScrubProfile returns a copy of profile with PII redacted from every value,
+// along with the field paths that changed.
+func (sc *PersonaPrivacyScrubber) ScrubProfile(profile PersonaProfile) (PersonaProfile, []string) {
+	out := profile.clone()
+	var changed []string
+	scalar := func(path string, v *string) {
+		if scrubbed := sc.ScrubText(*v); scrubbed != *v {
+			*v = scrubbed
+			changed = append(changed, path)
+		}
+	}
+	list := func(path string, values []string) {
+		for i := range values {
+			scalar(fmt.Sprintf("%s[%d]", path, i), &values[i])
+		}
+	}
+	attrs := func(path string, values map[string]string) {
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v := values[k]
+			scalar(path+"."+k, &v)
+			values[k] = v
+		}
+	}
+
+	scalar("identity.agent_name", &out.Identity.AgentName)
+	scalar("identity.role", &out.Identity.Role)
+	scalar("identity.purpose", &out.Identity.Purpose)
+	list("identity.goals", out.Identity.Goals)
+	list("identity.boundaries", out.Identity.Boundaries)
+	attrs("identity.attributes", out.Identity.Attributes)
+
+	scalar("soul.voice", &out.Soul.Voice)
+	scalar("soul.communication_style", &out.Soul.Communication)
+	list("soul.values", out.Soul.Values)
+	list("soul.behavioral_rules", out.Soul.BehavioralRules)
+	attrs("soul.attributes", out.Soul.Attributes)
+
+	scalar("user.name", &out.User.Name)
+	scalar("user.timezone", &out.User.Timezone)
+	scalar("user.location", &out.User.Location)
+	scalar("user.language", &out.User.Language)
+	scalar("user.communication_style", &out.User.CommunicationStyle)
+	list("user.goals", out.User.Goals)
+	attrs("user.preferences", out.User.Preferences)
+	scalar("user.session_intent", &out.User.SessionIntent)
+	attrs("user.attributes", out.User.Attributes)
+
+	return out, changed
+}
+
+// SetPrivacyMode controls whether BuildPrompt scrubs PII from the profile
+// before rendering it.
+func (pm *PersonaManager) SetPrivacyMode(mode PersonaPrivacyMode) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.privacy = mode
+	pm.promptCache = map[string]promptCacheEntry{}
+}
+
+func (pm *PersonaManager) privacyScrubber() *PersonaPrivacyScrubber {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	if pm.privacy != PersonaPrivacyScrub {
+		return nil
+	}
+	return NewPersonaPrivacyScrubber()
+}
+
+// ScrubStoredProfile redacts PII from the stored profile itself and records a
+// revision listing the redacted fields. The revision holds only the scrubbed
+// profile, so the removed values cannot be recovered by a rollback. It returns
+// the changed field paths; nothing is written when there is nothing to redact.
+func (pm *PersonaManager) ScrubStoredProfile(ctx context.Context, userID, agentID string) ([]string, error) {
+	profile, err := pm.store.GetPersonaProfile(ctx, userID, agentID)
+	if err != nil {
+		return nil, err
+	}
+	if profile.UserID == "" {
+		return nil, nil
+	}
+	scrubbed, changed := NewPersonaPrivacyScrubber().ScrubProfile(profile)
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now().UnixMilli()
+	scrubbed.Revision = profile.Revision + 1
+	scrubbed.UpdatedAtMS = now
+	rev := PersonaRevision{
+		ID:                "prv-" + uuid.NewString(),
+		UserID:            profile.UserID,
+		AgentID:           profile.AgentID,
+		FieldPath:         "persona.privacy",
+		Operation:         "scrub",
+		OldValue:          "(pii)",
+		NewValue:          personaRedacted,
+		Confidence:        1.0,
+		Evidence:          truncateForMetadata(strings.Join(changed, ", "), 500),
+		Reason:            "privacy_scrub",
+		Source:            "privacy_scrub",
+		ProfileBeforeJSON: profileToJSON(scrubbed),
+		ProfileAfterJSON:  profileToJSON(scrubbed),
+		CreatedAtMS:       now,
+	}
+	if err := pm.store.UpsertPersonaProfile(ctx, scrubbed); err != nil {
+		return nil, err
+	}
+	if err := pm.store.InsertPersonaRevision(ctx, rev); err != nil {
+		return nil, err
+	}
+	pm.invalidatePromptCache(userID, agentID)
+	return changed, pm.renderProfileFiles(scrubbed)
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPersonaPrivacyScrubber_ScrubText(t *testing.T) {
+	sc := NewPersonaPrivacyScrubber()
+	cases := map[string]string{
+		"mail me at alex.smith+work@example.co.uk": "mail me at [redacted]",
+		"call (555) 123-4567 after 5":              "call [redacted] after 5",
+		"cell +1 555.123.4567":                     "cell [redacted]",
+		"ssn 123-45-6789 on file":                  "ssn [redacted] on file",
+		"prefers pour-over coffee since 2019":      "prefers pour-over coffee since 2019",
+		"America/Los_Angeles":                      "America/Los_Angeles",
+	}
+	for in, want := range cases {
+		if got := sc.ScrubText(in); got != want {
+			t.Errorf("ScrubText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPersonaPrivacy_BuildPromptAndScrubStoredProfile(t *testing.T) {
+	ctx := context.Background()
+	svc, err := NewService(Config{
+		Workspace:       t.TempDir(),
+		AgentID:         "dotagent",
+		WorkerPoll:      40 * time.Millisecond,
+		PersonaFileSync: PersonaFileSyncDisabled,
+		PersonaPrivacy:  PersonaPrivacyScrub,
+	}, nil)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	defer svc.Close()
+
+	userID := "u-privacy"
+	profile := defaultPersonaProfile(userID, "dotagent")
+	profile.User.Name = "Alex"
+	profile.User.Preferences["contact"] = "alex@example.com"
+	profile.User.Attributes["phone"] = "555-123-4567"
+	if err := svc.store.UpsertPersonaProfile(ctx, profile); err != nil {
+		t.Fatalf("upsert profile: %v", err)
+	}
+
+	prompt, err := svc.persona.BuildPrompt(ctx, userID, "dotagent", "", 2000)
+	if err != nil {
+		t.Fatalf("build prompt: %v", err)
+	}
+	if strings.Contains(prompt, "alex@example.com") || strings.Contains(prompt, "555-123-4567") {
+		t.Fatalf("expected PII scrubbed from prompt:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "contact: [redacted]") || !strings.Contains(prompt, "User name: Alex") {
+		t.Fatalf("expected redacted values and untouched name in prompt:\n%s", prompt)
+	}
+
+	stored, _ := svc.GetPersonaProfile(ctx, userID)
+	if stored.User.Preferences["contact"] != "alex@example.com" {
+		t.Fatal("rendering must not modify the stored profile")
+	}
+
+	changed, err := svc.ScrubPersona(ctx, userID)
+	if err != nil {
+		t.Fatalf("scrub persona: %v", err)
+	}
+	if len(changed) != 2 {
+		t.Fatalf("expected 2 redacted fields, got %v", changed)
+	}
+	stored, _ = svc.GetPersonaProfile(ctx, userID)
+	if stored.User.Preferences["contact"] != "[redacted]" || stored.User.Attributes["phone"] != "[redacted]" {
+		t.Fatalf("expected stored profile scrubbed, got %+v", stored.User)
+	}
+	if stored.Revision != profile.Revision+1 {
+		t.Fatalf("expected revision bump, got %d", stored.Revision)
+	}
+	revs, err := svc.ListPersonaRevisions(ctx, userID, 1)
+	if err != nil || len(revs) != 1 || revs[0].Operation != "scrub" {
+		t.Fatalf("expected scrub revision, got %+v (%v)", revs, err)
+	}
+	if strings.Contains(revs[0].ProfileBeforeJSON, "alex@example.com") {
+		t.Fatal("scrub revision must not retain the redacted values")
+	}
+
+	if changed, err := svc.ScrubPersona(ctx, userID); err != nil || len(changed) != 0 {
+		t.Fatalf("expected second scrub to be a no-op, got %v (%v)", changed, err)
+	}
+}
@@ -0,0 +1,161 @@
+package memory
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:embed persona_schema.json
+var personaSchemaJSON []byte
+
+// personaSchema is the parsed persona_schema.json that persona update
+// candidates are validated against.
+var personaSchema = mustParsePersonaSchema(personaSchemaJSON)
+
+// PersonaSchema returns the JSON Schema describing the persona fields update
+// candidates may target.
+func PersonaSchema() []byte {
+	out := make([]byte, len(personaSchemaJSON))
+	copy(out, personaSchemaJSON)
+	return out
+}
+
+// personaSchemaNode is the subset of JSON Schema the persona schema uses:
+// typed strings, string arrays and objects, with maxLength, maxItems,
+// pattern and propertyNames constraints.
+type personaSchemaNode struct {
+	Type                 string                        `json:"type"`
+	Properties           map[string]*personaSchemaNode `json:"properties"`
+	AdditionalProperties json.RawMessage               `json:"additionalProperties"`
+	PropertyNames        *personaSchemaNode            `json:"propertyNames"`
+	Items                *personaSchemaNode            `json:"items"`
+	MaxLength            int                           `json:"maxLength"`
+	MaxItems             int                           `json:"maxItems"`
+	Pattern              string                        `json:"pattern"`
+
+	// additional is the schema for keys not in Properties; nil when
+	// additionalProperties is false or absent.
+	additional *personaSchemaNode
+	pattern    *regexp.Regexp
+}
+
+func mustParsePersonaSchema(raw []byte) *personaSchemaNode {
+	var root personaSchemaNode
+	if err := json.Unmarshal(raw, &root); err != nil {
+		panic(fmt.Sprintf("parse persona schema: %v", err))
+	}
+	if err := root.compile(); err != nil {
+		panic(fmt.Sprintf("compile persona schema: %v", err))
+	}
+	return &root
+}
+
+func (n *personaSchemaNode) compile() error {
+	if n.Pattern != "" {
+		re, err := regexp.Compile(n.Pattern)
+		if err != nil {
+			return fmt.Errorf("pattern %q: %w", n.Pattern, err)
+		}
+		n.pattern = re
+	}
+	if raw := strings.TrimSpace(string(n.AdditionalProperties)); raw != "" && raw != "false" && raw != "true" {
+		var additional personaSchemaNode
+		if err := json.Unmarshal(n.AdditionalProperties, &additional); err != nil {
+			return fmt.Errorf("additionalProperties: %w", err)
+		}
+		n.additional = &additional
+	}
+	for name, child := range n.Properties {
+		if err := child.compile(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	for _, child := range []*personaSchemaNode{n.additional, n.PropertyNames, n.Items} {
+		if child == nil {
+			continue
+		}
+		if err := child.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve walks a dot-separated field path down the schema.
+func (n *personaSchemaNode) resolve(path string) (*personaSchemaNode, error) {
+	segments := strings.Split(path, ".")
+	if len(segments) < 2 {
+		return nil, fmt.Errorf("field_path %q must name a field below identity, soul or user", path)
+	}
+	node := n
+	for i, seg := range segments {
+		if node.Type != "object" {
+			return nil, fmt.Errorf("field_path %q: %s is not an object", path, strings.Join(segments[:i], "."))
+		}
+		if child, ok := node.Properties[seg]; ok {
+			node = child
+			continue
+		}
+		if node.additional == nil {
+			return nil, fmt.Errorf("field_path %q: unknown field %q", path, seg)
+		}
+		if err := node.PropertyNames.checkString(seg); err != nil {
+			return nil, fmt.Errorf("field_path %q: key %q: %w", path, seg, err)
+		}
+		node = node.additional
+	}
+	return node, nil
+}
+
+// checkString applies a string schema's constraints to value. A nil node
+// accepts anything.
+func (n *personaSchemaNode) checkString(value string) error {
+	if n == nil {
+		return nil
+	}
+	if n.MaxLength > 0 && utf8.RuneCountInString(value) > n.MaxLength {
+		return fmt.Errorf("longer than %d characters", n.MaxLength)
+	}
+	if n.pattern != nil && !n.pattern.MatchString(value) {
+		return fmt.Errorf("does not match %s", n.Pattern)
+	}
+	return nil
+}
+
+// validatePersonaCandidate checks a normalized candidate's field path and
+// value against the persona schema.
+func validatePersonaCandidate(c PersonaUpdateCandidate) error {
+	node, err := personaSchema.resolve(c.FieldPath)
+	if err != nil {
+		return err
+	}
+	if c.Operation == "delete" {
+		return nil
+	}
+	switch node.Type {
+	case "string":
+		if err := node.checkString(c.Value); err != nil {
+			return fmt.Errorf("%s: value %w", c.FieldPath, err)
+		}
+	case "array":
+		items := []string{c.Value}
+		if c.Operation == "set" {
+			items = splitDelimitedList(c.Value)
+		}
+		if node.MaxItems > 0 && len(items) > node.MaxItems {
+			return fmt.Errorf("%s: more than %d items", c.FieldPath, node.MaxItems)
+		}
+		for _, item := range items {
+			if err := node.Items.checkString(item); err != nil {
+				return fmt.Errorf("%s: item %w", c.FieldPath, err)
+			}
+		}
+	default:
+		return fmt.Errorf("%s is an object; only delete is allowed", c.FieldPath)
+	}
+	return nil
+}
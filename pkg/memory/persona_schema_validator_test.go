@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValidatePersonaCandidate(t *testing.T) {
+	manyGoals := make([]string, 33)
+	for i := range manyGoals {
+		manyGoals[i] = fmt.Sprintf("goal %d", i)
+	}
+	cases := []struct {
+		path, op, value string
+		ok              bool
+	}{
+		{"user.name", "set", "Alex", true},
+		{"user.timezone", "set", "America/Los_Angeles", true},
+		{"user.preferences.pref_pour_over_coffee", "set", "pour-over coffee", true},
+		{"identity.attributes.appearance_tall", "set", "tall", true},
+		{"soul.behavioral_rules", "set", "be brief; cite sources", true},
+		{"user.goals", "append", "ship the release", true},
+		{"user.preferences", "delete", "", true},
+		{"user.attributes.bio", "delete", "", true},
+
+		{"user", "delete", "", false},
+		{"user.nickname", "set", "Al", false},
+		{"users.name", "set", "Alex", false},
+		{"user.name.first", "set", "Alex", false},
+		{"user.preferences", "set", "tea", false},
+		{"user.preferences.Bad Key", "set", "tea", false},
+		{"user.preferences.a.b", "set", "tea", false},
+		{"user.name", "set", strings.Repeat("x", 121), false},
+		{"user.goals", "set", strings.Join(manyGoals, ","), false},
+	}
+	for _, tc := range cases {
+		err := validatePersonaCandidate(PersonaUpdateCandidate{FieldPath: tc.path, Operation: tc.op, Value: tc.value})
+		if (err == nil) != tc.ok {
+			t.Errorf("%s %s %q: got err=%v, want ok=%v", tc.op, tc.path, tc.value, err, tc.ok)
+		}
+	}
+}
+
+func TestNormalizeCandidates_DropsSchemaViolations(t *testing.T) {
+	pm := &PersonaManager{}
+	out := pm.normalizeCandidates([]PersonaUpdateCandidate{
+		{FieldPath: " User.Name ", Value: " Alex "},
+		{FieldPath: "user.favorite_color", Value: "green"},
+		{FieldPath: "identity.agent_name", Value: strings.Repeat("n", 200)},
+	}, "s1", "t1", "u1", "dotagent")
+	if len(out) != 1 || out[0].FieldPath != "user.name" || out[0].Value != "Alex" || out[0].Operation != "set" {
+		t.Fatalf("expected only the user.name candidate to survive, got %+v", out)
+	}
+}
+
+func TestPersonaSchema_IsValidJSON(t *testing.T) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(PersonaSchema(), &doc); err != nil {
+		t.Fatalf("persona schema is not valid JSON: %v", err)
+	}
+	if doc["$schema"] == nil || doc["properties"] == nil {
+		t.Fatalf("expected a JSON Schema document, got keys %v", doc)
+	}
+}
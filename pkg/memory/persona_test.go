@@ -188,9 +188,13 @@ func TestPersonaRollback(t *testing.T) {
 		return p.User.Name == "Riley"
 	})
 
-	if err := svc.RollbackPersona(ctx, userID); err != nil {
+	rev, err := svc.RollbackPersona(ctx, userID)
+	if err != nil {
 		t.Fatalf("rollback persona: %v", err)
 	}
+	if rev.TurnID != turn2 || rev.SessionKey != session {
+		t.Fatalf("expected rollback of the %s revision, got %+v", turn2, rev)
+	}
 	p, err := svc.store.GetPersonaProfile(ctx, userID, "dotagent")
 	if err != nil {
 		t.Fatalf("get profile after rollback: %v", err)
@@ -257,4 +261,23 @@ Europe/Berlin
 	if p.User.Timezone != "Europe/Berlin" {
 		t.Fatalf("expected imported timezone Europe/Berlin, got %q", p.User.Timezone)
 	}
+
+	// USER.md is authoritative for the sections it has: trimming a list there
+	// replaces the stored list instead of being unioned back into it.
+	if _, err := svc.ImportPersonaProfile(ctx, userID, PersonaProfile{User: PersonaUser{Goals: []string{"ship v2", "learn Go"}}}); err != nil {
+		t.Fatalf("import profile: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "USER.md"), []byte(userFile+"\n## Goals\n- learn Go\n"), 0o644); err != nil {
+		t.Fatalf("write USER.md: %v", err)
+	}
+	if _, err := svc.BuildPromptContext(ctx, session, userID, "what is my profile", 2048); err != nil {
+		t.Fatalf("build prompt context: %v", err)
+	}
+	p, err = svc.store.GetPersonaProfile(ctx, userID, "dotagent")
+	if err != nil {
+		t.Fatalf("get profile: %v", err)
+	}
+	if len(p.User.Goals) != 1 || p.User.Goals[0] != "learn Go" {
+		t.Fatalf("expected goals replaced by USER.md, got %v", p.User.Goals)
+	}
 }
@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErasedContent replaces the content of events erased for a user.
+const ErasedContent = "[erased]"
+
+// userErasureStatements lists, per table, the statement erasing one user's
+// rows. Rows hanging off the user's memory items go before the items
+// themselves. Events, feedback ratings, compaction records and audit entries
+// are kept so session structure, turn numbering and history survive, but the
+// text they hold is overwritten. Statements take the user ID once per
+// placeholder.
+var userErasureStatements = []struct {
+	table string
+	query string
+}{
+	{"memory_embeddings", `DELETE FROM memory_embeddings WHERE item_id IN (` + erasedUserItems + `)`},
+	{"memory_links", `DELETE FROM memory_links WHERE from_item_id IN (` + erasedUserItems + `) OR to_item_id IN (` + erasedUserItems + `)`},
+	{"memory_observations", `DELETE FROM memory_observations WHERE item_id IN (` + erasedUserItems + `)`},
+	{"memory_items", `DELETE FROM memory_items WHERE user_id = ?`},
+	{"persona_profiles", `DELETE FROM persona_profiles WHERE user_id = ?`},
+	{"persona_candidates", `DELETE FROM persona_candidates WHERE user_id = ?`},
+	{"persona_revisions", `DELETE FROM persona_revisions WHERE user_id = ?`},
+	{"persona_signals", `DELETE FROM persona_signals WHERE user_id = ?`},
+	{"events", `UPDATE events SET content = '` + ErasedContent + `', metadata_json = '{}' WHERE content <> '` + ErasedContent + `' AND session_key IN (` + erasedUserSessions + `)`},
+	{"session_snapshots", `DELETE FROM session_snapshots WHERE session_key IN (` + erasedUserSessions + `)`},
+	{"session_tags", `DELETE FROM session_tags WHERE session_key IN (` + erasedUserSessions + `)`},
+	{"session_aliases", `DELETE FROM session_aliases WHERE session_key IN (` + erasedUserSessions + `)`},
+	{"session_metadata", `DELETE FROM session_metadata WHERE session_key IN (` + erasedUserSessions + `)`},
+	{"session_provider_states", `DELETE FROM session_provider_states WHERE session_key IN (` + erasedUserSessions + `)`},
+	{"session_compactions", `UPDATE session_compactions SET summary = '', checkpoint_json = '{}' WHERE (summary <> '' OR checkpoint_json <> '{}') AND session_key IN (` + erasedUserSessions + `)`},
+	{"response_feedback", `UPDATE response_feedback SET user_id = '', comment = '' WHERE (user_id = ? OR session_key IN (` + erasedUserSessions + `)) AND (user_id <> '' OR comment <> '')`},
+	{"memory_audit_log", `UPDATE memory_audit_log SET payload_json = '{}' WHERE payload_json <> '{}' AND action <> 'user_erasure' AND (user_id = ? OR session_key IN (` + erasedUserSessions + `))`},
+}
+
+const (
+	erasedUserItems    = `SELECT id FROM memory_items WHERE user_id = ?`
+	erasedUserSessions = `SELECT session_key FROM sessions WHERE user_id = ?`
+)
+
+// ErasureCount is the number of rows a user erasure deleted or, for events,
+// overwrote in one table.
+type ErasureCount struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+}
+
+// ErasureReport describes what EraseUser removed.
+type ErasureReport struct {
+	UserID string         `json:"user_id"`
+	Counts []ErasureCount `json:"counts"`
+}
+
+// Total returns the number of affected rows across all tables.
+func (r ErasureReport) Total() int64 {
+	var total int64
+	for _, c := range r.Counts {
+		total += c.Rows
+	}
+	return total
+}
+
+// Rows returns the count for table, or 0 when it was not touched.
+func (r ErasureReport) Rows(table string) int64 {
+	for _, c := range r.Counts {
+		if c.Table == table {
+			return c.Rows
+		}
+	}
+	return 0
+}
+
+// EraseUser carries out a right-to-erasure request: it deletes the user's
+// memory items and persona data, overwrites the content of events in the
+// user's sessions with ErasedContent, drops those sessions' snapshots,
+// summaries, compaction checkpoints, metadata, provider state and topic
+// tags, strips the user's feedback comments and audit payloads, and records
+// the per-table counts in the audit log.
+func (s *Service) EraseUser(ctx context.Context, userID string) (ErasureReport, error) {
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return ErasureReport{}, fmt.Errorf("erase user: empty user_id")
+	}
+	counts, err := s.store.EraseUser(ctx, userID, s.cfg.AgentID)
+	if err != nil {
+		return ErasureReport{}, err
+	}
+
+	// Drop in-process copies of the erased content.
+	s.snapshotMu.Lock()
+	s.snapshots = map[string][]Event{}
+	s.snapshotAccess = map[string]int64{}
+	s.snapshotMu.Unlock()
+	s.workingMemory.Reset()
+	if s.persona != nil {
+		s.persona.invalidatePromptCache(userID, s.cfg.AgentID)
+	}
+	return ErasureReport{UserID: userID, Counts: counts}, nil
+}
+
+// EraseUser erases userID's data in one transaction; see Service.EraseUser.
+func (s *SQLiteStore) EraseUser(ctx context.Context, userID, agentID string) ([]ErasureCount, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erase user begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	counts, err := eraseUserTx(ctx, tx, userID, agentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erase user commit: %w", err)
+	}
+	return counts, nil
+}
+
+// EraseUser erases userID's data in one transaction; see Service.EraseUser.
+func (s *PostgreSQLStore) EraseUser(ctx context.Context, userID, agentID string) ([]ErasureCount, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erase user begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	counts, err := eraseUserTx(ctx, tx, userID, agentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erase user commit: %w", err)
+	}
+	return counts, nil
+}
+
+func eraseUserTx(ctx context.Context, tx sqlTx, userID, agentID string) ([]ErasureCount, error) {
+	counts := make([]ErasureCount, 0, len(userErasureStatements)+1)
+	payload := map[string]string{}
+	var total int64
+	for _, stmt := range userErasureStatements {
+		args := make([]interface{}, strings.Count(stmt.query, "?"))
+		for i := range args {
+			args[i] = userID
+		}
+		res, err := tx.ExecContext(ctx, stmt.query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("erase %s for user %s: %w", stmt.table, userID, err)
+		}
+		n, _ := res.RowsAffected()
+		counts = append(counts, ErasureCount{Table: stmt.table, Rows: n})
+		payload[stmt.table] = strconv.FormatInt(n, 10)
+		total += n
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE sessions SET summary = '' WHERE user_id = ? AND summary <> ''`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erase session summaries for user %s: %w", userID, err)
+	}
+	n, _ := res.RowsAffected()
+	counts = append(counts, ErasureCount{Table: "sessions", Rows: n})
+	payload["sessions"] = strconv.FormatInt(n, 10)
+	payload["total"] = strconv.FormatInt(total+n, 10)
+
+	if err := invalidateRetrievalCacheTx(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := insertAuditLogTx(ctx, tx, "user_erasure", "user", userID, "", userID, agentID, "right_to_erasure", payload); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,201 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestService_EraseUser(t *testing.T) {
+	ctx := context.Background()
+	svc, err := NewService(Config{
+		Workspace:        t.TempDir(),
+		AgentID:          "dotagent",
+		ContextModel:     "openai/gpt-5.2",
+		MaxContextTokens: 4096,
+		WorkerPoll:       100 * time.Millisecond,
+	}, nil)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	defer svc.Close()
+	store := svc.store.(*SQLiteStore)
+
+	seed := func(userID, sessionKey, address string) {
+		t.Helper()
+		if err := svc.EnsureSession(ctx, sessionKey, "cli", sessionKey, userID); err != nil {
+			t.Fatalf("ensure session: %v", err)
+		}
+		for i, content := range []string{"my address is " + address, "noted"} {
+			role := "user"
+			if i == 1 {
+				role = "assistant"
+			}
+			if err := svc.AppendEvent(ctx, Event{SessionKey: sessionKey, TurnID: "t1", Seq: i + 1, Role: role, Content: content}); err != nil {
+				t.Fatalf("append event: %v", err)
+			}
+		}
+		if _, err := store.UpsertMemoryItem(ctx, MemoryItem{
+			UserID: userID, AgentID: "dotagent", ScopeType: MemoryScopeUser, SessionKey: sessionKey,
+			Kind: MemorySemanticFact, Key: "address", Content: "lives at " + address, Confidence: 0.9,
+		}); err != nil {
+			t.Fatalf("upsert memory item: %v", err)
+		}
+		if err := store.UpsertPersonaProfile(ctx, PersonaProfile{UserID: userID, AgentID: "dotagent", Revision: 1}); err != nil {
+			t.Fatalf("upsert persona profile: %v", err)
+		}
+		if _, err := store.BumpPersonaSignal(ctx, userID, "dotagent", "user.location", "h1", nowMS()); err != nil {
+			t.Fatalf("bump persona signal: %v", err)
+		}
+		if err := store.UpsertSessionSnapshot(ctx, SessionSnapshot{SessionKey: sessionKey, Revision: 1, Facts: []string{"lives at " + address}}); err != nil {
+			t.Fatalf("upsert snapshot: %v", err)
+		}
+		if err := store.SetSessionSummary(ctx, sessionKey, "user lives at "+address); err != nil {
+			t.Fatalf("set summary: %v", err)
+		}
+		if err := store.SetSessionMetadata(ctx, sessionKey, "title", "moving to "+address); err != nil {
+			t.Fatalf("set metadata: %v", err)
+		}
+		if err := store.SetSessionProviderState(ctx, sessionKey, "openai", "resp-"+address); err != nil {
+			t.Fatalf("set provider state: %v", err)
+		}
+		compactionID, err := store.StartCompaction(ctx, sessionKey, 2, 1, map[string]string{"facts": "lives at " + address})
+		if err != nil {
+			t.Fatalf("start compaction: %v", err)
+		}
+		if err := store.CompleteCompaction(ctx, compactionID, "user gave their address, "+address); err != nil {
+			t.Fatalf("complete compaction: %v", err)
+		}
+		if err := store.RecordFeedback(ctx, Feedback{SessionKey: sessionKey, TurnID: "t1", UserID: userID, Rating: 1, Comment: "wrong, it is " + address}); err != nil {
+			t.Fatalf("record feedback: %v", err)
+		}
+		if err := store.insertAuditLog(ctx, "upsert", "memory_item", "item-"+userID, sessionKey, userID, "dotagent", "consolidation", map[string]string{"content": "lives at " + address}); err != nil {
+			t.Fatalf("insert audit log: %v", err)
+		}
+	}
+	seed("u1", "cli:u1", "1 Main St")
+	seed("u2", "cli:u2", "2 Oak Ave")
+
+	report, err := svc.EraseUser(ctx, "u1")
+	if err != nil {
+		t.Fatalf("erase user: %v", err)
+	}
+	for table, want := range map[string]int64{
+		"memory_items":            1,
+		"persona_profiles":        1,
+		"persona_signals":         1,
+		"events":                  2,
+		"session_snapshots":       1,
+		"session_metadata":        1,
+		"session_provider_states": 1,
+		"session_compactions":     1,
+		"response_feedback":       1,
+	} {
+		if got := report.Rows(table); got != want {
+			t.Fatalf("expected %d %s rows erased, got %d (report %+v)", want, table, got, report)
+		}
+	}
+
+	events, err := store.ListRecentEvents(ctx, "cli:u1", 10, true)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected erased events to be kept, got %d", len(events))
+	}
+	for _, ev := range events {
+		if ev.Content != ErasedContent {
+			t.Fatalf("expected erased content, got %q", ev.Content)
+		}
+	}
+	if events, _ := store.ListRecentEvents(ctx, "cli:u2", 10, true); len(events) != 2 || events[0].Content == ErasedContent {
+		t.Fatalf("expected other users' events untouched, got %+v", events)
+	}
+	var remaining int
+	if err := store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM memory_items WHERE user_id = 'u2'`).Scan(&remaining); err != nil || remaining != 1 {
+		t.Fatalf("expected other users' memory items untouched, got %d (%v)", remaining, err)
+	}
+
+	// Nothing the erased user said survives anywhere in the database, while
+	// the other user's data is all still there.
+	if leaks := tablesContaining(t, store, "1 Main St"); len(leaks) > 0 {
+		t.Fatalf("erased content remains in %v", leaks)
+	}
+	if kept := tablesContaining(t, store, "2 Oak Ave"); len(kept) < 10 {
+		t.Fatalf("expected other users' data untouched, found it only in %v", kept)
+	}
+
+	var payload string
+	if err := store.db.QueryRowContext(ctx, `SELECT payload_json FROM memory_audit_log WHERE action = 'user_erasure' AND user_id = 'u1'`).Scan(&payload); err != nil {
+		t.Fatalf("expected erasure audit entry: %v", err)
+	}
+	if !strings.Contains(payload, `"events":"2"`) {
+		t.Fatalf("expected audit payload to carry counts, got %s", payload)
+	}
+
+	again, err := svc.EraseUser(ctx, "u1")
+	if err != nil {
+		t.Fatalf("erase user again: %v", err)
+	}
+	if again.Total() != 0 {
+		t.Fatalf("expected a repeated erasure to touch nothing, got %+v", again)
+	}
+}
+
+// tablesContaining lists the tables with a text value containing needle.
+func tablesContaining(t *testing.T, store *SQLiteStore, needle string) []string {
+	t.Helper()
+	ctx := context.Background()
+	rows, err := store.db.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`)
+	if err != nil {
+		t.Fatalf("list tables: %v", err)
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("scan table name: %v", err)
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+
+	var found []string
+	for _, table := range tables {
+		rows, err := store.db.QueryContext(ctx, `SELECT * FROM `+table)
+		if err != nil {
+			t.Fatalf("read %s: %v", table, err)
+		}
+		cols, _ := rows.Columns()
+		values := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		hit := false
+		for rows.Next() && !hit {
+			if err := rows.Scan(ptrs...); err != nil {
+				t.Fatalf("scan %s: %v", table, err)
+			}
+			for _, v := range values {
+				var text string
+				switch v := v.(type) {
+				case string:
+					text = v
+				case []byte:
+					text = string(v)
+				}
+				if strings.Contains(text, needle) {
+					hit = true
+					break
+				}
+			}
+		}
+		rows.Close()
+		if hit {
+			found = append(found, table)
+		}
+	}
+	return found
+}
@@ -173,6 +173,8 @@ func (r *HybridRetriever) Recall(ctx context.Context, query string, opts Retriev
 		card := MemoryCard{
 			ID:         s.item.ID,
 			Kind:       s.item.Kind,
+			Key:        s.item.Key,
+			Scope:      s.item.ScopeType,
 			Content:    s.item.Content,
 			Score:      s.rerankScore,
 			Confidence: s.item.Confidence,
@@ -13,6 +13,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dotsetgreg/dotagent/pkg/trace"
 	"github.com/google/uuid"
 )
 
@@ -24,6 +25,7 @@ type Config struct {
 	ContextModel                 string
 	EmbeddingModel               string
 	EmbeddingFallbackModels      []string
+	EmbeddingBackend             string
 	EmbeddingOpenAIToken         string
 	EmbeddingOpenAIAPIBase       string
 	EmbeddingOpenRouterKey       string
@@ -41,11 +43,14 @@ type Config struct {
 	PersonaExtractor             PersonaExtractionFunc
 	PersonaSyncApply             bool
 	PersonaFileSync              PersonaFileSyncMode
+	PersonaPrivacy               PersonaPrivacyMode
 	PersonaPolicyMode            string
 	PersonaMinConfidence         float64
+	PersonaExtractionTriggers    []string
 	EventRetention               time.Duration
 	AuditRetention               time.Duration
 	CompactionSummaryTimeout     time.Duration
+	CompactionTimeout            time.Duration
 	CompactionChunkChars         int
 	CompactionMaxTranscriptChars int
 	CompactionPartialSkipChars   int
@@ -56,6 +61,39 @@ type Config struct {
 	FileMemoryWatchEnabled       bool
 	FileMemoryWatchDebounce      time.Duration
 	FileMemoryMaxFileBytes       int
+	// MaxConsolidationRate is the messages per minute above which a session's
+	// turns are consolidated at most every five minutes; 0 disables.
+	MaxConsolidationRate float64
+	// RestoreHistoryFromSnapshot makes BuildPromptContext open the history with
+	// a recap of the latest session snapshot when no earlier events remain.
+	RestoreHistoryFromSnapshot bool
+	// Backend selects the store: "sqlite" (default), under DataDir, or
+	// "postgres", at PostgresDSN.
+	Backend     string
+	PostgresDSN string
+	// PersonaExperiment, when set, splits sessions between two personas.
+	PersonaExperiment *PersonaExperiment
+	// AutoRepair deletes the orphans found by the weekly consistency check.
+	AutoRepair bool
+	// TopicClassifier, when set, asks a model for session topic tags;
+	// otherwise tags come from keyword frequency.
+	TopicClassifier TopicClassifyFunc
+	// DisableSessionTags turns off topic tagging.
+	DisableSessionTags bool
+}
+
+// OpenStore opens the store cfg.Backend selects: the SQLite database under
+// cfg.DataDir, or the PostgreSQL database at cfg.PostgresDSN. Pending schema
+// migrations are applied.
+func OpenStore(cfg Config) (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Backend)) {
+	case "", "sqlite":
+		return NewSQLiteStore(filepath.Join(cfg.DataDir, "state", "memory.db"))
+	case "postgres":
+		return NewPostgreSQLStore(cfg.PostgresDSN)
+	default:
+		return nil, fmt.Errorf("unknown memory backend %q", cfg.Backend)
+	}
 }
 
 // Service is the orchestrator for memory capture, retrieval and compaction.
@@ -65,11 +103,13 @@ type Service struct {
 	store                   Store
 	retriever               Retriever
 	consolidator            Consolidator
+	autoLinker              *AutoLinker
 	compactor               Compactor
 	policy                  Policy
 	persona                 *PersonaManager
 	budgeter                *TokenBudgeter
 	embeddingEngine         *EmbeddingEngine
+	embeddings              EmbeddingProvider
 	embeddingFallbackModels []string
 
 	stopCh chan struct{}
@@ -84,8 +124,13 @@ type Service struct {
 	snapshotLimit       int
 	snapshotMaxSessions int
 
-	lastRetentionSweep int64
-	lastFileMemorySync int64
+	workingMemory *WorkingMemoryCache
+
+	lastRetentionSweep    int64
+	lastFileMemorySync    int64
+	lastConsistencyPeriod int64
+	// lastPersonaCalibration is when the calibration in use was computed.
+	lastPersonaCalibration int64
 
 	fileMemoryMu      sync.Mutex
 	fileMemoryIndex   map[string]fileMemorySnapshot
@@ -96,6 +141,14 @@ type Service struct {
 
 	compactionMu    sync.Mutex
 	compactionState map[string]*compactionFlight
+
+	// jobsMu is held while a worker claims a job and registers it in
+	// runningJobs, and while CancelJob marks a job canceled and stops it, so
+	// a cancel never lands between the two.
+	jobsMu      sync.Mutex
+	runningJobs map[string]context.CancelFunc
+
+	consolidationScheduler *ConsolidationScheduler
 }
 
 type compactionFlight struct {
@@ -154,6 +207,9 @@ func NewService(cfg Config, summarize SummaryFunc) (*Service, error) {
 	if cfg.CompactionSummaryTimeout <= 0 {
 		cfg.CompactionSummaryTimeout = 60 * time.Second
 	}
+	if cfg.CompactionTimeout <= 0 {
+		cfg.CompactionTimeout = 30 * time.Minute
+	}
 	if cfg.CompactionChunkChars <= 0 {
 		cfg.CompactionChunkChars = 9000
 	}
@@ -176,28 +232,26 @@ func NewService(cfg Config, summarize SummaryFunc) (*Service, error) {
 		cfg.FileMemoryMaxFileBytes = 256 * 1024
 	}
 
-	cfg.EmbeddingModel, cfg.EmbeddingFallbackModels = normalizeEmbeddingConfig(cfg)
+	cfg, err := resolveEmbeddingModels(cfg)
+	if err != nil {
+		return nil, err
+	}
 	if spec, err := parseEmbeddingModelSpec(cfg.EmbeddingModel); err == nil && spec.Provider == embeddingProviderLocal {
 		SetEmbedderByName(spec.Model)
 	} else {
 		SetEmbedderByName(defaultEmbeddingModel)
 	}
 
-	dbPath := filepath.Join(cfg.DataDir, "state", "memory.db")
-	store, err := NewSQLiteStore(dbPath)
+	store, err := OpenStore(cfg)
 	if err != nil {
 		return nil, err
 	}
-	embeddingEngine := NewEmbeddingEngine(EmbeddingEngineConfig{
-		OpenAIToken:       cfg.EmbeddingOpenAIToken,
-		OpenAIAPIBase:     cfg.EmbeddingOpenAIAPIBase,
-		OpenRouterToken:   cfg.EmbeddingOpenRouterKey,
-		OpenRouterAPIBase: cfg.EmbeddingOpenRouterBase,
-		OllamaAPIBase:     cfg.EmbeddingOllamaAPIBase,
-		BatchSize:         cfg.EmbeddingBatchSize,
-		Concurrency:       cfg.EmbeddingConcurrency,
-		Cache:             store,
-	})
+	embeddingEngine := newConfiguredEmbeddingEngine(cfg, store)
+	embeddings, err := newEmbeddingProvider(cfg.EmbeddingFallbackModels[0], embeddingEngine)
+	if err != nil {
+		_ = store.Close()
+		return nil, fmt.Errorf("embedding provider: %w", err)
+	}
 	policy := NewDefaultPolicy()
 
 	personaPolicy := NewPersonaPolicyEngine(PersonaPolicyConfig{
@@ -214,7 +268,8 @@ func NewService(cfg Config, summarize SummaryFunc) (*Service, error) {
 			EmbeddingEngine:         embeddingEngine,
 			EmbeddingFallbackModels: cfg.EmbeddingFallbackModels,
 		}),
-		consolidator: NewHeuristicConsolidator(store, policy),
+		consolidator: NewHeuristicConsolidator(store, policy, embeddings),
+		autoLinker:   NewAutoLinker(store),
 		compactor: NewSessionCompactor(store, summarize, CompactorConfig{
 			SummaryTimeout:     cfg.CompactionSummaryTimeout,
 			ChunkChars:         cfg.CompactionChunkChars,
@@ -225,17 +280,24 @@ func NewService(cfg Config, summarize SummaryFunc) (*Service, error) {
 		persona:                 NewPersonaManager(store, cfg.Workspace, cfg.PersonaExtractor, cfg.PersonaFileSync, personaPolicy),
 		budgeter:                NewTokenBudgeter(cfg.Workspace),
 		embeddingEngine:         embeddingEngine,
+		embeddings:              embeddings,
 		embeddingFallbackModels: append([]string(nil), cfg.EmbeddingFallbackModels...),
 		stopCh:                  make(chan struct{}),
 		snapshots:               map[string][]Event{},
 		snapshotAccess:          map[string]int64{},
 		snapshotLimit:           128,
 		snapshotMaxSessions:     256,
+		workingMemory:           NewWorkingMemoryCache(defaultWorkingMemoryEvents, defaultWorkingMemoryIdleTTL),
 		fileMemoryIndex:         map[string]fileMemorySnapshot{},
 		fileMemoryDirty:         true,
 		compactionState:         map[string]*compactionFlight{},
+		consolidationScheduler:  NewConsolidationScheduler(cfg.MaxConsolidationRate, consolidationMinInterval),
 	}
 
+	svc.persona.SetPrivacyMode(cfg.PersonaPrivacy)
+	svc.persona.SetExperiment(cfg.PersonaExperiment)
+	svc.persona.SetExtractionTriggers(cfg.PersonaExtractionTriggers)
+	svc.recoverStaleCompactions(context.Background(), time.Now().Add(-cfg.CompactionTimeout).UnixMilli())
 	svc.startFileMemoryWatcher()
 	svc.wg.Add(1)
 	go svc.runWorker()
@@ -440,12 +502,14 @@ func (s *Service) AppendEvent(ctx context.Context, ev Event) error {
 	ev = normalizeEvent(ev)
 	s.appendSnapshot(ev)
 	if err := s.store.AppendEvent(ctx, ev); err != nil {
+		s.workingMemory.Invalidate(ev.SessionKey)
 		_ = s.store.AddMetric(ctx, "memory.append_event.error", 1, map[string]string{
 			"session_key": ev.SessionKey,
 			"role":        ev.Role,
 		})
 		return err
 	}
+	s.workingMemory.Append(ev)
 	return nil
 }
 
@@ -489,7 +553,27 @@ func (s *Service) BuildPromptContext(ctx context.Context, sessionKey, userID, qu
 	if fetchLimit > 96 {
 		fetchLimit = 96
 	}
-	events, err := s.store.ListRecentEvents(ctx, sessionKey, fetchLimit, false)
+	// Working memory serves the history when it holds fetchLimit events or the
+	// whole session; otherwise read SQLite and refill it.
+	// The session row read above carries the history version, so events
+	// archived or erased by another process since the fill are not served.
+	var (
+		events []Event
+		cached bool
+	)
+	if sessErr == nil {
+		events, cached = s.workingMemory.Get(sessionKey, fetchLimit, session.HistoryVersion)
+	} else {
+		s.workingMemory.Invalidate(sessionKey)
+	}
+	err = nil
+	if !cached {
+		generation := s.workingMemory.Generation()
+		events, err = s.store.ListRecentEvents(ctx, sessionKey, fetchLimit, false)
+		if err == nil && sessErr == nil {
+			s.workingMemory.Fill(sessionKey, events, len(events) < fetchLimit, session.HistoryVersion, generation)
+		}
+	}
 	if err != nil {
 		degradedReasons = append(degradedReasons, "history")
 		events = s.getSnapshotEvents(sessionKey, fetchLimit)
@@ -503,6 +587,20 @@ func (s *Service) BuildPromptContext(ctx context.Context, sessionKey, userID, qu
 			"user_id":     userID,
 		})
 	}
+	// Once compaction has archived every earlier event, the snapshot is all
+	// that is left of the session; open the history with it as an assistant
+	// recap. The turn being answered may already be recorded and does not
+	// count as earlier history.
+	if s.cfg.RestoreHistoryFromSnapshot && !hasEarlierEvents(events, query) {
+		if recap, ok := snapshotRecapEvent(snapshot); ok {
+			events = append([]Event{recap}, events...)
+			continuity.RestoredFromSnapshot = true
+			_ = s.store.AddMetric(ctx, "memory.context.snapshot_restored", 1, map[string]string{
+				"session_key": sessionKey,
+				"user_id":     userID,
+			})
+		}
+	}
 
 	recallCards := []MemoryCard{}
 	recallOut, err := s.retriever.Recall(ctx, query, RetrievalOptions{
@@ -544,7 +642,8 @@ func (s *Service) BuildPromptContext(ctx context.Context, sessionKey, userID, qu
 
 	personaPrompt := ""
 	if s.persona != nil {
-		pp, pErr := s.persona.BuildPrompt(ctx, userID, s.cfg.AgentID, query, s.cfg.PersonaCardTokens)
+		group := s.personaExperimentGroup(ctx, sessionKey)
+		pp, pErr := s.persona.BuildExperimentPrompt(ctx, group, userID, s.cfg.AgentID, query, s.cfg.PersonaCardTokens)
 		if pErr == nil {
 			personaPrompt = strings.TrimSpace(pp)
 		} else {
@@ -560,7 +659,12 @@ func (s *Service) BuildPromptContext(ctx context.Context, sessionKey, userID, qu
 			remainingMemoryBudget = 128
 		}
 	}
-	recallPrompt := formatSnapshotAndRecall(snapshot, recallCards, remainingMemoryBudget, s.estimateMessageTokens)
+	promptSnapshot := snapshot
+	if continuity.RestoredFromSnapshot {
+		// The recap in History already carries the snapshot.
+		promptSnapshot = SessionSnapshot{}
+	}
+	recallPrompt := formatSnapshotAndRecall(promptSnapshot, recallCards, remainingMemoryBudget, s.estimateMessageTokens)
 	if personaPrompt != "" {
 		if recallPrompt != "" {
 			recallPrompt = personaPrompt + "\n\n" + recallPrompt
@@ -626,7 +730,7 @@ func (s *Service) RecordUserTurn(ctx context.Context, ev Event, userID string) (
 		filtered = append(filtered, op)
 	}
 
-	inserted, err := s.store.AppendUserEventAndMemories(ctx, ev, userID, s.cfg.AgentID, filtered)
+	inserted, err := s.store.AppendUserEventAndMemories(ctx, ev, userID, s.cfg.AgentID, filtered, s.embeddings)
 	if err != nil {
 		_ = s.store.AddMetric(ctx, "memory.record_user_turn.error", 1, map[string]string{
 			"session_key": ev.SessionKey,
@@ -635,13 +739,49 @@ func (s *Service) RecordUserTurn(ctx context.Context, ev Event, userID string) (
 		return Event{}, 0, err
 	}
 	s.appendSnapshot(ev)
+	s.workingMemory.Append(ev)
+	if inserted > 0 {
+		s.scheduleAutoLink(ctx, ev, userID)
+	}
 	_ = s.store.AddMetric(ctx, "memory.record_user_turn.memories", float64(inserted), map[string]string{
 		"session_key": ev.SessionKey,
 		"user_id":     userID,
 	})
+	if group := s.personaExperimentGroup(ctx, ev.SessionKey); group != "" {
+		treatment := 0.0
+		if group == PersonaExperimentTreatment {
+			treatment = 1
+		}
+		_ = s.store.AddMetric(ctx, "persona.experiment.treatment", treatment, map[string]string{
+			"session_key": ev.SessionKey,
+			"user_id":     userID,
+			"group":       group,
+		})
+	}
 	return ev, inserted, nil
 }
 
+// scheduleAutoLink defers linking the memories upserted from ev to the
+// background worker so the user turn is not held up by the similarity scan.
+func (s *Service) scheduleAutoLink(ctx context.Context, ev Event, userID string) {
+	now := time.Now().UnixMilli()
+	_ = s.store.EnqueueJob(ctx, Job{
+		ID:         maintenanceJobID(JobAutoLink, ev.SessionKey, ev.ID),
+		JobType:    JobAutoLink,
+		SessionKey: ev.SessionKey,
+		Status:     JobPending,
+		Priority:   70,
+		Payload: map[string]string{
+			"event_id": ev.ID,
+			"user_id":  userID,
+			"trace_id": trace.FromContext(ctx),
+		},
+		RunAfterMS:  now + autoLinkDelayMS,
+		CreatedAtMS: now,
+		UpdatedAtMS: now,
+	})
+}
+
 func (s *Service) CaptureImmediateUserSignals(ctx context.Context, sessionKey, userID, sourceEventID, content string) error {
 	ops := extractUserContentUpsertOps(content, sourceEventID)
 	if len(ops) == 0 {
@@ -686,8 +826,11 @@ func (s *Service) CaptureImmediateUserSignals(ctx context.Context, sessionKey, u
 			})
 			return err
 		}
-		vec := embedText(item.Content)
-		if err := s.store.UpsertEmbedding(ctx, item.ID, currentEmbeddingModel(), vec); err != nil {
+		vec, model, err := embeddingsOrDefault(s.embeddings).Embed(ctx, item.Content)
+		if err == nil {
+			err = s.store.UpsertEmbedding(ctx, item.ID, model, vec)
+		}
+		if err != nil {
 			_ = s.store.AddMetric(ctx, "memory.capture.immediate.error", 1, map[string]string{
 				"session_key": sessionKey,
 				"user_id":     userID,
@@ -742,6 +885,32 @@ func (s *Service) ForceCompact(ctx context.Context, sessionKey, userID string, m
 	return s.compactSessionSerialized(ctx, sessionKey, userID, budget)
 }
 
+// ForceCompactAll force-compacts the most recently active sessions (up to the
+// store's ListSessions cap) and returns how many were compacted. A failure on
+// one session does not stop the rest; the first error is returned.
+func (s *Service) ForceCompactAll(ctx context.Context, maxTokens int) (int, error) {
+	sessions, err := s.store.ListSessions(ctx, "", 200)
+	if err != nil {
+		return 0, err
+	}
+	budget := DeriveContextBudget(maxTokens)
+	compacted := 0
+	var firstErr error
+	for _, sess := range sessions {
+		if err := s.compactSessionSerialized(ctx, sess.SessionKey, sess.UserID, budget); err != nil {
+			if ctx.Err() != nil {
+				return compacted, ctx.Err()
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("compact session %s: %w", sess.SessionKey, err)
+			}
+			continue
+		}
+		compacted++
+	}
+	return compacted, firstErr
+}
+
 func (s *Service) compactSessionSerialized(ctx context.Context, sessionKey, userID string, budget ContextBudget) error {
 	sessionKey = strings.TrimSpace(sessionKey)
 	if sessionKey == "" {
@@ -770,6 +939,7 @@ func (s *Service) compactSessionSerialized(ctx context.Context, sessionKey, user
 	}
 
 	err := s.compactor.CompactSession(ctx, sessionKey, userID, s.cfg.AgentID, budget)
+	s.workingMemory.Invalidate(sessionKey)
 	s.compactionMu.Lock()
 	if flight := s.compactionState[sessionKey]; flight != nil {
 		close(flight.done)
@@ -779,13 +949,71 @@ func (s *Service) compactSessionSerialized(ctx context.Context, sessionKey, user
 	return err
 }
 
-func (s *Service) RollbackPersona(ctx context.Context, userID string) error {
+// IsAvailable reports whether the backing store answers a trivial query
+// within 500ms. Callers use it to degrade gracefully when SQLite is locked by
+// another process or the PostgreSQL server is unreachable.
+func (s *Service) IsAvailable(ctx context.Context) bool {
+	pingCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	return s.store.Ping(pingCtx) == nil
+}
+
+// RollbackPersona undoes the user's most recent persona revision and returns
+// it, so callers can offer to rewind the session turn that produced it.
+func (s *Service) RollbackPersona(ctx context.Context, userID string) (PersonaRevision, error) {
 	if s.persona == nil {
-		return nil
+		return PersonaRevision{}, nil
 	}
 	return s.persona.RollbackLastRevision(ctx, userID, s.cfg.AgentID)
 }
 
+// ArchiveEventsSinceTurn archives turnID and every later turn of the session,
+// keeping only the active turns that came before it.
+func (s *Service) ArchiveEventsSinceTurn(ctx context.Context, sessionKey, turnID string) (int, error) {
+	turnID = strings.TrimSpace(turnID)
+	if turnID == "" {
+		return 0, fmt.Errorf("archive events since turn: empty turn_id")
+	}
+	events, err := s.store.ListRecentEvents(ctx, sessionKey, 100000, false)
+	if err != nil {
+		return 0, err
+	}
+	// Events are oldest first; the turns seen before turnID are kept.
+	found := false
+	keep := []string{}
+	for _, ev := range events {
+		if ev.TurnID == turnID {
+			found = true
+			break
+		}
+		keep = append(keep, ev.TurnID)
+	}
+	if !found {
+		return 0, fmt.Errorf("turn %s has no active events in session %s", turnID, sessionKey)
+	}
+	archived, err := s.store.ArchiveEventsExceptTurns(ctx, sessionKey, keep)
+	if err != nil {
+		return 0, err
+	}
+	// Drop the in-process event stream so archived turns are not merged back
+	// into the prompt history.
+	s.snapshotMu.Lock()
+	delete(s.snapshots, sessionKey)
+	delete(s.snapshotAccess, sessionKey)
+	s.snapshotMu.Unlock()
+	s.workingMemory.Invalidate(sessionKey)
+	return archived, nil
+}
+
+// ScrubPersona redacts PII from the user's stored persona profile and returns
+// the redacted field paths.
+func (s *Service) ScrubPersona(ctx context.Context, userID string) ([]string, error) {
+	if s.persona == nil {
+		return nil, fmt.Errorf("persona manager is not configured")
+	}
+	return s.persona.ScrubStoredProfile(ctx, userID, s.cfg.AgentID)
+}
+
 func (s *Service) GetPersonaProfile(ctx context.Context, userID string) (PersonaProfile, error) {
 	if s.persona == nil {
 		return defaultPersonaProfile(userID, s.cfg.AgentID), nil
@@ -793,6 +1021,16 @@ func (s *Service) GetPersonaProfile(ctx context.Context, userID string) (Persona
 	return s.store.GetPersonaProfile(ctx, userID, s.cfg.AgentID)
 }
 
+// ImportPersonaProfile merges incoming into the user's stored persona using
+// PersonaManager.MergeProfiles and returns the resulting profile.
+func (s *Service) ImportPersonaProfile(ctx context.Context, userID string, incoming PersonaProfile) (PersonaProfile, error) {
+	if s.persona == nil {
+		return PersonaProfile{}, fmt.Errorf("persona manager is not configured")
+	}
+	profile, _, err := s.persona.ImportProfile(ctx, userID, s.cfg.AgentID, incoming)
+	return profile, err
+}
+
 func (s *Service) ListPersonaCandidates(ctx context.Context, userID, sessionKey, turnID, status string, limit int) ([]PersonaUpdateCandidate, error) {
 	return s.store.ListPersonaCandidates(ctx, userID, s.cfg.AgentID, sessionKey, turnID, status, limit)
 }
@@ -801,6 +1039,129 @@ func (s *Service) ListPersonaRevisions(ctx context.Context, userID string, limit
 	return s.store.ListPersonaRevisions(ctx, userID, s.cfg.AgentID, limit)
 }
 
+// StoreMemory writes an explicitly requested memory, bypassing extraction and
+// confidence gating. item.ScopeType selects session, user or global scope;
+// when empty the scope is derived from the kind as for extracted memories.
+func (s *Service) StoreMemory(ctx context.Context, sessionKey, userID string, item MemoryItem) (MemoryItem, error) {
+	item.Key = strings.TrimSpace(item.Key)
+	item.Content = strings.TrimSpace(item.Content)
+	if item.Key == "" || item.Content == "" {
+		return MemoryItem{}, fmt.Errorf("store memory: key and content are required")
+	}
+	if item.Kind == "" {
+		item.Kind = MemorySemanticFact
+	}
+	meta := map[string]string{"source": "explicit"}
+	for k, v := range item.Metadata {
+		meta[k] = v
+	}
+	if item.ScopeType != "" {
+		meta["scope_type"] = string(item.ScopeType)
+	}
+	now := time.Now().UnixMilli()
+	item.ID = "mem-" + uuid.NewString()
+	item.UserID = userID
+	item.AgentID = s.cfg.AgentID
+	item.SessionKey = sessionKey
+	item.ScopeType, item.ScopeID = deriveScopeForOp(item.Kind, sessionKey, userID, meta)
+	if item.Confidence <= 0 {
+		item.Confidence = 0.95
+	}
+	if item.Weight <= 0 {
+		item.Weight = 1.5
+	}
+	item.FirstSeenAtMS = now
+	item.LastSeenAtMS = now
+	if item.ExpiresAtMS == 0 {
+		item.ExpiresAtMS = s.ttlFor(item.Kind, 0)
+	}
+	item.Metadata = meta
+
+	stored, err := s.store.UpsertMemoryItem(ctx, item)
+	if err != nil {
+		return MemoryItem{}, err
+	}
+	vec, model, err := embeddingsOrDefault(s.embeddings).Embed(ctx, stored.Content)
+	if err != nil {
+		return MemoryItem{}, err
+	}
+	if err := s.store.UpsertEmbedding(ctx, stored.ID, model, vec); err != nil {
+		return MemoryItem{}, err
+	}
+	_ = s.store.AddMetric(ctx, "memory.explicit.stored", 1, map[string]string{
+		"session_key": sessionKey,
+		"user_id":     userID,
+	})
+	return stored, nil
+}
+
+// DeleteMemoryByKey soft-deletes the user's memories with the given key. An
+// empty kind deletes the key across every kind.
+func (s *Service) DeleteMemoryByKey(ctx context.Context, userID string, kind MemoryItemKind, key string) error {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("delete memory: key is required")
+	}
+	kinds := []MemoryItemKind{kind}
+	if kind == "" {
+		kinds = []MemoryItemKind{MemorySemanticFact, MemoryUserPreference, MemoryEpisodic, MemoryTaskState, MemoryProcedural}
+	}
+	for _, k := range kinds {
+		if err := s.store.DeleteMemoryByKey(ctx, userID, s.cfg.AgentID, k, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchMemory runs hybrid (FTS plus embedding) recall for an explicit query.
+// A non-empty kind restricts results to that memory kind. Session-scoped
+// memories are only searched when sessionKey is set.
+func (s *Service) SearchMemory(ctx context.Context, sessionKey, userID, query string, kind MemoryItemKind, limit int) ([]MemoryCard, error) {
+	if limit <= 0 {
+		limit = 8
+	}
+	if limit > 50 {
+		limit = 50
+	}
+	maxCards := limit
+	if kind != "" {
+		// Over-fetch so filtering by kind still leaves enough results.
+		maxCards = limit * 4
+	}
+	cards, err := s.retriever.Recall(ctx, query, RetrievalOptions{
+		SessionKey:      sessionKey,
+		UserID:          userID,
+		AgentID:         s.cfg.AgentID,
+		MaxCards:        maxCards,
+		CandidateLimit:  s.cfg.CandidateLimit,
+		MinScore:        0.32,
+		CacheTTL:        s.cfg.RetrievalCache,
+		NowMS:           time.Now().UnixMilli(),
+		IncludeSession:  sessionKey != "",
+		IncludeUser:     true,
+		IncludeGlobal:   true,
+		RecencyHalfLife: 14 * 24 * time.Hour,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search memory: %w", err)
+	}
+	out := make([]MemoryCard, 0, limit)
+	for _, card := range cards {
+		if kind != "" && card.Kind != kind {
+			continue
+		}
+		out = append(out, card)
+		if len(out) >= limit {
+			break
+		}
+	}
+	_ = s.store.AddMetric(ctx, "memory.search.results", float64(len(out)), map[string]string{
+		"user_id": userID,
+	})
+	return out, nil
+}
+
 func (s *Service) ApplyPersonaDirectivesSync(ctx context.Context, sessionKey, turnID, userID string) (PersonaApplyReport, error) {
 	report := PersonaApplyReport{
 		SessionKey: sessionKey,
@@ -869,7 +1230,16 @@ func (s *Service) ApplyPersonaDirectivesSync(ctx context.Context, sessionKey, tu
 }
 
 func (s *Service) ScheduleTurnMaintenance(ctx context.Context, sessionKey, turnID, userID string) {
-	now := time.Now().UnixMilli()
+	nowTime := time.Now()
+	now := nowTime.UnixMilli()
+	// Busy sessions consolidate each turn at the end of the throttle window
+	// instead of right away.
+	runAt := s.consolidationScheduler.ConsolidateAt(sessionKey, nowTime)
+	if runAt.After(nowTime) {
+		_ = s.store.AddMetric(ctx, "memory.consolidation.deferred_rate", 1, map[string]string{
+			"session_key": sessionKey,
+		})
+	}
 	_ = s.store.EnqueueJob(ctx, Job{
 		ID:         maintenanceJobID(JobConsolidate, sessionKey, turnID),
 		JobType:    JobConsolidate,
@@ -877,10 +1247,11 @@ func (s *Service) ScheduleTurnMaintenance(ctx context.Context, sessionKey, turnI
 		Status:     JobPending,
 		Priority:   30,
 		Payload: map[string]string{
-			"turn_id": turnID,
-			"user_id": userID,
+			"turn_id":  turnID,
+			"user_id":  userID,
+			"trace_id": trace.FromContext(ctx),
 		},
-		RunAfterMS:  now,
+		RunAfterMS:  runAt.UnixMilli(),
 		CreatedAtMS: now,
 		UpdatedAtMS: now,
 	})
@@ -891,8 +1262,9 @@ func (s *Service) ScheduleTurnMaintenance(ctx context.Context, sessionKey, turnI
 		Status:     JobPending,
 		Priority:   55,
 		Payload: map[string]string{
-			"turn_id": turnID,
-			"user_id": userID,
+			"turn_id":  turnID,
+			"user_id":  userID,
+			"trace_id": trace.FromContext(ctx),
 		},
 		RunAfterMS:  now + 200,
 		CreatedAtMS: now,
@@ -968,6 +1340,8 @@ func (s *Service) processPendingJobs() {
 	ctx := context.Background()
 	s.runRetentionSweepIfDue(ctx, now)
 	s.runFileMemorySyncIfDue(ctx, now)
+	s.scheduleConsistencyCheckIfDue(ctx, now)
+	s.runPersonaCalibrationIfDue(ctx, now)
 	_ = s.store.RequeueExpiredJobs(ctx, now)
 
 	leaseForMS := int64(s.cfg.WorkerLease / time.Millisecond)
@@ -976,12 +1350,18 @@ func (s *Service) processPendingJobs() {
 	}
 
 	for i := 0; i < maxBatch; i++ {
-		job, ok, err := s.store.ClaimNextJob(ctx, time.Now().UnixMilli(), leaseForMS)
-		if err != nil || !ok {
+		job, jobCtx, done, ok := s.claimJob(ctx, leaseForMS)
+		if !ok {
 			return
 		}
-
-		if err := s.handleJob(ctx, job); err != nil {
+		err := s.handleJob(trace.WithID(jobCtx, job.Payload["trace_id"]), job)
+		canceled := jobCtx.Err() != nil
+		done()
+		if canceled {
+			// CancelJob already recorded the outcome.
+			continue
+		}
+		if err != nil {
 			attempt := parseJobAttempt(job.Payload["attempt"])
 			if attempt < 3 {
 				nextAttempt := attempt + 1
@@ -1019,6 +1399,29 @@ func (s *Service) processPendingJobs() {
 	}
 }
 
+// claimJob leases the next runnable job and registers it as running under
+// jobsMu. done must be called once the job has been handled.
+func (s *Service) claimJob(ctx context.Context, leaseForMS int64) (Job, context.Context, func(), bool) {
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+	job, ok, err := s.store.ClaimNextJob(ctx, time.Now().UnixMilli(), leaseForMS)
+	if err != nil || !ok {
+		return Job{}, nil, nil, false
+	}
+	jobCtx, cancel := context.WithCancel(ctx)
+	if s.runningJobs == nil {
+		s.runningJobs = map[string]context.CancelFunc{}
+	}
+	s.runningJobs[job.ID] = cancel
+	done := func() {
+		s.jobsMu.Lock()
+		delete(s.runningJobs, job.ID)
+		s.jobsMu.Unlock()
+		cancel()
+	}
+	return job, jobCtx, done, true
+}
+
 func (s *Service) runRetentionSweepIfDue(ctx context.Context, nowMS int64) {
 	const minIntervalMS = int64((6 * time.Hour) / time.Millisecond)
 	if s.lastRetentionSweep > 0 && nowMS-s.lastRetentionSweep < minIntervalMS {
@@ -1083,11 +1486,6 @@ func (s *Service) runFileMemorySyncIfDue(ctx context.Context, nowMS int64) {
 		return
 	}
 
-	store, ok := s.store.(*SQLiteStore)
-	if !ok {
-		return
-	}
-
 	delta, err := s.collectFileMemoryDelta(nowMS)
 	if err != nil {
 		_ = s.store.AddMetric(ctx, "memory.file_sync.error", 1, map[string]string{
@@ -1120,7 +1518,7 @@ func (s *Service) runFileMemorySyncIfDue(ctx context.Context, nowMS int64) {
 	// One-time startup reconciliation to remove stale file-memory keys left over
 	// from periods when this process was not running.
 	if !s.fileMemoryPrimed {
-		existingKeys, err := store.ListMemoryKeysByPrefix(ctx, s.cfg.AgentID, fileMemoryKeyPrefix)
+		existingKeys, err := s.store.ListMemoryKeysByPrefix(ctx, s.cfg.AgentID, fileMemoryKeyPrefix)
 		if err == nil {
 			for _, key := range existingKeys {
 				if _, ok := delta.LiveSet[key]; ok {
@@ -1483,12 +1881,35 @@ func (s *Service) handleJob(ctx context.Context, job Job) error {
 		if strings.TrimSpace(userID) == "" {
 			return fmt.Errorf("invalid compact job payload")
 		}
-		return s.compactSessionSerialized(ctx, job.SessionKey, userID, DeriveContextBudget(s.cfg.MaxContextTokens))
+		if err := s.compactSessionSerialized(ctx, job.SessionKey, userID, DeriveContextBudget(s.cfg.MaxContextTokens)); err != nil {
+			return err
+		}
+		// Topic tags ride on the per-session compact job; a tagging failure
+		// is not worth retrying the compaction for.
+		if err := s.refreshSessionTags(ctx, job.SessionKey); err != nil {
+			_ = s.store.AddMetric(ctx, "memory.session_tags.failed", 1, map[string]string{"session_key": job.SessionKey})
+		}
+		return nil
+	case JobAutoLink:
+		eventID := job.Payload["event_id"]
+		userID := job.Payload["user_id"]
+		if strings.TrimSpace(eventID) == "" || strings.TrimSpace(userID) == "" {
+			return fmt.Errorf("invalid auto link job payload")
+		}
+		linked, err := s.autoLinker.LinkEvent(ctx, userID, s.cfg.AgentID, eventID)
+		if err != nil {
+			return err
+		}
+		_ = s.store.AddMetric(ctx, "memory.auto_link.links", float64(linked), map[string]string{"session_key": job.SessionKey})
+		return nil
 	case JobEmbeddingSync:
 		return s.syncSessionEmbeddingDeltas(ctx, job.SessionKey)
 	case JobEmbeddingReindex:
 		_, err := s.reindexEmbeddingsAtomic(ctx)
 		return err
+	case JobConsistencyCheck:
+		_, err := s.runConsistencyCheck(ctx)
+		return err
 	default:
 		return fmt.Errorf("unknown memory job type: %s", job.JobType)
 	}
@@ -1504,13 +1925,8 @@ func (s *Service) syncSessionEmbeddingDeltas(ctx context.Context, sessionKey str
 	if sessionKey == "" {
 		return nil
 	}
-	store, ok := s.store.(*SQLiteStore)
-	if !ok {
-		return nil
-	}
-
 	model := s.primaryEmbeddingModel()
-	cursor, err := store.GetSessionIndexCursor(ctx, sessionKey)
+	cursor, err := s.store.GetSessionIndexCursor(ctx, sessionKey)
 	if err != nil {
 		return err
 	}
@@ -1519,7 +1935,7 @@ func (s *Service) syncSessionEmbeddingDeltas(ctx context.Context, sessionKey str
 	maxSeen := cursor
 	const batchSize = 128
 	for i := 0; i < 8; i++ {
-		items, err := store.ListSessionEmbeddingDeltas(ctx, sessionKey, s.cfg.AgentID, model, cursor, batchSize)
+		items, err := s.store.ListSessionEmbeddingDeltas(ctx, sessionKey, s.cfg.AgentID, model, cursor, batchSize)
 		if err != nil {
 			return err
 		}
@@ -1538,7 +1954,7 @@ func (s *Service) syncSessionEmbeddingDeltas(ctx context.Context, sessionKey str
 			if idx >= len(vectors) {
 				break
 			}
-			if err := store.UpsertEmbedding(ctx, item.ID, modelUsed, vectors[idx]); err != nil {
+			if err := s.store.UpsertEmbedding(ctx, item.ID, modelUsed, vectors[idx]); err != nil {
 				return err
 			}
 			processed++
@@ -1553,7 +1969,7 @@ func (s *Service) syncSessionEmbeddingDeltas(ctx context.Context, sessionKey str
 	}
 
 	if maxSeen > 0 {
-		if err := store.SetSessionIndexCursor(ctx, sessionKey, maxSeen); err != nil {
+		if err := s.store.SetSessionIndexCursor(ctx, sessionKey, maxSeen); err != nil {
 			return err
 		}
 	}
@@ -1926,6 +2342,49 @@ func (s *Service) ttlFor(kind MemoryItemKind, override time.Duration) int64 {
 	return s.policy.TTLFor(kind)
 }
 
+// resolveEmbeddingModels sets cfg.EmbeddingModel and the fallback chain from
+// the configured model, fallbacks and backend.
+func resolveEmbeddingModels(cfg Config) (Config, error) {
+	cfg.EmbeddingModel, cfg.EmbeddingFallbackModels = normalizeEmbeddingConfig(cfg)
+	if strings.TrimSpace(cfg.EmbeddingBackend) != "" {
+		model, err := embeddingModelForBackend(cfg.EmbeddingBackend, cfg.EmbeddingModel)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.EmbeddingModel = model
+		cfg.EmbeddingFallbackModels = dedupeEmbeddingModels(append([]string{model}, cfg.EmbeddingFallbackModels...))
+	}
+	return cfg, nil
+}
+
+func newConfiguredEmbeddingEngine(cfg Config, cache embeddingCacheStore) *EmbeddingEngine {
+	return NewEmbeddingEngine(EmbeddingEngineConfig{
+		OpenAIToken:       cfg.EmbeddingOpenAIToken,
+		OpenAIAPIBase:     cfg.EmbeddingOpenAIAPIBase,
+		OpenRouterToken:   cfg.EmbeddingOpenRouterKey,
+		OpenRouterAPIBase: cfg.EmbeddingOpenRouterBase,
+		OllamaAPIBase:     cfg.EmbeddingOllamaAPIBase,
+		BatchSize:         cfg.EmbeddingBatchSize,
+		Concurrency:       cfg.EmbeddingConcurrency,
+		Cache:             cache,
+	})
+}
+
+// NewEmbeddingProvider returns the provider a Service built from cfg embeds
+// with, for tools that embed text without running a Service. store caches
+// remote embeddings and may be nil.
+func NewEmbeddingProvider(cfg Config, store Store) (EmbeddingProvider, error) {
+	cfg, err := resolveEmbeddingModels(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var cache embeddingCacheStore
+	if store != nil {
+		cache = store
+	}
+	return newEmbeddingProvider(cfg.EmbeddingFallbackModels[0], newConfiguredEmbeddingEngine(cfg, cache))
+}
+
 func normalizeEmbeddingConfig(cfg Config) (string, []string) {
 	primary := strings.TrimSpace(cfg.EmbeddingModel)
 	if primary == "" {
@@ -2190,6 +2649,56 @@ func formatRecallCards(cards []MemoryCard, budgetTokens int, estimate tokenEstim
 	return strings.TrimSpace(b.String())
 }
 
+// hasEarlierEvents reports whether events hold anything besides the user
+// turn for query, which is recorded before the prompt is built.
+func hasEarlierEvents(events []Event, query string) bool {
+	n := len(events)
+	if n > 0 && events[n-1].Role == "user" && strings.TrimSpace(events[n-1].Content) == strings.TrimSpace(query) {
+		n--
+	}
+	return n > 0
+}
+
+// snapshotRecapEvent turns a session snapshot into an assistant message
+// recapping the archived part of the session. It reports false when the
+// snapshot has nothing to recap.
+func snapshotRecapEvent(snapshot SessionSnapshot) (Event, bool) {
+	if snapshot.Revision == 0 {
+		return Event{}, false
+	}
+	const maxItems = 8
+	lines := []string{}
+	if summary := strings.TrimSpace(snapshot.Summary); summary != "" {
+		lines = append(lines, "Summary: "+summary)
+	}
+	appendList := func(label string, values []string) {
+		items := []string{}
+		for _, v := range values {
+			if v = strings.TrimSpace(v); v != "" && len(items) < maxItems {
+				items = append(items, "- "+v)
+			}
+		}
+		if len(items) > 0 {
+			lines = append(lines, label+":")
+			lines = append(lines, items...)
+		}
+	}
+	appendList("Facts", snapshot.Facts)
+	appendList("Preferences", snapshot.Preferences)
+	appendList("Tasks", snapshot.Tasks)
+	appendList("Open loops", snapshot.OpenLoops)
+	appendList("Constraints", snapshot.Constraints)
+	if len(lines) == 0 {
+		return Event{}, false
+	}
+	return Event{
+		SessionKey: snapshot.SessionKey,
+		Role:       "assistant",
+		Content:    fmt.Sprintf("Earlier messages in this session were archived. Where we left off (session snapshot revision %d):\n%s", snapshot.Revision, strings.Join(lines, "\n")),
+		CreatedAt:  time.UnixMilli(snapshot.CreatedAtMS),
+	}, true
+}
+
 func deriveContinuationNotes(snapshot SessionSnapshot) []string {
 	if snapshot.Revision == 0 {
 		return nil
@@ -199,6 +199,41 @@ func TestScheduleTurnMaintenance_DeduplicatesJobs(t *testing.T) {
 	}
 }
 
+func TestScheduleTurnMaintenance_DefersThrottledConsolidation(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	svc, err := NewService(Config{
+		Workspace:            dir,
+		AgentID:              "dotagent",
+		WorkerPoll:           10 * time.Second, // keep jobs queued for assertion
+		MaxConsolidationRate: 1,
+	}, nil)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	defer svc.Close()
+
+	sessionKey := "discord:busy"
+	userID := "u-busy"
+	if err := svc.EnsureSession(ctx, sessionKey, "discord", "busy", userID); err != nil {
+		t.Fatalf("ensure session: %v", err)
+	}
+	before := time.Now().UnixMilli()
+	svc.ScheduleTurnMaintenance(ctx, sessionKey, "turn-1", userID)
+	svc.ScheduleTurnMaintenance(ctx, sessionKey, "turn-2", userID)
+
+	store := svc.store.(*SQLiteStore)
+	for turn, deferred := range map[string]bool{"turn-1": false, "turn-2": true} {
+		var runAfter int64
+		if err := store.db.QueryRowContext(ctx, `SELECT run_after_ms FROM memory_jobs WHERE id = ?`, maintenanceJobID(JobConsolidate, sessionKey, turn)).Scan(&runAfter); err != nil {
+			t.Fatalf("expected a consolidate job for %s: %v", turn, err)
+		}
+		if got := runAfter >= before+consolidationMinInterval.Milliseconds(); got != deferred {
+			t.Fatalf("%s: expected deferred=%v, run_after_ms=%d (scheduled at %d)", turn, deferred, runAfter, before)
+		}
+	}
+}
+
 func TestService_SyncSessionEmbeddingDeltas(t *testing.T) {
 	ctx := context.Background()
 	dir := t.TempDir()
@@ -631,6 +666,93 @@ func TestBuildPromptContext_IncludesContinuationHandoff(t *testing.T) {
 	}
 }
 
+func TestBuildPromptContext_RestoresHistoryFromSnapshot(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name        string
+		restore     bool
+		earlierTurn bool
+		wantRecap   bool
+	}{
+		{name: "disabled", restore: false},
+		{name: "only current turn remains", restore: true, wantRecap: true},
+		{name: "earlier turn remains", restore: true, earlierTurn: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, err := NewService(Config{
+				Workspace:                  t.TempDir(),
+				AgentID:                    "dotagent",
+				ContextModel:               "openai/gpt-5.2",
+				MaxContextTokens:           4096,
+				WorkerPoll:                 100 * time.Millisecond,
+				RestoreHistoryFromSnapshot: tc.restore,
+			}, nil)
+			if err != nil {
+				t.Fatalf("new service: %v", err)
+			}
+			defer svc.Close()
+
+			sessionKey := "discord:snapshot-restore"
+			if err := svc.EnsureSession(ctx, sessionKey, "discord", "snapshot-restore", "u1"); err != nil {
+				t.Fatalf("ensure session: %v", err)
+			}
+			if err := svc.store.UpsertSessionSnapshot(ctx, SessionSnapshot{
+				SessionKey:  sessionKey,
+				Summary:     "Planning the database migration.",
+				Facts:       []string{"Primary database is Postgres 16"},
+				Preferences: []string{"Prefers short answers"},
+				Tasks:       []string{"Write the rollback plan"},
+			}); err != nil {
+				t.Fatalf("upsert snapshot: %v", err)
+			}
+			seq := 1
+			if tc.earlierTurn {
+				if err := svc.AppendEvent(ctx, Event{SessionKey: sessionKey, TurnID: "t0", Seq: seq, Role: "assistant", Content: "The plan is drafted."}); err != nil {
+					t.Fatalf("append event: %v", err)
+				}
+				seq++
+			}
+			if err := svc.AppendEvent(ctx, Event{SessionKey: sessionKey, TurnID: "t1", Seq: seq, Role: "user", Content: "What next?"}); err != nil {
+				t.Fatalf("append event: %v", err)
+			}
+
+			pc, err := svc.BuildPromptContext(ctx, sessionKey, "u1", "What next?", 4096)
+			if err != nil {
+				t.Fatalf("build prompt context: %v", err)
+			}
+			for _, msg := range pc.History {
+				if msg.Role == "user" && msg.Content != "What next?" {
+					t.Fatalf("expected no synthesized user turns, got %+v", pc.History)
+				}
+			}
+			if !tc.wantRecap {
+				if pc.Continuity.RestoredFromSnapshot || strings.Contains(pc.History[0].Content, "archived") {
+					t.Fatalf("expected no recap, got %+v", pc.History)
+				}
+				if !strings.Contains(pc.RecallPrompt, "Structured Session Snapshot") {
+					t.Fatalf("expected the snapshot block in the recall prompt, got %q", pc.RecallPrompt)
+				}
+				return
+			}
+			if !pc.Continuity.RestoredFromSnapshot || len(pc.History) != 2 {
+				t.Fatalf("expected recap ahead of the current turn, got %+v", pc.History)
+			}
+			if pc.History[0].Role != "assistant" || pc.History[1].Content != "What next?" {
+				t.Fatalf("unexpected restored history order: %+v", pc.History)
+			}
+			for _, want := range []string{"Planning the database migration.", "Postgres 16", "Prefers short answers", "Write the rollback plan"} {
+				if !strings.Contains(pc.History[0].Content, want) {
+					t.Fatalf("expected recap to mention %q, got %q", want, pc.History[0].Content)
+				}
+			}
+			if strings.Contains(pc.RecallPrompt, "Structured Session Snapshot") {
+				t.Fatalf("expected the snapshot block once, in the recap, got recall prompt %q", pc.RecallPrompt)
+			}
+		})
+	}
+}
+
 func TestSessionCompactor_ContinuesWithHeuristicSummaryWhenLLMSummaryFails(t *testing.T) {
 	ctx := context.Background()
 	dir := t.TempDir()
@@ -756,3 +878,265 @@ func TestService_ForceCompactSerializesPerSession(t *testing.T) {
 		t.Fatalf("expected compaction serialization, max concurrent summarizes=%d", got)
 	}
 }
+
+func TestService_ForceCompactAllCompactsEverySession(t *testing.T) {
+	ctx := context.Background()
+	var summaries int32
+	summarize := func(ctx context.Context, existingSummary, transcript string) (string, error) {
+		atomic.AddInt32(&summaries, 1)
+		return strings.TrimSpace(existingSummary + "\n" + transcript), nil
+	}
+	svc, err := NewService(Config{
+		Workspace:        t.TempDir(),
+		AgentID:          "dotagent",
+		ContextModel:     "test-model",
+		MaxContextTokens: 2048,
+	}, summarize)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	defer svc.Close()
+
+	for _, sessionKey := range []string{"discord:all-a", "discord:all-b"} {
+		if err := svc.EnsureSession(ctx, sessionKey, "discord", strings.TrimPrefix(sessionKey, "discord:"), "u-all"); err != nil {
+			t.Fatalf("ensure session: %v", err)
+		}
+		for i := 0; i < 36; i++ {
+			role := "assistant"
+			if i%2 == 0 {
+				role = "user"
+			}
+			if err := svc.AppendEvent(ctx, Event{
+				SessionKey: sessionKey,
+				TurnID:     fmt.Sprintf("turn-%d", i/2),
+				Seq:        i + 1,
+				Role:       role,
+				Content:    "compact all payload",
+			}); err != nil {
+				t.Fatalf("append event: %v", err)
+			}
+		}
+	}
+
+	compacted, err := svc.ForceCompactAll(ctx, 128)
+	if err != nil {
+		t.Fatalf("force compact all: %v", err)
+	}
+	if compacted != 2 {
+		t.Fatalf("expected 2 compacted sessions, got %d", compacted)
+	}
+	if got := atomic.LoadInt32(&summaries); got < 2 {
+		t.Fatalf("expected each session to be summarized, got %d summaries", got)
+	}
+}
+
+func TestService_IsAvailable(t *testing.T) {
+	svc, err := NewService(Config{Workspace: t.TempDir(), AgentID: "dotagent"}, nil)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	if !svc.IsAvailable(context.Background()) {
+		t.Fatalf("expected open store to be available")
+	}
+	_ = svc.Close()
+	if svc.IsAvailable(context.Background()) {
+		t.Fatalf("expected closed store to be unavailable")
+	}
+}
+
+func TestService_SearchMemoryFiltersByKind(t *testing.T) {
+	ctx := context.Background()
+	svc, err := NewService(Config{
+		Workspace:  t.TempDir(),
+		AgentID:    "dotagent",
+		WorkerPoll: 10 * time.Second,
+	}, nil)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	defer svc.Close()
+
+	if err := svc.EnsureSession(ctx, "discord:search", "discord", "search", "u-search"); err != nil {
+		t.Fatalf("ensure session: %v", err)
+	}
+	if _, _, err := svc.RecordUserTurn(ctx, Event{
+		SessionKey: "discord:search",
+		TurnID:     "turn-1",
+		Seq:        1,
+		Role:       "user",
+		Content:    "I really prefer pour-over coffee.",
+	}, "u-search"); err != nil {
+		t.Fatalf("record user turn: %v", err)
+	}
+
+	cards, err := svc.SearchMemory(ctx, "", "u-search", "coffee preference", MemoryUserPreference, 5)
+	if err != nil {
+		t.Fatalf("search memory: %v", err)
+	}
+	if len(cards) == 0 || cards[0].Key == "" || !strings.Contains(strings.ToLower(cards[0].Content), "pour-over") {
+		t.Fatalf("expected pour-over preference with key, got %+v", cards)
+	}
+	if cards[0].Scope != MemoryScopeUser {
+		t.Fatalf("expected user scope, got %q", cards[0].Scope)
+	}
+
+	cards, err = svc.SearchMemory(ctx, "", "u-search", "coffee preference", MemoryTaskState, 5)
+	if err != nil {
+		t.Fatalf("search memory by task kind: %v", err)
+	}
+	if len(cards) != 0 {
+		t.Fatalf("expected no task_state results, got %+v", cards)
+	}
+}
+
+func TestService_StoreAndDeleteMemoryByKey(t *testing.T) {
+	ctx := context.Background()
+	svc, err := NewService(Config{
+		Workspace:  t.TempDir(),
+		AgentID:    "dotagent",
+		WorkerPoll: 10 * time.Second,
+	}, nil)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	defer svc.Close()
+
+	item, err := svc.StoreMemory(ctx, "discord:explicit", "u-explicit", MemoryItem{
+		Kind:    MemorySemanticFact,
+		Key:     "flight_date",
+		Content: "User's flight to Lisbon departs on Friday.",
+	})
+	if err != nil {
+		t.Fatalf("store memory: %v", err)
+	}
+	if item.ScopeType != MemoryScopeUser || item.ScopeID != "u-explicit" || item.Metadata["source"] != "explicit" {
+		t.Fatalf("unexpected stored item: %+v", item)
+	}
+
+	scoped, err := svc.StoreMemory(ctx, "discord:explicit", "u-explicit", MemoryItem{
+		Kind:      MemoryTaskState,
+		Key:       "packing",
+		Content:   "Still needs to pack the charger.",
+		ScopeType: MemoryScopeSession,
+	})
+	if err != nil {
+		t.Fatalf("store session memory: %v", err)
+	}
+	if scoped.ScopeType != MemoryScopeSession || scoped.ScopeID != "discord:explicit" {
+		t.Fatalf("unexpected session scope: %+v", scoped)
+	}
+
+	cards, err := svc.SearchMemory(ctx, "", "u-explicit", "flight Lisbon", "", 5)
+	if err != nil || len(cards) == 0 || cards[0].Key != "flight_date" {
+		t.Fatalf("expected stored memory to be searchable, got %+v (err=%v)", cards, err)
+	}
+
+	if err := svc.DeleteMemoryByKey(ctx, "u-explicit", "", "flight_date"); err != nil {
+		t.Fatalf("delete memory: %v", err)
+	}
+	cards, err = svc.SearchMemory(ctx, "", "u-explicit", "flight Lisbon", "", 5)
+	if err != nil {
+		t.Fatalf("search after delete: %v", err)
+	}
+	for _, card := range cards {
+		if card.Key == "flight_date" {
+			t.Fatalf("expected deleted memory to be gone, got %+v", cards)
+		}
+	}
+}
+
+func TestService_RecoverStaleCompactions(t *testing.T) {
+	ctx := context.Background()
+	svc, err := NewService(Config{
+		Workspace:  t.TempDir(),
+		AgentID:    "dotagent",
+		WorkerPoll: 10 * time.Second, // keep jobs queued for assertion
+	}, nil)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	defer svc.Close()
+	store := svc.store.(*SQLiteStore)
+
+	if err := svc.EnsureSession(ctx, "discord:crashed", "discord", "c1", "u1"); err != nil {
+		t.Fatalf("ensure session: %v", err)
+	}
+	startedAt := time.Now().Add(-2 * time.Hour).UnixMilli()
+	var staleIDs []string
+	for i := 0; i < 2; i++ {
+		id, err := store.StartCompaction(ctx, "discord:crashed", 40, 12, map[string]string{"stage": "summarize"})
+		if err != nil {
+			t.Fatalf("start compaction: %v", err)
+		}
+		if _, err := store.db.ExecContext(ctx, `UPDATE session_compactions SET started_at_ms = ?, owner = '' WHERE id = ?`, startedAt, id); err != nil {
+			t.Fatalf("backdate compaction: %v", err)
+		}
+		staleIDs = append(staleIDs, id)
+	}
+	freshID, err := store.StartCompaction(ctx, "discord:crashed", 40, 12, nil)
+	if err != nil {
+		t.Fatalf("start fresh compaction: %v", err)
+	}
+	// Recent runs are recovered as soon as their owner is gone: here a
+	// predecessor that had the same pid, as after a container restart.
+	host, _ := os.Hostname()
+	for owner, ids := range map[string]*[]string{
+		fmt.Sprintf("%s:%d:previous-run", host, os.Getpid()):  &staleIDs,
+		fmt.Sprintf("%s:%d:parent-run", host, os.Getppid()):   nil,
+		fmt.Sprintf("%s-elsewhere:%d:other-host", host, 4242): nil,
+	} {
+		id, err := store.StartCompaction(ctx, "discord:crashed", 40, 12, nil)
+		if err != nil {
+			t.Fatalf("start owned compaction: %v", err)
+		}
+		if _, err := store.db.ExecContext(ctx, `UPDATE session_compactions SET owner = ? WHERE id = ?`, owner, id); err != nil {
+			t.Fatalf("set compaction owner: %v", err)
+		}
+		if ids != nil {
+			*ids = append(*ids, id)
+		}
+	}
+
+	cutoff := time.Now().Add(-30 * time.Minute).UnixMilli()
+	stale, err := store.ListStaleCompactions(ctx, cutoff)
+	if err != nil {
+		t.Fatalf("list stale compactions: %v", err)
+	}
+	if len(stale) != 2 || stale[0].Checkpoint["stage"] != "summarize" || stale[0].SourceEventCount != 40 {
+		t.Fatalf("unexpected stale compactions: %+v", stale)
+	}
+
+	if requeued := svc.recoverStaleCompactions(ctx, cutoff); requeued != 1 {
+		t.Fatalf("expected one session requeued, got %d", requeued)
+	}
+	for _, id := range staleIDs {
+		var status, errMsg string
+		if err := store.db.QueryRowContext(ctx, `SELECT status, error FROM session_compactions WHERE id = ?`, id).Scan(&status, &errMsg); err != nil {
+			t.Fatalf("load compaction: %v", err)
+		}
+		if status != JobFailed || errMsg != compactionInterruptedError {
+			t.Fatalf("expected stale compaction marked failed, got %s (%s)", status, errMsg)
+		}
+	}
+	var freshStatus string
+	if err := store.db.QueryRowContext(ctx, `SELECT status FROM session_compactions WHERE id = ?`, freshID).Scan(&freshStatus); err != nil {
+		t.Fatalf("load fresh compaction: %v", err)
+	}
+	if freshStatus != JobRunning {
+		t.Fatalf("fresh compaction should keep running, got %s", freshStatus)
+	}
+
+	var jobType, payload string
+	if err := store.db.QueryRowContext(ctx, `SELECT job_type, payload_json FROM memory_jobs WHERE session_key = ? AND status = ?`, "discord:crashed", JobPending).Scan(&jobType, &payload); err != nil {
+		t.Fatalf("load requeued job: %v", err)
+	}
+	if jobType != JobCompact || !strings.Contains(payload, `"user_id":"u1"`) {
+		t.Fatalf("unexpected requeued job %s %s", jobType, payload)
+	}
+	if stale, _ := store.ListStaleCompactions(ctx, cutoff); len(stale) != 0 {
+		t.Fatalf("expected no stale compactions after recovery, got %+v", stale)
+	}
+	if running, _ := store.ListStaleCompactions(ctx, time.Now().UnixMilli()); len(running) != 3 {
+		t.Fatalf("expected compactions with live or uncheckable owners to keep running, got %+v", running)
+	}
+}
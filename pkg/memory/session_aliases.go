@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrSessionAliasTaken is returned when an alias already names another
+// session or is itself a session key.
+var ErrSessionAliasTaken = errors.New("session alias already in use")
+
+// validateSessionAlias rejects aliases that could not be typed as a single
+// command argument.
+func validateSessionAlias(alias string) error {
+	if alias == "" {
+		return fmt.Errorf("session alias is required")
+	}
+	if strings.ContainsFunc(alias, unicode.IsSpace) {
+		return fmt.Errorf("session alias %q must not contain whitespace", alias)
+	}
+	return nil
+}
+
+// setSessionAlias names an existing session. Assigning an alias to the
+// session it already names is a no-op; a session may have several aliases.
+func setSessionAlias(ctx context.Context, db sqlTx, alias, sessionKey string) error {
+	alias = strings.TrimSpace(alias)
+	sessionKey = strings.TrimSpace(sessionKey)
+	if err := validateSessionAlias(alias); err != nil {
+		return err
+	}
+	var exists int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sessions WHERE session_key = ?`, sessionKey).Scan(&exists); err != nil {
+		return fmt.Errorf("set session alias: %w", err)
+	}
+	if exists == 0 {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, sessionKey)
+	}
+	if alias == sessionKey {
+		return nil
+	}
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sessions WHERE session_key = ?`, alias).Scan(&exists); err != nil {
+		return fmt.Errorf("set session alias: %w", err)
+	}
+	if exists > 0 {
+		return fmt.Errorf("%w: %q is a session key", ErrSessionAliasTaken, alias)
+	}
+	var current string
+	err := db.QueryRowContext(ctx, `SELECT session_key FROM session_aliases WHERE alias = ?`, alias).Scan(&current)
+	switch {
+	case err == nil && current == sessionKey:
+		return nil
+	case err == nil:
+		return fmt.Errorf("%w: %q names %s", ErrSessionAliasTaken, alias, current)
+	case !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("set session alias: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO session_aliases(alias, session_key, created_at_ms) VALUES(?, ?, ?)`, alias, sessionKey, nowMS()); err != nil {
+		return fmt.Errorf("set session alias: %w", err)
+	}
+	return nil
+}
+
+// resolveSessionKey returns the session an alias names, or keyOrAlias
+// unchanged when it is not an alias.
+func resolveSessionKey(ctx context.Context, db sqlTx, keyOrAlias string) (string, error) {
+	keyOrAlias = strings.TrimSpace(keyOrAlias)
+	if keyOrAlias == "" {
+		return "", nil
+	}
+	var sessionKey string
+	err := db.QueryRowContext(ctx, `SELECT session_key FROM session_aliases WHERE alias = ?`, keyOrAlias).Scan(&sessionKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return keyOrAlias, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("resolve session alias: %w", err)
+	}
+	return sessionKey, nil
+}
+
+func (s *SQLiteStore) SetSessionAlias(ctx context.Context, alias, sessionKey string) error {
+	return setSessionAlias(ctx, s.db, alias, sessionKey)
+}
+
+func (s *SQLiteStore) ResolveSessionKey(ctx context.Context, keyOrAlias string) (string, error) {
+	return resolveSessionKey(ctx, s.db, keyOrAlias)
+}
+
+func (s *PostgreSQLStore) SetSessionAlias(ctx context.Context, alias, sessionKey string) error {
+	return setSessionAlias(ctx, s.db, alias, sessionKey)
+}
+
+func (s *PostgreSQLStore) ResolveSessionKey(ctx context.Context, keyOrAlias string) (string, error) {
+	return resolveSessionKey(ctx, s.db, keyOrAlias)
+}
+
+// SetSessionAlias gives sessionKey a human-readable alias that commands
+// accepting a session key also accept.
+func (s *Service) SetSessionAlias(ctx context.Context, alias, sessionKey string) error {
+	return s.store.SetSessionAlias(ctx, alias, sessionKey)
+}
+
+// ResolveSessionKey maps an alias to its session key; anything else is
+// returned unchanged.
+func (s *Service) ResolveSessionKey(ctx context.Context, keyOrAlias string) (string, error) {
+	return s.store.ResolveSessionKey(ctx, keyOrAlias)
+}
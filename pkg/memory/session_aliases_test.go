@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSessionAliases(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+
+	for _, key := range []string{"discord:111", "discord:222"} {
+		if err := store.EnsureSession(ctx, key, "discord", key, "u1"); err != nil {
+			t.Fatalf("ensure session: %v", err)
+		}
+	}
+	if err := store.SetSessionAlias(ctx, "standup", "discord:111"); err != nil {
+		t.Fatalf("set alias: %v", err)
+	}
+	// Re-assigning to the same session is a no-op.
+	if err := store.SetSessionAlias(ctx, "standup", "discord:111"); err != nil {
+		t.Fatalf("repeat alias: %v", err)
+	}
+
+	for in, want := range map[string]string{
+		"standup":      "discord:111",
+		" standup ":    "discord:111",
+		"discord:222":  "discord:222",
+		"not-an-alias": "not-an-alias",
+	} {
+		got, err := store.ResolveSessionKey(ctx, in)
+		if err != nil || got != want {
+			t.Fatalf("resolve %q = %q, %v; want %q", in, got, err, want)
+		}
+	}
+
+	if err := store.SetSessionAlias(ctx, "standup", "discord:222"); !errors.Is(err, ErrSessionAliasTaken) {
+		t.Fatalf("expected alias reuse to fail, got %v", err)
+	}
+	if err := store.SetSessionAlias(ctx, "discord:222", "discord:111"); !errors.Is(err, ErrSessionAliasTaken) {
+		t.Fatalf("expected a session key alias to fail, got %v", err)
+	}
+	if err := store.SetSessionAlias(ctx, "daily", "discord:999"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected unknown session to fail, got %v", err)
+	}
+	if err := store.SetSessionAlias(ctx, "my standup", "discord:111"); err == nil {
+		t.Fatalf("expected an alias with whitespace to fail")
+	}
+
+	// Pruning a session drops its aliases.
+	sess, err := store.GetSession(ctx, "discord:111")
+	if err != nil {
+		t.Fatalf("get session: %v", err)
+	}
+	if _, err := store.PruneSessions(ctx, []Session{sess}, true); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if got, _ := store.ResolveSessionKey(ctx, "standup"); got != "standup" {
+		t.Fatalf("expected alias to be pruned with its session, resolved to %q", got)
+	}
+}
@@ -0,0 +1,436 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultSessionClusters is the number of topic clusters sessions are
+	// grouped into when no count is given.
+	DefaultSessionClusters = 10
+	// SessionClusterMetadataKey is the session_metadata key holding a
+	// session's topic cluster label.
+	SessionClusterMetadataKey = "topic_cluster"
+
+	sessionClusterTopics        = 3
+	sessionClusterMaxIterations = 50
+	sessionClusterRestarts      = 10
+	sessionClusterTextChars     = 4000
+	sessionClusterUserMessages  = 20
+)
+
+// SessionCluster is a group of sessions about similar topics.
+type SessionCluster struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"`
+	// Topics are the terms that best set the cluster apart, most distinctive
+	// first.
+	Topics []string `json:"topics"`
+	// Sessions are the member session keys, most recently updated first.
+	Sessions []string `json:"sessions"`
+}
+
+// SessionClusterer groups sessions by topic. It embeds each session's summary
+// (or its recent user messages when there is no summary yet), runs k-means on
+// the vectors and tags every clustered session with its cluster label.
+type SessionClusterer struct {
+	store      Store
+	embeddings EmbeddingProvider
+	k          int
+}
+
+// NewSessionClusterer clusters into k groups; k <= 0 selects
+// DefaultSessionClusters. A nil embeddings provider uses local embeddings.
+func NewSessionClusterer(store Store, embeddings EmbeddingProvider, k int) *SessionClusterer {
+	if k <= 0 {
+		k = DefaultSessionClusters
+	}
+	return &SessionClusterer{store: store, embeddings: embeddingsOrDefault(embeddings), k: k}
+}
+
+type clusterSession struct {
+	key    string
+	text   string
+	vector []float32
+}
+
+// Cluster groups the user's sessions, or every session when userID is empty,
+// stores each session's label under SessionClusterMetadataKey and returns the
+// clusters largest first. Sessions without any text are left untagged.
+func (c *SessionClusterer) Cluster(ctx context.Context, userID string) ([]SessionCluster, error) {
+	sessions, err := c.store.listClusterSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]clusterSession, 0, len(sessions))
+	dims := 0
+	for _, sess := range sessions {
+		if sess.text == "" {
+			continue
+		}
+		vec, _, err := c.embeddings.Embed(ctx, sess.text)
+		if err != nil {
+			return nil, fmt.Errorf("embed session %s: %w", sess.key, err)
+		}
+		// A provider that fell back to another model mid-run returns vectors
+		// of a different size; they cannot be compared, so skip them.
+		if len(vec) == 0 || (dims != 0 && len(vec) != dims) {
+			continue
+		}
+		dims = len(vec)
+		sess.vector = append([]float32(nil), vec...)
+		normalizeVector(sess.vector)
+		points = append(points, sess)
+	}
+
+	vectors := make([][]float32, len(points))
+	for i, p := range points {
+		vectors[i] = p.vector
+	}
+	assignments := kMeans(vectors, c.k)
+
+	groups := map[int][]clusterSession{}
+	for i, cluster := range assignments {
+		groups[cluster] = append(groups[cluster], points[i])
+	}
+	clusters := make([]SessionCluster, 0, len(groups))
+	texts := make([][]string, 0, len(groups))
+	for _, members := range groups {
+		cluster := SessionCluster{}
+		memberTexts := make([]string, 0, len(members))
+		for _, m := range members {
+			cluster.Sessions = append(cluster.Sessions, m.key)
+			memberTexts = append(memberTexts, m.text)
+		}
+		clusters = append(clusters, cluster)
+		texts = append(texts, memberTexts)
+	}
+	topics := clusterTopics(texts, sessionClusterTopics)
+	for i := range clusters {
+		clusters[i].Topics = topics[i]
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if len(clusters[i].Sessions) != len(clusters[j].Sessions) {
+			return len(clusters[i].Sessions) > len(clusters[j].Sessions)
+		}
+		return strings.Join(clusters[i].Topics, ",") < strings.Join(clusters[j].Topics, ",")
+	})
+
+	labels := make(map[string]string, len(points))
+	for i := range clusters {
+		clusters[i].ID = i + 1
+		clusters[i].Label = fmt.Sprintf("%d: %s", clusters[i].ID, strings.Join(clusters[i].Topics, ", "))
+		if len(clusters[i].Topics) == 0 {
+			clusters[i].Label = fmt.Sprintf("%d", clusters[i].ID)
+		}
+		for _, key := range clusters[i].Sessions {
+			labels[key] = clusters[i].Label
+		}
+	}
+	if err := c.store.setSessionClusterLabels(ctx, sessions, labels); err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
+
+// listClusterSessions returns the sessions to cluster, most recently updated
+// first, with the text that describes each one. The text is empty for
+// sessions with neither a summary nor user messages.
+func (s *SQLiteStore) listClusterSessions(ctx context.Context, userID string) ([]clusterSession, error) {
+	return listClusterSessions(ctx, s.db, userID)
+}
+
+func (s *PostgreSQLStore) listClusterSessions(ctx context.Context, userID string) ([]clusterSession, error) {
+	return listClusterSessions(ctx, s.db, userID)
+}
+
+func listClusterSessions(ctx context.Context, db sqlQuerier, userID string) ([]clusterSession, error) {
+	query := `SELECT session_key, summary FROM sessions`
+	args := []interface{}{}
+	if strings.TrimSpace(userID) != "" {
+		query += ` WHERE user_id = ?`
+		args = append(args, userID)
+	}
+	query += ` ORDER BY updated_at_ms DESC`
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions for clustering: %w", err)
+	}
+	var sessions []clusterSession
+	for rows.Next() {
+		var sess clusterSession
+		if err := rows.Scan(&sess.key, &sess.text); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan session row: %w", err)
+		}
+		sessions = append(sessions, sess)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("iterate sessions: %w", err)
+	}
+
+	for i := range sessions {
+		sess := &sessions[i]
+		if strings.TrimSpace(sess.text) == "" {
+			sess.text, err = recentUserText(ctx, db, sess.key)
+			if err != nil {
+				return nil, err
+			}
+		}
+		sess.text = strings.TrimSpace(sess.text)
+		if len(sess.text) > sessionClusterTextChars {
+			sess.text = sess.text[:sessionClusterTextChars]
+			for !utf8.ValidString(sess.text) {
+				sess.text = sess.text[:len(sess.text)-1]
+			}
+		}
+	}
+	return sessions, nil
+}
+
+func recentUserText(ctx context.Context, db sqlQuerier, sessionKey string) (string, error) {
+	rows, err := db.QueryContext(ctx, `
+SELECT content FROM events
+WHERE session_key = ? AND role = 'user'
+ORDER BY created_at_ms DESC, seq DESC
+LIMIT ?`, sessionKey, sessionClusterUserMessages)
+	if err != nil {
+		return "", fmt.Errorf("list session messages: %w", err)
+	}
+	defer rows.Close()
+	var parts []string
+	for rows.Next() {
+		var content string
+		if err := rows.Scan(&content); err != nil {
+			return "", fmt.Errorf("scan session message: %w", err)
+		}
+		parts = append(parts, content)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("iterate session messages: %w", err)
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+// setSessionClusterLabels replaces the cluster labels of sessions in one
+// transaction; sessions missing from labels lose their old label.
+func (s *SQLiteStore) setSessionClusterLabels(ctx context.Context, sessions []clusterSession, labels map[string]string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("tag session clusters: %w", err)
+	}
+	defer tx.Rollback()
+	if err := setSessionClusterLabelsTx(ctx, tx, sessions, labels); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("tag session clusters: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) setSessionClusterLabels(ctx context.Context, sessions []clusterSession, labels map[string]string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("tag session clusters: %w", err)
+	}
+	defer tx.Rollback()
+	if err := setSessionClusterLabelsTx(ctx, tx, sessions, labels); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("tag session clusters: %w", err)
+	}
+	return nil
+}
+
+func setSessionClusterLabelsTx(ctx context.Context, tx sqlTx, sessions []clusterSession, labels map[string]string) error {
+	now := nowMS()
+	for _, sess := range sessions {
+		label, ok := labels[sess.key]
+		if !ok {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM session_metadata WHERE session_key = ? AND key = ?`, sess.key, SessionClusterMetadataKey); err != nil {
+				return fmt.Errorf("clear session cluster: %w", err)
+			}
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO session_metadata(session_key, key, value, updated_at_ms)
+VALUES(?, ?, ?, ?)
+ON CONFLICT(session_key, key) DO UPDATE SET
+	value = excluded.value,
+	updated_at_ms = excluded.updated_at_ms`, sess.key, SessionClusterMetadataKey, label, now); err != nil {
+			return fmt.Errorf("tag session cluster: %w", err)
+		}
+	}
+	return nil
+}
+
+// kMeans assigns each unit vector to one of at most k clusters by cosine
+// distance. It keeps the best of several k-means++ runs, since a single run
+// can settle on clusters that mix topics. The seed is fixed, so the same
+// vectors always cluster the same way.
+func kMeans(vectors [][]float32, k int) []int {
+	n := len(vectors)
+	if n == 0 {
+		return nil
+	}
+	if k > n {
+		k = n
+	}
+	rng := rand.New(rand.NewSource(1))
+	var best []int
+	bestCost := math.Inf(1)
+	for run := 0; run < sessionClusterRestarts; run++ {
+		assignments, cost := kMeansRun(vectors, k, rng)
+		if cost < bestCost {
+			best, bestCost = assignments, cost
+		}
+	}
+	return best
+}
+
+// kMeansRun is one k-means++ seeded run. It returns the assignments and their
+// total distance to the cluster centroids.
+func kMeansRun(vectors [][]float32, k int, rng *rand.Rand) ([]int, float64) {
+	n := len(vectors)
+	dist := func(a, b []float32) float64 { return 1 - cosineSimilarity(a, b) }
+
+	centroids := [][]float32{vectors[rng.Intn(n)]}
+	nearest := make([]float64, n)
+	for len(centroids) < k {
+		total := 0.0
+		for i, v := range vectors {
+			nearest[i] = math.Inf(1)
+			for _, c := range centroids {
+				if d := dist(v, c); d < nearest[i] {
+					nearest[i] = d
+				}
+			}
+			total += nearest[i] * nearest[i]
+		}
+		if total == 0 {
+			break // every remaining point duplicates a centroid
+		}
+		target := rng.Float64() * total
+		next := n - 1
+		for i, d := range nearest {
+			target -= d * d
+			if target <= 0 {
+				next = i
+				break
+			}
+		}
+		centroids = append(centroids, vectors[next])
+	}
+
+	assignments := make([]int, n)
+	cost := 0.0
+	for iter := 0; iter < sessionClusterMaxIterations; iter++ {
+		changed := false
+		cost = 0
+		for i, v := range vectors {
+			best, bestDist := 0, math.Inf(1)
+			for c, centroid := range centroids {
+				if d := dist(v, centroid); d < bestDist {
+					best, bestDist = c, d
+				}
+			}
+			cost += bestDist
+			if iter == 0 || assignments[i] != best {
+				assignments[i] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+		sums := make([][]float32, len(centroids))
+		for i, v := range vectors {
+			c := assignments[i]
+			if sums[c] == nil {
+				sums[c] = make([]float32, len(v))
+			}
+			for d := range v {
+				sums[c][d] += v[d]
+			}
+		}
+		for c, sum := range sums {
+			if sum == nil {
+				continue // empty cluster keeps its centroid
+			}
+			normalizeVector(sum)
+			centroids[c] = sum
+		}
+	}
+	return assignments, cost
+}
+
+// clusterTopics picks up to limit terms per cluster that appear in a larger
+// share of the cluster's sessions than of all sessions.
+func clusterTopics(clusters [][]string, limit int) [][]string {
+	total := 0
+	overall := map[string]int{}
+	perCluster := make([]map[string]int, len(clusters))
+	for i, texts := range clusters {
+		perCluster[i] = map[string]int{}
+		for _, text := range texts {
+			for term := range clusterTerms(text) {
+				perCluster[i][term]++
+				overall[term]++
+			}
+		}
+		total += len(texts)
+	}
+	out := make([][]string, len(clusters))
+	for i, counts := range perCluster {
+		type scored struct {
+			term  string
+			score float64
+			count int
+		}
+		var terms []scored
+		size := float64(len(clusters[i]))
+		for term, count := range counts {
+			score := float64(count)/size - float64(overall[term])/float64(total)
+			if len(clusters) == 1 {
+				score = float64(count) / size
+			}
+			if score > 0 {
+				terms = append(terms, scored{term: term, score: score, count: count})
+			}
+		}
+		sort.Slice(terms, func(a, b int) bool {
+			if terms[a].score != terms[b].score {
+				return terms[a].score > terms[b].score
+			}
+			if terms[a].count != terms[b].count {
+				return terms[a].count > terms[b].count
+			}
+			return terms[a].term < terms[b].term
+		})
+		for j := 0; j < len(terms) && j < limit; j++ {
+			out[i] = append(out[i], terms[j].term)
+		}
+	}
+	return out
+}
+
+func clusterTerms(text string) map[string]struct{} {
+	terms := map[string]struct{}{}
+	for _, token := range unicodeQueryTokenPattern.FindAllString(strings.ToLower(text), -1) {
+		if utf8.RuneCountInString(token) < 3 || isStopwordToken(token) {
+			continue
+		}
+		terms[token] = struct{}{}
+	}
+	return terms
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSessionClusterer_GroupsByTopic(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state", "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+
+	summaries := map[string]string{
+		"cli:k8s-1":   "Debugged a kubernetes deployment whose pods crash-looped after the helm chart upgrade.",
+		"cli:k8s-2":   "Wrote a helm chart for the kubernetes ingress and fixed the deployment probes.",
+		"cli:k8s-3":   "Scaled the kubernetes deployment and tuned pod resource limits in the helm chart.",
+		"cli:bread-1": "Planned a sourdough bread recipe with a longer bulk fermentation and baking schedule.",
+		"cli:bread-2": "Adjusted sourdough starter feeding and bread hydration before baking.",
+		"cli:bread-3": "Compared sourdough bread baking temperatures and fermentation times.",
+	}
+	for key, summary := range summaries {
+		if err := store.EnsureSession(ctx, key, "cli", key, "u1"); err != nil {
+			t.Fatalf("upsert session: %v", err)
+		}
+		if err := store.SetSessionSummary(ctx, key, summary); err != nil {
+			t.Fatalf("set summary: %v", err)
+		}
+	}
+	if err := store.EnsureSession(ctx, "cli:empty", "cli", "empty", "u1"); err != nil {
+		t.Fatalf("upsert session: %v", err)
+	}
+	if err := store.SetSessionMetadata(ctx, "cli:empty", SessionClusterMetadataKey, "stale"); err != nil {
+		t.Fatalf("set metadata: %v", err)
+	}
+
+	clusters, err := NewSessionClusterer(store, nil, 2).Cluster(ctx, "u1")
+	if err != nil {
+		t.Fatalf("cluster: %v", err)
+	}
+	if len(clusters) != 2 || len(clusters[0].Sessions) != 3 || len(clusters[1].Sessions) != 3 {
+		t.Fatalf("expected two clusters of three sessions, got %+v", clusters)
+	}
+	for _, cluster := range clusters {
+		prefix := cluster.Sessions[0][:len("cli:k8s")]
+		for _, key := range cluster.Sessions {
+			if key[:len(prefix)] != prefix {
+				t.Fatalf("expected sessions grouped by topic, got %+v", clusters)
+			}
+		}
+		if len(cluster.Topics) == 0 {
+			t.Fatalf("expected representative topics, got %+v", cluster)
+		}
+		label, err := store.GetSessionMetadata(ctx, cluster.Sessions[0], SessionClusterMetadataKey)
+		if err != nil || label != cluster.Label || label != fmt.Sprintf("%d: %s", cluster.ID, strings.Join(cluster.Topics, ", ")) {
+			t.Fatalf("expected session tagged with %q, got %q %v", cluster.Label, label, err)
+		}
+	}
+	if label, _ := store.GetSessionMetadata(ctx, "cli:empty", SessionClusterMetadataKey); label != "" {
+		t.Fatalf("expected stale label cleared for session without text, got %q", label)
+	}
+}
+
+func TestKMeans_FewerPointsThanClusters(t *testing.T) {
+	vectors := [][]float32{{1, 0}, {0, 1}}
+	assignments := kMeans(vectors, 10)
+	if len(assignments) != 2 || assignments[0] == assignments[1] {
+		t.Fatalf("expected each point in its own cluster, got %v", assignments)
+	}
+}
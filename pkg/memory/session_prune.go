@@ -0,0 +1,188 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sessionPruneDeletes lists, per table, the statement removing one pruned
+// session's rows. Rows hanging off session-scoped memory items go before the
+// items themselves, and the session row goes last. Memory items scoped to the
+// user or globally are kept even when they were learned in a pruned session.
+var sessionPruneDeletes = []struct {
+	table string
+	query string
+}{
+	{"memory_embeddings", `DELETE FROM memory_embeddings WHERE item_id IN (` + prunedSessionItems + `)`},
+	{"memory_links", `DELETE FROM memory_links WHERE from_item_id IN (` + prunedSessionItems + `) OR to_item_id IN (` + prunedSessionItems + `)`},
+	{"memory_observations", `DELETE FROM memory_observations WHERE item_id IN (` + prunedSessionItems + `)`},
+	{"memory_items", `DELETE FROM memory_items WHERE id IN (` + prunedSessionItems + `)`},
+	{"events", `DELETE FROM events WHERE session_key = ?`},
+	{"session_snapshots", `DELETE FROM session_snapshots WHERE session_key = ?`},
+	{"session_compactions", `DELETE FROM session_compactions WHERE session_key = ?`},
+	{"session_provider_states", `DELETE FROM session_provider_states WHERE session_key = ?`},
+	{"session_metadata", `DELETE FROM session_metadata WHERE session_key = ?`},
+	{"session_tags", `DELETE FROM session_tags WHERE session_key = ?`},
+	{"session_aliases", `DELETE FROM session_aliases WHERE session_key = ?`},
+	{"session_index_state", `DELETE FROM session_index_state WHERE session_key = ?`},
+	{"sessions", `DELETE FROM sessions WHERE session_key = ?`},
+}
+
+const prunedSessionItems = `SELECT id FROM memory_items WHERE session_key = ? AND scope_type = 'session'`
+
+// PruneCount is the number of rows a session prune removed from one table.
+type PruneCount struct {
+	Table string
+	Rows  int64
+}
+
+// ListPrunableSessions returns sessions last updated before olderThanMS that
+// hold fewer than minMessages messages, oldest first.
+func (s *SQLiteStore) ListPrunableSessions(ctx context.Context, olderThanMS int64, minMessages int) ([]Session, error) {
+	return listPrunableSessions(ctx, s.db, olderThanMS, minMessages)
+}
+
+func (s *PostgreSQLStore) ListPrunableSessions(ctx context.Context, olderThanMS int64, minMessages int) ([]Session, error) {
+	return listPrunableSessions(ctx, s.db, olderThanMS, minMessages)
+}
+
+func listPrunableSessions(ctx context.Context, db sqlQuerier, olderThanMS int64, minMessages int) ([]Session, error) {
+	rows, err := db.QueryContext(ctx, `
+SELECT session_key, channel, chat_id, user_id, created_at_ms, updated_at_ms, message_count, summary, last_consolidated_ms, history_version
+FROM sessions
+WHERE updated_at_ms < ? AND message_count < ?
+ORDER BY updated_at_ms ASC`, olderThanMS, minMessages)
+	if err != nil {
+		return nil, fmt.Errorf("list prunable sessions: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Session{}
+	for rows.Next() {
+		var sess Session
+		if scanErr := rows.Scan(
+			&sess.SessionKey,
+			&sess.Channel,
+			&sess.ChatID,
+			&sess.UserID,
+			&sess.CreatedAtMS,
+			&sess.UpdatedAtMS,
+			&sess.MessageCount,
+			&sess.Summary,
+			&sess.LastConsolidatedMS,
+			&sess.HistoryVersion,
+		); scanErr != nil {
+			return nil, fmt.Errorf("scan prunable session row: %w", scanErr)
+		}
+		out = append(out, sess)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate prunable sessions: %w", err)
+	}
+	return out, nil
+}
+
+// PruneSessions hard-deletes the given sessions with their events, snapshots
+// and session-scoped memory items in a single transaction, returning the rows
+// removed per table. When apply is false nothing is written; the rows an
+// applied prune would remove are counted instead.
+func (s *SQLiteStore) PruneSessions(ctx context.Context, sessions []Session, apply bool) ([]PruneCount, error) {
+	if !apply {
+		return countSessionsPrune(ctx, s.db, sessions)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("prune sessions begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	counts, err := pruneSessionsTx(ctx, tx, sessions)
+	if err != nil {
+		return nil, err
+	}
+	if err := invalidateRetrievalCacheTx(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("prune sessions commit: %w", err)
+	}
+	return counts, nil
+}
+
+// PruneSessions hard-deletes the given sessions; see SQLiteStore.PruneSessions.
+func (s *PostgreSQLStore) PruneSessions(ctx context.Context, sessions []Session, apply bool) ([]PruneCount, error) {
+	if !apply {
+		return countSessionsPrune(ctx, s.db, sessions)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("prune sessions begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	counts, err := pruneSessionsTx(ctx, tx, sessions)
+	if err != nil {
+		return nil, err
+	}
+	if err := invalidateRetrievalCacheTx(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("prune sessions commit: %w", err)
+	}
+	return counts, nil
+}
+
+// countSessionsPrune counts the rows each prune statement would delete for
+// the given sessions, using the statements' own conditions.
+func countSessionsPrune(ctx context.Context, db sqlTx, sessions []Session) ([]PruneCount, error) {
+	counts := make([]PruneCount, len(sessionPruneDeletes))
+	for i, del := range sessionPruneDeletes {
+		counts[i].Table = del.table
+	}
+	for _, sess := range sessions {
+		for i, del := range sessionPruneDeletes {
+			var n int64
+			query := strings.Replace(del.query, "DELETE FROM", "SELECT COUNT(*) FROM", 1)
+			if err := db.QueryRowContext(ctx, query, sessionPruneArgs(del.table, sess)...).Scan(&n); err != nil {
+				return nil, fmt.Errorf("count %s for session %s: %w", del.table, sess.SessionKey, err)
+			}
+			counts[i].Rows += n
+		}
+	}
+	return counts, nil
+}
+
+func sessionPruneArgs(table string, sess Session) []interface{} {
+	if table == "memory_links" {
+		return []interface{}{sess.SessionKey, sess.SessionKey}
+	}
+	return []interface{}{sess.SessionKey}
+}
+
+// pruneSessionsTx deletes the sessions' rows and audits each prune inside tx,
+// leaving commit or rollback to the caller.
+func pruneSessionsTx(ctx context.Context, tx sqlTx, sessions []Session) ([]PruneCount, error) {
+	counts := make([]PruneCount, len(sessionPruneDeletes))
+	for i, del := range sessionPruneDeletes {
+		counts[i].Table = del.table
+	}
+	for _, sess := range sessions {
+		for i, del := range sessionPruneDeletes {
+			res, err := tx.ExecContext(ctx, del.query, sessionPruneArgs(del.table, sess)...)
+			if err != nil {
+				return nil, fmt.Errorf("prune %s for session %s: %w", del.table, sess.SessionKey, err)
+			}
+			n, _ := res.RowsAffected()
+			counts[i].Rows += n
+		}
+		if err := insertAuditLogTx(ctx, tx, "session_prune", "session", sess.SessionKey, sess.SessionKey, sess.UserID, "", "prune", map[string]string{
+			"message_count": strconv.Itoa(sess.MessageCount),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return counts, nil
+}
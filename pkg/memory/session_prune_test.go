@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteStore_PruneSessions(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state", "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+
+	seed := func(sessionKey string, messages int) {
+		t.Helper()
+		for i := 0; i < messages; i++ {
+			if err := store.AppendEvent(ctx, Event{SessionKey: sessionKey, Role: "user", Content: "hi"}); err != nil {
+				t.Fatalf("append event: %v", err)
+			}
+		}
+		if err := store.UpsertSessionSnapshot(ctx, SessionSnapshot{SessionKey: sessionKey, Revision: 1, Summary: "short"}); err != nil {
+			t.Fatalf("upsert snapshot: %v", err)
+		}
+		for _, scope := range []MemoryScopeType{MemoryScopeSession, MemoryScopeUser} {
+			if _, err := store.UpsertMemoryItem(ctx, MemoryItem{
+				UserID:     "u1",
+				AgentID:    "dotagent",
+				ScopeType:  scope,
+				SessionKey: sessionKey,
+				Kind:       MemorySemanticFact,
+				Key:        sessionKey + "/" + string(scope),
+				Content:    "fact from " + sessionKey,
+				Confidence: 0.8,
+			}); err != nil {
+				t.Fatalf("upsert memory item: %v", err)
+			}
+		}
+	}
+	seed("cli:old-short", 1)
+	seed("cli:old-long", 5)
+	seed("cli:new-short", 1)
+	if _, err := store.db.ExecContext(ctx, `UPDATE sessions SET updated_at_ms = 1000 WHERE session_key IN ('cli:old-short', 'cli:old-long')`); err != nil {
+		t.Fatalf("age sessions: %v", err)
+	}
+
+	sessions, err := store.ListPrunableSessions(ctx, nowMS()-60_000, 3)
+	if err != nil {
+		t.Fatalf("list prunable sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].SessionKey != "cli:old-short" {
+		t.Fatalf("expected only cli:old-short to be prunable, got %+v", sessions)
+	}
+
+	rowsFor := func(counts []PruneCount) map[string]int64 {
+		out := map[string]int64{}
+		for _, c := range counts {
+			out[c.Table] = c.Rows
+		}
+		return out
+	}
+	dry, err := store.PruneSessions(ctx, sessions, false)
+	if err != nil {
+		t.Fatalf("dry-run prune: %v", err)
+	}
+	if got := rowsFor(dry); got["events"] != 1 || got["session_snapshots"] != 1 || got["memory_items"] != 1 || got["sessions"] != 1 {
+		t.Fatalf("unexpected dry-run counts: %+v", got)
+	}
+	if _, err := store.GetSession(ctx, "cli:old-short"); err != nil {
+		t.Fatalf("expected dry run to keep the session: %v", err)
+	}
+
+	applied, err := store.PruneSessions(ctx, sessions, true)
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if got, want := rowsFor(applied), rowsFor(dry); len(got) != len(want) {
+		t.Fatalf("expected applied counts %+v to match dry run %+v", got, want)
+	} else {
+		for table, n := range want {
+			if got[table] != n {
+				t.Fatalf("expected applied counts %+v to match dry run %+v", got, want)
+			}
+		}
+	}
+	if events, _ := store.ListRecentEvents(ctx, "cli:old-short", 10, true); len(events) != 0 {
+		t.Fatalf("expected pruned events to be deleted, got %d", len(events))
+	}
+	if remaining, _ := store.ListPrunableSessions(ctx, nowMS()-60_000, 3); len(remaining) != 0 {
+		t.Fatalf("expected no prunable sessions left, got %+v", remaining)
+	}
+	items, err := store.FindMemoryItemsByKey(ctx, "cli:old-short/"+string(MemoryScopeUser))
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected user-scoped memory from a pruned session to be kept, got %d (err=%v)", len(items), err)
+	}
+	if items, _ := store.FindMemoryItemsByKey(ctx, "cli:old-short/"+string(MemoryScopeSession)); len(items) != 0 {
+		t.Fatalf("expected session-scoped memory to be deleted, got %+v", items)
+	}
+	if events, _ := store.ListRecentEvents(ctx, "cli:old-long", 10, true); len(events) != 5 {
+		t.Fatalf("expected cli:old-long to be kept, got %d events", len(events))
+	}
+}
@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// snapshotModifiedSimilarity is the token overlap above which a removed and
+// an added item of the same category count as one modified item.
+const snapshotModifiedSimilarity = 0.5
+
+// SnapshotItemChange is an item whose wording changed between revisions.
+type SnapshotItemChange struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// SnapshotCategoryChanges lists the item changes of one snapshot category.
+type SnapshotCategoryChanges struct {
+	Added    []string             `json:"added,omitempty"`
+	Removed  []string             `json:"removed,omitempty"`
+	Modified []SnapshotItemChange `json:"modified,omitempty"`
+}
+
+// Empty reports whether the category did not change.
+func (c SnapshotCategoryChanges) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Modified) == 0
+}
+
+// SnapshotChanges describes how a session snapshot changed from one revision
+// to another.
+type SnapshotChanges struct {
+	SessionKey     string                  `json:"session_key"`
+	FromRevision   int                     `json:"from_revision"`
+	ToRevision     int                     `json:"to_revision"`
+	Facts          SnapshotCategoryChanges `json:"facts"`
+	Preferences    SnapshotCategoryChanges `json:"preferences"`
+	Tasks          SnapshotCategoryChanges `json:"tasks"`
+	OpenLoops      SnapshotCategoryChanges `json:"open_loops"`
+	Constraints    SnapshotCategoryChanges `json:"constraints"`
+	SummaryChanged bool                    `json:"summary_changed"`
+}
+
+// SnapshotCategory pairs a category name with its changes.
+type SnapshotCategory struct {
+	Name    string
+	Changes SnapshotCategoryChanges
+}
+
+// Categories returns the per-category changes in display order.
+func (c SnapshotChanges) Categories() []SnapshotCategory {
+	return []SnapshotCategory{
+		{"facts", c.Facts},
+		{"preferences", c.Preferences},
+		{"tasks", c.Tasks},
+		{"open_loops", c.OpenLoops},
+		{"constraints", c.Constraints},
+	}
+}
+
+// Empty reports whether nothing changed between the two snapshots.
+func (c SnapshotChanges) Empty() bool {
+	for _, cat := range c.Categories() {
+		if !cat.Changes.Empty() {
+			return false
+		}
+	}
+	return !c.SummaryChanged
+}
+
+// SnapshotDiff compares snapshot a with the later snapshot b. Items are
+// matched case-insensitively; a removed item that shares most of its words
+// with an added item is reported as modified instead.
+func SnapshotDiff(a, b SessionSnapshot) SnapshotChanges {
+	return SnapshotChanges{
+		SessionKey:     b.SessionKey,
+		FromRevision:   a.Revision,
+		ToRevision:     b.Revision,
+		Facts:          diffSnapshotItems(a.Facts, b.Facts),
+		Preferences:    diffSnapshotItems(a.Preferences, b.Preferences),
+		Tasks:          diffSnapshotItems(a.Tasks, b.Tasks),
+		OpenLoops:      diffSnapshotItems(a.OpenLoops, b.OpenLoops),
+		Constraints:    diffSnapshotItems(a.Constraints, b.Constraints),
+		SummaryChanged: strings.TrimSpace(a.Summary) != strings.TrimSpace(b.Summary),
+	}
+}
+
+func diffSnapshotItems(before, after []string) SnapshotCategoryChanges {
+	beforeKeys := map[string]struct{}{}
+	for _, item := range before {
+		beforeKeys[strings.ToLower(strings.TrimSpace(item))] = struct{}{}
+	}
+	afterKeys := map[string]struct{}{}
+	for _, item := range after {
+		afterKeys[strings.ToLower(strings.TrimSpace(item))] = struct{}{}
+	}
+
+	removed := []string{}
+	for _, item := range before {
+		if _, ok := afterKeys[strings.ToLower(strings.TrimSpace(item))]; !ok {
+			removed = append(removed, item)
+		}
+	}
+	added := []string{}
+	for _, item := range after {
+		if _, ok := beforeKeys[strings.ToLower(strings.TrimSpace(item))]; !ok {
+			added = append(added, item)
+		}
+	}
+
+	var out SnapshotCategoryChanges
+	paired := make([]bool, len(added))
+	for _, old := range removed {
+		best, bestScore := -1, snapshotModifiedSimilarity
+		for i, candidate := range added {
+			if paired[i] {
+				continue
+			}
+			if score := textTokenJaccard(old, candidate); score >= bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			out.Removed = append(out.Removed, old)
+			continue
+		}
+		paired[best] = true
+		out.Modified = append(out.Modified, SnapshotItemChange{Before: old, After: added[best]})
+	}
+	for i, item := range added {
+		if !paired[i] {
+			out.Added = append(out.Added, item)
+		}
+	}
+	return out
+}
+
+// GetSessionSnapshot returns the snapshot of sessionKey at revision, or an
+// error when that revision does not exist.
+func (s *SQLiteStore) GetSessionSnapshot(ctx context.Context, sessionKey string, revision int) (SessionSnapshot, error) {
+	return getSessionSnapshot(ctx, s.db, sessionKey, revision)
+}
+
+func (s *PostgreSQLStore) GetSessionSnapshot(ctx context.Context, sessionKey string, revision int) (SessionSnapshot, error) {
+	return getSessionSnapshot(ctx, s.db, sessionKey, revision)
+}
+
+func getSessionSnapshot(ctx context.Context, db sqlTx, sessionKey string, revision int) (SessionSnapshot, error) {
+	row := db.QueryRowContext(ctx, `
+SELECT session_key, revision, created_at_ms, facts_json, preferences_json, tasks_json, open_loops_json, constraints_json, summary, compaction_id
+FROM session_snapshots
+WHERE session_key = ? AND revision = ?`, sessionKey, revision)
+	var snap SessionSnapshot
+	var factsRaw, prefRaw, tasksRaw, loopsRaw, constraintsRaw string
+	if err := row.Scan(&snap.SessionKey, &snap.Revision, &snap.CreatedAtMS, &factsRaw, &prefRaw, &tasksRaw, &loopsRaw, &constraintsRaw, &snap.Summary, &snap.CompactionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return SessionSnapshot{}, fmt.Errorf("session %s has no snapshot revision %d", sessionKey, revision)
+		}
+		return SessionSnapshot{}, fmt.Errorf("get session snapshot: %w", err)
+	}
+	snap.Facts = decodeStringSlice(factsRaw)
+	snap.Preferences = decodeStringSlice(prefRaw)
+	snap.Tasks = decodeStringSlice(tasksRaw)
+	snap.OpenLoops = decodeStringSlice(loopsRaw)
+	snap.Constraints = decodeStringSlice(constraintsRaw)
+	return snap, nil
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotDiff(t *testing.T) {
+	a := SessionSnapshot{
+		SessionKey:  "cli:direct",
+		Revision:    3,
+		Facts:       []string{"User lives in Berlin", "Project uses Go 1.24 toolchain"},
+		Preferences: []string{"Prefers short answers"},
+		Tasks:       []string{"Write release notes"},
+		Summary:     "Planning the release.",
+	}
+	b := SessionSnapshot{
+		SessionKey:  "cli:direct",
+		Revision:    5,
+		Facts:       []string{"user lives in berlin", "Project uses Go 1.25 toolchain", "Team ships on Fridays"},
+		Preferences: []string{"Prefers short answers"},
+		Summary:     "Planning the release.",
+	}
+
+	changes := SnapshotDiff(a, b)
+	if changes.FromRevision != 3 || changes.ToRevision != 5 || changes.SessionKey != "cli:direct" {
+		t.Fatalf("unexpected header: %+v", changes)
+	}
+	if !reflect.DeepEqual(changes.Facts.Added, []string{"Team ships on Fridays"}) {
+		t.Fatalf("facts added = %v", changes.Facts.Added)
+	}
+	if len(changes.Facts.Removed) != 0 {
+		t.Fatalf("facts removed = %v", changes.Facts.Removed)
+	}
+	wantModified := []SnapshotItemChange{{Before: "Project uses Go 1.24 toolchain", After: "Project uses Go 1.25 toolchain"}}
+	if !reflect.DeepEqual(changes.Facts.Modified, wantModified) {
+		t.Fatalf("facts modified = %v", changes.Facts.Modified)
+	}
+	if !changes.Preferences.Empty() {
+		t.Fatalf("preferences should be unchanged: %+v", changes.Preferences)
+	}
+	if !reflect.DeepEqual(changes.Tasks.Removed, []string{"Write release notes"}) {
+		t.Fatalf("tasks removed = %v", changes.Tasks.Removed)
+	}
+	if changes.SummaryChanged || changes.Empty() {
+		t.Fatalf("unexpected summary/empty state: %+v", changes)
+	}
+	if !SnapshotDiff(a, a).Empty() {
+		t.Fatal("diff of a snapshot with itself should be empty")
+	}
+}
+
+func TestSQLiteStore_GetSessionSnapshot(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state", "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+
+	for _, facts := range [][]string{{"first"}, {"second"}} {
+		if err := store.UpsertSessionSnapshot(ctx, SessionSnapshot{SessionKey: "cli:direct", Facts: facts}); err != nil {
+			t.Fatalf("upsert snapshot: %v", err)
+		}
+	}
+	snap, err := store.GetSessionSnapshot(ctx, "cli:direct", 1)
+	if err != nil {
+		t.Fatalf("get snapshot: %v", err)
+	}
+	if snap.Revision != 1 || !reflect.DeepEqual(snap.Facts, []string{"first"}) {
+		t.Fatalf("unexpected snapshot: %+v", snap)
+	}
+	if _, err := store.GetSessionSnapshot(ctx, "cli:direct", 7); err == nil {
+		t.Fatal("expected error for missing revision")
+	}
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// UserStats summarizes how much data the memory store holds for one user.
+type UserStats struct {
+	UserID            string                 `json:"user_id"`
+	Sessions          int                    `json:"sessions"`
+	Messages          int                    `json:"messages"`
+	Events            int                    `json:"events"`
+	MemoryItemsByKind map[MemoryItemKind]int `json:"memory_items_by_kind"`
+	PersonaRevisions  int                    `json:"persona_revisions"`
+}
+
+// MemoryItems returns the number of active memory items across all kinds.
+func (u UserStats) MemoryItems() int {
+	total := 0
+	for _, n := range u.MemoryItemsByKind {
+		total += n
+	}
+	return total
+}
+
+// ErrStatsUnsupported is returned when the configured store cannot report
+// per-user statistics.
+var ErrStatsUnsupported = errors.New("memory store does not support user statistics")
+
+// UserStats returns session, event, memory and persona counts for userID.
+func (s *Service) UserStats(ctx context.Context, userID string) (UserStats, error) {
+	store, ok := s.store.(*SQLiteStore)
+	if !ok {
+		return UserStats{}, ErrStatsUnsupported
+	}
+	return store.UserStats(ctx, userID, s.cfg.AgentID)
+}
+
+// UserStats counts the rows owned by userID. Messages counts active user and
+// assistant events; Events counts every event, archived ones included.
+func (s *SQLiteStore) UserStats(ctx context.Context, userID, agentID string) (UserStats, error) {
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return UserStats{}, fmt.Errorf("user id is required")
+	}
+	stats := UserStats{UserID: userID, MemoryItemsByKind: map[MemoryItemKind]int{}}
+
+	if err := s.db.QueryRowContext(ctx, `
+SELECT
+	(SELECT COUNT(*) FROM sessions WHERE user_id = ?),
+	COUNT(*),
+	COALESCE(SUM(CASE WHEN e.archived = 0 AND e.role IN ('user', 'assistant') THEN 1 ELSE 0 END), 0)
+FROM events e
+JOIN sessions s ON s.session_key = e.session_key
+WHERE s.user_id = ?`, userID, userID).Scan(&stats.Sessions, &stats.Events, &stats.Messages); err != nil {
+		return UserStats{}, fmt.Errorf("count user sessions: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+SELECT kind, COUNT(*)
+FROM memory_items
+WHERE user_id = ? AND agent_id = ? AND deleted_at_ms = 0 AND (expires_at_ms = 0 OR expires_at_ms > ?)
+GROUP BY kind`, userID, agentID, nowMS())
+	if err != nil {
+		return UserStats{}, fmt.Errorf("count user memory items: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			kind  string
+			count int
+		)
+		if err := rows.Scan(&kind, &count); err != nil {
+			return UserStats{}, fmt.Errorf("scan memory item count: %w", err)
+		}
+		stats.MemoryItemsByKind[MemoryItemKind(kind)] = count
+	}
+	if err := rows.Err(); err != nil {
+		return UserStats{}, fmt.Errorf("count user memory items: %w", err)
+	}
+
+	if err := s.db.QueryRowContext(ctx, `
+SELECT COUNT(*) FROM persona_revisions WHERE user_id = ? AND agent_id = ?`, userID, agentID).Scan(&stats.PersonaRevisions); err != nil {
+		return UserStats{}, fmt.Errorf("count persona revisions: %w", err)
+	}
+	return stats, nil
+}
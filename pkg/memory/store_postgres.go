@@ -0,0 +1,1784 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/health"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+//go:embed migrations_postgres/*.sql
+var postgresMigrationFiles embed.FS
+
+var loadedPostgresMigrations []Migration
+
+func init() {
+	migrations, err := loadMigrations(postgresMigrationFiles, "migrations_postgres")
+	if err != nil {
+		panic(fmt.Sprintf("load embedded postgres memory migrations: %v", err))
+	}
+	loadedPostgresMigrations = migrations
+}
+
+// postgresMigrationLockID is the advisory lock key that serializes schema
+// migrations when several agent instances start against the same database.
+const postgresMigrationLockID = 0x646f7461 // "dota"
+
+// PostgreSQLStore is a Store backed by PostgreSQL, for deployments where
+// several agent instances share one memory database. It mirrors SQLiteStore
+// table for table; SQLite-only maintenance features such as export, stats
+// and embedding reindexing are not available on it.
+type PostgreSQLStore struct {
+	db               postgresDB
+	unregisterHealth func()
+}
+
+// PostgreSQLStoreOptions tunes how NewPostgreSQLStoreWithOptions connects.
+type PostgreSQLStoreOptions struct {
+	// ReadOnly applies no migrations and runs every transaction read-only.
+	// Used by checks that must not change what they inspect.
+	ReadOnly bool
+}
+
+// NewPostgreSQLStore connects to the database at dsn and applies any pending
+// schema migrations.
+func NewPostgreSQLStore(dsn string) (*PostgreSQLStore, error) {
+	return NewPostgreSQLStoreWithOptions(dsn, PostgreSQLStoreOptions{})
+}
+
+// NewPostgreSQLStoreWithOptions connects to the database at dsn.
+func NewPostgreSQLStoreWithOptions(dsn string, opts PostgreSQLStoreOptions) (*PostgreSQLStore, error) {
+	if strings.TrimSpace(dsn) == "" {
+		return nil, fmt.Errorf("open postgres db: dsn is required")
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres db: %w", err)
+	}
+	db.SetMaxOpenConns(10)
+	db.SetConnMaxIdleTime(5 * time.Minute)
+	if opts.ReadOnly {
+		// One long-lived connection keeps the session setting below in force.
+		db.SetMaxOpenConns(1)
+		db.SetConnMaxIdleTime(0)
+	}
+
+	store := &PostgreSQLStore{db: postgresDB{db: db}}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("connect postgres db: %w", err)
+	}
+	if opts.ReadOnly {
+		if _, err := db.ExecContext(ctx, `SET SESSION CHARACTERISTICS AS TRANSACTION READ ONLY`); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("set postgres session read-only: %w", err)
+		}
+		store.unregisterHealth = health.Register("memory_db", store.Ping)
+		return store, nil
+	}
+	if _, err := store.Migrate(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	if _, err := store.db.ExecContext(ctx, `DELETE FROM retrieval_cache WHERE expires_at_ms <= ?`, nowMS()); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("purge retrieval cache: %w", err)
+	}
+	store.unregisterHealth = health.Register("memory_db", store.Ping)
+	return store, nil
+}
+
+func (s *PostgreSQLStore) Close() error {
+	if s == nil || s.db.db == nil {
+		return nil
+	}
+	if s.unregisterHealth != nil {
+		s.unregisterHealth()
+	}
+	return s.db.db.Close()
+}
+
+// Ping runs a trivial query to confirm a connection can be acquired and used.
+func (s *PostgreSQLStore) Ping(ctx context.Context) error {
+	if s == nil || s.db.db == nil {
+		return fmt.Errorf("memory db is not open")
+	}
+	var one int
+	return s.db.QueryRowContext(ctx, `SELECT 1`).Scan(&one)
+}
+
+// Migrate applies every pending embedded PostgreSQL migration in order. Each
+// runs in its own transaction under an advisory lock, so instances starting
+// together apply each migration exactly once. It returns the IDs applied.
+func (s *PostgreSQLStore) Migrate(ctx context.Context) ([]int, error) {
+	var ran []int
+	for _, m := range loadedPostgresMigrations {
+		applied, err := s.runMigration(ctx, m)
+		if err != nil {
+			return ran, err
+		}
+		if applied {
+			ran = append(ran, m.ID)
+		}
+	}
+	return ran, nil
+}
+
+// PendingMigrations returns the IDs of embedded migrations not yet applied,
+// without creating the schema_migrations table.
+func (s *PostgreSQLStore) PendingMigrations(ctx context.Context) ([]int, error) {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("query schema_migrations: %w", err)
+	}
+	if !exists {
+		return pendingMigrationIDs(loadedPostgresMigrations, nil), nil
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+	applied := map[int]int64{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan schema_migrations: %w", err)
+		}
+		applied[id] = 0
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return pendingMigrationIDs(loadedPostgresMigrations, applied), nil
+}
+
+func (s *PostgreSQLStore) runMigration(ctx context.Context, m Migration) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin migration %04d_%s: %w", m.ID, m.Name, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(?)`, postgresMigrationLockID); err != nil {
+		return false, fmt.Errorf("lock migration %04d_%s: %w", m.ID, m.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at_ms BIGINT NOT NULL
+)`); err != nil {
+		return false, fmt.Errorf("create schema_migrations table: %w", err)
+	}
+	var applied bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE id = ?)`, m.ID).Scan(&applied); err != nil {
+		return false, fmt.Errorf("query schema_migrations: %w", err)
+	}
+	if applied {
+		return false, nil
+	}
+	if _, err := tx.ExecContext(ctx, m.Up); err != nil {
+		return false, fmt.Errorf("migration %04d_%s (up) failed: %w", m.ID, m.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations(id, name, applied_at_ms) VALUES(?, ?, ?)`, m.ID, m.Name, nowMS()); err != nil {
+		return false, fmt.Errorf("record migration %04d_%s (up): %w", m.ID, m.Name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit migration %04d_%s (up): %w", m.ID, m.Name, err)
+	}
+	return true, nil
+}
+
+// postgresDB and postgresTx let PostgreSQLStore share the "?" placeholder
+// queries and write helpers of SQLiteStore by rewriting placeholders to
+// PostgreSQL's $n form before each statement runs.
+type postgresDB struct {
+	db *sql.DB
+}
+
+func (d postgresDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return d.db.ExecContext(ctx, rebindPostgres(query), args...)
+}
+
+func (d postgresDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return d.db.QueryContext(ctx, rebindPostgres(query), args...)
+}
+
+func (d postgresDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return d.db.QueryRowContext(ctx, rebindPostgres(query), args...)
+}
+
+func (d postgresDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (postgresTx, error) {
+	tx, err := d.db.BeginTx(ctx, opts)
+	return postgresTx{tx: tx}, err
+}
+
+type postgresTx struct {
+	tx *sql.Tx
+}
+
+func (t postgresTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.ExecContext(ctx, rebindPostgres(query), args...)
+}
+
+func (t postgresTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.tx.QueryContext(ctx, rebindPostgres(query), args...)
+}
+
+func (t postgresTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return t.tx.QueryRowContext(ctx, rebindPostgres(query), args...)
+}
+
+func (t postgresTx) Commit() error   { return t.tx.Commit() }
+func (t postgresTx) Rollback() error { return t.tx.Rollback() }
+
+// rebindPostgres replaces each "?" placeholder with $1, $2, ... Question
+// marks inside string literals, quoted identifiers and comments are kept.
+func rebindPostgres(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+	n := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			b.WriteString(query[i : i+end+2])
+			i += end + 1
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			b.WriteString(query[i : i+end])
+			i += end - 1
+		case c == '?':
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func (s *PostgreSQLStore) invalidateRetrievalCache(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM retrieval_cache`); err != nil {
+		return fmt.Errorf("invalidate retrieval cache: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) EnsureSession(ctx context.Context, sessionKey, channel, chatID, userID string) error {
+	now := nowMS()
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO sessions(session_key, channel, chat_id, user_id, created_at_ms, updated_at_ms, message_count, summary, last_consolidated_ms)
+VALUES(?, ?, ?, ?, ?, ?, 0, '', 0)
+ON CONFLICT(session_key) DO UPDATE SET
+	channel = CASE WHEN excluded.channel <> '' THEN excluded.channel ELSE sessions.channel END,
+	chat_id = CASE WHEN excluded.chat_id <> '' THEN excluded.chat_id ELSE sessions.chat_id END,
+	user_id = CASE WHEN sessions.user_id = '' THEN excluded.user_id ELSE sessions.user_id END,
+	updated_at_ms = excluded.updated_at_ms`,
+		sessionKey, channel, chatID, userID, now, now)
+	if err != nil {
+		return fmt.Errorf("ensure session: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) GetSession(ctx context.Context, sessionKey string) (Session, error) {
+	row := s.db.QueryRowContext(ctx, `
+SELECT session_key, channel, chat_id, user_id, created_at_ms, updated_at_ms, message_count, summary, last_consolidated_ms, history_version
+FROM sessions WHERE session_key = ?`, sessionKey)
+	var out Session
+	if err := row.Scan(&out.SessionKey, &out.Channel, &out.ChatID, &out.UserID, &out.CreatedAtMS, &out.UpdatedAtMS, &out.MessageCount, &out.Summary, &out.LastConsolidatedMS, &out.HistoryVersion); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Session{}, sql.ErrNoRows
+		}
+		return Session{}, fmt.Errorf("get session: %w", err)
+	}
+	return out, nil
+}
+
+func (s *PostgreSQLStore) ListSessions(ctx context.Context, userID string, limit int) ([]Session, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	query := `
+SELECT session_key, channel, chat_id, user_id, created_at_ms, updated_at_ms, message_count, summary, last_consolidated_ms, history_version
+FROM sessions`
+	args := []interface{}{}
+	if strings.TrimSpace(userID) != "" {
+		query += ` WHERE user_id = ?`
+		args = append(args, userID)
+	}
+	query += ` ORDER BY updated_at_ms DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]Session, 0, limit)
+	for rows.Next() {
+		var sess Session
+		if err := rows.Scan(&sess.SessionKey, &sess.Channel, &sess.ChatID, &sess.UserID, &sess.CreatedAtMS, &sess.UpdatedAtMS, &sess.MessageCount, &sess.Summary, &sess.LastConsolidatedMS, &sess.HistoryVersion); err != nil {
+			return nil, fmt.Errorf("scan session row: %w", err)
+		}
+		out = append(out, sess)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sessions: %w", err)
+	}
+	return out, nil
+}
+
+func (s *PostgreSQLStore) MarkSessionConsolidated(ctx context.Context, sessionKey string, atMS int64) error {
+	if atMS == 0 {
+		atMS = nowMS()
+	}
+	_, err := s.db.ExecContext(ctx, `
+UPDATE sessions
+SET last_consolidated_ms = ?, updated_at_ms = ?
+WHERE session_key = ?`, atMS, atMS, sessionKey)
+	if err != nil {
+		return fmt.Errorf("mark session consolidated: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) GetSessionSummary(ctx context.Context, sessionKey string) (string, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT summary FROM sessions WHERE session_key = ?`, sessionKey)
+	var summary string
+	if err := row.Scan(&summary); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("get session summary: %w", err)
+	}
+	return summary, nil
+}
+
+func (s *PostgreSQLStore) SetSessionSummary(ctx context.Context, sessionKey, summary string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE sessions SET summary = ?, updated_at_ms = ? WHERE session_key = ?`, summary, nowMS(), sessionKey)
+	if err != nil {
+		return fmt.Errorf("set session summary: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) GetSessionProviderState(ctx context.Context, sessionKey, provider string) (string, error) {
+	sessionKey = strings.TrimSpace(sessionKey)
+	provider = strings.TrimSpace(strings.ToLower(provider))
+	if sessionKey == "" || provider == "" {
+		return "", nil
+	}
+	row := s.db.QueryRowContext(ctx, `SELECT state_id FROM session_provider_states WHERE session_key = ? AND provider = ?`, sessionKey, provider)
+	var stateID string
+	if err := row.Scan(&stateID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("get session provider state: %w", err)
+	}
+	return stateID, nil
+}
+
+func (s *PostgreSQLStore) SetSessionProviderState(ctx context.Context, sessionKey, provider, stateID string) error {
+	sessionKey = strings.TrimSpace(sessionKey)
+	provider = strings.TrimSpace(strings.ToLower(provider))
+	if sessionKey == "" || provider == "" {
+		return fmt.Errorf("set session provider state: session key and provider are required")
+	}
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO session_provider_states(session_key, provider, state_id, updated_at_ms)
+VALUES(?, ?, ?, ?)
+ON CONFLICT(session_key, provider) DO UPDATE SET
+	state_id = excluded.state_id,
+	updated_at_ms = excluded.updated_at_ms`,
+		sessionKey, provider, strings.TrimSpace(stateID), nowMS(),
+	)
+	if err != nil {
+		return fmt.Errorf("set session provider state: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) GetSessionMetadata(ctx context.Context, sessionKey, key string) (string, error) {
+	sessionKey = strings.TrimSpace(sessionKey)
+	key = strings.TrimSpace(key)
+	if sessionKey == "" || key == "" {
+		return "", nil
+	}
+	row := s.db.QueryRowContext(ctx, `SELECT value FROM session_metadata WHERE session_key = ? AND key = ?`, sessionKey, key)
+	var value string
+	if err := row.Scan(&value); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("get session metadata: %w", err)
+	}
+	return value, nil
+}
+
+func (s *PostgreSQLStore) SetSessionMetadata(ctx context.Context, sessionKey, key, value string) error {
+	sessionKey = strings.TrimSpace(sessionKey)
+	key = strings.TrimSpace(key)
+	if sessionKey == "" || key == "" {
+		return fmt.Errorf("set session metadata: session key and key are required")
+	}
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO session_metadata(session_key, key, value, updated_at_ms)
+VALUES(?, ?, ?, ?)
+ON CONFLICT(session_key, key) DO UPDATE SET
+	value = excluded.value,
+	updated_at_ms = excluded.updated_at_ms`,
+		sessionKey, key, value, nowMS(),
+	)
+	if err != nil {
+		return fmt.Errorf("set session metadata: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) GetLatestSessionSnapshot(ctx context.Context, sessionKey string) (SessionSnapshot, error) {
+	row := s.db.QueryRowContext(ctx, `
+SELECT session_key, revision, created_at_ms, facts_json, preferences_json, tasks_json, open_loops_json, constraints_json, summary, compaction_id
+FROM session_snapshots
+WHERE session_key = ?
+ORDER BY revision DESC, created_at_ms DESC
+LIMIT 1`, sessionKey)
+	var snap SessionSnapshot
+	var factsRaw, prefRaw, tasksRaw, loopsRaw, constraintsRaw string
+	if err := row.Scan(&snap.SessionKey, &snap.Revision, &snap.CreatedAtMS, &factsRaw, &prefRaw, &tasksRaw, &loopsRaw, &constraintsRaw, &snap.Summary, &snap.CompactionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return SessionSnapshot{}, nil
+		}
+		return SessionSnapshot{}, fmt.Errorf("get latest session snapshot: %w", err)
+	}
+	snap.Facts = decodeStringSlice(factsRaw)
+	snap.Preferences = decodeStringSlice(prefRaw)
+	snap.Tasks = decodeStringSlice(tasksRaw)
+	snap.OpenLoops = decodeStringSlice(loopsRaw)
+	snap.Constraints = decodeStringSlice(constraintsRaw)
+	return snap, nil
+}
+
+func (s *PostgreSQLStore) UpsertSessionSnapshot(ctx context.Context, snap SessionSnapshot) error {
+	if strings.TrimSpace(snap.SessionKey) == "" {
+		return fmt.Errorf("upsert session snapshot: empty session key")
+	}
+	if snap.CreatedAtMS == 0 {
+		snap.CreatedAtMS = nowMS()
+	}
+	if snap.Revision <= 0 {
+		var next int
+		row := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(revision), 0) + 1 FROM session_snapshots WHERE session_key = ?`, snap.SessionKey)
+		if err := row.Scan(&next); err != nil {
+			return fmt.Errorf("upsert session snapshot: resolve revision: %w", err)
+		}
+		snap.Revision = next
+	}
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO session_snapshots(session_key, revision, created_at_ms, facts_json, preferences_json, tasks_json, open_loops_json, constraints_json, summary, compaction_id)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(session_key, revision) DO UPDATE SET
+	created_at_ms = excluded.created_at_ms,
+	facts_json = excluded.facts_json,
+	preferences_json = excluded.preferences_json,
+	tasks_json = excluded.tasks_json,
+	open_loops_json = excluded.open_loops_json,
+	constraints_json = excluded.constraints_json,
+	summary = excluded.summary,
+	compaction_id = excluded.compaction_id`,
+		snap.SessionKey,
+		snap.Revision,
+		snap.CreatedAtMS,
+		encodeStringSlice(snap.Facts),
+		encodeStringSlice(snap.Preferences),
+		encodeStringSlice(snap.Tasks),
+		encodeStringSlice(snap.OpenLoops),
+		encodeStringSlice(snap.Constraints),
+		snap.Summary,
+		snap.CompactionID,
+	)
+	if err != nil {
+		return fmt.Errorf("upsert session snapshot: %w", err)
+	}
+	return nil
+}
+
+// insertEventTx records ev and bumps its session's message count, creating
+// the session row when needed.
+func (s *PostgreSQLStore) insertEventTx(ctx context.Context, tx postgresTx, ev Event) error {
+	now := nowMS()
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO sessions(session_key, channel, chat_id, user_id, created_at_ms, updated_at_ms, message_count, summary, last_consolidated_ms)
+VALUES(?, '', '', '', ?, ?, 0, '', 0)
+ON CONFLICT(session_key) DO UPDATE SET updated_at_ms = excluded.updated_at_ms`, ev.SessionKey, now, now); err != nil {
+		return fmt.Errorf("ensure session: %w", err)
+	}
+	created := ev.CreatedAt.UnixMilli()
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO events(id, session_key, turn_id, seq, role, content, tool_call_id, tool_name, metadata_json, created_at_ms, archived)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, ev.ID, ev.SessionKey, ev.TurnID, ev.Seq, ev.Role, ev.Content, ev.ToolCallID, ev.ToolName, encodeMap(ev.Metadata), created, boolToInt(ev.Archived)); err != nil {
+		return fmt.Errorf("insert: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+UPDATE sessions
+SET updated_at_ms = ?, message_count = message_count + 1
+WHERE session_key = ?`, created, ev.SessionKey); err != nil {
+		return fmt.Errorf("update session: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) AppendEvent(ctx context.Context, ev Event) error {
+	if strings.TrimSpace(ev.SessionKey) == "" {
+		return fmt.Errorf("append event: empty session_key")
+	}
+	if strings.TrimSpace(ev.Role) == "" {
+		return fmt.Errorf("append event: empty role")
+	}
+	if ev.ID == "" {
+		ev.ID = uuid.NewString()
+	}
+	if ev.TurnID == "" {
+		ev.TurnID = "turn-" + uuid.NewString()
+	}
+	if ev.CreatedAt.IsZero() {
+		ev.CreatedAt = time.Now()
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("append event begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := s.insertEventTx(ctx, tx, ev); err != nil {
+		return fmt.Errorf("append event %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("append event commit: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) AppendUserEventAndMemories(ctx context.Context, ev Event, userID, agentID string, ops []ConsolidationOp, embeddings EmbeddingProvider) (int, error) {
+	if strings.TrimSpace(ev.SessionKey) == "" {
+		return 0, fmt.Errorf("append user event and memories: empty session_key")
+	}
+	if strings.TrimSpace(ev.Role) == "" {
+		ev.Role = "user"
+	}
+	if ev.ID == "" {
+		ev.ID = "evt-" + uuid.NewString()
+	}
+	if ev.TurnID == "" {
+		ev.TurnID = "turn-" + uuid.NewString()
+	}
+	if ev.CreatedAt.IsZero() {
+		ev.CreatedAt = time.Now()
+	}
+	if strings.TrimSpace(agentID) == "" {
+		agentID = "dotagent"
+	}
+	ev.Archived = false
+	vectors, err := embedOps(ctx, embeddings, ops)
+	if err != nil {
+		return 0, fmt.Errorf("append user event and memories: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("append user event and memories begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := s.insertEventTx(ctx, tx, ev); err != nil {
+		return 0, fmt.Errorf("append user event and memories %w", err)
+	}
+
+	inserted := 0
+	for i, op := range ops {
+		if strings.TrimSpace(op.Key) == "" {
+			continue
+		}
+		scopeType, scopeID := deriveScopeForOp(op.Kind, ev.SessionKey, userID, op.Metadata)
+		if strings.EqualFold(strings.TrimSpace(op.Action), "delete") {
+			args := []interface{}{nowMS(), userID, agentID, string(op.Kind), op.Key}
+			query := `
+UPDATE memory_items
+SET deleted_at_ms = ?
+WHERE user_id = ? AND agent_id = ? AND kind = ? AND item_key = ?`
+			if scopeType != "" {
+				query += ` AND scope_type = ?`
+				args = append(args, string(scopeType))
+			}
+			if strings.TrimSpace(scopeID) != "" {
+				query += ` AND scope_id = ?`
+				args = append(args, scopeID)
+			}
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return inserted, fmt.Errorf("append user event and memories delete memory: %w", err)
+			}
+			if err := insertAuditLogTx(ctx, tx, "memory_delete", "memory_item", op.Key, ev.SessionKey, userID, agentID, "user_forget_request", map[string]string{
+				"kind":      string(op.Kind),
+				"scope":     string(scopeType),
+				"scope_id":  scopeID,
+				"source_ev": ev.ID,
+			}); err != nil {
+				// A failed statement aborts the whole PostgreSQL transaction.
+				return inserted, err
+			}
+			continue
+		}
+		item, mapErr := buildMemoryItemFromOp(op, ev, userID, agentID, scopeType, scopeID)
+		if mapErr != nil {
+			return inserted, mapErr
+		}
+		itemID, upsertErr := upsertMemoryItemTx(ctx, tx, item)
+		if upsertErr != nil {
+			return inserted, fmt.Errorf("append user event and memories upsert memory: %w", upsertErr)
+		}
+		if embErr := upsertEmbeddingTx(ctx, tx, itemID, vectors[i].model, vectors[i].vector); embErr != nil {
+			return inserted, embErr
+		}
+		inserted++
+	}
+
+	if err := invalidateRetrievalCacheTx(ctx, tx); err != nil {
+		return inserted, err
+	}
+	if err := tx.Commit(); err != nil {
+		return inserted, fmt.Errorf("append user event and memories commit: %w", err)
+	}
+	return inserted, nil
+}
+
+// scanEvents reads event rows and returns them oldest first; the queries
+// select newest first so LIMIT keeps the most recent events.
+func scanEvents(rows *sql.Rows, limit int) ([]Event, error) {
+	out := make([]Event, 0, limit)
+	for rows.Next() {
+		var ev Event
+		var createdMS int64
+		var metaRaw string
+		var archived int
+		if err := rows.Scan(&ev.ID, &ev.SessionKey, &ev.TurnID, &ev.Seq, &ev.Role, &ev.Content, &ev.ToolCallID, &ev.ToolName, &metaRaw, &createdMS, &archived); err != nil {
+			return nil, err
+		}
+		ev.Metadata = decodeMap(metaRaw)
+		ev.CreatedAt = time.UnixMilli(createdMS)
+		ev.Archived = archived != 0
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+
+func (s *PostgreSQLStore) ListRecentEvents(ctx context.Context, sessionKey string, limit int, includeArchived bool) ([]Event, error) {
+	if limit <= 0 {
+		limit = 1
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, session_key, turn_id, seq, role, content, tool_call_id, tool_name, metadata_json, created_at_ms, archived
+FROM events
+WHERE session_key = ?
+AND (? = 1 OR archived = 0)
+ORDER BY created_at_ms DESC, seq DESC
+LIMIT ?`, sessionKey, boolToInt(includeArchived), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list recent events: %w", err)
+	}
+	defer rows.Close()
+
+	out, err := scanEvents(rows, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list recent events: %w", err)
+	}
+	return out, nil
+}
+
+func (s *PostgreSQLStore) ListEventsByTurn(ctx context.Context, sessionKey, turnID string, limit int) ([]Event, error) {
+	if strings.TrimSpace(sessionKey) == "" {
+		return nil, fmt.Errorf("list events by turn: empty session_key")
+	}
+	if strings.TrimSpace(turnID) == "" {
+		return nil, fmt.Errorf("list events by turn: empty turn_id")
+	}
+	if limit <= 0 {
+		limit = 64
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, session_key, turn_id, seq, role, content, tool_call_id, tool_name, metadata_json, created_at_ms, archived
+FROM events
+WHERE session_key = ?
+AND turn_id = ?
+AND archived = 0
+ORDER BY created_at_ms DESC, seq DESC
+LIMIT ?`, sessionKey, turnID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list events by turn: %w", err)
+	}
+	defer rows.Close()
+
+	out, err := scanEvents(rows, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list events by turn: %w", err)
+	}
+	return out, nil
+}
+
+func (s *PostgreSQLStore) ArchiveEventsBefore(ctx context.Context, sessionKey string, keepLatest int) (int, error) {
+	if keepLatest < 0 {
+		keepLatest = 0
+	}
+	res, err := s.db.ExecContext(ctx, `
+UPDATE events
+SET archived = 1
+WHERE session_key = ?
+AND archived = 0
+AND id NOT IN (
+	SELECT id FROM events
+	WHERE session_key = ? AND archived = 0
+	ORDER BY created_at_ms DESC, seq DESC
+	LIMIT ?
+)`, sessionKey, sessionKey, keepLatest)
+	if err != nil {
+		return 0, fmt.Errorf("archive events before: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+func (s *PostgreSQLStore) ArchiveEventsExceptTurns(ctx context.Context, sessionKey string, keepTurnIDs []string) (int, error) {
+	if strings.TrimSpace(sessionKey) == "" {
+		return 0, fmt.Errorf("archive events except turns: empty session_key")
+	}
+	query := `
+UPDATE events
+SET archived = 1
+WHERE session_key = ?
+AND archived = 0`
+	args := []interface{}{sessionKey}
+	if keep := uniqueStrings(keepTurnIDs); len(keep) > 0 {
+		query += fmt.Sprintf(` AND turn_id NOT IN (%s)`, strings.TrimRight(strings.Repeat("?,", len(keep)), ","))
+		for _, turnID := range keep {
+			args = append(args, turnID)
+		}
+	}
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("archive events except turns: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+func (s *PostgreSQLStore) StartCompaction(ctx context.Context, sessionKey string, sourceCount, retainedCount int, checkpoint map[string]string) (string, error) {
+	id := "cmp-" + uuid.NewString()
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO session_compactions(id, session_key, started_at_ms, completed_at_ms, status, source_event_count, retained_event_count, summary, checkpoint_json, error, owner)
+VALUES(?, ?, ?, 0, ?, ?, ?, '', ?, '', ?)`, id, sessionKey, nowMS(), JobRunning, sourceCount, retainedCount, encodeMap(checkpoint), compactionOwner)
+	if err != nil {
+		return "", fmt.Errorf("start compaction: %w", err)
+	}
+	return id, nil
+}
+
+func (s *PostgreSQLStore) CheckpointCompaction(ctx context.Context, compactionID string, checkpoint map[string]string) error {
+	_, err := s.db.ExecContext(ctx, `
+UPDATE session_compactions
+SET checkpoint_json = ?, status = ?, error = ''
+WHERE id = ?`, encodeMap(checkpoint), JobRunning, compactionID)
+	if err != nil {
+		return fmt.Errorf("checkpoint compaction: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) CompleteCompaction(ctx context.Context, compactionID, summary string) error {
+	_, err := s.db.ExecContext(ctx, `
+UPDATE session_compactions
+SET summary = ?, status = ?, completed_at_ms = ?, error = ''
+WHERE id = ?`, summary, JobCompleted, nowMS(), compactionID)
+	if err != nil {
+		return fmt.Errorf("complete compaction: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) FailCompaction(ctx context.Context, compactionID, errMsg string) error {
+	_, err := s.db.ExecContext(ctx, `
+UPDATE session_compactions
+SET status = ?, completed_at_ms = ?, error = ?
+WHERE id = ?`, JobFailed, nowMS(), errMsg, compactionID)
+	if err != nil {
+		return fmt.Errorf("fail compaction: %w", err)
+	}
+	return nil
+}
+
+const postgresMemoryItemColumns = `m.id, m.user_id, m.agent_id, m.scope_type, m.scope_id, m.session_key, m.kind, m.item_key, m.content, m.confidence, m.weight, m.source_event_id, m.first_seen_at_ms, m.last_seen_at_ms, m.expires_at_ms, m.deleted_at_ms, m.evergreen, m.metadata_json`
+
+func (s *PostgreSQLStore) UpsertMemoryItem(ctx context.Context, item MemoryItem) (MemoryItem, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return MemoryItem{}, fmt.Errorf("upsert memory item begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	id, err := upsertMemoryItemTx(ctx, tx, item)
+	if err != nil {
+		return MemoryItem{}, fmt.Errorf("upsert memory item: %w", err)
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT `+postgresMemoryItemColumns+` FROM memory_items m WHERE m.id = ?`, id)
+	if err != nil {
+		return MemoryItem{}, fmt.Errorf("read upserted memory item: %w", err)
+	}
+	items, err := scanMemoryItems(rows)
+	rows.Close()
+	if err != nil {
+		return MemoryItem{}, fmt.Errorf("read upserted memory item: %w", err)
+	}
+	if len(items) == 0 {
+		return MemoryItem{}, fmt.Errorf("read upserted memory item: %w", sql.ErrNoRows)
+	}
+	if err := invalidateRetrievalCacheTx(ctx, tx); err != nil {
+		return MemoryItem{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return MemoryItem{}, fmt.Errorf("upsert memory item commit: %w", err)
+	}
+	return items[0], nil
+}
+
+func (s *PostgreSQLStore) DeleteMemoryByKey(ctx context.Context, userID, agentID string, kind MemoryItemKind, key string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("delete memory by key begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `
+UPDATE memory_items
+SET deleted_at_ms = ?
+WHERE user_id = ? AND agent_id = ? AND kind = ? AND item_key = ?`, nowMS(), userID, agentID, string(kind), key); err != nil {
+		return fmt.Errorf("delete memory by key: %w", err)
+	}
+	if err := insertAuditLogTx(ctx, tx, "memory_delete", "memory_item", key, "", userID, agentID, "delete_by_key", map[string]string{
+		"kind": string(kind),
+	}); err != nil {
+		return err
+	}
+	if err := invalidateRetrievalCacheTx(ctx, tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("delete memory by key commit: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) ListMemoryCandidates(ctx context.Context, userID, agentID, sessionKey string, limit int) ([]MemoryItem, error) {
+	_ = sessionKey
+	if limit <= 0 {
+		limit = 20
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT `+postgresMemoryItemColumns+`
+FROM memory_items m
+WHERE m.agent_id = ?
+AND (m.user_id = ? OR (m.scope_type = 'global' AND m.user_id = ''))
+AND m.deleted_at_ms = 0
+AND (m.expires_at_ms = 0 OR m.expires_at_ms > ?)
+ORDER BY m.last_seen_at_ms DESC
+LIMIT ?`, agentID, userID, nowMS(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list memory candidates: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMemoryItems(rows)
+}
+
+// SearchMemoryFTS ranks memories with PostgreSQL full-text search. The query
+// uses the SQLite FTS form built by buildFTSQuery ("a" OR "b"), which
+// websearch_to_tsquery reads the same way.
+func (s *PostgreSQLStore) SearchMemoryFTS(ctx context.Context, userID, agentID, sessionKey, query string, limit int) ([]MemoryItem, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT `+postgresMemoryItemColumns+`
+FROM memory_items m, websearch_to_tsquery('simple', ?) q
+WHERE to_tsvector('simple', m.content) @@ q
+AND m.agent_id = ?
+AND (m.user_id = ? OR (m.scope_type = 'global' AND m.user_id = ''))
+AND m.deleted_at_ms = 0
+AND (m.expires_at_ms = 0 OR m.expires_at_ms > ?)
+ORDER BY ts_rank(to_tsvector('simple', m.content), q) DESC, m.last_seen_at_ms DESC
+LIMIT ?`, query, agentID, userID, nowMS(), limit)
+	if err != nil {
+		return s.searchMemoryLexicalFallback(ctx, userID, agentID, sessionKey, query, limit)
+	}
+	defer rows.Close()
+
+	return scanMemoryItems(rows)
+}
+
+func (s *PostgreSQLStore) searchMemoryLexicalFallback(ctx context.Context, userID, agentID, sessionKey, query string, limit int) ([]MemoryItem, error) {
+	candidates, err := s.ListMemoryCandidates(ctx, userID, agentID, sessionKey, maxInt(limit*4, 64))
+	if err != nil {
+		return nil, fmt.Errorf("search memory fallback: %w", err)
+	}
+	return rankLexicalMatches(candidates, query, limit), nil
+}
+
+func (s *PostgreSQLStore) UpsertMemoryLink(ctx context.Context, link MemoryLink) error {
+	if link.ID == "" {
+		link.ID = "lnk-" + uuid.NewString()
+	}
+	if link.CreatedAtMS == 0 {
+		link.CreatedAtMS = nowMS()
+	}
+	if link.Weight == 0 {
+		link.Weight = 1
+	}
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO memory_links(id, from_item_id, to_item_id, relation, weight, created_at_ms)
+VALUES(?, ?, ?, ?, ?, ?)
+ON CONFLICT(from_item_id, to_item_id, relation) DO UPDATE SET
+	weight = excluded.weight`,
+		link.ID, link.FromItemID, link.ToItemID, link.Relation, link.Weight, link.CreatedAtMS)
+	if err != nil {
+		return fmt.Errorf("upsert memory link: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) ListMemoryLinks(ctx context.Context, itemID string, limit int) ([]MemoryLink, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, from_item_id, to_item_id, relation, weight, created_at_ms
+FROM memory_links
+WHERE from_item_id = ?
+ORDER BY created_at_ms DESC
+LIMIT ?`, itemID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list memory links: %w", err)
+	}
+	defer rows.Close()
+
+	out := []MemoryLink{}
+	for rows.Next() {
+		var l MemoryLink
+		if err := rows.Scan(&l.ID, &l.FromItemID, &l.ToItemID, &l.Relation, &l.Weight, &l.CreatedAtMS); err != nil {
+			return nil, fmt.Errorf("scan memory link: %w", err)
+		}
+		out = append(out, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate memory links: %w", err)
+	}
+	return out, nil
+}
+
+func (s *PostgreSQLStore) ListMemoryObservations(ctx context.Context, itemID string, limit int) ([]MemoryObservation, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, item_id, session_key, event_id, observed_at_ms, confidence, content, extractor, action, metadata_json
+FROM memory_observations
+WHERE item_id = ?
+ORDER BY observed_at_ms DESC
+LIMIT ?`, itemID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list memory observations: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]MemoryObservation, 0, limit)
+	for rows.Next() {
+		var obs MemoryObservation
+		var rawMeta string
+		if err := rows.Scan(&obs.ID, &obs.ItemID, &obs.SessionKey, &obs.EventID, &obs.ObservedAt, &obs.Confidence, &obs.Content, &obs.Extractor, &obs.Action, &rawMeta); err != nil {
+			return nil, fmt.Errorf("scan memory observation: %w", err)
+		}
+		obs.Metadata = decodeMap(rawMeta)
+		out = append(out, obs)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate memory observations: %w", err)
+	}
+	return out, nil
+}
+
+func (s *PostgreSQLStore) UpsertEmbedding(ctx context.Context, itemID, model string, vector []float32) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO memory_embeddings(item_id, model, vector_json, norm, updated_at_ms)
+VALUES(?, ?, ?, ?, ?)
+ON CONFLICT(item_id) DO UPDATE SET
+	model = excluded.model,
+	vector_json = excluded.vector_json,
+	norm = excluded.norm,
+	updated_at_ms = excluded.updated_at_ms`, itemID, model, encodeVector(vector), vectorNorm(vector), nowMS())
+	if err != nil {
+		return fmt.Errorf("upsert embedding: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) GetEmbeddings(ctx context.Context, itemIDs []string) (map[string][]float32, error) {
+	records, err := s.GetEmbeddingRecords(ctx, itemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("get embeddings: %w", err)
+	}
+	out := make(map[string][]float32, len(records))
+	for id, rec := range records {
+		out[id] = rec.Vector
+	}
+	return out, nil
+}
+
+func (s *PostgreSQLStore) GetEmbeddingRecords(ctx context.Context, itemIDs []string) (map[string]EmbeddingRecord, error) {
+	if len(itemIDs) == 0 {
+		return map[string]EmbeddingRecord{}, nil
+	}
+	ids := uniqueStrings(itemIDs)
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	query := fmt.Sprintf(`SELECT item_id, model, vector_json FROM memory_embeddings WHERE item_id IN (%s)`, strings.TrimRight(strings.Repeat("?,", len(ids)), ","))
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get embedding records: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string]EmbeddingRecord, len(ids))
+	for rows.Next() {
+		var id, model, raw string
+		if err := rows.Scan(&id, &model, &raw); err != nil {
+			return nil, fmt.Errorf("scan embedding record: %w", err)
+		}
+		out[id] = EmbeddingRecord{Model: strings.TrimSpace(model), Vector: decodeVector(raw)}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate embedding records: %w", err)
+	}
+	return out, nil
+}
+
+func (s *PostgreSQLStore) GetEmbeddingCacheBatch(ctx context.Context, provider, model, providerKey string, contentHashes []string) (map[string][]float32, error) {
+	provider = strings.TrimSpace(provider)
+	model = strings.TrimSpace(model)
+	providerKey = strings.TrimSpace(providerKey)
+	if provider == "" || model == "" || providerKey == "" || len(contentHashes) == 0 {
+		return map[string][]float32{}, nil
+	}
+	hashes := uniqueStrings(contentHashes)
+	args := make([]interface{}, 0, 3+len(hashes))
+	args = append(args, provider, model, providerKey)
+	for _, hash := range hashes {
+		args = append(args, hash)
+	}
+	query := fmt.Sprintf(`
+SELECT content_hash, vector_json
+FROM memory_embedding_cache
+WHERE provider = ? AND model = ? AND provider_key = ?
+AND content_hash IN (%s)`, strings.TrimRight(strings.Repeat("?,", len(hashes)), ","))
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get embedding cache batch: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string][]float32, len(hashes))
+	for rows.Next() {
+		var hash, raw string
+		if err := rows.Scan(&hash, &raw); err != nil {
+			return nil, fmt.Errorf("scan embedding cache row: %w", err)
+		}
+		out[hash] = decodeVector(raw)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate embedding cache rows: %w", err)
+	}
+	return out, nil
+}
+
+func (s *PostgreSQLStore) PutEmbeddingCacheBatch(ctx context.Context, provider, model, providerKey string, vectors map[string][]float32) error {
+	provider = strings.TrimSpace(provider)
+	model = strings.TrimSpace(model)
+	providerKey = strings.TrimSpace(providerKey)
+	if provider == "" || model == "" || providerKey == "" || len(vectors) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("put embedding cache batch begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := nowMS()
+	for contentHash, vec := range vectors {
+		contentHash = strings.TrimSpace(contentHash)
+		if contentHash == "" || len(vec) == 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO memory_embedding_cache(provider, model, provider_key, content_hash, vector_json, norm, updated_at_ms)
+VALUES(?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(provider, model, provider_key, content_hash) DO UPDATE SET
+	vector_json = excluded.vector_json,
+	norm = excluded.norm,
+	updated_at_ms = excluded.updated_at_ms`,
+			provider, model, providerKey, contentHash, encodeVector(vec), vectorNorm(vec), now); err != nil {
+			return fmt.Errorf("put embedding cache row: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("put embedding cache batch commit: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) GetSessionIndexCursor(ctx context.Context, sessionKey string) (int64, error) {
+	return getSessionIndexCursor(ctx, s.db, sessionKey)
+}
+
+func (s *PostgreSQLStore) SetSessionIndexCursor(ctx context.Context, sessionKey string, lastIndexedMS int64) error {
+	return setSessionIndexCursor(ctx, s.db, sessionKey, lastIndexedMS)
+}
+
+func (s *PostgreSQLStore) ListSessionEmbeddingDeltas(ctx context.Context, sessionKey, agentID, model string, sinceMS int64, limit int) ([]MemoryItem, error) {
+	return listSessionEmbeddingDeltas(ctx, s.db, sessionKey, agentID, model, sinceMS, limit)
+}
+
+func (s *PostgreSQLStore) ListMemoryKeysByPrefix(ctx context.Context, agentID, prefix string) ([]string, error) {
+	return listMemoryKeysByPrefix(ctx, s.db, agentID, prefix)
+}
+
+func (s *PostgreSQLStore) GetRetrievalCache(ctx context.Context, key string, nowMS int64) (string, bool, error) {
+	row := s.db.QueryRowContext(ctx, `
+SELECT result_json, expires_at_ms FROM retrieval_cache WHERE cache_key = ?`, key)
+	var payload string
+	var expires int64
+	if err := row.Scan(&payload, &expires); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("get retrieval cache: %w", err)
+	}
+	if expires <= nowMS {
+		_, _ = s.db.ExecContext(ctx, `DELETE FROM retrieval_cache WHERE cache_key = ?`, key)
+		return "", false, nil
+	}
+	return payload, true, nil
+}
+
+func (s *PostgreSQLStore) PutRetrievalCache(ctx context.Context, key, value string, expiresAtMS int64) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO retrieval_cache(cache_key, result_json, created_at_ms, expires_at_ms)
+VALUES(?, ?, ?, ?)
+ON CONFLICT(cache_key) DO UPDATE SET
+	result_json = excluded.result_json,
+	created_at_ms = excluded.created_at_ms,
+	expires_at_ms = excluded.expires_at_ms`, key, value, nowMS(), expiresAtMS)
+	if err != nil {
+		return fmt.Errorf("put retrieval cache: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) EnqueueJob(ctx context.Context, job Job) error {
+	now := nowMS()
+	if job.ID == "" {
+		job.ID = "job-" + uuid.NewString()
+	}
+	if job.Status == "" {
+		job.Status = JobPending
+	}
+	if job.Priority == 0 {
+		job.Priority = 100
+	}
+	if job.RunAfterMS == 0 {
+		job.RunAfterMS = now
+	}
+	if job.CreatedAtMS == 0 {
+		job.CreatedAtMS = now
+	}
+	if job.UpdatedAtMS == 0 {
+		job.UpdatedAtMS = now
+	}
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO memory_jobs(id, job_type, session_key, status, priority, payload_json, error, run_after_ms, lease_until_ms, created_at_ms, updated_at_ms, completed_at_ms)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(id) DO UPDATE SET
+	status = excluded.status,
+	priority = excluded.priority,
+	payload_json = excluded.payload_json,
+	error = excluded.error,
+	run_after_ms = excluded.run_after_ms,
+	lease_until_ms = excluded.lease_until_ms,
+	updated_at_ms = excluded.updated_at_ms,
+	completed_at_ms = excluded.completed_at_ms
+WHERE memory_jobs.status <> ?`,
+		job.ID,
+		job.JobType,
+		job.SessionKey,
+		job.Status,
+		job.Priority,
+		encodeMap(job.Payload),
+		job.Error,
+		job.RunAfterMS,
+		job.LeaseUntilMS,
+		job.CreatedAtMS,
+		job.UpdatedAtMS,
+		job.CompletedAtMS,
+		JobCanceled,
+	)
+	if err != nil {
+		return fmt.Errorf("enqueue job: %w", err)
+	}
+	return nil
+}
+
+// ClaimNextJob leases the next runnable job. SKIP LOCKED lets workers in
+// other instances claim different jobs instead of queueing on the same row.
+func (s *PostgreSQLStore) ClaimNextJob(ctx context.Context, nowMS, leaseForMS int64) (Job, bool, error) {
+	if leaseForMS <= 0 {
+		leaseForMS = 60_000
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Job{}, false, fmt.Errorf("claim next job begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	row := tx.QueryRowContext(ctx, `
+SELECT id, job_type, session_key, status, priority, payload_json, error, run_after_ms, lease_until_ms, created_at_ms, updated_at_ms, completed_at_ms
+FROM memory_jobs
+WHERE run_after_ms <= ?
+AND (status = ? OR (status = ? AND lease_until_ms <= ?))
+ORDER BY priority ASC, created_at_ms ASC
+LIMIT 1
+FOR UPDATE SKIP LOCKED`, nowMS, JobPending, JobRunning, nowMS)
+
+	var job Job
+	var payloadRaw string
+	if err := row.Scan(&job.ID, &job.JobType, &job.SessionKey, &job.Status, &job.Priority, &payloadRaw, &job.Error, &job.RunAfterMS, &job.LeaseUntilMS, &job.CreatedAtMS, &job.UpdatedAtMS, &job.CompletedAtMS); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Job{}, false, nil
+		}
+		return Job{}, false, fmt.Errorf("claim next job select: %w", err)
+	}
+
+	leaseUntil := nowMS + leaseForMS
+	if _, err := tx.ExecContext(ctx, `
+UPDATE memory_jobs
+SET status = ?, lease_until_ms = ?, updated_at_ms = ?, error = ''
+WHERE id = ?`, JobRunning, leaseUntil, nowMS, job.ID); err != nil {
+		return Job{}, false, fmt.Errorf("claim next job update: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return Job{}, false, fmt.Errorf("claim next job commit: %w", err)
+	}
+
+	job.Status = JobRunning
+	job.LeaseUntilMS = leaseUntil
+	job.UpdatedAtMS = nowMS
+	job.Payload = decodeMap(payloadRaw)
+	return job, true, nil
+}
+
+func (s *PostgreSQLStore) CompleteJob(ctx context.Context, id string) error {
+	now := nowMS()
+	_, err := s.db.ExecContext(ctx, `
+UPDATE memory_jobs
+SET status = ?, completed_at_ms = ?, updated_at_ms = ?, lease_until_ms = 0
+WHERE id = ? AND status <> ?`, JobCompleted, now, now, id, JobCanceled)
+	if err != nil {
+		return fmt.Errorf("complete job: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) FailJob(ctx context.Context, id, errMsg string) error {
+	_, err := s.db.ExecContext(ctx, `
+UPDATE memory_jobs
+SET status = ?, error = ?, updated_at_ms = ?, lease_until_ms = 0
+WHERE id = ? AND status <> ?`, JobFailed, errMsg, nowMS(), id, JobCanceled)
+	if err != nil {
+		return fmt.Errorf("fail job: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) RequeueExpiredJobs(ctx context.Context, nowMS int64) error {
+	_, err := s.db.ExecContext(ctx, `
+UPDATE memory_jobs
+SET status = ?, updated_at_ms = ?, error = ''
+WHERE status = ? AND lease_until_ms > 0 AND lease_until_ms <= ?`, JobPending, nowMS, JobRunning, nowMS)
+	if err != nil {
+		return fmt.Errorf("requeue expired jobs: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) SweepRetention(ctx context.Context, nowMS, eventRetentionMS, auditRetentionMS int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sweep retention begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if eventRetentionMS > 0 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM events WHERE archived = 1 AND created_at_ms <= ?`, nowMS-eventRetentionMS); err != nil {
+			return fmt.Errorf("sweep retention events: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM memory_items WHERE deleted_at_ms > 0 AND deleted_at_ms <= ?`, nowMS); err != nil {
+		return fmt.Errorf("sweep retention deleted memory: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM memory_items WHERE expires_at_ms > 0 AND expires_at_ms <= ?`, nowMS); err != nil {
+		return fmt.Errorf("sweep retention expired memory: %w", err)
+	}
+	if auditRetentionMS > 0 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM memory_audit_log WHERE created_at_ms <= ?`, nowMS-auditRetentionMS); err != nil {
+			return fmt.Errorf("sweep retention audit log: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM retrieval_cache WHERE expires_at_ms <= ?`, nowMS); err != nil {
+		return fmt.Errorf("sweep retention retrieval cache: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sweep retention commit: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) AddMetric(ctx context.Context, metric string, value float64, labels map[string]string) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO memory_metrics(metric, value, labels_json, created_at_ms)
+VALUES(?, ?, ?, ?)`, metric, value, encodeMap(labels), nowMS())
+	if err != nil {
+		return fmt.Errorf("add metric: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) GetPersonaProfile(ctx context.Context, userID, agentID string) (PersonaProfile, error) {
+	row := s.db.QueryRowContext(ctx, `
+SELECT profile_json
+FROM persona_profiles
+WHERE user_id = ? AND agent_id = ?`, userID, agentID)
+	var raw string
+	if err := row.Scan(&raw); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return defaultPersonaProfile(userID, agentID), nil
+		}
+		return PersonaProfile{}, fmt.Errorf("get persona profile: %w", err)
+	}
+	return profileFromJSON(raw, userID, agentID), nil
+}
+
+const postgresUpsertPersonaProfileSQL = `
+INSERT INTO persona_profiles(user_id, agent_id, profile_json, revision, updated_at_ms)
+VALUES(?, ?, ?, ?, ?)
+ON CONFLICT(user_id, agent_id) DO UPDATE SET
+	profile_json = excluded.profile_json,
+	revision = excluded.revision,
+	updated_at_ms = excluded.updated_at_ms`
+
+func (s *PostgreSQLStore) UpsertPersonaProfile(ctx context.Context, profile PersonaProfile) error {
+	if strings.TrimSpace(profile.UserID) == "" || strings.TrimSpace(profile.AgentID) == "" {
+		return fmt.Errorf("upsert persona profile: missing user_id/agent_id")
+	}
+	if profile.Revision <= 0 {
+		profile.Revision = 1
+	}
+	if profile.UpdatedAtMS <= 0 {
+		profile.UpdatedAtMS = nowMS()
+	}
+	if _, err := s.db.ExecContext(ctx, postgresUpsertPersonaProfileSQL,
+		profile.UserID, profile.AgentID, profileToJSON(profile), profile.Revision, profile.UpdatedAtMS,
+	); err != nil {
+		return fmt.Errorf("upsert persona profile: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) InsertPersonaCandidates(ctx context.Context, candidates []PersonaUpdateCandidate) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("insert persona candidates begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, c := range candidates {
+		if c.ID == "" {
+			c.ID = "pcd-" + uuid.NewString()
+		}
+		if c.Status == "" {
+			c.Status = personaCandidatePending
+		}
+		if c.CreatedAtMS == 0 {
+			c.CreatedAtMS = nowMS()
+		}
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO persona_candidates(
+	id, user_id, agent_id, session_key, turn_id, source_event_id,
+	field_path, operation, value, confidence, evidence, source, status,
+	rejected_reason, applied_revision_id, created_at_ms, applied_at_ms
+)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(user_id, agent_id, session_key, turn_id, field_path, operation, value) DO UPDATE SET
+	confidence = GREATEST(excluded.confidence, persona_candidates.confidence),
+	evidence = CASE WHEN excluded.evidence <> '' THEN excluded.evidence ELSE persona_candidates.evidence END,
+	source_event_id = CASE WHEN excluded.source_event_id <> '' THEN excluded.source_event_id ELSE persona_candidates.source_event_id END,
+	source = CASE WHEN excluded.source <> '' THEN excluded.source ELSE persona_candidates.source END,
+	status = CASE WHEN persona_candidates.status = ? THEN persona_candidates.status ELSE excluded.status END`,
+			c.ID, c.UserID, c.AgentID, c.SessionKey, c.TurnID, c.SourceEventID,
+			c.FieldPath, c.Operation, c.Value, c.Confidence, c.Evidence, c.Source, c.Status,
+			c.RejectedReason, c.AppliedRevisionID, c.CreatedAtMS, c.AppliedAtMS,
+			personaCandidateApplied,
+		); err != nil {
+			return fmt.Errorf("insert persona candidate: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("insert persona candidates commit: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) ListPersonaCandidates(ctx context.Context, userID, agentID, sessionKey, turnID, status string, limit int) ([]PersonaUpdateCandidate, error) {
+	if limit <= 0 {
+		limit = 32
+	}
+	query := `
+SELECT id, user_id, agent_id, session_key, turn_id, source_event_id,
+	field_path, operation, value, confidence, evidence, source, status,
+	rejected_reason, applied_revision_id, created_at_ms, applied_at_ms
+FROM persona_candidates
+WHERE user_id = ? AND agent_id = ?`
+	args := []interface{}{userID, agentID}
+	for _, filter := range []struct{ column, value string }{
+		{"session_key", sessionKey},
+		{"turn_id", turnID},
+		{"status", status},
+	} {
+		if filter.value != "" {
+			query += ` AND ` + filter.column + ` = ?`
+			args = append(args, filter.value)
+		}
+	}
+	query += ` ORDER BY created_at_ms ASC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list persona candidates: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]PersonaUpdateCandidate, 0, limit)
+	for rows.Next() {
+		var c PersonaUpdateCandidate
+		if err := rows.Scan(
+			&c.ID, &c.UserID, &c.AgentID, &c.SessionKey, &c.TurnID, &c.SourceEventID,
+			&c.FieldPath, &c.Operation, &c.Value, &c.Confidence, &c.Evidence, &c.Source, &c.Status,
+			&c.RejectedReason, &c.AppliedRevisionID, &c.CreatedAtMS, &c.AppliedAtMS,
+		); err != nil {
+			return nil, fmt.Errorf("scan persona candidate: %w", err)
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate persona candidates: %w", err)
+	}
+	return out, nil
+}
+
+func (s *PostgreSQLStore) UpdatePersonaCandidateStatus(ctx context.Context, id, status, reason, revisionID string, appliedAtMS int64) error {
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("update persona candidate status: empty id")
+	}
+	if strings.TrimSpace(status) == "" {
+		status = personaCandidateRejected
+	}
+	if appliedAtMS < 0 {
+		appliedAtMS = 0
+	}
+	_, err := s.db.ExecContext(ctx, `
+UPDATE persona_candidates
+SET status = ?, rejected_reason = ?, applied_revision_id = ?, applied_at_ms = ?
+WHERE id = ?`, status, reason, revisionID, appliedAtMS, id)
+	if err != nil {
+		return fmt.Errorf("update persona candidate status: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) BumpPersonaSignal(ctx context.Context, userID, agentID, fieldPath, valueHash string, atMS int64) (int, error) {
+	if atMS == 0 {
+		atMS = nowMS()
+	}
+	var hits int
+	err := s.db.QueryRowContext(ctx, `
+INSERT INTO persona_signals(user_id, agent_id, field_path, value_hash, hits, last_seen_at_ms)
+VALUES(?, ?, ?, ?, 1, ?)
+ON CONFLICT(user_id, agent_id, field_path, value_hash) DO UPDATE SET
+	hits = persona_signals.hits + 1,
+	last_seen_at_ms = excluded.last_seen_at_ms
+RETURNING hits`, userID, agentID, fieldPath, valueHash, atMS).Scan(&hits)
+	if err != nil {
+		return 0, fmt.Errorf("bump persona signal: %w", err)
+	}
+	return hits, nil
+}
+
+const postgresInsertPersonaRevisionSQL = `
+INSERT INTO persona_revisions(
+	id, user_id, agent_id, session_key, turn_id, candidate_id, field_path, operation,
+	old_value, new_value, confidence, evidence, reason, source,
+	profile_before_json, profile_after_json, created_at_ms
+)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func personaRevisionArgs(rev PersonaRevision) []interface{} {
+	return []interface{}{
+		rev.ID, rev.UserID, rev.AgentID, rev.SessionKey, rev.TurnID, rev.CandidateID, rev.FieldPath, rev.Operation,
+		rev.OldValue, rev.NewValue, rev.Confidence, rev.Evidence, rev.Reason, rev.Source,
+		rev.ProfileBeforeJSON, rev.ProfileAfterJSON, rev.CreatedAtMS,
+	}
+}
+
+func (s *PostgreSQLStore) InsertPersonaRevision(ctx context.Context, rev PersonaRevision) error {
+	if rev.ID == "" {
+		rev.ID = "prv-" + uuid.NewString()
+	}
+	if rev.CreatedAtMS == 0 {
+		rev.CreatedAtMS = nowMS()
+	}
+	if _, err := s.db.ExecContext(ctx, postgresInsertPersonaRevisionSQL, personaRevisionArgs(rev)...); err != nil {
+		return fmt.Errorf("insert persona revision: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) ListPersonaRevisions(ctx context.Context, userID, agentID string, limit int) ([]PersonaRevision, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, user_id, agent_id, session_key, turn_id, candidate_id, field_path, operation,
+	old_value, new_value, confidence, evidence, reason, source,
+	profile_before_json, profile_after_json, created_at_ms
+FROM persona_revisions
+WHERE user_id = ? AND agent_id = ?
+ORDER BY created_at_ms DESC
+LIMIT ?`, userID, agentID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list persona revisions: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]PersonaRevision, 0, limit)
+	for rows.Next() {
+		var rev PersonaRevision
+		if err := rows.Scan(
+			&rev.ID, &rev.UserID, &rev.AgentID, &rev.SessionKey, &rev.TurnID, &rev.CandidateID, &rev.FieldPath, &rev.Operation,
+			&rev.OldValue, &rev.NewValue, &rev.Confidence, &rev.Evidence, &rev.Reason, &rev.Source,
+			&rev.ProfileBeforeJSON, &rev.ProfileAfterJSON, &rev.CreatedAtMS,
+		); err != nil {
+			return nil, fmt.Errorf("scan persona revision: %w", err)
+		}
+		out = append(out, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate persona revisions: %w", err)
+	}
+	return out, nil
+}
+
+// ApplyPersonaMutation atomically writes profile + revision + candidate status + memory links.
+func (s *PostgreSQLStore) ApplyPersonaMutation(ctx context.Context, profile PersonaProfile, candidate PersonaUpdateCandidate, revision PersonaRevision, memoryOps []ConsolidationOp) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("apply persona mutation begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if profile.Revision <= 0 {
+		profile.Revision = 1
+	}
+	if profile.UpdatedAtMS <= 0 {
+		profile.UpdatedAtMS = nowMS()
+	}
+	if _, err := tx.ExecContext(ctx, postgresUpsertPersonaProfileSQL,
+		profile.UserID, profile.AgentID, profileToJSON(profile), profile.Revision, profile.UpdatedAtMS,
+	); err != nil {
+		return fmt.Errorf("apply persona mutation profile: %w", err)
+	}
+
+	if revision.ID == "" {
+		revision.ID = "prv-" + uuid.NewString()
+	}
+	if revision.CreatedAtMS == 0 {
+		revision.CreatedAtMS = nowMS()
+	}
+	if _, err := tx.ExecContext(ctx, postgresInsertPersonaRevisionSQL, personaRevisionArgs(revision)...); err != nil {
+		return fmt.Errorf("apply persona mutation revision: %w", err)
+	}
+
+	if candidate.ID != "" {
+		if _, err := tx.ExecContext(ctx, `
+UPDATE persona_candidates
+SET status = ?, rejected_reason = '', applied_revision_id = ?, applied_at_ms = ?
+WHERE id = ?`, personaCandidateApplied, revision.ID, nowMS(), candidate.ID); err != nil {
+			return fmt.Errorf("apply persona mutation candidate status: %w", err)
+		}
+	}
+
+	if len(memoryOps) > 0 {
+		rootID, err := upsertMemoryItemTx(ctx, tx, MemoryItem{
+			ID:            "mem-" + uuid.NewString(),
+			UserID:        profile.UserID,
+			AgentID:       profile.AgentID,
+			ScopeType:     MemoryScopeUser,
+			ScopeID:       profile.UserID,
+			SessionKey:    candidate.SessionKey,
+			Kind:          MemoryProcedural,
+			Key:           "persona/profile",
+			Content:       fmt.Sprintf("Persona profile revision: %d", profile.Revision),
+			Confidence:    0.9,
+			Weight:        1.0,
+			SourceEventID: candidate.SourceEventID,
+			FirstSeenAtMS: nowMS(),
+			LastSeenAtMS:  nowMS(),
+			Metadata:      map[string]string{"source": "persona"},
+		})
+		if err != nil {
+			return fmt.Errorf("apply persona mutation root memory item: %w", err)
+		}
+
+		for _, op := range memoryOps {
+			if op.Action == "delete" {
+				if _, err := tx.ExecContext(ctx, `
+UPDATE memory_items
+SET deleted_at_ms = ?
+WHERE user_id = ? AND agent_id = ? AND kind = ?
+AND (item_key = ? OR item_key LIKE ?)`,
+					nowMS(), profile.UserID, profile.AgentID, string(op.Kind), op.Key, op.Key+"/%"); err != nil {
+					return fmt.Errorf("apply persona mutation delete memory: %w", err)
+				}
+				continue
+			}
+
+			memID, err := upsertMemoryItemTx(ctx, tx, MemoryItem{
+				ID:            "mem-" + uuid.NewString(),
+				UserID:        profile.UserID,
+				AgentID:       profile.AgentID,
+				ScopeType:     MemoryScopeUser,
+				ScopeID:       profile.UserID,
+				SessionKey:    candidate.SessionKey,
+				Kind:          op.Kind,
+				Key:           op.Key,
+				Content:       op.Content,
+				Confidence:    op.Confidence,
+				Weight:        1.0,
+				SourceEventID: candidate.SourceEventID,
+				FirstSeenAtMS: nowMS(),
+				LastSeenAtMS:  nowMS(),
+				Metadata:      op.Metadata,
+			})
+			if err != nil {
+				return fmt.Errorf("apply persona mutation memory item: %w", err)
+			}
+			if rootID != "" && rootID != memID {
+				if _, err := tx.ExecContext(ctx, `
+INSERT INTO memory_links(id, from_item_id, to_item_id, relation, weight, created_at_ms)
+VALUES(?, ?, ?, ?, ?, ?)
+ON CONFLICT(from_item_id, to_item_id, relation) DO UPDATE SET
+	weight = excluded.weight`,
+					"lnk-"+uuid.NewString(), rootID, memID, "persona_field", 1.0, nowMS(),
+				); err != nil {
+					return fmt.Errorf("apply persona mutation memory link: %w", err)
+				}
+			}
+		}
+	}
+	if err := invalidateRetrievalCacheTx(ctx, tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("apply persona mutation commit: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) RollbackPersonaToRevision(ctx context.Context, userID, agentID, revisionID string) (PersonaProfile, error) {
+	row := s.db.QueryRowContext(ctx, `
+SELECT profile_before_json
+FROM persona_revisions
+WHERE id = ? AND user_id = ? AND agent_id = ?`, revisionID, userID, agentID)
+	var beforeRaw string
+	if err := row.Scan(&beforeRaw); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PersonaProfile{}, fmt.Errorf("rollback persona: revision not found")
+		}
+		return PersonaProfile{}, fmt.Errorf("rollback persona: %w", err)
+	}
+	profile := profileFromJSON(beforeRaw, userID, agentID)
+	profile.Revision++
+	profile.UpdatedAtMS = nowMS()
+	if err := s.UpsertPersonaProfile(ctx, profile); err != nil {
+		return PersonaProfile{}, err
+	}
+	return profile, nil
+}
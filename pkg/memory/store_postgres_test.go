@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRebindPostgres(t *testing.T) {
+	cases := map[string]string{
+		`SELECT 1`: `SELECT 1`,
+		`SELECT * FROM t WHERE a = ? AND b IN (?,?)`:      `SELECT * FROM t WHERE a = $1 AND b IN ($2,$3)`,
+		`UPDATE t SET note = 'why?' WHERE id = ?`:         `UPDATE t SET note = 'why?' WHERE id = $1`,
+		`SELECT "odd?col" FROM t WHERE x = ?`:             `SELECT "odd?col" FROM t WHERE x = $1`,
+		"SELECT a -- is it?\nFROM t WHERE b = ?":          "SELECT a -- is it?\nFROM t WHERE b = $1",
+		`INSERT INTO t(a, b) VALUES(?, 'it''s ?') -- ok?`: `INSERT INTO t(a, b) VALUES($1, 'it''s ?') -- ok?`,
+	}
+	for in, want := range cases {
+		if got := rebindPostgres(in); got != want {
+			t.Errorf("rebindPostgres(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPostgresMigrationsEmbedded(t *testing.T) {
+	if len(loadedPostgresMigrations) == 0 {
+		t.Fatalf("expected embedded postgres migrations")
+	}
+	for i, m := range loadedPostgresMigrations {
+		if m.ID != i+1 {
+			t.Fatalf("expected postgres migration ids to be sequential, got %04d_%s at position %d", m.ID, m.Name, i)
+		}
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			t.Fatalf("expected up and down scripts for postgres migration %04d_%s", m.ID, m.Name)
+		}
+	}
+}
+
+// TestPostgreSQLStore_RoundTrip runs against a real server when
+// DOTAGENT_TEST_POSTGRES_DSN points at a disposable database.
+func TestPostgreSQLStore_RoundTrip(t *testing.T) {
+	dsn := os.Getenv("DOTAGENT_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("DOTAGENT_TEST_POSTGRES_DSN not set")
+	}
+	ctx := context.Background()
+	store, err := NewPostgreSQLStore(dsn)
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+
+	if ran, err := store.Migrate(ctx); err != nil || len(ran) != 0 {
+		t.Fatalf("expected no pending migrations after open, got %v (err=%v)", ran, err)
+	}
+
+	sessionKey := "pg-test:" + t.Name()
+	if err := store.EnsureSession(ctx, sessionKey, "cli", "chat", "user-1"); err != nil {
+		t.Fatalf("ensure session: %v", err)
+	}
+	for _, content := range []string{"first", "second"} {
+		if err := store.AppendEvent(ctx, Event{SessionKey: sessionKey, Role: "user", Content: content}); err != nil {
+			t.Fatalf("append event: %v", err)
+		}
+	}
+	events, err := store.ListRecentEvents(ctx, sessionKey, 10, false)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d (err=%v)", len(events), err)
+	}
+
+	item, err := store.UpsertMemoryItem(ctx, MemoryItem{
+		UserID:     "user-1",
+		AgentID:    "pg-test",
+		ScopeType:  MemoryScopeUser,
+		Kind:       MemorySemanticFact,
+		Key:        "favorite/editor",
+		Content:    "The user prefers the Helix editor",
+		Confidence: 0.9,
+	})
+	if err != nil {
+		t.Fatalf("upsert memory item: %v", err)
+	}
+	defer store.DeleteMemoryByKey(ctx, "user-1", "pg-test", item.Kind, item.Key)
+	found, err := store.SearchMemoryFTS(ctx, "user-1", "pg-test", sessionKey, `"helix" OR "vim"`, 5)
+	if err != nil || len(found) != 1 || found[0].ID != item.ID {
+		t.Fatalf("expected full-text search to find %s, got %+v (err=%v)", item.ID, found, err)
+	}
+
+	if err := store.EnqueueJob(ctx, Job{ID: "job-" + sessionKey, JobType: "test", SessionKey: sessionKey}); err != nil {
+		t.Fatalf("enqueue job: %v", err)
+	}
+	job, ok, err := store.ClaimNextJob(ctx, nowMS(), 60_000)
+	if err != nil || !ok || job.Status != JobRunning {
+		t.Fatalf("expected to claim a job, got %+v ok=%v err=%v", job, ok, err)
+	}
+	if err := store.CompleteJob(ctx, job.ID); err != nil {
+		t.Fatalf("complete job: %v", err)
+	}
+
+	if err := store.SetSessionIndexCursor(ctx, sessionKey, 42); err != nil {
+		t.Fatalf("set index cursor: %v", err)
+	}
+	if cursor, err := store.GetSessionIndexCursor(ctx, sessionKey); err != nil || cursor != 42 {
+		t.Fatalf("expected index cursor 42, got %d (err=%v)", cursor, err)
+	}
+	if err := store.SetSessionTags(ctx, sessionKey, []string{"editors"}); err != nil {
+		t.Fatalf("set session tags: %v", err)
+	}
+	if keys, err := store.ListSessionsByTag(ctx, "editors", 10); err != nil || len(keys) == 0 {
+		t.Fatalf("expected a session tagged editors, got %v (err=%v)", keys, err)
+	}
+	sess, err := store.GetSession(ctx, sessionKey)
+	if err != nil {
+		t.Fatalf("get session: %v", err)
+	}
+	if _, err := store.PruneSessions(ctx, []Session{sess}, true); err != nil {
+		t.Fatalf("prune session: %v", err)
+	}
+	if _, err := store.GetSession(ctx, sessionKey); err == nil {
+		t.Fatalf("expected pruned session to be gone")
+	}
+}
@@ -12,25 +12,52 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dotsetgreg/dotagent/pkg/health"
+	"github.com/dotsetgreg/dotagent/pkg/trace"
 	"github.com/google/uuid"
 	_ "modernc.org/sqlite"
 )
 
 // SQLiteStore is the canonical persistent memory storage.
 type SQLiteStore struct {
-	db         *sql.DB
-	ftsEnabled bool
+	db               *sql.DB
+	ftsEnabled       bool
+	unregisterHealth func()
 }
 
 type embeddingVectorizeFunc func(content string) (model string, vector []float32, err error)
 
-// NewSQLiteStore creates/opens the memory database at path.
+// SQLiteStoreOptions tunes how NewSQLiteStoreWithOptions opens the database.
+type SQLiteStoreOptions struct {
+	// SkipMigrations opens the database without applying pending schema
+	// migrations. Used by maintenance commands that inspect or roll back the
+	// schema.
+	SkipMigrations bool
+	// ReadOnly opens an existing database read-only and, like
+	// SkipMigrations, leaves the schema alone. Used by checks that must not
+	// change what they inspect.
+	ReadOnly bool
+}
+
+// NewSQLiteStore creates/opens the memory database at path and applies any
+// pending schema migrations.
 func NewSQLiteStore(path string) (*SQLiteStore, error) {
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+	return NewSQLiteStoreWithOptions(path, SQLiteStoreOptions{})
+}
+
+// NewSQLiteStoreWithOptions creates/opens the memory database at path.
+func NewSQLiteStoreWithOptions(path string, opts SQLiteStoreOptions) (*SQLiteStore, error) {
+	dsn := path
+	if opts.ReadOnly {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("open sqlite db: %w", err)
+		}
+		dsn = "file:" + path + "?mode=ro"
+	} else if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return nil, fmt.Errorf("create memory db dir: %w", err)
 	}
 
-	db, err := sql.Open("sqlite", path)
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite db: %w", err)
 	}
@@ -40,10 +67,17 @@ func NewSQLiteStore(path string) (*SQLiteStore, error) {
 	db.SetMaxIdleConns(1)
 
 	store := &SQLiteStore{db: db}
-	if err := store.init(); err != nil {
+	if err := store.initPragmas(opts.ReadOnly); err != nil {
 		_ = db.Close()
 		return nil, err
 	}
+	if !opts.SkipMigrations && !opts.ReadOnly {
+		if err := store.init(); err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
+	store.unregisterHealth = health.Register("memory_db", store.QuickCheck)
 	return store, nil
 }
 
@@ -51,10 +85,38 @@ func (s *SQLiteStore) Close() error {
 	if s == nil || s.db == nil {
 		return nil
 	}
+	if s.unregisterHealth != nil {
+		s.unregisterHealth()
+	}
 	return s.db.Close()
 }
 
-func (s *SQLiteStore) init() error {
+// QuickCheck runs SQLite's quick_check pragma and reports the first problem found.
+func (s *SQLiteStore) QuickCheck(ctx context.Context) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("memory db is not open")
+	}
+	var result string
+	if err := s.db.QueryRowContext(ctx, `PRAGMA quick_check(1);`).Scan(&result); err != nil {
+		return fmt.Errorf("quick_check: %w", err)
+	}
+	if !strings.EqualFold(strings.TrimSpace(result), "ok") {
+		return fmt.Errorf("quick_check: %s", result)
+	}
+	return nil
+}
+
+// Ping runs a trivial query to confirm the database connection can be
+// acquired and used.
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("memory db is not open")
+	}
+	var one int
+	return s.db.QueryRowContext(ctx, `SELECT 1`).Scan(&one)
+}
+
+func (s *SQLiteStore) initPragmas(readOnly bool) error {
 	journalModeStmt := `PRAGMA journal_mode=WAL;`
 	if raceDetectorEnabled() {
 		// WAL checkpoint paths in modernc/sqlite can crash under race
@@ -67,249 +129,29 @@ func (s *SQLiteStore) init() error {
 		`PRAGMA synchronous=NORMAL;`,
 		`PRAGMA temp_store=MEMORY;`,
 		`PRAGMA busy_timeout=5000;`,
-		`CREATE TABLE IF NOT EXISTS sessions (
-			session_key TEXT PRIMARY KEY,
-			channel TEXT NOT NULL DEFAULT '',
-			chat_id TEXT NOT NULL DEFAULT '',
-			user_id TEXT NOT NULL DEFAULT '',
-			created_at_ms INTEGER NOT NULL,
-			updated_at_ms INTEGER NOT NULL,
-			message_count INTEGER NOT NULL DEFAULT 0,
-			summary TEXT NOT NULL DEFAULT '',
-			last_consolidated_ms INTEGER NOT NULL DEFAULT 0
-		);`,
-		`CREATE TABLE IF NOT EXISTS session_provider_states (
-			session_key TEXT NOT NULL,
-			provider TEXT NOT NULL,
-			state_id TEXT NOT NULL DEFAULT '',
-			updated_at_ms INTEGER NOT NULL,
-			PRIMARY KEY(session_key, provider)
-		);`,
-		`CREATE TABLE IF NOT EXISTS events (
-			id TEXT PRIMARY KEY,
-			session_key TEXT NOT NULL,
-			turn_id TEXT NOT NULL,
-			seq INTEGER NOT NULL,
-			role TEXT NOT NULL,
-			content TEXT NOT NULL,
-			tool_call_id TEXT NOT NULL DEFAULT '',
-			tool_name TEXT NOT NULL DEFAULT '',
-			metadata_json TEXT NOT NULL DEFAULT '{}',
-			created_at_ms INTEGER NOT NULL,
-			archived INTEGER NOT NULL DEFAULT 0
-		);`,
-		`CREATE INDEX IF NOT EXISTS events_session_active_idx ON events(session_key, archived, created_at_ms DESC, seq DESC);`,
-		`CREATE INDEX IF NOT EXISTS events_session_turn_idx ON events(session_key, turn_id, seq);`,
-		`CREATE TABLE IF NOT EXISTS session_compactions (
-			id TEXT PRIMARY KEY,
-			session_key TEXT NOT NULL,
-			started_at_ms INTEGER NOT NULL,
-			completed_at_ms INTEGER NOT NULL DEFAULT 0,
-			status TEXT NOT NULL,
-			source_event_count INTEGER NOT NULL,
-			retained_event_count INTEGER NOT NULL,
-			summary TEXT NOT NULL DEFAULT '',
-			checkpoint_json TEXT NOT NULL DEFAULT '{}',
-			error TEXT NOT NULL DEFAULT ''
-		);`,
-		`CREATE INDEX IF NOT EXISTS compaction_session_idx ON session_compactions(session_key, started_at_ms DESC);`,
-		`CREATE TABLE IF NOT EXISTS session_snapshots (
-			session_key TEXT NOT NULL,
-			revision INTEGER NOT NULL,
-			created_at_ms INTEGER NOT NULL,
-			facts_json TEXT NOT NULL DEFAULT '[]',
-			preferences_json TEXT NOT NULL DEFAULT '[]',
-			tasks_json TEXT NOT NULL DEFAULT '[]',
-			open_loops_json TEXT NOT NULL DEFAULT '[]',
-			constraints_json TEXT NOT NULL DEFAULT '[]',
-			summary TEXT NOT NULL DEFAULT '',
-			compaction_id TEXT NOT NULL DEFAULT '',
-			PRIMARY KEY(session_key, revision)
-		);`,
-		`CREATE INDEX IF NOT EXISTS session_snapshots_latest_idx ON session_snapshots(session_key, revision DESC, created_at_ms DESC);`,
-		`CREATE TABLE IF NOT EXISTS memory_items (
-			id TEXT PRIMARY KEY,
-			user_id TEXT NOT NULL DEFAULT '',
-			agent_id TEXT NOT NULL DEFAULT '',
-			scope_type TEXT NOT NULL DEFAULT 'session',
-			scope_id TEXT NOT NULL DEFAULT '',
-			session_key TEXT NOT NULL DEFAULT '',
-			kind TEXT NOT NULL,
-			item_key TEXT NOT NULL,
-			content TEXT NOT NULL,
-			confidence REAL NOT NULL DEFAULT 0,
-			weight REAL NOT NULL DEFAULT 1,
-			source_event_id TEXT NOT NULL DEFAULT '',
-			first_seen_at_ms INTEGER NOT NULL,
-			last_seen_at_ms INTEGER NOT NULL,
-			expires_at_ms INTEGER NOT NULL DEFAULT 0,
-			deleted_at_ms INTEGER NOT NULL DEFAULT 0,
-			evergreen INTEGER NOT NULL DEFAULT 0,
-			metadata_json TEXT NOT NULL DEFAULT '{}'
-		);`,
-		`CREATE TABLE IF NOT EXISTS memory_observations (
-			id TEXT PRIMARY KEY,
-			item_id TEXT NOT NULL,
-			session_key TEXT NOT NULL DEFAULT '',
-			event_id TEXT NOT NULL DEFAULT '',
-			observed_at_ms INTEGER NOT NULL,
-			confidence REAL NOT NULL DEFAULT 0,
-			content TEXT NOT NULL DEFAULT '',
-			extractor TEXT NOT NULL DEFAULT '',
-			action TEXT NOT NULL DEFAULT 'upsert',
-			metadata_json TEXT NOT NULL DEFAULT '{}'
-		);`,
-		`CREATE INDEX IF NOT EXISTS memory_obs_item_idx ON memory_observations(item_id, observed_at_ms DESC);`,
-		`CREATE INDEX IF NOT EXISTS memory_obs_event_idx ON memory_observations(event_id, observed_at_ms DESC);`,
-		`CREATE TABLE IF NOT EXISTS memory_links (
-			id TEXT PRIMARY KEY,
-			from_item_id TEXT NOT NULL,
-			to_item_id TEXT NOT NULL,
-			relation TEXT NOT NULL,
-			weight REAL NOT NULL DEFAULT 1,
-			created_at_ms INTEGER NOT NULL
-		);`,
-		`CREATE UNIQUE INDEX IF NOT EXISTS memory_links_unique ON memory_links(from_item_id, to_item_id, relation);`,
-		`CREATE INDEX IF NOT EXISTS memory_links_from_idx ON memory_links(from_item_id, created_at_ms DESC);`,
-		`CREATE TABLE IF NOT EXISTS memory_embeddings (
-			item_id TEXT PRIMARY KEY,
-			model TEXT NOT NULL,
-			vector_json TEXT NOT NULL,
-			norm REAL NOT NULL DEFAULT 0,
-			updated_at_ms INTEGER NOT NULL
-		);`,
-		`CREATE TABLE IF NOT EXISTS memory_embedding_cache (
-			provider TEXT NOT NULL,
-			model TEXT NOT NULL,
-			provider_key TEXT NOT NULL,
-			content_hash TEXT NOT NULL,
-			vector_json TEXT NOT NULL,
-			norm REAL NOT NULL DEFAULT 0,
-			updated_at_ms INTEGER NOT NULL,
-			PRIMARY KEY(provider, model, provider_key, content_hash)
-		);`,
-		`CREATE INDEX IF NOT EXISTS memory_embedding_cache_updated_idx ON memory_embedding_cache(updated_at_ms DESC);`,
-		`CREATE TABLE IF NOT EXISTS session_index_state (
-			session_key TEXT PRIMARY KEY,
-			last_indexed_at_ms INTEGER NOT NULL DEFAULT 0,
-			updated_at_ms INTEGER NOT NULL DEFAULT 0
-		);`,
-		`CREATE INDEX IF NOT EXISTS session_index_state_updated_idx ON session_index_state(updated_at_ms DESC);`,
-		`CREATE TABLE IF NOT EXISTS retrieval_cache (
-			cache_key TEXT PRIMARY KEY,
-			result_json TEXT NOT NULL,
-			created_at_ms INTEGER NOT NULL,
-			expires_at_ms INTEGER NOT NULL
-		);`,
-		`CREATE INDEX IF NOT EXISTS retrieval_cache_exp_idx ON retrieval_cache(expires_at_ms);`,
-		`CREATE TABLE IF NOT EXISTS memory_jobs (
-			id TEXT PRIMARY KEY,
-			job_type TEXT NOT NULL,
-			session_key TEXT NOT NULL DEFAULT '',
-			status TEXT NOT NULL,
-			priority INTEGER NOT NULL DEFAULT 100,
-			payload_json TEXT NOT NULL DEFAULT '{}',
-			error TEXT NOT NULL DEFAULT '',
-			run_after_ms INTEGER NOT NULL,
-			lease_until_ms INTEGER NOT NULL DEFAULT 0,
-			created_at_ms INTEGER NOT NULL,
-			updated_at_ms INTEGER NOT NULL,
-			completed_at_ms INTEGER NOT NULL DEFAULT 0
-		);`,
-		`CREATE INDEX IF NOT EXISTS memory_jobs_claim_idx ON memory_jobs(status, run_after_ms, lease_until_ms, priority, created_at_ms);`,
-		`CREATE TABLE IF NOT EXISTS memory_metrics (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			metric TEXT NOT NULL,
-			value REAL NOT NULL,
-			labels_json TEXT NOT NULL DEFAULT '{}',
-			created_at_ms INTEGER NOT NULL
-		);`,
-		`CREATE INDEX IF NOT EXISTS memory_metrics_metric_idx ON memory_metrics(metric, created_at_ms DESC);`,
-		`CREATE TABLE IF NOT EXISTS memory_audit_log (
-			id TEXT PRIMARY KEY,
-			action TEXT NOT NULL,
-			entity TEXT NOT NULL,
-			entity_id TEXT NOT NULL DEFAULT '',
-			session_key TEXT NOT NULL DEFAULT '',
-			user_id TEXT NOT NULL DEFAULT '',
-			agent_id TEXT NOT NULL DEFAULT '',
-			reason TEXT NOT NULL DEFAULT '',
-			payload_json TEXT NOT NULL DEFAULT '{}',
-			created_at_ms INTEGER NOT NULL
-		);`,
-		`CREATE INDEX IF NOT EXISTS memory_audit_created_idx ON memory_audit_log(created_at_ms DESC);`,
-		`CREATE TABLE IF NOT EXISTS persona_profiles (
-			user_id TEXT NOT NULL,
-			agent_id TEXT NOT NULL,
-			profile_json TEXT NOT NULL,
-			revision INTEGER NOT NULL DEFAULT 1,
-			updated_at_ms INTEGER NOT NULL,
-			PRIMARY KEY(user_id, agent_id)
-		);`,
-		`CREATE TABLE IF NOT EXISTS persona_candidates (
-			id TEXT PRIMARY KEY,
-			user_id TEXT NOT NULL,
-			agent_id TEXT NOT NULL,
-			session_key TEXT NOT NULL DEFAULT '',
-			turn_id TEXT NOT NULL DEFAULT '',
-			source_event_id TEXT NOT NULL DEFAULT '',
-			field_path TEXT NOT NULL,
-			operation TEXT NOT NULL,
-			value TEXT NOT NULL DEFAULT '',
-			confidence REAL NOT NULL DEFAULT 0,
-			evidence TEXT NOT NULL DEFAULT '',
-			source TEXT NOT NULL DEFAULT '',
-			status TEXT NOT NULL,
-			rejected_reason TEXT NOT NULL DEFAULT '',
-			applied_revision_id TEXT NOT NULL DEFAULT '',
-			created_at_ms INTEGER NOT NULL,
-			applied_at_ms INTEGER NOT NULL DEFAULT 0
-		);`,
-		`CREATE INDEX IF NOT EXISTS persona_candidates_status_idx ON persona_candidates(user_id, agent_id, status, created_at_ms DESC);`,
-		`CREATE INDEX IF NOT EXISTS persona_candidates_turn_idx ON persona_candidates(user_id, agent_id, session_key, turn_id, status, created_at_ms DESC);`,
-		`DELETE FROM persona_candidates
-WHERE rowid NOT IN (
-	SELECT MAX(rowid)
-	FROM persona_candidates
-	GROUP BY user_id, agent_id, session_key, turn_id, field_path, operation, value
-);`,
-		`CREATE UNIQUE INDEX IF NOT EXISTS persona_candidates_unique_key ON persona_candidates(user_id, agent_id, session_key, turn_id, field_path, operation, value);`,
-		`CREATE TABLE IF NOT EXISTS persona_revisions (
-			id TEXT PRIMARY KEY,
-			user_id TEXT NOT NULL,
-			agent_id TEXT NOT NULL,
-			session_key TEXT NOT NULL DEFAULT '',
-			turn_id TEXT NOT NULL DEFAULT '',
-			candidate_id TEXT NOT NULL DEFAULT '',
-			field_path TEXT NOT NULL,
-			operation TEXT NOT NULL,
-			old_value TEXT NOT NULL DEFAULT '',
-			new_value TEXT NOT NULL DEFAULT '',
-			confidence REAL NOT NULL DEFAULT 0,
-			evidence TEXT NOT NULL DEFAULT '',
-			reason TEXT NOT NULL DEFAULT '',
-			source TEXT NOT NULL DEFAULT '',
-			profile_before_json TEXT NOT NULL DEFAULT '{}',
-			profile_after_json TEXT NOT NULL DEFAULT '{}',
-			created_at_ms INTEGER NOT NULL
-		);`,
-		`CREATE INDEX IF NOT EXISTS persona_revisions_profile_idx ON persona_revisions(user_id, agent_id, created_at_ms DESC);`,
-		`CREATE TABLE IF NOT EXISTS persona_signals (
-			user_id TEXT NOT NULL,
-			agent_id TEXT NOT NULL,
-			field_path TEXT NOT NULL,
-			value_hash TEXT NOT NULL,
-			hits INTEGER NOT NULL DEFAULT 0,
-			last_seen_at_ms INTEGER NOT NULL,
-			PRIMARY KEY(user_id, agent_id, field_path, value_hash)
-		);`,
 	}
-
+	if readOnly {
+		// Changing the journal mode writes to the database file.
+		stmts = stmts[1:]
+	}
 	for _, stmt := range stmts {
 		if _, err := s.db.Exec(stmt); err != nil {
-			return fmt.Errorf("init sqlite schema failed on %q: %w", trimSQL(stmt), err)
+			return fmt.Errorf("init sqlite pragmas failed on %q: %w", trimSQL(stmt), err)
 		}
 	}
+	return nil
+}
+
+func (s *SQLiteStore) init() error {
+	// Databases created before the scope columns existed need them before the
+	// baseline migration rebuilds the scope indexes.
+	if err := s.upgradeLegacyColumns(); err != nil {
+		return err
+	}
+	if _, err := s.Migrate(context.Background()); err != nil {
+		return err
+	}
+
 	if raceDetectorEnabled() {
 		// Skip FTS5 setup under race builds: modernc/sqlite FTS init is unstable
 		// with race instrumentation on darwin/arm64.
@@ -324,63 +166,46 @@ WHERE rowid NOT IN (
 		s.ftsEnabled = true
 	}
 
-	// Backward-compatible migrations for older databases.
-	if err := ensureColumnExists(s.db, "memory_items", "scope_type", "TEXT NOT NULL DEFAULT 'session'"); err != nil {
-		return err
-	}
-	if err := ensureColumnExists(s.db, "memory_items", "scope_id", "TEXT NOT NULL DEFAULT ''"); err != nil {
-		return err
-	}
-	if err := ensureColumnExists(s.db, "memory_items", "evergreen", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+	if err := s.migrateLegacyProviderStateTable(); err != nil {
 		return err
 	}
-	if _, err := s.db.Exec(`
-UPDATE memory_items
-SET scope_type = CASE
-	WHEN TRIM(scope_type) = '' AND TRIM(session_key) = '' THEN 'global'
-	WHEN TRIM(scope_type) = '' THEN 'session'
-	ELSE scope_type
-END,
-scope_id = CASE
-	WHEN TRIM(scope_id) = '' AND TRIM(scope_type) = 'session' THEN session_key
-	WHEN TRIM(scope_id) = '' AND TRIM(scope_type) = 'user' THEN user_id
-	ELSE scope_id
-END`); err != nil {
-		return fmt.Errorf("migrate memory scope columns: %w", err)
-	}
-	if _, err := s.db.Exec(`
-UPDATE memory_items
-SET scope_type = 'global'
-WHERE TRIM(scope_type) = 'session' AND TRIM(scope_id) = '' AND TRIM(session_key) = ''`); err != nil {
-		return fmt.Errorf("normalize global memory scope: %w", err)
-	}
-	if _, err := s.db.Exec(`DROP INDEX IF EXISTS memory_items_unique_active`); err != nil {
-		return fmt.Errorf("drop legacy memory unique index: %w", err)
-	}
-	if _, err := s.db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS memory_items_unique_active ON memory_items(user_id, agent_id, scope_type, scope_id, kind, item_key)`); err != nil {
-		return fmt.Errorf("create memory unique index: %w", err)
+
+	if _, err := s.db.Exec(`DELETE FROM retrieval_cache WHERE expires_at_ms <= ?`, time.Now().UnixMilli()); err != nil {
+		return fmt.Errorf("purge retrieval cache: %w", err)
 	}
-	if _, err := s.db.Exec(`DROP INDEX IF EXISTS memory_items_scope_idx`); err != nil {
-		return fmt.Errorf("drop legacy memory scope index: %w", err)
+
+	return nil
+}
+
+func (s *SQLiteStore) upgradeLegacyColumns() error {
+	exists, err := tableExists(s.db, "memory_items")
+	if err != nil {
+		return fmt.Errorf("check memory_items table: %w", err)
 	}
-	if _, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS memory_items_scope_idx ON memory_items(user_id, agent_id, scope_type, scope_id, deleted_at_ms, expires_at_ms, last_seen_at_ms DESC)`); err != nil {
-		return fmt.Errorf("create memory scope index: %w", err)
+	if !exists {
+		return nil
 	}
-	if _, err := s.db.Exec(`DROP INDEX IF EXISTS memory_items_legacy_scope_idx`); err != nil {
-		return fmt.Errorf("drop legacy memory scope compatibility index: %w", err)
+	if err := ensureColumnExists(s.db, "memory_items", "scope_type", "TEXT NOT NULL DEFAULT 'session'"); err != nil {
+		return err
 	}
-	if err := s.migrateLegacyProviderStateTable(); err != nil {
+	if err := ensureColumnExists(s.db, "memory_items", "scope_id", "TEXT NOT NULL DEFAULT ''"); err != nil {
 		return err
 	}
-
-	if _, err := s.db.Exec(`DELETE FROM retrieval_cache WHERE expires_at_ms <= ?`, time.Now().UnixMilli()); err != nil {
-		return fmt.Errorf("purge retrieval cache: %w", err)
+	if err := ensureColumnExists(s.db, "memory_items", "evergreen", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
 	}
-
 	return nil
 }
 
 func (s *SQLiteStore) initFTS() error {
+	eventsIndexed, err := tableExists(s.db, "memory_events_fts")
+	if err != nil {
+		return fmt.Errorf("check memory_events_fts table: %w", err)
+	}
+	var updatesIndexed int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = 'memory_events_au'`).Scan(&updatesIndexed); err != nil {
+		return fmt.Errorf("check memory_events_au trigger: %w", err)
+	}
 	stmts := []string{
 		`CREATE VIRTUAL TABLE IF NOT EXISTS memory_items_fts USING fts5(item_id UNINDEXED, content, tokenize='unicode61 remove_diacritics 2');`,
 		`DROP TRIGGER IF EXISTS memory_items_ai;`,
@@ -396,12 +221,40 @@ func (s *SQLiteStore) initFTS() error {
 		`CREATE TRIGGER IF NOT EXISTS memory_items_ad AFTER DELETE ON memory_items BEGIN
 			DELETE FROM memory_items_fts WHERE item_id = old.id;
 		END;`,
+		// Conversation search only covers user and assistant messages.
+		`CREATE VIRTUAL TABLE IF NOT EXISTS memory_events_fts USING fts5(event_id UNINDEXED, content, tokenize='unicode61 remove_diacritics 2');`,
+		`CREATE TRIGGER IF NOT EXISTS memory_events_ai AFTER INSERT ON events WHEN new.role IN ('user', 'assistant') BEGIN
+			INSERT INTO memory_events_fts(event_id, content) VALUES (new.id, new.content);
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS memory_events_ad AFTER DELETE ON events BEGIN
+			DELETE FROM memory_events_fts WHERE event_id = old.id;
+		END;`,
+		// Erased events leave the index rather than being reindexed.
+		`CREATE TRIGGER IF NOT EXISTS memory_events_au AFTER UPDATE OF content ON events BEGIN
+			DELETE FROM memory_events_fts WHERE event_id = old.id;
+			INSERT INTO memory_events_fts(event_id, content)
+			SELECT new.id, new.content WHERE new.role IN ('user', 'assistant') AND new.content <> '` + ErasedContent + `';
+		END;`,
 	}
 	for _, stmt := range stmts {
 		if _, err := s.db.Exec(stmt); err != nil {
 			return fmt.Errorf("init sqlite fts failed on %q: %w", trimSQL(stmt), err)
 		}
 	}
+	if !eventsIndexed {
+		// Index history written before the table existed.
+		if _, err := s.db.Exec(`
+INSERT INTO memory_events_fts(event_id, content)
+SELECT id, content FROM events WHERE role IN ('user', 'assistant')`); err != nil {
+			return fmt.Errorf("backfill memory_events_fts: %w", err)
+		}
+	} else if updatesIndexed == 0 {
+		// Drop what earlier versions left indexed for events erased since.
+		if _, err := s.db.Exec(`
+DELETE FROM memory_events_fts WHERE event_id IN (SELECT id FROM events WHERE content = '` + ErasedContent + `')`); err != nil {
+			return fmt.Errorf("clean memory_events_fts: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -410,6 +263,9 @@ func (s *SQLiteStore) dropFTSTriggers() error {
 		`DROP TRIGGER IF EXISTS memory_items_ai;`,
 		`DROP TRIGGER IF EXISTS memory_items_au;`,
 		`DROP TRIGGER IF EXISTS memory_items_ad;`,
+		`DROP TRIGGER IF EXISTS memory_events_ai;`,
+		`DROP TRIGGER IF EXISTS memory_events_ad;`,
+		`DROP TRIGGER IF EXISTS memory_events_au;`,
 	}
 	for _, stmt := range stmts {
 		if _, err := s.db.Exec(stmt); err != nil {
@@ -494,7 +350,21 @@ func tableExists(db *sql.DB, table string) (bool, error) {
 
 func nowMS() int64 { return time.Now().UnixMilli() }
 
-func invalidateRetrievalCacheTx(ctx context.Context, tx *sql.Tx) error {
+// sqlTx is the part of *sql.Tx used by the write helpers shared with
+// PostgreSQLStore, whose transactions rewrite placeholders before running.
+type sqlTx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// sqlQuerier is a sqlTx that can also return result sets, for read helpers
+// shared by SQLiteStore and PostgreSQLStore.
+type sqlQuerier interface {
+	sqlTx
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func invalidateRetrievalCacheTx(ctx context.Context, tx sqlTx) error {
 	if _, err := tx.ExecContext(ctx, `DELETE FROM retrieval_cache`); err != nil {
 		return fmt.Errorf("invalidate retrieval cache: %w", err)
 	}
@@ -555,13 +425,13 @@ func decodeStringSlice(raw string) []string {
 	return out
 }
 
-func insertAuditLogTx(ctx context.Context, tx *sql.Tx, action, entity, entityID, sessionKey, userID, agentID, reason string, payload map[string]string) error {
+func insertAuditLogTx(ctx context.Context, tx sqlTx, action, entity, entityID, sessionKey, userID, agentID, reason string, payload map[string]string) error {
 	if tx == nil {
 		return nil
 	}
 	_, err := tx.ExecContext(ctx, `
-INSERT INTO memory_audit_log(id, action, entity, entity_id, session_key, user_id, agent_id, reason, payload_json, created_at_ms)
-VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+INSERT INTO memory_audit_log(id, action, entity, entity_id, session_key, user_id, agent_id, reason, payload_json, trace_id, created_at_ms)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		"audit-"+uuid.NewString(),
 		action,
 		entity,
@@ -571,6 +441,7 @@ VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		agentID,
 		reason,
 		encodeMap(payload),
+		trace.FromContext(ctx),
 		nowMS(),
 	)
 	if err != nil {
@@ -581,8 +452,8 @@ VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 
 func (s *SQLiteStore) insertAuditLog(ctx context.Context, action, entity, entityID, sessionKey, userID, agentID, reason string, payload map[string]string) error {
 	_, err := s.db.ExecContext(ctx, `
-INSERT INTO memory_audit_log(id, action, entity, entity_id, session_key, user_id, agent_id, reason, payload_json, created_at_ms)
-VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+INSERT INTO memory_audit_log(id, action, entity, entity_id, session_key, user_id, agent_id, reason, payload_json, trace_id, created_at_ms)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		"audit-"+uuid.NewString(),
 		action,
 		entity,
@@ -592,6 +463,7 @@ VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		agentID,
 		reason,
 		encodeMap(payload),
+		trace.FromContext(ctx),
 		nowMS(),
 	)
 	if err != nil {
@@ -652,10 +524,10 @@ ON CONFLICT(session_key) DO UPDATE SET
 
 func (s *SQLiteStore) GetSession(ctx context.Context, sessionKey string) (Session, error) {
 	row := s.db.QueryRowContext(ctx, `
-SELECT session_key, channel, chat_id, user_id, created_at_ms, updated_at_ms, message_count, summary, last_consolidated_ms
+SELECT session_key, channel, chat_id, user_id, created_at_ms, updated_at_ms, message_count, summary, last_consolidated_ms, history_version
 FROM sessions WHERE session_key = ?`, sessionKey)
 	var out Session
-	if err := row.Scan(&out.SessionKey, &out.Channel, &out.ChatID, &out.UserID, &out.CreatedAtMS, &out.UpdatedAtMS, &out.MessageCount, &out.Summary, &out.LastConsolidatedMS); err != nil {
+	if err := row.Scan(&out.SessionKey, &out.Channel, &out.ChatID, &out.UserID, &out.CreatedAtMS, &out.UpdatedAtMS, &out.MessageCount, &out.Summary, &out.LastConsolidatedMS, &out.HistoryVersion); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return Session{}, sql.ErrNoRows
 		}
@@ -672,7 +544,7 @@ func (s *SQLiteStore) ListSessions(ctx context.Context, userID string, limit int
 		limit = 200
 	}
 	query := `
-SELECT session_key, channel, chat_id, user_id, created_at_ms, updated_at_ms, message_count, summary, last_consolidated_ms
+SELECT session_key, channel, chat_id, user_id, created_at_ms, updated_at_ms, message_count, summary, last_consolidated_ms, history_version
 FROM sessions`
 	args := []interface{}{}
 	if strings.TrimSpace(userID) != "" {
@@ -701,6 +573,7 @@ FROM sessions`
 			&sess.MessageCount,
 			&sess.Summary,
 			&sess.LastConsolidatedMS,
+			&sess.HistoryVersion,
 		); scanErr != nil {
 			return nil, fmt.Errorf("scan session row: %w", scanErr)
 		}
@@ -783,6 +656,43 @@ ON CONFLICT(session_key, provider) DO UPDATE SET
 	return nil
 }
 
+func (s *SQLiteStore) GetSessionMetadata(ctx context.Context, sessionKey, key string) (string, error) {
+	sessionKey = strings.TrimSpace(sessionKey)
+	key = strings.TrimSpace(key)
+	if sessionKey == "" || key == "" {
+		return "", nil
+	}
+	row := s.db.QueryRowContext(ctx, `SELECT value FROM session_metadata WHERE session_key = ? AND key = ?`, sessionKey, key)
+	var value string
+	if err := row.Scan(&value); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("get session metadata: %w", err)
+	}
+	return value, nil
+}
+
+func (s *SQLiteStore) SetSessionMetadata(ctx context.Context, sessionKey, key, value string) error {
+	sessionKey = strings.TrimSpace(sessionKey)
+	key = strings.TrimSpace(key)
+	if sessionKey == "" || key == "" {
+		return fmt.Errorf("set session metadata: session key and key are required")
+	}
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO session_metadata(session_key, key, value, updated_at_ms)
+VALUES(?, ?, ?, ?)
+ON CONFLICT(session_key, key) DO UPDATE SET
+	value = excluded.value,
+	updated_at_ms = excluded.updated_at_ms`,
+		sessionKey, key, value, nowMS(),
+	)
+	if err != nil {
+		return fmt.Errorf("set session metadata: %w", err)
+	}
+	return nil
+}
+
 func (s *SQLiteStore) GetLatestSessionSnapshot(ctx context.Context, sessionKey string) (SessionSnapshot, error) {
 	row := s.db.QueryRowContext(ctx, `
 SELECT session_key, revision, created_at_ms, facts_json, preferences_json, tasks_json, open_loops_json, constraints_json, summary, compaction_id
@@ -907,7 +817,7 @@ WHERE session_key = ?`, created, ev.SessionKey); err != nil {
 	return nil
 }
 
-func (s *SQLiteStore) AppendUserEventAndMemories(ctx context.Context, ev Event, userID, agentID string, ops []ConsolidationOp) (int, error) {
+func (s *SQLiteStore) AppendUserEventAndMemories(ctx context.Context, ev Event, userID, agentID string, ops []ConsolidationOp, embeddings EmbeddingProvider) (int, error) {
 	if strings.TrimSpace(ev.SessionKey) == "" {
 		return 0, fmt.Errorf("append user event and memories: empty session_key")
 	}
@@ -929,6 +839,10 @@ func (s *SQLiteStore) AppendUserEventAndMemories(ctx context.Context, ev Event,
 
 	meta := encodeMap(ev.Metadata)
 	created := ev.CreatedAt.UnixMilli()
+	vectors, err := embedOps(ctx, embeddings, ops)
+	if err != nil {
+		return 0, fmt.Errorf("append user event and memories: %w", err)
+	}
 
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -957,7 +871,7 @@ WHERE session_key = ?`, created, ev.SessionKey); err != nil {
 	}
 
 	inserted := 0
-	for _, op := range ops {
+	for i, op := range ops {
 		if strings.TrimSpace(op.Key) == "" {
 			continue
 		}
@@ -996,7 +910,7 @@ WHERE user_id = ? AND agent_id = ? AND kind = ? AND item_key = ?`
 			if upsertErr != nil {
 				return inserted, fmt.Errorf("append user event and memories upsert memory: %w", upsertErr)
 			}
-			if embErr := upsertEmbeddingTx(ctx, tx, itemID, currentEmbeddingModel(), embedText(item.Content)); embErr != nil {
+			if embErr := upsertEmbeddingTx(ctx, tx, itemID, vectors[i].model, vectors[i].vector); embErr != nil {
 				return inserted, embErr
 			}
 			inserted++
@@ -1167,8 +1081,8 @@ AND turn_id NOT IN (%s)`, placeholders)
 func (s *SQLiteStore) StartCompaction(ctx context.Context, sessionKey string, sourceCount, retainedCount int, checkpoint map[string]string) (string, error) {
 	id := "cmp-" + uuid.NewString()
 	_, err := s.db.ExecContext(ctx, `
-INSERT INTO session_compactions(id, session_key, started_at_ms, completed_at_ms, status, source_event_count, retained_event_count, summary, checkpoint_json, error)
-VALUES(?, ?, ?, 0, ?, ?, ?, '', ?, '')`, id, sessionKey, nowMS(), JobRunning, sourceCount, retainedCount, encodeMap(checkpoint))
+INSERT INTO session_compactions(id, session_key, started_at_ms, completed_at_ms, status, source_event_count, retained_event_count, summary, checkpoint_json, error, owner)
+VALUES(?, ?, ?, 0, ?, ?, ?, '', ?, '', ?)`, id, sessionKey, nowMS(), JobRunning, sourceCount, retainedCount, encodeMap(checkpoint), compactionOwner)
 	if err != nil {
 		return "", fmt.Errorf("start compaction: %w", err)
 	}
@@ -1343,9 +1257,15 @@ func (s *SQLiteStore) searchMemoryLexicalFallback(ctx context.Context, userID, a
 	if err != nil {
 		return nil, fmt.Errorf("search memory fallback: %w", err)
 	}
+	return rankLexicalMatches(candidates, query, limit), nil
+}
+
+// rankLexicalMatches orders candidates by how many query terms their content
+// contains, most recent first among equals, dropping items with no match.
+func rankLexicalMatches(candidates []MemoryItem, query string, limit int) []MemoryItem {
 	terms := lexicalSearchTerms(query)
 	if len(terms) == 0 {
-		return nil, nil
+		return nil
 	}
 
 	type scored struct {
@@ -1383,16 +1303,22 @@ func (s *SQLiteStore) searchMemoryLexicalFallback(ctx context.Context, userID, a
 			break
 		}
 	}
-	return out, nil
+	return out
 }
 
 func (s *SQLiteStore) ListMemoryKeysByPrefix(ctx context.Context, agentID, prefix string) ([]string, error) {
+	return listMemoryKeysByPrefix(ctx, s.db, agentID, prefix)
+}
+
+// listMemoryKeysByPrefix returns the keys of an agent's live memories that
+// start with prefix.
+func listMemoryKeysByPrefix(ctx context.Context, db sqlQuerier, agentID, prefix string) ([]string, error) {
 	agentID = strings.TrimSpace(agentID)
 	prefix = strings.TrimSpace(prefix)
 	if agentID == "" || prefix == "" {
 		return nil, nil
 	}
-	rows, err := s.db.QueryContext(ctx, `
+	rows, err := db.QueryContext(ctx, `
 SELECT item_key
 FROM memory_items
 WHERE agent_id = ?
@@ -1569,7 +1495,7 @@ ON CONFLICT(item_id) DO UPDATE SET
 	return nil
 }
 
-func upsertEmbeddingTx(ctx context.Context, tx *sql.Tx, itemID, model string, vector []float32) error {
+func upsertEmbeddingTx(ctx context.Context, tx sqlTx, itemID, model string, vector []float32) error {
 	if strings.TrimSpace(itemID) == "" {
 		return fmt.Errorf("upsert embedding tx: empty item_id")
 	}
@@ -1733,12 +1659,26 @@ ON CONFLICT(provider, model, provider_key, content_hash) DO UPDATE SET
 }
 
 func (s *SQLiteStore) GetSessionIndexCursor(ctx context.Context, sessionKey string) (int64, error) {
+	return getSessionIndexCursor(ctx, s.db, sessionKey)
+}
+
+func (s *SQLiteStore) SetSessionIndexCursor(ctx context.Context, sessionKey string, lastIndexedMS int64) error {
+	return setSessionIndexCursor(ctx, s.db, sessionKey, lastIndexedMS)
+}
+
+func (s *SQLiteStore) ListSessionEmbeddingDeltas(ctx context.Context, sessionKey, agentID, model string, sinceMS int64, limit int) ([]MemoryItem, error) {
+	return listSessionEmbeddingDeltas(ctx, s.db, sessionKey, agentID, model, sinceMS, limit)
+}
+
+// getSessionIndexCursor returns the last_seen_at_ms up to which a session's
+// memories have been embedded.
+func getSessionIndexCursor(ctx context.Context, db sqlTx, sessionKey string) (int64, error) {
 	sessionKey = strings.TrimSpace(sessionKey)
 	if sessionKey == "" {
 		return 0, nil
 	}
 	var cursor int64
-	if err := s.db.QueryRowContext(ctx, `SELECT last_indexed_at_ms FROM session_index_state WHERE session_key = ?`, sessionKey).Scan(&cursor); err != nil {
+	if err := db.QueryRowContext(ctx, `SELECT last_indexed_at_ms FROM session_index_state WHERE session_key = ?`, sessionKey).Scan(&cursor); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return 0, nil
 		}
@@ -1747,13 +1687,15 @@ func (s *SQLiteStore) GetSessionIndexCursor(ctx context.Context, sessionKey stri
 	return cursor, nil
 }
 
-func (s *SQLiteStore) SetSessionIndexCursor(ctx context.Context, sessionKey string, lastIndexedMS int64) error {
+// setSessionIndexCursor advances a session's embedding cursor; it never moves
+// backwards.
+func setSessionIndexCursor(ctx context.Context, db sqlTx, sessionKey string, lastIndexedMS int64) error {
 	sessionKey = strings.TrimSpace(sessionKey)
 	if sessionKey == "" {
 		return nil
 	}
 	now := nowMS()
-	_, err := s.db.ExecContext(ctx, `
+	_, err := db.ExecContext(ctx, `
 INSERT INTO session_index_state(session_key, last_indexed_at_ms, updated_at_ms)
 VALUES(?, ?, ?)
 ON CONFLICT(session_key) DO UPDATE SET
@@ -1769,7 +1711,9 @@ ON CONFLICT(session_key) DO UPDATE SET
 	return nil
 }
 
-func (s *SQLiteStore) ListSessionEmbeddingDeltas(ctx context.Context, sessionKey, agentID, model string, sinceMS int64, limit int) ([]MemoryItem, error) {
+// listSessionEmbeddingDeltas returns a session's live memories seen after
+// sinceMS whose embedding is missing, stale, or from another model.
+func listSessionEmbeddingDeltas(ctx context.Context, db sqlQuerier, sessionKey, agentID, model string, sinceMS int64, limit int) ([]MemoryItem, error) {
 	if limit <= 0 {
 		limit = 128
 	}
@@ -1779,7 +1723,7 @@ func (s *SQLiteStore) ListSessionEmbeddingDeltas(ctx context.Context, sessionKey
 		return nil, fmt.Errorf("list embedding deltas: agent_id is required")
 	}
 	now := nowMS()
-	rows, err := s.db.QueryContext(ctx, `
+	rows, err := db.QueryContext(ctx, `
 SELECT m.id, m.user_id, m.agent_id, m.scope_type, m.scope_id, m.session_key, m.kind, m.item_key, m.content, m.confidence, m.weight, m.source_event_id, m.first_seen_at_ms, m.last_seen_at_ms, m.expires_at_ms, m.deleted_at_ms, m.evergreen, m.metadata_json
 FROM memory_items m
 LEFT JOIN memory_embeddings e ON e.item_id = m.id
@@ -2056,7 +2000,8 @@ ON CONFLICT(id) DO UPDATE SET
 	run_after_ms = excluded.run_after_ms,
 	lease_until_ms = excluded.lease_until_ms,
 	updated_at_ms = excluded.updated_at_ms,
-	completed_at_ms = excluded.completed_at_ms`,
+	completed_at_ms = excluded.completed_at_ms
+WHERE memory_jobs.status <> ?`,
 		job.ID,
 		job.JobType,
 		job.SessionKey,
@@ -2069,6 +2014,7 @@ ON CONFLICT(id) DO UPDATE SET
 		job.CreatedAtMS,
 		job.UpdatedAtMS,
 		job.CompletedAtMS,
+		JobCanceled,
 	)
 	if err != nil {
 		return fmt.Errorf("enqueue job: %w", err)
@@ -2132,7 +2078,7 @@ func (s *SQLiteStore) CompleteJob(ctx context.Context, id string) error {
 	_, err := s.db.ExecContext(ctx, `
 UPDATE memory_jobs
 SET status = ?, completed_at_ms = ?, updated_at_ms = ?, lease_until_ms = 0
-WHERE id = ?`, JobCompleted, now, now, id)
+WHERE id = ? AND status <> ?`, JobCompleted, now, now, id, JobCanceled)
 	if err != nil {
 		return fmt.Errorf("complete job: %w", err)
 	}
@@ -2144,7 +2090,7 @@ func (s *SQLiteStore) FailJob(ctx context.Context, id, errMsg string) error {
 	_, err := s.db.ExecContext(ctx, `
 UPDATE memory_jobs
 SET status = ?, error = ?, updated_at_ms = ?, lease_until_ms = 0
-WHERE id = ?`, JobFailed, errMsg, now, id)
+WHERE id = ? AND status <> ?`, JobFailed, errMsg, now, id, JobCanceled)
 	if err != nil {
 		return fmt.Errorf("fail job: %w", err)
 	}
@@ -2688,7 +2634,7 @@ ON CONFLICT(from_item_id, to_item_id, relation) DO UPDATE SET
 	return nil
 }
 
-func upsertMemoryItemTx(ctx context.Context, tx *sql.Tx, item MemoryItem) (string, error) {
+func upsertMemoryItemTx(ctx context.Context, tx sqlTx, item MemoryItem) (string, error) {
 	if item.ID == "" {
 		item.ID = "mem-" + uuid.NewString()
 	}
@@ -2953,7 +2899,7 @@ func tokenSet(in string) map[string]struct{} {
 	return out
 }
 
-func insertMemoryObservationTx(ctx context.Context, tx *sql.Tx, itemID string, item MemoryItem, action string) error {
+func insertMemoryObservationTx(ctx context.Context, tx sqlTx, itemID string, item MemoryItem, action string) error {
 	content := strings.TrimSpace(item.Content)
 	if strings.TrimSpace(item.SourceEventID) == "" && content == "" {
 		return nil
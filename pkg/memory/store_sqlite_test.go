@@ -0,0 +1,534 @@
+package memory
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/health"
+	"github.com/dotsetgreg/dotagent/pkg/trace"
+)
+
+func TestSQLiteStore_QuickCheckRegistersHealthProbe(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state", "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+
+	if err := store.QuickCheck(context.Background()); err != nil {
+		t.Fatalf("quick check: %v", err)
+	}
+	results := health.RunChecks(context.Background())
+	if err, ok := results["memory_db"]; !ok || err != nil {
+		t.Fatalf("expected healthy memory_db probe, got %v (present=%v)", err, ok)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("close store: %v", err)
+	}
+	if _, ok := health.RunChecks(context.Background())["memory_db"]; ok {
+		t.Fatalf("expected memory_db probe to be unregistered after close")
+	}
+}
+
+func TestSQLiteStore_MigrationsApplyStatusAndRollback(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state", "memory.db")
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	statuses, err := store.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatalf("migration status: %v", err)
+	}
+	if len(statuses) == 0 {
+		t.Fatalf("expected embedded migrations")
+	}
+	for _, st := range statuses {
+		if !st.Applied {
+			t.Fatalf("expected migration %04d_%s to be applied on open", st.ID, st.Name)
+		}
+	}
+	if ran, err := store.Migrate(ctx); err != nil || len(ran) != 0 {
+		t.Fatalf("expected no pending migrations, got %v (err=%v)", ran, err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	maint, err := NewSQLiteStoreWithOptions(path, SQLiteStoreOptions{SkipMigrations: true})
+	if err != nil {
+		t.Fatalf("open maintenance store: %v", err)
+	}
+	defer maint.Close()
+	rolledBack, err := maint.RollbackMigration(ctx, 1)
+	if err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if len(rolledBack) != len(statuses) || rolledBack[len(rolledBack)-1] != 1 {
+		t.Fatalf("unexpected rollback order: %v", rolledBack)
+	}
+	exists, err := tableExists(maint.db, "sessions")
+	if err != nil || exists {
+		t.Fatalf("expected sessions table to be dropped (exists=%v err=%v)", exists, err)
+	}
+	if _, err := maint.RollbackMigration(ctx, 1); err == nil {
+		t.Fatalf("expected error rolling back an unapplied migration")
+	}
+	if ran, err := maint.Migrate(ctx); err != nil || len(ran) != len(statuses) {
+		t.Fatalf("expected migrations to re-apply, got %v (err=%v)", ran, err)
+	}
+}
+
+func TestSQLiteStore_ReadOnlyReportsPendingWithoutMigrating(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state", "memory.db")
+	raw, err := NewSQLiteStoreWithOptions(path, SQLiteStoreOptions{SkipMigrations: true})
+	if err != nil {
+		t.Fatalf("open raw store: %v", err)
+	}
+	_ = raw.Close()
+
+	ro, err := NewSQLiteStoreWithOptions(path, SQLiteStoreOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("open read-only store: %v", err)
+	}
+	pending, err := ro.PendingMigrations(ctx)
+	if err != nil || len(pending) != len(loadedMigrations) {
+		t.Fatalf("expected every migration pending, got %v (err=%v)", pending, err)
+	}
+	if exists, err := tableExists(ro.db, "schema_migrations"); err != nil || exists {
+		t.Fatalf("expected read-only open to leave schema_migrations uncreated (exists=%v err=%v)", exists, err)
+	}
+	if _, err := ro.db.Exec(`CREATE TABLE t (id INTEGER)`); err == nil {
+		t.Fatalf("expected writes to fail on a read-only store")
+	}
+	_ = ro.Close()
+
+	if _, err := NewSQLiteStoreWithOptions(filepath.Join(t.TempDir(), "missing.db"), SQLiteStoreOptions{ReadOnly: true}); err == nil {
+		t.Fatalf("expected read-only open of a missing database to fail")
+	}
+
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+	if pending, err := store.PendingMigrations(ctx); err != nil || len(pending) != 0 {
+		t.Fatalf("expected no pending migrations after open, got %v (err=%v)", pending, err)
+	}
+}
+
+func TestSQLiteStore_AdoptsPreMigrationDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "memory.db")
+	raw, err := NewSQLiteStoreWithOptions(path, SQLiteStoreOptions{SkipMigrations: true})
+	if err != nil {
+		t.Fatalf("open raw store: %v", err)
+	}
+	// Shape of memory_items before scope columns were introduced.
+	if _, err := raw.db.Exec(`CREATE TABLE memory_items (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL DEFAULT '',
+		agent_id TEXT NOT NULL DEFAULT '',
+		session_key TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL,
+		item_key TEXT NOT NULL,
+		content TEXT NOT NULL,
+		confidence REAL NOT NULL DEFAULT 0,
+		weight REAL NOT NULL DEFAULT 1,
+		source_event_id TEXT NOT NULL DEFAULT '',
+		first_seen_at_ms INTEGER NOT NULL,
+		last_seen_at_ms INTEGER NOT NULL,
+		expires_at_ms INTEGER NOT NULL DEFAULT 0,
+		deleted_at_ms INTEGER NOT NULL DEFAULT 0,
+		metadata_json TEXT NOT NULL DEFAULT '{}'
+	);`); err != nil {
+		t.Fatalf("create legacy table: %v", err)
+	}
+	if _, err := raw.db.Exec(`INSERT INTO memory_items(id, user_id, kind, item_key, content, first_seen_at_ms, last_seen_at_ms)
+VALUES('m1', 'u1', 'semantic_fact', 'k1', 'likes tea', 1, 1)`); err != nil {
+		t.Fatalf("insert legacy row: %v", err)
+	}
+	_ = raw.Close()
+
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("open legacy database: %v", err)
+	}
+	defer store.Close()
+	var scopeType string
+	if err := store.db.QueryRow(`SELECT scope_type FROM memory_items WHERE id = 'm1'`).Scan(&scopeType); err != nil {
+		t.Fatalf("query scope_type: %v", err)
+	}
+	if scopeType != "global" {
+		t.Fatalf("expected legacy row to be backfilled to global scope, got %q", scopeType)
+	}
+}
+
+func TestSQLiteStore_ListAuditByTrace(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state", "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := trace.WithID(context.Background(), "trace-1")
+	if _, err := store.UpsertMemoryItem(ctx, MemoryItem{
+		UserID:     "u1",
+		AgentID:    "dotagent",
+		SessionKey: "discord:trace",
+		Kind:       MemoryUserPreference,
+		Key:        "drink",
+		Content:    "prefers green tea",
+		Confidence: 0.9,
+	}); err != nil {
+		t.Fatalf("upsert memory item: %v", err)
+	}
+	if _, err := store.UpsertMemoryItem(context.Background(), MemoryItem{
+		UserID:     "u1",
+		AgentID:    "dotagent",
+		SessionKey: "discord:trace",
+		Kind:       MemoryUserPreference,
+		Key:        "food",
+		Content:    "likes ramen",
+		Confidence: 0.9,
+	}); err != nil {
+		t.Fatalf("upsert untraced memory item: %v", err)
+	}
+
+	entries, err := store.ListAuditByTrace(context.Background(), "trace-1", 0)
+	if err != nil {
+		t.Fatalf("list audit by trace: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != "memory_upsert" || entries[0].TraceID != "trace-1" {
+		t.Fatalf("unexpected audit entries: %#v", entries)
+	}
+	if _, err := store.ListAuditByTrace(context.Background(), " ", 0); err == nil {
+		t.Fatalf("expected error for blank trace id")
+	}
+}
+
+func TestService_CancelJobStopsRunningJob(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state", "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+	svc := &Service{store: store}
+
+	ctx := context.Background()
+	if err := store.EnqueueJob(ctx, Job{ID: "job-run", JobType: JobConsolidate, SessionKey: "discord:jobs"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	job, jobCtx, done, ok := svc.claimJob(ctx, 60_000)
+	if !ok || job.ID != "job-run" {
+		t.Fatalf("expected to claim job-run, got %+v ok=%v", job, ok)
+	}
+	if canceled, err := svc.CancelJob(ctx, job.ID); err != nil || !canceled {
+		t.Fatalf("cancel running job: canceled=%v err=%v", canceled, err)
+	}
+	if jobCtx.Err() == nil {
+		t.Fatalf("expected canceling a running job to stop its context")
+	}
+	done()
+	if len(svc.runningJobs) != 0 {
+		t.Fatalf("expected finished job to be unregistered, got %v", svc.runningJobs)
+	}
+}
+
+func TestSQLiteStore_ListAndCancelJobs(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state", "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	for i, id := range []string{"job-a", "job-b", "job-c"} {
+		if err := store.EnqueueJob(ctx, Job{ID: id, JobType: JobConsolidate, SessionKey: "discord:jobs", CreatedAtMS: int64(1000 + i)}); err != nil {
+			t.Fatalf("enqueue %s: %v", id, err)
+		}
+	}
+	if err := store.CompleteJob(ctx, "job-c"); err != nil {
+		t.Fatalf("complete job: %v", err)
+	}
+
+	jobs, err := store.ListJobs(ctx, JobPending, 0)
+	if err != nil {
+		t.Fatalf("list jobs: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].ID != "job-b" || jobs[1].ID != "job-a" {
+		t.Fatalf("expected pending jobs newest first, got %#v", jobs)
+	}
+
+	canceled, err := store.CancelJob(ctx, "job-a")
+	if err != nil || !canceled {
+		t.Fatalf("cancel job-a: canceled=%v err=%v", canceled, err)
+	}
+	if canceled, _ := store.CancelJob(ctx, "job-c"); canceled {
+		t.Fatalf("expected completed job not to be canceled")
+	}
+	if canceled, _ := store.CancelJob(ctx, "missing"); canceled {
+		t.Fatalf("expected unknown job not to be canceled")
+	}
+
+	// A worker finishing or retrying a canceled job must not revive it.
+	if err := store.CompleteJob(ctx, "job-a"); err != nil {
+		t.Fatalf("complete canceled job: %v", err)
+	}
+	if err := store.EnqueueJob(ctx, Job{ID: "job-a", JobType: JobConsolidate, SessionKey: "discord:jobs", Status: JobPending}); err != nil {
+		t.Fatalf("requeue canceled job: %v", err)
+	}
+	jobs, err = store.ListJobs(ctx, JobCanceled, 0)
+	if err != nil {
+		t.Fatalf("list canceled jobs: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != "job-a" {
+		t.Fatalf("expected job-a to stay canceled, got %#v", jobs)
+	}
+
+	job, ok, err := store.ClaimNextJob(ctx, nowMS(), 0)
+	if err != nil || !ok || job.ID != "job-b" {
+		t.Fatalf("expected to claim job-b, got %#v ok=%v err=%v", job, ok, err)
+	}
+}
+
+func TestSQLiteStore_MetricSummaries(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state", "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	since := nowMS()
+	for _, v := range []float64{1, 2, 4} {
+		if err := store.AddMetric(ctx, "memory.test.latency", v, nil); err != nil {
+			t.Fatalf("add metric: %v", err)
+		}
+	}
+	if err := store.AddMetric(ctx, "memory.test.count", 1, nil); err != nil {
+		t.Fatalf("add metric: %v", err)
+	}
+
+	summaries, err := store.MetricSummaries(ctx, since)
+	if err != nil {
+		t.Fatalf("metric summaries: %v", err)
+	}
+	if len(summaries) != 2 || summaries[1].Metric != "memory.test.latency" {
+		t.Fatalf("unexpected summaries: %#v", summaries)
+	}
+	latency := summaries[1]
+	if latency.Count != 3 || latency.Sum != 7 || latency.LastValue != 4 {
+		t.Fatalf("unexpected latency summary: %#v", latency)
+	}
+
+	summaries, err = store.MetricSummaries(ctx, nowMS()+60_000)
+	if err != nil || len(summaries) != 0 {
+		t.Fatalf("expected no summaries in the future window, got %#v err=%v", summaries, err)
+	}
+}
+
+func TestSQLiteStore_SearchEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "memory.db")
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := store.EnsureSession(ctx, "discord:infra", "discord", "chat-1", "u1"); err != nil {
+		t.Fatalf("ensure session: %v", err)
+	}
+	if err := store.EnsureSession(ctx, "telegram:other", "telegram", "chat-2", "u2"); err != nil {
+		t.Fatalf("ensure other session: %v", err)
+	}
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 12, 0, 0, 0, time.UTC) }
+	for _, ev := range []Event{
+		{SessionKey: "discord:infra", Role: "user", Content: "How do I prune old Docker images?", CreatedAt: day(1)},
+		{SessionKey: "discord:infra", Role: "assistant", Content: "Run docker image prune -a.", CreatedAt: day(1)},
+		{SessionKey: "discord:infra", Role: "tool", Content: "docker output", CreatedAt: day(1)},
+		{SessionKey: "discord:infra", Role: "user", Content: "Docker compose keeps restarting", CreatedAt: day(9), Archived: true},
+		{SessionKey: "telegram:other", Role: "user", Content: "docker for u2", CreatedAt: day(5)},
+	} {
+		if err := store.AppendEvent(ctx, ev); err != nil {
+			t.Fatalf("append event: %v", err)
+		}
+	}
+
+	matches, err := store.SearchEvents(ctx, "docker", EventSearchOptions{})
+	if err != nil {
+		t.Fatalf("search events: %v", err)
+	}
+	if len(matches) != 4 {
+		t.Fatalf("expected 4 user/assistant matches, got %+v", matches)
+	}
+	groups := GroupEventMatches(matches)
+	if len(groups) != 2 || len(groups[0].Matches)+len(groups[1].Matches) != 4 {
+		t.Fatalf("unexpected grouping: %+v", groups)
+	}
+
+	matches, err = store.SearchEvents(ctx, "docker prune", EventSearchOptions{UserID: "u1", ToMS: day(2).UnixMilli()})
+	if err != nil {
+		t.Fatalf("search events: %v", err)
+	}
+	if len(matches) != 2 || matches[0].SessionKey != "discord:infra" || !strings.Contains(matches[0].Snippet, "**prune**") {
+		t.Fatalf("unexpected filtered matches: %+v", matches)
+	}
+
+	matches, err = store.SearchEvents(ctx, `compose "restarting`, EventSearchOptions{UserID: "u1", FromMS: day(2).UnixMilli()})
+	if err != nil {
+		t.Fatalf("search with quote: %v", err)
+	}
+	if len(matches) != 1 || matches[0].CreatedAtMS != day(9).UnixMilli() {
+		t.Fatalf("expected archived match, got %+v", matches)
+	}
+	if _, err := store.SearchEvents(ctx, "  ", EventSearchOptions{}); err == nil {
+		t.Fatalf("expected error for blank query")
+	}
+
+	// Databases created before the index existed are backfilled on open.
+	if _, err := store.db.Exec(`DROP TABLE memory_events_fts`); err != nil {
+		t.Fatalf("drop fts table: %v", err)
+	}
+	store.Close()
+	store, err = NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("reopen store: %v", err)
+	}
+	defer store.Close()
+	matches, err = store.SearchEvents(ctx, "docker", EventSearchOptions{UserID: "u2"})
+	if err != nil || len(matches) != 1 || matches[0].SessionKey != "telegram:other" {
+		t.Fatalf("expected backfilled match, got %+v (%v)", matches, err)
+	}
+}
+
+func TestSQLiteStore_UserStats(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state", "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	if err := store.EnsureSession(ctx, "discord:stats", "discord", "chat-1", "u1"); err != nil {
+		t.Fatalf("ensure session: %v", err)
+	}
+	if err := store.EnsureSession(ctx, "discord:other", "discord", "chat-2", "u2"); err != nil {
+		t.Fatalf("ensure other session: %v", err)
+	}
+	for _, ev := range []Event{
+		{SessionKey: "discord:stats", Role: "user", Content: "hi"},
+		{SessionKey: "discord:stats", Role: "assistant", Content: "hello"},
+		{SessionKey: "discord:stats", Role: "tool", Content: "{}"},
+		{SessionKey: "discord:stats", Role: "user", Content: "old", Archived: true},
+		{SessionKey: "discord:other", Role: "user", Content: "not mine"},
+	} {
+		if err := store.AppendEvent(ctx, ev); err != nil {
+			t.Fatalf("append event: %v", err)
+		}
+	}
+	for _, item := range []MemoryItem{
+		{UserID: "u1", AgentID: "dotagent", Kind: MemoryUserPreference, Key: "drink", Content: "green tea"},
+		{UserID: "u1", AgentID: "dotagent", Kind: MemoryUserPreference, Key: "food", Content: "ramen"},
+		{UserID: "u1", AgentID: "dotagent", Kind: MemorySemanticFact, Key: "city", Content: "lives in Oslo"},
+		{UserID: "u2", AgentID: "dotagent", Kind: MemorySemanticFact, Key: "city", Content: "lives in Rome"},
+	} {
+		item.Confidence = 0.9
+		if _, err := store.UpsertMemoryItem(ctx, item); err != nil {
+			t.Fatalf("upsert memory item: %v", err)
+		}
+	}
+	if err := store.InsertPersonaRevision(ctx, PersonaRevision{UserID: "u1", AgentID: "dotagent", FieldPath: "user.name", Operation: "set", NewValue: "Ada"}); err != nil {
+		t.Fatalf("insert persona revision: %v", err)
+	}
+
+	stats, err := store.UserStats(ctx, "u1", "dotagent")
+	if err != nil {
+		t.Fatalf("user stats: %v", err)
+	}
+	if stats.Sessions != 1 || stats.Events != 4 || stats.Messages != 2 || stats.PersonaRevisions != 1 {
+		t.Fatalf("unexpected counts: %+v", stats)
+	}
+	if stats.MemoryItemsByKind[MemoryUserPreference] != 2 || stats.MemoryItemsByKind[MemorySemanticFact] != 1 || stats.MemoryItems() != 3 {
+		t.Fatalf("unexpected memory counts: %+v", stats.MemoryItemsByKind)
+	}
+	if _, err := store.UserStats(ctx, "", "dotagent"); err == nil {
+		t.Fatalf("expected error for blank user id")
+	}
+}
+
+func TestSQLiteStore_GetObservationTimeline(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state", "memory.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC).UnixMilli()
+	upserts := []struct {
+		event      string
+		content    string
+		confidence float64
+		at         int64
+	}{
+		{"evt-1", "lives in Berlin", 0.6, base},
+		{"evt-1", "lives in Berlin", 0.7, base + 1000},
+		{"evt-2", "lives in Hamburg", 0.9, base + 60_000},
+	}
+	var itemID string
+	for _, u := range upserts {
+		item, err := store.UpsertMemoryItem(ctx, MemoryItem{
+			UserID:        "u1",
+			AgentID:       "dotagent",
+			SessionKey:    "discord:home",
+			Kind:          MemorySemanticFact,
+			Key:           "home_city",
+			Content:       u.content,
+			Confidence:    u.confidence,
+			SourceEventID: u.event,
+			FirstSeenAtMS: base,
+			LastSeenAtMS:  u.at,
+		})
+		if err != nil {
+			t.Fatalf("upsert memory item: %v", err)
+		}
+		itemID = item.ID
+	}
+
+	items, err := store.FindMemoryItemsByKey(ctx, "home_city")
+	if err != nil {
+		t.Fatalf("find by key: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != itemID {
+		t.Fatalf("unexpected items: %#v", items)
+	}
+
+	obs, err := store.GetObservationTimeline(ctx, itemID, 0)
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	if len(obs) != 3 || obs[0].ObservedAt != base || obs[0].Action != "insert" || obs[2].Content != "lives in Hamburg" {
+		t.Fatalf("expected chronological observations, got %#v", obs)
+	}
+
+	clusters := BuildObservationTimeline(obs)
+	if len(clusters) != 2 || clusters[0].EventID != "evt-1" || len(clusters[0].Steps) != 2 {
+		t.Fatalf("expected observations clustered by event, got %#v", clusters)
+	}
+	second := clusters[0].Steps[1]
+	if second.ContentChanged || second.ConfidenceDelta < 0.09 || second.ConfidenceDelta > 0.11 {
+		t.Fatalf("unexpected second step: %#v", second)
+	}
+	if !clusters[1].Steps[0].ContentChanged {
+		t.Fatalf("expected content change on evt-2")
+	}
+
+	if limited, err := store.GetObservationTimeline(ctx, itemID, 1); err != nil || len(limited) != 1 || limited[0].Content != "lives in Berlin" {
+		t.Fatalf("expected limit to keep the first observation, got %#v (%v)", limited, err)
+	}
+}
@@ -0,0 +1,360 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxSessionTags is the most topic tags a session carries.
+	MaxSessionTags = 3
+
+	// sessionTagsMessageCountKey is the session_metadata key holding the
+	// session's message count when its tags were last extracted.
+	sessionTagsMessageCountKey = "topic_tags_message_count"
+
+	sessionTagMinMessages     = 4
+	sessionTagRefreshMessages = 20
+	sessionTagEvents          = 60
+	sessionTagTranscriptChars = 6000
+	sessionTagMaxLength       = 32
+	sessionTagMinKeywordCount = 2
+)
+
+// TopicClassifyFunc asks a model for up to MaxSessionTags short topic tags
+// describing a conversation transcript.
+type TopicClassifyFunc func(ctx context.Context, transcript string) ([]string, error)
+
+// TopicExtractor infers 1-3 topic tags ("cooking", "kubernetes", "python")
+// for a session from its events. It uses the classifier when one is set and
+// falls back to keyword frequency over the user's messages when there is no
+// classifier or it fails or returns nothing usable.
+type TopicExtractor struct {
+	classify TopicClassifyFunc
+}
+
+// NewTopicExtractor returns an extractor; a nil classify uses keyword
+// frequency only.
+func NewTopicExtractor(classify TopicClassifyFunc) *TopicExtractor {
+	return &TopicExtractor{classify: classify}
+}
+
+// Extract returns the topic tags for events, most relevant first. It returns
+// no tags when the events hold too little text to classify.
+func (x *TopicExtractor) Extract(ctx context.Context, events []Event) ([]string, error) {
+	transcript := topicTranscript(events)
+	if transcript == "" {
+		return nil, nil
+	}
+	if x.classify != nil {
+		tags, err := x.classify(ctx, transcript)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err == nil {
+			if tags = normalizeTopicTags(tags); len(tags) > 0 {
+				return tags, nil
+			}
+		}
+	}
+	return keywordTopicTags(events), nil
+}
+
+// topicTranscript renders user and assistant messages, oldest first, capped
+// at sessionTagTranscriptChars from the most recent end.
+func topicTranscript(events []Event) string {
+	var lines []string
+	size := 0
+	for i := len(events) - 1; i >= 0; i-- {
+		ev := events[i]
+		if ev.Role != "user" && ev.Role != "assistant" {
+			continue
+		}
+		content := strings.Join(strings.Fields(ev.Content), " ")
+		if content == "" || content == ErasedContent {
+			continue
+		}
+		line := ev.Role + ": " + content
+		if size+len(line) > sessionTagTranscriptChars {
+			if size > 0 {
+				break
+			}
+			line = line[:sessionTagTranscriptChars]
+			for !utf8.ValidString(line) {
+				line = line[:len(line)-1]
+			}
+		}
+		size += len(line) + 1
+		lines = append(lines, line)
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// keywordTopicTags picks the terms the user mentions most often, counting
+// each term at most once per message so one long paste does not dominate.
+func keywordTopicTags(events []Event) []string {
+	counts := map[string]int{}
+	for _, ev := range events {
+		if ev.Role != "user" || ev.Content == ErasedContent {
+			continue
+		}
+		for term := range clusterTerms(ev.Content) {
+			counts[term]++
+		}
+	}
+	type scored struct {
+		term  string
+		count int
+	}
+	terms := make([]scored, 0, len(counts))
+	for term, count := range counts {
+		if count >= sessionTagMinKeywordCount && !isNumericTerm(term) {
+			terms = append(terms, scored{term: term, count: count})
+		}
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].count != terms[j].count {
+			return terms[i].count > terms[j].count
+		}
+		return terms[i].term < terms[j].term
+	})
+	tags := make([]string, 0, MaxSessionTags)
+	for _, t := range terms {
+		tags = append(tags, t.term)
+	}
+	return normalizeTopicTags(tags)
+}
+
+func isNumericTerm(term string) bool {
+	for _, r := range term {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeTopicTags lowercases tags, turns spaces into hyphens, drops other
+// punctuation apart from "+", "#" and ".", and keeps the first MaxSessionTags
+// distinct ones.
+func normalizeTopicTags(tags []string) []string {
+	out := make([]string, 0, MaxSessionTags)
+	seen := map[string]bool{}
+	for _, raw := range tags {
+		var b strings.Builder
+		for _, r := range strings.ToLower(strings.TrimSpace(raw)) {
+			switch {
+			case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+' || r == '#' || r == '.':
+				b.WriteRune(r)
+			case unicode.IsSpace(r) || r == '-' || r == '_':
+				b.WriteRune('-')
+			}
+		}
+		tag := strings.Trim(b.String(), "-.")
+		for strings.Contains(tag, "--") {
+			tag = strings.ReplaceAll(tag, "--", "-")
+		}
+		if tag == "" || utf8.RuneCountInString(tag) > sessionTagMaxLength || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+		if len(out) == MaxSessionTags {
+			break
+		}
+	}
+	return out
+}
+
+// ParseTopicTags reads tags from a classifier reply given as a JSON array or
+// as comma- or newline-separated words.
+func ParseTopicTags(reply string) []string {
+	reply = strings.TrimSpace(reply)
+	reply = strings.TrimPrefix(strings.TrimSuffix(reply, "]"), "[")
+	fields := strings.FieldsFunc(reply, func(r rune) bool { return r == ',' || r == '\n' })
+	tags := make([]string, 0, len(fields))
+	for _, f := range fields {
+		tags = append(tags, strings.Trim(strings.TrimSpace(f), "\"'`-* "))
+	}
+	return normalizeTopicTags(tags)
+}
+
+// refreshSessionTags re-extracts a session's topic tags once it has enough
+// messages and has grown by sessionTagRefreshMessages since the last time.
+func (s *Service) refreshSessionTags(ctx context.Context, sessionKey string) error {
+	if s.cfg.DisableSessionTags {
+		return nil
+	}
+	sess, err := s.store.GetSession(ctx, sessionKey)
+	if err != nil {
+		return err
+	}
+	if sess.MessageCount < sessionTagMinMessages {
+		return nil
+	}
+	last, err := s.store.GetSessionMetadata(ctx, sessionKey, sessionTagsMessageCountKey)
+	if err != nil {
+		return err
+	}
+	if lastCount, err := strconv.Atoi(last); err == nil && sess.MessageCount-lastCount < sessionTagRefreshMessages {
+		return nil
+	}
+	events, err := s.store.ListRecentEvents(ctx, sessionKey, sessionTagEvents, true)
+	if err != nil {
+		return err
+	}
+	tags, err := NewTopicExtractor(s.cfg.TopicClassifier).Extract(ctx, events)
+	if err != nil {
+		return err
+	}
+	if len(tags) > 0 {
+		if err := s.store.SetSessionTags(ctx, sessionKey, tags); err != nil {
+			return err
+		}
+	}
+	return s.store.SetSessionMetadata(ctx, sessionKey, sessionTagsMessageCountKey, strconv.Itoa(sess.MessageCount))
+}
+
+// SetSessionTags replaces a session's topic tags.
+func (s *SQLiteStore) SetSessionTags(ctx context.Context, sessionKey string, tags []string) error {
+	sessionKey = strings.TrimSpace(sessionKey)
+	if sessionKey == "" {
+		return fmt.Errorf("set session tags: session key is required")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("set session tags: %w", err)
+	}
+	defer tx.Rollback()
+	if err := replaceSessionTagsTx(ctx, tx, sessionKey, tags); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("set session tags: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgreSQLStore) SetSessionTags(ctx context.Context, sessionKey string, tags []string) error {
+	sessionKey = strings.TrimSpace(sessionKey)
+	if sessionKey == "" {
+		return fmt.Errorf("set session tags: session key is required")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("set session tags: %w", err)
+	}
+	defer tx.Rollback()
+	if err := replaceSessionTagsTx(ctx, tx, sessionKey, tags); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("set session tags: %w", err)
+	}
+	return nil
+}
+
+func replaceSessionTagsTx(ctx context.Context, tx sqlTx, sessionKey string, tags []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM session_tags WHERE session_key = ?`, sessionKey); err != nil {
+		return fmt.Errorf("clear session tags: %w", err)
+	}
+	now := nowMS()
+	for i, tag := range normalizeTopicTags(tags) {
+		// created_at_ms keeps the tags' relevance order on read.
+		if _, err := tx.ExecContext(ctx, `INSERT INTO session_tags(session_key, tag, created_at_ms) VALUES(?, ?, ?)`, sessionKey, tag, now+int64(i)); err != nil {
+			return fmt.Errorf("insert session tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListSessionTags returns the topic tags of the given sessions, most relevant
+// first, keyed by session; sessions without tags are absent.
+func (s *SQLiteStore) ListSessionTags(ctx context.Context, sessionKeys []string) (map[string][]string, error) {
+	return listSessionTags(ctx, s.db, sessionKeys)
+}
+
+func (s *PostgreSQLStore) ListSessionTags(ctx context.Context, sessionKeys []string) (map[string][]string, error) {
+	return listSessionTags(ctx, s.db, sessionKeys)
+}
+
+func listSessionTags(ctx context.Context, db sqlQuerier, sessionKeys []string) (map[string][]string, error) {
+	out := map[string][]string{}
+	if len(sessionKeys) == 0 {
+		return out, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(sessionKeys)), ",")
+	args := make([]interface{}, len(sessionKeys))
+	for i, key := range sessionKeys {
+		args[i] = key
+	}
+	rows, err := db.QueryContext(ctx, `
+SELECT session_key, tag FROM session_tags
+WHERE session_key IN (`+placeholders+`)
+ORDER BY session_key, created_at_ms, tag`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list session tags: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key, tag string
+		if err := rows.Scan(&key, &tag); err != nil {
+			return nil, fmt.Errorf("scan session tag: %w", err)
+		}
+		out[key] = append(out[key], tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate session tags: %w", err)
+	}
+	return out, nil
+}
+
+// ListSessionsByTag returns the keys of sessions tagged with tag, most
+// recently updated first.
+func (s *SQLiteStore) ListSessionsByTag(ctx context.Context, tag string, limit int) ([]string, error) {
+	return listSessionsByTag(ctx, s.db, tag, limit)
+}
+
+func (s *PostgreSQLStore) ListSessionsByTag(ctx context.Context, tag string, limit int) ([]string, error) {
+	return listSessionsByTag(ctx, s.db, tag, limit)
+}
+
+func listSessionsByTag(ctx context.Context, db sqlQuerier, tag string, limit int) ([]string, error) {
+	normalized := normalizeTopicTags([]string{tag})
+	if len(normalized) == 0 {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	rows, err := db.QueryContext(ctx, `
+SELECT t.session_key FROM session_tags t
+JOIN sessions s ON s.session_key = t.session_key
+WHERE t.tag = ?
+ORDER BY s.updated_at_ms DESC
+LIMIT ?`, normalized[0], limit)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions by tag: %w", err)
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("scan session key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sessions by tag: %w", err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func topicEvents(user ...string) []Event {
+	var events []Event
+	for i, content := range user {
+		events = append(events,
+			Event{Role: "user", Content: content, Seq: 2 * i},
+			Event{Role: "assistant", Content: "Sure, here is what I found.", Seq: 2*i + 1},
+		)
+	}
+	return events
+}
+
+func TestTopicExtractor_KeywordFrequency(t *testing.T) {
+	events := topicEvents(
+		"My kubernetes pod keeps restarting",
+		"The kubernetes deployment uses a python sidecar",
+		"Can the python sidecar read the kubernetes secret?",
+		"Also what should I cook for dinner",
+	)
+	tags, err := NewTopicExtractor(nil).Extract(context.Background(), events)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if want := []string{"kubernetes", "python", "sidecar"}; !reflect.DeepEqual(tags, want) {
+		t.Fatalf("tags = %v, want %v", tags, want)
+	}
+}
+
+func TestTopicExtractor_UsesClassifierAndFallsBack(t *testing.T) {
+	events := topicEvents("Roast chicken recipe please", "How long should the chicken rest?")
+	var transcript string
+	classify := func(_ context.Context, text string) ([]string, error) {
+		transcript = text
+		return []string{"Cooking", "Home Cooking", "cooking", "recipes", "poultry"}, nil
+	}
+	tags, err := NewTopicExtractor(classify).Extract(context.Background(), events)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if want := []string{"cooking", "home-cooking", "recipes"}; !reflect.DeepEqual(tags, want) {
+		t.Fatalf("tags = %v, want %v", tags, want)
+	}
+	if transcript == "" || transcript[:6] != "user: " {
+		t.Fatalf("expected a role-prefixed transcript, got %q", transcript)
+	}
+
+	failing := func(context.Context, string) ([]string, error) { return nil, errors.New("model down") }
+	tags, err = NewTopicExtractor(failing).Extract(context.Background(), events)
+	if err != nil || !reflect.DeepEqual(tags, []string{"chicken"}) {
+		t.Fatalf("expected keyword fallback, got %v %v", tags, err)
+	}
+}
+
+func TestParseTopicTags(t *testing.T) {
+	if got := ParseTopicTags(`["Kubernetes", "Go"]`); !reflect.DeepEqual(got, []string{"kubernetes", "go"}) {
+		t.Fatalf("json array: %v", got)
+	}
+	if got := ParseTopicTags("- travel\n- Japan trip\n"); !reflect.DeepEqual(got, []string{"travel", "japan-trip"}) {
+		t.Fatalf("list: %v", got)
+	}
+}
+
+func TestService_RefreshSessionTags(t *testing.T) {
+	ctx := context.Background()
+	svc, err := NewService(Config{
+		Workspace:  t.TempDir(),
+		AgentID:    "dotagent",
+		WorkerPoll: 10 * time.Second,
+	}, nil)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	defer svc.Close()
+	store := svc.store.(*SQLiteStore)
+
+	sessionKey := "discord:topics"
+	if err := svc.EnsureSession(ctx, sessionKey, "discord", "topics", "u1"); err != nil {
+		t.Fatalf("ensure session: %v", err)
+	}
+	appendTurns := func(from, n int, content string) {
+		for i := from; i < from+n; i++ {
+			if err := store.AppendEvent(ctx, Event{
+				ID: fmt.Sprintf("ev-%d", i), SessionKey: sessionKey, TurnID: fmt.Sprintf("t-%d", i),
+				Seq: i, Role: "user", Content: content, CreatedAt: time.Now(),
+			}); err != nil {
+				t.Fatalf("append event: %v", err)
+			}
+		}
+	}
+
+	appendTurns(0, 2, "sourdough starter feeding schedule")
+	if err := svc.refreshSessionTags(ctx, sessionKey); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if tags, _ := store.ListSessionTags(ctx, []string{sessionKey}); len(tags) != 0 {
+		t.Fatalf("expected no tags for a short session, got %v", tags)
+	}
+
+	appendTurns(2, 2, "sourdough starter feeding schedule")
+	if err := svc.refreshSessionTags(ctx, sessionKey); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	tags, err := store.ListSessionTags(ctx, []string{sessionKey})
+	if err != nil {
+		t.Fatalf("list tags: %v", err)
+	}
+	if want := []string{"feeding", "schedule", "sourdough"}; !reflect.DeepEqual(tags[sessionKey], want) {
+		t.Fatalf("tags = %v, want %v", tags[sessionKey], want)
+	}
+
+	// Tags are not recomputed until the session grows substantially.
+	appendTurns(4, 4, "kubernetes ingress kubernetes ingress")
+	if err := svc.refreshSessionTags(ctx, sessionKey); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if tags, _ := store.ListSessionTags(ctx, []string{sessionKey}); tags[sessionKey][0] != "feeding" {
+		t.Fatalf("expected tags to stay until more messages arrive, got %v", tags)
+	}
+
+	keys, err := store.ListSessionsByTag(ctx, "Sourdough", 10)
+	if err != nil || !reflect.DeepEqual(keys, []string{sessionKey}) {
+		t.Fatalf("list by tag: %v %v", keys, err)
+	}
+}
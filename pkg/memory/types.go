@@ -13,6 +13,9 @@ type Session struct {
 	MessageCount       int
 	Summary            string
 	LastConsolidatedMS int64
+	// HistoryVersion changes whenever any process archives, rewrites or
+	// deletes the session's stored events.
+	HistoryVersion int64
 }
 
 // Event is the canonical append-only conversation record.
@@ -100,6 +103,8 @@ type MemoryLink struct {
 type MemoryCard struct {
 	ID         string
 	Kind       MemoryItemKind
+	Key        string
+	Scope      MemoryScopeType
 	Content    string
 	Score      float64
 	Confidence float64
@@ -159,8 +164,11 @@ type PromptContinuity struct {
 	HasRecall         bool
 	SnapshotRevision  int
 	ContinuationNotes []string
-	Degraded          bool
-	DegradedBy        []string
+	// RestoredFromSnapshot reports that History opens with a recap built from
+	// the latest snapshot because no earlier events remained.
+	RestoredFromSnapshot bool
+	Degraded             bool
+	DegradedBy           []string
 }
 
 // SessionSnapshot is a structured compaction artifact used for long-horizon continuity.
@@ -200,6 +208,8 @@ const (
 	JobCompact          = "compact"
 	JobEmbeddingSync    = "embedding_sync"
 	JobEmbeddingReindex = "embedding_reindex"
+	JobConsistencyCheck = "consistency_check"
+	JobAutoLink         = "auto_link"
 )
 
 // JobStatus values.
@@ -208,6 +218,7 @@ const (
 	JobRunning   = "running"
 	JobCompleted = "completed"
 	JobFailed    = "failed"
+	JobCanceled  = "canceled"
 )
 
 // Job is a durable background memory task.
@@ -0,0 +1,202 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultWorkingMemoryEvents  = 20
+	defaultWorkingMemoryIdleTTL = 10 * time.Minute
+)
+
+// WorkingMemoryCache keeps the most recent active events of each session in
+// process so prompt assembly can skip the SQLite history query. It mirrors
+// the store: entries are filled from a store read and then kept current by
+// appending each event after it has been written. Each entry remembers the
+// session's HistoryVersion when it was filled; a read with a different version
+// misses, so events another process archived, erased or pruned are not served.
+// Sessions idle for longer than the TTL are evicted.
+type WorkingMemoryCache struct {
+	sessions sync.Map // session key -> *workingMemorySession
+	capacity int
+	idleTTL  time.Duration
+	now      func() time.Time
+
+	// generation changes on every append and invalidation, so a fill computed
+	// from a store read that raced with a write is discarded.
+	generation atomic.Uint64
+	lastSweep  atomic.Int64
+}
+
+type workingMemorySession struct {
+	mu     sync.Mutex
+	events []Event
+	// complete reports that events holds every active event of the session,
+	// not just its tail.
+	complete       bool
+	historyVersion int64
+	lastAccess     time.Time
+}
+
+// NewWorkingMemoryCache keeps up to capacity events per session and evicts
+// sessions not read or written for idleTTL. Non-positive values select the
+// defaults of 20 events and 10 minutes.
+func NewWorkingMemoryCache(capacity int, idleTTL time.Duration) *WorkingMemoryCache {
+	if capacity <= 0 {
+		capacity = defaultWorkingMemoryEvents
+	}
+	if idleTTL <= 0 {
+		idleTTL = defaultWorkingMemoryIdleTTL
+	}
+	return &WorkingMemoryCache{capacity: capacity, idleTTL: idleTTL, now: time.Now}
+}
+
+// Get returns the last limit cached events of the session, oldest first. It
+// reports a miss when the session is not cached, when it was cached at another
+// historyVersion, or when the cache holds fewer than limit events of a session
+// that has more.
+func (c *WorkingMemoryCache) Get(sessionKey string, limit int, historyVersion int64) ([]Event, bool) {
+	if c == nil || strings.TrimSpace(sessionKey) == "" || limit <= 0 {
+		return nil, false
+	}
+	now := c.now()
+	c.sweep(now)
+	v, ok := c.sessions.Load(sessionKey)
+	if !ok {
+		return nil, false
+	}
+	entry := v.(*workingMemorySession)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if now.Sub(entry.lastAccess) > c.idleTTL || entry.historyVersion != historyVersion {
+		c.sessions.CompareAndDelete(sessionKey, entry)
+		return nil, false
+	}
+	if len(entry.events) < limit && !entry.complete {
+		return nil, false
+	}
+	entry.lastAccess = now
+	start := 0
+	if len(entry.events) > limit {
+		start = len(entry.events) - limit
+	}
+	return append([]Event(nil), entry.events[start:]...), true
+}
+
+// Generation returns a token to pass to Fill. Take it before reading the
+// store.
+func (c *WorkingMemoryCache) Generation() uint64 {
+	if c == nil {
+		return 0
+	}
+	return c.generation.Load()
+}
+
+// Fill caches events read from the store, oldest first. complete reports that
+// the read returned every active event of the session, and historyVersion is
+// the session's HistoryVersion read before the events. The fill is dropped if
+// any append or invalidation happened since generation was taken.
+func (c *WorkingMemoryCache) Fill(sessionKey string, events []Event, complete bool, historyVersion int64, generation uint64) {
+	if c == nil || strings.TrimSpace(sessionKey) == "" {
+		return
+	}
+	if len(events) > c.capacity {
+		events = events[len(events)-c.capacity:]
+		complete = false
+	}
+	entry := &workingMemorySession{
+		events:         append([]Event(nil), events...),
+		complete:       complete,
+		historyVersion: historyVersion,
+		lastAccess:     c.now(),
+	}
+	if c.generation.Load() != generation {
+		return
+	}
+	c.sessions.Store(sessionKey, entry)
+	// An append may have missed the entry while it was being stored.
+	if c.generation.Load() != generation {
+		c.sessions.CompareAndDelete(sessionKey, entry)
+	}
+}
+
+// Append adds an event that has been written to the store. Sessions that are
+// not cached are left alone: the cache cannot know what precedes the event.
+func (c *WorkingMemoryCache) Append(ev Event) {
+	if c == nil || strings.TrimSpace(ev.SessionKey) == "" {
+		return
+	}
+	c.generation.Add(1)
+	if ev.Archived {
+		return
+	}
+	v, ok := c.sessions.Load(ev.SessionKey)
+	if !ok {
+		return
+	}
+	entry := v.(*workingMemorySession)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	// Match the store, which keeps millisecond timestamps.
+	ev.CreatedAt = time.UnixMilli(ev.CreatedAt.UnixMilli())
+	entry.events = append(entry.events, ev)
+	if n := len(entry.events); n > 1 && eventBefore(entry.events[n-1], entry.events[n-2]) {
+		sort.SliceStable(entry.events, func(i, j int) bool { return eventBefore(entry.events[i], entry.events[j]) })
+	}
+	if len(entry.events) > c.capacity {
+		entry.events = append([]Event(nil), entry.events[len(entry.events)-c.capacity:]...)
+		entry.complete = false
+	}
+	entry.lastAccess = c.now()
+}
+
+// Invalidate drops the session, for example after its events were archived.
+func (c *WorkingMemoryCache) Invalidate(sessionKey string) {
+	if c == nil {
+		return
+	}
+	c.generation.Add(1)
+	c.sessions.Delete(sessionKey)
+}
+
+// Reset drops every session.
+func (c *WorkingMemoryCache) Reset() {
+	if c == nil {
+		return
+	}
+	c.generation.Add(1)
+	c.sessions.Range(func(key, _ any) bool {
+		c.sessions.Delete(key)
+		return true
+	})
+}
+
+// sweep evicts idle sessions, at most once per tenth of the TTL.
+func (c *WorkingMemoryCache) sweep(now time.Time) {
+	last := c.lastSweep.Load()
+	if now.UnixMilli()-last < c.idleTTL.Milliseconds()/10 || !c.lastSweep.CompareAndSwap(last, now.UnixMilli()) {
+		return
+	}
+	c.sessions.Range(func(key, v any) bool {
+		entry := v.(*workingMemorySession)
+		entry.mu.Lock()
+		idle := now.Sub(entry.lastAccess) > c.idleTTL
+		entry.mu.Unlock()
+		if idle {
+			c.sessions.CompareAndDelete(key, entry)
+		}
+		return true
+	})
+}
+
+// eventBefore orders events the way the store lists them.
+func eventBefore(a, b Event) bool {
+	if am, bm := a.CreatedAt.UnixMilli(), b.CreatedAt.UnixMilli(); am != bm {
+		return am < bm
+	}
+	return a.Seq < b.Seq
+}
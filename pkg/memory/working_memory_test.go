@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWorkingMemoryCache_GetFillAppend(t *testing.T) {
+	c := NewWorkingMemoryCache(3, time.Minute)
+	if _, ok := c.Get("s1", 2, 0); ok {
+		t.Fatal("expected miss for uncached session")
+	}
+	c.Append(Event{SessionKey: "s1", ID: "ignored", Content: "before fill"})
+
+	gen := c.Generation()
+	c.Fill("s1", []Event{{SessionKey: "s1", ID: "e1", Seq: 1}}, true, 0, gen)
+	events, ok := c.Get("s1", 24, 0)
+	if !ok || len(events) != 1 || events[0].ID != "e1" {
+		t.Fatalf("expected complete session hit, got %v %+v", ok, events)
+	}
+
+	for i := 2; i <= 4; i++ {
+		c.Append(Event{SessionKey: "s1", ID: fmt.Sprintf("e%d", i), Seq: i})
+	}
+	events, ok = c.Get("s1", 3, 0)
+	if !ok || len(events) != 3 || events[0].ID != "e2" || events[2].ID != "e4" {
+		t.Fatalf("expected last three events, got %v %+v", ok, events)
+	}
+	if _, ok := c.Get("s1", 4, 0); ok {
+		t.Fatal("expected miss once the session outgrew the cache")
+	}
+
+	if _, ok := c.Get("s1", 1, 1); ok {
+		t.Fatal("expected miss once the session's history version moved on")
+	}
+	if _, ok := c.Get("s1", 1, 0); ok {
+		t.Fatal("expected the stale entry to have been dropped")
+	}
+
+	c.Fill("s1", []Event{{SessionKey: "s1", ID: "e1", Seq: 1}}, true, 0, c.Generation())
+	c.Invalidate("s1")
+	if _, ok := c.Get("s1", 1, 0); ok {
+		t.Fatal("expected miss after invalidate")
+	}
+}
+
+func TestWorkingMemoryCache_DropsStaleFill(t *testing.T) {
+	c := NewWorkingMemoryCache(20, time.Minute)
+	gen := c.Generation()
+	c.Append(Event{SessionKey: "s1", ID: "written-during-read"})
+	c.Fill("s1", nil, true, 0, gen)
+	if _, ok := c.Get("s1", 1, 0); ok {
+		t.Fatal("expected fill that raced with an append to be dropped")
+	}
+}
+
+func TestWorkingMemoryCache_EvictsIdleSessions(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	c := NewWorkingMemoryCache(20, 10*time.Minute)
+	c.now = func() time.Time { return now }
+	c.Fill("s1", []Event{{SessionKey: "s1", ID: "e1"}}, true, 0, c.Generation())
+	c.Fill("s2", []Event{{SessionKey: "s2", ID: "e1"}}, true, 0, c.Generation())
+
+	now = now.Add(6 * time.Minute)
+	if _, ok := c.Get("s1", 1, 0); !ok {
+		t.Fatal("expected s1 to still be cached")
+	}
+	now = now.Add(6 * time.Minute)
+	if _, ok := c.Get("s1", 1, 0); !ok {
+		t.Fatal("expected recently read s1 to still be cached")
+	}
+	if _, ok := c.sessions.Load("s2"); ok {
+		t.Fatal("expected idle s2 to be evicted")
+	}
+}
+
+func TestBuildPromptContext_ReadsWorkingMemory(t *testing.T) {
+	ctx := context.Background()
+	svc, err := NewService(Config{Workspace: t.TempDir(), AgentID: "dotagent", MaxContextTokens: 4096}, nil)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	defer svc.Close()
+
+	sessionKey := "cli:working-memory"
+	if err := svc.EnsureSession(ctx, sessionKey, "cli", "working-memory", "u1"); err != nil {
+		t.Fatalf("ensure session: %v", err)
+	}
+	if err := svc.AppendEvent(ctx, Event{SessionKey: sessionKey, TurnID: "t1", Seq: 1, Role: "user", Content: "original question"}); err != nil {
+		t.Fatalf("append event: %v", err)
+	}
+	if _, err := svc.BuildPromptContext(ctx, sessionKey, "u1", "question", 4096); err != nil {
+		t.Fatalf("build prompt context: %v", err)
+	}
+
+	// Change the row behind the cache's back without touching its history
+	// version: a cache hit still sees the original role.
+	store := svc.store.(*SQLiteStore)
+	if _, err := store.db.ExecContext(ctx, `UPDATE events SET role = 'assistant' WHERE session_key = ?`, sessionKey); err != nil {
+		t.Fatalf("edit event: %v", err)
+	}
+	pc, err := svc.BuildPromptContext(ctx, sessionKey, "u1", "question", 4096)
+	if err != nil {
+		t.Fatalf("build prompt context: %v", err)
+	}
+	if len(pc.History) != 1 || pc.History[0].Role != "user" {
+		t.Fatalf("expected history from working memory, got %+v", pc.History)
+	}
+
+	svc.workingMemory.Invalidate(sessionKey)
+	pc, err = svc.BuildPromptContext(ctx, sessionKey, "u1", "question", 4096)
+	if err != nil {
+		t.Fatalf("build prompt context: %v", err)
+	}
+	if len(pc.History) != 1 || pc.History[0].Role != "assistant" {
+		t.Fatalf("expected history from sqlite after invalidate, got %+v", pc.History)
+	}
+}
+
+func TestBuildPromptContext_WorkingMemorySkipsEventsChangedElsewhere(t *testing.T) {
+	ctx := context.Background()
+	svc, err := NewService(Config{Workspace: t.TempDir(), AgentID: "dotagent", MaxContextTokens: 4096}, nil)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	defer svc.Close()
+
+	sessionKey := "cli:working-memory-stale"
+	if err := svc.EnsureSession(ctx, sessionKey, "cli", "working-memory-stale", "u1"); err != nil {
+		t.Fatalf("ensure session: %v", err)
+	}
+	if err := svc.AppendEvent(ctx, Event{SessionKey: sessionKey, TurnID: "t1", Seq: 1, Role: "user", Content: "secret question"}); err != nil {
+		t.Fatalf("append event: %v", err)
+	}
+	if _, err := svc.BuildPromptContext(ctx, sessionKey, "u1", "question", 4096); err != nil {
+		t.Fatalf("build prompt context: %v", err)
+	}
+
+	// Erase the content the way another process would, straight in the
+	// store; the cached copy must not be served.
+	store := svc.store.(*SQLiteStore)
+	if _, err := store.db.ExecContext(ctx, `UPDATE events SET content = ? WHERE session_key = ?`, ErasedContent, sessionKey); err != nil {
+		t.Fatalf("erase event: %v", err)
+	}
+	pc, err := svc.BuildPromptContext(ctx, sessionKey, "u1", "question", 4096)
+	if err != nil {
+		t.Fatalf("build prompt context: %v", err)
+	}
+	for _, ev := range pc.History {
+		if ev.Content == "secret question" {
+			t.Fatalf("expected erased content not to be served from working memory, got %+v", pc.History)
+		}
+	}
+
+	if _, err := store.ArchiveEventsBefore(ctx, sessionKey, 0); err != nil {
+		t.Fatalf("archive events: %v", err)
+	}
+	pc, err = svc.BuildPromptContext(ctx, sessionKey, "u1", "question", 4096)
+	if err != nil {
+		t.Fatalf("build prompt context: %v", err)
+	}
+	for _, ev := range pc.History {
+		if ev.Role == "user" && ev.Content == ErasedContent {
+			t.Fatalf("expected archived events not to be served from working memory, got %+v", pc.History)
+		}
+	}
+}
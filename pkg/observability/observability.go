@@ -0,0 +1,117 @@
+// Package observability wires OpenTelemetry tracing into the agent request
+// path. Spans are no-ops until Setup installs an exporting tracer provider,
+// and root spans reuse the request's correlation ID (pkg/trace) as their
+// trace ID so exported traces line up with logs and audit records.
+package observability
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/config"
+	reqtrace "github.com/dotsetgreg/dotagent/pkg/trace"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/dotsetgreg/dotagent"
+
+// ShutdownFunc flushes buffered spans and stops the exporter.
+type ShutdownFunc func(context.Context) error
+
+// Setup installs a global tracer provider exporting to the configured OTLP
+// endpoint. When observability is disabled it does nothing and returns a
+// no-op shutdown.
+func Setup(ctx context.Context, cfg config.ObservabilityConfig, serviceVersion string) (ShutdownFunc, error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+	endpoint := strings.TrimSpace(cfg.OTLP.Endpoint)
+	if endpoint == "" {
+		return nil, fmt.Errorf("observability.otlp.endpoint is required")
+	}
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP exporter: %w", err)
+	}
+	provider := NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(resource.NewSchemaless(
+		attribute.String("service.name", "dotagent"),
+		attribute.String("service.version", serviceVersion),
+	)))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewTracerProvider builds an SDK tracer provider that derives root trace IDs
+// from correlation IDs. opts are applied after the ID generator.
+func NewTracerProvider(opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(append([]sdktrace.TracerProviderOption{
+		sdktrace.WithIDGenerator(correlationIDGenerator{}),
+	}, opts...)...)
+}
+
+// StartSpan starts a span named name as a child of any span in ctx.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, oteltrace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, oteltrace.WithAttributes(attrs...))
+}
+
+// EndSpan records err on span, if any, and ends it.
+func EndSpan(span oteltrace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceIDFor maps a correlation ID to an OpenTelemetry trace ID. UUIDs map
+// to their 16 bytes directly, so the hex trace ID is the UUID without dashes;
+// other IDs are hashed.
+func TraceIDFor(correlationID string) oteltrace.TraceID {
+	var id oteltrace.TraceID
+	raw := strings.ReplaceAll(strings.TrimSpace(correlationID), "-", "")
+	if b, err := hex.DecodeString(raw); err == nil && len(b) == len(id) {
+		copy(id[:], b)
+		if id.IsValid() {
+			return id
+		}
+	}
+	sum := sha256.Sum256([]byte(correlationID))
+	copy(id[:], sum[:len(id)])
+	return id
+}
+
+// correlationIDGenerator uses the correlation ID in ctx as the trace ID for
+// root spans and falls back to random IDs when there is none.
+type correlationIDGenerator struct{}
+
+func (correlationIDGenerator) NewIDs(ctx context.Context) (oteltrace.TraceID, oteltrace.SpanID) {
+	var traceID oteltrace.TraceID
+	if id := reqtrace.FromContext(ctx); id != "" {
+		traceID = TraceIDFor(id)
+	} else {
+		_, _ = rand.Read(traceID[:])
+	}
+	return traceID, newSpanID()
+}
+
+func (correlationIDGenerator) NewSpanID(ctx context.Context, traceID oteltrace.TraceID) oteltrace.SpanID {
+	return newSpanID()
+}
+
+func newSpanID() oteltrace.SpanID {
+	var id oteltrace.SpanID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
@@ -0,0 +1,57 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	reqtrace "github.com/dotsetgreg/dotagent/pkg/trace"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTraceIDFor(t *testing.T) {
+	id := "3f2b8c1e-9a4d-4e7f-8b6a-1c2d3e4f5a6b"
+	if got := TraceIDFor(id).String(); got != strings.ReplaceAll(id, "-", "") {
+		t.Fatalf("expected UUID to map directly, got %s", got)
+	}
+	other := TraceIDFor("req-42")
+	if !other.IsValid() || other != TraceIDFor("req-42") {
+		t.Fatalf("expected stable hashed trace ID, got %s", other)
+	}
+}
+
+func TestStartSpan_UsesCorrelationIDAsRootTraceID(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	correlationID := reqtrace.NewID()
+	ctx := reqtrace.WithID(context.Background(), correlationID)
+	ctx, root := StartSpan(ctx, "agent.process_message")
+	_, child := StartSpan(ctx, "tool.execute.exec")
+	EndSpan(child, errors.New("exit status 1"))
+	EndSpan(root, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	want := strings.ReplaceAll(correlationID, "-", "")
+	for _, span := range spans {
+		if span.SpanContext.TraceID().String() != want {
+			t.Fatalf("span %s has trace ID %s, want %s", span.Name, span.SpanContext.TraceID(), want)
+		}
+	}
+	if spans[0].Name != "tool.execute.exec" || spans[0].Status.Code != codes.Error {
+		t.Fatalf("expected failed child span first, got %s (%v)", spans[0].Name, spans[0].Status)
+	}
+	if spans[0].Parent.SpanID() != spans[1].SpanContext.SpanID() {
+		t.Fatal("expected tool span to be a child of the root span")
+	}
+}
@@ -14,6 +14,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/trace"
 )
 
 const defaultHTTPTimeout = 300 * time.Second
@@ -88,7 +90,7 @@ func (p *chatCompletionsProvider) Chat(ctx context.Context, messages []Message,
 
 	requestBody := map[string]interface{}{
 		"model":    model,
-		"messages": messages,
+		"messages": toChatCompletionsMessages(messages),
 	}
 	streamCallback := optionAsStreamCallback(options)
 	streaming := streamCallback != nil || optionAsBool(options, "stream")
@@ -120,6 +122,9 @@ func (p *chatCompletionsProvider) Chat(ctx context.Context, messages []Message,
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	if id := trace.FromContext(ctx); id != "" {
+		req.Header.Set(trace.HeaderName, id)
+	}
 	if err := p.auth.Apply(ctx, req); err != nil {
 		return nil, fmt.Errorf("apply %s auth: %w", p.providerName, err)
 	}
@@ -137,7 +142,7 @@ func (p *chatCompletionsProvider) Chat(ctx context.Context, messages []Message,
 		body, _ := io.ReadAll(resp.Body)
 		msg := augmentProviderError(p.providerName, extractAPIError(body))
 		retryAfter := ParseRetryAfterHeader(resp.Header.Get("Retry-After"))
-		return nil, NewHTTPError(p.providerName, resp.StatusCode, msg, retryAfter)
+		return nil, NewHTTPErrorWithBody(p.providerName, resp.StatusCode, msg, body, retryAfter)
 	}
 
 	if streaming {
@@ -160,6 +165,10 @@ func (p *chatCompletionsProvider) Chat(ctx context.Context, messages []Message,
 	return result, nil
 }
 
+func (p *chatCompletionsProvider) SupportsStreaming() bool {
+	return true
+}
+
 func (p *chatCompletionsProvider) GetDefaultModel() string {
 	if p == nil {
 		return ""
@@ -243,7 +252,7 @@ func (p *chatCompletionsProvider) resolveOpenRouterContextWindow(ctx context.Con
 		return 0, err
 	}
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
-		return 0, NewHTTPError(p.providerName, resp.StatusCode, extractAPIError(body), ParseRetryAfterHeader(resp.Header.Get("Retry-After")))
+		return 0, NewHTTPErrorWithBody(p.providerName, resp.StatusCode, extractAPIError(body), body, ParseRetryAfterHeader(resp.Header.Get("Retry-After")))
 	}
 
 	var payload struct {
@@ -309,7 +318,7 @@ func (p *chatCompletionsProvider) resolveOllamaContextWindow(ctx context.Context
 		return 0, err
 	}
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
-		return 0, NewHTTPError(p.providerName, resp.StatusCode, extractAPIError(body), ParseRetryAfterHeader(resp.Header.Get("Retry-After")))
+		return 0, NewHTTPErrorWithBody(p.providerName, resp.StatusCode, extractAPIError(body), body, ParseRetryAfterHeader(resp.Header.Get("Retry-After")))
 	}
 	return parseOllamaContextWindow(body)
 }
@@ -714,6 +723,32 @@ func parseChatCompletionsStreamResponse(r io.Reader, onDelta func(string)) (*LLM
 	}, nil
 }
 
+// chatCompletionsMessage is the wire form of Message: content is a string,
+// or an array of parts when the message carries ContentBlocks.
+type chatCompletionsMessage struct {
+	Role       string      `json:"role"`
+	Content    interface{} `json:"content"`
+	ToolCalls  []ToolCall  `json:"tool_calls,omitempty"`
+	ToolCallID string      `json:"tool_call_id,omitempty"`
+}
+
+func toChatCompletionsMessages(messages []Message) []chatCompletionsMessage {
+	out := make([]chatCompletionsMessage, 0, len(messages))
+	for _, msg := range messages {
+		wire := chatCompletionsMessage{
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCalls:  msg.ToolCalls,
+			ToolCallID: msg.ToolCallID,
+		}
+		if len(msg.ContentBlocks) > 0 {
+			wire.Content = msg.ContentBlocks
+		}
+		out = append(out, wire)
+	}
+	return out
+}
+
 func flattenMessageContent(raw interface{}) string {
 	switch v := raw.(type) {
 	case string:
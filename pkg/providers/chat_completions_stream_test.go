@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 )
@@ -58,3 +59,37 @@ func TestParseChatCompletionsStreamResponse_ToolCalls(t *testing.T) {
 		t.Fatalf("expected reconstructed args, got %#v", got)
 	}
 }
+
+func TestToChatCompletionsMessages_ImageContentBlocks(t *testing.T) {
+	wire := toChatCompletionsMessages([]Message{
+		{Role: "system", Content: "be brief"},
+		{
+			Role:    "user",
+			Content: "describe [image: shot.png]",
+			ContentBlocks: []ContentBlock{
+				{Type: "text", Text: "describe [image: shot.png]"},
+				{Type: "image_url", ImageURL: &ImageURL{URL: "data:image/png;base64,AAAA"}},
+			},
+		},
+	})
+	data, err := json.Marshal(wire)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got, _ := decoded[0]["content"].(string); got != "be brief" {
+		t.Fatalf("expected plain string content for system message, got %#v", decoded[0]["content"])
+	}
+	parts, ok := decoded[1]["content"].([]interface{})
+	if !ok || len(parts) != 2 {
+		t.Fatalf("expected content array for user message, got %#v", decoded[1]["content"])
+	}
+	image, _ := parts[1].(map[string]interface{})
+	imageURL, _ := image["image_url"].(map[string]interface{})
+	if image["type"] != "image_url" || imageURL["url"] != "data:image/png;base64,AAAA" {
+		t.Fatalf("unexpected image part %#v", image)
+	}
+}
@@ -21,11 +21,24 @@ const (
 	ErrorKindRateLimited     ErrorKind = "rate_limited"
 	ErrorKindContextOverflow ErrorKind = "context_overflow"
 	ErrorKindAuth            ErrorKind = "auth"
+	ErrorKindModelNotFound   ErrorKind = "model_not_found"
 	ErrorKindBadRequest      ErrorKind = "bad_request"
 	ErrorKindUnavailable     ErrorKind = "unavailable"
 	ErrorKindCanceled        ErrorKind = "canceled"
 )
 
+// Sentinel errors matched by errors.Is against any *Error of the
+// corresponding kind, so callers can branch without inspecting messages.
+var (
+	ErrRateLimit      = errors.New("provider rate limit exceeded")
+	ErrContextTooLong = errors.New("request exceeds model context window")
+	ErrUnauthorized   = errors.New("provider rejected credentials")
+	ErrModelNotFound  = errors.New("model not found")
+)
+
+// maxErrorBodyBytes caps the raw response body kept on an Error.
+const maxErrorBodyBytes = 8 << 10
+
 // Error is a typed provider error used for robust retry and recovery behavior.
 type Error struct {
 	Provider   string
@@ -33,7 +46,9 @@ type Error struct {
 	StatusCode int
 	RetryAfter time.Duration
 	Message    string
-	Cause      error
+	// Body is the raw HTTP response body, when the error came from one.
+	Body  string
+	Cause error
 }
 
 func (e *Error) Error() string {
@@ -72,19 +87,51 @@ func (e *Error) Unwrap() error {
 	return e.Cause
 }
 
+// Is reports whether target is the sentinel for this error's kind.
+func (e *Error) Is(target error) bool {
+	if e == nil {
+		return false
+	}
+	switch target {
+	case ErrRateLimit:
+		return e.Kind == ErrorKindRateLimited
+	case ErrContextTooLong:
+		return e.Kind == ErrorKindContextOverflow
+	case ErrUnauthorized:
+		return e.Kind == ErrorKindAuth
+	case ErrModelNotFound:
+		return e.Kind == ErrorKindModelNotFound
+	default:
+		return false
+	}
+}
+
 func NewHTTPError(provider string, statusCode int, message string, retryAfter time.Duration) error {
+	return NewHTTPErrorWithBody(provider, statusCode, message, nil, retryAfter)
+}
+
+// NewHTTPErrorWithBody is NewHTTPError that also keeps the raw response body
+// for logging and diagnostics.
+func NewHTTPErrorWithBody(provider string, statusCode int, message string, body []byte, retryAfter time.Duration) error {
 	kind := classifyHTTPStatusKind(statusCode)
 	if kind == ErrorKindBadRequest {
-		if detected := detectContextOverflowFromMessage(message); detected {
+		switch {
+		case detectContextOverflowFromMessage(message):
 			kind = ErrorKindContextOverflow
+		case detectModelNotFoundFromMessage(message):
+			kind = ErrorKindModelNotFound
 		}
 	}
+	if len(body) > maxErrorBodyBytes {
+		body = body[:maxErrorBodyBytes]
+	}
 	return &Error{
 		Provider:   strings.TrimSpace(strings.ToLower(provider)),
 		Kind:       kind,
 		StatusCode: statusCode,
 		RetryAfter: retryAfter,
 		Message:    strings.TrimSpace(message),
+		Body:       strings.TrimSpace(string(body)),
 	}
 }
 
@@ -306,11 +353,22 @@ func classifyMessageKind(message string) ErrorKind {
 		return ErrorKindAuth
 	case detectContextOverflowFromMessage(msg):
 		return ErrorKindContextOverflow
+	case detectModelNotFoundFromMessage(msg):
+		return ErrorKindModelNotFound
 	default:
 		return ErrorKindUnknown
 	}
 }
 
+func detectModelNotFoundFromMessage(message string) bool {
+	msg := strings.ToLower(strings.TrimSpace(message))
+	return strings.Contains(msg, "model_not_found") ||
+		strings.Contains(msg, "no endpoints found for") ||
+		(strings.Contains(msg, "model") && (strings.Contains(msg, "not found") ||
+			strings.Contains(msg, "does not exist") ||
+			strings.Contains(msg, "is not a valid model")))
+}
+
 func detectContextOverflowFromMessage(message string) bool {
 	msg := strings.ToLower(strings.TrimSpace(message))
 	return strings.Contains(msg, "context_length_exceeded") ||
@@ -2,6 +2,7 @@ package providers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"testing"
@@ -94,3 +95,44 @@ func TestNormalizeProviderError_WrapsUntypedError(t *testing.T) {
 		t.Fatalf("expected provider openrouter, got %q", meta.Provider)
 	}
 }
+
+func TestError_IsMatchesKindSentinels(t *testing.T) {
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{NewHTTPError("openrouter", 429, "slow down", 0), ErrRateLimit},
+		{NewHTTPError("openrouter", 400, "This model's maximum context length is 8192 tokens", 0), ErrContextTooLong},
+		{NewHTTPError("openrouter", 401, "No auth credentials found", 0), ErrUnauthorized},
+		{NewHTTPError("openrouter", 404, "No endpoints found for acme/unknown.", 0), ErrModelNotFound},
+		{NewHTTPError("openai", 404, "The model `gpt-9` does not exist", 0), ErrModelNotFound},
+	}
+	sentinels := []error{ErrRateLimit, ErrContextTooLong, ErrUnauthorized, ErrModelNotFound}
+	for _, tt := range tests {
+		wrapped := fmt.Errorf("turn failed: %w", tt.err)
+		for _, sentinel := range sentinels {
+			if got := errors.Is(wrapped, sentinel); got != (sentinel == tt.want) {
+				t.Fatalf("errors.Is(%q, %v) = %v", tt.err, sentinel, got)
+			}
+		}
+	}
+
+	if errors.Is(NewHTTPError("openrouter", 404, "route not found", 0), ErrModelNotFound) {
+		t.Fatalf("a 404 without a model message should not be classified as model not found")
+	}
+}
+
+func TestNewHTTPErrorWithBody_KeepsRawBody(t *testing.T) {
+	body := []byte(`{"error":{"message":"Rate limit exceeded","code":429}}`)
+	err := NewHTTPErrorWithBody("openrouter", 429, "Rate limit exceeded", body, time.Second)
+	var pe *Error
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected *Error, got %T", err)
+	}
+	if pe.Body != string(body) {
+		t.Fatalf("expected raw body to be kept, got %q", pe.Body)
+	}
+	if !errors.Is(err, ErrRateLimit) {
+		t.Fatalf("expected ErrRateLimit")
+	}
+}
@@ -103,7 +103,13 @@ func ProviderCredentialStatus(cfg *config.Config) (provider string, configured b
 }
 
 func CreateProvider(cfg *config.Config) (LLMProvider, error) {
-	factory, _, err := getFactory(cfg)
+	return CreateProviderByName(cfg, ActiveProviderName(cfg))
+}
+
+// CreateProviderByName builds the named provider from cfg, regardless of
+// which provider is active.
+func CreateProviderByName(cfg *config.Config, name string) (LLMProvider, error) {
+	factory, _, err := getFactoryByName(NormalizeProviderName(name))
 	if err != nil {
 		return nil, err
 	}
@@ -115,8 +121,10 @@ func CreateProvider(cfg *config.Config) (LLMProvider, error) {
 }
 
 func getFactory(cfg *config.Config) (providerFactory, string, error) {
-	name := ActiveProviderName(cfg)
+	return getFactoryByName(ActiveProviderName(cfg))
+}
 
+func getFactoryByName(name string) (providerFactory, string, error) {
 	factoryMu.RLock()
 	if registrationErr != nil {
 		err := registrationErr
@@ -0,0 +1,184 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RoundRobinProvider spreads calls across several providers, typically one
+// per API key. Calls rotate through the providers in order; a provider that
+// returns a rate-limit error is skipped until its cooldown expires and the
+// call moves on to the next one.
+type RoundRobinProvider struct {
+	providers []LLMProvider
+	cooldown  time.Duration
+	counter   atomic.Uint64
+
+	mu           sync.Mutex
+	coolingUntil []time.Time
+	now          func() time.Time
+}
+
+func NewRoundRobinProvider(providers []LLMProvider, cooldown time.Duration) *RoundRobinProvider {
+	if cooldown <= 0 {
+		cooldown = time.Minute
+	}
+	return &RoundRobinProvider{
+		providers:    providers,
+		cooldown:     cooldown,
+		coolingUntil: make([]time.Time, len(providers)),
+		now:          time.Now,
+	}
+}
+
+func (p *RoundRobinProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	return p.dispatch(-1, func(_ int, provider LLMProvider) (*LLMResponse, error) {
+		return provider.Chat(ctx, messages, tools, model, options)
+	})
+}
+
+// ChatWithState forwards to the wrapped providers' ChatWithState. A state ID
+// is only valid on the provider (API key) that issued it, so the returned ID
+// is tagged with that provider's index and later calls go back to it. When it
+// is cooling down the call moves on like Chat and starts a fresh state there.
+func (p *RoundRobinProvider) ChatWithState(ctx context.Context, stateID string, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, string, error) {
+	pinned, prev := splitRoundRobinState(stateID)
+	var newState string
+	resp, err := p.dispatch(pinned, func(idx int, provider LLMProvider) (*LLMResponse, error) {
+		stateful, ok := provider.(StatefulLLMProvider)
+		if !ok {
+			return provider.Chat(ctx, messages, tools, model, options)
+		}
+		state := ""
+		if idx == pinned {
+			state = prev
+		}
+		resp, next, err := stateful.ChatWithState(ctx, state, messages, tools, model, options)
+		if err == nil && strings.TrimSpace(next) != "" {
+			newState = strconv.Itoa(idx) + ":" + next
+		}
+		return resp, err
+	})
+	return resp, newState, err
+}
+
+// splitRoundRobinState undoes the tagging done by ChatWithState, returning
+// -1 for IDs it did not issue.
+func splitRoundRobinState(stateID string) (int, string) {
+	idx, state, ok := strings.Cut(strings.TrimSpace(stateID), ":")
+	if !ok {
+		return -1, ""
+	}
+	n, err := strconv.Atoi(idx)
+	if err != nil || n < 0 {
+		return -1, ""
+	}
+	return n, state
+}
+
+// dispatch runs call on the preferred provider when it is valid and not
+// cooling down, then on the others in rotation order, skipping any that hit
+// a rate limit.
+func (p *RoundRobinProvider) dispatch(preferred int, call func(int, LLMProvider) (*LLMResponse, error)) (*LLMResponse, error) {
+	n := len(p.providers)
+	if n == 0 {
+		return nil, fmt.Errorf("load balancer has no providers")
+	}
+	order := make([]int, 0, n)
+	if preferred >= 0 && preferred < n {
+		order = append(order, preferred)
+	}
+	start := p.counter.Add(1) - 1
+	for i := 0; i < n; i++ {
+		if idx := int((start + uint64(i)) % uint64(n)); idx != preferred {
+			order = append(order, idx)
+		}
+	}
+	var lastErr error
+	for _, idx := range order {
+		if p.isCooling(idx) {
+			continue
+		}
+		resp, err := call(idx, p.providers[idx])
+		if err != nil && errors.Is(err, ErrRateLimit) {
+			p.markCooling(idx, InspectError(err).RetryAfter)
+			lastErr = err
+			continue
+		}
+		return resp, err
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, &Error{
+		Kind:       ErrorKindRateLimited,
+		RetryAfter: p.nextAvailableIn(),
+		Message:    fmt.Sprintf("all %d API keys are cooling down after rate limits", n),
+	}
+}
+
+func (p *RoundRobinProvider) GetDefaultModel() string {
+	if len(p.providers) == 0 {
+		return ""
+	}
+	return p.providers[0].GetDefaultModel()
+}
+
+// SupportsStreaming reports whether every wrapped provider can stream.
+func (p *RoundRobinProvider) SupportsStreaming() bool {
+	for _, provider := range p.providers {
+		streaming, ok := provider.(StreamingLLMProvider)
+		if !ok || !streaming.SupportsStreaming() {
+			return false
+		}
+	}
+	return len(p.providers) > 0
+}
+
+// ResolveContextWindow asks the first provider; all of them serve the same
+// models.
+func (p *RoundRobinProvider) ResolveContextWindow(ctx context.Context, model string) (int, error) {
+	if len(p.providers) > 0 {
+		if cw, ok := p.providers[0].(ContextWindowProvider); ok {
+			return cw.ResolveContextWindow(ctx, model)
+		}
+	}
+	return 0, nil
+}
+
+func (p *RoundRobinProvider) isCooling(idx int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.now().Before(p.coolingUntil[idx])
+}
+
+// markCooling parks a provider for the configured cooldown, or for the
+// provider's Retry-After when that is longer.
+func (p *RoundRobinProvider) markCooling(idx int, retryAfter time.Duration) {
+	wait := p.cooldown
+	if retryAfter > wait {
+		wait = retryAfter
+	}
+	p.mu.Lock()
+	p.coolingUntil[idx] = p.now().Add(wait)
+	p.mu.Unlock()
+}
+
+func (p *RoundRobinProvider) nextAvailableIn() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	var soonest time.Duration
+	for _, until := range p.coolingUntil {
+		if wait := until.Sub(now); wait > 0 && (soonest == 0 || wait < soonest) {
+			soonest = wait
+		}
+	}
+	return soonest
+}
@@ -0,0 +1,193 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/config"
+)
+
+type fakeKeyProvider struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (f *fakeKeyProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &LLMResponse{Content: f.name}, nil
+}
+
+func (f *fakeKeyProvider) GetDefaultModel() string { return "m" }
+
+func TestRoundRobinProvider_RotatesAndCoolsDownRateLimitedKeys(t *testing.T) {
+	a := &fakeKeyProvider{name: "a"}
+	b := &fakeKeyProvider{name: "b"}
+	c := &fakeKeyProvider{name: "c"}
+	lb := NewRoundRobinProvider([]LLMProvider{a, b, c}, time.Minute)
+	now := time.Unix(1_700_000_000, 0)
+	lb.now = func() time.Time { return now }
+
+	chat := func() string {
+		t.Helper()
+		resp, err := lb.Chat(context.Background(), nil, nil, "", nil)
+		if err != nil {
+			t.Fatalf("chat: %v", err)
+		}
+		return resp.Content
+	}
+	for _, want := range []string{"a", "b", "c", "a"} {
+		if got := chat(); got != want {
+			t.Fatalf("expected %s, got %s", want, got)
+		}
+	}
+
+	b.err = &Error{Kind: ErrorKindRateLimited, StatusCode: 429}
+	if got := chat(); got != "c" {
+		t.Fatalf("expected rate-limited b to fall through to c, got %s", got)
+	}
+	b.err = nil
+	callsBefore := b.calls
+	for i := 0; i < 6; i++ {
+		if chat() == "b" {
+			t.Fatal("expected b to be skipped while cooling down")
+		}
+	}
+	if b.calls != callsBefore {
+		t.Fatalf("expected no calls to b during cooldown, got %d", b.calls-callsBefore)
+	}
+
+	now = now.Add(61 * time.Second)
+	seen := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		seen[chat()] = true
+	}
+	if !seen["b"] {
+		t.Fatal("expected b back in rotation after cooldown")
+	}
+}
+
+func TestRoundRobinProvider_AllCoolingReturnsRateLimit(t *testing.T) {
+	limited := &Error{Kind: ErrorKindRateLimited, StatusCode: 429, RetryAfter: 2 * time.Minute}
+	a := &fakeKeyProvider{err: limited}
+	b := &fakeKeyProvider{err: limited}
+	lb := NewRoundRobinProvider([]LLMProvider{a, b}, time.Minute)
+
+	if _, err := lb.Chat(context.Background(), nil, nil, "", nil); !errors.Is(err, ErrRateLimit) {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	_, err := lb.Chat(context.Background(), nil, nil, "", nil)
+	if !errors.Is(err, ErrRateLimit) {
+		t.Fatalf("expected rate limit error while all keys cool down, got %v", err)
+	}
+	if retry := InspectError(err).RetryAfter; retry <= time.Minute {
+		t.Fatalf("expected Retry-After to extend the cooldown, got %s", retry)
+	}
+	if a.calls != 1 || b.calls != 1 {
+		t.Fatalf("expected one call per key, got a=%d b=%d", a.calls, b.calls)
+	}
+
+	a.err = errors.New("boom")
+	lb.coolingUntil[0] = time.Time{}
+	if _, err := lb.Chat(context.Background(), nil, nil, "", nil); err == nil || errors.Is(err, ErrRateLimit) {
+		t.Fatalf("expected non-rate-limit errors to pass through, got %v", err)
+	}
+}
+
+type fakeStatefulKeyProvider struct {
+	fakeKeyProvider
+	states []string
+}
+
+func (f *fakeStatefulKeyProvider) ChatWithState(ctx context.Context, stateID string, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, string, error) {
+	f.states = append(f.states, stateID)
+	resp, err := f.Chat(ctx, messages, tools, model, options)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp, fmt.Sprintf("%s-%d", f.name, f.calls), nil
+}
+
+func TestRoundRobinProvider_ChatWithStateStaysOnIssuingKey(t *testing.T) {
+	a := &fakeStatefulKeyProvider{fakeKeyProvider: fakeKeyProvider{name: "a"}}
+	b := &fakeStatefulKeyProvider{fakeKeyProvider: fakeKeyProvider{name: "b"}}
+	lb := NewRoundRobinProvider([]LLMProvider{a, b}, time.Minute)
+	var _ StatefulLLMProvider = lb
+
+	chat := func(state string) (string, string) {
+		t.Helper()
+		resp, next, err := lb.ChatWithState(context.Background(), state, nil, nil, "", nil)
+		if err != nil {
+			t.Fatalf("chat with state: %v", err)
+		}
+		return resp.Content, next
+	}
+	got, state := chat("")
+	if got != "a" || state != "0:a-1" {
+		t.Fatalf("expected a fresh state from a, got %s %q", got, state)
+	}
+	for i := 0; i < 2; i++ {
+		if got, state = chat(state); got != "a" {
+			t.Fatalf("expected calls with a's state to stay on a, got %s", got)
+		}
+	}
+	if len(a.states) != 3 || a.states[1] != "a-1" || a.states[2] != "a-2" || b.calls != 0 {
+		t.Fatalf("expected a to receive its own state IDs, got %v (b calls %d)", a.states, b.calls)
+	}
+
+	a.err = &Error{Kind: ErrorKindRateLimited, StatusCode: 429}
+	got, state = chat(state)
+	if got != "b" || state != "1:b-1" || b.states[0] != "" {
+		t.Fatalf("expected a rate-limited a to move to b with a fresh state, got %s %q (b states %v)", got, state, b.states)
+	}
+}
+
+func TestCreateProvider_OpenRouter_MultipleKeys(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		mu.Lock()
+		seen[auth]++
+		mu.Unlock()
+		if auth == "Bearer key-1" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"message":"rate limited"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Providers.OpenRouter.APIKey = "key-1"
+	cfg.Providers.OpenRouter.APIKeys = config.FlexibleStringSlice{"key-2", "key-1", " "}
+	cfg.Providers.OpenRouter.APIBase = server.URL
+
+	provider, err := CreateProvider(cfg)
+	if err != nil {
+		t.Fatalf("create provider: %v", err)
+	}
+	if _, ok := provider.(*RoundRobinProvider); !ok {
+		t.Fatalf("expected round-robin provider for multiple keys, got %T", provider)
+	}
+	for i := 0; i < 3; i++ {
+		resp, err := provider.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, "", nil)
+		if err != nil || resp.Content != "ok" {
+			t.Fatalf("chat %d: %v", i, err)
+		}
+	}
+	if seen["Bearer key-1"] != 1 || seen["Bearer key-2"] != 3 {
+		t.Fatalf("expected key-1 tried once then cooled down, got %v", seen)
+	}
+}
@@ -0,0 +1,183 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrModelListUnsupported is returned for providers without a models endpoint.
+var ErrModelListUnsupported = errors.New("provider does not support listing models")
+
+// ModelInfo describes one model a provider serves. Costs are USD per token
+// and zero when the provider does not publish pricing.
+type ModelInfo struct {
+	ID                     string  `json:"id"`
+	ContextLength          int     `json:"context_length,omitempty"`
+	PromptCostPerToken     float64 `json:"prompt_cost_per_token,omitempty"`
+	CompletionCostPerToken float64 `json:"completion_cost_per_token,omitempty"`
+}
+
+// ModelLister is an optional provider capability that lists available models.
+type ModelLister interface {
+	ListModels(ctx context.Context) ([]ModelInfo, error)
+}
+
+// ListModels asks provider for its models, sorted by ID.
+func ListModels(ctx context.Context, provider LLMProvider) ([]ModelInfo, error) {
+	lister, ok := provider.(ModelLister)
+	if !ok || lister == nil {
+		return nil, ErrModelListUnsupported
+	}
+	models, err := lister.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
+// ModelCachePath is where the model list for providerName is cached under the
+// instance data directory, out of reach of the workspace file tools.
+func ModelCachePath(dataDir, providerName string) string {
+	return filepath.Join(dataDir, "cache", "models-"+NormalizeProviderName(providerName)+".json")
+}
+
+type modelCacheFile struct {
+	FetchedAtMS int64       `json:"fetched_at_ms"`
+	Models      []ModelInfo `json:"models"`
+}
+
+// ListModelsCached returns the model list stored at cachePath when it is
+// younger than ttl, and otherwise queries provider and refreshes the cache.
+// The returned time is when the list was fetched. A ttl of 0 bypasses the
+// cache.
+func ListModelsCached(ctx context.Context, provider LLMProvider, cachePath string, ttl time.Duration) ([]ModelInfo, time.Time, error) {
+	if ttl > 0 && cachePath != "" {
+		if raw, err := os.ReadFile(cachePath); err == nil {
+			var cached modelCacheFile
+			if json.Unmarshal(raw, &cached) == nil && len(cached.Models) > 0 {
+				fetchedAt := time.UnixMilli(cached.FetchedAtMS)
+				if time.Since(fetchedAt) < ttl {
+					return cached.Models, fetchedAt, nil
+				}
+			}
+		}
+	}
+
+	models, err := ListModels(ctx, provider)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	now := time.Now()
+	if ttl > 0 && cachePath != "" {
+		if raw, err := json.Marshal(modelCacheFile{FetchedAtMS: now.UnixMilli(), Models: models}); err == nil {
+			if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err == nil {
+				_ = os.WriteFile(cachePath, raw, 0o644)
+			}
+		}
+	}
+	return models, now, nil
+}
+
+// ListModels queries the OpenAI-compatible models endpoint. OpenRouter adds
+// context lengths and pricing; other providers fall back to known context
+// windows.
+func (p *chatCompletionsProvider) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	if p == nil {
+		return nil, fmt.Errorf("provider not initialized")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.apiBase+"/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.auth.Apply(ctx, req); err != nil {
+		return nil, err
+	}
+	for name, value := range p.extraHeaders {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, NewHTTPErrorWithBody(p.providerName, resp.StatusCode, extractAPIError(body), body, ParseRetryAfterHeader(resp.Header.Get("Retry-After")))
+	}
+	return parseModelsResponse(body)
+}
+
+func parseModelsResponse(body []byte) ([]ModelInfo, error) {
+	var payload struct {
+		Data []struct {
+			ID            string `json:"id"`
+			ContextLength int    `json:"context_length"`
+			Pricing       struct {
+				Prompt     json.RawMessage `json:"prompt"`
+				Completion json.RawMessage `json:"completion"`
+			} `json:"pricing"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("parse models response: %w", err)
+	}
+	models := make([]ModelInfo, 0, len(payload.Data))
+	for _, entry := range payload.Data {
+		id := strings.TrimSpace(entry.ID)
+		if id == "" {
+			continue
+		}
+		contextLength := entry.ContextLength
+		if contextLength <= 0 {
+			contextLength = knownModelContextWindow(id)
+		}
+		models = append(models, ModelInfo{
+			ID:                     id,
+			ContextLength:          contextLength,
+			PromptCostPerToken:     parsePrice(entry.Pricing.Prompt),
+			CompletionCostPerToken: parsePrice(entry.Pricing.Completion),
+		})
+	}
+	return models, nil
+}
+
+// parsePrice accepts OpenRouter's decimal-string prices as well as numbers.
+func parsePrice(raw json.RawMessage) float64 {
+	if len(raw) == 0 {
+		return 0
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		v, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return v
+	}
+	var v float64
+	_ = json.Unmarshal(raw, &v)
+	return v
+}
+
+// ListModels asks the first provider; all of them serve the same models.
+func (p *RoundRobinProvider) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	if len(p.providers) > 0 {
+		if lister, ok := p.providers[0].(ModelLister); ok {
+			return lister.ListModels(ctx)
+		}
+	}
+	return nil, ErrModelListUnsupported
+}
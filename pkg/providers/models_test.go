@@ -0,0 +1,66 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListModelsCached_ParsesOpenRouterAndCaches(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/models" {
+			t.Fatalf("unexpected path %q", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Fatalf("expected API key auth, got %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[
+			{"id":"openai/gpt-5","context_length":400000,"pricing":{"prompt":"0.00000125","completion":"0.00001"}},
+			{"id":"anthropic/claude-sonnet-4","context_length":200000,"pricing":{"prompt":"0.000003","completion":"0.000015"}},
+			{"id":""}
+		]}`))
+	}))
+	defer server.Close()
+
+	provider, err := newChatCompletionsProvider(ProviderOpenRouter, server.URL, "openai/gpt-5", "", NewAPIKeyAuth(NewStaticTokenSource("sk-test", "test")), nil)
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	cachePath := filepath.Join(t.TempDir(), "cache", "models-openrouter.json")
+
+	models, _, err := ListModelsCached(context.Background(), provider, cachePath, time.Hour)
+	if err != nil {
+		t.Fatalf("list models: %v", err)
+	}
+	if len(models) != 2 || models[0].ID != "anthropic/claude-sonnet-4" {
+		t.Fatalf("expected 2 models sorted by id, got %+v", models)
+	}
+	if models[0].ContextLength != 200000 || models[0].PromptCostPerToken != 0.000003 || models[0].CompletionCostPerToken != 0.000015 {
+		t.Fatalf("unexpected model metadata %+v", models[0])
+	}
+
+	if _, _, err := ListModelsCached(context.Background(), provider, cachePath, time.Hour); err != nil {
+		t.Fatalf("list models from cache: %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("expected the second listing to be served from cache, got %d requests", requests)
+	}
+	if _, _, err := ListModelsCached(context.Background(), provider, cachePath, 0); err != nil {
+		t.Fatalf("list models without cache: %v", err)
+	}
+	if requests != 2 {
+		t.Fatalf("expected a zero ttl to query the provider, got %d requests", requests)
+	}
+}
+
+func TestListModels_UnsupportedProvider(t *testing.T) {
+	if _, err := ListModels(context.Background(), mockWindowProvider{}); err != ErrModelListUnsupported {
+		t.Fatalf("expected ErrModelListUnsupported, got %v", err)
+	}
+}
@@ -1,8 +1,13 @@
 package providers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/dotsetgreg/dotagent/pkg/config"
 )
@@ -20,17 +25,33 @@ func validateOpenRouterConfig(cfg *config.Config) error {
 	if cfg == nil {
 		return fmt.Errorf("config is required")
 	}
-	if strings.TrimSpace(cfg.Providers.OpenRouter.APIKey) == "" {
+	if len(openRouterAPIKeys(cfg)) == 0 {
 		return fmt.Errorf("OpenRouter API key is required (set providers.openrouter.api_key or DOTAGENT_PROVIDERS_OPENROUTER_API_KEY)")
 	}
 	return nil
 }
 
+// openRouterAPIKeys returns api_key followed by api_keys, blank entries and
+// duplicates removed.
+func openRouterAPIKeys(cfg *config.Config) []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, key := range append([]string{cfg.Providers.OpenRouter.APIKey}, cfg.Providers.OpenRouter.APIKeys...) {
+		key = strings.TrimSpace(key)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+
 func openRouterCredentialStatus(cfg *config.Config) (bool, string) {
 	if cfg == nil {
 		return false, ""
 	}
-	if strings.TrimSpace(cfg.Providers.OpenRouter.APIKey) == "" {
+	if len(openRouterAPIKeys(cfg)) == 0 {
 		return false, ""
 	}
 	return true, authModeAPIKey
@@ -45,13 +66,153 @@ func newOpenRouterProviderFromConfig(cfg *config.Config) (LLMProvider, error) {
 	if apiBase == "" {
 		apiBase = defaultOpenRouterAPIBase
 	}
-	auth := NewAPIKeyAuth(NewStaticTokenSource(cfg.Providers.OpenRouter.APIKey, "providers.openrouter.api_key"))
-	return newChatCompletionsProvider(
+	proxy := strings.TrimSpace(cfg.Providers.OpenRouter.Proxy)
+	keys := openRouterAPIKeys(cfg)
+	build := func(key, field string) (LLMProvider, error) {
+		auth := NewAPIKeyAuth(NewStaticTokenSource(key, field))
+		chat, err := newChatCompletionsProvider(ProviderOpenRouter, apiBase, defaultOpenRouterModel, proxy, auth, nil)
+		if err != nil || !cfg.Providers.OpenRouter.ResponsesAPI {
+			return chat, err
+		}
+		return newOpenRouterProvider(chat, apiBase, proxy, auth)
+	}
+	if len(keys) == 1 {
+		return build(keys[0], "providers.openrouter.api_key")
+	}
+
+	pool := make([]LLMProvider, 0, len(keys))
+	for _, key := range keys {
+		provider, err := build(key, "providers.openrouter.api_keys")
+		if err != nil {
+			return nil, err
+		}
+		pool = append(pool, provider)
+	}
+	cooldown := time.Duration(cfg.Providers.LoadBalancer.CooldownSeconds) * time.Second
+	return NewRoundRobinProvider(pool, cooldown), nil
+}
+
+// OpenRouterProvider sends stateless calls over Chat Completions and
+// implements StatefulLLMProvider over OpenRouter's Responses API. With a
+// state ID it passes previous_response_id and sends only the items added
+// since that response, plus the current system prompt as instructions, so
+// the history is not re-sent on every call.
+//
+// If OpenRouter rejects a stateful request, the call is retried with the full
+// history and state is not used again by this provider.
+type OpenRouterProvider struct {
+	*chatCompletionsProvider
+	responses        *responsesProvider
+	stateUnsupported atomic.Bool
+}
+
+func newOpenRouterProvider(chat *chatCompletionsProvider, apiBase, proxy string, auth AuthStrategy) (*OpenRouterProvider, error) {
+	responses, err := newResponsesProviderWithOptions(
 		ProviderOpenRouter,
 		apiBase,
 		defaultOpenRouterModel,
-		strings.TrimSpace(cfg.Providers.OpenRouter.Proxy),
+		proxy,
 		auth,
 		nil,
+		&responsesProviderOptions{beforeMarshal: trimResponsesInputForState},
 	)
+	if err != nil {
+		return nil, err
+	}
+	return &OpenRouterProvider{chatCompletionsProvider: chat, responses: responses}, nil
+}
+
+// ChatWithState calls the Responses API and returns the response ID as the
+// new state.
+func (p *OpenRouterProvider) ChatWithState(ctx context.Context, stateID string, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, string, error) {
+	if p == nil {
+		return nil, "", fmt.Errorf("provider not initialized")
+	}
+	stateID = strings.TrimSpace(stateID)
+	if p.stateUnsupported.Load() {
+		stateID = ""
+	}
+	resp, newState, err := p.responses.ChatWithState(ctx, stateID, messages, tools, model, options)
+	if err == nil || stateID == "" || !isRejectedStateError(err) {
+		return resp, newState, err
+	}
+	resp, newState, retryErr := p.responses.ChatWithState(ctx, "", messages, tools, model, options)
+	if retryErr != nil {
+		return nil, "", retryErr
+	}
+	// A chain that outgrew the context window says nothing about whether the
+	// server supports state, so only a real refusal turns state off.
+	if !IsContextOverflowError(err) {
+		p.stateUnsupported.Store(true)
+	}
+	return resp, newState, nil
+}
+
+// isRejectedStateError reports whether err looks like the server refusing
+// previous_response_id rather than a transient or auth failure. A context
+// overflow counts: the stored chain has outgrown the window, and starting a
+// fresh state from the (compacted) messages is the way out.
+func isRejectedStateError(err error) bool {
+	var perr *Error
+	if !errors.As(err, &perr) {
+		return false
+	}
+	if perr.Kind == ErrorKindContextOverflow {
+		return true
+	}
+	switch perr.StatusCode {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity:
+		return true
+	default:
+		return false
+	}
+}
+
+// trimResponsesInputForState drops input items the server already holds when
+// the request continues from previous_response_id. Items after the last
+// assistant output are kept; system and developer messages move to
+// instructions, which the Responses API does not carry between responses.
+func trimResponsesInputForState(body map[string]interface{}) {
+	if prev, _ := body["previous_response_id"].(string); strings.TrimSpace(prev) == "" {
+		return
+	}
+	input, ok := body["input"].([]map[string]interface{})
+	if !ok {
+		return
+	}
+	lastOutput := -1
+	for i, item := range input {
+		if item["role"] == "assistant" || item["type"] == "function_call" {
+			lastOutput = i
+		}
+	}
+
+	var instructions []string
+	trimmed := make([]map[string]interface{}, 0, len(input)-lastOutput-1)
+	for i, item := range input {
+		if role, _ := item["role"].(string); role == "system" || role == "developer" {
+			if text := responsesItemText(item); text != "" {
+				instructions = append(instructions, text)
+			}
+			continue
+		}
+		if i > lastOutput {
+			trimmed = append(trimmed, item)
+		}
+	}
+	body["input"] = trimmed
+	if len(instructions) > 0 {
+		body["instructions"] = strings.Join(instructions, "\n\n")
+	}
+}
+
+func responsesItemText(item map[string]interface{}) string {
+	parts, _ := item["content"].([]map[string]interface{})
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if text, _ := part["text"].(string); strings.TrimSpace(text) != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n")
 }
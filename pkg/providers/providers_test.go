@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -72,6 +73,159 @@ func TestCreateProvider_OpenRouter_DefaultSelection(t *testing.T) {
 	}
 }
 
+func TestCreateProvider_OpenRouter_ResponsesAPIState(t *testing.T) {
+	var requests []map[string]interface{}
+	var paths []string
+	rejectState := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		requests = append(requests, req)
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/chat/completions" {
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"ok"},"finish_reason":"stop"}]}`))
+			return
+		}
+		if _, ok := req["previous_response_id"]; ok && rejectState {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"previous_response_id is not supported"}}`))
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"id":"resp_%d","status":"completed","output":[{"type":"message","role":"assistant","content":[{"type":"output_text","text":"ok"}]}]}`, len(requests))
+	}))
+	defer server.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Providers.OpenRouter.APIKey = "or-key"
+	cfg.Providers.OpenRouter.APIBase = server.URL
+	cfg.Providers.OpenRouter.ResponsesAPI = true
+	cfg.Agents.Defaults.Provider = ProviderOpenRouter
+
+	provider, err := CreateProvider(cfg)
+	if err != nil {
+		t.Fatalf("create provider: %v", err)
+	}
+	stateful, ok := provider.(StatefulLLMProvider)
+	if !ok {
+		t.Fatalf("expected openrouter provider to implement StatefulLLMProvider")
+	}
+
+	history := []Message{
+		{Role: "system", Content: "be brief"},
+		{Role: "user", Content: "first"},
+	}
+	_, state, err := stateful.ChatWithState(context.Background(), "", history, nil, "", nil)
+	if err != nil {
+		t.Fatalf("first chat: %v", err)
+	}
+	if state != "resp_1" || paths[0] != "/responses" {
+		t.Fatalf("expected state resp_1 from /responses, got %q from %q", state, paths[0])
+	}
+	if input := requests[0]["input"].([]interface{}); len(input) != 2 {
+		t.Fatalf("expected full history without state, got %d items", len(input))
+	}
+
+	history = append(history, Message{Role: "assistant", Content: "ok"}, Message{Role: "user", Content: "second"})
+	_, state, err = stateful.ChatWithState(context.Background(), state, history, nil, "", nil)
+	if err != nil {
+		t.Fatalf("second chat: %v", err)
+	}
+	second := requests[1]
+	if second["previous_response_id"] != "resp_1" || state != "resp_2" {
+		t.Fatalf("expected previous_response_id resp_1 and state resp_2, got %v and %q", second["previous_response_id"], state)
+	}
+	input := second["input"].([]interface{})
+	if len(input) != 1 || !strings.Contains(fmt.Sprint(input[0]), "second") {
+		t.Fatalf("expected only the new user message, got %v", input)
+	}
+	if second["instructions"] != "be brief" {
+		t.Fatalf("expected system prompt as instructions, got %v", second["instructions"])
+	}
+
+	rejectState = true
+	history = append(history, Message{Role: "assistant", Content: "ok"}, Message{Role: "user", Content: "third"})
+	_, state, err = stateful.ChatWithState(context.Background(), state, history, nil, "", nil)
+	if err != nil {
+		t.Fatalf("third chat should fall back to full history: %v", err)
+	}
+	if len(requests) != 4 || state != "resp_4" {
+		t.Fatalf("expected a stateless retry, got %d requests and state %q", len(requests), state)
+	}
+	if _, ok := requests[3]["previous_response_id"]; ok {
+		t.Fatalf("expected retry without previous_response_id")
+	}
+	if input := requests[3]["input"].([]interface{}); len(input) != 6 {
+		t.Fatalf("expected full history on retry, got %d items", len(input))
+	}
+
+	if _, _, err := stateful.ChatWithState(context.Background(), state, history, nil, "", nil); err != nil {
+		t.Fatalf("fourth chat: %v", err)
+	}
+	if _, ok := requests[4]["previous_response_id"]; ok {
+		t.Fatalf("expected state to stay disabled after rejection")
+	}
+
+	if _, err := provider.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, "", nil); err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if paths[len(paths)-1] != "/chat/completions" {
+		t.Fatalf("expected stateless Chat over /chat/completions, got %q", paths[len(paths)-1])
+	}
+}
+
+func TestOpenRouterChatWithState_ContextOverflowStartsFreshState(t *testing.T) {
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		requests = append(requests, req)
+		w.Header().Set("Content-Type", "application/json")
+		if req["previous_response_id"] == "resp_long" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"This model's maximum context length is 8192 tokens"}}`))
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"id":"resp_%d","status":"completed","output":[{"type":"message","role":"assistant","content":[{"type":"output_text","text":"ok"}]}]}`, len(requests))
+	}))
+	defer server.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Providers.OpenRouter.APIKey = "or-key"
+	cfg.Providers.OpenRouter.APIBase = server.URL
+	cfg.Providers.OpenRouter.ResponsesAPI = true
+	cfg.Agents.Defaults.Provider = ProviderOpenRouter
+	provider, err := CreateProvider(cfg)
+	if err != nil {
+		t.Fatalf("create provider: %v", err)
+	}
+	stateful := provider.(StatefulLLMProvider)
+
+	history := []Message{{Role: "user", Content: "first"}, {Role: "assistant", Content: "ok"}, {Role: "user", Content: "second"}}
+	_, state, err := stateful.ChatWithState(context.Background(), "resp_long", history, nil, "", nil)
+	if err != nil {
+		t.Fatalf("expected an overflowing state to be dropped and retried: %v", err)
+	}
+	if len(requests) != 2 || state != "resp_2" {
+		t.Fatalf("expected a stateless retry, got %d requests and state %q", len(requests), state)
+	}
+	if _, ok := requests[1]["previous_response_id"]; ok {
+		t.Fatalf("expected retry without previous_response_id")
+	}
+
+	history = append(history, Message{Role: "assistant", Content: "ok"}, Message{Role: "user", Content: "third"})
+	if _, _, err := stateful.ChatWithState(context.Background(), state, history, nil, "", nil); err != nil {
+		t.Fatalf("chat after overflow: %v", err)
+	}
+	if requests[2]["previous_response_id"] != "resp_2" {
+		t.Fatalf("expected state to stay enabled after an overflow, got %v", requests[2]["previous_response_id"])
+	}
+}
+
 func TestCreateProvider_Ollama_NoAuth_DefaultBaseNormalization(t *testing.T) {
 	var seenAuth string
 	var seenPath string
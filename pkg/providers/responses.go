@@ -10,6 +10,8 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+
+	"github.com/dotsetgreg/dotagent/pkg/trace"
 )
 
 type responsesProvider struct {
@@ -153,6 +155,9 @@ func (p *responsesProvider) ChatWithState(ctx context.Context, stateID string, m
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	if id := trace.FromContext(ctx); id != "" {
+		req.Header.Set(trace.HeaderName, id)
+	}
 	if err := p.auth.Apply(ctx, req); err != nil {
 		return nil, "", fmt.Errorf("apply %s auth: %w", p.providerName, err)
 	}
@@ -175,7 +180,7 @@ func (p *responsesProvider) ChatWithState(ctx context.Context, stateID string, m
 		body, _ := io.ReadAll(resp.Body)
 		msg := augmentProviderError(p.providerName, extractAPIError(body))
 		retryAfter := ParseRetryAfterHeader(resp.Header.Get("Retry-After"))
-		return nil, "", NewHTTPError(p.providerName, resp.StatusCode, msg, retryAfter)
+		return nil, "", NewHTTPErrorWithBody(p.providerName, resp.StatusCode, msg, body, retryAfter)
 	}
 
 	var parsed *parsedResponsesResult
@@ -207,6 +212,10 @@ func (p *responsesProvider) ChatWithState(ctx context.Context, stateID string, m
 	return parsed.Response, parsed.ResponseID, nil
 }
 
+func (p *responsesProvider) SupportsStreaming() bool {
+	return true
+}
+
 func (p *responsesProvider) GetDefaultModel() string {
 	if p == nil {
 		return ""
@@ -541,6 +550,13 @@ func buildResponsesInput(messages []Message) []map[string]interface{} {
 			})
 			continue
 		default:
+			if len(msg.ContentBlocks) > 0 && role != "assistant" {
+				out = append(out, map[string]interface{}{
+					"role":    role,
+					"content": toResponsesContentParts(msg.ContentBlocks),
+				})
+				break
+			}
 			content := strings.TrimSpace(msg.Content)
 			if content != "" {
 				contentType := "input_text"
@@ -596,6 +612,27 @@ func buildResponsesInput(messages []Message) []map[string]interface{} {
 	return out
 }
 
+func toResponsesContentParts(blocks []ContentBlock) []map[string]interface{} {
+	parts := make([]map[string]interface{}, 0, len(blocks))
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			if text := strings.TrimSpace(block.Text); text != "" {
+				parts = append(parts, map[string]interface{}{"type": "input_text", "text": text})
+			}
+		case "image_url":
+			if block.ImageURL != nil && block.ImageURL.URL != "" {
+				part := map[string]interface{}{"type": "input_image", "image_url": block.ImageURL.URL}
+				if block.ImageURL.Detail != "" {
+					part["detail"] = block.ImageURL.Detail
+				}
+				parts = append(parts, part)
+			}
+		}
+	}
+	return parts
+}
+
 func toResponsesTools(defs []ToolDefinition) []map[string]interface{} {
 	out := make([]map[string]interface{}, 0, len(defs))
 	for _, def := range defs {
@@ -71,6 +71,35 @@ func TestBuildResponsesInput_AssistantUsesOutputText(t *testing.T) {
 	}
 }
 
+func TestBuildResponsesInput_ImageContentBlocks(t *testing.T) {
+	input := buildResponsesInput([]Message{
+		{
+			Role:    "user",
+			Content: "what is in [image: shot.png]",
+			ContentBlocks: []ContentBlock{
+				{Type: "text", Text: "what is in [image: shot.png]"},
+				{Type: "image_url", ImageURL: &ImageURL{URL: "data:image/png;base64,AAAA"}},
+			},
+		},
+	})
+	if len(input) != 1 {
+		t.Fatalf("expected one input item, got %d", len(input))
+	}
+	content, ok := input[0]["content"].([]map[string]interface{})
+	if !ok || len(content) != 2 {
+		t.Fatalf("expected two content parts, got %#v", input[0]["content"])
+	}
+	if got, _ := content[0]["type"].(string); got != "input_text" {
+		t.Fatalf("expected input_text first, got %q", got)
+	}
+	if got, _ := content[1]["type"].(string); got != "input_image" {
+		t.Fatalf("expected input_image second, got %q", got)
+	}
+	if got, _ := content[1]["image_url"].(string); got != "data:image/png;base64,AAAA" {
+		t.Fatalf("unexpected image_url %q", got)
+	}
+}
+
 func TestBuildResponsesInput_SkipsOrphanToolOutput(t *testing.T) {
 	input := buildResponsesInput([]Message{
 		{Role: "user", Content: "hello"},
@@ -33,6 +33,24 @@ type Message struct {
 	Content    string     `json:"content"`
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string     `json:"tool_call_id,omitempty"`
+	// ContentBlocks, when set, replaces Content on the wire for providers
+	// that accept multi-part input. Content still carries the text-only
+	// form for logging, token estimates and providers without vision.
+	ContentBlocks []ContentBlock `json:"-"`
+}
+
+// ContentBlock is one part of a multi-part message: Type "text" with Text,
+// or Type "image_url" with ImageURL.
+type ContentBlock struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+// ImageURL points at an image, either a remote URL or a base64 data URL.
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
 }
 
 type LLMProvider interface {
@@ -47,6 +65,13 @@ type StatefulLLMProvider interface {
 	ChatWithState(ctx context.Context, stateID string, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, string, error)
 }
 
+// StreamingLLMProvider is an optional extension for providers that can emit
+// incremental text deltas through the "stream_callback" Chat option.
+type StreamingLLMProvider interface {
+	LLMProvider
+	SupportsStreaming() bool
+}
+
 type ToolDefinition struct {
 	Type     string                 `json:"type"`
 	Function ToolFunctionDefinition `json:"function"`
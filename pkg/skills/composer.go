@@ -0,0 +1,159 @@
+package skills
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// minComposedSkillTokens is the smallest share worth inlining; skills that
+// would get less are dropped instead, lowest priority first.
+const minComposedSkillTokens = 64
+
+// SkillComposer inlines skill instructions within a token budget.
+type SkillComposer struct {
+	loader *SkillsLoader
+}
+
+func NewSkillComposer(loader *SkillsLoader) *SkillComposer {
+	return &SkillComposer{loader: loader}
+}
+
+type composedSkill struct {
+	info    SkillInfo
+	content string
+	want    int
+	alloc   int
+}
+
+// Compose returns the instructions of every available skill, highest priority
+// first. Each skill is capped at its front-matter max_tokens. When the total
+// exceeds budget, tokens are shared in proportion to priority, so the lowest
+// priority skills are truncated first, and skills whose share falls below a
+// useful minimum are left out. A budget <= 0 applies only the per-skill caps.
+func (c *SkillComposer) Compose(budget int) string {
+	var items []*composedSkill
+	for _, info := range c.loader.ListSkills() {
+		content, ok := c.loader.LoadSkill(filepath.Base(filepath.Dir(info.Path)))
+		content = strings.TrimSpace(content)
+		if !ok || content == "" {
+			continue
+		}
+		want := estimateSkillTokens(content)
+		if info.MaxTokens > 0 && want > info.MaxTokens {
+			want = info.MaxTokens
+		}
+		items = append(items, &composedSkill{info: info, content: content, want: want})
+	}
+	if len(items) == 0 {
+		return ""
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].info.Priority != items[j].info.Priority {
+			return items[i].info.Priority > items[j].info.Priority
+		}
+		return items[i].info.Name < items[j].info.Name
+	})
+
+	var dropped []string
+	for {
+		allocateSkillTokens(items, budget)
+		drop := -1
+		for i := len(items) - 1; i >= 0; i-- {
+			if items[i].alloc < items[i].want && items[i].alloc < minComposedSkillTokens {
+				drop = i
+				break
+			}
+		}
+		if drop < 0 {
+			break
+		}
+		dropped = append(dropped, items[drop].info.Name)
+		items = append(items[:drop], items[drop+1:]...)
+	}
+
+	var (
+		parts     []string
+		truncated []string
+	)
+	for _, item := range items {
+		content := item.content
+		if estimateSkillTokens(content) > item.alloc {
+			content = truncateSkillContent(content, item.alloc)
+			truncated = append(truncated, item.info.Name)
+		}
+		parts = append(parts, fmt.Sprintf("### Skill: %s\n\n%s", item.info.Name, content))
+	}
+	if len(truncated) > 0 || len(dropped) > 0 {
+		slog.Warn("skills truncated to fit token budget", "budget", budget, "truncated", truncated, "dropped", dropped)
+	}
+	return strings.Join(parts, "\n\n---\n\n")
+}
+
+// allocateSkillTokens water-fills budget across items weighted by priority:
+// skills that want less than their share keep what they want and the surplus
+// is redistributed among the rest.
+func allocateSkillTokens(items []*composedSkill, budget int) {
+	if budget <= 0 {
+		for _, item := range items {
+			item.alloc = item.want
+		}
+		return
+	}
+	active := make([]*composedSkill, len(items))
+	copy(active, items)
+	remaining := budget
+	for len(active) > 0 {
+		totalWeight := 0
+		for _, item := range active {
+			totalWeight += skillWeight(item.info)
+		}
+		var next []*composedSkill
+		satisfied := 0
+		for _, item := range active {
+			if share := remaining * skillWeight(item.info) / totalWeight; item.want <= share {
+				item.alloc = item.want
+				satisfied += item.want
+				continue
+			}
+			next = append(next, item)
+		}
+		if len(next) == len(active) {
+			for _, item := range active {
+				item.alloc = remaining * skillWeight(item.info) / totalWeight
+			}
+			return
+		}
+		remaining -= satisfied
+		active = next
+	}
+}
+
+func skillWeight(info SkillInfo) int {
+	if info.Priority < 1 {
+		return 1
+	}
+	return info.Priority
+}
+
+// estimateSkillTokens matches the agent's prompt section estimate.
+func estimateSkillTokens(text string) int {
+	return len([]rune(text)) * 2 / 5
+}
+
+// truncateSkillContent cuts content to roughly maxTokens, preferring a line
+// break, and marks the cut.
+func truncateSkillContent(content string, maxTokens int) string {
+	runes := []rune(content)
+	limit := maxTokens * 5 / 2
+	if limit >= len(runes) {
+		return content
+	}
+	cut := string(runes[:limit])
+	if i := strings.LastIndex(cut, "\n"); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " \n") + "\n\n[truncated to fit the skill token budget]"
+}
@@ -0,0 +1,89 @@
+package skills
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeComposerTestSkill(t *testing.T, workspace, name, frontmatter string) {
+	t.Helper()
+	dir := filepath.Join(workspace, "skills", name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir skill: %v", err)
+	}
+	body := "# " + name + "\n\n" + strings.Repeat("line of skill text\n", 53)
+	content := "---\nname: " + name + "\ndescription: " + name + " skill\n" + frontmatter + "---\n" + body
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write skill: %v", err)
+	}
+}
+
+func TestSkillsLoader_ListSkills_ParsesBudgetFrontmatter(t *testing.T) {
+	workspace := t.TempDir()
+	writeComposerTestSkill(t, workspace, "gamma", "max_tokens: 50\npriority: 5\n")
+	writeComposerTestSkill(t, workspace, "beta", "priority: nope\n")
+
+	byName := map[string]SkillInfo{}
+	for _, info := range NewSkillsLoader(workspace, "", "").ListSkills() {
+		byName[info.Name] = info
+	}
+	if got := byName["gamma"]; got.MaxTokens != 50 || got.Priority != 5 {
+		t.Fatalf("unexpected gamma metadata: %+v", got)
+	}
+	if got := byName["beta"]; got.MaxTokens != 0 || got.Priority != 0 {
+		t.Fatalf("unexpected beta metadata: %+v", got)
+	}
+}
+
+func TestSkillComposer_Compose(t *testing.T) {
+	workspace := t.TempDir()
+	writeComposerTestSkill(t, workspace, "alpha", "priority: 3\n")
+	writeComposerTestSkill(t, workspace, "beta", "priority: 1\n")
+	writeComposerTestSkill(t, workspace, "gamma", "max_tokens: 50\npriority: 5\n")
+	composer := NewSkillComposer(NewSkillsLoader(workspace, "", ""))
+
+	unbounded := composer.Compose(0)
+	for _, name := range []string{"alpha", "beta", "gamma"} {
+		if !strings.Contains(unbounded, "### Skill: "+name) {
+			t.Fatalf("expected %s in unbounded composition", name)
+		}
+	}
+	if strings.Count(unbounded, "[truncated") != 1 {
+		t.Fatalf("expected only the capped skill truncated:\n%s", unbounded)
+	}
+
+	// gamma keeps its 50-token cap, alpha and beta split the rest 3:1; beta's
+	// share is too small to be useful so it is dropped and alpha gets the rest.
+	composed := composer.Compose(300)
+	if strings.Contains(composed, "### Skill: beta") {
+		t.Fatalf("expected lowest-priority skill dropped:\n%s", composed)
+	}
+	gamma, alpha := strings.Index(composed, "### Skill: gamma"), strings.Index(composed, "### Skill: alpha")
+	if gamma < 0 || alpha < 0 || gamma > alpha {
+		t.Fatalf("expected gamma before alpha:\n%s", composed)
+	}
+	if strings.Count(composed, "[truncated") != 2 {
+		t.Fatalf("expected gamma and alpha truncated:\n%s", composed)
+	}
+	// Headings, separators and truncation markers sit outside the budget.
+	if tokens := estimateSkillTokens(composed); tokens > 360 {
+		t.Fatalf("composition uses %d tokens, budget was 300", tokens)
+	}
+}
+
+func TestAllocateSkillTokens_RedistributesSurplus(t *testing.T) {
+	items := []*composedSkill{
+		{info: SkillInfo{Name: "big", Priority: 1}, want: 500},
+		{info: SkillInfo{Name: "small", Priority: 1}, want: 20},
+		{info: SkillInfo{Name: "heavy", Priority: 2}, want: 500},
+	}
+	allocateSkillTokens(items, 420)
+	if items[1].alloc != 20 {
+		t.Fatalf("small skill should get what it wants, got %d", items[1].alloc)
+	}
+	if items[0].alloc != 133 || items[2].alloc != 266 {
+		t.Fatalf("expected 1:2 split of the remaining 400, got %d and %d", items[0].alloc, items[2].alloc)
+	}
+}
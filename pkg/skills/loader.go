@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -21,6 +22,10 @@ const (
 type SkillMetadata struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	MaxTokens   int    `json:"max_tokens,omitempty"`
+	Priority    int    `json:"priority,omitempty"`
+	// Requires lists the tools the skill expects to be registered.
+	Requires []string `json:"requires,omitempty"`
 }
 
 type SkillInfo struct {
@@ -28,6 +33,25 @@ type SkillInfo struct {
 	Path        string `json:"path"`
 	Source      string `json:"source"`
 	Description string `json:"description"`
+	// MaxTokens caps the skill's share of a composed budget; 0 means no cap.
+	MaxTokens int `json:"max_tokens,omitempty"`
+	// Priority weights the skill's share of a composed budget; higher wins.
+	Priority int `json:"priority,omitempty"`
+	// Requires lists the tool names from the front-matter requires field.
+	Requires []string `json:"requires,omitempty"`
+}
+
+func (info *SkillInfo) applyMetadata(metadata *SkillMetadata) {
+	if metadata == nil {
+		return
+	}
+	info.Description = metadata.Description
+	if strings.TrimSpace(metadata.Name) != "" {
+		info.Name = metadata.Name
+	}
+	info.MaxTokens = metadata.MaxTokens
+	info.Priority = metadata.Priority
+	info.Requires = metadata.Requires
 }
 
 func (info SkillInfo) validate() error {
@@ -81,13 +105,7 @@ func (sl *SkillsLoader) ListSkills() []SkillInfo {
 							Path:   skillFile,
 							Source: "workspace",
 						}
-						metadata := sl.getSkillMetadata(skillFile)
-						if metadata != nil {
-							info.Description = metadata.Description
-							if strings.TrimSpace(metadata.Name) != "" {
-								info.Name = metadata.Name
-							}
-						}
+						info.applyMetadata(sl.getSkillMetadata(skillFile))
 						if err := info.validate(); err != nil {
 							slog.Warn("invalid skill from workspace", "name", info.Name, "error", err)
 							continue
@@ -123,13 +141,7 @@ func (sl *SkillsLoader) ListSkills() []SkillInfo {
 							Path:   skillFile,
 							Source: "global",
 						}
-						metadata := sl.getSkillMetadata(skillFile)
-						if metadata != nil {
-							info.Description = metadata.Description
-							if strings.TrimSpace(metadata.Name) != "" {
-								info.Name = metadata.Name
-							}
-						}
+						info.applyMetadata(sl.getSkillMetadata(skillFile))
 						if err := info.validate(); err != nil {
 							slog.Warn("invalid skill from global", "name", info.Name, "error", err)
 							continue
@@ -164,13 +176,7 @@ func (sl *SkillsLoader) ListSkills() []SkillInfo {
 							Path:   skillFile,
 							Source: "builtin",
 						}
-						metadata := sl.getSkillMetadata(skillFile)
-						if metadata != nil {
-							info.Description = metadata.Description
-							if strings.TrimSpace(metadata.Name) != "" {
-								info.Name = metadata.Name
-							}
-						}
+						info.applyMetadata(sl.getSkillMetadata(skillFile))
 						if err := info.validate(); err != nil {
 							slog.Warn("invalid skill from builtin", "name", info.Name, "error", err)
 							continue
@@ -277,7 +283,66 @@ func (sl *SkillsLoader) getSkillMetadata(skillPath string) *SkillMetadata {
 	return &SkillMetadata{
 		Name:        yamlMeta["name"],
 		Description: description,
+		MaxTokens:   parseNonNegativeInt(yamlMeta["max_tokens"]),
+		Priority:    parseNonNegativeInt(yamlMeta["priority"]),
+		Requires:    parseRequires(frontmatter),
+	}
+}
+
+// parseRequires reads the requires field, written inline ("requires:
+// web_search, web_fetch" or "requires: [web_search]") or as a block list of
+// "- name" lines.
+func parseRequires(frontmatter string) []string {
+	var raw []string
+	inBlock := false
+	for _, line := range strings.Split(frontmatter, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if inBlock {
+			if item, ok := strings.CutPrefix(trimmed, "-"); ok {
+				raw = append(raw, item)
+				continue
+			}
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			break
+		}
+		value, ok := strings.CutPrefix(trimmed, "requires:")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			inBlock = true
+			continue
+		}
+		value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+		raw = append(raw, strings.Split(value, ",")...)
+		break
+	}
+
+	seen := make(map[string]bool, len(raw))
+	requires := make([]string, 0, len(raw))
+	for _, name := range raw {
+		name = strings.Trim(strings.TrimSpace(name), "\"'")
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		requires = append(requires, name)
+	}
+	if len(requires) == 0 {
+		return nil
+	}
+	return requires
+}
+
+func parseNonNegativeInt(raw string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || n < 0 {
+		return 0
 	}
+	return n
 }
 
 func (sl *SkillsLoader) deriveSkillDescription(body string) string {
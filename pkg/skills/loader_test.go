@@ -159,3 +159,31 @@ Run common operational diagnostics safely.
 		t.Fatalf("expected derived description to be non-empty")
 	}
 }
+
+func TestSkillsLoader_ListSkills_ParsesRequires(t *testing.T) {
+	workspace := t.TempDir()
+	files := map[string]string{
+		"inline": "---\ndescription: Inline list\nrequires: web_search, web_fetch\n---\n",
+		"flow":   "---\ndescription: Flow list\nrequires: [\"brave_search\"]\n---\n",
+		"block":  "---\ndescription: Block list\nrequires:\n  - exec\n  - read_file\npriority: 2\n---\n",
+		"none":   "---\ndescription: No requirements\n---\n",
+	}
+	for name, content := range files {
+		dir := filepath.Join(workspace, "skills", name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir skill dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(content), 0o644); err != nil {
+			t.Fatalf("write skill file: %v", err)
+		}
+	}
+
+	got := map[string][]string{}
+	for _, info := range NewSkillsLoader(workspace, "", "").ListSkills() {
+		got[info.Name] = info.Requires
+	}
+	assert.Equal(t, []string{"web_search", "web_fetch"}, got["inline"])
+	assert.Equal(t, []string{"brave_search"}, got["flow"])
+	assert.Equal(t, []string{"exec", "read_file"}, got["block"])
+	assert.Nil(t, got["none"])
+}
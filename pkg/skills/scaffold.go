@@ -0,0 +1,149 @@
+package skills
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SkillTemplates are the body templates available when scaffolding a skill.
+// %s is replaced with the skill name.
+var SkillTemplates = map[string]string{
+	"basic": `# %s
+
+Describe when this skill applies and what the agent should do.
+
+## Instructions
+
+1. First step.
+2. Second step.
+`,
+	"workflow": `# %s
+
+Use this skill when the user asks for the workflow below.
+
+## Steps
+
+1. Confirm the inputs with the user.
+2. Run the work, reporting progress after each stage.
+3. Summarize the result and any follow-ups.
+
+## Failure handling
+
+- If a step fails, stop and explain what went wrong before retrying.
+`,
+	"reference": `# %s
+
+Reference material the agent can consult while answering.
+
+## Key facts
+
+- Fact one.
+- Fact two.
+
+## Examples
+
+` + "```" + `
+example input -> expected output
+` + "```" + `
+`,
+}
+
+// SkillTemplateNames returns the available template names in sorted order.
+func SkillTemplateNames() []string {
+	names := make([]string, 0, len(SkillTemplates))
+	for name := range SkillTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SkillScaffold describes a new workspace skill.
+type SkillScaffold struct {
+	Name        string
+	Description string
+	Triggers    []string
+	Version     string
+	Template    string
+}
+
+// Render returns the SKILL.md content for the scaffold.
+func (s SkillScaffold) Render() (string, error) {
+	info := SkillInfo{Name: s.Name, Description: strings.TrimSpace(s.Description)}
+	if err := info.validate(); err != nil {
+		return "", err
+	}
+	template := s.Template
+	if template == "" {
+		template = "basic"
+	}
+	body, ok := SkillTemplates[template]
+	if !ok {
+		return "", fmt.Errorf("unknown template %q (available: %s)", template, strings.Join(SkillTemplateNames(), ", "))
+	}
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "name: %s\n", s.Name)
+	fmt.Fprintf(&b, "description: %q\n", info.Description)
+	if version := strings.TrimSpace(s.Version); version != "" {
+		fmt.Fprintf(&b, "version: %s\n", version)
+	}
+	var triggers []string
+	for _, trigger := range s.Triggers {
+		if trigger = strings.TrimSpace(trigger); trigger != "" {
+			triggers = append(triggers, trigger)
+		}
+	}
+	if len(triggers) > 0 {
+		fmt.Fprintf(&b, "triggers: %q\n", strings.Join(triggers, ", "))
+	}
+	b.WriteString("---\n\n")
+	fmt.Fprintf(&b, body, s.Name)
+	return b.String(), nil
+}
+
+// CreateWorkspaceSkill writes the scaffold to workspace/skills/<name>/SKILL.md
+// and returns the file path. It refuses to overwrite an existing skill.
+func CreateWorkspaceSkill(workspace string, s SkillScaffold) (string, error) {
+	content, err := s.Render()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(workspace, "skills", s.Name)
+	path := filepath.Join(dir, "SKILL.md")
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("skill '%s' already exists at %s", s.Name, path)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create skill directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return "", fmt.Errorf("write skill: %w", err)
+	}
+	return path, nil
+}
+
+// ValidateSkill checks a workspace skill the way the loader does when listing
+// skills, and additionally reports an empty body.
+func (sl *SkillsLoader) ValidateSkill(name string) error {
+	path := filepath.Join(sl.workspaceSkills, name, "SKILL.md")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	info := SkillInfo{Name: name}
+	info.applyMetadata(sl.getSkillMetadata(path))
+	errs := info.validate()
+	if info.Name != name {
+		errs = errors.Join(errs, fmt.Errorf("front-matter name %q does not match directory %q", info.Name, name))
+	}
+	if strings.TrimSpace(sl.stripFrontmatter(string(content))) == "" {
+		errs = errors.Join(errs, errors.New("skill body is empty"))
+	}
+	return errs
+}
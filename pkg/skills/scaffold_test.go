@@ -0,0 +1,72 @@
+package skills
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateWorkspaceSkill(t *testing.T) {
+	workspace := t.TempDir()
+	path, err := CreateWorkspaceSkill(workspace, SkillScaffold{
+		Name:        "deploy-helper",
+		Description: "Guide production deploys",
+		Triggers:    []string{"deploy", " rollback ", ""},
+		Version:     "1.2.0",
+		Template:    "workflow",
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkspaceSkill: %v", err)
+	}
+	if path != filepath.Join(workspace, "skills", "deploy-helper", "SKILL.md") {
+		t.Fatalf("unexpected path %s", path)
+	}
+	raw, _ := os.ReadFile(path)
+	content := string(raw)
+	for _, want := range []string{"name: deploy-helper\n", "version: 1.2.0\n", `triggers: "deploy, rollback"`, "# deploy-helper", "## Steps"} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("expected %q in skill file:\n%s", want, content)
+		}
+	}
+
+	loader := NewSkillsLoader(workspace, "", "")
+	if err := loader.ValidateSkill("deploy-helper"); err != nil {
+		t.Fatalf("expected scaffolded skill to validate, got %v", err)
+	}
+	listed := loader.ListSkills()
+	if len(listed) != 1 || listed[0].Description != "Guide production deploys" {
+		t.Fatalf("expected loader to pick up the new skill, got %+v", listed)
+	}
+
+	if _, err := CreateWorkspaceSkill(workspace, SkillScaffold{Name: "deploy-helper", Description: "again"}); err == nil {
+		t.Fatal("expected existing skill to be refused")
+	}
+}
+
+func TestSkillScaffold_RenderRejectsInvalidInput(t *testing.T) {
+	if _, err := (SkillScaffold{Name: "bad name", Description: "x"}).Render(); err == nil {
+		t.Fatal("expected invalid name to be rejected")
+	}
+	if _, err := (SkillScaffold{Name: "ok"}).Render(); err == nil {
+		t.Fatal("expected missing description to be rejected")
+	}
+	if _, err := (SkillScaffold{Name: "ok", Description: "x", Template: "nope"}).Render(); err == nil {
+		t.Fatal("expected unknown template to be rejected")
+	}
+}
+
+func TestSkillsLoader_ValidateSkill(t *testing.T) {
+	workspace := t.TempDir()
+	dir := filepath.Join(workspace, "skills", "empty")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("---\nname: other\ndescription: x\n---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := NewSkillsLoader(workspace, "", "").ValidateSkill("empty")
+	if err == nil || !strings.Contains(err.Error(), "does not match") || !strings.Contains(err.Error(), "body is empty") {
+		t.Fatalf("expected name mismatch and empty body errors, got %v", err)
+	}
+}
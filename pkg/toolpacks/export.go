@@ -0,0 +1,192 @@
+package toolpacks
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// manifestChecksumFile holds the SHA-256 of toolpack.json in an exported
+// archive, in sha256sum format.
+const manifestChecksumFile = "MANIFEST_SHA256.txt"
+
+// Export writes the installed toolpack id to dst as a ZIP that
+// InstallFromPath accepts. Entries sit under a top-level directory named
+// after the pack; lock files are left out and a manifest checksum is added.
+func (m *Manager) Export(id, dst string) (Manifest, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return Manifest{}, fmt.Errorf("toolpack id is required")
+	}
+	dst = strings.TrimSpace(dst)
+	if dst == "" {
+		return Manifest{}, fmt.Errorf("output path is required")
+	}
+	packDir := filepath.Join(m.rootDir, filepath.Base(id))
+	manifestPath := filepath.Join(packDir, manifestFile)
+	manifest, err := readManifest(manifestPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Manifest{}, fmt.Errorf("toolpack %s not found", id)
+		}
+		return Manifest{}, fmt.Errorf("read manifest: %w", err)
+	}
+	if err := validateManifest(&manifest); err != nil {
+		return Manifest{}, fmt.Errorf("validate manifest: %w", err)
+	}
+	manifestData, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return Manifest{}, fmt.Errorf("create output dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".toolpack-export-*")
+	if err != nil {
+		return Manifest{}, err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	prefix := filepath.Base(manifest.ID) + "/"
+	zw := zip.NewWriter(tmp)
+	walkErr := filepath.Walk(packDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("symlinks are not supported in toolpacks: %s", path)
+		}
+		rel, err := filepath.Rel(packDir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if !info.IsDir() && (info.Name() == lockFile || rel == manifestChecksumFile) {
+			return nil
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = prefix + filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+			_, err = zw.CreateHeader(header)
+			return err
+		}
+		header.Method = zip.Deflate
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		_, err = io.Copy(w, in)
+		return err
+	})
+	if walkErr == nil {
+		sum := sha256.Sum256(manifestData)
+		var w io.Writer
+		if w, walkErr = zw.Create(prefix + manifestChecksumFile); walkErr == nil {
+			_, walkErr = fmt.Fprintf(w, "%s  %s\n", hex.EncodeToString(sum[:]), manifestFile)
+		}
+	}
+	if closeErr := zw.Close(); walkErr == nil {
+		walkErr = closeErr
+	}
+	if closeErr := tmp.Close(); walkErr == nil {
+		walkErr = closeErr
+	}
+	if walkErr != nil {
+		return Manifest{}, fmt.Errorf("write archive: %w", walkErr)
+	}
+	if err := os.Rename(tmpPath, dst); err != nil {
+		return Manifest{}, err
+	}
+	return manifest, nil
+}
+
+// installFromZip installs the toolpack packed in the ZIP at path, checking
+// the manifest against MANIFEST_SHA256.txt when the archive has one.
+func (m *Manager) installFromZip(path string) (Manifest, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("open toolpack zip: %w", err)
+	}
+	root, err := extractToolpackZip(&zr.Reader)
+	_ = zr.Close()
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer os.RemoveAll(root)
+
+	manifestPath, err := selectToolpackManifestPath(root)
+	if err != nil {
+		return Manifest{}, err
+	}
+	srcDir := filepath.Dir(manifestPath)
+	if err := verifyManifestChecksum(srcDir); err != nil {
+		return Manifest{}, err
+	}
+	manifest, err := readManifest(manifestPath)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("read archive manifest: %w", err)
+	}
+	if err := validateManifest(&manifest); err != nil {
+		return Manifest{}, fmt.Errorf("validate archive manifest: %w", err)
+	}
+	targetDir := filepath.Join(m.rootDir, filepath.Base(manifest.ID))
+	if err := os.RemoveAll(targetDir); err != nil {
+		return Manifest{}, fmt.Errorf("clear target dir: %w", err)
+	}
+	if err := copyDir(srcDir, targetDir); err != nil {
+		return Manifest{}, fmt.Errorf("copy toolpack from zip: %w", err)
+	}
+	if err := m.updateLock(manifest, "zip:"+path, filepath.Join(targetDir, manifestFile)); err != nil {
+		return Manifest{}, err
+	}
+	m.invalidateSchemaCache(manifest.ID)
+	return manifest, nil
+}
+
+func verifyManifestChecksum(dir string) error {
+	raw, err := os.ReadFile(filepath.Join(dir, manifestChecksumFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	want := ""
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == manifestFile {
+			want = strings.ToLower(fields[0])
+			break
+		}
+	}
+	if want == "" {
+		return fmt.Errorf("%s has no entry for %s", manifestChecksumFile, manifestFile)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("manifest checksum mismatch: %s records %s, got %s", manifestChecksumFile, want, got)
+	}
+	return nil
+}
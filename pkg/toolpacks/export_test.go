@@ -0,0 +1,107 @@
+package toolpacks
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManager_ExportRoundTripsThroughInstall(t *testing.T) {
+	workspace := t.TempDir()
+	src := filepath.Join(workspace, "src-pack")
+	if err := os.MkdirAll(filepath.Join(src, "scripts"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	manifest := Manifest{
+		ID:      "share-pack",
+		Name:    "Share Pack",
+		Version: "1.2.0",
+		Enabled: true,
+		Tools: []ManifestTool{
+			{Name: "say_hi", Type: "command", Description: "say hi", CommandTemplate: "sh scripts/hi.sh"},
+		},
+	}
+	raw, _ := json.MarshalIndent(manifest, "", "  ")
+	if err := os.WriteFile(filepath.Join(src, "toolpack.json"), raw, 0o644); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "scripts", "hi.sh"), []byte("echo hi\n"), 0o755); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "lock.json"), []byte("[]"), 0o644); err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+
+	mgr := NewManager(workspace, false)
+	if _, err := mgr.InstallFromPath(src); err != nil {
+		t.Fatalf("install from path: %v", err)
+	}
+	zipPath := filepath.Join(t.TempDir(), "share-pack.zip")
+	if _, err := mgr.Export("share-pack", zipPath); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	names := map[string]bool{}
+	for _, f := range zr.File {
+		names[f.Name] = true
+	}
+	_ = zr.Close()
+	for _, want := range []string{"share-pack/toolpack.json", "share-pack/scripts/hi.sh", "share-pack/MANIFEST_SHA256.txt"} {
+		if !names[want] {
+			t.Fatalf("expected %s in archive, got %v", want, names)
+		}
+	}
+	if names["share-pack/lock.json"] {
+		t.Fatal("expected lock.json to be left out of the archive")
+	}
+
+	other := NewManager(t.TempDir(), false)
+	pack, err := other.InstallFromPath(zipPath)
+	if err != nil {
+		t.Fatalf("install from zip: %v", err)
+	}
+	if pack.ID != "share-pack" || pack.Version != "1.2.0" {
+		t.Fatalf("unexpected installed manifest: %+v", pack)
+	}
+	if _, err := os.Stat(filepath.Join(other.RootDir(), "share-pack", "scripts", "hi.sh")); err != nil {
+		t.Fatalf("expected script to be installed: %v", err)
+	}
+	lock, ok, err := other.GetLock("share-pack")
+	if err != nil || !ok || !strings.HasPrefix(lock.Source, "zip:") {
+		t.Fatalf("expected zip lock source, got %+v ok=%v err=%v", lock, ok, err)
+	}
+}
+
+func TestManager_InstallFromZipRejectsChecksumMismatch(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "tampered.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("create zip: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	raw, _ := json.Marshal(Manifest{ID: "tampered", Name: "Tampered", Version: "1.0.0"})
+	for name, content := range map[string]string{
+		"tampered/toolpack.json":       string(raw),
+		"tampered/MANIFEST_SHA256.txt": strings.Repeat("0", 64) + "  toolpack.json\n",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip entry: %v", err)
+		}
+		_, _ = w.Write([]byte(content))
+	}
+	_ = zw.Close()
+	_ = f.Close()
+
+	_, err = NewManager(t.TempDir(), false).InstallFromPath(zipPath)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+}
@@ -0,0 +1,341 @@
+package toolpacks
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// lintPlaceholderRegex matches the {{name}} placeholders rendered by command
+// tools. It mirrors the pattern used by the template command tool.
+var lintPlaceholderRegex = regexp.MustCompile(`^\{\{\s*([a-zA-Z0-9_]+)\s*\}\}`)
+
+var lintAssignmentRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// LintWarning is a potential security issue in a command tool's template.
+// Line and Column are 1-based positions within the template.
+type LintWarning struct {
+	PackID  string
+	Tool    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (w LintWarning) String() string {
+	return fmt.Sprintf("%s/%s:%d:%d: %s", w.PackID, w.Tool, w.Line, w.Column, w.Message)
+}
+
+// Lint statically checks the command templates of installed toolpacks, or of
+// the toolpack with the given id, for shell constructs that are easy to abuse.
+// Manifests that fail to load are reported by Validate and skipped here.
+func (m *Manager) Lint(id string) ([]LintWarning, error) {
+	manifests, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	id = strings.TrimSpace(id)
+	found := id == ""
+	warnings := []LintWarning{}
+	for _, manifest := range manifests {
+		if id != "" && manifest.ID != id {
+			continue
+		}
+		found = true
+		for _, tool := range manifest.Tools {
+			if tool.Type != "command" {
+				continue
+			}
+			for _, w := range LintCommandTemplate(tool.CommandTemplate) {
+				w.PackID = manifest.ID
+				w.Tool = tool.Name
+				warnings = append(warnings, w)
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("toolpack %q not found", id)
+	}
+	return warnings, nil
+}
+
+// LintCommandTemplate reports unquoted shell variables and command
+// substitutions, eval, quoted or command-position placeholders, and redirects
+// or pipes without a command on both sides. Placeholders are shell-quoted when
+// rendered, so wrapping them in quotes of their own undoes that protection.
+func LintCommandTemplate(template string) []LintWarning {
+	l := &templateLinter{src: []rune(template), line: 1, col: 1, cmdPos: true}
+	l.run()
+	return l.warnings
+}
+
+type templateLinter struct {
+	src       []rune
+	i         int
+	line, col int
+	warnings  []LintWarning
+
+	quote               rune
+	quoteLine, quoteCol int
+	inWord              bool
+	inBacktick          bool
+	cmdPos              bool
+
+	// redirect is the pending redirect operator awaiting its target.
+	redirect                  string
+	redirectLine, redirectCol int
+	// pipe is the last control operator when no command has followed it yet.
+	pipe              string
+	pipeLine, pipeCol int
+}
+
+func (l *templateLinter) warn(line, col int, format string, args ...interface{}) {
+	l.warnings = append(l.warnings, LintWarning{Line: line, Column: col, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *templateLinter) advance(n int) {
+	for k := 0; k < n && l.i < len(l.src); k++ {
+		if l.src[l.i] == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+		l.i++
+	}
+}
+
+func (l *templateLinter) peek(offset int) rune {
+	if l.i+offset >= len(l.src) {
+		return 0
+	}
+	return l.src[l.i+offset]
+}
+
+func (l *templateLinter) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(string(l.src[l.i:]), prefix)
+}
+
+// startWord records that an unquoted word begins at the current position and
+// returns whether it is in command position.
+func (l *templateLinter) startWord() (cmdPos bool, redirect string) {
+	if l.inWord {
+		return false, ""
+	}
+	l.inWord = true
+	cmdPos, redirect = l.cmdPos, l.redirect
+	l.cmdPos = false
+	l.redirect = ""
+	l.pipe = ""
+	return cmdPos, redirect
+}
+
+func (l *templateLinter) run() {
+	for l.i < len(l.src) {
+		line, col := l.line, l.col
+		r := l.src[l.i]
+
+		if m := lintPlaceholderRegex.FindStringSubmatch(string(l.src[l.i:])); m != nil {
+			l.placeholder(m[0], line, col)
+			continue
+		}
+
+		switch l.quote {
+		case '\'':
+			if r == '\'' {
+				l.quote = 0
+			}
+			l.advance(1)
+			continue
+		case '"':
+			switch r {
+			case '"':
+				l.quote = 0
+				l.advance(1)
+			case '\\':
+				l.advance(2)
+			case '$':
+				l.dollar(line, col)
+			default:
+				l.advance(1)
+			}
+			continue
+		}
+
+		switch {
+		case r == ' ' || r == '\t' || r == '\r':
+			l.inWord = false
+			l.advance(1)
+		case r == '\n':
+			l.inWord = false
+			l.cmdPos = true
+			l.advance(1)
+		case r == '\\':
+			l.startWord()
+			l.advance(2)
+		case r == '\'' || r == '"':
+			l.startWord()
+			l.quote, l.quoteLine, l.quoteCol = r, line, col
+			l.advance(1)
+		case r == '`':
+			l.startWord()
+			if !l.inBacktick {
+				l.warn(line, col, "unquoted backtick command substitution; use \"$(...)\" instead")
+			}
+			l.inBacktick = !l.inBacktick
+			l.advance(1)
+		case r == '$':
+			l.dollar(line, col)
+		case r == '|' || r == '&' || r == ';' || r == '>' || r == '<' || r == '(' || r == ')':
+			l.operator(line, col)
+		default:
+			l.word(line, col)
+		}
+	}
+
+	if l.quote != 0 {
+		l.warn(l.quoteLine, l.quoteCol, "unterminated %c quote", l.quote)
+	}
+	if l.redirect != "" {
+		l.warn(l.redirectLine, l.redirectCol, "redirect %q has no target", l.redirect)
+	}
+	if l.pipe != "" {
+		l.warn(l.pipeLine, l.pipeCol, "operator %q is not followed by a command", l.pipe)
+	}
+}
+
+func (l *templateLinter) placeholder(match string, line, col int) {
+	name := strings.TrimSpace(strings.Trim(match, "{}"))
+	switch l.quote {
+	case '\'':
+		l.warn(line, col, "placeholder {{%s}} is inside single quotes; placeholders are already quoted and the extra quotes leave the value unquoted", name)
+	case '"':
+		l.warn(line, col, "placeholder {{%s}} is inside double quotes; the value can still trigger $ and backtick expansion", name)
+	default:
+		cmdPos, redirect := l.startWord()
+		if cmdPos {
+			l.warn(line, col, "placeholder {{%s}} is used as the command name", name)
+		}
+		if redirect != "" {
+			l.warn(line, col, "redirect %q writes to a path taken from placeholder {{%s}}", redirect, name)
+		}
+	}
+	l.advance(len([]rune(match)))
+}
+
+func (l *templateLinter) dollar(line, col int) {
+	next := l.peek(1)
+	switch {
+	case next == '(' && l.peek(2) == '(':
+		// Arithmetic expansion cannot run commands.
+		if l.quote == 0 {
+			l.startWord()
+		}
+		l.advance(3)
+	case next == '(':
+		if l.quote == 0 {
+			l.startWord()
+			l.warn(line, col, "unquoted command substitution $(...); wrap it in double quotes")
+		}
+		l.advance(2)
+		l.inWord = false
+		l.cmdPos = true
+	case next == '{' || next == '_' || next == '@' || next == '*' || isASCIILetter(next) || (next >= '0' && next <= '9'):
+		name := l.variableName()
+		if l.quote == 0 {
+			l.startWord()
+			l.warn(line, col, "unquoted variable %s; wrap it in double quotes", name)
+		}
+		l.advance(len([]rune(name)))
+	default:
+		if l.quote == 0 {
+			l.startWord()
+		}
+		l.advance(1)
+	}
+}
+
+// variableName returns the $NAME or ${...} reference at the current position.
+func (l *templateLinter) variableName() string {
+	rest := l.src[l.i:]
+	if len(rest) > 1 && rest[1] == '{' {
+		for j := 2; j < len(rest); j++ {
+			if rest[j] == '}' {
+				return string(rest[:j+1])
+			}
+		}
+		return string(rest)
+	}
+	if len(rest) > 1 && (rest[1] == '@' || rest[1] == '*' || (rest[1] >= '0' && rest[1] <= '9')) {
+		return string(rest[:2])
+	}
+	j := 1
+	for j < len(rest) && (rest[j] == '_' || isASCIILetter(rest[j]) || (rest[j] >= '0' && rest[j] <= '9')) {
+		j++
+	}
+	return string(rest[:j])
+}
+
+func (l *templateLinter) operator(line, col int) {
+	l.inWord = false
+	for _, op := range []string{"&&", "||", "|&", ">>", ">&", "&>", "<<", "|", "&", ";", ">", "<", "(", ")"} {
+		if !l.hasPrefix(op) {
+			continue
+		}
+		l.advance(len(op))
+		switch op {
+		case ">>", ">&", "&>", "<<", ">", "<":
+			if l.redirect != "" {
+				l.warn(line, col, "redirect %q follows redirect %q without a target", op, l.redirect)
+			}
+			l.redirect, l.redirectLine, l.redirectCol = op, line, col
+		case "(":
+			l.cmdPos = true
+		case ")":
+			l.cmdPos = false
+		default:
+			if l.cmdPos || l.redirect != "" {
+				l.warn(line, col, "operator %q has no command before it", op)
+			}
+			l.redirect = ""
+			l.cmdPos = true
+			if op != ";" && op != "&" {
+				l.pipe, l.pipeLine, l.pipeCol = op, line, col
+			}
+		}
+		return
+	}
+}
+
+func (l *templateLinter) word(line, col int) {
+	start := l.i
+	j := l.i
+	for j < len(l.src) && !isWordBreak(l.src[j]) && !strings.HasPrefix(string(l.src[j:]), "{{") {
+		j++
+	}
+	text := string(l.src[start:j])
+	cmdPos, _ := l.startWord()
+	if cmdPos {
+		switch {
+		case text == "eval" && (j == len(l.src) || l.src[j] == ' ' || l.src[j] == '\t'):
+			l.warn(line, col, "eval runs its arguments as shell code")
+		case lintAssignmentRegex.MatchString(text):
+			// A leading VAR=value assignment keeps the next word in
+			// command position.
+			l.cmdPos = true
+			l.inWord = false
+		}
+	}
+	l.advance(j - start)
+	if j == start {
+		l.advance(1)
+	}
+}
+
+func isWordBreak(r rune) bool {
+	return strings.ContainsRune(" \t\r\n|&;<>()'\"`$\\", r)
+}
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
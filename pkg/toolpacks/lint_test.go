@@ -0,0 +1,90 @@
+package toolpacks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintCommandTemplate(t *testing.T) {
+	cases := []struct {
+		name     string
+		template string
+		want     []string // message substrings, in order
+	}{
+		{name: "clean", template: "gh issue list --state open --limit {{limit}} | head -n 20 > /dev/null 2>&1"},
+		{name: "quoted variable", template: `echo "$HOME" "${USER}" "$(date)" $((1 + 2))`},
+		{name: "unquoted variable", template: "ls $HOME ${TARGET}", want: []string{"unquoted variable $HOME", "unquoted variable ${TARGET}"}},
+		{name: "command substitution", template: "echo $(cat {{file}})", want: []string{"unquoted command substitution"}},
+		{name: "backtick", template: "echo `date`", want: []string{"backtick"}},
+		{name: "eval", template: "FOO=1 eval {{script}}", want: []string{"eval runs"}},
+		{name: "quoted placeholders", template: `grep '{{pattern}}' "{{file}}"`, want: []string{"inside single quotes", "inside double quotes"}},
+		{name: "placeholder command", template: "{{cmd}} --help | {{filter}}", want: []string{"used as the command name", "used as the command name"}},
+		{name: "redirect target", template: "cat notes.txt > {{path}}", want: []string{"writes to a path taken from placeholder {{path}}"}},
+		{name: "dangling operators", template: "| grep x >", want: []string{"has no command before it", "has no target"}},
+		{name: "trailing pipe", template: "ls |", want: []string{"is not followed by a command"}},
+		{name: "unterminated quote", template: "echo 'oops", want: []string{"unterminated ' quote"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := LintCommandTemplate(tc.template)
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %d warnings, got %+v", len(tc.want), got)
+			}
+			for i, w := range tc.want {
+				if !strings.Contains(got[i].Message, w) {
+					t.Fatalf("warning %d: expected %q, got %q", i, w, got[i].Message)
+				}
+			}
+		})
+	}
+}
+
+func TestLintCommandTemplate_ReportsLineAndColumn(t *testing.T) {
+	got := LintCommandTemplate("set -e\ncd /tmp && rm -rf $DIR")
+	if len(got) != 1 {
+		t.Fatalf("expected one warning, got %+v", got)
+	}
+	if got[0].Line != 2 || got[0].Column != 19 {
+		t.Fatalf("expected warning at 2:19, got %d:%d", got[0].Line, got[0].Column)
+	}
+}
+
+func TestManager_Lint(t *testing.T) {
+	workspace := t.TempDir()
+	packDir := filepath.Join(workspace, "toolpacks", "lint-pack")
+	if err := os.MkdirAll(packDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	manifest := Manifest{
+		ID:      "lint-pack",
+		Name:    "Lint Pack",
+		Version: "1.0.0",
+		Enabled: true,
+		Tools: []ManifestTool{
+			{Name: "safe_tool", CommandTemplate: "echo {{text}}"},
+			{Name: "risky_tool", CommandTemplate: "eval \"{{script}}\""},
+		},
+	}
+	raw, _ := json.MarshalIndent(manifest, "", "  ")
+	if err := os.WriteFile(filepath.Join(packDir, "toolpack.json"), raw, 0o644); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+
+	mgr := NewManager(workspace, false)
+	warnings, err := mgr.Lint("")
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("expected two warnings, got %+v", warnings)
+	}
+	if got := warnings[0].String(); got != "lint-pack/risky_tool:1:1: eval runs its arguments as shell code" {
+		t.Fatalf("unexpected warning: %q", got)
+	}
+	if _, err := mgr.Lint("missing-pack"); err == nil {
+		t.Fatalf("expected unknown toolpack to fail")
+	}
+}
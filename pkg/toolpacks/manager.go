@@ -100,9 +100,10 @@ type LockEntry struct {
 }
 
 type Manager struct {
-	workspace string
-	rootDir   string
-	restrict  bool
+	workspace      string
+	rootDir        string
+	restrict       bool
+	schemaCacheTTL time.Duration
 }
 
 type connectorInvokerAdapter struct {
@@ -251,6 +252,13 @@ func (m *Manager) LoadEnabledTools() ([]tools.Tool, error) {
 	registered := make([]tools.Tool, 0, len(manifests))
 	loadedNames := map[string]string{}
 	warnings := make([]string, 0)
+	var cache *schemaCache
+	if m.schemaCacheTTL > 0 && discoversMCPSchemas(manifests) {
+		// Loading works without the cache, so an unusable one is skipped.
+		if cache, err = openSchemaCache(m.rootDir); err == nil {
+			defer cache.Close()
+		}
+	}
 	for _, manifest := range manifests {
 		if !manifest.Enabled {
 			continue
@@ -322,9 +330,7 @@ func (m *Manager) LoadEnabledTools() ([]tools.Tool, error) {
 				desc := strings.TrimSpace(mt.Description)
 				params := mt.Parameters
 				if params == nil || len(params) == 0 {
-					schemaCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-					autoDesc, autoParams, schemaErr := runtime.ToolSchema(schemaCtx, target)
-					cancel()
+					autoDesc, autoParams, schemaErr := m.toolSchema(cache, manifest.ID, connectorID, toolType, runtime, target)
 					if schemaErr != nil {
 						warnings = append(warnings, fmt.Sprintf("%s: could not load schema for %s:%s (%v)", manifest.ID, connectorID, target, schemaErr))
 						continue
@@ -359,6 +365,46 @@ func (m *Manager) LoadEnabledTools() ([]tools.Tool, error) {
 	return registered, nil
 }
 
+// discoversMCPSchemas reports whether any enabled MCP tool relies on the
+// connector for its parameters.
+func discoversMCPSchemas(manifests []Manifest) bool {
+	for _, manifest := range manifests {
+		if !manifest.Enabled {
+			continue
+		}
+		for _, mt := range manifest.Tools {
+			if strings.EqualFold(strings.TrimSpace(mt.Type), "mcp") && len(mt.Parameters) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// toolSchema asks the connector for a tool's schema. MCP schemas are served
+// from cache when it holds a fresh entry, since discovery is a round trip to
+// the server on every load.
+func (m *Manager) toolSchema(cache *schemaCache, packID, connectorID, toolType string, runtime connectors.Runtime, target string) (string, map[string]interface{}, error) {
+	if toolType != "mcp" {
+		cache = nil
+	}
+	if cache != nil {
+		if cached, ok, err := cache.Get(packID, connectorID, target, m.schemaCacheTTL); err == nil && ok {
+			return cached.Description, cached.Parameters, nil
+		}
+	}
+	schemaCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	desc, params, err := runtime.ToolSchema(schemaCtx, target)
+	if err != nil {
+		return "", nil, err
+	}
+	if cache != nil {
+		_ = cache.Put(packID, connectorID, target, cachedSchema{Description: desc, Parameters: params})
+	}
+	return desc, params, nil
+}
+
 func defaultParameters(params map[string]interface{}) map[string]interface{} {
 	if params != nil {
 		return params
@@ -697,6 +743,7 @@ func (m *Manager) Enable(id string, enabled bool) error {
 	if err := m.updateLock(manifest, "local:"+manifestPath, manifestPath); err != nil {
 		return fmt.Errorf("update lock %s: %w", id, err)
 	}
+	m.invalidateSchemaCache(manifest.ID)
 	return nil
 }
 
@@ -712,9 +759,12 @@ func (m *Manager) Remove(id string) error {
 	if err := os.RemoveAll(dir); err != nil {
 		return err
 	}
+	m.invalidateSchemaCache(id)
 	return m.removeLock(id)
 }
 
+// InstallFromPath installs a toolpack from a directory or from a ZIP written
+// by Export.
 func (m *Manager) InstallFromPath(src string) (Manifest, error) {
 	src = strings.TrimSpace(src)
 	if src == "" {
@@ -724,6 +774,9 @@ func (m *Manager) InstallFromPath(src string) (Manifest, error) {
 	if err != nil {
 		return Manifest{}, err
 	}
+	if fi, statErr := os.Stat(srcAbs); statErr == nil && fi.Mode().IsRegular() {
+		return m.installFromZip(srcAbs)
+	}
 	manifest, err := readManifest(filepath.Join(srcAbs, manifestFile))
 	if err != nil {
 		return Manifest{}, fmt.Errorf("read source manifest: %w", err)
@@ -741,6 +794,7 @@ func (m *Manager) InstallFromPath(src string) (Manifest, error) {
 	if err := m.updateLock(manifest, "path:"+srcAbs, filepath.Join(targetDir, manifestFile)); err != nil {
 		return Manifest{}, err
 	}
+	m.invalidateSchemaCache(manifest.ID)
 	return manifest, nil
 }
 
@@ -787,6 +841,7 @@ func (m *Manager) InstallFromGitHub(ctx context.Context, repo string) (Manifest,
 	if err := m.updateLock(manifest, source, targetManifestPath); err != nil {
 		return Manifest{}, err
 	}
+	m.invalidateSchemaCache(manifest.ID)
 	return manifest, nil
 }
 
@@ -910,7 +965,14 @@ func downloadAndExtractGitHubRepo(ctx context.Context, repo, ref string) (string
 	if err != nil {
 		return "", fmt.Errorf("open github archive zip: %w", err)
 	}
-	root, err := os.MkdirTemp("", "dotagent-toolpack-github-*")
+	return extractToolpackZip(zr)
+}
+
+// extractToolpackZip unpacks zr into a new temporary directory, dropping the
+// archive's top-level directory the way GitHub and Export lay it out. The
+// caller removes the returned directory.
+func extractToolpackZip(zr *zip.Reader) (string, error) {
+	root, err := os.MkdirTemp("", "dotagent-toolpack-zip-*")
 	if err != nil {
 		return "", err
 	}
@@ -927,7 +989,7 @@ func downloadAndExtractGitHubRepo(ctx context.Context, repo, ref string) (string
 		mode := zf.Mode()
 		if mode&os.ModeSymlink != 0 {
 			_ = os.RemoveAll(root)
-			return "", fmt.Errorf("symlinks are not allowed in github toolpack archives: %s", zf.Name)
+			return "", fmt.Errorf("symlinks are not allowed in toolpack archives: %s", zf.Name)
 		}
 		if zf.FileInfo().IsDir() {
 			if err := os.MkdirAll(target, 0o755); err != nil {
@@ -1080,7 +1142,7 @@ func selectToolpackManifestPath(repoRoot string) (string, error) {
 		return "", err
 	}
 	if len(candidates) == 0 {
-		return "", fmt.Errorf("github archive did not contain %s", manifestFile)
+		return "", fmt.Errorf("archive did not contain %s", manifestFile)
 	}
 	rootManifest := filepath.Join(repoRoot, manifestFile)
 	for _, candidate := range candidates {
@@ -0,0 +1,123 @@
+package toolpacks
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const schemaCacheFile = "schema_cache.db"
+
+// schemaCache stores tool schemas discovered from connectors so that reloads
+// do not have to ask the connector again. It lives next to lock.json in the
+// toolpacks root.
+type schemaCache struct {
+	db *sql.DB
+}
+
+type cachedSchema struct {
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+func openSchemaCache(rootDir string) (*schemaCache, error) {
+	if err := os.MkdirAll(rootDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create toolpacks root: %w", err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(rootDir, schemaCacheFile))
+	if err != nil {
+		return nil, fmt.Errorf("open schema cache: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS connector_schema_cache (
+	pack_id TEXT NOT NULL,
+	connector_id TEXT NOT NULL,
+	tool_name TEXT NOT NULL,
+	schema_json TEXT NOT NULL,
+	cached_at_ms INTEGER NOT NULL,
+	PRIMARY KEY (pack_id, connector_id, tool_name)
+)`); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("init schema cache: %w", err)
+	}
+	return &schemaCache{db: db}, nil
+}
+
+func (c *schemaCache) Close() error {
+	return c.db.Close()
+}
+
+// Get returns the cached schema when it was stored within ttl.
+func (c *schemaCache) Get(packID, connectorID, toolName string, ttl time.Duration) (cachedSchema, bool, error) {
+	var raw string
+	var cachedAtMS int64
+	err := c.db.QueryRow(`
+SELECT schema_json, cached_at_ms FROM connector_schema_cache
+WHERE pack_id = ? AND connector_id = ? AND tool_name = ?`, packID, connectorID, toolName).Scan(&raw, &cachedAtMS)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cachedSchema{}, false, nil
+	}
+	if err != nil {
+		return cachedSchema{}, false, fmt.Errorf("read schema cache: %w", err)
+	}
+	if time.Since(time.UnixMilli(cachedAtMS)) >= ttl {
+		return cachedSchema{}, false, nil
+	}
+	var schema cachedSchema
+	if err := json.Unmarshal([]byte(raw), &schema); err != nil {
+		return cachedSchema{}, false, nil
+	}
+	return schema, true, nil
+}
+
+func (c *schemaCache) Put(packID, connectorID, toolName string, schema cachedSchema) error {
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("encode schema: %w", err)
+	}
+	_, err = c.db.Exec(`
+INSERT INTO connector_schema_cache(pack_id, connector_id, tool_name, schema_json, cached_at_ms)
+VALUES(?, ?, ?, ?, ?)
+ON CONFLICT(pack_id, connector_id, tool_name) DO UPDATE SET
+	schema_json = excluded.schema_json,
+	cached_at_ms = excluded.cached_at_ms`, packID, connectorID, toolName, string(raw), time.Now().UnixMilli())
+	if err != nil {
+		return fmt.Errorf("write schema cache: %w", err)
+	}
+	return nil
+}
+
+func (c *schemaCache) Invalidate(packID string) error {
+	if _, err := c.db.Exec(`DELETE FROM connector_schema_cache WHERE pack_id = ?`, packID); err != nil {
+		return fmt.Errorf("invalidate schema cache: %w", err)
+	}
+	return nil
+}
+
+// SetSchemaCacheTTL sets how long MCP tool schemas are cached between loads;
+// zero or less disables the cache.
+func (m *Manager) SetSchemaCacheTTL(ttl time.Duration) {
+	m.schemaCacheTTL = ttl
+}
+
+// invalidateSchemaCache drops cached schemas for a pack whose manifest
+// changed. The cache is only an optimization, so failures are ignored and the
+// entries expire on their own.
+func (m *Manager) invalidateSchemaCache(id string) {
+	if _, err := os.Stat(filepath.Join(m.rootDir, schemaCacheFile)); err != nil {
+		return
+	}
+	cache, err := openSchemaCache(m.rootDir)
+	if err != nil {
+		return
+	}
+	defer cache.Close()
+	_ = cache.Invalidate(id)
+}
@@ -0,0 +1,86 @@
+package toolpacks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/connectors"
+)
+
+type countingSchemaRuntime struct {
+	fakeConnectorRuntime
+	schemaCalls atomic.Int32
+}
+
+func (c *countingSchemaRuntime) ToolSchema(ctx context.Context, target string) (string, map[string]interface{}, error) {
+	c.schemaCalls.Add(1)
+	return "Echo text", map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"text": map[string]interface{}{"type": "string"}},
+	}, nil
+}
+
+func TestManager_LoadEnabledTools_CachesMCPSchemas(t *testing.T) {
+	workspace := t.TempDir()
+	packDir := filepath.Join(workspace, "toolpacks", "cached-pack")
+	if err := os.MkdirAll(packDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	manifest := Manifest{
+		ID:         "cached-pack",
+		Name:       "Cached Pack",
+		Version:    "1.0.0",
+		Enabled:    true,
+		Connectors: []ManifestConnector{{ID: "mcp", Type: "mcp"}},
+		Tools: []ManifestTool{
+			{Name: "mcp_echo", Type: "mcp", ConnectorID: "mcp", RemoteTool: "echo"},
+		},
+	}
+	writeManifestForTest(t, packDir, manifest)
+
+	rt := &countingSchemaRuntime{fakeConnectorRuntime: fakeConnectorRuntime{id: "mcp", typ: "mcp"}}
+	prevMCP := newMCPRuntimeFn
+	newMCPRuntimeFn = func(id string, cfg connectors.MCPConfig) (connectors.Runtime, error) {
+		return rt, nil
+	}
+	defer func() {
+		newMCPRuntimeFn = prevMCP
+	}()
+
+	mgr := NewManager(workspace, false)
+	mgr.SetSchemaCacheTTL(time.Hour)
+	load := func() {
+		t.Helper()
+		loaded, err := mgr.LoadEnabledTools()
+		if err != nil {
+			t.Fatalf("LoadEnabledTools failed: %v", err)
+		}
+		if len(loaded) != 1 || loaded[0].Description() != "Echo text" {
+			t.Fatalf("expected the discovered schema to be used, got %+v", loaded)
+		}
+	}
+
+	load()
+	load()
+	if got := rt.schemaCalls.Load(); got != 1 {
+		t.Fatalf("expected one schema fetch with a warm cache, got %d", got)
+	}
+
+	if err := mgr.Enable("cached-pack", true); err != nil {
+		t.Fatalf("enable: %v", err)
+	}
+	load()
+	if got := rt.schemaCalls.Load(); got != 2 {
+		t.Fatalf("expected enable to invalidate the cache, got %d fetches", got)
+	}
+
+	mgr.SetSchemaCacheTTL(0)
+	load()
+	if got := rt.schemaCalls.Load(); got != 3 {
+		t.Fatalf("expected a disabled cache to fetch again, got %d fetches", got)
+	}
+}
@@ -0,0 +1,184 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ApprovalTool asks the user to confirm a destructive action and blocks until
+// they reply, the timeout passes, or the turn is cancelled. Replies reach the
+// tool through HandleReply, which the agent loop calls for every inbound
+// message before scheduling it. Only the user whose message started the turn
+// can answer; in group chats everyone else is ignored.
+type ApprovalTool struct {
+	sendCallback   SendCallback
+	timeout        time.Duration
+	defaultChannel string
+	defaultChatID  string
+	pending        map[string]*pendingApproval
+	mu             sync.Mutex
+}
+
+// pendingApproval is an approval waiting for a reply from requester. An empty
+// requester (no actor on the turn) accepts a reply from anyone in the chat.
+type pendingApproval struct {
+	requester string
+	reply     chan string
+}
+
+// NewApprovalTool creates an ApprovalTool. A timeout <= 0 defaults to 60s.
+func NewApprovalTool(timeout time.Duration) *ApprovalTool {
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	return &ApprovalTool{
+		timeout: timeout,
+		pending: map[string]*pendingApproval{},
+	}
+}
+
+func (t *ApprovalTool) Name() string {
+	return "request_approval"
+}
+
+func (t *ApprovalTool) Description() string {
+	return "Ask the user to approve a destructive or sensitive action (e.g. deleting files with exec rm -rf, overwriting credentials) and wait for their yes/no reply. Returns 'approved' or 'denied'; only proceed when approved."
+}
+
+func (t *ApprovalTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "The exact action you want to take, e.g. the command to run",
+			},
+			"reason": map[string]interface{}{
+				"type":        "string",
+				"description": "Why the action is needed",
+			},
+		},
+		"required": []string{"action", "reason"},
+	}
+}
+
+func (t *ApprovalTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.defaultChannel = channel
+	t.defaultChatID = chatID
+}
+
+// SetSendCallback sets how the approval prompt is delivered to the user.
+func (t *ApprovalTool) SetSendCallback(callback SendCallback) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sendCallback = callback
+}
+
+// HandleReply delivers content from senderID to an approval waiting on
+// channel/chatID. It reports whether the message was consumed; unconsumed
+// messages, including ones from anyone but the requester, should be processed
+// normally.
+func (t *ApprovalTool) HandleReply(channel, chatID, senderID, content string) bool {
+	key := approvalKey(channel, chatID)
+	t.mu.Lock()
+	wait, ok := t.pending[key]
+	if ok && wait.requester != "" && wait.requester != strings.TrimSpace(senderID) {
+		ok = false
+	}
+	if ok {
+		delete(t.pending, key)
+	}
+	t.mu.Unlock()
+	if !ok {
+		return false
+	}
+	wait.reply <- content
+	return true
+}
+
+func (t *ApprovalTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	action, _ := args["action"].(string)
+	reason, _ := args["reason"].(string)
+	action = strings.TrimSpace(action)
+	reason = strings.TrimSpace(reason)
+	if action == "" {
+		return ErrorResult("action is required")
+	}
+
+	channel, chatID := channelChatFromContext(ctx)
+	// Buffered so HandleReply never blocks if the wait has already ended.
+	wait := &pendingApproval{requester: actorFromContext(ctx), reply: make(chan string, 1)}
+	t.mu.Lock()
+	if channel == "" {
+		channel = t.defaultChannel
+	}
+	if chatID == "" {
+		chatID = t.defaultChatID
+	}
+	sendCallback := t.sendCallback
+	key := approvalKey(channel, chatID)
+	_, busy := t.pending[key]
+	registered := channel != "" && chatID != "" && sendCallback != nil && !busy
+	if registered {
+		t.pending[key] = wait
+	}
+	t.mu.Unlock()
+
+	if channel == "" || chatID == "" {
+		return ErrorResult("No target channel/chat specified")
+	}
+	if sendCallback == nil {
+		return ErrorResult("Approval requests not configured")
+	}
+	if busy {
+		return ErrorResult("Another approval is already waiting for a reply in this chat")
+	}
+	defer func() {
+		t.mu.Lock()
+		if t.pending[key] == wait {
+			delete(t.pending, key)
+		}
+		t.mu.Unlock()
+	}()
+
+	prompt := fmt.Sprintf("Approval needed: %s", action)
+	if reason != "" {
+		prompt += fmt.Sprintf("\nReason: %s", reason)
+	}
+	prompt += "\nApprove? (yes/no)"
+	if err := sendCallback(channel, chatID, prompt); err != nil {
+		return ErrorResult(fmt.Sprintf("sending approval request: %v", err)).WithError(err)
+	}
+
+	timer := time.NewTimer(t.timeout)
+	defer timer.Stop()
+	select {
+	case answer := <-wait.reply:
+		if isApprovalReply(answer) {
+			return NewToolResult("approved")
+		}
+		return NewToolResult("denied")
+	case <-timer.C:
+		return NewToolResult(fmt.Sprintf("denied (no reply within %s)", t.timeout))
+	case <-ctx.Done():
+		return ErrorResult("approval request cancelled").WithError(ctx.Err())
+	}
+}
+
+func approvalKey(channel, chatID string) string {
+	return strings.TrimSpace(channel) + "|" + strings.TrimSpace(chatID)
+}
+
+func isApprovalReply(content string) bool {
+	switch strings.ToLower(strings.Trim(strings.TrimSpace(content), ".!")) {
+	case "yes", "y", "approve", "approved", "ok", "okay":
+		return true
+	default:
+		return false
+	}
+}
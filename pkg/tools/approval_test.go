@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func runApproval(t *testing.T, tool *ApprovalTool, reply string) *ToolResult {
+	t.Helper()
+	sent := make(chan string, 1)
+	tool.SetSendCallback(func(channel, chatID, content string) error {
+		sent <- content
+		return nil
+	})
+	done := make(chan *ToolResult, 1)
+	go func() {
+		done <- tool.Execute(context.Background(), map[string]interface{}{
+			"action": "exec rm -rf build/",
+			"reason": "clean stale artifacts",
+		})
+	}()
+	prompt := <-sent
+	if !strings.Contains(prompt, "exec rm -rf build/") || !strings.HasSuffix(prompt, "Approve? (yes/no)") {
+		t.Fatalf("unexpected approval prompt: %q", prompt)
+	}
+	if reply != "" {
+		if tool.HandleReply("other", "chat-1", "", reply) {
+			t.Fatal("reply from another channel should not be consumed")
+		}
+		if !tool.HandleReply("test-channel", "chat-1", "", reply) {
+			t.Fatal("expected reply to be consumed by pending approval")
+		}
+	}
+	select {
+	case result := <-done:
+		return result
+	case <-time.After(2 * time.Second):
+		t.Fatal("approval did not return")
+		return nil
+	}
+}
+
+func TestApprovalTool_Approved(t *testing.T) {
+	tool := NewApprovalTool(time.Minute)
+	tool.SetContext("test-channel", "chat-1")
+
+	result := runApproval(t, tool, "Yes!")
+	if result.IsError || result.ForLLM != "approved" {
+		t.Fatalf("expected approved, got %+v", result)
+	}
+	if tool.HandleReply("test-channel", "chat-1", "", "yes") {
+		t.Fatal("no approval should be pending after a reply")
+	}
+}
+
+func TestApprovalTool_Denied(t *testing.T) {
+	tool := NewApprovalTool(time.Minute)
+	tool.SetContext("test-channel", "chat-1")
+
+	result := runApproval(t, tool, "no, keep it")
+	if result.IsError || result.ForLLM != "denied" {
+		t.Fatalf("expected denied, got %+v", result)
+	}
+}
+
+func TestApprovalTool_TimeoutDenies(t *testing.T) {
+	tool := NewApprovalTool(20 * time.Millisecond)
+	tool.SetContext("test-channel", "chat-1")
+
+	result := runApproval(t, tool, "")
+	if result.IsError || !strings.HasPrefix(result.ForLLM, "denied") {
+		t.Fatalf("expected denied on timeout, got %+v", result)
+	}
+}
+
+func TestApprovalTool_RequiresTarget(t *testing.T) {
+	tool := NewApprovalTool(time.Minute)
+	tool.SetSendCallback(func(channel, chatID, content string) error { return nil })
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"action": "rm -rf /tmp/x", "reason": "cleanup"})
+	if !result.IsError {
+		t.Fatal("expected error without a target chat")
+	}
+}
+
+func TestApprovalTool_OnlyRequesterCanReply(t *testing.T) {
+	tool := NewApprovalTool(time.Minute)
+	tool.SetContext("test-channel", "chat-1")
+	sent := make(chan string, 1)
+	tool.SetSendCallback(func(channel, chatID, content string) error {
+		sent <- content
+		return nil
+	})
+	ctx := WithToolExecutionActor(context.Background(), "alice")
+	done := make(chan *ToolResult, 1)
+	go func() {
+		done <- tool.Execute(ctx, map[string]interface{}{"action": "exec rm -rf build/", "reason": "cleanup"})
+	}()
+	<-sent
+
+	second := tool.Execute(ctx, map[string]interface{}{"action": "exec rm -rf dist/", "reason": "cleanup"})
+	if !second.IsError || !strings.Contains(second.ForLLM, "already waiting") {
+		t.Fatalf("expected a second approval in the chat to be refused, got %+v", second)
+	}
+	if tool.HandleReply("test-channel", "chat-1", "mallory", "yes") {
+		t.Fatal("a reply from another group member should not be consumed")
+	}
+	if !tool.HandleReply("test-channel", "chat-1", "alice", "no") {
+		t.Fatal("expected the requester's reply to be consumed")
+	}
+	if result := <-done; result.ForLLM != "denied" {
+		t.Fatalf("expected the requester's answer to win, got %+v", result)
+	}
+}
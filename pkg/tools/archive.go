@@ -0,0 +1,534 @@
+package tools
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultArchiveMaxSizeMB = 100
+
+var errArchiveTooLarge = errors.New("archive exceeds the size limit")
+
+// archiveFormatFromPath infers zip, tar.gz or tar.bz2 from a file name.
+func archiveFormatFromPath(path string) string {
+	name := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return "zip"
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(name, ".tar.bz2"), strings.HasSuffix(name, ".tbz2"):
+		return "tar.bz2"
+	}
+	return ""
+}
+
+func archiveMaxBytes(maxSizeMB int) int64 {
+	if maxSizeMB <= 0 {
+		maxSizeMB = defaultArchiveMaxSizeMB
+	}
+	return int64(maxSizeMB) << 20
+}
+
+func formatArchiveLimit(limit int64) string {
+	return fmt.Sprintf("%d MB", limit>>20)
+}
+
+// ArchiveCreateTool packs workspace files into a zip or tar.gz archive.
+type ArchiveCreateTool struct {
+	workspace string
+	restrict  bool
+	maxBytes  int64
+}
+
+func NewArchiveCreateTool(workspace string, restrict bool, maxSizeMB int) *ArchiveCreateTool {
+	return &ArchiveCreateTool{workspace: workspace, restrict: restrict, maxBytes: archiveMaxBytes(maxSizeMB)}
+}
+
+func (t *ArchiveCreateTool) Name() string {
+	return "archive_create"
+}
+
+func (t *ArchiveCreateTool) Description() string {
+	return fmt.Sprintf("Pack files and directories into a zip or tar.gz archive. Directories are added recursively under their own name; symlinks are skipped. The files may total at most %s.", formatArchiveLimit(t.maxBytes))
+}
+
+func (t *ArchiveCreateTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"output_path": map[string]interface{}{
+				"type":        "string",
+				"description": "Archive file to write",
+			},
+			"input_paths": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Files and directories to add",
+			},
+			"format": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"zip", "tar.gz", "tar.bz2"},
+				"description": "Archive format (default from the output_path extension, else zip). tar.bz2 can only be extracted.",
+			},
+		},
+		"required": []string{"output_path", "input_paths"},
+	}
+}
+
+// archiveInput is a regular file to add and its name inside the archive.
+type archiveInput struct {
+	path string
+	name string
+	info fs.FileInfo
+}
+
+func (t *ArchiveCreateTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	outputPath, _ := args["output_path"].(string)
+	if strings.TrimSpace(outputPath) == "" {
+		return ErrorResult("output_path is required")
+	}
+	inputPaths := archiveInputPathsArg(args["input_paths"])
+	if len(inputPaths) == 0 {
+		return ErrorResult("input_paths must list at least one file or directory")
+	}
+	format, _ := args["format"].(string)
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = archiveFormatFromPath(outputPath)
+	}
+	switch format {
+	case "":
+		format = "zip"
+	case "zip", "tar.gz":
+	case "tar.bz2":
+		return ErrorResult("creating tar.bz2 archives is not supported (bzip2 is extract-only); use zip or tar.gz")
+	default:
+		return ErrorResult("format must be one of: zip, tar.gz, tar.bz2")
+	}
+
+	resolvedOutput, err := validatePath(outputPath, t.workspace, t.restrict)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	inputs, total, err := t.collectInputs(ctx, inputPaths, resolvedOutput)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	if total > t.maxBytes {
+		return ErrorResult(fmt.Sprintf("input files total at least %d bytes, over the %s limit (tools.archive.max_size_mb)", total, formatArchiveLimit(t.maxBytes)))
+	}
+	if len(inputs) == 0 {
+		return ErrorResult("input_paths contain no regular files")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(resolvedOutput), 0o755); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to create directory: %v", err))
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(resolvedOutput), ".archive-*")
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to create archive: %v", err))
+	}
+	defer os.Remove(tmp.Name())
+	if format == "zip" {
+		err = writeZipArchive(ctx, tmp, inputs)
+	} else {
+		err = writeTarGzArchive(ctx, tmp, inputs)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to write archive: %v", err))
+	}
+	agentWrites.record(resolvedOutput)
+	if err := os.Rename(tmp.Name(), resolvedOutput); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to write archive: %v", err))
+	}
+	size := int64(0)
+	if info, err := os.Stat(resolvedOutput); err == nil {
+		size = info.Size()
+	}
+	return NewToolResult(fmt.Sprintf("Created %s archive %s with %d files (%d bytes, %d bytes uncompressed)", format, resolvedOutput, len(inputs), size, total))
+}
+
+// collectInputs expands input paths into regular files. Entries are named
+// relative to each input's parent directory, so a directory keeps its name.
+func (t *ArchiveCreateTool) collectInputs(ctx context.Context, inputPaths []string, outputPath string) ([]archiveInput, int64, error) {
+	var (
+		inputs []archiveInput
+		total  int64
+		seen   = map[string]bool{}
+	)
+	for _, input := range inputPaths {
+		resolved, err := validatePath(input, t.workspace, t.restrict)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", input, err)
+		}
+		base := filepath.Dir(resolved)
+		err = filepath.WalkDir(resolved, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() || path == outputPath || seen[path] {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(base, path)
+			if err != nil {
+				return err
+			}
+			seen[path] = true
+			total += info.Size()
+			if total > t.maxBytes {
+				return errArchiveTooLarge
+			}
+			inputs = append(inputs, archiveInput{path: path, name: filepath.ToSlash(rel), info: info})
+			return nil
+		})
+		if errors.Is(err, errArchiveTooLarge) {
+			return nil, total, nil
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read %s: %w", input, err)
+		}
+	}
+	return inputs, total, nil
+}
+
+func writeZipArchive(ctx context.Context, w io.Writer, inputs []archiveInput) error {
+	zw := zip.NewWriter(w)
+	for _, in := range inputs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(in.info)
+		if err != nil {
+			return err
+		}
+		header.Name = in.name
+		header.Method = zip.Deflate
+		dst, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if err := copyArchiveInput(dst, in.path); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeTarGzArchive(ctx context.Context, w io.Writer, inputs []archiveInput) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, in := range inputs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(in.info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = in.name
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if err := copyArchiveInput(tw, in.path); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func copyArchiveInput(dst io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(dst, f)
+	return err
+}
+
+// archiveInputPathsArg accepts a JSON array of paths or a single path.
+func archiveInputPathsArg(raw interface{}) []string {
+	var values []string
+	switch v := raw.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	case []string:
+		values = v
+	case string:
+		values = []string{v}
+	}
+	paths := make([]string, 0, len(values))
+	for _, path := range values {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// ArchiveExtractTool unpacks zip, tar.gz and tar.bz2 archives into the
+// workspace.
+type ArchiveExtractTool struct {
+	workspace string
+	restrict  bool
+	maxBytes  int64
+}
+
+func NewArchiveExtractTool(workspace string, restrict bool, maxSizeMB int) *ArchiveExtractTool {
+	return &ArchiveExtractTool{workspace: workspace, restrict: restrict, maxBytes: archiveMaxBytes(maxSizeMB)}
+}
+
+func (t *ArchiveExtractTool) Name() string {
+	return "archive_extract"
+}
+
+func (t *ArchiveExtractTool) Description() string {
+	return fmt.Sprintf("Extract a zip, tar.gz or tar.bz2 archive into a directory. Existing files are overwritten; links and entries that would land outside output_dir are rejected. The archive and its extracted contents may each be at most %s.", formatArchiveLimit(t.maxBytes))
+}
+
+func (t *ArchiveExtractTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"archive_path": map[string]interface{}{
+				"type":        "string",
+				"description": "Archive file to extract",
+			},
+			"output_dir": map[string]interface{}{
+				"type":        "string",
+				"description": "Directory to extract into (created if missing)",
+			},
+		},
+		"required": []string{"archive_path", "output_dir"},
+	}
+}
+
+func (t *ArchiveExtractTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	archivePath, _ := args["archive_path"].(string)
+	if strings.TrimSpace(archivePath) == "" {
+		return ErrorResult("archive_path is required")
+	}
+	outputDir, _ := args["output_dir"].(string)
+	if strings.TrimSpace(outputDir) == "" {
+		return ErrorResult("output_dir is required")
+	}
+	resolvedArchive, err := validatePath(archivePath, t.workspace, t.restrict)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	resolvedDir, err := validatePath(outputDir, t.workspace, t.restrict)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	f, err := os.Open(resolvedArchive)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to open archive: %v", err))
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to open archive: %v", err))
+	}
+	if info.Size() > t.maxBytes {
+		return ErrorResult(fmt.Sprintf("archive is %d bytes, over the %s limit (tools.archive.max_size_mb)", info.Size(), formatArchiveLimit(t.maxBytes)))
+	}
+	format, err := sniffArchiveFormat(f)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	if err := os.MkdirAll(resolvedDir, 0o755); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to create directory: %v", err))
+	}
+
+	x := &archiveExtractor{ctx: ctx, dir: resolvedDir, workspace: t.workspace, restrict: t.restrict, limit: t.maxBytes}
+	switch format {
+	case "zip":
+		err = x.extractZip(f, info.Size())
+	case "tar.gz":
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(f); err == nil {
+			err = x.extractTar(gz)
+			gz.Close()
+		}
+	case "tar.bz2":
+		err = x.extractTar(bzip2.NewReader(bufio.NewReader(f)))
+	}
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to extract %s archive after %d files: %v", format, x.files, err))
+	}
+	return NewToolResult(fmt.Sprintf("Extracted %d files (%d bytes) from %s archive to %s", x.files, x.written, format, resolvedDir))
+}
+
+// sniffArchiveFormat identifies the archive from its magic bytes and rewinds f.
+func sniffArchiveFormat(f *os.File) (string, error) {
+	magic := make([]byte, 4)
+	n, _ := io.ReadFull(f, magic)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to read archive: %w", err)
+	}
+	magic = magic[:n]
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		return "zip", nil
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return "tar.gz", nil
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		return "tar.bz2", nil
+	}
+	return "", fmt.Errorf("unsupported archive: expected zip, tar.gz or tar.bz2")
+}
+
+// archiveExtractor writes entries under dir, refusing entries that escape it
+// and stopping once more than limit bytes have been written.
+type archiveExtractor struct {
+	ctx       context.Context
+	dir       string
+	workspace string
+	restrict  bool
+	limit     int64
+	written   int64
+	files     int
+}
+
+func (x *archiveExtractor) extractZip(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	for _, entry := range zr.File {
+		if err := x.ctx.Err(); err != nil {
+			return err
+		}
+		mode := entry.Mode()
+		if mode.IsDir() {
+			if _, err := x.target(entry.Name, true); err != nil {
+				return err
+			}
+			continue
+		}
+		if !mode.IsRegular() {
+			return fmt.Errorf("%s: only regular files and directories can be extracted", entry.Name)
+		}
+		src, err := entry.Open()
+		if err != nil {
+			return fmt.Errorf("%s: %w", entry.Name, err)
+		}
+		err = x.writeFile(entry.Name, src, mode)
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *archiveExtractor) extractTar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		if err := x.ctx.Err(); err != nil {
+			return err
+		}
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if _, err := x.target(header.Name, true); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := x.writeFile(header.Name, tr, header.FileInfo().Mode()); err != nil {
+				return err
+			}
+		case tar.TypeXGlobalHeader:
+		default:
+			return fmt.Errorf("%s: only regular files and directories can be extracted", header.Name)
+		}
+	}
+}
+
+// target resolves an entry name inside dir, creating it when it is a
+// directory and its parent otherwise.
+func (x *archiveExtractor) target(name string, isDir bool) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(os.PathSeparator)) {
+		return "", fmt.Errorf("%s: entry escapes output_dir", name)
+	}
+	path := filepath.Join(x.dir, clean)
+	if !isWithinWorkspace(path, x.dir) {
+		return "", fmt.Errorf("%s: entry escapes output_dir", name)
+	}
+	if _, err := validatePath(path, x.workspace, x.restrict); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	mkdir := filepath.Dir(path)
+	if isDir {
+		mkdir = path
+	}
+	if err := os.MkdirAll(mkdir, 0o755); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return path, nil
+}
+
+func (x *archiveExtractor) writeFile(name string, src io.Reader, mode fs.FileMode) error {
+	path, err := x.target(name, false)
+	if err != nil {
+		return err
+	}
+	agentWrites.record(path)
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm()|0o600)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	remaining := x.limit - x.written
+	n, err := io.Copy(dst, io.LimitReader(src, remaining+1))
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if n > remaining {
+		os.Remove(path)
+		return fmt.Errorf("extracted contents exceed the %s limit (tools.archive.max_size_mb)", formatArchiveLimit(x.limit))
+	}
+	x.written += n
+	x.files++
+	return nil
+}
@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeArchiveFixture(t *testing.T, dir string) {
+	t.Helper()
+	for name, content := range map[string]string{
+		"docs/readme.md":      "# Readme\n",
+		"docs/guide/intro.md": "intro\n",
+		"notes.txt":           "notes\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write fixture: %v", err)
+		}
+	}
+}
+
+func TestArchiveTools_RoundTrip(t *testing.T) {
+	for _, output := range []string{"out/bundle.zip", "out/bundle.tar.gz"} {
+		t.Run(output, func(t *testing.T) {
+			dir := t.TempDir()
+			writeArchiveFixture(t, dir)
+
+			create := NewArchiveCreateTool(dir, true, 0).Execute(context.Background(), map[string]interface{}{
+				"output_path": output,
+				"input_paths": []interface{}{"docs", "notes.txt"},
+			})
+			if create.IsError {
+				t.Fatalf("create failed: %s", create.ForLLM)
+			}
+			if !strings.Contains(create.ForLLM, "with 3 files") {
+				t.Fatalf("unexpected create result: %s", create.ForLLM)
+			}
+
+			extract := NewArchiveExtractTool(dir, true, 0).Execute(context.Background(), map[string]interface{}{
+				"archive_path": output,
+				"output_dir":   "unpacked",
+			})
+			if extract.IsError {
+				t.Fatalf("extract failed: %s", extract.ForLLM)
+			}
+			data, err := os.ReadFile(filepath.Join(dir, "unpacked", "docs", "guide", "intro.md"))
+			if err != nil || string(data) != "intro\n" {
+				t.Fatalf("expected extracted nested file, got %q %v", data, err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "unpacked", "notes.txt")); err != nil {
+				t.Fatalf("expected extracted top-level file: %v", err)
+			}
+		})
+	}
+}
+
+func TestArchiveExtractTool_TarBz2(t *testing.T) {
+	if _, err := exec.LookPath("tar"); err != nil {
+		t.Skip("tar not available")
+	}
+	if _, err := exec.LookPath("bzip2"); err != nil {
+		t.Skip("bzip2 not available")
+	}
+	dir := t.TempDir()
+	writeArchiveFixture(t, dir)
+	if out, err := exec.Command("tar", "-cjf", filepath.Join(dir, "docs.tar.bz2"), "-C", dir, "docs").CombinedOutput(); err != nil {
+		t.Fatalf("tar: %v: %s", err, out)
+	}
+
+	result := NewArchiveExtractTool(dir, true, 0).Execute(context.Background(), map[string]interface{}{
+		"archive_path": "docs.tar.bz2",
+		"output_dir":   "unpacked",
+	})
+	if result.IsError {
+		t.Fatalf("extract failed: %s", result.ForLLM)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "unpacked", "docs", "readme.md")); err != nil {
+		t.Fatalf("expected extracted file: %v", err)
+	}
+}
+
+func TestArchiveCreateTool_Rejects(t *testing.T) {
+	dir := t.TempDir()
+	writeArchiveFixture(t, dir)
+	tool := NewArchiveCreateTool(dir, true, 1)
+
+	if result := tool.Execute(context.Background(), map[string]interface{}{
+		"output_path": "out.tar.bz2",
+		"input_paths": []interface{}{"docs"},
+	}); !result.IsError || !strings.Contains(result.ForLLM, "tar.bz2") {
+		t.Fatalf("expected tar.bz2 creation to be rejected, got %s", result.ForLLM)
+	}
+	if result := tool.Execute(context.Background(), map[string]interface{}{
+		"output_path": "out.zip",
+		"input_paths": []interface{}{"../outside"},
+	}); !result.IsError || !strings.Contains(result.ForLLM, "outside the workspace") {
+		t.Fatalf("expected input outside the workspace to be rejected, got %s", result.ForLLM)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "big.bin"), make([]byte, 2<<20), 0o644); err != nil {
+		t.Fatalf("write big file: %v", err)
+	}
+	if result := tool.Execute(context.Background(), map[string]interface{}{
+		"output_path": "out.zip",
+		"input_paths": []interface{}{"big.bin"},
+	}); !result.IsError || !strings.Contains(result.ForLLM, "max_size_mb") {
+		t.Fatalf("expected size limit error, got %s", result.ForLLM)
+	}
+}
+
+func TestArchiveExtractTool_RejectsEscapingEntries(t *testing.T) {
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "evil.zip"))
+	if err != nil {
+		t.Fatalf("create zip: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("../escaped.txt")
+	if err != nil {
+		t.Fatalf("zip entry: %v", err)
+	}
+	w.Write([]byte("gotcha"))
+	zw.Close()
+	f.Close()
+
+	result := NewArchiveExtractTool(dir, true, 0).Execute(context.Background(), map[string]interface{}{
+		"archive_path": "evil.zip",
+		"output_dir":   "unpacked",
+	})
+	if !result.IsError || !strings.Contains(result.ForLLM, "escapes output_dir") {
+		t.Fatalf("expected escaping entry to be rejected, got %s", result.ForLLM)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escaped.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected no file outside output_dir, got %v", err)
+	}
+}
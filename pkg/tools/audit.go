@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/utils"
+)
+
+const auditResultSummaryLen = 500
+
+// ToolAuditEntry is one line of the tool audit log.
+type ToolAuditEntry struct {
+	Timestamp     string                 `json:"timestamp"`
+	SessionKey    string                 `json:"session_key"`
+	TurnID        string                 `json:"turn_id"`
+	ToolName      string                 `json:"tool_name"`
+	Arguments     map[string]interface{} `json:"arguments"`
+	ResultSummary string                 `json:"result_summary"`
+	IsError       bool                   `json:"is_error"`
+	DurationMS    int64                  `json:"duration_ms"`
+}
+
+// AuditLogger appends one JSON line per tool call to workspace/audit/tools.jsonl.
+// Entries are never rewritten: when the file would grow past the size limit
+// it is renamed with a timestamp suffix and a new file is started.
+type AuditLogger struct {
+	path     string
+	maxBytes int64
+	mu       sync.Mutex
+	now      func() time.Time
+}
+
+// NewAuditLogger creates an AuditLogger writing under dataDir/audit, outside
+// the workspace the file tools can reach. A maxFileSizeMB <= 0 disables
+// rotation.
+func NewAuditLogger(dataDir string, maxFileSizeMB int) *AuditLogger {
+	return &AuditLogger{
+		path:     filepath.Join(dataDir, "audit", "tools.jsonl"),
+		maxBytes: int64(maxFileSizeMB) << 20,
+		now:      time.Now,
+	}
+}
+
+// Path returns the active audit file.
+func (l *AuditLogger) Path() string {
+	return l.path
+}
+
+// LogToolCall records a completed tool call. Arguments are redacted the same
+// way as in the tool execution logs.
+func (l *AuditLogger) LogToolCall(sessionKey, turnID, toolName string, args map[string]interface{}, result *ToolResult) error {
+	entry := ToolAuditEntry{
+		SessionKey: sessionKey,
+		TurnID:     turnID,
+		ToolName:   toolName,
+		Arguments:  sanitizeToolArgs(args),
+	}
+	if result != nil {
+		summary := result.ForLLM
+		if summary == "" && result.Err != nil {
+			summary = result.Err.Error()
+		}
+		entry.ResultSummary = utils.Truncate(summary, auditResultSummaryLen)
+		entry.IsError = result.IsError
+		entry.DurationMS = result.Duration.Milliseconds()
+	}
+	return l.Write(entry)
+}
+
+// Write appends entry, stamping it with the current time if unset.
+func (l *AuditLogger) Write(entry ToolAuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if entry.Timestamp == "" {
+		entry.Timestamp = now.UTC().Format(time.RFC3339Nano)
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("create audit dir: %w", err)
+	}
+	if err := l.rotateIfNeeded(int64(len(line)), now); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	return f.Close()
+}
+
+func (l *AuditLogger) rotateIfNeeded(incoming int64, now time.Time) error {
+	if l.maxBytes <= 0 {
+		return nil
+	}
+	info, err := os.Stat(l.path)
+	if err != nil || info.Size() == 0 || info.Size()+incoming <= l.maxBytes {
+		return nil
+	}
+	ext := filepath.Ext(l.path)
+	base := l.path[:len(l.path)-len(ext)]
+	rotated := fmt.Sprintf("%s-%s%s", base, now.UTC().Format("20060102T150405.000000000"), ext)
+	if err := os.Rename(l.path, rotated); err != nil {
+		return fmt.Errorf("rotate audit log: %w", err)
+	}
+	return nil
+}
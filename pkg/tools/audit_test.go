@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readAuditEntries(t *testing.T, path string) []ToolAuditEntry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	defer f.Close()
+	var entries []ToolAuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for scanner.Scan() {
+		var entry ToolAuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("decode audit line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("scan audit log: %v", err)
+	}
+	return entries
+}
+
+func TestAuditLogger_LogToolCall(t *testing.T) {
+	dataDir := t.TempDir()
+	logger := NewAuditLogger(dataDir, 10)
+
+	result := NewToolResult("wrote 12 bytes")
+	result.Duration = 42 * time.Millisecond
+	err := logger.LogToolCall("sess-1", "turn-1", "write_file", map[string]interface{}{
+		"path":    "notes.txt",
+		"api_key": "sk-secret",
+	}, result)
+	if err != nil {
+		t.Fatalf("LogToolCall: %v", err)
+	}
+	if err := logger.LogToolCall("sess-1", "turn-1", "exec", map[string]interface{}{"command": "false"}, ErrorResult("exit status 1")); err != nil {
+		t.Fatalf("LogToolCall: %v", err)
+	}
+
+	if logger.Path() != filepath.Join(dataDir, "audit", "tools.jsonl") {
+		t.Fatalf("unexpected audit path %s", logger.Path())
+	}
+	entries := readAuditEntries(t, logger.Path())
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", len(entries))
+	}
+	first := entries[0]
+	if first.SessionKey != "sess-1" || first.TurnID != "turn-1" || first.ToolName != "write_file" {
+		t.Fatalf("unexpected entry identity: %+v", first)
+	}
+	if first.ResultSummary != "wrote 12 bytes" || first.DurationMS != 42 || first.Timestamp == "" {
+		t.Fatalf("unexpected entry details: %+v", first)
+	}
+	if first.Arguments["api_key"] != "<redacted>" || first.Arguments["path"] != "notes.txt" {
+		t.Fatalf("expected sensitive arguments redacted, got %v", first.Arguments)
+	}
+	if !entries[1].IsError {
+		t.Fatal("expected failed tool call to be marked as error")
+	}
+}
+
+func TestAuditLogger_Rotates(t *testing.T) {
+	dataDir := t.TempDir()
+	logger := NewAuditLogger(dataDir, 1)
+	payload := strings.Repeat("x", 400<<10)
+
+	for i := 0; i < 3; i++ {
+		if err := logger.Write(ToolAuditEntry{ToolName: "read_file", ResultSummary: payload}); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	rotated, _ := filepath.Glob(filepath.Join(dataDir, "audit", "tools-*.jsonl"))
+	if len(rotated) != 1 {
+		t.Fatalf("expected one rotated file, got %v", rotated)
+	}
+	if got := len(readAuditEntries(t, rotated[0])); got != 2 {
+		t.Fatalf("expected 2 entries in rotated file, got %d", got)
+	}
+	if got := len(readAuditEntries(t, logger.Path())); got != 1 {
+		t.Fatalf("expected 1 entry in active file, got %d", got)
+	}
+}
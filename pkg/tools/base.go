@@ -73,6 +73,15 @@ type AsyncTool interface {
 	SetCallback(cb AsyncCallback)
 }
 
+// UntrustedContentTool is an optional interface for tools whose output comes
+// from outside the user's control, such as web pages, search results or
+// third-party services. Only their results are passed through SanitizeResult;
+// local files and command output reach the model unchanged.
+type UntrustedContentTool interface {
+	Tool
+	UntrustedContent() bool
+}
+
 // ClosableTool is an optional interface for tools that hold runtime resources
 // and require explicit teardown when the agent stops.
 type ClosableTool interface {
@@ -0,0 +1,249 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type codeLanguage struct {
+	name         string
+	interpreter  string
+	extension    string
+	sandboxImage string
+}
+
+var codeRunnerLanguages = map[string]codeLanguage{
+	"python":     {name: "python", interpreter: "python3", extension: ".py", sandboxImage: "python:3.12-slim"},
+	"javascript": {name: "javascript", interpreter: "node", extension: ".js", sandboxImage: "node:22-slim"},
+	"bash":       {name: "bash", interpreter: "bash", extension: ".sh", sandboxImage: "bash:5"},
+}
+
+var codeRunnerAliases = map[string]string{
+	"py":      "python",
+	"python3": "python",
+	"js":      "javascript",
+	"node":    "javascript",
+	"sh":      "bash",
+	"shell":   "bash",
+}
+
+type CodeRunnerToolOptions struct {
+	TimeoutSeconds   int
+	AllowedLanguages []string
+	UseSandbox       bool
+	// Restrict mirrors restrict_to_workspace. Snippets bypass the exec
+	// tool's command guards, so a restricted runner always uses the sandbox.
+	Restrict bool
+}
+
+// CodeRunnerTool executes short Python, JavaScript or shell snippets, optionally
+// inside a throwaway Docker container.
+type CodeRunnerTool struct {
+	workspace  string
+	timeout    time.Duration
+	allowed    map[string]bool
+	useSandbox bool
+}
+
+func NewCodeRunnerTool(workspace string, opts CodeRunnerToolOptions) *CodeRunnerTool {
+	timeout := 30 * time.Second
+	if opts.TimeoutSeconds > 0 {
+		timeout = time.Duration(opts.TimeoutSeconds) * time.Second
+	}
+	allowed := map[string]bool{}
+	for _, raw := range opts.AllowedLanguages {
+		if lang, ok := resolveCodeLanguage(raw); ok {
+			allowed[lang.name] = true
+		}
+	}
+	if len(opts.AllowedLanguages) == 0 {
+		for name := range codeRunnerLanguages {
+			allowed[name] = true
+		}
+	}
+	return &CodeRunnerTool{
+		workspace:  workspace,
+		timeout:    timeout,
+		allowed:    allowed,
+		useSandbox: opts.UseSandbox || opts.Restrict,
+	}
+}
+
+func (t *CodeRunnerTool) Name() string {
+	return "code_run"
+}
+
+func (t *CodeRunnerTool) Description() string {
+	return "Run a Python, JavaScript, or bash snippet and return its stdout and stderr. Use for calculations and data processing."
+}
+
+func (t *CodeRunnerTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"language": map[string]interface{}{
+				"type":        "string",
+				"description": "Snippet language",
+				"enum":        []string{"python", "javascript", "bash"},
+			},
+			"code": map[string]interface{}{
+				"type":        "string",
+				"description": "Source code to execute",
+			},
+		},
+		"required": []string{"language", "code"},
+	}
+}
+
+func (t *CodeRunnerTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	rawLang, _ := args["language"].(string)
+	if strings.TrimSpace(rawLang) == "" {
+		return ErrorResult("language is required")
+	}
+	code, ok := args["code"].(string)
+	if !ok || strings.TrimSpace(code) == "" {
+		return ErrorResult("code is required")
+	}
+	lang, ok := resolveCodeLanguage(rawLang)
+	if !ok {
+		return ErrorResult(fmt.Sprintf("unsupported language %q (supported: python, javascript, bash)", rawLang))
+	}
+	if !t.allowed[lang.name] {
+		return ErrorResult(fmt.Sprintf("language %q is not allowed (allowed: %s)", lang.name, strings.Join(t.allowedLanguages(), ", ")))
+	}
+
+	if t.useSandbox {
+		if _, err := exec.LookPath("docker"); err != nil {
+			return ErrorResult("code_run needs Docker to run snippets in a sandbox (required when restrict_to_workspace is enabled); docker was not found")
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "dotagent-code-*")
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to create temp dir: %v", err))
+	}
+	defer os.RemoveAll(dir)
+
+	scriptName := "main" + lang.extension
+	if err := os.WriteFile(filepath.Join(dir, scriptName), []byte(code), 0o644); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to write code: %v", err))
+	}
+
+	cmdCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	containerName := "dotagent-code-" + uuid.NewString()[:12]
+	name, cmdArgs := t.commandFor(lang, dir, scriptName, containerName)
+	cmd := exec.CommandContext(cmdCtx, name, cmdArgs...)
+	cmd.Dir = dir
+	if !t.useSandbox && strings.TrimSpace(t.workspace) != "" {
+		if info, err := os.Stat(t.workspace); err == nil && info.IsDir() {
+			cmd.Dir = t.workspace
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if t.useSandbox && cmdCtx.Err() != nil {
+		// Cancelling only kills the docker CLI; the container would keep
+		// running the snippet and --rm would never fire.
+		removeCtx, removeCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		_ = exec.CommandContext(removeCtx, "docker", "rm", "-f", containerName).Run()
+		removeCancel()
+	}
+	if cmdCtx.Err() == context.DeadlineExceeded {
+		msg := fmt.Sprintf("Code execution timed out after %v", t.timeout)
+		return &ToolResult{
+			ForLLM:  msg,
+			ForUser: msg,
+			IsError: true,
+		}
+	}
+
+	output := formatCodeRunOutput(stdout.String(), stderr.String())
+	if err != nil {
+		output += fmt.Sprintf("\nExit code: %v", err)
+	}
+
+	maxLen := 10000
+	if len(output) > maxLen {
+		output = output[:maxLen] + fmt.Sprintf("\n... (truncated, %d more chars)", len(output)-maxLen)
+	}
+
+	return &ToolResult{
+		ForLLM:  output,
+		ForUser: output,
+		IsError: err != nil,
+	}
+}
+
+// commandFor returns the program and arguments used to run the script in dir.
+// Sandboxed runs are named containerName so they can be removed on timeout,
+// and the interpreter is also wrapped in timeout inside the container in case
+// that removal fails.
+func (t *CodeRunnerTool) commandFor(lang codeLanguage, dir, scriptName, containerName string) (string, []string) {
+	if !t.useSandbox {
+		return lang.interpreter, []string{filepath.Join(dir, scriptName)}
+	}
+	return "docker", []string{
+		"run", "--rm",
+		"--name", containerName,
+		"--stop-timeout", "1",
+		"--network", "none",
+		"--memory", "256m",
+		"--cpus", "1",
+		"-v", dir + ":/sandbox:ro",
+		"-w", "/sandbox",
+		lang.sandboxImage,
+		"timeout", strconv.Itoa(int(t.timeout.Seconds())),
+		lang.interpreter, "/sandbox/" + scriptName,
+	}
+}
+
+func (t *CodeRunnerTool) allowedLanguages() []string {
+	out := make([]string, 0, len(t.allowed))
+	for name := range t.allowed {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func resolveCodeLanguage(raw string) (codeLanguage, bool) {
+	key := strings.ToLower(strings.TrimSpace(raw))
+	if alias, ok := codeRunnerAliases[key]; ok {
+		key = alias
+	}
+	lang, ok := codeRunnerLanguages[key]
+	return lang, ok
+}
+
+func formatCodeRunOutput(stdout, stderr string) string {
+	if stdout == "" && stderr == "" {
+		return "(no output)"
+	}
+	var sb strings.Builder
+	sb.WriteString("STDOUT:\n")
+	sb.WriteString(stdout)
+	if stderr != "" {
+		if !strings.HasSuffix(stdout, "\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("STDERR:\n")
+		sb.WriteString(stderr)
+	}
+	return sb.String()
+}
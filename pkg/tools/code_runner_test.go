@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCodeRunnerTool_Bash_CapturesStdoutAndStderr(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	tool := NewCodeRunnerTool(t.TempDir(), CodeRunnerToolOptions{TimeoutSeconds: 5})
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"language": "bash",
+		"code":     "echo out; echo err >&2",
+	})
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "STDOUT:\nout") || !strings.Contains(result.ForLLM, "STDERR:\nerr") {
+		t.Fatalf("expected stdout and stderr sections, got: %q", result.ForLLM)
+	}
+}
+
+func TestCodeRunnerTool_NonZeroExitIsError(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	tool := NewCodeRunnerTool("", CodeRunnerToolOptions{TimeoutSeconds: 5})
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"language": "sh",
+		"code":     "exit 3",
+	})
+	if !result.IsError {
+		t.Fatalf("expected error for non-zero exit")
+	}
+	if !strings.Contains(result.ForLLM, "Exit code") {
+		t.Fatalf("expected exit code in output, got: %s", result.ForLLM)
+	}
+}
+
+func TestCodeRunnerTool_RejectsDisallowedLanguage(t *testing.T) {
+	tool := NewCodeRunnerTool("", CodeRunnerToolOptions{AllowedLanguages: []string{"python"}})
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"language": "bash",
+		"code":     "echo hi",
+	})
+	if !result.IsError || !strings.Contains(result.ForLLM, "not allowed") {
+		t.Fatalf("expected disallowed language error, got: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{
+		"language": "ruby",
+		"code":     "puts 1",
+	})
+	if !result.IsError || !strings.Contains(result.ForLLM, "unsupported language") {
+		t.Fatalf("expected unsupported language error, got: %s", result.ForLLM)
+	}
+}
+
+func TestCodeRunnerTool_Timeout(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	tool := NewCodeRunnerTool("", CodeRunnerToolOptions{TimeoutSeconds: 1})
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"language": "bash",
+		"code":     "sleep 5",
+	})
+	if !result.IsError || !strings.Contains(result.ForLLM, "timed out") {
+		t.Fatalf("expected timeout error, got: %s", result.ForLLM)
+	}
+}
+
+func TestCodeRunnerTool_SandboxCommand(t *testing.T) {
+	tool := NewCodeRunnerTool("", CodeRunnerToolOptions{UseSandbox: true})
+	lang, _ := resolveCodeLanguage("js")
+	name, args := tool.commandFor(lang, "/tmp/snippet", "main.js", "dotagent-code-test")
+	if name != "docker" {
+		t.Fatalf("expected docker command, got %q", name)
+	}
+	joined := strings.Join(args, " ")
+	for _, want := range []string{"--name dotagent-code-test", "--network none", "/tmp/snippet:/sandbox:ro", "node:22-slim timeout 30 node /sandbox/main.js"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("expected %q in docker args, got: %s", want, joined)
+		}
+	}
+}
+
+func TestCodeRunnerTool_RestrictForcesSandbox(t *testing.T) {
+	tool := NewCodeRunnerTool(t.TempDir(), CodeRunnerToolOptions{Restrict: true})
+	lang, _ := resolveCodeLanguage("bash")
+	if name, _ := tool.commandFor(lang, "/tmp/snippet", "main.sh", "dotagent-code-test"); name != "docker" {
+		t.Fatalf("expected a restricted runner to use the docker sandbox, got %q", name)
+	}
+	if _, err := exec.LookPath("docker"); err == nil {
+		return
+	}
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"language": "bash",
+		"code":     "echo hi",
+	})
+	if !result.IsError || !strings.Contains(result.ForLLM, "Docker") {
+		t.Fatalf("expected a restricted runner without docker to refuse, got: %s", result.ForLLM)
+	}
+}
@@ -0,0 +1,349 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/utils"
+)
+
+const (
+	codeSearchMaxResults  = 200
+	codeSearchMaxSnippet  = 300
+	codeSearchTimeout     = 30 * time.Second
+	codeSearchMaxLineScan = 1 << 20
+)
+
+// codeSearchLanguages maps language names to the file globs searched.
+var codeSearchLanguages = map[string][]string{
+	"go":         {"*.go"},
+	"python":     {"*.py"},
+	"javascript": {"*.js", "*.jsx", "*.mjs", "*.cjs"},
+	"typescript": {"*.ts", "*.tsx"},
+	"java":       {"*.java"},
+	"kotlin":     {"*.kt", "*.kts"},
+	"rust":       {"*.rs"},
+	"c":          {"*.c", "*.h"},
+	"cpp":        {"*.cc", "*.cpp", "*.cxx", "*.hh", "*.hpp", "*.h"},
+	"csharp":     {"*.cs"},
+	"ruby":       {"*.rb"},
+	"php":        {"*.php"},
+	"swift":      {"*.swift"},
+	"shell":      {"*.sh", "*.bash"},
+}
+
+var codeSearchLanguageAliases = map[string]string{
+	"golang": "go",
+	"py":     "python",
+	"js":     "javascript",
+	"ts":     "typescript",
+	"rs":     "rust",
+	"c++":    "cpp",
+	"cs":     "csharp",
+	"c#":     "csharp",
+	"rb":     "ruby",
+	"sh":     "shell",
+	"bash":   "shell",
+}
+
+// codeSearchSkipDirs are not searched by the grep fallback; ripgrep skips
+// them through .gitignore and its hidden-file rules.
+var codeSearchSkipDirs = []string{".git", ".history", "node_modules", "vendor"}
+
+// CodeSearchMatch is one line matched by code_search.
+type CodeSearchMatch struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Snippet string `json:"snippet"`
+}
+
+// CodeSearchTool finds regex matches in workspace source files with ripgrep,
+// or grep -r when ripgrep is not installed.
+type CodeSearchTool struct {
+	workspace string
+	restrict  bool
+	lookPath  func(string) (string, error)
+}
+
+func NewCodeSearchTool(workspace string, restrict bool) *CodeSearchTool {
+	return &CodeSearchTool{workspace: workspace, restrict: restrict, lookPath: exec.LookPath}
+}
+
+func (t *CodeSearchTool) Name() string {
+	return "code_search"
+}
+
+func (t *CodeSearchTool) Description() string {
+	return fmt.Sprintf("Search source files for a regular expression, e.g. every usage of a function or class. Returns a JSON array of {file, line, column, snippet}, at most %d matches.", codeSearchMaxResults)
+}
+
+func (t *CodeSearchTool) Parameters() map[string]interface{} {
+	languages := make([]string, 0, len(codeSearchLanguages))
+	for name := range codeSearchLanguages {
+		languages = append(languages, name)
+	}
+	sort.Strings(languages)
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"pattern": map[string]interface{}{
+				"type":        "string",
+				"description": "Regular expression to search for, e.g. \\bParseConfig\\(",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File or directory to search (default: workspace root)",
+			},
+			"language": map[string]interface{}{
+				"type":        "string",
+				"description": "Only search files of this language",
+				"enum":        languages,
+			},
+		},
+		"required": []string{"pattern"},
+	}
+}
+
+func (t *CodeSearchTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	pattern, _ := args["pattern"].(string)
+	if strings.TrimSpace(pattern) == "" {
+		return ErrorResult("pattern is required")
+	}
+	path, _ := args["path"].(string)
+	if strings.TrimSpace(path) == "" {
+		path = "."
+	}
+	var globs []string
+	if raw, _ := args["language"].(string); strings.TrimSpace(raw) != "" {
+		lang := strings.ToLower(strings.TrimSpace(raw))
+		if alias, ok := codeSearchLanguageAliases[lang]; ok {
+			lang = alias
+		}
+		var ok bool
+		if globs, ok = codeSearchLanguages[lang]; !ok {
+			return ErrorResult(fmt.Sprintf("unsupported language %q", raw))
+		}
+	}
+
+	root, err := validatePath(path, t.workspace, t.restrict)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	searchCtx, cancel := context.WithTimeout(ctx, codeSearchTimeout)
+	defer cancel()
+
+	var name string
+	var cmdArgs []string
+	var parse func(io.Reader, func(CodeSearchMatch) bool) error
+	if rg, err := t.lookPath("rg"); err == nil {
+		name, cmdArgs, parse = rg, ripgrepArgs(pattern, root, globs), parseRipgrepJSON
+	} else if grep, err := t.lookPath("grep"); err == nil {
+		name, cmdArgs = grep, grepArgs(pattern, root, globs)
+		parse = func(r io.Reader, emit func(CodeSearchMatch) bool) error {
+			return parseGrepOutput(r, pattern, emit)
+		}
+	} else {
+		return ErrorResult("code_search needs ripgrep (rg) or grep on PATH")
+	}
+
+	cmd := exec.CommandContext(searchCtx, name, cmdArgs...)
+	cmd.Dir = root
+	if !isDir(root) {
+		cmd.Dir = filepath.Dir(root)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("code search failed: %v", err))
+	}
+	if err := cmd.Start(); err != nil {
+		return ErrorResult(fmt.Sprintf("code search failed: %v", err))
+	}
+
+	matches := []CodeSearchMatch{}
+	truncated := false
+	parseErr := parse(stdout, func(m CodeSearchMatch) bool {
+		if len(matches) == codeSearchMaxResults {
+			truncated = true
+			return false
+		}
+		m.File = t.displayPath(m.File, cmd.Dir)
+		m.Snippet = utils.Truncate(m.Snippet, codeSearchMaxSnippet)
+		matches = append(matches, m)
+		return true
+	})
+	if truncated {
+		cancel()
+	}
+	_, _ = io.Copy(io.Discard, stdout)
+	waitErr := cmd.Wait()
+
+	switch {
+	case searchCtx.Err() == context.DeadlineExceeded:
+		return ErrorResult(fmt.Sprintf("code search timed out after %v", codeSearchTimeout))
+	case parseErr != nil:
+		return ErrorResult(fmt.Sprintf("code search failed: %v", parseErr))
+	case waitErr != nil && !truncated && !isNoMatchExit(waitErr):
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = waitErr.Error()
+		}
+		return ErrorResult(fmt.Sprintf("code search failed: %s", msg))
+	}
+
+	raw, err := json.Marshal(matches)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("encode code search results: %v", err))
+	}
+	out := string(raw)
+	if truncated {
+		out += fmt.Sprintf("\n(results truncated at %d matches; narrow the pattern, path or language)", codeSearchMaxResults)
+	}
+	return SilentResult(out)
+}
+
+// displayPath reports file relative to the workspace when it lies inside it.
+func (t *CodeSearchTool) displayPath(file, dir string) string {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	if t.workspace == "" {
+		return file
+	}
+	workspace, err := filepath.Abs(t.workspace)
+	if err != nil {
+		return file
+	}
+	if rel, err := filepath.Rel(workspace, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return file
+}
+
+func ripgrepArgs(pattern, root string, globs []string) []string {
+	args := []string{"--json", "--no-config", "--max-columns", "1000", "--max-filesize", "10M"}
+	for _, glob := range globs {
+		args = append(args, "--glob", glob)
+	}
+	return append(args, "-e", pattern, "--", root)
+}
+
+func grepArgs(pattern, root string, globs []string) []string {
+	args := []string{"-rnHIE", "--null"}
+	for _, dir := range codeSearchSkipDirs {
+		args = append(args, "--exclude-dir="+dir)
+	}
+	for _, glob := range globs {
+		args = append(args, "--include="+glob)
+	}
+	return append(args, "-e", pattern, "--", root)
+}
+
+// parseRipgrepJSON reads `rg --json` output and emits each match until emit
+// returns false.
+func parseRipgrepJSON(r io.Reader, emit func(CodeSearchMatch) bool) error {
+	type rgText struct {
+		Text string `json:"text"`
+	}
+	type rgEvent struct {
+		Type string `json:"type"`
+		Data struct {
+			Path       rgText `json:"path"`
+			Lines      rgText `json:"lines"`
+			LineNumber int    `json:"line_number"`
+			Submatches []struct {
+				Start int `json:"start"`
+			} `json:"submatches"`
+		} `json:"data"`
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), codeSearchMaxLineScan)
+	for scanner.Scan() {
+		var event rgEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || event.Type != "match" {
+			continue
+		}
+		column := 1
+		if len(event.Data.Submatches) > 0 {
+			column = columnAt(event.Data.Lines.Text, event.Data.Submatches[0].Start)
+		}
+		if !emit(CodeSearchMatch{
+			File:    event.Data.Path.Text,
+			Line:    event.Data.LineNumber,
+			Column:  column,
+			Snippet: strings.TrimSpace(strings.TrimRight(event.Data.Lines.Text, "\r\n")),
+		}) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// parseGrepOutput reads `grep -rn --null` output. grep does not report
+// columns, so the column of the first match is found with Go's regexp when
+// the pattern compiles, and is 1 otherwise.
+func parseGrepOutput(r io.Reader, pattern string, emit func(CodeSearchMatch) bool) error {
+	re, _ := regexp.Compile(pattern)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), codeSearchMaxLineScan)
+	for scanner.Scan() {
+		file, rest, ok := strings.Cut(scanner.Text(), "\x00")
+		if !ok {
+			continue
+		}
+		lineText, text, ok := strings.Cut(rest, ":")
+		if !ok {
+			continue
+		}
+		line, err := strconv.Atoi(lineText)
+		if err != nil {
+			continue
+		}
+		column := 1
+		if re != nil {
+			if loc := re.FindStringIndex(text); loc != nil {
+				column = columnAt(text, loc[0])
+			}
+		}
+		if !emit(CodeSearchMatch{File: file, Line: line, Column: column, Snippet: strings.TrimSpace(text)}) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// columnAt converts a byte offset in line to a 1-based character column.
+func columnAt(line string, offset int) int {
+	if offset > len(line) {
+		offset = len(line)
+	}
+	return len([]rune(line[:offset])) + 1
+}
+
+// isNoMatchExit reports whether err is the exit status 1 that grep and
+// ripgrep use for "no matches".
+func isNoMatchExit(err error) bool {
+	exitErr, ok := err.(*exec.ExitError)
+	return ok && exitErr.ExitCode() == 1
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
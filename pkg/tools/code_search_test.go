@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCodeSearchFixture(t *testing.T, workspace string) {
+	t.Helper()
+	files := map[string]string{
+		"main.go":        "package main\n\nfunc main() {\n\tParseConfig(\"a\")\n}\n",
+		"pkg/config.go":  "package pkg\n\nfunc ParseConfig(path string) error {\n\treturn nil\n}\n",
+		"scripts/run.py": "ParseConfig('b')\n",
+		".git/HEAD.go":   "ParseConfig()\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(workspace, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func decodeCodeSearch(t *testing.T, result *ToolResult) []CodeSearchMatch {
+	t.Helper()
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	raw, _, _ := strings.Cut(result.ForLLM, "\n")
+	var matches []CodeSearchMatch
+	if err := json.Unmarshal([]byte(raw), &matches); err != nil {
+		t.Fatalf("decode results %q: %v", result.ForLLM, err)
+	}
+	return matches
+}
+
+func grepOnlyLookPath(name string) (string, error) {
+	if name == "rg" {
+		return "", exec.ErrNotFound
+	}
+	return exec.LookPath(name)
+}
+
+func TestCodeSearchTool_GrepFallback(t *testing.T) {
+	if _, err := exec.LookPath("grep"); err != nil {
+		t.Skip("grep not installed")
+	}
+	workspace := t.TempDir()
+	writeCodeSearchFixture(t, workspace)
+	tool := NewCodeSearchTool(workspace, true)
+	tool.lookPath = grepOnlyLookPath
+
+	matches := decodeCodeSearch(t, tool.Execute(context.Background(), map[string]interface{}{
+		"pattern":  `ParseConfig\(`,
+		"language": "go",
+	}))
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 go matches outside .git, got %+v", matches)
+	}
+	byFile := map[string]CodeSearchMatch{}
+	for _, m := range matches {
+		byFile[m.File] = m
+	}
+	if m := byFile["main.go"]; m.Line != 4 || m.Column != 2 || m.Snippet != `ParseConfig("a")` {
+		t.Fatalf("unexpected main.go match: %+v", m)
+	}
+	if m := byFile["pkg/config.go"]; m.Line != 3 || m.Column != 6 {
+		t.Fatalf("unexpected pkg/config.go match: %+v", m)
+	}
+
+	matches = decodeCodeSearch(t, tool.Execute(context.Background(), map[string]interface{}{
+		"pattern": "ParseConfig",
+		"path":    "scripts",
+	}))
+	if len(matches) != 1 || matches[0].File != "scripts/run.py" {
+		t.Fatalf("expected the python match under scripts, got %+v", matches)
+	}
+
+	matches = decodeCodeSearch(t, tool.Execute(context.Background(), map[string]interface{}{
+		"pattern": "NoSuchSymbol",
+	}))
+	if len(matches) != 0 {
+		t.Fatalf("expected no matches, got %+v", matches)
+	}
+}
+
+func TestCodeSearchTool_LimitsResults(t *testing.T) {
+	if _, err := exec.LookPath("grep"); err != nil {
+		t.Skip("grep not installed")
+	}
+	workspace := t.TempDir()
+	content := strings.Repeat("needle()\n", codeSearchMaxResults+50)
+	if err := os.WriteFile(filepath.Join(workspace, "big.go"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewCodeSearchTool(workspace, true)
+	tool.lookPath = grepOnlyLookPath
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"pattern": "needle"})
+	if matches := decodeCodeSearch(t, result); len(matches) != codeSearchMaxResults {
+		t.Fatalf("expected %d matches, got %d", codeSearchMaxResults, len(matches))
+	}
+	if !strings.Contains(result.ForLLM, "truncated") {
+		t.Fatalf("expected truncation note, got %q", result.ForLLM)
+	}
+}
+
+func TestCodeSearchTool_RestrictAndValidation(t *testing.T) {
+	tool := NewCodeSearchTool(t.TempDir(), true)
+	tool.lookPath = grepOnlyLookPath
+
+	if result := tool.Execute(context.Background(), map[string]interface{}{"pattern": "x", "path": "/etc"}); !result.IsError || !strings.Contains(result.ForLLM, "outside the workspace") {
+		t.Fatalf("expected path outside workspace to be rejected, got %+v", result)
+	}
+	if result := tool.Execute(context.Background(), map[string]interface{}{"pattern": " "}); !result.IsError {
+		t.Fatalf("expected error for empty pattern")
+	}
+	if result := tool.Execute(context.Background(), map[string]interface{}{"pattern": "x", "language": "cobol"}); !result.IsError {
+		t.Fatalf("expected error for unsupported language")
+	}
+
+	tool.lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	if result := tool.Execute(context.Background(), map[string]interface{}{"pattern": "x"}); !result.IsError || !strings.Contains(result.ForLLM, "ripgrep") {
+		t.Fatalf("expected missing search binary error, got %+v", result)
+	}
+}
+
+func TestParseRipgrepJSON(t *testing.T) {
+	output := strings.Join([]string{
+		`{"type":"begin","data":{"path":{"text":"src/app.ts"}}}`,
+		`{"type":"match","data":{"path":{"text":"src/app.ts"},"lines":{"text":"  const café = loadUser(id);\n"},"line_number":12,"absolute_offset":100,"submatches":[{"match":{"text":"loadUser"},"start":16,"end":24}]}}`,
+		`{"type":"end","data":{"path":{"text":"src/app.ts"}}}`,
+		`{"type":"summary","data":{}}`,
+	}, "\n")
+
+	var matches []CodeSearchMatch
+	if err := parseRipgrepJSON(strings.NewReader(output), func(m CodeSearchMatch) bool {
+		matches = append(matches, m)
+		return true
+	}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("expected one match, got %+v", matches)
+	}
+	// "  const café = " is 16 bytes but 15 characters.
+	want := CodeSearchMatch{File: "src/app.ts", Line: 12, Column: 16, Snippet: "const café = loadUser(id);"}
+	if matches[0] != want {
+		t.Fatalf("got %+v, want %+v", matches[0], want)
+	}
+}
@@ -48,6 +48,8 @@ func (t *ConnectorProxyTool) Name() string {
 	return t.name
 }
 
+func (t *ConnectorProxyTool) UntrustedContent() bool { return true }
+
 func (t *ConnectorProxyTool) Description() string {
 	if t.description == "" {
 		return "Connector-backed tool"
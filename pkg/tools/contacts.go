@@ -0,0 +1,659 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+const (
+	contactsFileName        = "contacts.json"
+	contactSearchMaxResults = 20
+	vCardMaxLineOctets      = 75
+)
+
+// Contact is one address book entry. The JSON field names are the vCard 3.0
+// property names (RFC 2426), so a contacts.json converts to and from .vcf
+// without losing anything.
+type Contact struct {
+	UID   string   `json:"uid"`
+	FN    string   `json:"fn"`
+	Email []string `json:"email,omitempty"`
+	Tel   []string `json:"tel,omitempty"`
+	Note  string   `json:"note,omitempty"`
+	Rev   string   `json:"rev,omitempty"`
+}
+
+// contactsMu serializes read-modify-write cycles on contacts files shared by
+// the contact tools of every agent in the process.
+var contactsMu sync.Mutex
+
+// contactBook resolves the contacts file the contact_* tools operate on:
+// contacts.json in the workspace, or, for unrestricted agents, any .json or
+// vCard (.vcf) file passed as "file".
+type contactBook struct {
+	workspace string
+	restrict  bool
+}
+
+var contactFileParam = map[string]interface{}{
+	"type":        "string",
+	"description": "Contacts file: a .json or vCard .vcf file (default contacts.json in the workspace; workspace-restricted agents can only use the default)",
+}
+
+func (b contactBook) path(args map[string]interface{}) (string, error) {
+	defaultPath := filepath.Join(b.workspace, contactsFileName)
+	file, _ := args["file"].(string)
+	if strings.TrimSpace(file) == "" {
+		return defaultPath, nil
+	}
+	resolved, err := validatePath(expandHomePath(strings.TrimSpace(file)), b.workspace, b.restrict)
+	if err != nil {
+		return "", err
+	}
+	if b.restrict {
+		if want, err := filepath.Abs(defaultPath); err != nil || resolved != want {
+			return "", fmt.Errorf("access denied: workspace-restricted agents can only use %s", contactsFileName)
+		}
+	}
+	return resolved, nil
+}
+
+func isVCardPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".vcf" || ext == ".vcard"
+}
+
+// loadContacts reads a contacts file; a missing file is an empty book.
+func loadContacts(path string) ([]Contact, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read contacts: %w", err)
+	}
+	if isVCardPath(path) {
+		return ParseVCards(string(data))
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return nil, nil
+	}
+	var contacts []Contact
+	if err := json.Unmarshal(data, &contacts); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+	return contacts, nil
+}
+
+func saveContacts(path string, contacts []Contact) error {
+	var data []byte
+	if isVCardPath(path) {
+		data = []byte(FormatVCards(contacts))
+	} else {
+		var err error
+		if data, err = json.MarshalIndent(contacts, "", "  "); err != nil {
+			return fmt.Errorf("encode contacts: %w", err)
+		}
+		data = append(data, '\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".contacts-*")
+	if err != nil {
+		return fmt.Errorf("write contacts: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write contacts: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return fmt.Errorf("write contacts: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write contacts: %w", err)
+	}
+	return nil
+}
+
+// ContactAddTool adds a contact or merges new details into an existing one
+// with the same name.
+type ContactAddTool struct {
+	book contactBook
+	now  func() time.Time
+}
+
+func NewContactAddTool(workspace string, restrict bool) *ContactAddTool {
+	return &ContactAddTool{book: contactBook{workspace: workspace, restrict: restrict}, now: time.Now}
+}
+
+func (t *ContactAddTool) Name() string {
+	return "contact_add"
+}
+
+func (t *ContactAddTool) Description() string {
+	return "Save a person's name, email, phone number and notes to the contacts file. Adding a name that already exists merges the new email and phone into it and replaces its notes when notes are given."
+}
+
+func (t *ContactAddTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "Full name",
+			},
+			"email": map[string]interface{}{
+				"type":        "string",
+				"description": "Email address",
+			},
+			"phone": map[string]interface{}{
+				"type":        "string",
+				"description": "Phone number",
+			},
+			"notes": map[string]interface{}{
+				"type":        "string",
+				"description": "Free-form notes (how you know them, birthday, ...)",
+			},
+			"file": contactFileParam,
+		},
+		"required": []string{"name"},
+	}
+}
+
+func (t *ContactAddTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	name := contactArg(args, "name")
+	if name == "" {
+		return ErrorResult("name is required")
+	}
+	email := contactArg(args, "email")
+	phone := contactArg(args, "phone")
+	notes := contactArg(args, "notes")
+	if email != "" && !strings.Contains(email, "@") {
+		return ErrorResult(fmt.Sprintf("email %q is not an email address", email))
+	}
+	path, err := t.book.path(args)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	contactsMu.Lock()
+	defer contactsMu.Unlock()
+	contacts, err := loadContacts(path)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	rev := t.now().UTC().Format(time.RFC3339)
+	idx := -1
+	for i := range contacts {
+		if strings.EqualFold(contacts[i].FN, name) {
+			idx = i
+			break
+		}
+	}
+	verb := "Updated"
+	if idx < 0 {
+		contacts = append(contacts, Contact{UID: uuid.NewString(), FN: name})
+		idx = len(contacts) - 1
+		verb = "Added"
+	}
+	c := &contacts[idx]
+	if email != "" && !containsFold(c.Email, email) {
+		c.Email = append(c.Email, email)
+	}
+	if phone != "" && !containsPhone(c.Tel, phone) {
+		c.Tel = append(c.Tel, phone)
+	}
+	if notes != "" {
+		c.Note = notes
+	}
+	c.Rev = rev
+	sort.SliceStable(contacts, func(i, j int) bool {
+		return strings.ToLower(contacts[i].FN) < strings.ToLower(contacts[j].FN)
+	})
+	if err := saveContacts(path, contacts); err != nil {
+		return ErrorResult(err.Error())
+	}
+	for _, saved := range contacts {
+		if strings.EqualFold(saved.FN, name) {
+			return NewToolResult(fmt.Sprintf("%s contact in %s:\n%s", verb, path, formatContact(saved)))
+		}
+	}
+	return NewToolResult(fmt.Sprintf("%s contact %s in %s", verb, name, path))
+}
+
+// ContactSearchTool finds contacts whose name, email, phone or notes match a
+// query.
+type ContactSearchTool struct {
+	book contactBook
+}
+
+func NewContactSearchTool(workspace string, restrict bool) *ContactSearchTool {
+	return &ContactSearchTool{book: contactBook{workspace: workspace, restrict: restrict}}
+}
+
+func (t *ContactSearchTool) Name() string {
+	return "contact_search"
+}
+
+func (t *ContactSearchTool) Description() string {
+	return "Search the contacts file by name, email, phone number or notes (case-insensitive; phone numbers match ignoring spaces and punctuation)."
+}
+
+func (t *ContactSearchTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Text to look for",
+			},
+			"file": contactFileParam,
+		},
+		"required": []string{"query"},
+	}
+}
+
+func (t *ContactSearchTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	query := contactArg(args, "query")
+	if query == "" {
+		return ErrorResult("query is required")
+	}
+	path, err := t.book.path(args)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	contactsMu.Lock()
+	contacts, err := loadContacts(path)
+	contactsMu.Unlock()
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	var matches []Contact
+	for _, c := range contacts {
+		if contactMatches(c, query) {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) == 0 {
+		return NewToolResult(fmt.Sprintf("No contacts match %q.", query))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d contact(s) match %q:", len(matches), query)
+	for i, c := range matches {
+		if i == contactSearchMaxResults {
+			fmt.Fprintf(&b, "\n... and %d more; refine the query", len(matches)-i)
+			break
+		}
+		b.WriteString("\n\n")
+		b.WriteString(formatContact(c))
+	}
+	return NewToolResult(b.String())
+}
+
+// ContactGetTool returns one contact by name, as text, JSON or a vCard.
+type ContactGetTool struct {
+	book contactBook
+}
+
+func NewContactGetTool(workspace string, restrict bool) *ContactGetTool {
+	return &ContactGetTool{book: contactBook{workspace: workspace, restrict: restrict}}
+}
+
+func (t *ContactGetTool) Name() string {
+	return "contact_get"
+}
+
+func (t *ContactGetTool) Description() string {
+	return "Look up one contact by name (exact, or a unique partial match). format=vcard returns a vCard 3.0 card ready to share or import elsewhere."
+}
+
+func (t *ContactGetTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the contact",
+			},
+			"format": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"text", "json", "vcard"},
+				"description": "Output format (default text)",
+			},
+			"file": contactFileParam,
+		},
+		"required": []string{"name"},
+	}
+}
+
+func (t *ContactGetTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	name := contactArg(args, "name")
+	if name == "" {
+		return ErrorResult("name is required")
+	}
+	format := strings.ToLower(contactArg(args, "format"))
+	if format == "" {
+		format = "text"
+	}
+	if format != "text" && format != "json" && format != "vcard" {
+		return ErrorResult("format must be one of: text, json, vcard")
+	}
+	path, err := t.book.path(args)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	contactsMu.Lock()
+	contacts, err := loadContacts(path)
+	contactsMu.Unlock()
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	var partial []Contact
+	var found *Contact
+	for i, c := range contacts {
+		if strings.EqualFold(c.FN, name) {
+			found = &contacts[i]
+			break
+		}
+		if strings.Contains(strings.ToLower(c.FN), strings.ToLower(name)) {
+			partial = append(partial, c)
+		}
+	}
+	if found == nil {
+		switch len(partial) {
+		case 0:
+			return ErrorResult(fmt.Sprintf("no contact named %q", name))
+		case 1:
+			found = &partial[0]
+		default:
+			names := make([]string, len(partial))
+			for i, c := range partial {
+				names[i] = c.FN
+			}
+			return ErrorResult(fmt.Sprintf("%q matches several contacts: %s", name, strings.Join(names, ", ")))
+		}
+	}
+
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(found, "", "  ")
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("failed to encode contact: %v", err))
+		}
+		return NewToolResult(string(data))
+	case "vcard":
+		return NewToolResult(FormatVCards([]Contact{*found}))
+	default:
+		return NewToolResult(formatContact(*found))
+	}
+}
+
+func contactArg(args map[string]interface{}, key string) string {
+	v, _ := args[key].(string)
+	return strings.TrimSpace(v)
+}
+
+func formatContact(c Contact) string {
+	lines := []string{c.FN}
+	for _, e := range c.Email {
+		lines = append(lines, "  email: "+e)
+	}
+	for _, p := range c.Tel {
+		lines = append(lines, "  phone: "+p)
+	}
+	if c.Note != "" {
+		lines = append(lines, "  notes: "+c.Note)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func contactMatches(c Contact, query string) bool {
+	q := strings.ToLower(query)
+	if strings.Contains(strings.ToLower(c.FN), q) || strings.Contains(strings.ToLower(c.Note), q) {
+		return true
+	}
+	for _, e := range c.Email {
+		if strings.Contains(strings.ToLower(e), q) {
+			return true
+		}
+	}
+	if digits := phoneDigits(query); len(digits) >= 3 {
+		for _, p := range c.Tel {
+			if strings.Contains(phoneDigits(p), digits) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, v string) bool {
+	for _, existing := range values {
+		if strings.EqualFold(existing, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPhone(values []string, v string) bool {
+	for _, existing := range values {
+		if phoneDigits(existing) == phoneDigits(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func phoneDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// FormatVCards renders contacts as vCard 3.0 cards with CRLF line endings
+// and lines folded at 75 octets.
+func FormatVCards(contacts []Contact) string {
+	var b strings.Builder
+	for _, c := range contacts {
+		writeVCardLine(&b, "BEGIN:VCARD")
+		writeVCardLine(&b, "VERSION:3.0")
+		if c.UID != "" {
+			writeVCardLine(&b, "UID:"+escapeVCard(c.UID))
+		}
+		writeVCardLine(&b, "FN:"+escapeVCard(c.FN))
+		writeVCardLine(&b, "N:"+vCardStructuredName(c.FN))
+		for _, e := range c.Email {
+			writeVCardLine(&b, "EMAIL;TYPE=INTERNET:"+escapeVCard(e))
+		}
+		for _, p := range c.Tel {
+			writeVCardLine(&b, "TEL:"+escapeVCard(p))
+		}
+		if c.Note != "" {
+			writeVCardLine(&b, "NOTE:"+escapeVCard(c.Note))
+		}
+		if c.Rev != "" {
+			writeVCardLine(&b, "REV:"+escapeVCard(c.Rev))
+		}
+		writeVCardLine(&b, "END:VCARD")
+	}
+	return b.String()
+}
+
+// vCardStructuredName derives the required N property from a full name,
+// treating the last word as the family name.
+func vCardStructuredName(fn string) string {
+	words := strings.Fields(fn)
+	if len(words) == 0 {
+		return ";;;;"
+	}
+	family := words[len(words)-1]
+	given := strings.Join(words[:len(words)-1], " ")
+	return escapeVCard(family) + ";" + escapeVCard(given) + ";;;"
+}
+
+func writeVCardLine(b *strings.Builder, line string) {
+	for len(line) > vCardMaxLineOctets {
+		cut := vCardMaxLineOctets
+		// Never split a UTF-8 sequence across folded lines.
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+var vCardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeVCard(s string) string {
+	return vCardEscaper.Replace(s)
+}
+
+func unescapeVCard(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// ParseVCards reads the cards in a .vcf file. It understands the properties
+// Contact holds (FN, N, EMAIL, TEL, NOTE, UID, REV) in vCard 2.1, 3.0 and 4.0
+// files and ignores the rest.
+func ParseVCards(text string) ([]Contact, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	// Unfold continuation lines, which start with a space or tab.
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\n ", ""), "\n\t", "")
+
+	var contacts []Contact
+	var card *Contact
+	var structuredName string
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("parse vCard line %d: missing ':'", n+1)
+		}
+		prop, _, _ := strings.Cut(key, ";")
+		// Drop a group prefix such as "item1.EMAIL".
+		if dot := strings.LastIndex(prop, "."); dot >= 0 {
+			prop = prop[dot+1:]
+		}
+		prop = strings.ToUpper(strings.TrimSpace(prop))
+		value = strings.TrimSpace(value)
+
+		switch {
+		case prop == "BEGIN" && strings.EqualFold(value, "VCARD"):
+			card, structuredName = &Contact{}, ""
+			continue
+		case prop == "END" && strings.EqualFold(value, "VCARD"):
+			if card == nil {
+				return nil, fmt.Errorf("parse vCard line %d: END without BEGIN", n+1)
+			}
+			if card.FN == "" {
+				card.FN = nameFromStructured(structuredName)
+			}
+			if card.FN != "" {
+				if card.UID == "" {
+					card.UID = uuid.NewString()
+				}
+				contacts = append(contacts, *card)
+			}
+			card = nil
+			continue
+		case card == nil:
+			continue
+		}
+		switch prop {
+		case "FN":
+			card.FN = unescapeVCard(value)
+		case "N":
+			structuredName = value
+		case "EMAIL":
+			if v := unescapeVCard(value); v != "" {
+				card.Email = append(card.Email, v)
+			}
+		case "TEL":
+			if v := strings.TrimPrefix(unescapeVCard(value), "tel:"); v != "" {
+				card.Tel = append(card.Tel, v)
+			}
+		case "NOTE":
+			card.Note = unescapeVCard(value)
+		case "UID":
+			card.UID = unescapeVCard(value)
+		case "REV":
+			card.Rev = unescapeVCard(value)
+		}
+	}
+	if card != nil {
+		return nil, errors.New("parse vCard: missing END:VCARD")
+	}
+	return contacts, nil
+}
+
+// nameFromStructured turns an N value (family;given;additional;prefix;suffix)
+// into a display name.
+func nameFromStructured(n string) string {
+	parts := splitVCardStructured(n)
+	for len(parts) < 5 {
+		parts = append(parts, "")
+	}
+	return strings.Join(strings.Fields(strings.Join([]string{parts[3], parts[1], parts[2], parts[0], parts[4]}, " ")), " ")
+}
+
+func splitVCardStructured(s string) []string {
+	var parts []string
+	var cur strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			cur.WriteByte(s[i])
+			cur.WriteByte(s[i+1])
+			i++
+		case s[i] == ';':
+			parts = append(parts, unescapeVCard(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteByte(s[i])
+		}
+	}
+	return append(parts, unescapeVCard(cur.String()))
+}
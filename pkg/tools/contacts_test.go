@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContactTools_AddSearchGet(t *testing.T) {
+	workspace := t.TempDir()
+	add := NewContactAddTool(workspace, true)
+	add.now = func() time.Time { return time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC) }
+	search := NewContactSearchTool(workspace, true)
+	get := NewContactGetTool(workspace, true)
+	ctx := context.Background()
+
+	for _, args := range []map[string]interface{}{
+		{"name": "Grace Hopper", "email": "grace@navy.example", "phone": "+1 (555) 010-2000", "notes": "COBOL"},
+		{"name": "Ada Lovelace", "email": "ada@engine.example"},
+		{"name": "grace hopper", "email": "GRACE@navy.example", "phone": "+15550102000"},
+		{"name": "Grace Hopper", "phone": "555 0199"},
+	} {
+		if result := add.Execute(ctx, args); result.IsError {
+			t.Fatalf("add %v: %s", args, result.ForLLM)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(workspace, "contacts.json"))
+	if err != nil {
+		t.Fatalf("read contacts.json: %v", err)
+	}
+	var saved []Contact
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("decode contacts.json: %v", err)
+	}
+	if len(saved) != 2 || saved[0].FN != "Ada Lovelace" || saved[1].FN != "Grace Hopper" {
+		t.Fatalf("unexpected contacts: %+v", saved)
+	}
+	grace := saved[1]
+	if !reflect.DeepEqual(grace.Email, []string{"grace@navy.example"}) || !reflect.DeepEqual(grace.Tel, []string{"+1 (555) 010-2000", "555 0199"}) {
+		t.Fatalf("expected merged details, got %+v", grace)
+	}
+	if grace.Note != "COBOL" || grace.UID == "" || grace.Rev != "2026-05-01T09:00:00Z" {
+		t.Fatalf("unexpected contact fields: %+v", grace)
+	}
+
+	result := search.Execute(ctx, map[string]interface{}{"query": "555-010"})
+	if result.IsError || !strings.Contains(result.ForLLM, "1 contact(s)") || !strings.Contains(result.ForLLM, "Grace Hopper") {
+		t.Fatalf("unexpected phone search result: %q", result.ForLLM)
+	}
+	result = search.Execute(ctx, map[string]interface{}{"query": "EXAMPLE"})
+	if !strings.Contains(result.ForLLM, "2 contact(s)") {
+		t.Fatalf("unexpected email search result: %q", result.ForLLM)
+	}
+	result = search.Execute(ctx, map[string]interface{}{"query": "fortran"})
+	if result.IsError || !strings.Contains(result.ForLLM, "No contacts match") {
+		t.Fatalf("unexpected empty search result: %q", result.ForLLM)
+	}
+
+	result = get.Execute(ctx, map[string]interface{}{"name": "ada"})
+	if result.IsError || result.ForLLM != "Ada Lovelace\n  email: ada@engine.example" {
+		t.Fatalf("unexpected partial get: %q", result.ForLLM)
+	}
+	result = get.Execute(ctx, map[string]interface{}{"name": "a"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "several contacts") {
+		t.Fatalf("expected ambiguous match error, got %q", result.ForLLM)
+	}
+	result = get.Execute(ctx, map[string]interface{}{"name": "Grace Hopper", "format": "vcard"})
+	if result.IsError || !strings.HasPrefix(result.ForLLM, "BEGIN:VCARD\r\nVERSION:3.0\r\n") || !strings.Contains(result.ForLLM, "N:Hopper;Grace;;;\r\n") {
+		t.Fatalf("unexpected vcard: %q", result.ForLLM)
+	}
+}
+
+func TestContactTools_RestrictedToWorkspaceFile(t *testing.T) {
+	workspace := t.TempDir()
+	add := NewContactAddTool(workspace, true)
+
+	for _, file := range []string{"other.json", filepath.Join(t.TempDir(), "contacts.json")} {
+		result := add.Execute(context.Background(), map[string]interface{}{"name": "Eve", "file": file})
+		if !result.IsError || !strings.Contains(result.ForLLM, "access denied") {
+			t.Fatalf("file %s: expected access denied, got %q", file, result.ForLLM)
+		}
+	}
+	result := add.Execute(context.Background(), map[string]interface{}{"name": "Eve", "file": "contacts.json"})
+	if result.IsError {
+		t.Fatalf("expected the workspace file to be allowed, got %q", result.ForLLM)
+	}
+}
+
+func TestContactTools_VCardFile(t *testing.T) {
+	dir := t.TempDir()
+	vcf := filepath.Join(dir, "export.vcf")
+	imported := "BEGIN:VCARD\r\nVERSION:2.1\r\nN:Turing;Alan;Mathison;Dr.;\r\nitem1.EMAIL;TYPE=INTERNET:alan@bletchley.example\r\nTEL;TYPE=CELL:+44 20 7946 0000\r\nNOTE:Enigma\\, Bombe\\nA\r\n CE\r\nX-IGNORED:yes\r\nEND:VCARD\r\n"
+	if err := os.WriteFile(vcf, []byte(imported), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	get := NewContactGetTool(t.TempDir(), false)
+	result := get.Execute(context.Background(), map[string]interface{}{"name": "Alan", "file": vcf, "format": "json"})
+	if result.IsError {
+		t.Fatalf("get from vcf: %s", result.ForLLM)
+	}
+	var c Contact
+	if err := json.Unmarshal([]byte(result.ForLLM), &c); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if c.FN != "Dr. Alan Mathison Turing" || c.Note != "Enigma, Bombe\nACE" || !reflect.DeepEqual(c.Email, []string{"alan@bletchley.example"}) {
+		t.Fatalf("unexpected imported contact: %+v", c)
+	}
+
+	add := NewContactAddTool(t.TempDir(), false)
+	longNote := strings.Repeat("é", 60)
+	if result := add.Execute(context.Background(), map[string]interface{}{"name": "Alan Turing", "notes": longNote, "file": vcf}); result.IsError {
+		t.Fatalf("add to vcf: %s", result.ForLLM)
+	}
+	data, err := os.ReadFile(vcf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(data), "\r\n") {
+		if len(line) > 76 {
+			t.Fatalf("line not folded: %q", line)
+		}
+	}
+	contacts, err := ParseVCards(string(data))
+	if err != nil {
+		t.Fatalf("reparse: %v", err)
+	}
+	if len(contacts) != 2 || contacts[0].FN != "Alan Turing" || contacts[0].Note != longNote || contacts[1].FN != c.FN || contacts[1].UID == "" {
+		t.Fatalf("unexpected round trip: %+v", contacts)
+	}
+}
@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/blake2b"
+)
+
+// cryptoKeyRefusal answers input that looks like a private key. The crypto_*
+// tools hash data, encode and decode base64 and generate UUIDs; they
+// deliberately have no key material: nothing here signs, encrypts or derives
+// keys, and keys are refused so they do not end up in the conversation.
+const cryptoKeyRefusal = "refusing to process private key material; the crypto tools do not handle keys or passwords"
+
+// CryptoHashTool computes a hex digest of its input.
+type CryptoHashTool struct{}
+
+func NewCryptoHashTool() *CryptoHashTool {
+	return &CryptoHashTool{}
+}
+
+func (t *CryptoHashTool) Name() string {
+	return "crypto_hash"
+}
+
+func (t *CryptoHashTool) Description() string {
+	return "Compute a hex digest of data (sha256, md5, blake2b), e.g. for checksums. Does not accept private keys or passwords."
+}
+
+func (t *CryptoHashTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"algorithm": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"sha256", "md5", "blake2b"},
+				"description": "Hash algorithm (default sha256)",
+			},
+			"data": map[string]interface{}{
+				"type":        "string",
+				"description": "Input to hash",
+			},
+		},
+		"required": []string{"data"},
+	}
+}
+
+func (t *CryptoHashTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	algorithm, _ := args["algorithm"].(string)
+	data, _ := args["data"].(string)
+	if looksLikeSecretKey(data) {
+		return ErrorResult(cryptoKeyRefusal)
+	}
+	return cryptoHash(strings.ToLower(strings.TrimSpace(algorithm)), data)
+}
+
+// CryptoBase64Tool encodes or decodes base64.
+type CryptoBase64Tool struct{}
+
+func NewCryptoBase64Tool() *CryptoBase64Tool {
+	return &CryptoBase64Tool{}
+}
+
+func (t *CryptoBase64Tool) Name() string {
+	return "crypto_base64"
+}
+
+func (t *CryptoBase64Tool) Description() string {
+	return "Encode data as base64 or decode base64 (standard or URL-safe) back to text. Does not accept private keys or passwords."
+}
+
+func (t *CryptoBase64Tool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"mode": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"encode", "decode"},
+				"description": "Direction (default encode)",
+			},
+			"data": map[string]interface{}{
+				"type":        "string",
+				"description": "Text to encode, or base64 to decode",
+			},
+		},
+		"required": []string{"data"},
+	}
+}
+
+func (t *CryptoBase64Tool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	mode, _ := args["mode"].(string)
+	data, _ := args["data"].(string)
+	if looksLikeSecretKey(data) {
+		return ErrorResult(cryptoKeyRefusal)
+	}
+	return cryptoBase64(strings.ToLower(strings.TrimSpace(mode)), data)
+}
+
+// CryptoUUIDTool generates random UUIDs.
+type CryptoUUIDTool struct{}
+
+func NewCryptoUUIDTool() *CryptoUUIDTool {
+	return &CryptoUUIDTool{}
+}
+
+func (t *CryptoUUIDTool) Name() string {
+	return "crypto_uuid"
+}
+
+func (t *CryptoUUIDTool) Description() string {
+	return "Generate a random (version 4) UUID."
+}
+
+func (t *CryptoUUIDTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	}
+}
+
+func (t *CryptoUUIDTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	return NewToolResult(uuid.NewString())
+}
+
+func cryptoHash(algorithm, data string) *ToolResult {
+	var h hash.Hash
+	switch algorithm {
+	case "", "sha256", "sha-256":
+		algorithm = "sha256"
+		h = sha256.New()
+	case "md5":
+		h = md5.New()
+	case "blake2b", "blake2b-256":
+		algorithm = "blake2b"
+		h, _ = blake2b.New256(nil)
+	default:
+		return ErrorResult("algorithm must be one of: sha256, md5, blake2b")
+	}
+	h.Write([]byte(data))
+	return NewToolResult(fmt.Sprintf("%s: %s", algorithm, hex.EncodeToString(h.Sum(nil))))
+}
+
+func cryptoBase64(mode, data string) *ToolResult {
+	switch mode {
+	case "", "encode":
+		return NewToolResult(base64.StdEncoding.EncodeToString([]byte(data)))
+	case "decode":
+		trimmed := strings.TrimSpace(data)
+		decoded, err := base64.StdEncoding.DecodeString(trimmed)
+		if err != nil {
+			decoded, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(trimmed, "="))
+		}
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("invalid base64 input: %v", err))
+		}
+		if looksLikeSecretKey(string(decoded)) {
+			return ErrorResult("decoded data contains private key material; not returning it")
+		}
+		return NewToolResult(string(decoded))
+	default:
+		return ErrorResult("mode must be one of: encode, decode")
+	}
+}
+
+// looksLikeSecretKey reports whether s contains a PEM or OpenSSH private key.
+func looksLikeSecretKey(s string) bool {
+	return strings.Contains(s, "PRIVATE KEY-----")
+}
@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// NamedAgentsDir is the workspace directory holding named agent configs, one
+// <name>.json file per agent.
+const NamedAgentsDir = "agents"
+
+var namedAgentNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// NamedAgentConfig specializes a subagent for delegate_to. Model overrides
+// the default model, SystemPrompt is appended to the subagent prompt and
+// Skills are loaded into it by name.
+type NamedAgentConfig struct {
+	Name         string   `json:"-"`
+	Description  string   `json:"description,omitempty"`
+	Model        string   `json:"model,omitempty"`
+	SystemPrompt string   `json:"system_prompt,omitempty"`
+	Skills       []string `json:"skills,omitempty"`
+}
+
+// LoadNamedAgent reads <dir>/<name>.json.
+func LoadNamedAgent(dir, name string) (NamedAgentConfig, error) {
+	name = strings.TrimSpace(name)
+	if !namedAgentNameRegex.MatchString(name) {
+		return NamedAgentConfig{}, fmt.Errorf("invalid agent name %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return NamedAgentConfig{}, fmt.Errorf("agent %q not found in %s", name, dir)
+		}
+		return NamedAgentConfig{}, fmt.Errorf("read agent %q: %w", name, err)
+	}
+	var cfg NamedAgentConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return NamedAgentConfig{}, fmt.Errorf("parse agent %q: %w", name, err)
+	}
+	cfg.Name = name
+	return cfg, nil
+}
+
+// ListNamedAgents returns the valid agent configs in dir sorted by name. A
+// missing directory yields no agents; unreadable configs are skipped.
+func ListNamedAgents(dir string) []NamedAgentConfig {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	agents := []NamedAgentConfig{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		cfg, err := LoadNamedAgent(dir, strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		agents = append(agents, cfg)
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Name < agents[j].Name })
+	return agents
+}
+
+// DelegateTool forwards a message to a named agent and returns its answer so
+// the calling agent can build on it. The agent runs synchronously through the
+// subagent manager with its own model, instructions and skills.
+type DelegateTool struct {
+	manager    *SubagentManager
+	agentsDir  string
+	loadSkills func(names []string) string
+	mu         sync.RWMutex
+	channel    string
+	chatID     string
+}
+
+func NewDelegateTool(manager *SubagentManager, workspace string) *DelegateTool {
+	return &DelegateTool{
+		manager:   manager,
+		agentsDir: filepath.Join(workspace, NamedAgentsDir),
+		channel:   "cli",
+		chatID:    "direct",
+	}
+}
+
+// SetSkillLoader sets how the skills listed in an agent config are turned
+// into prompt content. Without it, skills are ignored.
+func (t *DelegateTool) SetSkillLoader(fn func(names []string) string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.loadSkills = fn
+}
+
+func (t *DelegateTool) Name() string {
+	return "delegate_to"
+}
+
+func (t *DelegateTool) Description() string {
+	desc := "Forward a question or task to a specialized named agent and wait for its answer. Named agents are configured in workspace/agents/<name>.json."
+	agents := ListNamedAgents(t.agentsDir)
+	if len(agents) == 0 {
+		return desc + " No named agents are configured."
+	}
+	lines := make([]string, 0, len(agents))
+	for _, agent := range agents {
+		line := "- " + agent.Name
+		if d := strings.TrimSpace(agent.Description); d != "" {
+			line += ": " + d
+		}
+		lines = append(lines, line)
+	}
+	return desc + " Available agents:\n" + strings.Join(lines, "\n")
+}
+
+func (t *DelegateTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"agent_name": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of the agent to delegate to",
+			},
+			"message": map[string]interface{}{
+				"type":        "string",
+				"description": "The question or task for the agent, with any context it needs",
+			},
+		},
+		"required": []string{"agent_name", "message"},
+	}
+}
+
+func (t *DelegateTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *DelegateTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	name, _ := args["agent_name"].(string)
+	message, _ := args["message"].(string)
+	if strings.TrimSpace(name) == "" {
+		return ErrorResult("agent_name is required")
+	}
+	if strings.TrimSpace(message) == "" {
+		return ErrorResult("message is required")
+	}
+	if t.manager == nil {
+		return ErrorResult("Subagent manager not configured").WithError(fmt.Errorf("manager is nil"))
+	}
+
+	agent, err := LoadNamedAgent(t.agentsDir, name)
+	if err != nil {
+		return ErrorResult(err.Error()).WithError(err)
+	}
+
+	channel, chatID := channelChatFromContext(ctx)
+	t.mu.RLock()
+	if channel == "" {
+		channel = t.channel
+	}
+	if chatID == "" {
+		chatID = t.chatID
+	}
+	loadSkills := t.loadSkills
+	t.mu.RUnlock()
+
+	instructions := []string{}
+	if prompt := strings.TrimSpace(agent.SystemPrompt); prompt != "" {
+		instructions = append(instructions, "## Role\n"+prompt)
+	}
+	if loadSkills != nil && len(agent.Skills) > 0 {
+		if content := strings.TrimSpace(loadSkills(agent.Skills)); content != "" {
+			instructions = append(instructions, "## Skills\n"+content)
+		}
+	}
+
+	loopResult, err := t.manager.Execute(ctx, SubagentRequest{
+		Task:         message,
+		Model:        agent.Model,
+		Instructions: strings.Join(instructions, "\n\n"),
+	}, channel, chatID)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("Agent %s failed: %v", agent.Name, err)).WithError(err)
+	}
+
+	userContent := loopResult.Content
+	maxUserLen := 500
+	if len(userContent) > maxUserLen {
+		userContent = userContent[:maxUserLen] + "..."
+	}
+	return &ToolResult{
+		ForLLM:  fmt.Sprintf("Agent %s replied:\n%s", agent.Name, loopResult.Content),
+		ForUser: userContent,
+	}
+}
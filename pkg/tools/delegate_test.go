@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dotsetgreg/dotagent/pkg/providers"
+)
+
+type recordingDelegateProvider struct {
+	model    string
+	messages []providers.Message
+}
+
+func (p *recordingDelegateProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, options map[string]interface{}) (*providers.LLMResponse, error) {
+	p.model = model
+	p.messages = append([]providers.Message(nil), messages...)
+	return &providers.LLMResponse{Content: "reviewed: " + messages[len(messages)-1].Content}, nil
+}
+
+func (p *recordingDelegateProvider) GetDefaultModel() string { return "default-model" }
+
+func writeNamedAgent(t *testing.T, workspace, name, body string) {
+	t.Helper()
+	dir := filepath.Join(workspace, NamedAgentsDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".json"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDelegateTool_RunsNamedAgent(t *testing.T) {
+	workspace := t.TempDir()
+	writeNamedAgent(t, workspace, "reviewer", `{
+		"description": "Reviews Go code",
+		"model": "review-model",
+		"system_prompt": "You are a strict code reviewer.",
+		"skills": ["go-style"]
+	}`)
+
+	provider := &recordingDelegateProvider{}
+	manager := NewSubagentManager(provider, "default-model", workspace, workspace, nil)
+	tool := NewDelegateTool(manager, workspace)
+	var requested []string
+	tool.SetSkillLoader(func(names []string) string {
+		requested = names
+		return "### Skill: go-style\n\nPrefer early returns."
+	})
+
+	if desc := tool.Description(); !strings.Contains(desc, "- reviewer: Reviews Go code") {
+		t.Fatalf("description should list named agents, got %q", desc)
+	}
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"agent_name": "reviewer",
+		"message":    "Is this loop correct?",
+	})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if provider.model != "review-model" {
+		t.Fatalf("expected agent model, got %q", provider.model)
+	}
+	if len(requested) != 1 || requested[0] != "go-style" {
+		t.Fatalf("expected agent skills to be loaded, got %v", requested)
+	}
+	system := provider.messages[0].Content
+	if !strings.Contains(system, "You are a strict code reviewer.") || !strings.Contains(system, "Prefer early returns.") {
+		t.Fatalf("system prompt missing agent instructions: %q", system)
+	}
+	if !strings.Contains(result.ForLLM, "Agent reviewer replied:\nreviewed: Is this loop correct?") {
+		t.Fatalf("unexpected result: %q", result.ForLLM)
+	}
+}
+
+func TestDelegateTool_UnknownOrInvalidAgent(t *testing.T) {
+	workspace := t.TempDir()
+	manager := NewSubagentManager(&recordingDelegateProvider{}, "default-model", workspace, workspace, nil)
+	tool := NewDelegateTool(manager, workspace)
+
+	for _, name := range []string{"missing", "../secrets", ""} {
+		result := tool.Execute(context.Background(), map[string]interface{}{
+			"agent_name": name,
+			"message":    "hello",
+		})
+		if !result.IsError {
+			t.Fatalf("expected error for agent %q", name)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// fileHistoryDir holds the shadow copies write_file keeps so file_diff can
+// show what the last write changed.
+const fileHistoryDir = ".history"
+
+// maxHistoryFileSize skips snapshots of large files; their diffs would be
+// truncated anyway.
+const maxHistoryFileSize = 1 << 20
+
+var diffContextLinesParameter = map[string]interface{}{
+	"type":        "integer",
+	"description": "Unchanged lines shown around each change (default 3)",
+}
+
+func diffContextLines(args map[string]interface{}) (int, error) {
+	contextLines, err := readOptionalInt(args, "context_lines", 3)
+	if err != nil || contextLines < 0 {
+		return 0, fmt.Errorf("context_lines must be a non-negative integer")
+	}
+	return contextLines, nil
+}
+
+// FileDiffTool produces unified diffs between two workspace files, or a file
+// and its version before the last write_file.
+type FileDiffTool struct {
+	workspace string
+	restrict  bool
+}
+
+func NewFileDiffTool(workspace string, restrict bool) *FileDiffTool {
+	return &FileDiffTool{workspace: workspace, restrict: restrict}
+}
+
+func (t *FileDiffTool) Name() string {
+	return "file_diff"
+}
+
+func (t *FileDiffTool) Description() string {
+	return "Show a unified diff of path_a against path_b; given only one path, compare that file with the version before its last write_file."
+}
+
+func (t *FileDiffTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path_a": map[string]interface{}{
+				"type":        "string",
+				"description": "Original file",
+			},
+			"path_b": map[string]interface{}{
+				"type":        "string",
+				"description": "Changed file",
+			},
+			"context_lines": diffContextLinesParameter,
+		},
+	}
+}
+
+func (t *FileDiffTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	contextLines, err := diffContextLines(args)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	return t.fileDiff(args, contextLines)
+}
+
+// TextDiffTool produces a unified diff between two text snippets.
+type TextDiffTool struct{}
+
+func NewTextDiffTool() *TextDiffTool {
+	return &TextDiffTool{}
+}
+
+func (t *TextDiffTool) Name() string {
+	return "text_diff"
+}
+
+func (t *TextDiffTool) Description() string {
+	return "Show a unified diff of text_a against text_b."
+}
+
+func (t *TextDiffTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"text_a": map[string]interface{}{
+				"type":        "string",
+				"description": "Original text",
+			},
+			"text_b": map[string]interface{}{
+				"type":        "string",
+				"description": "Changed text",
+			},
+			"context_lines": diffContextLinesParameter,
+		},
+		"required": []string{"text_a", "text_b"},
+	}
+}
+
+func (t *TextDiffTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	contextLines, err := diffContextLines(args)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	textA, okA := args["text_a"].(string)
+	textB, okB := args["text_b"].(string)
+	if !okA || !okB {
+		return ErrorResult("text_a and text_b are required")
+	}
+	return diffResult(UnifiedDiff("text_a", "text_b", textA, textB, contextLines))
+}
+
+func (t *FileDiffTool) fileDiff(args map[string]interface{}, contextLines int) *ToolResult {
+	pathA, _ := args["path_a"].(string)
+	pathB, _ := args["path_b"].(string)
+	pathA, pathB = strings.TrimSpace(pathA), strings.TrimSpace(pathB)
+
+	if pathA == "" && pathB == "" {
+		return ErrorResult("path_a or path_b is required")
+	}
+	if pathA != "" && pathB != "" {
+		textA, err := t.readFile(pathA)
+		if err != nil {
+			return ErrorResult(err.Error())
+		}
+		textB, err := t.readFile(pathB)
+		if err != nil {
+			return ErrorResult(err.Error())
+		}
+		return diffResult(UnifiedDiff(pathA, pathB, textA, textB, contextLines))
+	}
+
+	path := pathA + pathB
+	resolvedPath, err := validatePath(path, t.workspace, t.restrict)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	historyPath, ok := fileHistoryPath(t.workspace, resolvedPath)
+	if !ok {
+		return ErrorResult(fmt.Sprintf("no history is kept for %s: only files inside the workspace are tracked", path))
+	}
+	previous, err := os.ReadFile(historyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrorResult(fmt.Sprintf("no previous version of %s: history is recorded when write_file overwrites an existing file", path))
+		}
+		return ErrorResult(fmt.Sprintf("failed to read history for %s: %v", path, err))
+	}
+	current, err := os.ReadFile(resolvedPath)
+	if err != nil && !os.IsNotExist(err) {
+		return ErrorResult(fmt.Sprintf("failed to read file: %v", err))
+	}
+	return diffResult(UnifiedDiff(path+" (previous)", path, string(previous), string(current), contextLines))
+}
+
+func (t *FileDiffTool) readFile(path string) (string, error) {
+	resolvedPath, err := validatePath(path, t.workspace, t.restrict)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(resolvedPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return string(data), nil
+}
+
+func diffResult(diff string) *ToolResult {
+	if diff == "" {
+		return NewToolResult("No differences")
+	}
+	maxLen := 10000
+	if len(diff) > maxLen {
+		diff = diff[:maxLen] + fmt.Sprintf("\n... (truncated, %d more chars)", len(diff)-maxLen)
+	}
+	return NewToolResult(diff)
+}
+
+// fileHistoryPath maps a file inside the workspace to its shadow copy under
+// .history, keeping the relative path so same-named files don't collide.
+// Files outside the workspace and the history directory itself are untracked.
+func fileHistoryPath(workspace, resolvedPath string) (string, bool) {
+	if workspace == "" {
+		return "", false
+	}
+	absWorkspace, err := filepath.Abs(workspace)
+	if err != nil {
+		return "", false
+	}
+	absPath, err := filepath.Abs(resolvedPath)
+	if err != nil || !isWithinWorkspace(absPath, absWorkspace) {
+		return "", false
+	}
+	rel, err := filepath.Rel(absWorkspace, absPath)
+	if err != nil || rel == "." {
+		return "", false
+	}
+	if rel == fileHistoryDir || strings.HasPrefix(rel, fileHistoryDir+string(os.PathSeparator)) {
+		return "", false
+	}
+	return filepath.Join(absWorkspace, fileHistoryDir, rel), true
+}
+
+// saveFileHistory copies the current contents of resolvedPath to its shadow
+// copy before the file is overwritten. Missing files are not an error.
+func saveFileHistory(workspace, resolvedPath string) error {
+	historyPath, ok := fileHistoryPath(workspace, resolvedPath)
+	if !ok {
+		return nil
+	}
+	info, err := os.Stat(resolvedPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if !info.Mode().IsRegular() || info.Size() > maxHistoryFileSize {
+		return nil
+	}
+	data, err := os.ReadFile(resolvedPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(historyPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(historyPath, data, 0644)
+}
+
+type diffLine struct {
+	op   byte // ' ', '-', or '+'
+	text string
+}
+
+// UnifiedDiff renders a line-based diff of a and b in unified format with
+// contextLines of surrounding context. It returns "" when the inputs match.
+func UnifiedDiff(nameA, nameB, a, b string, contextLines int) string {
+	if a == b {
+		return ""
+	}
+
+	// go-diff's DiffLinesToRunes encodes line numbers as comma-separated
+	// digits, so the rune diff can split a multi-digit index and map lines
+	// wrongly. Encode each distinct line as one rune instead.
+	enc := lineEncoder{index: map[string]rune{}}
+	runesA, runesB := enc.encode(a), enc.encode(b)
+	diffs := diffmatchpatch.New().DiffMainRunes(runesA, runesB, false)
+
+	var lines []diffLine
+	for _, d := range diffs {
+		op := byte(' ')
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			op = '-'
+		case diffmatchpatch.DiffInsert:
+			op = '+'
+		}
+		for _, r := range d.Text {
+			lines = append(lines, diffLine{op: op, text: enc.lines[enc.decode(r)]})
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		// Grow the hunk while the next change is close enough that the
+		// context between them would overlap.
+		start := max(i-contextLines, 0)
+		end := i + 1
+		for j := end; j < len(lines); j++ {
+			if lines[j].op == ' ' {
+				continue
+			}
+			if j-end > 2*contextLines {
+				break
+			}
+			end = j + 1
+		}
+		end = min(end+contextLines, len(lines))
+		writeDiffHunk(&out, lines, start, end)
+		i = end
+	}
+	return out.String()
+}
+
+// lineEncoder maps each distinct line to a rune, skipping the surrogate
+// range so the runes survive conversion to and from strings.
+type lineEncoder struct {
+	index map[string]rune
+	lines []string
+}
+
+func (e *lineEncoder) encode(text string) []rune {
+	var out []rune
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line == "" {
+			continue
+		}
+		r, ok := e.index[line]
+		if !ok {
+			r = rune(len(e.lines))
+			if r >= 0xD800 {
+				r += 0x800
+			}
+			e.index[line] = r
+			e.lines = append(e.lines, line)
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func (e *lineEncoder) decode(r rune) int {
+	if r >= 0xE000 {
+		r -= 0x800
+	}
+	return int(r)
+}
+
+func writeDiffHunk(out *strings.Builder, lines []diffLine, start, end int) {
+	lineA, lineB := 1, 1
+	for _, l := range lines[:start] {
+		if l.op != '+' {
+			lineA++
+		}
+		if l.op != '-' {
+			lineB++
+		}
+	}
+	countA, countB := 0, 0
+	for _, l := range lines[start:end] {
+		if l.op != '+' {
+			countA++
+		}
+		if l.op != '-' {
+			countB++
+		}
+	}
+	// An empty range starts at the line before it, per the unified format.
+	if countA == 0 {
+		lineA--
+	}
+	if countB == 0 {
+		lineB--
+	}
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(lineA, countA), hunkRange(lineB, countB))
+	for _, l := range lines[start:end] {
+		out.WriteByte(l.op)
+		out.WriteString(l.text)
+		if !strings.HasSuffix(l.text, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,135 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTextDiffTool(t *testing.T) {
+	tool := NewTextDiffTool()
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"text_a": "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n",
+		"text_b": "one\nTWO\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\n",
+	})
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.ForLLM)
+	}
+	want := strings.Join([]string{
+		"--- text_a",
+		"+++ text_b",
+		"@@ -1,5 +1,5 @@",
+		" one",
+		"-two",
+		"+TWO",
+		" three",
+		" four",
+		" five",
+		"@@ -8,3 +8,4 @@",
+		" eight",
+		" nine",
+		" ten",
+		"+eleven",
+		"",
+	}, "\n")
+	if result.ForLLM != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", result.ForLLM, want)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{
+		"text_a":        "a\nb",
+		"text_b":        "a\nc",
+		"context_lines": float64(0),
+	})
+	want = "--- text_a\n+++ text_b\n@@ -2 +2 @@\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n"
+	if result.ForLLM != want {
+		t.Fatalf("unexpected zero-context diff:\n%q\nwant:\n%q", result.ForLLM, want)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{
+		"text_a": "same\n",
+		"text_b": "same\n",
+	})
+	if result.IsError || result.ForLLM != "No differences" {
+		t.Fatalf("expected no differences, got %q", result.ForLLM)
+	}
+}
+
+func TestUnifiedDiff_MultiDigitLineNumbers(t *testing.T) {
+	var a strings.Builder
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&a, "line %d\n", i)
+	}
+	b := strings.Replace(a.String(), "line 15\n", "line 15 edited\n", 1)
+
+	diff := UnifiedDiff("a", "b", a.String(), b, 1)
+	want := "--- a\n+++ b\n@@ -14,3 +14,3 @@\n line 14\n-line 15\n+line 15 edited\n line 16\n"
+	if diff != want {
+		t.Fatalf("unexpected diff:\n%s", diff)
+	}
+}
+
+func TestFileDiffTool_AgainstWriteHistory(t *testing.T) {
+	workspace := t.TempDir()
+	writer := NewWriteFileTool(workspace, true)
+	tool := NewFileDiffTool(workspace, true)
+	ctx := context.Background()
+
+	result := writer.Execute(ctx, map[string]interface{}{"path": "notes/todo.md", "content": "- buy milk\n"})
+	if result.IsError {
+		t.Fatalf("write failed: %s", result.ForLLM)
+	}
+	result = tool.Execute(ctx, map[string]interface{}{"path_a": "notes/todo.md"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "no previous version") {
+		t.Fatalf("expected missing history error, got %q", result.ForLLM)
+	}
+
+	result = writer.Execute(ctx, map[string]interface{}{"path": "notes/todo.md", "content": "- buy milk\n- call mom\n"})
+	if result.IsError {
+		t.Fatalf("write failed: %s", result.ForLLM)
+	}
+	shadow, err := os.ReadFile(filepath.Join(workspace, ".history", "notes", "todo.md"))
+	if err != nil || string(shadow) != "- buy milk\n" {
+		t.Fatalf("expected shadow copy of previous content, got %q (%v)", shadow, err)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"path_b": "notes/todo.md"})
+	want := "--- notes/todo.md (previous)\n+++ notes/todo.md\n@@ -1 +1,2 @@\n - buy milk\n+- call mom\n"
+	if result.IsError || result.ForLLM != want {
+		t.Fatalf("unexpected history diff:\n%q\nwant:\n%q", result.ForLLM, want)
+	}
+
+	if err := os.WriteFile(filepath.Join(workspace, "other.md"), []byte("- buy milk\n"), 0644); err != nil {
+		t.Fatalf("write other: %v", err)
+	}
+	result = tool.Execute(ctx, map[string]interface{}{"path_a": "other.md", "path_b": "notes/todo.md"})
+	if result.IsError || !strings.HasPrefix(result.ForLLM, "--- other.md\n+++ notes/todo.md\n") {
+		t.Fatalf("unexpected two-file diff: %q", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"path_a": "../outside.txt"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "outside the workspace") {
+		t.Fatalf("expected workspace restriction, got %q", result.ForLLM)
+	}
+}
+
+func TestFileHistoryPath_SkipsHistoryDirAndOutsideFiles(t *testing.T) {
+	workspace := t.TempDir()
+
+	if got, ok := fileHistoryPath(workspace, filepath.Join(workspace, "a", "b.txt")); !ok || got != filepath.Join(workspace, ".history", "a", "b.txt") {
+		t.Fatalf("unexpected history path %q (%v)", got, ok)
+	}
+	if _, ok := fileHistoryPath(workspace, filepath.Join(workspace, ".history", "b.txt")); ok {
+		t.Fatalf("history files must not be snapshotted")
+	}
+	if _, ok := fileHistoryPath(workspace, filepath.Join(filepath.Dir(workspace), "elsewhere.txt")); ok {
+		t.Fatalf("files outside the workspace must not be snapshotted")
+	}
+	if _, ok := fileHistoryPath("", "/tmp/x.txt"); ok {
+		t.Fatalf("no history without a workspace")
+	}
+}
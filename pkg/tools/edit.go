@@ -112,6 +112,7 @@ func (t *EditFileTool) Execute(ctx context.Context, args map[string]interface{})
 	}
 	newContent := contentStr[:targetIdx] + newText + contentStr[targetIdx+len(oldText):]
 
+	agentWrites.record(resolvedPath)
 	if err := os.WriteFile(resolvedPath, []byte(newContent), 0644); err != nil {
 		return ErrorResult(fmt.Sprintf("failed to write file: %v", err))
 	}
@@ -188,6 +189,7 @@ func (t *AppendFileTool) Execute(ctx context.Context, args map[string]interface{
 		return ErrorResult(err.Error())
 	}
 
+	agentWrites.record(resolvedPath)
 	f, err := os.OpenFile(resolvedPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return ErrorResult(fmt.Sprintf("failed to open file: %v", err))
@@ -60,6 +60,13 @@ func validatePath(path, workspace string, restrict bool) (string, error) {
 	return absPath, nil
 }
 
+// ValidatePath resolves path against workspace the way the file tools do. With
+// restrict set it rejects paths, including symlinks, that lead outside the
+// workspace.
+func ValidatePath(path, workspace string, restrict bool) (string, error) {
+	return validatePath(path, workspace, restrict)
+}
+
 func resolveExistingAncestor(path string) (string, error) {
 	for current := filepath.Clean(path); ; current = filepath.Dir(current) {
 		if resolved, err := filepath.EvalSymlinks(current); err == nil {
@@ -225,6 +232,10 @@ func (t *WriteFileTool) Execute(ctx context.Context, args map[string]interface{}
 		return ErrorResult(fmt.Sprintf("failed to create directory: %v", err))
 	}
 
+	// Best effort: a failed snapshot only costs diff history, not the write.
+	_ = saveFileHistory(t.workspace, resolvedPath)
+
+	agentWrites.record(resolvedPath)
 	if err := os.WriteFile(resolvedPath, []byte(content), 0644); err != nil {
 		return ErrorResult(fmt.Sprintf("failed to write file: %v", err))
 	}
@@ -0,0 +1,474 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+	"github.com/dotsetgreg/dotagent/pkg/logger"
+	"github.com/fsnotify/fsnotify"
+	"github.com/google/uuid"
+)
+
+// fileWatchDebounce suppresses the burst of events editors emit for a single
+// save so one change triggers one agent turn.
+const fileWatchDebounce = time.Second
+
+// agentWriteGrace is how long after one of the agent's file tools writes a
+// path that watch events for it are attributed to the agent.
+const agentWriteGrace = 2 * time.Second
+
+var fileWatchEventTypes = []string{"any", "write", "create", "remove", "rename"}
+
+// agentWrites remembers paths the agent's own file tools wrote so watches do
+// not fire on them; a watch whose callback edits the watched file would
+// otherwise trigger itself forever.
+var agentWrites = &recentWrites{at: map[string]time.Time{}}
+
+type recentWrites struct {
+	mu sync.Mutex
+	at map[string]time.Time
+}
+
+// record marks path as written by the agent now. Callers record before
+// writing so the event cannot arrive first.
+func (w *recentWrites) record(path string) {
+	now := time.Now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for p, at := range w.at {
+		if now.Sub(at) >= agentWriteGrace {
+			delete(w.at, p)
+		}
+	}
+	w.at[filepath.Clean(path)] = now
+}
+
+func (w *recentWrites) wroteRecently(path string, now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	at, ok := w.at[filepath.Clean(path)]
+	return ok && now.Sub(at) < agentWriteGrace
+}
+
+type fileWatch struct {
+	ID        string
+	Path      string
+	IsDir     bool
+	EventType string
+	Prompt    string
+	Channel   string
+	ChatID    string
+	Actor     string
+	lastFired time.Time
+}
+
+// FileWatcher triggers agent turns when workspace files change. It backs the
+// watch_file, list_watches and unwatch_file tools; each watch publishes its
+// callback prompt as an inbound message to the conversation that created it.
+type FileWatcher struct {
+	workspace   string
+	restrict    bool
+	maxWatchers int
+	msgBus      *bus.MessageBus
+
+	mu        sync.Mutex
+	watcher   *fsnotify.Watcher
+	watches   map[string]*fileWatch
+	dirRefs   map[string]int
+	channel   string
+	chatID    string
+	closed    bool
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func NewFileWatcher(workspace string, restrict bool, maxWatchers int, msgBus *bus.MessageBus) *FileWatcher {
+	if maxWatchers <= 0 {
+		maxWatchers = 10
+	}
+	return &FileWatcher{
+		workspace:   workspace,
+		restrict:    restrict,
+		maxWatchers: maxWatchers,
+		msgBus:      msgBus,
+		watches:     map[string]*fileWatch{},
+		dirRefs:     map[string]int{},
+		done:        make(chan struct{}),
+	}
+}
+
+func (t *FileWatcher) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.channel = channel
+	t.chatID = chatID
+}
+
+// WatchFileTool starts a watch on a workspace file or directory.
+type WatchFileTool struct {
+	*FileWatcher
+}
+
+func NewWatchFileTool(watcher *FileWatcher) *WatchFileTool {
+	return &WatchFileTool{watcher}
+}
+
+func (t *WatchFileTool) Name() string {
+	return "watch_file"
+}
+
+func (t *WatchFileTool) Description() string {
+	return "Watch a workspace file or directory and run a prompt when it changes (e.g., run the tests when code changes). Files written by your own file tools do not trigger watches. Returns a watch ID for unwatch_file."
+}
+
+func (t *WatchFileTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File or directory to watch. Directories match changes to their direct children.",
+			},
+			"event_type": map[string]interface{}{
+				"type":        "string",
+				"enum":        fileWatchEventTypes,
+				"description": "Which change triggers the prompt. Default any.",
+			},
+			"callback_prompt": map[string]interface{}{
+				"type":        "string",
+				"description": "Instruction to run as a new message when the event fires.",
+			},
+		},
+		"required": []string{"path", "callback_prompt"},
+	}
+}
+
+func (t *WatchFileTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	return t.watchFile(ctx, args)
+}
+
+// ListWatchesTool lists the active file watches.
+type ListWatchesTool struct {
+	*FileWatcher
+}
+
+func NewListWatchesTool(watcher *FileWatcher) *ListWatchesTool {
+	return &ListWatchesTool{watcher}
+}
+
+func (t *ListWatchesTool) Name() string {
+	return "list_watches"
+}
+
+func (t *ListWatchesTool) Description() string {
+	return "List the active file watches with their IDs, event types, paths and callback prompts."
+}
+
+func (t *ListWatchesTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	}
+}
+
+func (t *ListWatchesTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	return t.listWatches()
+}
+
+// UnwatchFileTool stops a file watch.
+type UnwatchFileTool struct {
+	*FileWatcher
+}
+
+func NewUnwatchFileTool(watcher *FileWatcher) *UnwatchFileTool {
+	return &UnwatchFileTool{watcher}
+}
+
+func (t *UnwatchFileTool) Name() string {
+	return "unwatch_file"
+}
+
+func (t *UnwatchFileTool) Description() string {
+	return "Stop a file watch by the ID watch_file or list_watches returned."
+}
+
+func (t *UnwatchFileTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "Watch ID",
+			},
+		},
+		"required": []string{"id"},
+	}
+}
+
+func (t *UnwatchFileTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	id, _ := args["id"].(string)
+	return t.unwatchFile(strings.TrimSpace(id))
+}
+
+func (t *FileWatcher) watchFile(ctx context.Context, args map[string]interface{}) *ToolResult {
+	if t.msgBus == nil {
+		return ErrorResult("file watching is unavailable: message bus not configured")
+	}
+	rawPath, _ := args["path"].(string)
+	prompt, _ := args["callback_prompt"].(string)
+	prompt = strings.TrimSpace(prompt)
+	if strings.TrimSpace(rawPath) == "" || prompt == "" {
+		return ErrorResult("path and callback_prompt are required")
+	}
+	eventType, _ := args["event_type"].(string)
+	eventType = strings.ToLower(strings.TrimSpace(eventType))
+	if eventType == "" {
+		eventType = "any"
+	}
+	if !slices.Contains(fileWatchEventTypes, eventType) {
+		return ErrorResult(fmt.Sprintf("event_type must be one of: %s", strings.Join(fileWatchEventTypes, ", ")))
+	}
+
+	path, err := validatePath(rawPath, t.workspace, t.restrict)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("cannot watch %s: %v", rawPath, err))
+	}
+
+	channel, chatID := channelChatFromContext(ctx)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if channel == "" {
+		channel = t.channel
+	}
+	if chatID == "" {
+		chatID = t.chatID
+	}
+	if channel == "" || chatID == "" {
+		return ErrorResult("no session context (channel/chat_id not set). Use this tool in an active conversation.")
+	}
+	if t.closed {
+		return ErrorResult("file watching has been shut down")
+	}
+	if len(t.watches) >= t.maxWatchers {
+		return ErrorResult(fmt.Sprintf("watcher limit reached (%d); remove one with unwatch_file first", t.maxWatchers))
+	}
+	if err := t.ensureWatcherLocked(); err != nil {
+		return ErrorResult(fmt.Sprintf("start file watcher: %v", err))
+	}
+
+	// Watch the parent directory for files so atomic saves (write to temp,
+	// rename over the original) keep triggering after the inode changes.
+	dir := path
+	if !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	if t.dirRefs[dir] == 0 {
+		if err := t.watcher.Add(dir); err != nil {
+			return ErrorResult(fmt.Sprintf("watch %s: %v", rawPath, err))
+		}
+	}
+	t.dirRefs[dir]++
+
+	w := &fileWatch{
+		ID:        uuid.NewString()[:8],
+		Path:      path,
+		IsDir:     info.IsDir(),
+		EventType: eventType,
+		Prompt:    prompt,
+		Channel:   channel,
+		ChatID:    chatID,
+		Actor:     actorFromContext(ctx),
+	}
+	t.watches[w.ID] = w
+	return SilentResult(fmt.Sprintf("Watching %s for %s events (id: %s)", rawPath, eventType, w.ID))
+}
+
+func (t *FileWatcher) listWatches() *ToolResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.watches) == 0 {
+		return SilentResult("No active file watches")
+	}
+	ids := make([]string, 0, len(t.watches))
+	for id := range t.watches {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	lines := []string{fmt.Sprintf("Active file watches (%d/%d):", len(ids), t.maxWatchers)}
+	for _, id := range ids {
+		w := t.watches[id]
+		lines = append(lines, fmt.Sprintf("- %s %s on %s -> %q", w.ID, w.EventType, w.Path, w.Prompt))
+	}
+	return SilentResult(strings.Join(lines, "\n"))
+}
+
+func (t *FileWatcher) unwatchFile(id string) *ToolResult {
+	if id == "" {
+		return ErrorResult("id is required")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w, ok := t.watches[id]
+	if !ok {
+		return ErrorResult(fmt.Sprintf("Watch %s not found", id))
+	}
+	delete(t.watches, id)
+	dir := w.Path
+	if !w.IsDir {
+		dir = filepath.Dir(w.Path)
+	}
+	t.dirRefs[dir]--
+	if t.dirRefs[dir] <= 0 {
+		delete(t.dirRefs, dir)
+		if t.watcher != nil {
+			_ = t.watcher.Remove(dir)
+		}
+	}
+	return SilentResult(fmt.Sprintf("Stopped watching %s (id: %s)", w.Path, id))
+}
+
+// Close stops the underlying watcher and drops every watch.
+func (t *FileWatcher) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		t.mu.Lock()
+		t.closed = true
+		watcher := t.watcher
+		t.watches = map[string]*fileWatch{}
+		t.dirRefs = map[string]int{}
+		t.mu.Unlock()
+		close(t.done)
+		if watcher != nil {
+			err = watcher.Close()
+		}
+	})
+	return err
+}
+
+func (t *FileWatcher) ensureWatcherLocked() error {
+	if t.watcher != nil {
+		return nil
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	t.watcher = watcher
+	go t.run(watcher)
+	return nil
+}
+
+func (t *FileWatcher) run(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case <-t.done:
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			t.dispatch(event)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.WarnCF("tools", "File watcher error", map[string]interface{}{"error": err.Error()})
+		}
+	}
+}
+
+func (t *FileWatcher) dispatch(event fsnotify.Event) {
+	now := time.Now()
+	if agentWrites.wroteRecently(event.Name, now) {
+		return
+	}
+	t.mu.Lock()
+	var fired []fileWatch
+	for _, w := range t.watches {
+		if !fileWatchMatches(w, event) {
+			continue
+		}
+		if now.Sub(w.lastFired) < fileWatchDebounce {
+			continue
+		}
+		w.lastFired = now
+		fired = append(fired, *w)
+	}
+	t.mu.Unlock()
+
+	for _, w := range fired {
+		sender := w.Actor
+		if sender == "" {
+			sender = "file_watch:" + w.ID
+		}
+		err := t.msgBus.PublishInbound(bus.InboundMessage{
+			Channel:   w.Channel,
+			SenderID:  sender,
+			ChatID:    w.ChatID,
+			Content:   w.Prompt,
+			MessageID: fmt.Sprintf("file-watch-%s-%d", w.ID, now.UnixNano()),
+			Metadata: map[string]string{
+				"source":   "file_watch",
+				"watch_id": w.ID,
+				"path":     event.Name,
+				"event":    fileWatchEventName(event.Op),
+			},
+		})
+		if err != nil {
+			logger.WarnCF("tools", "Failed to publish file watch event", map[string]interface{}{
+				"watch_id": w.ID,
+				"error":    err.Error(),
+			})
+		}
+	}
+}
+
+func fileWatchMatches(w *fileWatch, event fsnotify.Event) bool {
+	name := filepath.Clean(event.Name)
+	if w.IsDir {
+		if filepath.Dir(name) != w.Path && name != w.Path {
+			return false
+		}
+	} else if name != w.Path {
+		return false
+	}
+	switch w.EventType {
+	case "write":
+		return event.Has(fsnotify.Write)
+	case "create":
+		return event.Has(fsnotify.Create)
+	case "remove":
+		return event.Has(fsnotify.Remove)
+	case "rename":
+		return event.Has(fsnotify.Rename)
+	default:
+		return event.Op != fsnotify.Chmod
+	}
+}
+
+func fileWatchEventName(op fsnotify.Op) string {
+	switch {
+	case op.Has(fsnotify.Create):
+		return "create"
+	case op.Has(fsnotify.Write):
+		return "write"
+	case op.Has(fsnotify.Remove):
+		return "remove"
+	case op.Has(fsnotify.Rename):
+		return "rename"
+	default:
+		return "chmod"
+	}
+}
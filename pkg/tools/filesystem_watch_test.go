@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/bus"
+)
+
+func TestWatchFileTool_PublishesCallbackOnWrite(t *testing.T) {
+	workspace := t.TempDir()
+	target := filepath.Join(workspace, "main.go")
+	if err := os.WriteFile(target, []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write target: %v", err)
+	}
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	watcher := NewFileWatcher(workspace, true, 2, msgBus)
+	defer watcher.Close()
+	tool := NewWatchFileTool(watcher)
+
+	ctx := WithToolExecutionActor(withToolExecutionContext(context.Background(), "discord", "chat-1", nil), "user-42")
+	res := tool.Execute(ctx, map[string]interface{}{
+		"path":            "main.go",
+		"event_type":      "write",
+		"callback_prompt": "Run the tests",
+	})
+	if res.IsError {
+		t.Fatalf("watch_file: %s", res.ForLLM)
+	}
+
+	if err := os.WriteFile(target, []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatalf("rewrite target: %v", err)
+	}
+	waitCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	msg, ok := msgBus.ConsumeInbound(waitCtx)
+	if !ok {
+		t.Fatalf("expected inbound message after write")
+	}
+	if msg.Content != "Run the tests" || msg.Channel != "discord" || msg.ChatID != "chat-1" || msg.SenderID != "user-42" {
+		t.Fatalf("unexpected inbound message: %+v", msg)
+	}
+	if msg.Metadata["source"] != "file_watch" || msg.Metadata["event"] != "write" {
+		t.Fatalf("unexpected metadata: %+v", msg.Metadata)
+	}
+}
+
+func TestWatchFileTool_EnforcesLimitAndWorkspace(t *testing.T) {
+	workspace := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(workspace, name), nil, 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	watcher := NewFileWatcher(workspace, true, 1, msgBus)
+	defer watcher.Close()
+	ctx := withToolExecutionContext(context.Background(), "cli", "direct", nil)
+
+	watch := func(path string) *ToolResult {
+		return NewWatchFileTool(watcher).Execute(ctx, map[string]interface{}{"path": path, "callback_prompt": "check"})
+	}
+	if res := watch("a.txt"); res.IsError {
+		t.Fatalf("first watch: %s", res.ForLLM)
+	}
+	if res := watch("b.txt"); !res.IsError {
+		t.Fatalf("expected watcher limit to be enforced")
+	}
+	if res := watch(filepath.Join(t.TempDir(), "outside.txt")); !res.IsError {
+		t.Fatalf("expected paths outside the workspace to be rejected")
+	}
+
+	watcher.mu.Lock()
+	var id string
+	for watchID := range watcher.watches {
+		id = watchID
+	}
+	watcher.mu.Unlock()
+	if res := NewListWatchesTool(watcher).Execute(ctx, map[string]interface{}{}); !strings.Contains(res.ForLLM, id) {
+		t.Fatalf("expected list_watches to show %s, got %q", id, res.ForLLM)
+	}
+	if res := NewUnwatchFileTool(watcher).Execute(ctx, map[string]interface{}{"id": id}); res.IsError {
+		t.Fatalf("unwatch_file: %s", res.ForLLM)
+	}
+	if res := watch("b.txt"); res.IsError {
+		t.Fatalf("expected a slot after unwatch: %s", res.ForLLM)
+	}
+}
+
+func TestWatchFileTool_IgnoresAgentWrites(t *testing.T) {
+	workspace := t.TempDir()
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	watcher := NewFileWatcher(workspace, true, 1, msgBus)
+	defer watcher.Close()
+	tool := NewWatchFileTool(watcher)
+
+	ctx := withToolExecutionContext(context.Background(), "cli", "direct", nil)
+	res := tool.Execute(ctx, map[string]interface{}{
+		"path":            ".",
+		"callback_prompt": "Summarize the change",
+	})
+	if res.IsError {
+		t.Fatalf("watch_file: %s", res.ForLLM)
+	}
+
+	if res := NewWriteFileTool(workspace, true).Execute(ctx, map[string]interface{}{"path": "notes.md", "content": "agent"}); res.IsError {
+		t.Fatalf("write_file: %s", res.ForLLM)
+	}
+	userFile := filepath.Join(workspace, "user.md")
+	if err := os.WriteFile(userFile, []byte("user"), 0o644); err != nil {
+		t.Fatalf("write user file: %v", err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	msg, ok := msgBus.ConsumeInbound(waitCtx)
+	if !ok {
+		t.Fatalf("expected inbound message for the user's write")
+	}
+	if msg.Metadata["path"] != userFile {
+		t.Fatalf("expected only the user's write to fire, got event for %s", msg.Metadata["path"])
+	}
+}
@@ -0,0 +1,202 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	issueListDefaultLimit = 20
+	issueListMaxLimit     = 100
+)
+
+// Issue is the backend-neutral view of a tracker issue.
+type Issue struct {
+	Key      string
+	Title    string
+	Status   string
+	Assignee string
+	Labels   []string
+	URL      string
+}
+
+// IssueDraft is the input for creating an issue.
+type IssueDraft struct {
+	Title  string
+	Body   string
+	Labels []string
+}
+
+// IssueTracker is implemented by each issue tracker backend. filter is
+// backend-specific (JQL for Jira); plain text should fall back to a
+// full-text search.
+type IssueTracker interface {
+	Backend() string
+	CreateIssue(ctx context.Context, draft IssueDraft) (Issue, error)
+	ListIssues(ctx context.Context, filter string, limit int) ([]Issue, error)
+}
+
+// IssueCreateTool files an issue through whichever IssueTracker backend is
+// configured.
+type IssueCreateTool struct {
+	tracker IssueTracker
+}
+
+func NewIssueCreateTool(tracker IssueTracker) *IssueCreateTool {
+	return &IssueCreateTool{tracker: tracker}
+}
+
+func (t *IssueCreateTool) Name() string {
+	return "issue_create"
+}
+
+func (t *IssueCreateTool) UntrustedContent() bool { return true }
+
+func (t *IssueCreateTool) Description() string {
+	return fmt.Sprintf("File an issue in the team's %s tracker.", t.tracker.Backend())
+}
+
+func (t *IssueCreateTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "One-line issue title",
+			},
+			"body": map[string]interface{}{
+				"type":        "string",
+				"description": "Issue description (steps to reproduce, expected and actual behavior)",
+			},
+			"labels": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Labels to apply",
+			},
+		},
+		"required": []string{"title"},
+	}
+}
+
+func (t *IssueCreateTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	title, _ := args["title"].(string)
+	body, _ := args["body"].(string)
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return ErrorResult("title is required")
+	}
+	issue, err := t.tracker.CreateIssue(ctx, IssueDraft{
+		Title:  title,
+		Body:   strings.TrimSpace(body),
+		Labels: issueLabelsArg(args["labels"]),
+	})
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to create issue: %v", err))
+	}
+	msg := fmt.Sprintf("Created %s: %s", issue.Key, title)
+	if issue.URL != "" {
+		msg += "\n" + issue.URL
+	}
+	return NewToolResult(msg)
+}
+
+// IssueListTool queries issues through whichever IssueTracker backend is
+// configured.
+type IssueListTool struct {
+	tracker IssueTracker
+}
+
+func NewIssueListTool(tracker IssueTracker) *IssueListTool {
+	return &IssueListTool{tracker: tracker}
+}
+
+func (t *IssueListTool) Name() string {
+	return "issue_list"
+}
+
+func (t *IssueListTool) UntrustedContent() bool { return true }
+
+func (t *IssueListTool) Description() string {
+	return fmt.Sprintf("Query issues in the team's %s tracker by a tracker query such as JQL, or plain text to search for.", t.tracker.Backend())
+}
+
+func (t *IssueListTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"filter": map[string]interface{}{
+				"type":        "string",
+				"description": "Tracker query (e.g. JQL \"status = 'In Progress' AND sprint in openSprints()\") or plain text. Omit for the most recently updated issues.",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum issues to return. Default 20.",
+				"minimum":     1.0,
+				"maximum":     float64(issueListMaxLimit),
+			},
+		},
+	}
+}
+
+func (t *IssueListTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	filter, _ := args["filter"].(string)
+	limit := issueListDefaultLimit
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		limit = min(int(v), issueListMaxLimit)
+	}
+	issues, err := t.tracker.ListIssues(ctx, strings.TrimSpace(filter), limit)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to list issues: %v", err))
+	}
+	return NewToolResult(formatIssueList(issues))
+}
+
+func formatIssueList(issues []Issue) string {
+	if len(issues) == 0 {
+		return "No matching issues."
+	}
+	lines := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		line := fmt.Sprintf("- %s [%s] %s", issue.Key, valueOrNone(issue.Status), issue.Title)
+		if issue.Assignee != "" {
+			line += " (assignee: " + issue.Assignee + ")"
+		}
+		if len(issue.Labels) > 0 {
+			line += " {" + strings.Join(issue.Labels, ", ") + "}"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// issueLabelsArg accepts labels as a JSON array or a comma-separated string.
+func issueLabelsArg(raw interface{}) []string {
+	var values []string
+	switch v := raw.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	case []string:
+		values = v
+	case string:
+		values = strings.Split(v, ",")
+	}
+	labels := make([]string, 0, len(values))
+	for _, label := range values {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+func valueOrNone(v string) string {
+	if strings.TrimSpace(v) == "" {
+		return "none"
+	}
+	return v
+}
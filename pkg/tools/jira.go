@@ -0,0 +1,265 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const jiraDefaultIssueType = "Task"
+
+// jiraQuerySyntax spots filters that are already JQL rather than search text.
+var jiraQuerySyntax = regexp.MustCompile(`(?i)([=~<>]|\s(not\s+)?in\s*\(|\sis\s+(not\s+)?(empty|null)\b|\border\s+by\s)`)
+
+var jiraProjectClause = regexp.MustCompile(`(?i)\bproject\s*(!=|=|not\s+in\b|in\b)`)
+
+// jiraOrderBy finds the ORDER BY that ends a JQL filter's conditions.
+var jiraOrderBy = regexp.MustCompile(`(?i)(^|\s)order\s+by\s`)
+
+type JiraOptions struct {
+	BaseURL    string
+	User       string
+	APIToken   string
+	ProjectKey string
+	IssueType  string
+}
+
+// JiraIssueTracker talks to Jira Cloud's REST API v3 with basic auth
+// (account email plus API token).
+type JiraIssueTracker struct {
+	baseURL    string
+	user       string
+	apiToken   string
+	projectKey string
+	issueType  string
+	client     *http.Client
+}
+
+func NewJiraIssueTracker(opts JiraOptions) *JiraIssueTracker {
+	issueType := strings.TrimSpace(opts.IssueType)
+	if issueType == "" {
+		issueType = jiraDefaultIssueType
+	}
+	return &JiraIssueTracker{
+		baseURL:    strings.TrimRight(strings.TrimSpace(opts.BaseURL), "/"),
+		user:       strings.TrimSpace(opts.User),
+		apiToken:   strings.TrimSpace(opts.APIToken),
+		projectKey: strings.TrimSpace(opts.ProjectKey),
+		issueType:  issueType,
+		client:     &http.Client{Timeout: 20 * time.Second},
+	}
+}
+
+func (j *JiraIssueTracker) Backend() string {
+	return "Jira"
+}
+
+func (j *JiraIssueTracker) CreateIssue(ctx context.Context, draft IssueDraft) (Issue, error) {
+	labels := make([]string, 0, len(draft.Labels))
+	for _, label := range draft.Labels {
+		// Jira labels cannot contain spaces.
+		labels = append(labels, strings.Join(strings.Fields(label), "-"))
+	}
+	fields := map[string]interface{}{
+		"project":   map[string]string{"key": j.projectKey},
+		"summary":   draft.Title,
+		"issuetype": map[string]string{"name": j.issueType},
+		"labels":    labels,
+	}
+	if draft.Body != "" {
+		fields["description"] = jiraADFDocument(draft.Body)
+	}
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := j.do(ctx, http.MethodPost, "/rest/api/3/issue", map[string]interface{}{"fields": fields}, &created); err != nil {
+		return Issue{}, err
+	}
+	return Issue{
+		Key:    created.Key,
+		Title:  draft.Title,
+		Labels: labels,
+		URL:    j.browseURL(created.Key),
+	}, nil
+}
+
+func (j *JiraIssueTracker) ListIssues(ctx context.Context, filter string, limit int) ([]Issue, error) {
+	query := url.Values{}
+	query.Set("jql", j.buildJQL(filter))
+	query.Set("fields", "summary,status,assignee,labels")
+	query.Set("maxResults", strconv.Itoa(limit))
+
+	var result struct {
+		Issues []struct {
+			Key    string `json:"key"`
+			Fields struct {
+				Summary string `json:"summary"`
+				Status  *struct {
+					Name string `json:"name"`
+				} `json:"status"`
+				Assignee *struct {
+					DisplayName string `json:"displayName"`
+				} `json:"assignee"`
+				Labels []string `json:"labels"`
+			} `json:"fields"`
+		} `json:"issues"`
+	}
+	if err := j.do(ctx, http.MethodGet, "/rest/api/3/search/jql?"+query.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+
+	issues := make([]Issue, 0, len(result.Issues))
+	for _, raw := range result.Issues {
+		issue := Issue{
+			Key:    raw.Key,
+			Title:  raw.Fields.Summary,
+			Labels: raw.Fields.Labels,
+			URL:    j.browseURL(raw.Key),
+		}
+		if raw.Fields.Status != nil {
+			issue.Status = raw.Fields.Status.Name
+		}
+		if raw.Fields.Assignee != nil {
+			issue.Assignee = raw.Fields.Assignee.DisplayName
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// buildJQL scopes the filter to the configured project. JQL filters are used
+// as given unless they already name a project; otherwise their conditions are
+// parenthesized so an OR cannot escape the project scope. Plain text becomes a
+// text search. An empty filter lists the most recently updated issues.
+func (j *JiraIssueTracker) buildJQL(filter string) string {
+	project := "project = " + jqlString(j.projectKey)
+	filter = strings.TrimSpace(filter)
+	switch {
+	case filter == "":
+		return project + " ORDER BY updated DESC"
+	case jiraQuerySyntax.MatchString(filter):
+		if jiraProjectClause.MatchString(filter) {
+			return filter
+		}
+		where, orderBy := filter, ""
+		if loc := jiraOrderBy.FindStringIndex(filter); loc != nil {
+			where, orderBy = strings.TrimSpace(filter[:loc[0]]), strings.TrimSpace(filter[loc[0]:])
+		}
+		if where != "" {
+			project += " AND (" + where + ")"
+		}
+		if orderBy != "" {
+			project += " " + orderBy
+		}
+		return project
+	default:
+		return project + " AND text ~ " + jqlString(filter) + " ORDER BY updated DESC"
+	}
+}
+
+// jqlString quotes s as a JQL string literal, which escapes quotes and
+// backslashes with a backslash.
+func jqlString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (j *JiraIssueTracker) browseURL(key string) string {
+	if key == "" {
+		return ""
+	}
+	return j.baseURL + "/browse/" + key
+}
+
+func (j *JiraIssueTracker) do(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, j.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(j.user, j.apiToken)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("jira returned %s: %s", resp.Status, jiraErrorDetail(respBody))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// jiraErrorDetail pulls the human-readable messages out of a Jira error body.
+func jiraErrorDetail(body []byte) string {
+	var parsed struct {
+		ErrorMessages []string          `json:"errorMessages"`
+		Errors        map[string]string `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		msgs := append([]string(nil), parsed.ErrorMessages...)
+		for field, msg := range parsed.Errors {
+			msgs = append(msgs, field+": "+msg)
+		}
+		if len(msgs) > 0 {
+			return strings.Join(msgs, "; ")
+		}
+	}
+	detail := strings.TrimSpace(string(body))
+	if len(detail) > 300 {
+		detail = detail[:300] + "..."
+	}
+	return detail
+}
+
+// jiraADFDocument wraps plain text in the Atlassian Document Format that
+// API v3 requires for descriptions, one paragraph per blank-line block.
+func jiraADFDocument(text string) map[string]interface{} {
+	var paragraphs []interface{}
+	for _, block := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+		var content []interface{}
+		for i, line := range strings.Split(block, "\n") {
+			if i > 0 {
+				content = append(content, map[string]interface{}{"type": "hardBreak"})
+			}
+			if line != "" {
+				content = append(content, map[string]interface{}{"type": "text", "text": line})
+			}
+		}
+		paragraphs = append(paragraphs, map[string]interface{}{"type": "paragraph", "content": content})
+	}
+	return map[string]interface{}{"type": "doc", "version": 1, "content": paragraphs}
+}
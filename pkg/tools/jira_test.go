@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIssueTools_JiraCreateAndList(t *testing.T) {
+	var created map[string]interface{}
+	var gotJQL, gotUser, gotToken string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, gotToken, _ = r.BasicAuth()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/api/3/issue":
+			body, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(body, &created)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"10001","key":"OPS-42"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/rest/api/3/search/jql":
+			gotJQL = r.URL.Query().Get("jql")
+			_, _ = w.Write([]byte(`{"issues":[
+				{"key":"OPS-41","fields":{"summary":"Login fails","status":{"name":"In Progress"},"assignee":{"displayName":"Sam"},"labels":["bug"]}},
+				{"key":"OPS-40","fields":{"summary":"Docs typo","status":{"name":"To Do"},"assignee":null,"labels":[]}}
+			]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tracker := NewJiraIssueTracker(JiraOptions{
+		BaseURL:    server.URL + "/",
+		User:       "bot@example.com",
+		APIToken:   "secret",
+		ProjectKey: "OPS",
+	})
+
+	result := NewIssueCreateTool(tracker).Execute(context.Background(), map[string]interface{}{
+		"title":  "Login fails on Safari",
+		"body":   "Steps:\n1. open login\n\nExpected: works",
+		"labels": []interface{}{"bug", "needs triage"},
+	})
+	if result.IsError {
+		t.Fatalf("issue_create failed: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Created OPS-42") || !strings.Contains(result.ForLLM, server.URL+"/browse/OPS-42") {
+		t.Fatalf("unexpected create result: %s", result.ForLLM)
+	}
+	if gotUser != "bot@example.com" || gotToken != "secret" {
+		t.Fatalf("expected basic auth, got %q/%q", gotUser, gotToken)
+	}
+	fields := created["fields"].(map[string]interface{})
+	if fields["summary"] != "Login fails on Safari" || fields["issuetype"].(map[string]interface{})["name"] != "Task" {
+		t.Fatalf("unexpected fields: %#v", fields)
+	}
+	if labels := fields["labels"].([]interface{}); len(labels) != 2 || labels[1] != "needs-triage" {
+		t.Fatalf("expected jira-safe labels, got %#v", fields["labels"])
+	}
+	if doc := fields["description"].(map[string]interface{}); doc["type"] != "doc" || len(doc["content"].([]interface{})) != 2 {
+		t.Fatalf("expected two ADF paragraphs, got %#v", doc)
+	}
+
+	result = NewIssueListTool(tracker).Execute(context.Background(), map[string]interface{}{"filter": "login"})
+	if result.IsError {
+		t.Fatalf("issue_list failed: %s", result.ForLLM)
+	}
+	if gotJQL != `project = "OPS" AND text ~ "login" ORDER BY updated DESC` {
+		t.Fatalf("unexpected JQL %q", gotJQL)
+	}
+	want := "- OPS-41 [In Progress] Login fails (assignee: Sam) {bug}\n- OPS-40 [To Do] Docs typo"
+	if result.ForLLM != want {
+		t.Fatalf("unexpected list output:\n%s", result.ForLLM)
+	}
+}
+
+func TestJiraIssueTracker_BuildJQL(t *testing.T) {
+	j := NewJiraIssueTracker(JiraOptions{ProjectKey: "OPS"})
+	cases := map[string]string{
+		"": `project = "OPS" ORDER BY updated DESC`,
+		"status = 'In Progress' AND sprint in openSprints()": `project = "OPS" AND (status = 'In Progress' AND sprint in openSprints())`,
+		"status = Done OR priority = High":                   `project = "OPS" AND (status = Done OR priority = High)`,
+		"assignee = currentUser() order by created":          `project = "OPS" AND (assignee = currentUser()) order by created`,
+		"project = WEB AND assignee is EMPTY":                "project = WEB AND assignee is EMPTY",
+		"ORDER BY created DESC":                              `project = "OPS" ORDER BY created DESC`,
+		"login is broken":                                    `project = "OPS" AND text ~ "login is broken" ORDER BY updated DESC`,
+		`the "save" button\ fails`:                           `project = "OPS" AND text ~ "the \"save\" button\\ fails" ORDER BY updated DESC`,
+	}
+	for filter, want := range cases {
+		if got := j.buildJQL(filter); got != want {
+			t.Errorf("buildJQL(%q) = %q, want %q", filter, got, want)
+		}
+	}
+}
+
+func TestJiraIssueTracker_ReportsAPIErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"errorMessages":[],"errors":{"issuetype":"Specify a valid issue type"}}`))
+	}))
+	defer server.Close()
+
+	tool := NewIssueCreateTool(NewJiraIssueTracker(JiraOptions{BaseURL: server.URL, User: "u", APIToken: "t", ProjectKey: "OPS"}))
+	result := tool.Execute(context.Background(), map[string]interface{}{"title": "x"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "issuetype: Specify a valid issue type") {
+		t.Fatalf("expected jira error detail, got %q", result.ForLLM)
+	}
+	if result := tool.Execute(context.Background(), map[string]interface{}{}); !result.IsError {
+		t.Fatal("expected missing title to fail")
+	}
+}
@@ -0,0 +1,565 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	k8sDefaultTailLines = 100
+	k8sMaxTailLines     = 2000
+	k8sMaxOutputChars   = 10000
+)
+
+type KubernetesToolOptions struct {
+	KubeconfigPath    string
+	InCluster         bool
+	AllowedNamespaces []string
+	// Restrict limits every call to AllowedNamespaces and hides
+	// cluster-scoped resources.
+	Restrict bool
+}
+
+// k8sResource describes how to list and fetch one supported resource kind.
+type k8sResource struct {
+	namespaced bool
+	list       func(ctx context.Context, c kubernetes.Interface, ns string) ([]string, [][]string, error)
+	get        func(ctx context.Context, c kubernetes.Interface, ns, name string) (metav1.Object, error)
+}
+
+var k8sResourceAliases = map[string]string{
+	"po": "pods", "pod": "pods",
+	"deploy": "deployments", "deployment": "deployments",
+	"sts": "statefulsets", "statefulset": "statefulsets",
+	"ds": "daemonsets", "daemonset": "daemonsets",
+	"svc": "services", "service": "services",
+	"ev": "events", "event": "events",
+	"no": "nodes", "node": "nodes",
+	"ns": "namespaces", "namespace": "namespaces",
+}
+
+// KubernetesClient gives the k8s_get and k8s_logs tools read-only access to
+// a cluster. The client is built on first use so a missing kubeconfig only
+// fails the call, not startup.
+type KubernetesClient struct {
+	kubeconfigPath string
+	inCluster      bool
+	allowed        []string
+	restrict       bool
+
+	mu     sync.Mutex
+	client kubernetes.Interface
+}
+
+func NewKubernetesClient(opts KubernetesToolOptions) *KubernetesClient {
+	allowed := make([]string, 0, len(opts.AllowedNamespaces))
+	for _, ns := range opts.AllowedNamespaces {
+		if ns = strings.TrimSpace(ns); ns != "" && !slices.Contains(allowed, ns) {
+			allowed = append(allowed, ns)
+		}
+	}
+	sort.Strings(allowed)
+	return &KubernetesClient{
+		kubeconfigPath: expandHomePath(opts.KubeconfigPath),
+		inCluster:      opts.InCluster,
+		allowed:        allowed,
+		restrict:       opts.Restrict,
+	}
+}
+
+// k8sNamespaceArg returns the "namespace" argument, defaulting to "default".
+func k8sNamespaceArg(args map[string]interface{}) string {
+	namespace, _ := args["namespace"].(string)
+	if namespace = strings.TrimSpace(namespace); namespace == "" {
+		return "default"
+	}
+	return namespace
+}
+
+// K8sGetTool lists or fetches cluster resources.
+type K8sGetTool struct {
+	*KubernetesClient
+}
+
+func NewK8sGetTool(client *KubernetesClient) *K8sGetTool {
+	return &K8sGetTool{client}
+}
+
+func (t *K8sGetTool) Name() string {
+	return "k8s_get"
+}
+
+func (t *K8sGetTool) Description() string {
+	return "List or fetch Kubernetes resources (read-only): pods, deployments, statefulsets, daemonsets, services, events, nodes, namespaces."
+}
+
+func (t *K8sGetTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"resource": map[string]interface{}{
+				"type":        "string",
+				"description": "pods, deployments, statefulsets, daemonsets, services, events, nodes, or namespaces (short names like po, deploy, svc also work)",
+			},
+			"namespace": map[string]interface{}{
+				"type":        "string",
+				"description": "Namespace. Default \"default\"; ignored for nodes and namespaces.",
+			},
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "Object name. Omit to list every object of the resource.",
+			},
+		},
+		"required": []string{"resource"},
+	}
+}
+
+func (t *K8sGetTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	resource, _ := args["resource"].(string)
+	name, _ := args["name"].(string)
+	return t.get(ctx, resource, k8sNamespaceArg(args), strings.TrimSpace(name))
+}
+
+// K8sLogsTool tails a pod's logs.
+type K8sLogsTool struct {
+	*KubernetesClient
+}
+
+func NewK8sLogsTool(client *KubernetesClient) *K8sLogsTool {
+	return &K8sLogsTool{client}
+}
+
+func (t *K8sLogsTool) Name() string {
+	return "k8s_logs"
+}
+
+func (t *K8sLogsTool) Description() string {
+	return "Tail the logs of a Kubernetes pod (read-only)."
+}
+
+func (t *K8sLogsTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"pod": map[string]interface{}{
+				"type":        "string",
+				"description": "Pod name",
+			},
+			"namespace": map[string]interface{}{
+				"type":        "string",
+				"description": "Namespace. Default \"default\".",
+			},
+			"container": map[string]interface{}{
+				"type":        "string",
+				"description": "Container name, required when the pod has more than one",
+			},
+			"tail_lines": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of trailing lines. Default 100.",
+				"minimum":     1.0,
+				"maximum":     float64(k8sMaxTailLines),
+			},
+		},
+		"required": []string{"pod"},
+	}
+}
+
+func (t *K8sLogsTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	pod, _ := args["pod"].(string)
+	container, _ := args["container"].(string)
+	return t.logs(ctx, strings.TrimSpace(pod), k8sNamespaceArg(args), strings.TrimSpace(container), parseLimit(args["tail_lines"], k8sDefaultTailLines))
+}
+
+func (t *KubernetesClient) get(ctx context.Context, rawResource, namespace, name string) *ToolResult {
+	resourceName := strings.ToLower(strings.TrimSpace(rawResource))
+	if alias, ok := k8sResourceAliases[resourceName]; ok {
+		resourceName = alias
+	}
+	resource, ok := k8sResources[resourceName]
+	if !ok {
+		return ErrorResult(fmt.Sprintf("unsupported resource %q (supported: %s)", rawResource, strings.Join(k8sResourceNames(), ", ")))
+	}
+	if resource.namespaced {
+		if err := t.checkNamespace(namespace); err != nil {
+			return ErrorResult(err.Error())
+		}
+	} else if t.restrict {
+		return ErrorResult(fmt.Sprintf("%s are cluster-scoped and unavailable while the agent is restricted to tools.kubernetes.allowed_namespaces", resourceName))
+	}
+
+	client, err := t.clientset()
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	if name == "" {
+		header, rows, err := resource.list(ctx, client, namespace)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("list %s: %v", resourceName, err))
+		}
+		if len(rows) == 0 {
+			if resource.namespaced {
+				return NewToolResult(fmt.Sprintf("No %s found in namespace %s", resourceName, namespace))
+			}
+			return NewToolResult(fmt.Sprintf("No %s found", resourceName))
+		}
+		return NewToolResult(truncateK8sOutput(formatK8sTable(header, rows)))
+	}
+
+	obj, err := resource.get(ctx, client, namespace, name)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("get %s/%s: %v", resourceName, name, err))
+	}
+	// Managed fields are server bookkeeping and dwarf the useful content.
+	obj.SetManagedFields(nil)
+	raw, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("encode %s/%s: %v", resourceName, name, err))
+	}
+	return NewToolResult(truncateK8sOutput(string(raw)))
+}
+
+func (t *KubernetesClient) logs(ctx context.Context, pod, namespace, container string, tailLines int) *ToolResult {
+	if pod == "" {
+		return ErrorResult("pod is required")
+	}
+	if err := t.checkNamespace(namespace); err != nil {
+		return ErrorResult(err.Error())
+	}
+	client, err := t.clientset()
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	tail := int64(min(tailLines, k8sMaxTailLines))
+	raw, err := client.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: container,
+		TailLines: &tail,
+	}).DoRaw(ctx)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("logs %s/%s: %v", namespace, pod, err))
+	}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return NewToolResult(fmt.Sprintf("No log output for %s/%s", namespace, pod))
+	}
+	// Keep the end of the log, which is what the caller asked for.
+	out := string(raw)
+	if len(out) > k8sMaxOutputChars {
+		out = fmt.Sprintf("... (truncated, %d earlier chars)\n", len(out)-k8sMaxOutputChars) + out[len(out)-k8sMaxOutputChars:]
+	}
+	return NewToolResult(out)
+}
+
+func (t *KubernetesClient) checkNamespace(namespace string) error {
+	if !t.restrict || slices.Contains(t.allowed, namespace) {
+		return nil
+	}
+	if len(t.allowed) == 0 {
+		return fmt.Errorf("namespace %q is not allowed: tools.kubernetes.allowed_namespaces is empty", namespace)
+	}
+	return fmt.Errorf("namespace %q is not allowed (allowed: %s)", namespace, strings.Join(t.allowed, ", "))
+}
+
+func (t *KubernetesClient) clientset() (kubernetes.Interface, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client != nil {
+		return t.client, nil
+	}
+
+	var (
+		restConfig *rest.Config
+		err        error
+	)
+	if t.inCluster {
+		restConfig, err = rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("load in-cluster Kubernetes config: %v", err)
+		}
+	} else {
+		if t.kubeconfigPath == "" {
+			return nil, fmt.Errorf("tools.kubernetes.kubeconfig_path is not configured")
+		}
+		restConfig, err = clientcmd.BuildConfigFromFlags("", t.kubeconfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("load kubeconfig %s: %v", t.kubeconfigPath, err)
+		}
+	}
+	restConfig.Timeout = 30 * time.Second
+
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create Kubernetes client: %v", err)
+	}
+	t.client = client
+	return client, nil
+}
+
+var k8sResources = map[string]k8sResource{
+	"pods": {
+		namespaced: true,
+		list: func(ctx context.Context, c kubernetes.Interface, ns string) ([]string, [][]string, error) {
+			items, err := c.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, nil, err
+			}
+			rows := make([][]string, 0, len(items.Items))
+			for _, pod := range items.Items {
+				ready, restarts := 0, int32(0)
+				status := string(pod.Status.Phase)
+				for _, cs := range pod.Status.ContainerStatuses {
+					if cs.Ready {
+						ready++
+					}
+					restarts += cs.RestartCount
+					// Surface waiting reasons such as CrashLoopBackOff, as kubectl does.
+					if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+						status = cs.State.Waiting.Reason
+					}
+				}
+				if pod.DeletionTimestamp != nil {
+					status = "Terminating"
+				}
+				rows = append(rows, []string{
+					pod.Name,
+					fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+					status,
+					fmt.Sprintf("%d", restarts),
+					k8sAge(pod.CreationTimestamp),
+					pod.Spec.NodeName,
+				})
+			}
+			return []string{"NAME", "READY", "STATUS", "RESTARTS", "AGE", "NODE"}, rows, nil
+		},
+		get: func(ctx context.Context, c kubernetes.Interface, ns, name string) (metav1.Object, error) {
+			return c.CoreV1().Pods(ns).Get(ctx, name, metav1.GetOptions{})
+		},
+	},
+	"deployments": {
+		namespaced: true,
+		list: func(ctx context.Context, c kubernetes.Interface, ns string) ([]string, [][]string, error) {
+			items, err := c.AppsV1().Deployments(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, nil, err
+			}
+			rows := make([][]string, 0, len(items.Items))
+			for _, d := range items.Items {
+				rows = append(rows, []string{
+					d.Name,
+					fmt.Sprintf("%d/%d", d.Status.ReadyReplicas, k8sReplicas(d.Spec.Replicas)),
+					fmt.Sprintf("%d", d.Status.UpdatedReplicas),
+					fmt.Sprintf("%d", d.Status.AvailableReplicas),
+					k8sAge(d.CreationTimestamp),
+				})
+			}
+			return []string{"NAME", "READY", "UP-TO-DATE", "AVAILABLE", "AGE"}, rows, nil
+		},
+		get: func(ctx context.Context, c kubernetes.Interface, ns, name string) (metav1.Object, error) {
+			return c.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+		},
+	},
+	"statefulsets": {
+		namespaced: true,
+		list: func(ctx context.Context, c kubernetes.Interface, ns string) ([]string, [][]string, error) {
+			items, err := c.AppsV1().StatefulSets(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, nil, err
+			}
+			rows := make([][]string, 0, len(items.Items))
+			for _, s := range items.Items {
+				rows = append(rows, []string{
+					s.Name,
+					fmt.Sprintf("%d/%d", s.Status.ReadyReplicas, k8sReplicas(s.Spec.Replicas)),
+					k8sAge(s.CreationTimestamp),
+				})
+			}
+			return []string{"NAME", "READY", "AGE"}, rows, nil
+		},
+		get: func(ctx context.Context, c kubernetes.Interface, ns, name string) (metav1.Object, error) {
+			return c.AppsV1().StatefulSets(ns).Get(ctx, name, metav1.GetOptions{})
+		},
+	},
+	"daemonsets": {
+		namespaced: true,
+		list: func(ctx context.Context, c kubernetes.Interface, ns string) ([]string, [][]string, error) {
+			items, err := c.AppsV1().DaemonSets(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, nil, err
+			}
+			rows := make([][]string, 0, len(items.Items))
+			for _, d := range items.Items {
+				rows = append(rows, []string{
+					d.Name,
+					fmt.Sprintf("%d", d.Status.DesiredNumberScheduled),
+					fmt.Sprintf("%d", d.Status.NumberReady),
+					fmt.Sprintf("%d", d.Status.NumberAvailable),
+					k8sAge(d.CreationTimestamp),
+				})
+			}
+			return []string{"NAME", "DESIRED", "READY", "AVAILABLE", "AGE"}, rows, nil
+		},
+		get: func(ctx context.Context, c kubernetes.Interface, ns, name string) (metav1.Object, error) {
+			return c.AppsV1().DaemonSets(ns).Get(ctx, name, metav1.GetOptions{})
+		},
+	},
+	"services": {
+		namespaced: true,
+		list: func(ctx context.Context, c kubernetes.Interface, ns string) ([]string, [][]string, error) {
+			items, err := c.CoreV1().Services(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, nil, err
+			}
+			rows := make([][]string, 0, len(items.Items))
+			for _, s := range items.Items {
+				ports := make([]string, 0, len(s.Spec.Ports))
+				for _, p := range s.Spec.Ports {
+					ports = append(ports, fmt.Sprintf("%d/%s", p.Port, p.Protocol))
+				}
+				rows = append(rows, []string{
+					s.Name,
+					string(s.Spec.Type),
+					s.Spec.ClusterIP,
+					strings.Join(ports, ","),
+					k8sAge(s.CreationTimestamp),
+				})
+			}
+			return []string{"NAME", "TYPE", "CLUSTER-IP", "PORTS", "AGE"}, rows, nil
+		},
+		get: func(ctx context.Context, c kubernetes.Interface, ns, name string) (metav1.Object, error) {
+			return c.CoreV1().Services(ns).Get(ctx, name, metav1.GetOptions{})
+		},
+	},
+	"events": {
+		namespaced: true,
+		list: func(ctx context.Context, c kubernetes.Interface, ns string) ([]string, [][]string, error) {
+			items, err := c.CoreV1().Events(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, nil, err
+			}
+			events := items.Items
+			sort.SliceStable(events, func(i, j int) bool {
+				return events[i].LastTimestamp.Before(&events[j].LastTimestamp)
+			})
+			rows := make([][]string, 0, len(events))
+			for _, e := range events {
+				rows = append(rows, []string{
+					k8sAge(e.LastTimestamp),
+					e.Type,
+					e.Reason,
+					strings.ToLower(e.InvolvedObject.Kind) + "/" + e.InvolvedObject.Name,
+					strings.Join(strings.Fields(e.Message), " "),
+				})
+			}
+			return []string{"LAST SEEN", "TYPE", "REASON", "OBJECT", "MESSAGE"}, rows, nil
+		},
+		get: func(ctx context.Context, c kubernetes.Interface, ns, name string) (metav1.Object, error) {
+			return c.CoreV1().Events(ns).Get(ctx, name, metav1.GetOptions{})
+		},
+	},
+	"nodes": {
+		list: func(ctx context.Context, c kubernetes.Interface, _ string) ([]string, [][]string, error) {
+			items, err := c.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, nil, err
+			}
+			rows := make([][]string, 0, len(items.Items))
+			for _, n := range items.Items {
+				status := "NotReady"
+				for _, cond := range n.Status.Conditions {
+					if cond.Type == corev1.NodeReady && cond.Status == corev1.ConditionTrue {
+						status = "Ready"
+					}
+				}
+				if n.Spec.Unschedulable {
+					status += ",SchedulingDisabled"
+				}
+				rows = append(rows, []string{n.Name, status, n.Status.NodeInfo.KubeletVersion, k8sAge(n.CreationTimestamp)})
+			}
+			return []string{"NAME", "STATUS", "VERSION", "AGE"}, rows, nil
+		},
+		get: func(ctx context.Context, c kubernetes.Interface, _, name string) (metav1.Object, error) {
+			return c.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+		},
+	},
+	"namespaces": {
+		list: func(ctx context.Context, c kubernetes.Interface, _ string) ([]string, [][]string, error) {
+			items, err := c.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, nil, err
+			}
+			rows := make([][]string, 0, len(items.Items))
+			for _, n := range items.Items {
+				rows = append(rows, []string{n.Name, string(n.Status.Phase), k8sAge(n.CreationTimestamp)})
+			}
+			return []string{"NAME", "STATUS", "AGE"}, rows, nil
+		},
+		get: func(ctx context.Context, c kubernetes.Interface, _, name string) (metav1.Object, error) {
+			return c.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+		},
+	},
+}
+
+func k8sResourceNames() []string {
+	names := make([]string, 0, len(k8sResources))
+	for name := range k8sResources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func formatK8sTable(header []string, rows [][]string) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	_ = w.Flush()
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+func truncateK8sOutput(out string) string {
+	if len(out) <= k8sMaxOutputChars {
+		return out
+	}
+	return out[:k8sMaxOutputChars] + fmt.Sprintf("\n... (truncated, %d more chars)", len(out)-k8sMaxOutputChars)
+}
+
+func k8sReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// k8sAge renders a timestamp the way kubectl's AGE column does.
+func k8sAge(ts metav1.Time) string {
+	if ts.IsZero() {
+		return "<unknown>"
+	}
+	d := time.Since(ts.Time)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestKubernetesClient(restrict bool) *KubernetesClient {
+	replicas := int32(2)
+	kube := NewKubernetesClient(KubernetesToolOptions{
+		AllowedNamespaces: []string{"web", " web ", ""},
+		Restrict:          restrict,
+	})
+	kube.client = fake.NewClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-7d9f", Namespace: "web"},
+			Spec:       corev1.PodSpec{NodeName: "node-a", Containers: []corev1.Container{{Name: "api"}}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:         "api",
+					RestartCount: 4,
+					State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				}},
+			},
+		},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "data"}},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "web", ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}}},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 1, UpdatedReplicas: 2, AvailableReplicas: 1},
+		},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}},
+	)
+	return kube
+}
+
+func TestK8sGetTool(t *testing.T) {
+	tool := NewK8sGetTool(newTestKubernetesClient(true))
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"resource":  "po",
+		"namespace": "web",
+	})
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.ForLLM)
+	}
+	lines := strings.Split(result.ForLLM, "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "NAME") {
+		t.Fatalf("unexpected pod table:\n%s", result.ForLLM)
+	}
+	if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != "api-7d9f 0/1 CrashLoopBackOff 4 <unknown> node-a" {
+		t.Fatalf("unexpected pod row: %q", lines[1])
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{
+		"resource":  "deployments",
+		"namespace": "web",
+		"name":      "api",
+	})
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, `"readyReplicas": 1`) || strings.Contains(result.ForLLM, "managedFields") {
+		t.Fatalf("unexpected deployment output:\n%s", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{
+		"resource":  "services",
+		"namespace": "web",
+	})
+	if result.IsError || result.ForLLM != "No services found in namespace web" {
+		t.Fatalf("unexpected empty list output: %q", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{
+		"resource": "secrets",
+	})
+	if !result.IsError || !strings.Contains(result.ForLLM, "unsupported resource") {
+		t.Fatalf("expected unsupported resource error, got %q", result.ForLLM)
+	}
+}
+
+func TestKubernetesTools_RestrictNamespaces(t *testing.T) {
+	kube := newTestKubernetesClient(true)
+	tool := NewK8sGetTool(kube)
+
+	for _, tc := range []struct {
+		tool Tool
+		args map[string]interface{}
+	}{
+		{tool, map[string]interface{}{"resource": "pods", "namespace": "data"}},
+		{tool, map[string]interface{}{"resource": "pods"}},
+		{NewK8sLogsTool(kube), map[string]interface{}{"pod": "db-0", "namespace": "data"}},
+	} {
+		result := tc.tool.Execute(context.Background(), tc.args)
+		if !result.IsError || !strings.Contains(result.ForLLM, "is not allowed (allowed: web)") {
+			t.Fatalf("expected namespace restriction for %s %v, got %q", tc.tool.Name(), tc.args, result.ForLLM)
+		}
+	}
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"resource": "nodes",
+	})
+	if !result.IsError || !strings.Contains(result.ForLLM, "cluster-scoped") {
+		t.Fatalf("expected cluster-scoped restriction, got %q", result.ForLLM)
+	}
+
+	unrestricted := NewK8sGetTool(newTestKubernetesClient(false))
+	result = unrestricted.Execute(context.Background(), map[string]interface{}{
+		"resource": "nodes",
+	})
+	if result.IsError || !strings.Contains(result.ForLLM, "node-a") {
+		t.Fatalf("expected node listing without restriction, got %q", result.ForLLM)
+	}
+}
+
+func TestK8sLogsTool(t *testing.T) {
+	tool := NewK8sLogsTool(newTestKubernetesClient(true))
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"pod":        "api-7d9f",
+		"namespace":  "web",
+		"tail_lines": float64(20),
+	})
+	// The fake clientset serves a fixed log body.
+	if result.IsError || result.ForLLM != "fake logs" {
+		t.Fatalf("unexpected logs result: %q", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"namespace": "web"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "pod is required") {
+		t.Fatalf("expected missing pod error, got %q", result.ForLLM)
+	}
+}
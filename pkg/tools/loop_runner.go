@@ -9,8 +9,11 @@ import (
 	"strings"
 
 	"github.com/dotsetgreg/dotagent/pkg/logger"
+	"github.com/dotsetgreg/dotagent/pkg/observability"
 	"github.com/dotsetgreg/dotagent/pkg/providers"
+	"github.com/dotsetgreg/dotagent/pkg/trace"
 	"github.com/dotsetgreg/dotagent/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // LoopCallbacks allows callers to inject persistence/notification side-effects.
@@ -18,6 +21,7 @@ type LoopCallbacks struct {
 	OnTransientRetry  func(ctx context.Context, info providers.RetryInfo)
 	OnOverflowStage   func(ctx context.Context, stage string, attempt int, maxAttempts int, err error)
 	OnAssistantTurn   func(ctx context.Context, response *providers.LLMResponse, promptEstimateTokens int, iteration int) error
+	OnToolStart       func(ctx context.Context, call providers.ToolCall, iteration int)
 	OnToolResult      func(ctx context.Context, call providers.ToolCall, result *ToolResult, contentForLLM string, iteration int) error
 	OnToolUserMessage func(ctx context.Context, call providers.ToolCall, result *ToolResult, iteration int)
 	OnLoopWarning     func(ctx context.Context, reason string, level string, count int, message string, iteration int)
@@ -229,11 +233,11 @@ func RunToolLoop(ctx context.Context, config ToolLoopConfig, messages []provider
 		state.iteration++
 		state.runAttempts++
 
-		logger.DebugCF("toolloop", "LLM iteration", map[string]any{
+		logger.DebugCF("toolloop", "LLM iteration", trace.Fields(ctx, map[string]any{
 			"iteration": state.iteration,
 			"max":       config.MaxIterations,
 			"attempts":  state.runAttempts,
-		})
+		}))
 
 		applyContextPruningInPlace(state.messages, config.ContextPruningMode, config.ContextPruningKeepLast)
 		enforceToolResultContextBudgetInPlace(state.messages, config.ContextWindowTokens)
@@ -243,11 +247,15 @@ func RunToolLoop(ctx context.Context, config ToolLoopConfig, messages []provider
 		if err != nil {
 			recovered, recErr := recoverFromModelError(ctx, config, state, err)
 			if recErr != nil {
-				logger.ErrorCF("toolloop", "LLM call failed", map[string]any{
+				logger.ErrorCF("toolloop", "LLM call failed", trace.Fields(ctx, map[string]any{
 					"iteration": state.iteration,
+					"kind":      string(providers.InspectError(recErr).Kind),
 					"error":     recErr.Error(),
-				})
-				return nil, fmt.Errorf("LLM call failed after retries: %w", recErr)
+				}))
+				if providers.IsTransientError(recErr) {
+					return nil, fmt.Errorf("LLM call failed after retries: %w", recErr)
+				}
+				return nil, fmt.Errorf("LLM call failed: %w", recErr)
 			}
 			if recovered {
 				state.iteration--
@@ -261,10 +269,10 @@ func RunToolLoop(ctx context.Context, config ToolLoopConfig, messages []provider
 
 		if len(response.ToolCalls) == 0 {
 			state.finalContent = strings.TrimSpace(response.Content)
-			logger.InfoCF("toolloop", "LLM response without tool calls (direct answer)", map[string]any{
+			logger.InfoCF("toolloop", "LLM response without tool calls (direct answer)", trace.Fields(ctx, map[string]any{
 				"iteration":     state.iteration,
 				"content_chars": len(state.finalContent),
-			})
+			}))
 			break
 		}
 
@@ -287,11 +295,11 @@ func RunToolLoop(ctx context.Context, config ToolLoopConfig, messages []provider
 		for _, tc := range response.ToolCalls {
 			toolNames = append(toolNames, tc.Name)
 		}
-		logger.InfoCF("toolloop", "LLM requested tool calls", map[string]any{
+		logger.InfoCF("toolloop", "LLM requested tool calls", trace.Fields(ctx, map[string]any{
 			"tools":     toolNames,
 			"count":     len(response.ToolCalls),
 			"iteration": state.iteration,
-		})
+		}))
 
 		assistantMsg := providers.Message{Role: "assistant", Content: response.Content}
 		for _, tc := range response.ToolCalls {
@@ -316,11 +324,14 @@ func RunToolLoop(ctx context.Context, config ToolLoopConfig, messages []provider
 		for _, tc := range response.ToolCalls {
 			argsJSON, _ := json.Marshal(tc.Arguments)
 			argsPreview := utils.Truncate(string(argsJSON), 200)
-			logger.InfoCF("toolloop", fmt.Sprintf("Tool call: %s(%s)", tc.Name, argsPreview), map[string]any{
+			logger.InfoCF("toolloop", fmt.Sprintf("Tool call: %s(%s)", tc.Name, argsPreview), trace.Fields(ctx, map[string]any{
 				"tool":      tc.Name,
 				"iteration": state.iteration,
-			})
+			}))
 
+			if config.Callbacks.OnToolStart != nil {
+				config.Callbacks.OnToolStart(ctx, tc, state.iteration)
+			}
 			toolResult := executeToolCall(ctx, config, channel, chatID, tc)
 			if toolResult == nil {
 				toolResult = ErrorResult(fmt.Sprintf("tool %s returned no result", tc.Name))
@@ -334,6 +345,9 @@ func RunToolLoop(ctx context.Context, config ToolLoopConfig, messages []provider
 			if contentForLLM == "" && toolResult.Err != nil {
 				contentForLLM = toolResult.Err.Error()
 			}
+			if returnsUntrustedContent(config.Tools, tc.Name) {
+				contentForLLM = SanitizeResult(contentForLLM)
+			}
 			contentForLLM = normalizeSingleToolResult(contentForLLM, config.ContextWindowTokens)
 
 			state.messages = append(state.messages, providers.Message{
@@ -397,7 +411,20 @@ func callModelWithRetry(ctx context.Context, config ToolLoopConfig, messages []p
 	}
 
 	resp, err := providers.RetryCall(ctx, config.Retry, func() (*providers.LLMResponse, error) {
-		return call(ctx, messages, toolDefs, config.Model, config.LLMOptions)
+		callCtx, span := observability.StartSpan(ctx, "llm.chat_call",
+			attribute.String("llm.model", config.Model),
+			attribute.Int("llm.messages", len(messages)),
+			attribute.Int("llm.tools", len(toolDefs)),
+		)
+		resp, err := call(callCtx, messages, toolDefs, config.Model, config.LLMOptions)
+		if resp != nil && resp.Usage != nil {
+			span.SetAttributes(
+				attribute.Int("llm.usage.prompt_tokens", resp.Usage.PromptTokens),
+				attribute.Int("llm.usage.completion_tokens", resp.Usage.CompletionTokens),
+			)
+		}
+		observability.EndSpan(span, err)
+		return resp, err
 	}, providers.IsTransientError, func(info providers.RetryInfo) {
 		if config.Callbacks.OnTransientRetry != nil {
 			config.Callbacks.OnTransientRetry(ctx, info)
@@ -409,10 +436,23 @@ func callModelWithRetry(ctx context.Context, config ToolLoopConfig, messages []p
 	return resp, nil
 }
 
+// recoverFromModelError decides from the error kind whether the loop can
+// retry. Only context overflow is recoverable here; transient errors were
+// already retried by callModelWithRetry.
 func recoverFromModelError(ctx context.Context, config ToolLoopConfig, state *runnerState, err error) (bool, error) {
-	if !providers.IsContextOverflowError(err) {
+	switch providers.InspectError(err).Kind {
+	case providers.ErrorKindContextOverflow:
+		return recoverFromContextOverflow(ctx, config, state, err)
+	case providers.ErrorKindAuth:
+		return false, fmt.Errorf("%w (check the provider credentials in config)", err)
+	case providers.ErrorKindModelNotFound:
+		return false, fmt.Errorf("%w (model %q is not available from this provider)", err, config.Model)
+	default:
 		return false, err
 	}
+}
+
+func recoverFromContextOverflow(ctx context.Context, config ToolLoopConfig, state *runnerState, err error) (bool, error) {
 	state.lastContextOverflowError = err
 
 	if state.overflowCompactionAttempts < config.MaxOverflowCompactions && config.RebuildContext != nil {
@@ -422,15 +462,15 @@ func recoverFromModelError(ctx context.Context, config ToolLoopConfig, state *ru
 		}
 		rebuilt, rebuildErr := config.RebuildContext(ctx)
 		if rebuildErr != nil {
-			logger.WarnCF("toolloop", "Failed rebuilding context after compaction", map[string]any{
+			logger.WarnCF("toolloop", "Failed rebuilding context after compaction", trace.Fields(ctx, map[string]any{
 				"attempt": state.overflowCompactionAttempts,
 				"error":   rebuildErr.Error(),
-			})
+			}))
 		} else if len(rebuilt) > 0 {
 			if messagesSemanticallyEqual(state.messages, rebuilt) {
-				logger.WarnCF("toolloop", "Rebuilt context unchanged after compaction; trying truncation fallback", map[string]any{
+				logger.WarnCF("toolloop", "Rebuilt context unchanged after compaction; trying truncation fallback", trace.Fields(ctx, map[string]any{
 					"attempt": state.overflowCompactionAttempts,
-				})
+				}))
 			} else {
 				state.messages = cloneMessages(rebuilt)
 				state.hasContextOverflowCompacted = true
@@ -462,6 +502,20 @@ func executeToolCall(ctx context.Context, config ToolLoopConfig, channel, chatID
 	return config.Tools.ExecuteWithContext(ctx, tc.Name, tc.Arguments, channel, chatID, nil)
 }
 
+// returnsUntrustedContent reports whether the named tool declares its output
+// untrusted via UntrustedContentTool.
+func returnsUntrustedContent(registry *ToolRegistry, name string) bool {
+	if registry == nil {
+		return false
+	}
+	tool, ok := registry.Get(name)
+	if !ok {
+		return false
+	}
+	untrusted, ok := tool.(UntrustedContentTool)
+	return ok && untrusted.UntrustedContent()
+}
+
 func cloneMessages(messages []providers.Message) []providers.Message {
 	if len(messages) == 0 {
 		return nil
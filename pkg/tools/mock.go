@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// MockInteraction is one expected tool call and the result to return for it.
+// A nil Args matches any arguments; a nil Result returns an empty success.
+type MockInteraction struct {
+	Name   string
+	Args   map[string]interface{}
+	Result *ToolResult
+}
+
+// MockToolRegistry is a ToolRegistry for tests whose tools replay scripted
+// interactions in order instead of touching the filesystem or network. Each
+// distinct interaction name is registered as a stub tool, so the registry
+// can be passed anywhere a *ToolRegistry is expected via its embedded field.
+//
+// A call that does not match the next interaction, or arrives after the
+// queue is exhausted, makes ExecuteWithContext panic so the test fails at the
+// offending call. Code holding only the embedded *ToolRegistry (such as
+// RunToolLoop) sees that panic recovered as a tool error; check Verify after
+// the run in that case.
+type MockToolRegistry struct {
+	*ToolRegistry
+
+	mu      sync.Mutex
+	pending []MockInteraction
+	calls   []MockInteraction
+	failure error // first failed call, for Verify
+	last    error
+	fails   int
+}
+
+func NewMockToolRegistry(interactions []MockInteraction) *MockToolRegistry {
+	m := &MockToolRegistry{
+		ToolRegistry: NewToolRegistry(),
+		pending:      append([]MockInteraction(nil), interactions...),
+	}
+	for _, interaction := range interactions {
+		if _, ok := m.Get(interaction.Name); ok {
+			continue
+		}
+		if err := m.Register(&mockTool{name: interaction.Name, registry: m}); err != nil {
+			panic(fmt.Sprintf("mock tool registry: %v", err))
+		}
+	}
+	return m
+}
+
+// ExecuteWithContext replays the next interaction through the embedded
+// registry and panics if the call was not the one scripted.
+func (m *MockToolRegistry) ExecuteWithContext(ctx context.Context, name string, args map[string]interface{}, channel, chatID string, asyncCallback AsyncCallback) *ToolResult {
+	if _, ok := m.Get(name); !ok {
+		m.mu.Lock()
+		failure := m.fail(fmt.Errorf("mock tool registry: call %d to unscripted tool %q", len(m.calls)+1, name))
+		m.mu.Unlock()
+		panic(failure.Error())
+	}
+	m.mu.Lock()
+	before := m.fails
+	m.mu.Unlock()
+	result := m.ToolRegistry.ExecuteWithContext(ctx, name, args, channel, chatID, asyncCallback)
+	m.mu.Lock()
+	failed, last := m.fails != before, m.last
+	m.mu.Unlock()
+	if failed {
+		panic(last.Error())
+	}
+	return result
+}
+
+func (m *MockToolRegistry) Execute(ctx context.Context, name string, args map[string]interface{}) *ToolResult {
+	return m.ExecuteWithContext(ctx, name, args, "", "", nil)
+}
+
+// Verify reports the first unexpected call, or the interactions that were
+// never replayed.
+func (m *MockToolRegistry) Verify() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failure != nil {
+		return m.failure
+	}
+	if len(m.pending) > 0 {
+		names := make([]string, 0, len(m.pending))
+		for _, interaction := range m.pending {
+			names = append(names, interaction.Name)
+		}
+		return fmt.Errorf("mock tool registry: %d interaction(s) not replayed: %v", len(m.pending), names)
+	}
+	return nil
+}
+
+// Calls returns the tool calls executed so far, with the results returned.
+func (m *MockToolRegistry) Calls() []MockInteraction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockInteraction(nil), m.calls...)
+}
+
+// Remaining returns how many scripted interactions have not been replayed.
+func (m *MockToolRegistry) Remaining() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.pending)
+}
+
+func (m *MockToolRegistry) replay(name string, args map[string]interface{}) *ToolResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	call := len(m.calls) + 1
+	var failure error
+	switch {
+	case len(m.pending) == 0:
+		failure = fmt.Errorf("mock tool registry: unexpected call %d to %q after all scripted interactions", call, name)
+	case m.pending[0].Name != name:
+		failure = fmt.Errorf("mock tool registry: call %d: expected %q, got %q", call, m.pending[0].Name, name)
+	case m.pending[0].Args != nil && !reflect.DeepEqual(m.pending[0].Args, args):
+		failure = fmt.Errorf("mock tool registry: call %d to %q: expected args %v, got %v", call, name, m.pending[0].Args, args)
+	}
+	if failure != nil {
+		panic(m.fail(failure).Error())
+	}
+
+	next := m.pending[0]
+	m.pending = m.pending[1:]
+	result := next.Result
+	if result == nil {
+		result = NewToolResult("")
+	}
+	m.calls = append(m.calls, MockInteraction{Name: name, Args: args, Result: result})
+	return result
+}
+
+// fail records a failed call; m.mu must be held.
+func (m *MockToolRegistry) fail(err error) error {
+	if m.failure == nil {
+		m.failure = err
+	}
+	m.last = err
+	m.fails++
+	return err
+}
+
+type mockTool struct {
+	name     string
+	registry *MockToolRegistry
+}
+
+func (t *mockTool) Name() string {
+	return t.name
+}
+
+func (t *mockTool) Description() string {
+	return "Mock " + t.name + " tool that replays scripted results"
+}
+
+func (t *mockTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+}
+
+func (t *mockTool) Execute(_ context.Context, args map[string]interface{}) *ToolResult {
+	return t.registry.replay(t.name, args)
+}
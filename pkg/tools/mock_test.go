@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dotsetgreg/dotagent/pkg/providers"
+)
+
+func TestMockToolRegistry_ReplaysInOrder(t *testing.T) {
+	mock := NewMockToolRegistry([]MockInteraction{
+		{Name: "read_file", Args: map[string]interface{}{"path": "a.txt"}, Result: NewToolResult("alpha")},
+		{Name: "write_file", Result: SilentResult("written")},
+		{Name: "read_file"},
+	})
+
+	if got := mock.ExecuteWithContext(context.Background(), "read_file", map[string]interface{}{"path": "a.txt"}, "cli", "direct", nil); got.ForLLM != "alpha" {
+		t.Fatalf("expected scripted result, got %q", got.ForLLM)
+	}
+	if got := mock.Execute(context.Background(), "write_file", map[string]interface{}{"path": "b.txt", "content": "x"}); !got.Silent {
+		t.Fatalf("expected silent scripted result, got %+v", got)
+	}
+	if got := mock.Execute(context.Background(), "read_file", map[string]interface{}{"path": "c.txt"}); got.IsError {
+		t.Fatalf("expected empty success for nil Result, got %+v", got)
+	}
+
+	if err := mock.Verify(); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	calls := mock.Calls()
+	if len(calls) != 3 || calls[1].Name != "write_file" || calls[1].Args["path"] != "b.txt" {
+		t.Fatalf("unexpected recorded calls: %+v", calls)
+	}
+}
+
+func TestMockToolRegistry_PanicsOnUnexpectedCalls(t *testing.T) {
+	expectPanic := func(t *testing.T, want string, fn func()) {
+		t.Helper()
+		defer func() {
+			t.Helper()
+			r := recover()
+			if r == nil {
+				t.Fatalf("expected panic containing %q", want)
+			}
+			if msg, _ := r.(string); !strings.Contains(msg, want) {
+				t.Fatalf("expected panic containing %q, got %v", want, r)
+			}
+		}()
+		fn()
+	}
+
+	mock := NewMockToolRegistry([]MockInteraction{
+		{Name: "read_file", Args: map[string]interface{}{"path": "a.txt"}},
+	})
+	expectPanic(t, `expected args`, func() {
+		mock.Execute(context.Background(), "read_file", map[string]interface{}{"path": "other.txt"})
+	})
+	expectPanic(t, `unscripted tool "exec"`, func() {
+		mock.Execute(context.Background(), "exec", nil)
+	})
+	mock.Execute(context.Background(), "read_file", map[string]interface{}{"path": "a.txt"})
+	expectPanic(t, "after all scripted interactions", func() {
+		mock.Execute(context.Background(), "read_file", map[string]interface{}{"path": "a.txt"})
+	})
+}
+
+func TestMockToolRegistry_VerifyAfterToolLoop(t *testing.T) {
+	mock := NewMockToolRegistry([]MockInteraction{
+		{Name: "list_dir", Result: NewToolResult("a.txt")},
+		{Name: "read_file"},
+	})
+	provider := &scriptedToolProvider{responses: []*providers.LLMResponse{
+		{ToolCalls: []providers.ToolCall{{ID: "call-1", Name: "list_dir", Arguments: map[string]interface{}{"path": "."}}}},
+		{Content: "done"},
+	}}
+
+	_, err := RunToolLoop(context.Background(), ToolLoopConfig{
+		Provider:            provider,
+		Model:               "test-model",
+		Tools:               mock.ToolRegistry,
+		MaxIterations:       5,
+		ContextWindowTokens: 4096,
+	}, nil, "cli", "direct")
+	if err != nil {
+		t.Fatalf("run tool loop: %v", err)
+	}
+	if err := mock.Verify(); err == nil || !strings.Contains(err.Error(), "read_file") {
+		t.Fatalf("expected verify to report the unreplayed read_file, got %v", err)
+	}
+}
@@ -0,0 +1,408 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	defaultPDFMaxChars = 50000
+	pdfMaxFileBytes    = 100 << 20
+	pdfMaxDecodedBytes = 256 << 20
+	pdfTextTimeout     = 60 * time.Second
+)
+
+var errPDFNoText = errors.New("no extractable text")
+
+// PDFTool extracts the text of a PDF in the workspace. Text is read with
+// github.com/ledongthuc/pdf, which understands Flate and ASCII85 streams and
+// the common font encodings; when that finds nothing it can decode (scans,
+// unusual filters, damaged files) the tool shells out to poppler's pdftotext
+// if it is installed. Streams are decoded within maxDecoded bytes per
+// document, so a small file of stacked filters cannot inflate without bound.
+type PDFTool struct {
+	workspace  string
+	restrict   bool
+	maxChars   int
+	maxDecoded int64
+	lookPath   func(string) (string, error)
+	run        func(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+func NewPDFTool(workspace string, restrict bool, maxChars int) *PDFTool {
+	if maxChars <= 0 {
+		maxChars = defaultPDFMaxChars
+	}
+	return &PDFTool{
+		workspace:  workspace,
+		restrict:   restrict,
+		maxChars:   maxChars,
+		maxDecoded: pdfMaxDecodedBytes,
+		lookPath:   exec.LookPath,
+		run:        runPDFTextCommand,
+	}
+}
+
+func (t *PDFTool) Name() string {
+	return "pdf_read"
+}
+
+func (t *PDFTool) Description() string {
+	return fmt.Sprintf("Extract the text of a PDF file in the workspace, optionally limited to some pages (e.g. pages=\"1-3,5\"). Returns at most %d characters; read long documents a few pages at a time.", t.maxChars)
+}
+
+func (t *PDFTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Path to the PDF file",
+			},
+			"pages": map[string]interface{}{
+				"type":        "string",
+				"description": "Pages to read as a comma-separated list of numbers and ranges, e.g. \"1-3,5\" or \"10-\" (default: all pages)",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+func (t *PDFTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	path, _ := args["path"].(string)
+	if strings.TrimSpace(path) == "" {
+		return ErrorResult("path is required")
+	}
+	pagesSpec, _ := args["pages"].(string)
+
+	resolved, err := validatePath(path, t.workspace, t.restrict)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to read file: %v", err))
+	}
+	if info.IsDir() {
+		return ErrorResult(fmt.Sprintf("%s is a directory", path))
+	}
+	if info.Size() > pdfMaxFileBytes {
+		return ErrorResult(fmt.Sprintf("file is %d MB; pdf_read handles files up to %d MB", info.Size()>>20, pdfMaxFileBytes>>20))
+	}
+	data, err := os.ReadFile(resolved)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to read file: %v", err))
+	}
+	if !bytes.HasPrefix(bytes.TrimLeft(data[:min(len(data), 1024)], "\x00\t\r\n "), []byte("%PDF-")) {
+		return ErrorResult(fmt.Sprintf("%s is not a PDF file", path))
+	}
+
+	pages, err := t.extract(ctx, resolved, data)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to extract text from %s: %v", path, err))
+	}
+	selected, err := parsePDFPageRanges(pagesSpec, len(pages))
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	return NewToolResult(formatPDFPages(pages, selected, t.maxChars))
+}
+
+// extract returns the text of every page, using pdftotext when the built-in
+// parser fails or finds no readable text.
+func (t *PDFTool) extract(ctx context.Context, path string, data []byte) ([]string, error) {
+	pages, builtinErr := extractPDFText(data, t.maxDecoded)
+	if builtinErr == nil && !pdfTextReadable(pages) {
+		builtinErr = errPDFNoText
+	}
+	if builtinErr == nil {
+		return pages, nil
+	}
+	if errors.Is(builtinErr, errPDFTooLarge) {
+		return nil, builtinErr
+	}
+
+	bin, err := t.lookPath("pdftotext")
+	if err != nil {
+		if errors.Is(builtinErr, errPDFNoText) {
+			return nil, fmt.Errorf("%w (the PDF may be scanned or use fonts the built-in reader cannot decode; install poppler's pdftotext for better coverage)", builtinErr)
+		}
+		return nil, fmt.Errorf("%w (install poppler's pdftotext for better coverage)", builtinErr)
+	}
+	runCtx, cancel := context.WithTimeout(ctx, pdfTextTimeout)
+	defer cancel()
+	out, err := t.run(runCtx, bin, "-enc", "UTF-8", "-layout", path, "-")
+	if err != nil {
+		if runCtx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("pdftotext timed out after %v", pdfTextTimeout)
+		}
+		return nil, fmt.Errorf("pdftotext: %w", err)
+	}
+	// pdftotext ends every page with a form feed.
+	text := strings.TrimSuffix(string(out), "\f")
+	return strings.Split(text, "\f"), nil
+}
+
+func runPDFTextCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// parsePDFPageRanges turns a spec like "1-3,5,9-" into ascending, distinct
+// 1-based page numbers. An empty spec selects every page.
+func parsePDFPageRanges(spec string, total int) ([]int, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		pages := make([]int, total)
+		for i := range pages {
+			pages[i] = i + 1
+		}
+		return pages, nil
+	}
+	seen := map[int]bool{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, fmt.Errorf("invalid page range %q: use numbers and ranges like \"1-3,5\"", part)
+		}
+		end := start
+		if isRange {
+			end = total
+			if hi = strings.TrimSpace(hi); hi != "" {
+				if end, err = strconv.Atoi(hi); err != nil {
+					return nil, fmt.Errorf("invalid page range %q: use numbers and ranges like \"1-3,5\"", part)
+				}
+			}
+		}
+		if start < 1 || end < start {
+			return nil, fmt.Errorf("invalid page range %q", part)
+		}
+		if start > total || end > total {
+			return nil, fmt.Errorf("page range %q is out of bounds: the document has %d page(s)", part, total)
+		}
+		for p := start; p <= end; p++ {
+			seen[p] = true
+		}
+	}
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("invalid page range %q", spec)
+	}
+	pages := make([]int, 0, len(seen))
+	for p := range seen {
+		pages = append(pages, p)
+	}
+	sort.Ints(pages)
+	return pages, nil
+}
+
+func formatPDFPages(pages []string, selected []int, maxChars int) string {
+	var b strings.Builder
+	for i, p := range selected {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "--- Page %d of %d ---\n", p, len(pages))
+		text := strings.TrimSpace(pages[p-1])
+		if text == "" {
+			text = "(no text)"
+		}
+		b.WriteString(text)
+	}
+	out := b.String()
+	if utf8.RuneCountInString(out) <= maxChars {
+		return out
+	}
+	runes := []rune(out)
+	return string(runes[:maxChars]) + fmt.Sprintf("\n... (truncated, %d more chars; request fewer pages to read the rest)", len(runes)-maxChars)
+}
+
+// pdfTextReadable reports whether the extracted pages hold any text and
+// that text is mostly printable, which it is not when the fonts map bytes to
+// glyph IDs rather than characters.
+func pdfTextReadable(pages []string) bool {
+	total, printable := 0, 0
+	for _, page := range pages {
+		for _, r := range page {
+			if unicode.IsSpace(r) {
+				continue
+			}
+			total++
+			if unicode.IsPrint(r) && r != utf8.RuneError {
+				printable++
+			}
+		}
+	}
+	return total > 0 && printable*10 >= total*9
+}
+
+// errPDFTooLarge is returned when a document's streams decode to more than
+// the tool's budget, as a stack of Flate filters over a small file can.
+var errPDFTooLarge = errors.New("decoded content exceeds the size limit")
+
+// extractPDFText returns the text of each page of a PDF, in page order.
+// Content and font streams are decoded within maxDecoded bytes in total
+// before any of them is interpreted.
+func extractPDFText(data []byte, maxDecoded int64) (pages []string, err error) {
+	// The reader reports malformed input by panicking.
+	defer func() {
+		if r := recover(); r != nil {
+			pages, err = nil, fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	budget := maxDecoded
+	for i := 1; i <= r.NumPage(); i++ {
+		page := r.Page(i)
+		if page.V.IsNull() {
+			// /Count claims more pages than the tree holds.
+			break
+		}
+		fonts := map[string]*pdf.Font{}
+		for _, name := range page.Fonts() {
+			font := page.Font(name)
+			if err := chargePDFStream(font.V.Key("ToUnicode"), &budget); err != nil {
+				return nil, err
+			}
+			fonts[name] = &font
+		}
+		contents := page.V.Key("Contents")
+		if err := chargePDFStream(contents, &budget); err != nil {
+			return nil, err
+		}
+		pages = append(pages, pdfPageText(contents, fonts))
+	}
+	if len(pages) == 0 {
+		return nil, errors.New("no pages found")
+	}
+	return pages, nil
+}
+
+// chargePDFStream decodes a stream, or an array of them, and subtracts its
+// size from budget, failing as soon as the budget runs out rather than after
+// inflating the whole thing.
+func chargePDFStream(v pdf.Value, budget *int64) error {
+	switch v.Kind() {
+	case pdf.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := chargePDFStream(v.Index(i), budget); err != nil {
+				return err
+			}
+		}
+	case pdf.Stream:
+		rc := v.Reader()
+		defer rc.Close()
+		n, err := io.Copy(io.Discard, io.LimitReader(rc, *budget+1))
+		if err != nil {
+			return fmt.Errorf("decode stream: %w", err)
+		}
+		if n > *budget {
+			return errPDFTooLarge
+		}
+		*budget -= n
+	}
+	return nil
+}
+
+// pdfLineBreaks flattens line breaks inside shown strings; lines come from
+// the positioning operators instead.
+var pdfLineBreaks = strings.NewReplacer("\r", " ", "\n", " ", "\t", " ")
+
+// pdfPageText interprets the text operators of a page's content streams:
+// Tj, TJ, ' and " show strings in the current font's encoding, and Td, TD,
+// T*, Tm and ET move to a new line when the position changes vertically.
+func pdfPageText(contents pdf.Value, fonts map[string]*pdf.Font) string {
+	var (
+		b   strings.Builder
+		enc pdf.TextEncoding
+	)
+	newline := func() {
+		s := b.String()
+		if s != "" && !strings.HasSuffix(s, "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	show := func(raw string) {
+		if enc != nil {
+			raw = enc.Decode(raw)
+		}
+		b.WriteString(pdfLineBreaks.Replace(raw))
+	}
+	pdf.Interpret(contents, func(stk *pdf.Stack, op string) {
+		args := make([]pdf.Value, stk.Len())
+		for i := len(args) - 1; i >= 0; i-- {
+			args[i] = stk.Pop()
+		}
+		last := pdf.Value{}
+		if len(args) > 0 {
+			last = args[len(args)-1]
+		}
+		switch op {
+		case "Tf":
+			enc = nil
+			if len(args) == 2 {
+				if font, ok := fonts[args[0].Name()]; ok {
+					enc = font.Encoder()
+				}
+			}
+		case "Tj":
+			show(last.RawString())
+		case "'", "\"":
+			newline()
+			show(last.RawString())
+		case "TJ":
+			for i := 0; i < last.Len(); i++ {
+				switch x := last.Index(i); x.Kind() {
+				case pdf.String:
+					show(x.RawString())
+				case pdf.Integer, pdf.Real:
+					// Large negative kerning separates words.
+					if s := b.String(); x.Float64() < -200 && s != "" && !strings.HasSuffix(s, " ") {
+						b.WriteByte(' ')
+					}
+				}
+			}
+		case "T*", "ET", "Tm":
+			newline()
+		case "Td", "TD":
+			if len(args) < 2 {
+				break
+			}
+			if last.Float64() != 0 {
+				newline()
+			} else if s := b.String(); s != "" && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+				b.WriteByte(' ')
+			}
+		}
+	})
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,229 @@
+package tools
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testPDFStream struct {
+	filter  string
+	content []byte
+}
+
+// writeTestPDF builds a PDF whose pages draw the given content streams. The
+// page objects are numbered in reverse so page order must come from /Kids.
+// Each /FlateDecode in a stream's filter compresses its content once more.
+func writeTestPDF(t *testing.T, path string, pages ...testPDFStream) {
+	t.Helper()
+	var b bytes.Buffer
+	n := len(pages)
+	offsets := make([]int, 3+2*n)
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	pageNum := func(i int) int { return 3 + 2*(n-1-i) }
+	offsets[1] = b.Len()
+	b.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	var kids []string
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageNum(i)))
+	}
+	offsets[2] = b.Len()
+	fmt.Fprintf(&b, "2 0 obj\n<< /Type /Pages /Kids [%s] /Count %d >>\nendobj\n", strings.Join(kids, " "), n)
+	for i := n - 1; i >= 0; i-- {
+		page := pages[i]
+		num := pageNum(i)
+		offsets[num] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R /Resources << /Font << /F1 << /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >> >> >> >>\nendobj\n", num, num+1)
+		data := page.content
+		for range strings.Count(page.filter, "/FlateDecode") {
+			var z bytes.Buffer
+			w := zlib.NewWriter(&z)
+			_, _ = w.Write(data)
+			_ = w.Close()
+			data = z.Bytes()
+		}
+		filter := ""
+		if page.filter != "" {
+			filter = " /Filter " + page.filter
+		}
+		offsets[num+1] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n<< /Length %d%s >>\nstream\n", num+1, len(data), filter)
+		b.Write(data)
+		b.WriteString("\nendstream\nendobj\n")
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f\r\n", len(offsets))
+	for _, off := range offsets[1:] {
+		fmt.Fprintf(&b, "%010d 00000 n\r\n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets), xref)
+	if err := os.WriteFile(path, b.Bytes(), 0o644); err != nil {
+		t.Fatalf("write pdf: %v", err)
+	}
+}
+
+func noPDFToText(t *testing.T, tool *PDFTool) {
+	t.Helper()
+	tool.lookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+	tool.run = func(context.Context, string, ...string) ([]byte, error) {
+		t.Fatal("pdftotext should not run")
+		return nil, nil
+	}
+}
+
+func TestPDFTool_ExtractsPagesInOrder(t *testing.T) {
+	workspace := t.TempDir()
+	writeTestPDF(t, filepath.Join(workspace, "report.pdf"),
+		testPDFStream{filter: "/FlateDecode", content: []byte("BT /F1 24 Tf 72 720 Td (Quarterly Report) Tj 0 -30 Td [(Revenue ) -250 (grew \\(again\\)) ] TJ ET")},
+		testPDFStream{content: []byte("BT /F1 12 Tf 72 720 Td <48656C6C6F> Tj T* (second line) Tj ET")},
+		testPDFStream{filter: "/FlateDecode", content: []byte("BT /F1 12 Tf 72 720 Td (Caf\\351 \\223quoted\\224) Tj ET")},
+	)
+	tool := NewPDFTool(workspace, true, 0)
+	noPDFToText(t, tool)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"path": "report.pdf"})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	want := "--- Page 1 of 3 ---\nQuarterly Report\nRevenue grew (again)\n\n" +
+		"--- Page 2 of 3 ---\nHello\nsecond line\n\n" +
+		"--- Page 3 of 3 ---\nCafé “quoted”"
+	if result.ForLLM != want {
+		t.Fatalf("unexpected text:\n%s\nwant:\n%s", result.ForLLM, want)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"path": "report.pdf", "pages": "3, 1"})
+	if result.IsError || !strings.HasPrefix(result.ForLLM, "--- Page 1 of 3 ---") || !strings.Contains(result.ForLLM, "--- Page 3 of 3 ---") || strings.Contains(result.ForLLM, "Hello") {
+		t.Fatalf("unexpected filtered text: %q", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{"path": "report.pdf", "pages": "4"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "out of bounds") {
+		t.Fatalf("expected out of bounds error, got %q", result.ForLLM)
+	}
+}
+
+func TestPDFTool_TruncatesToMaxChars(t *testing.T) {
+	workspace := t.TempDir()
+	writeTestPDF(t, filepath.Join(workspace, "long.pdf"),
+		testPDFStream{content: []byte("BT (" + strings.Repeat("a", 200) + ") Tj ET")})
+	tool := NewPDFTool(workspace, true, 50)
+	noPDFToText(t, tool)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"path": "long.pdf"})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if !strings.HasPrefix(result.ForLLM, "--- Page 1 of 1 ---\naaa") || !strings.Contains(result.ForLLM, "... (truncated, 170 more chars") {
+		t.Fatalf("unexpected truncation: %q", result.ForLLM)
+	}
+}
+
+func TestPDFTool_FallsBackToPDFToText(t *testing.T) {
+	workspace := t.TempDir()
+	path := filepath.Join(workspace, "scan.pdf")
+	writeTestPDF(t, path,
+		testPDFStream{filter: "/LZWDecode", content: []byte("opaque")},
+		testPDFStream{filter: "/LZWDecode", content: []byte("opaque")},
+	)
+	tool := NewPDFTool(workspace, true, 0)
+	tool.lookPath = func(name string) (string, error) { return "/usr/bin/" + name, nil }
+	var gotArgs []string
+	tool.run = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		gotArgs = append([]string{name}, args...)
+		return []byte("first page\fsecond page\f"), nil
+	}
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"path": "scan.pdf", "pages": "2-"})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if result.ForLLM != "--- Page 2 of 2 ---\nsecond page" {
+		t.Fatalf("unexpected text: %q", result.ForLLM)
+	}
+	if want := []string{"/usr/bin/pdftotext", "-enc", "UTF-8", "-layout", path, "-"}; !reflect.DeepEqual(gotArgs, want) {
+		t.Fatalf("unexpected command: %v", gotArgs)
+	}
+
+	tool.lookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+	result = tool.Execute(context.Background(), map[string]interface{}{"path": "scan.pdf"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "pdftotext") {
+		t.Fatalf("expected an error suggesting pdftotext, got %q", result.ForLLM)
+	}
+}
+
+func TestPDFTool_BoundsDecodedContent(t *testing.T) {
+	workspace := t.TempDir()
+	writeTestPDF(t, filepath.Join(workspace, "bomb.pdf"),
+		testPDFStream{filter: "[/FlateDecode /FlateDecode]", content: bytes.Repeat([]byte("BT (a) Tj ET\n"), 1<<16)})
+	tool := NewPDFTool(workspace, true, 0)
+	noPDFToText(t, tool)
+
+	tool.maxDecoded = 1 << 20
+	result := tool.Execute(context.Background(), map[string]interface{}{"path": "bomb.pdf"})
+	if result.IsError {
+		t.Fatalf("unexpected error under the limit: %s", result.ForLLM)
+	}
+
+	tool.maxDecoded = 1 << 16
+	result = tool.Execute(context.Background(), map[string]interface{}{"path": "bomb.pdf"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "exceeds the size limit") {
+		t.Fatalf("expected the decoded size limit to be enforced, got %q", result.ForLLM)
+	}
+}
+
+func TestPDFTool_RespectsWorkspaceRestriction(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "secret.pdf")
+	writeTestPDF(t, outside, testPDFStream{content: []byte("BT (secret) Tj ET")})
+
+	tool := NewPDFTool(t.TempDir(), true, 0)
+	noPDFToText(t, tool)
+	result := tool.Execute(context.Background(), map[string]interface{}{"path": outside})
+	if !result.IsError || !strings.Contains(result.ForLLM, "outside the workspace") {
+		t.Fatalf("expected access denied, got %q", result.ForLLM)
+	}
+
+	if err := os.WriteFile(filepath.Join(tool.workspace, "notes.pdf"), []byte("just text"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result = tool.Execute(context.Background(), map[string]interface{}{"path": "notes.pdf"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "not a PDF") {
+		t.Fatalf("expected not a PDF error, got %q", result.ForLLM)
+	}
+}
+
+func TestParsePDFPageRanges(t *testing.T) {
+	cases := []struct {
+		spec    string
+		want    []int
+		wantErr string
+	}{
+		{spec: "", want: []int{1, 2, 3, 4, 5, 6}},
+		{spec: "1-3,5", want: []int{1, 2, 3, 5}},
+		{spec: "5, 2-3, 3", want: []int{2, 3, 5}},
+		{spec: "4-", want: []int{4, 5, 6}},
+		{spec: "0", wantErr: "invalid page range"},
+		{spec: "3-1", wantErr: "invalid page range"},
+		{spec: "two", wantErr: "invalid page range"},
+		{spec: "2-9", wantErr: "out of bounds"},
+	}
+	for _, tc := range cases {
+		got, err := parsePDFPageRanges(tc.spec, 6)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("%q: expected error %q, got %v", tc.spec, tc.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: got %v, %v; want %v", tc.spec, got, err, tc.want)
+		}
+	}
+}
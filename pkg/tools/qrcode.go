@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const qrCodePNGSize = 256
+
+// QRCodeTool renders text or URLs as QR codes, either as a PNG in the
+// workspace or as text art that can be shown inline in chat.
+type QRCodeTool struct {
+	workspace string
+	restrict  bool
+}
+
+func NewQRCodeTool(workspace string, restrict bool) *QRCodeTool {
+	return &QRCodeTool{workspace: workspace, restrict: restrict}
+}
+
+func (t *QRCodeTool) Name() string {
+	return "qr_generate"
+}
+
+func (t *QRCodeTool) Description() string {
+	return "Generate a QR code for a URL or short text. format=png writes an image to the workspace and returns its path; format=ascii returns the code as text art to paste into chat."
+}
+
+func (t *QRCodeTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "Text or URL to encode",
+			},
+			"format": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"png", "ascii"},
+				"description": "Output format (default png)",
+			},
+			"output_path": map[string]interface{}{
+				"type":        "string",
+				"description": "Where to write the file (default qrcodes/qr-<hash>.png for png; ascii is only written when set)",
+			},
+		},
+		"required": []string{"content"},
+	}
+}
+
+func (t *QRCodeTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	content, _ := args["content"].(string)
+	if strings.TrimSpace(content) == "" {
+		return ErrorResult("content is required")
+	}
+	format, _ := args["format"].(string)
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = "png"
+	}
+	if format != "png" && format != "ascii" {
+		return ErrorResult("format must be one of: png, ascii")
+	}
+	outputPath, _ := args["output_path"].(string)
+	outputPath = strings.TrimSpace(outputPath)
+
+	code, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to encode QR code: %v", err))
+	}
+
+	if format == "ascii" {
+		art := code.ToSmallString(false)
+		if outputPath != "" {
+			resolvedPath, err := t.write(outputPath, []byte(art))
+			if err != nil {
+				return ErrorResult(err.Error())
+			}
+			return NewToolResult(fmt.Sprintf("QR code written to %s\n\n%s", resolvedPath, art))
+		}
+		return NewToolResult(art)
+	}
+
+	if outputPath == "" {
+		sum := sha256.Sum256([]byte(content))
+		outputPath = filepath.Join("qrcodes", "qr-"+hex.EncodeToString(sum[:4])+".png")
+	}
+	png, err := code.PNG(qrCodePNGSize)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to render QR code: %v", err))
+	}
+	resolvedPath, err := t.write(outputPath, png)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	return NewToolResult(fmt.Sprintf("QR code PNG written to %s", resolvedPath))
+}
+
+func (t *QRCodeTool) write(path string, data []byte) (string, error) {
+	resolvedPath, err := validatePath(path, t.workspace, t.restrict)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(resolvedPath), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	agentWrites.record(resolvedPath)
+	if err := os.WriteFile(resolvedPath, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	return resolvedPath, nil
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQRCodeTool_PNG(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewQRCodeTool(dir, true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"content":     "https://example.com",
+		"output_path": "share/link.png",
+	})
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.ForLLM)
+	}
+	path := filepath.Join(dir, "share", "link.png")
+	if !strings.Contains(result.ForLLM, path) {
+		t.Fatalf("expected output path in result, got %q", result.ForLLM)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read png: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		t.Fatal("expected a PNG file")
+	}
+}
+
+func TestQRCodeTool_DefaultPNGPath(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewQRCodeTool(dir, true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"content": "hello"})
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.ForLLM)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "qrcodes", "qr-*.png"))
+	if len(matches) != 1 {
+		t.Fatalf("expected one generated png, got %v", matches)
+	}
+}
+
+func TestQRCodeTool_ASCII(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewQRCodeTool(dir, true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"content": "hello",
+		"format":  "ascii",
+	})
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.ForLLM)
+	}
+	if strings.Count(result.ForLLM, "\n") < 10 || !strings.ContainsAny(result.ForLLM, "█▀▄") {
+		t.Fatalf("expected QR text art, got:\n%s", result.ForLLM)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("ascii without output_path should not write files, found %d", len(entries))
+	}
+}
+
+func TestQRCodeTool_RestrictsOutputPath(t *testing.T) {
+	tool := NewQRCodeTool(t.TempDir(), true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"content":     "hello",
+		"output_path": filepath.Join(t.TempDir(), "outside.png"),
+	})
+	if !result.IsError || !strings.Contains(result.ForLLM, "outside the workspace") {
+		t.Fatalf("expected workspace restriction error, got %+v", result)
+	}
+}
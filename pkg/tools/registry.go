@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -9,7 +10,11 @@ import (
 	"time"
 
 	"github.com/dotsetgreg/dotagent/pkg/logger"
+	"github.com/dotsetgreg/dotagent/pkg/observability"
 	"github.com/dotsetgreg/dotagent/pkg/providers"
+	"github.com/dotsetgreg/dotagent/pkg/trace"
+	"github.com/dotsetgreg/dotagent/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type ToolRegistry struct {
@@ -96,32 +101,43 @@ func (r *ToolRegistry) Execute(ctx context.Context, name string, args map[string
 func (r *ToolRegistry) ExecuteWithContext(ctx context.Context, name string, args map[string]interface{}, channel, chatID string, asyncCallback AsyncCallback) *ToolResult {
 	sanitizedArgs := sanitizeToolArgs(args)
 	logger.InfoCF("tool", "Tool execution started",
-		map[string]interface{}{
+		trace.Fields(ctx, map[string]interface{}{
 			"tool": name,
 			"args": sanitizedArgs,
-		})
+		}))
 
 	tool, ok := r.Get(name)
 	if !ok {
 		logger.ErrorCF("tool", "Tool not found",
-			map[string]interface{}{
+			trace.Fields(ctx, map[string]interface{}{
 				"tool": name,
-			})
+			}))
 		return ErrorResult(fmt.Sprintf("tool %q not found", name)).WithError(fmt.Errorf("tool not found"))
 	}
 
-	execCtx := withToolExecutionContext(ctx, channel, chatID, asyncCallback)
+	spanCtx, span := observability.StartSpan(ctx, "tool.execute."+name, attribute.String("tool.name", name))
+	execCtx := withToolExecutionContext(spanCtx, channel, chatID, asyncCallback)
 
 	start := time.Now()
 	var result *ToolResult
+	defer func() {
+		var spanErr error
+		if result != nil && result.IsError {
+			spanErr = result.Err
+			if spanErr == nil {
+				spanErr = errors.New(utils.Truncate(result.ForLLM, 200))
+			}
+		}
+		observability.EndSpan(span, spanErr)
+	}()
 	func() {
 		defer func() {
 			if r := recover(); r != nil {
 				panicErr := fmt.Errorf("tool %q panicked: %v", name, r)
-				logger.ErrorCF("tool", "Tool execution panicked", map[string]interface{}{
+				logger.ErrorCF("tool", "Tool execution panicked", trace.Fields(ctx, map[string]interface{}{
 					"tool":  name,
 					"panic": fmt.Sprint(r),
-				})
+				}))
 				result = ErrorResult(panicErr.Error()).WithError(panicErr)
 			}
 		}()
@@ -131,33 +147,35 @@ func (r *ToolRegistry) ExecuteWithContext(ctx context.Context, name string, args
 	if result == nil {
 		err := fmt.Errorf("tool %q returned nil result", name)
 		logger.ErrorCF("tool", "Tool returned nil result",
-			map[string]interface{}{
+			trace.Fields(ctx, map[string]interface{}{
 				"tool": name,
-			})
-		return ErrorResult(err.Error()).WithError(err)
+			}))
+		result = ErrorResult(err.Error()).WithError(err)
+		return result
 	}
+	result.Duration = duration
 
 	// Log based on result type
 	if result.IsError {
 		logger.ErrorCF("tool", "Tool execution failed",
-			map[string]interface{}{
+			trace.Fields(ctx, map[string]interface{}{
 				"tool":     name,
 				"duration": duration.Milliseconds(),
 				"error":    result.ForLLM,
-			})
+			}))
 	} else if result.Async {
 		logger.InfoCF("tool", "Tool started (async)",
-			map[string]interface{}{
+			trace.Fields(ctx, map[string]interface{}{
 				"tool":     name,
 				"duration": duration.Milliseconds(),
-			})
+			}))
 	} else {
 		logger.InfoCF("tool", "Tool execution completed",
-			map[string]interface{}{
+			trace.Fields(ctx, map[string]interface{}{
 				"tool":          name,
 				"duration_ms":   duration.Milliseconds(),
 				"result_length": len(result.ForLLM),
-			})
+			}))
 	}
 
 	return result
@@ -0,0 +1,214 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+)
+
+type MemoryWriteService interface {
+	StoreMemory(ctx context.Context, sessionKey, userID string, item memory.MemoryItem) (memory.MemoryItem, error)
+	DeleteMemoryByKey(ctx context.Context, userID string, kind memory.MemoryItemKind, key string) error
+}
+
+// memoryScopes are the scopes the model may write to. Global memory is shared
+// by every user, so the model cannot write it on one user's behalf.
+var memoryScopes = []string{
+	string(memory.MemoryScopeSession),
+	string(memory.MemoryScopeUser),
+}
+
+// RememberTool stores a memory immediately when the user explicitly asks the
+// agent to remember something, instead of waiting for background extraction.
+type RememberTool struct {
+	service  MemoryWriteService
+	resolver SessionKeyResolver
+
+	mu      sync.RWMutex
+	channel string
+	chatID  string
+}
+
+func NewRememberTool(service MemoryWriteService, resolver SessionKeyResolver) *RememberTool {
+	return &RememberTool{
+		service:  service,
+		resolver: resolver,
+	}
+}
+
+func (t *RememberTool) Name() string {
+	return "remember"
+}
+
+func (t *RememberTool) Description() string {
+	return "Store a fact in long-term memory right away. Use this only when the user explicitly asks you to remember something critical (e.g., 'remember that my flight is on Friday'). Reusing a key overwrites the previous memory."
+}
+
+func (t *RememberTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"key": map[string]interface{}{
+				"type":        "string",
+				"description": "Short stable identifier for the memory (e.g., 'flight_date'). Use the same key to update it later.",
+			},
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "The fact to remember, written as a full sentence.",
+			},
+			"kind": map[string]interface{}{
+				"type":        "string",
+				"enum":        memorySearchKinds,
+				"description": "Memory kind. Default semantic_fact.",
+			},
+			"scope": map[string]interface{}{
+				"type":        "string",
+				"enum":        memoryScopes,
+				"description": "Where the memory applies: this session or this user across sessions. Defaults by kind.",
+			},
+		},
+		"required": []string{"key", "content"},
+	}
+}
+
+func (t *RememberTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *RememberTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	if t.service == nil {
+		return ErrorResult("memory service is unavailable")
+	}
+	key, _ := args["key"].(string)
+	content, _ := args["content"].(string)
+	key, content = strings.TrimSpace(key), strings.TrimSpace(content)
+	if key == "" || content == "" {
+		return ErrorResult("key and content are required")
+	}
+	kind, err := parseMemoryKindArg(args)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	scope, _ := args["scope"].(string)
+	scope = strings.TrimSpace(scope)
+	if scope != "" && !slices.Contains(memoryScopes, scope) {
+		return ErrorResult(fmt.Sprintf("scope must be one of: %s", strings.Join(memoryScopes, ", ")))
+	}
+
+	userID := actorFromContext(ctx)
+	if userID == "" {
+		return ErrorResult("no user context for remember")
+	}
+	t.mu.RLock()
+	channel, chatID := t.channel, t.chatID
+	t.mu.RUnlock()
+	sessionKey := resolveToolSessionKey(ctx, t.resolver, channel, chatID, userID)
+
+	item, err := t.service.StoreMemory(ctx, sessionKey, userID, memory.MemoryItem{
+		Kind:      kind,
+		Key:       key,
+		Content:   content,
+		ScopeType: memory.MemoryScopeType(scope),
+	})
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("remember failed: %v", err))
+	}
+	return SilentResult(fmt.Sprintf("Remembered %s (%s, %s scope)", item.Key, item.Kind, item.ScopeType))
+}
+
+// ForgetTool removes memories by key, the counterpart to RememberTool.
+type ForgetTool struct {
+	service MemoryWriteService
+}
+
+func NewForgetTool(service MemoryWriteService) *ForgetTool {
+	return &ForgetTool{service: service}
+}
+
+func (t *ForgetTool) Name() string {
+	return "forget"
+}
+
+func (t *ForgetTool) Description() string {
+	return "Delete a long-term memory by key when the user asks you to forget something. Omit kind to remove the key from every memory kind."
+}
+
+func (t *ForgetTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"key": map[string]interface{}{
+				"type":        "string",
+				"description": "Key of the memory to delete.",
+			},
+			"kind": map[string]interface{}{
+				"type":        "string",
+				"enum":        memorySearchKinds,
+				"description": "Optional memory kind to restrict deletion to.",
+			},
+		},
+		"required": []string{"key"},
+	}
+}
+
+func (t *ForgetTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	if t.service == nil {
+		return ErrorResult("memory service is unavailable")
+	}
+	key, _ := args["key"].(string)
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return ErrorResult("key is required")
+	}
+	kind, _ := args["kind"].(string)
+	kind = strings.TrimSpace(kind)
+	if kind != "" && !slices.Contains(memorySearchKinds, kind) {
+		return ErrorResult(fmt.Sprintf("kind must be one of: %s", strings.Join(memorySearchKinds, ", ")))
+	}
+	userID := actorFromContext(ctx)
+	if userID == "" {
+		return ErrorResult("no user context for forget")
+	}
+	if err := t.service.DeleteMemoryByKey(ctx, userID, memory.MemoryItemKind(kind), key); err != nil {
+		return ErrorResult(fmt.Sprintf("forget failed: %v", err))
+	}
+	return SilentResult(fmt.Sprintf("Forgot %s", key))
+}
+
+// parseMemoryKindArg reads the optional "kind" argument, defaulting to
+// semantic_fact.
+func parseMemoryKindArg(args map[string]interface{}) (memory.MemoryItemKind, error) {
+	kind, _ := args["kind"].(string)
+	kind = strings.TrimSpace(kind)
+	if kind == "" {
+		return memory.MemorySemanticFact, nil
+	}
+	if !slices.Contains(memorySearchKinds, kind) {
+		return "", fmt.Errorf("kind must be one of: %s", strings.Join(memorySearchKinds, ", "))
+	}
+	return memory.MemoryItemKind(kind), nil
+}
+
+// resolveToolSessionKey maps the active channel/chat to a session key,
+// preferring the per-call context over the SetContext fallback. It returns ""
+// when no conversation is active.
+func resolveToolSessionKey(ctx context.Context, resolver SessionKeyResolver, channel, chatID, userID string) string {
+	if ctxChannel, ctxChatID := channelChatFromContext(ctx); ctxChannel != "" && ctxChatID != "" {
+		channel, chatID = ctxChannel, ctxChatID
+	}
+	if resolver == nil || channel == "" || chatID == "" {
+		return ""
+	}
+	sessionKey, err := resolver(channel, chatID, userID)
+	if err != nil {
+		return ""
+	}
+	return sessionKey
+}
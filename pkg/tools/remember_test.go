@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+)
+
+type stubMemoryWriteService struct {
+	sessionKey string
+	userID     string
+	stored     memory.MemoryItem
+	deleted    []string
+	kind       memory.MemoryItemKind
+}
+
+func (s *stubMemoryWriteService) StoreMemory(ctx context.Context, sessionKey, userID string, item memory.MemoryItem) (memory.MemoryItem, error) {
+	s.sessionKey, s.userID, s.stored = sessionKey, userID, item
+	if item.ScopeType == "" {
+		item.ScopeType = memory.MemoryScopeUser
+	}
+	return item, nil
+}
+
+func (s *stubMemoryWriteService) DeleteMemoryByKey(ctx context.Context, userID string, kind memory.MemoryItemKind, key string) error {
+	s.userID, s.kind = userID, kind
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+func TestRememberTool_StoresWithSessionAndScope(t *testing.T) {
+	svc := &stubMemoryWriteService{}
+	tool := NewRememberTool(svc, func(channel, chatID, userID string) (string, error) {
+		return channel + ":" + chatID, nil
+	})
+	ctx := WithToolExecutionActor(withToolExecutionContext(context.Background(), "telegram", "42", nil), "user-7")
+
+	res := tool.Execute(ctx, map[string]interface{}{
+		"key":     "anniversary",
+		"content": "Anniversary is on June 3rd.",
+		"scope":   "session",
+	})
+	if res.IsError {
+		t.Fatalf("remember: %s", res.ForLLM)
+	}
+	if svc.sessionKey != "telegram:42" || svc.userID != "user-7" {
+		t.Fatalf("unexpected routing: session=%q user=%q", svc.sessionKey, svc.userID)
+	}
+	if svc.stored.Kind != memory.MemorySemanticFact || svc.stored.ScopeType != memory.MemoryScopeSession || svc.stored.Key != "anniversary" {
+		t.Fatalf("unexpected stored item: %+v", svc.stored)
+	}
+
+	res = tool.Execute(ctx, map[string]interface{}{"key": "x", "content": "y", "scope": "team"})
+	if !res.IsError {
+		t.Fatalf("expected unknown scope to be rejected")
+	}
+	res = tool.Execute(ctx, map[string]interface{}{"key": "x", "content": "y", "scope": "global"})
+	if !res.IsError {
+		t.Fatalf("expected global scope to be rejected")
+	}
+}
+
+func TestForgetTool_DeletesAcrossKindsByDefault(t *testing.T) {
+	svc := &stubMemoryWriteService{}
+	tool := NewForgetTool(svc)
+	ctx := WithToolExecutionActor(context.Background(), "user-7")
+
+	res := tool.Execute(ctx, map[string]interface{}{"key": "anniversary"})
+	if res.IsError {
+		t.Fatalf("forget: %s", res.ForLLM)
+	}
+	if len(svc.deleted) != 1 || svc.deleted[0] != "anniversary" || svc.kind != "" || svc.userID != "user-7" {
+		t.Fatalf("unexpected delete call: %+v", svc)
+	}
+
+	res = tool.Execute(ctx, map[string]interface{}{"key": "anniversary", "kind": "rumor"})
+	if !res.IsError {
+		t.Fatalf("expected unknown kind to be rejected")
+	}
+}
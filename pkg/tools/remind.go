@@ -0,0 +1,290 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/cron"
+	"github.com/dotsetgreg/dotagent/pkg/utils"
+)
+
+// reminderPayloadKind marks cron jobs created by SetReminderTool so they can be
+// listed and cancelled separately from general cron jobs.
+const reminderPayloadKind = "reminder"
+
+// maxReminderDelayMinutes caps reminders at one year out.
+const maxReminderDelayMinutes = 365 * 24 * 60
+
+// reminders is the state shared by the set_reminder, list_reminders and
+// cancel_reminder tools: one-shot cron jobs delivered back to the
+// conversation they were set from.
+type reminders struct {
+	cronService *cron.CronService
+	channel     string
+	chatID      string
+	mu          sync.RWMutex
+	now         func() time.Time
+}
+
+// SetContext sets the current session context for reminder creation
+func (r *reminders) SetContext(channel, chatID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.channel = channel
+	r.chatID = chatID
+}
+
+// conversation resolves the channel and chat the reminder tools act on, or an
+// error result when they cannot run.
+func (r *reminders) conversation(ctx context.Context) (string, string, *ToolResult) {
+	if r.cronService == nil {
+		return "", "", ErrorResult("reminders are unavailable: cron service not configured")
+	}
+	channel, chatID := r.currentContext(ctx)
+	if channel == "" || chatID == "" {
+		return "", "", ErrorResult("no session context (channel/chat_id not set). Use this tool in an active conversation.")
+	}
+	return channel, chatID, nil
+}
+
+// SetReminderTool schedules a one-shot reminder on top of the cron service.
+type SetReminderTool struct {
+	reminders
+}
+
+// NewSetReminderTool creates a new SetReminderTool
+func NewSetReminderTool(cronService *cron.CronService) *SetReminderTool {
+	return &SetReminderTool{reminders{cronService: cronService, now: time.Now}}
+}
+
+func (t *SetReminderTool) Name() string {
+	return "set_reminder"
+}
+
+func (t *SetReminderTool) Description() string {
+	return "Set a one-time reminder for the current conversation. Use it when the user says 'remind me in N minutes/hours' (e.g., 'in 2 hours' → delay_minutes=120). The reminder is sent back to this chat when it fires. Use the cron tool instead for recurring schedules."
+}
+
+func (t *SetReminderTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"message": map[string]interface{}{
+				"type":        "string",
+				"description": "What to remind the user about",
+			},
+			"delay_minutes": map[string]interface{}{
+				"type":        "integer",
+				"description": "Minutes from now when the reminder should fire",
+			},
+		},
+		"required": []string{"message", "delay_minutes"},
+	}
+}
+
+func (t *SetReminderTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	channel, chatID, errResult := t.conversation(ctx)
+	if errResult != nil {
+		return errResult
+	}
+
+	message, _ := args["message"].(string)
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return ErrorResult("message is required")
+	}
+	delay, ok := args["delay_minutes"].(float64)
+	if !ok {
+		return ErrorResult("delay_minutes is required")
+	}
+	delayMinutes := int(delay)
+	if delayMinutes < 1 || delayMinutes > maxReminderDelayMinutes {
+		return ErrorResult(fmt.Sprintf("delay_minutes must be between 1 and %d", maxReminderDelayMinutes))
+	}
+
+	setAt := t.now()
+	fireAt := setAt.Add(time.Duration(delayMinutes) * time.Minute)
+	atMS := fireAt.UnixMilli()
+
+	job, err := t.cronService.AddJob(
+		utils.Truncate(message, 30),
+		cron.CronSchedule{Kind: "at", AtMS: &atMS},
+		formatReminderMessage(message, delayMinutes, setAt),
+		true,
+		channel,
+		chatID,
+	)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("Error setting reminder: %v", err))
+	}
+
+	job.Payload.Kind = reminderPayloadKind
+	job.Payload.Actor = actorFromContext(ctx)
+	if err := t.cronService.UpdateJob(job); err != nil {
+		t.cronService.RemoveJob(job.ID)
+		return ErrorResult(fmt.Sprintf("Error setting reminder: %v", err))
+	}
+
+	return SilentResult(fmt.Sprintf("Reminder set for %s (id: %s)", fireAt.Format(time.RFC3339), job.ID))
+}
+
+// ListRemindersTool lists the pending reminders of the current conversation.
+type ListRemindersTool struct {
+	reminders
+}
+
+// NewListRemindersTool creates a new ListRemindersTool
+func NewListRemindersTool(cronService *cron.CronService) *ListRemindersTool {
+	return &ListRemindersTool{reminders{cronService: cronService, now: time.Now}}
+}
+
+func (t *ListRemindersTool) Name() string {
+	return "list_reminders"
+}
+
+func (t *ListRemindersTool) Description() string {
+	return "List the pending one-time reminders set from the current conversation, with their IDs and due times."
+}
+
+func (t *ListRemindersTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	}
+}
+
+func (t *ListRemindersTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	channel, chatID, errResult := t.conversation(ctx)
+	if errResult != nil {
+		return errResult
+	}
+
+	pending := t.remindersFor(channel, chatID)
+	if len(pending) == 0 {
+		return SilentResult("No pending reminders")
+	}
+
+	var b strings.Builder
+	b.WriteString("Pending reminders:\n")
+	for _, job := range pending {
+		due := "unscheduled"
+		if job.State.NextRunAtMS != nil {
+			due = time.UnixMilli(*job.State.NextRunAtMS).Format(time.RFC3339)
+		}
+		fmt.Fprintf(&b, "- %s (id: %s, due %s)\n", job.Name, job.ID, due)
+	}
+	return SilentResult(b.String())
+}
+
+// CancelReminderTool cancels a pending reminder of the current conversation.
+type CancelReminderTool struct {
+	reminders
+}
+
+// NewCancelReminderTool creates a new CancelReminderTool
+func NewCancelReminderTool(cronService *cron.CronService) *CancelReminderTool {
+	return &CancelReminderTool{reminders{cronService: cronService, now: time.Now}}
+}
+
+func (t *CancelReminderTool) Name() string {
+	return "cancel_reminder"
+}
+
+func (t *CancelReminderTool) Description() string {
+	return "Cancel a pending reminder by the ID list_reminders or set_reminder returned. Only reminders set from the current conversation can be cancelled."
+}
+
+func (t *CancelReminderTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "Reminder ID",
+			},
+		},
+		"required": []string{"id"},
+	}
+}
+
+func (t *CancelReminderTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	channel, chatID, errResult := t.conversation(ctx)
+	if errResult != nil {
+		return errResult
+	}
+
+	id, _ := args["id"].(string)
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return ErrorResult("id is required")
+	}
+
+	// Only reminders set from this conversation may be cancelled here.
+	for _, job := range t.remindersFor(channel, chatID) {
+		if job.ID == id && t.cronService.RemoveJob(id) {
+			return SilentResult(fmt.Sprintf("Reminder cancelled: %s", job.Name))
+		}
+	}
+	return ErrorResult(fmt.Sprintf("Reminder %s not found", id))
+}
+
+func (r *reminders) remindersFor(channel, chatID string) []cron.CronJob {
+	var out []cron.CronJob
+	for _, job := range r.cronService.ListJobs(false) {
+		if job.Payload.Kind != reminderPayloadKind {
+			continue
+		}
+		if job.Payload.Channel != channel || job.Payload.To != chatID {
+			continue
+		}
+		out = append(out, job)
+	}
+	return out
+}
+
+func (r *reminders) currentContext(ctx context.Context) (string, string) {
+	ctxChannel, ctxChatID := channelChatFromContext(ctx)
+
+	r.mu.RLock()
+	channel, chatID := r.channel, r.chatID
+	r.mu.RUnlock()
+
+	if ctxChannel != "" {
+		channel = ctxChannel
+	}
+	if ctxChatID != "" {
+		chatID = ctxChatID
+	}
+
+	return channel, chatID
+}
+
+// formatReminderMessage renders the text delivered when the reminder fires,
+// including when and how far ahead it was requested.
+func formatReminderMessage(message string, delayMinutes int, setAt time.Time) string {
+	return fmt.Sprintf("⏰ Reminder: %s\n\n(You asked me %s ago, at %s, to remind you about this.)",
+		message, formatReminderDelay(delayMinutes), setAt.Format("2006-01-02 15:04 MST"))
+}
+
+func formatReminderDelay(minutes int) string {
+	switch {
+	case minutes < 60:
+		return reminderUnits(minutes, "minute")
+	case minutes%(24*60) == 0:
+		return reminderUnits(minutes/(24*60), "day")
+	case minutes%60 == 0:
+		return reminderUnits(minutes/60, "hour")
+	default:
+		return reminderUnits(minutes/60, "hour") + " " + reminderUnits(minutes%60, "minute")
+	}
+}
+
+func reminderUnits(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
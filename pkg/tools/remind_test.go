@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dotsetgreg/dotagent/pkg/cron"
+)
+
+func newTestSetReminderTool(t *testing.T) (*SetReminderTool, *cron.CronService) {
+	t.Helper()
+	cs, err := cron.NewCronService(t.TempDir()+"/state/jobs.json", nil)
+	if err != nil {
+		t.Fatalf("new cron service: %v", err)
+	}
+	tool := NewSetReminderTool(cs)
+	tool.now = func() time.Time { return time.Now().Truncate(time.Minute) }
+	return tool, cs
+}
+
+func TestSetReminderTool_SchedulesOneShotDelivery(t *testing.T) {
+	tool, cs := newTestSetReminderTool(t)
+	ctx := WithToolExecutionActor(withToolExecutionContext(context.Background(), "discord", "chat-1", nil), "user-42")
+
+	res := tool.Execute(ctx, map[string]interface{}{
+		"message":       "stretch your legs",
+		"delay_minutes": float64(90),
+	})
+	if res == nil || res.IsError {
+		t.Fatalf("expected set_reminder success, got %+v", res)
+	}
+
+	jobs := cs.ListJobs(true)
+	if len(jobs) != 1 {
+		t.Fatalf("expected one job, got %d", len(jobs))
+	}
+	job := jobs[0]
+	if job.Schedule.Kind != "at" || !job.DeleteAfterRun {
+		t.Fatalf("expected one-shot schedule, got %+v (deleteAfterRun=%v)", job.Schedule, job.DeleteAfterRun)
+	}
+	if !job.Payload.Deliver || job.Payload.Channel != "discord" || job.Payload.To != "chat-1" {
+		t.Fatalf("unexpected payload routing: %+v", job.Payload)
+	}
+	if job.Payload.Kind != reminderPayloadKind || job.Payload.Actor != "user-42" {
+		t.Fatalf("unexpected payload metadata: %+v", job.Payload)
+	}
+	for _, want := range []string{"Reminder: stretch your legs", "1 hour 30 minutes ago"} {
+		if !strings.Contains(job.Payload.Message, want) {
+			t.Fatalf("expected delivered message to contain %q, got %q", want, job.Payload.Message)
+		}
+	}
+}
+
+func TestReminderTools_ListAndCancelScopedToConversation(t *testing.T) {
+	tool, cs := newTestSetReminderTool(t)
+	chat1 := withToolExecutionContext(context.Background(), "discord", "chat-1", nil)
+	chat2 := withToolExecutionContext(context.Background(), "discord", "chat-2", nil)
+
+	for _, ctx := range []context.Context{chat1, chat2} {
+		res := tool.Execute(ctx, map[string]interface{}{
+			"message":       "check the oven",
+			"delay_minutes": float64(10),
+		})
+		if res.IsError {
+			t.Fatalf("set_reminder: %s", res.ForLLM)
+		}
+	}
+	atMS := time.Now().Add(time.Hour).UnixMilli()
+	if _, err := cs.AddJob("plain cron", cron.CronSchedule{Kind: "at", AtMS: &atMS}, "hi", true, "discord", "chat-1"); err != nil {
+		t.Fatalf("add cron job: %v", err)
+	}
+
+	list := NewListRemindersTool(cs).Execute(chat1, map[string]interface{}{})
+	if strings.Count(list.ForLLM, "\n- ") != 1 || strings.Contains(list.ForLLM, "plain cron") {
+		t.Fatalf("expected exactly one reminder for chat-1, got %q", list.ForLLM)
+	}
+
+	var chat2ID string
+	for _, job := range tool.remindersFor("discord", "chat-2") {
+		chat2ID = job.ID
+	}
+	cancel := NewCancelReminderTool(cs)
+	res := cancel.Execute(chat1, map[string]interface{}{"id": chat2ID})
+	if !res.IsError {
+		t.Fatalf("expected cancelling another chat's reminder to fail")
+	}
+	res = cancel.Execute(chat2, map[string]interface{}{"id": chat2ID})
+	if res.IsError {
+		t.Fatalf("cancel_reminder: %s", res.ForLLM)
+	}
+	if len(cs.ListJobs(true)) != 2 {
+		t.Fatalf("expected two jobs to remain after cancel")
+	}
+}
+
+func TestSetReminderTool_ValidatesDelay(t *testing.T) {
+	tool, _ := newTestSetReminderTool(t)
+	ctx := withToolExecutionContext(context.Background(), "cli", "direct", nil)
+	res := tool.Execute(ctx, map[string]interface{}{
+		"message":       "too soon",
+		"delay_minutes": float64(0),
+	})
+	if !res.IsError {
+		t.Fatalf("expected zero delay to be rejected")
+	}
+}
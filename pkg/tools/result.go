@@ -1,6 +1,9 @@
 package tools
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // ToolResult represents the structured return value from tool execution.
 // It provides clear semantics for different types of results and supports
@@ -30,6 +33,10 @@ type ToolResult struct {
 	// Err is the underlying error (not JSON serialized).
 	// Used for internal error handling and logging.
 	Err error `json:"-"`
+
+	// Duration is how long Execute took, set by the registry (not JSON
+	// serialized).
+	Duration time.Duration `json:"-"`
 }
 
 // NewToolResult creates a basic ToolResult with content for the LLM.
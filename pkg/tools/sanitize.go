@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Output from an UntrustedContentTool is untrusted: a fetched page or a
+// third-party issue can contain text that imitates the delimiters providers
+// and chat templates use to separate messages. These patterns match the known
+// ones so SanitizeResult can defuse them before the content is handed back to
+// the model.
+var (
+	// XML-style tags used for tool calls, tool results and role blocks.
+	injectionTagPattern = regexp.MustCompile(`(?i)</?\s*(?:tool_result|tool_use|tool_call|tool_response|function_results?|function_calls?|invoke|antml:[a-z_]+|system|system-reminder|assistant|human|im_start|im_end)\b[^<>]{0,200}>`)
+	// Chat-template special tokens such as <|im_start|> or <|eot_id|>.
+	injectionSpecialTokenPattern = regexp.MustCompile(`<\|[a-zA-Z0-9_]{1,40}\|>`)
+	// Bracketed role markers such as [SYSTEM], [INST] and <<SYS>>.
+	injectionBracketPattern = regexp.MustCompile(`(?i)\[/?\s*(?:system|inst|assistant|user|human|developer)\s*\]|<</?SYS>>`)
+	// Transcript-style role prefixes at the start of a line.
+	injectionRolePrefixPattern = regexp.MustCompile(`(?m)^([ \t]*(?:#{1,6}[ \t]*)?)(System|Assistant|Human|Developer)([ \t]*):`)
+)
+
+// SanitizeResult neutralizes text in tool output that looks like a message
+// role delimiter or provider control markup. The text stays readable, but
+// angle brackets in tags are escaped, special tokens lose their pipes,
+// bracketed role markers become parenthesized, and line-leading role prefixes
+// are marked as quoted, so none of them can be mistaken for real structure.
+func SanitizeResult(content string) string {
+	if content == "" {
+		return content
+	}
+	content = injectionSpecialTokenPattern.ReplaceAllStringFunc(content, func(m string) string {
+		return "&lt;" + strings.Trim(m, "<|>") + "&gt;"
+	})
+	content = injectionTagPattern.ReplaceAllStringFunc(content, escapeAngleBrackets)
+	content = injectionBracketPattern.ReplaceAllStringFunc(content, func(m string) string {
+		if strings.HasPrefix(m, "<<") {
+			return escapeAngleBrackets(m)
+		}
+		return "(" + strings.TrimSpace(m[1:len(m)-1]) + ")"
+	})
+	return injectionRolePrefixPattern.ReplaceAllString(content, "${1}${2} (quoted)${3}:")
+}
+
+func escapeAngleBrackets(s string) string {
+	return strings.NewReplacer("<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,253 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	screenshotDir     = "screenshots"
+	screenshotTimeout = 30 * time.Second
+)
+
+var errScreenshotHeadless = errors.New("no graphical display available: DISPLAY and WAYLAND_DISPLAY are unset, so there is no screen to capture")
+
+// ScreenshotTool captures the screen, or a single window, to a PNG under
+// <workspace>/screenshots using the platform's own capture utilities.
+type ScreenshotTool struct {
+	workspace string
+	goos      string
+	now       func() time.Time
+	getenv    func(string) string
+	lookPath  func(string) (string, error)
+	run       func(ctx context.Context, env []string, name string, args ...string) error
+}
+
+func NewScreenshotTool(workspace string) *ScreenshotTool {
+	return &ScreenshotTool{
+		workspace: workspace,
+		goos:      runtime.GOOS,
+		now:       time.Now,
+		getenv:    os.Getenv,
+		lookPath:  exec.LookPath,
+		run:       runScreenshotCommand,
+	}
+}
+
+func (t *ScreenshotTool) Name() string {
+	return "screenshot"
+}
+
+func (t *ScreenshotTool) Description() string {
+	return "Capture the whole screen, or the first window whose title contains window_title, as a PNG saved under screenshots/ in the workspace. Returns the file path."
+}
+
+func (t *ScreenshotTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"window_title": map[string]interface{}{
+				"type":        "string",
+				"description": "Part of the title of the window to capture (default: the full screen)",
+			},
+		},
+	}
+}
+
+func (t *ScreenshotTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	windowTitle, _ := args["window_title"].(string)
+	windowTitle = strings.TrimSpace(windowTitle)
+
+	dir := filepath.Join(t.workspace, screenshotDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to create directory: %v", err))
+	}
+	dest := t.nextPath(dir)
+
+	captureCtx, cancel := context.WithTimeout(ctx, screenshotTimeout)
+	defer cancel()
+	if err := t.capture(captureCtx, windowTitle, dest); err != nil {
+		_ = os.Remove(dest)
+		if captureCtx.Err() == context.DeadlineExceeded {
+			return ErrorResult(fmt.Sprintf("screenshot timed out after %v", screenshotTimeout))
+		}
+		return ErrorResult(fmt.Sprintf("screenshot failed: %v", err))
+	}
+	if info, err := os.Stat(dest); err != nil || info.Size() == 0 {
+		_ = os.Remove(dest)
+		return ErrorResult("screenshot failed: the capture command did not write an image")
+	}
+	return NewToolResult(fmt.Sprintf("Screenshot saved to %s", dest))
+}
+
+// nextPath names the PNG after the current time, adding a counter when
+// several screenshots are taken within the same second.
+func (t *ScreenshotTool) nextPath(dir string) string {
+	stamp := t.now().Format("20060102-150405")
+	path := filepath.Join(dir, stamp+".png")
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d.png", stamp, i))
+	}
+}
+
+func (t *ScreenshotTool) capture(ctx context.Context, windowTitle, dest string) error {
+	switch t.goos {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return t.captureUnix(ctx, windowTitle, dest)
+	case "darwin":
+		return t.captureDarwin(ctx, windowTitle, dest)
+	case "windows":
+		return t.captureWindows(ctx, windowTitle, dest)
+	default:
+		return fmt.Errorf("screenshots are not supported on %s", t.goos)
+	}
+}
+
+// captureUnix uses grim on Wayland and ImageMagick, scrot or
+// gnome-screenshot on X11. Window capture needs xdotool and X11.
+func (t *ScreenshotTool) captureUnix(ctx context.Context, windowTitle, dest string) error {
+	x11 := t.getenv("DISPLAY") != ""
+	wayland := t.getenv("WAYLAND_DISPLAY") != ""
+	if !x11 && !wayland {
+		return errScreenshotHeadless
+	}
+
+	if windowTitle != "" {
+		if !x11 {
+			return errors.New("capturing a single window is not supported on Wayland; omit window_title to capture the full screen")
+		}
+		xdotool, err := t.lookPath("xdotool")
+		if err != nil {
+			return errors.New("capturing a window needs xdotool and ImageMagick's import on PATH")
+		}
+		imp, err := t.lookPath("import")
+		if err != nil {
+			return errors.New("capturing a window needs xdotool and ImageMagick's import on PATH")
+		}
+		id, err := t.findX11Window(ctx, xdotool, windowTitle)
+		if err != nil {
+			return err
+		}
+		return t.run(ctx, nil, imp, "-window", id, dest)
+	}
+
+	if wayland {
+		if grim, err := t.lookPath("grim"); err == nil {
+			return t.run(ctx, nil, grim, dest)
+		}
+	}
+	if x11 {
+		if imp, err := t.lookPath("import"); err == nil {
+			return t.run(ctx, nil, imp, "-window", "root", dest)
+		}
+		if scrot, err := t.lookPath("scrot"); err == nil {
+			return t.run(ctx, nil, scrot, "--overwrite", dest)
+		}
+	}
+	if gnome, err := t.lookPath("gnome-screenshot"); err == nil {
+		return t.run(ctx, nil, gnome, "-f", dest)
+	}
+	return errors.New("no screenshot utility found; install grim (Wayland), or ImageMagick, scrot or gnome-screenshot (X11)")
+}
+
+// findX11Window returns the id of the first window whose title contains
+// windowTitle.
+func (t *ScreenshotTool) findX11Window(ctx context.Context, xdotool, windowTitle string) (string, error) {
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, xdotool, "search", "--onlyvisible", "--name", regexp.QuoteMeta(windowTitle))
+	cmd.Stdout = &stdout
+	_ = cmd.Run() // xdotool exits 1 when nothing matches.
+	id, _, _ := strings.Cut(strings.TrimSpace(stdout.String()), "\n")
+	if id == "" {
+		return "", fmt.Errorf("no visible window title contains %q", windowTitle)
+	}
+	return strings.TrimSpace(id), nil
+}
+
+// darwinWindowBoundsScript prints "x,y,width,height" of the first window
+// whose title contains the script's argument.
+const darwinWindowBoundsScript = `on run argv
+	set wanted to item 1 of argv
+	tell application "System Events"
+		repeat with p in (every process whose background only is false)
+			repeat with w in (every window of p)
+				if name of w contains wanted then
+					set {x, y} to position of w
+					set {wd, ht} to size of w
+					return (x as text) & "," & (y as text) & "," & (wd as text) & "," & (ht as text)
+				end if
+			end repeat
+		end repeat
+	end tell
+	error "no window title contains " & quoted form of wanted
+end run`
+
+func (t *ScreenshotTool) captureDarwin(ctx context.Context, windowTitle, dest string) error {
+	if windowTitle == "" {
+		return t.run(ctx, nil, "screencapture", "-x", dest)
+	}
+	out, err := exec.CommandContext(ctx, "osascript", "-e", darwinWindowBoundsScript, windowTitle).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("locate window: %s", strings.TrimSpace(string(out)))
+	}
+	return t.run(ctx, nil, "screencapture", "-x", "-R", strings.TrimSpace(string(out)), dest)
+}
+
+// windowsScreenshotScript captures the virtual screen, or the main window of
+// the first process whose title matches, through System.Drawing. The title
+// and output path arrive in environment variables to avoid quoting issues.
+const windowsScreenshotScript = `$ErrorActionPreference = 'Stop'
+Add-Type -AssemblyName System.Windows.Forms, System.Drawing
+$title = $env:DOTAGENT_SCREENSHOT_TITLE
+if ($title) {
+	Add-Type -TypeDefinition 'using System; using System.Runtime.InteropServices; public struct DotagentRect { public int Left, Top, Right, Bottom; } public static class DotagentUser32 { [DllImport("user32.dll")] public static extern bool GetWindowRect(IntPtr hWnd, out DotagentRect rect); }'
+	$proc = Get-Process | Where-Object { $_.MainWindowHandle -ne 0 -and $_.MainWindowTitle.Contains($title) } | Select-Object -First 1
+	if (-not $proc) { throw "no window title contains '$title'" }
+	$rect = New-Object DotagentRect
+	[void][DotagentUser32]::GetWindowRect($proc.MainWindowHandle, [ref]$rect)
+	$bounds = [System.Drawing.Rectangle]::FromLTRB($rect.Left, $rect.Top, $rect.Right, $rect.Bottom)
+} else {
+	$bounds = [System.Windows.Forms.SystemInformation]::VirtualScreen
+}
+$bitmap = New-Object System.Drawing.Bitmap $bounds.Width, $bounds.Height
+$graphics = [System.Drawing.Graphics]::FromImage($bitmap)
+$graphics.CopyFromScreen($bounds.Location, [System.Drawing.Point]::Empty, $bounds.Size)
+$bitmap.Save($env:DOTAGENT_SCREENSHOT_PATH, [System.Drawing.Imaging.ImageFormat]::Png)
+$graphics.Dispose()
+$bitmap.Dispose()`
+
+func (t *ScreenshotTool) captureWindows(ctx context.Context, windowTitle, dest string) error {
+	env := []string{
+		"DOTAGENT_SCREENSHOT_TITLE=" + windowTitle,
+		"DOTAGENT_SCREENSHOT_PATH=" + dest,
+	}
+	return t.run(ctx, env, "powershell", "-NoProfile", "-NonInteractive", "-Command", windowsScreenshotScript)
+}
+
+func runScreenshotCommand(ctx context.Context, env []string, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("%s: %s", filepath.Base(name), msg)
+	}
+	return nil
+}
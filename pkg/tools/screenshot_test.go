@@ -0,0 +1,135 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type screenshotCall struct {
+	name string
+	args []string
+	env  []string
+}
+
+func newFakeScreenshotTool(t *testing.T, env map[string]string, installed ...string) (*ScreenshotTool, *[]screenshotCall) {
+	t.Helper()
+	tool := NewScreenshotTool(t.TempDir())
+	tool.goos = "linux"
+	tool.now = func() time.Time { return time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC) }
+	tool.getenv = func(key string) string { return env[key] }
+	tool.lookPath = func(name string) (string, error) {
+		for _, bin := range installed {
+			if bin == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+	var calls []screenshotCall
+	tool.run = func(_ context.Context, env []string, name string, args ...string) error {
+		calls = append(calls, screenshotCall{name: name, args: args, env: env})
+		return os.WriteFile(args[len(args)-1], []byte("\x89PNG"), 0o644)
+	}
+	return tool, &calls
+}
+
+func TestScreenshotTool_CapturesFullScreen(t *testing.T) {
+	tool, calls := newFakeScreenshotTool(t, map[string]string{"DISPLAY": ":0"}, "import", "scrot")
+
+	result := tool.Execute(context.Background(), map[string]interface{}{})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	want := filepath.Join(tool.workspace, "screenshots", "20260304-050607.png")
+	if !strings.Contains(result.ForLLM, want) {
+		t.Fatalf("expected path %s in result, got %q", want, result.ForLLM)
+	}
+	if len(*calls) != 1 || (*calls)[0].name != "/usr/bin/import" || strings.Join((*calls)[0].args, " ") != "-window root "+want {
+		t.Fatalf("unexpected capture command: %+v", *calls)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{})
+	if want := filepath.Join(tool.workspace, "screenshots", "20260304-050607-2.png"); !strings.Contains(result.ForLLM, want) {
+		t.Fatalf("expected a second file %s, got %q", want, result.ForLLM)
+	}
+}
+
+func TestScreenshotTool_PrefersGrimOnWayland(t *testing.T) {
+	tool, calls := newFakeScreenshotTool(t, map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, "grim", "import")
+
+	if result := tool.Execute(context.Background(), map[string]interface{}{}); result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if len(*calls) != 1 || (*calls)[0].name != "/usr/bin/grim" {
+		t.Fatalf("expected grim to be used, got %+v", *calls)
+	}
+
+	tool, _ = newFakeScreenshotTool(t, map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, "grim")
+	result := tool.Execute(context.Background(), map[string]interface{}{"window_title": "Firefox"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "Wayland") {
+		t.Fatalf("expected window capture to be rejected on Wayland, got %+v", result)
+	}
+}
+
+func TestScreenshotTool_Errors(t *testing.T) {
+	tool, calls := newFakeScreenshotTool(t, nil, "import")
+	result := tool.Execute(context.Background(), map[string]interface{}{})
+	if !result.IsError || !strings.Contains(result.ForLLM, "no graphical display") {
+		t.Fatalf("expected headless error, got %+v", result)
+	}
+	if len(*calls) != 0 {
+		t.Fatalf("expected no capture command on a headless system, got %+v", *calls)
+	}
+
+	tool, _ = newFakeScreenshotTool(t, map[string]string{"DISPLAY": ":0"})
+	if result := tool.Execute(context.Background(), map[string]interface{}{}); !result.IsError || !strings.Contains(result.ForLLM, "no screenshot utility") {
+		t.Fatalf("expected missing utility error, got %+v", result)
+	}
+
+	tool, _ = newFakeScreenshotTool(t, map[string]string{"DISPLAY": ":0"}, "import")
+	tool.run = func(context.Context, []string, string, ...string) error {
+		return errors.New("import: unable to open X server")
+	}
+	if result := tool.Execute(context.Background(), map[string]interface{}{}); !result.IsError || !strings.Contains(result.ForLLM, "unable to open X server") {
+		t.Fatalf("expected capture error, got %+v", result)
+	}
+	entries, _ := os.ReadDir(filepath.Join(tool.workspace, "screenshots"))
+	if len(entries) != 0 {
+		t.Fatalf("expected no leftover files after a failed capture, got %d", len(entries))
+	}
+
+	tool.goos = "plan9"
+	if result := tool.Execute(context.Background(), map[string]interface{}{}); !result.IsError || !strings.Contains(result.ForLLM, "not supported on plan9") {
+		t.Fatalf("expected unsupported platform error, got %+v", result)
+	}
+}
+
+func TestScreenshotTool_WindowsPassesTitleThroughEnv(t *testing.T) {
+	tool, calls := newFakeScreenshotTool(t, nil)
+	tool.goos = "windows"
+	tool.run = func(_ context.Context, env []string, name string, args ...string) error {
+		*calls = append(*calls, screenshotCall{name: name, args: args, env: env})
+		for _, kv := range env {
+			if path, ok := strings.CutPrefix(kv, "DOTAGENT_SCREENSHOT_PATH="); ok {
+				return os.WriteFile(path, []byte("\x89PNG"), 0o644)
+			}
+		}
+		return errors.New("no output path")
+	}
+
+	if result := tool.Execute(context.Background(), map[string]interface{}{"window_title": `Notepad "draft"`}); result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if len(*calls) != 1 || (*calls)[0].name != "powershell" {
+		t.Fatalf("expected one powershell call, got %+v", *calls)
+	}
+	if got := (*calls)[0].env[0]; got != `DOTAGENT_SCREENSHOT_TITLE=Notepad "draft"` {
+		t.Fatalf("unexpected title env: %q", got)
+	}
+}
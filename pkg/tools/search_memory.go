@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+)
+
+type MemorySearchService interface {
+	SearchMemory(ctx context.Context, sessionKey, userID, query string, kind memory.MemoryItemKind, limit int) ([]memory.MemoryCard, error)
+}
+
+// memorySearchResult is the JSON shape returned to the model for each match.
+type memorySearchResult struct {
+	Key        string  `json:"key"`
+	Kind       string  `json:"kind"`
+	Content    string  `json:"content"`
+	Confidence float64 `json:"confidence"`
+	Scope      string  `json:"scope"`
+}
+
+var memorySearchKinds = []string{
+	string(memory.MemorySemanticFact),
+	string(memory.MemoryUserPreference),
+	string(memory.MemoryEpisodic),
+	string(memory.MemoryTaskState),
+	string(memory.MemoryProcedural),
+}
+
+// MemorySearchTool lets the model query long-term memory on demand instead of
+// relying only on the recall block injected into the prompt.
+type MemorySearchTool struct {
+	service  MemorySearchService
+	resolver SessionKeyResolver
+
+	mu      sync.RWMutex
+	channel string
+	chatID  string
+}
+
+func NewMemorySearchTool(service MemorySearchService, resolver SessionKeyResolver) *MemorySearchTool {
+	return &MemorySearchTool{
+		service:  service,
+		resolver: resolver,
+	}
+}
+
+func (t *MemorySearchTool) Name() string {
+	return "memory_search"
+}
+
+func (t *MemorySearchTool) Description() string {
+	return "Search long-term memory about the current user for facts, preferences, past episodes, tasks, or procedures. Use this when you need a specific detail that is not already in the recalled memory context. Returns a JSON array of {key, kind, content, confidence, scope}."
+}
+
+func (t *MemorySearchTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "What to look for, in natural language or keywords.",
+			},
+			"kind": map[string]interface{}{
+				"type":        "string",
+				"enum":        memorySearchKinds,
+				"description": "Optional memory kind to restrict results to.",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum results. Default 8.",
+				"minimum":     1.0,
+				"maximum":     50.0,
+			},
+		},
+		"required": []string{"query"},
+	}
+}
+
+func (t *MemorySearchTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *MemorySearchTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	if t.service == nil {
+		return ErrorResult("memory service is unavailable")
+	}
+	query, _ := args["query"].(string)
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return ErrorResult("query is required")
+	}
+	kind, _ := args["kind"].(string)
+	kind = strings.TrimSpace(kind)
+	if kind != "" && !slices.Contains(memorySearchKinds, kind) {
+		return ErrorResult(fmt.Sprintf("kind must be one of: %s", strings.Join(memorySearchKinds, ", ")))
+	}
+	limit := parseLimit(args["limit"], 8)
+
+	userID := actorFromContext(ctx)
+	if userID == "" {
+		return ErrorResult("no user context for memory search")
+	}
+	t.mu.RLock()
+	channel, chatID := t.channel, t.chatID
+	t.mu.RUnlock()
+	sessionKey := resolveToolSessionKey(ctx, t.resolver, channel, chatID, userID)
+
+	cards, err := t.service.SearchMemory(ctx, sessionKey, userID, query, memory.MemoryItemKind(kind), limit)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("memory search failed: %v", err))
+	}
+	results := make([]memorySearchResult, 0, len(cards))
+	for _, card := range cards {
+		results = append(results, memorySearchResult{
+			Key:        card.Key,
+			Kind:       string(card.Kind),
+			Content:    card.Content,
+			Confidence: card.Confidence,
+			Scope:      string(card.Scope),
+		})
+	}
+	raw, err := json.Marshal(results)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("encode memory search results: %v", err))
+	}
+	return SilentResult(string(raw))
+}
@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dotsetgreg/dotagent/pkg/memory"
+)
+
+type stubMemorySearchService struct {
+	sessionKey string
+	userID     string
+	kind       memory.MemoryItemKind
+	limit      int
+}
+
+func (s *stubMemorySearchService) SearchMemory(ctx context.Context, sessionKey, userID, query string, kind memory.MemoryItemKind, limit int) ([]memory.MemoryCard, error) {
+	s.sessionKey, s.userID, s.kind, s.limit = sessionKey, userID, kind, limit
+	return []memory.MemoryCard{{
+		Key:        "drink",
+		Kind:       memory.MemoryUserPreference,
+		Scope:      memory.MemoryScopeUser,
+		Content:    "prefers green tea",
+		Confidence: 0.9,
+	}}, nil
+}
+
+func TestMemorySearchTool_ReturnsJSONResults(t *testing.T) {
+	svc := &stubMemorySearchService{}
+	tool := NewMemorySearchTool(svc, func(channel, chatID, userID string) (string, error) {
+		return channel + ":" + chatID, nil
+	})
+	ctx := WithToolExecutionActor(withToolExecutionContext(context.Background(), "discord", "chat-1", nil), "user-42")
+
+	res := tool.Execute(ctx, map[string]interface{}{
+		"query": "what does the user drink",
+		"kind":  "user_preference",
+		"limit": float64(3),
+	})
+	if res.IsError {
+		t.Fatalf("memory_search: %s", res.ForLLM)
+	}
+	if svc.sessionKey != "discord:chat-1" || svc.userID != "user-42" || svc.kind != memory.MemoryUserPreference || svc.limit != 3 {
+		t.Fatalf("unexpected search args: %+v", svc)
+	}
+	var results []map[string]interface{}
+	if err := json.Unmarshal([]byte(res.ForLLM), &results); err != nil {
+		t.Fatalf("decode results: %v (%s)", err, res.ForLLM)
+	}
+	if len(results) != 1 || results[0]["key"] != "drink" || results[0]["scope"] != "user" || results[0]["confidence"] != 0.9 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+}
+
+func TestMemorySearchTool_RejectsUnknownKind(t *testing.T) {
+	tool := NewMemorySearchTool(&stubMemorySearchService{}, nil)
+	ctx := WithToolExecutionActor(context.Background(), "user-42")
+	res := tool.Execute(ctx, map[string]interface{}{"query": "tea", "kind": "gossip"})
+	if !res.IsError {
+		t.Fatalf("expected unknown kind to be rejected")
+	}
+}
@@ -0,0 +1,229 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+type SSHToolOptions struct {
+	KeyPath        string
+	KnownHostsPath string
+	AllowedHosts   []string
+	TimeoutSeconds int
+}
+
+// SSHTool runs a single command on an allowlisted remote host. The private key
+// and known_hosts file come from config and are read per call, so neither is
+// ever part of the conversation.
+type SSHTool struct {
+	keyPath        string
+	knownHostsPath string
+	allowed        map[string]bool
+	timeout        time.Duration
+}
+
+func NewSSHTool(opts SSHToolOptions) *SSHTool {
+	timeout := 60 * time.Second
+	if opts.TimeoutSeconds > 0 {
+		timeout = time.Duration(opts.TimeoutSeconds) * time.Second
+	}
+	allowed := map[string]bool{}
+	for _, host := range opts.AllowedHosts {
+		if addr := normalizeSSHAddress(host); addr != "" {
+			allowed[addr] = true
+		}
+	}
+	return &SSHTool{
+		keyPath:        expandHomePath(opts.KeyPath),
+		knownHostsPath: expandHomePath(opts.KnownHostsPath),
+		allowed:        allowed,
+		timeout:        timeout,
+	}
+}
+
+func (t *SSHTool) Name() string {
+	return "ssh_exec"
+}
+
+func (t *SSHTool) Description() string {
+	return "Run a command on an allowlisted remote host over SSH and return its stdout, stderr, and exit code. Authentication uses the configured key; only hosts in tools.ssh.allowed_hosts are reachable."
+}
+
+func (t *SSHTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"host": map[string]interface{}{
+				"type":        "string",
+				"description": "Remote host, optionally with :port (default 22). Must be in the allowlist.",
+			},
+			"user": map[string]interface{}{
+				"type":        "string",
+				"description": "Remote user to log in as",
+			},
+			"command": map[string]interface{}{
+				"type":        "string",
+				"description": "Command to run in the remote user's shell",
+			},
+		},
+		"required": []string{"host", "user", "command"},
+	}
+}
+
+func (t *SSHTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	host, _ := args["host"].(string)
+	user, _ := args["user"].(string)
+	command, _ := args["command"].(string)
+	host, user = strings.TrimSpace(host), strings.TrimSpace(user)
+	if host == "" || user == "" || strings.TrimSpace(command) == "" {
+		return ErrorResult("host, user, and command are required")
+	}
+	addr := normalizeSSHAddress(host)
+	if !t.allowed[addr] {
+		return ErrorResult(fmt.Sprintf("host %q is not in tools.ssh.allowed_hosts (allowed: %s)", host, strings.Join(t.allowedHosts(), ", ")))
+	}
+
+	clientConfig, err := t.clientConfig(user)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	stdout, stderr, exitCode, err := runSSHCommand(runCtx, addr, clientConfig, command)
+	if runCtx.Err() == context.DeadlineExceeded {
+		msg := fmt.Sprintf("SSH command on %s timed out after %v", host, t.timeout)
+		return &ToolResult{ForLLM: msg, ForUser: msg, IsError: true}
+	}
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("ssh %s@%s failed: %v", user, host, err))
+	}
+
+	output := formatCodeRunOutput(stdout, stderr) + fmt.Sprintf("\nExit code: %d", exitCode)
+	maxLen := 10000
+	if len(output) > maxLen {
+		output = output[:maxLen] + fmt.Sprintf("\n... (truncated, %d more chars)", len(output)-maxLen)
+	}
+	return &ToolResult{
+		ForLLM:  output,
+		ForUser: output,
+		IsError: exitCode != 0,
+	}
+}
+
+func (t *SSHTool) clientConfig(user string) (*ssh.ClientConfig, error) {
+	if t.keyPath == "" {
+		return nil, fmt.Errorf("tools.ssh.key_path is not configured")
+	}
+	// Errors below name the file but never echo its contents.
+	keyData, err := os.ReadFile(t.keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH key %s: %v", t.keyPath, err)
+	}
+	signer, err := ssh.ParsePrivateKey(keyData)
+	if err != nil {
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return nil, fmt.Errorf("SSH key %s is passphrase-protected; configure an unencrypted deploy key", t.keyPath)
+		}
+		return nil, fmt.Errorf("failed to parse SSH key %s", t.keyPath)
+	}
+	if t.knownHostsPath == "" {
+		return nil, fmt.Errorf("tools.ssh.known_hosts_path is not configured")
+	}
+	hostKeyCallback, err := knownhosts.New(t.knownHostsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known hosts %s: %v", t.knownHostsPath, err)
+	}
+	return &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+	}, nil
+}
+
+func (t *SSHTool) allowedHosts() []string {
+	out := make([]string, 0, len(t.allowed))
+	for host := range t.allowed {
+		out = append(out, host)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// runSSHCommand dials addr, runs command in a new session, and returns its
+// output and exit status. Cancelling ctx closes the connection, which aborts
+// a running command.
+func runSSHCommand(ctx context.Context, addr string, cfg *ssh.ClientConfig, command string) (string, string, int, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return "", "", 0, err
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, addr, cfg)
+	if err != nil {
+		_ = conn.Close()
+		return "", "", 0, err
+	}
+	client := ssh.NewClient(clientConn, chans, reqs)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return "", "", 0, err
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	err = session.Run(command)
+
+	var exitErr *ssh.ExitError
+	switch {
+	case err == nil:
+		return stdout.String(), stderr.String(), 0, nil
+	case errors.As(err, &exitErr):
+		return stdout.String(), stderr.String(), exitErr.ExitStatus(), nil
+	default:
+		return stdout.String(), stderr.String(), 0, err
+	}
+}
+
+// normalizeSSHAddress lowercases the host and adds the default port so
+// "web1" and "web1:22" match the same allowlist entry.
+func normalizeSSHAddress(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "" {
+		return ""
+	}
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(strings.Trim(host, "[]"), "22")
+}
+
+func expandHomePath(path string) string {
+	path = strings.TrimSpace(path)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
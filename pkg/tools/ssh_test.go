@@ -0,0 +1,200 @@
+package tools
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// startTestSSHServer accepts public-key logins for clientKey and answers each
+// exec request with fixed output. It returns the listener address.
+func startTestSSHServer(t *testing.T, hostKey ssh.Signer, clientKey ssh.PublicKey) string {
+	t.Helper()
+	cfg := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "deploy" && string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, ssh.ErrNoAuth
+		},
+	}
+	cfg.AddHostKey(hostKey)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			nConn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestSSHConn(nConn, cfg)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveTestSSHConn(nConn net.Conn, cfg *ssh.ServerConfig) {
+	defer nConn.Close()
+	_, chans, reqs, err := ssh.NewServerConn(nConn, cfg)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChan := range chans {
+		ch, requests, err := newChan.Accept()
+		if err != nil {
+			return
+		}
+		for req := range requests {
+			if req.Type != "exec" {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			_ = req.Reply(true, nil)
+			command := string(req.Payload[4:])
+			status := uint32(0)
+			if command == "false" {
+				_, _ = ch.Stderr().Write([]byte("command failed\n"))
+				status = 1
+			} else {
+				_, _ = ch.Write([]byte("ran: " + command + "\n"))
+			}
+			payload := make([]byte, 4)
+			binary.BigEndian.PutUint32(payload, status)
+			_, _ = ch.SendRequest("exit-status", false, payload)
+			_ = ch.Close()
+			break
+		}
+	}
+}
+
+func newTestSSHTool(t *testing.T) (*SSHTool, string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("host key: %v", err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatalf("host signer: %v", err)
+	}
+	_, clientPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("client key: %v", err)
+	}
+	clientSigner, err := ssh.NewSignerFromKey(clientPriv)
+	if err != nil {
+		t.Fatalf("client signer: %v", err)
+	}
+
+	addr := startTestSSHServer(t, hostSigner, clientSigner.PublicKey())
+
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatalf("marshal client key: %v", err)
+	}
+	keyPath := filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	knownHostsPath := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostSigner.PublicKey())
+	if err := os.WriteFile(knownHostsPath, []byte(line+"\n"), 0o600); err != nil {
+		t.Fatalf("write known_hosts: %v", err)
+	}
+
+	return NewSSHTool(SSHToolOptions{
+		KeyPath:        keyPath,
+		KnownHostsPath: knownHostsPath,
+		AllowedHosts:   []string{addr},
+		TimeoutSeconds: 5,
+	}), addr
+}
+
+func TestSSHTool_ExecReturnsOutputAndExitCode(t *testing.T) {
+	tool, addr := newTestSSHTool(t)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"host":    addr,
+		"user":    "deploy",
+		"command": "uptime",
+	})
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "STDOUT:\nran: uptime") || !strings.HasSuffix(result.ForLLM, "Exit code: 0") {
+		t.Fatalf("unexpected output: %q", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{
+		"host":    addr,
+		"user":    "deploy",
+		"command": "false",
+	})
+	if !result.IsError || !strings.Contains(result.ForLLM, "STDERR:\ncommand failed") || !strings.HasSuffix(result.ForLLM, "Exit code: 1") {
+		t.Fatalf("expected failing command output, got: %q", result.ForLLM)
+	}
+}
+
+func TestSSHTool_RejectsUnlistedHostsAndBadAuth(t *testing.T) {
+	tool, addr := newTestSSHTool(t)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"host":    "evil.example.com",
+		"user":    "deploy",
+		"command": "uptime",
+	})
+	if !result.IsError || !strings.Contains(result.ForLLM, "not in tools.ssh.allowed_hosts") {
+		t.Fatalf("expected allowlist error, got: %q", result.ForLLM)
+	}
+
+	result = tool.Execute(context.Background(), map[string]interface{}{
+		"host":    addr,
+		"user":    "root",
+		"command": "uptime",
+	})
+	if !result.IsError || !strings.Contains(result.ForLLM, "ssh root@") {
+		t.Fatalf("expected authentication failure, got: %q", result.ForLLM)
+	}
+
+	keyData, err := os.ReadFile(tool.keyPath)
+	if err != nil {
+		t.Fatalf("read key: %v", err)
+	}
+	if strings.Contains(result.ForLLM, string(keyData)) {
+		t.Fatalf("error output must not include key material")
+	}
+}
+
+func TestNormalizeSSHAddress(t *testing.T) {
+	tests := map[string]string{
+		"Web1":       "web1:22",
+		"web1:2222":  "web1:2222",
+		"10.0.0.5":   "10.0.0.5:22",
+		"[::1]:2200": "[::1]:2200",
+		"::1":        "[::1]:22",
+		"  ":         "",
+	}
+	for in, want := range tests {
+		if got := normalizeSSHAddress(in); got != want {
+			t.Fatalf("normalizeSSHAddress(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
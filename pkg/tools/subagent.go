@@ -23,6 +23,7 @@ type SubagentTask struct {
 	Label              string `json:"label"`
 	OriginChannel      string `json:"origin_channel"`
 	OriginChatID       string `json:"origin_chat_id"`
+	Depth              int    `json:"depth,omitempty"`
 	Status             string `json:"status"`
 	Result             string `json:"result"`
 	Created            int64  `json:"created"`
@@ -47,6 +48,7 @@ type SubagentManager struct {
 	contextPruningMode     string
 	contextPruningKeepLast int
 	maxOverflowCompactions int
+	maxDepth               int
 	retry                  providers.RetryConfig
 	loopDetection          ToolLoopDetectionConfig
 	nextID                 int
@@ -67,15 +69,39 @@ type SubagentLoopRuntimeOptions struct {
 	ContextPruningMode     string
 	ContextPruningKeepLast int
 	MaxOverflowCompactions int
-	Retry                  providers.RetryConfig
-	LoopDetection          ToolLoopDetectionConfig
+	// MaxDepth bounds how deeply subagents may nest; 0 keeps the default.
+	MaxDepth      int
+	Retry         providers.RetryConfig
+	LoopDetection ToolLoopDetectionConfig
 }
 
 const (
-	subagentStateVersion = 1
-	subagentStateFile    = "subagent_tasks.json"
+	subagentStateVersion    = 1
+	subagentStateFile       = "subagent_tasks.json"
+	defaultMaxSubagentDepth = 3
 )
 
+type subagentDepthKey struct{}
+
+// WithSubagentDepth records that ctx runs inside a subagent nested depth
+// levels below the main agent.
+func WithSubagentDepth(ctx context.Context, depth int) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, subagentDepthKey{}, depth)
+}
+
+// SubagentDepth reports how many subagent levels ctx is nested in; 0 for the
+// main agent.
+func SubagentDepth(ctx context.Context) int {
+	if ctx == nil {
+		return 0
+	}
+	depth, _ := ctx.Value(subagentDepthKey{}).(int)
+	return depth
+}
+
 func NewSubagentManager(provider providers.LLMProvider, defaultModel, workspace string, stateRoot string, bus *bus.MessageBus) *SubagentManager {
 	if strings.TrimSpace(stateRoot) == "" {
 		stateRoot = workspace
@@ -94,6 +120,7 @@ func NewSubagentManager(provider providers.LLMProvider, defaultModel, workspace
 		contextPruningMode:     "off",
 		contextPruningKeepLast: 5,
 		maxOverflowCompactions: 2,
+		maxDepth:               defaultMaxSubagentDepth,
 		retry:                  providers.DefaultRetryConfig(),
 		nextID:                 1,
 		statePath:              filepath.Join(stateRoot, "state", subagentStateFile),
@@ -122,6 +149,9 @@ func (sm *SubagentManager) ConfigureLoopRuntime(opts SubagentLoopRuntimeOptions)
 	if opts.MaxOverflowCompactions > 0 {
 		sm.maxOverflowCompactions = opts.MaxOverflowCompactions
 	}
+	if opts.MaxDepth > 0 {
+		sm.maxDepth = opts.MaxDepth
+	}
 	if opts.Retry.MaxAttempts > 0 {
 		sm.retry = opts.Retry
 	}
@@ -177,6 +207,10 @@ func (sm *SubagentManager) pruneCompleted() {
 }
 
 func (sm *SubagentManager) Spawn(ctx context.Context, task, label, originChannel, originChatID string, callback AsyncCallback) (string, error) {
+	ctx, err := sm.enterSubagent(ctx)
+	if err != nil {
+		return "", err
+	}
 	sm.mu.Lock()
 	sm.pruneCompleted()
 
@@ -190,6 +224,7 @@ func (sm *SubagentManager) Spawn(ctx context.Context, task, label, originChannel
 		Label:              label,
 		OriginChannel:      originChannel,
 		OriginChatID:       originChatID,
+		Depth:              SubagentDepth(ctx),
 		Status:             "running",
 		Created:            now,
 		Updated:            now,
@@ -393,10 +428,19 @@ func (sm *SubagentManager) recoverPendingState() {
 	notifyIDs := append([]string(nil), sm.pendingNotifyIDs...)
 	sm.pendingResumeIDs = nil
 	sm.pendingNotifyIDs = nil
+	resumeDepths := make(map[string]int, len(resumeIDs))
+	for _, taskID := range resumeIDs {
+		// Tasks persisted before depth was recorded were spawned at depth 1
+		// or deeper.
+		resumeDepths[taskID] = 1
+		if task := sm.tasks[taskID]; task != nil && task.Depth > 1 {
+			resumeDepths[taskID] = task.Depth
+		}
+	}
 	sm.mu.Unlock()
 
 	for _, taskID := range resumeIDs {
-		go sm.runTask(context.Background(), taskID, nil)
+		go sm.runTask(WithSubagentDepth(context.Background(), resumeDepths[taskID]), taskID, nil)
 	}
 	for _, taskID := range notifyIDs {
 		go sm.retryPendingAnnouncement(taskID)
@@ -622,6 +666,97 @@ func (sm *SubagentManager) addPendingNotifyLocked(taskID string) {
 	sm.pendingNotifyIDs = append(sm.pendingNotifyIDs, taskID)
 }
 
+// SubagentRequest describes a synchronous subagent run. Model overrides the
+// manager's default model and Instructions are appended to the subagent
+// system prompt; both are optional.
+type SubagentRequest struct {
+	Task         string
+	Model        string
+	Instructions string
+}
+
+// Execute runs a subagent to completion with the manager's tools and runtime
+// settings and returns the loop result. Unlike Spawn, the task is not
+// persisted or announced on the bus.
+func (sm *SubagentManager) Execute(ctx context.Context, req SubagentRequest, originChannel, originChatID string) (*ToolLoopResult, error) {
+	ctx, err := sm.enterSubagent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sm.mu.RLock()
+	workspaceContext := sm.workspaceContext
+	tools := sm.tools
+	model := sm.defaultModel
+	maxIter := sm.maxIterations
+	contextWindow := sm.contextWindow
+	contextPruningMode := sm.contextPruningMode
+	contextPruningKeepLast := sm.contextPruningKeepLast
+	maxOverflowCompactions := sm.maxOverflowCompactions
+	retryCfg := sm.retry
+	loopDetection := sm.loopDetection
+	sm.mu.RUnlock()
+	if strings.TrimSpace(req.Model) != "" {
+		model = strings.TrimSpace(req.Model)
+	}
+
+	systemPrompt := buildSubagentSystemPrompt(workspaceContext)
+	if instructions := strings.TrimSpace(req.Instructions); instructions != "" {
+		systemPrompt += "\n\n" + instructions
+	}
+	messages := []providers.Message{
+		{
+			Role:    "system",
+			Content: systemPrompt,
+		},
+		{
+			Role:    "user",
+			Content: req.Task,
+		},
+	}
+	initialMessages := cloneSubagentMessages(messages)
+
+	return RunToolLoop(ctx, ToolLoopConfig{
+		Provider:               sm.provider,
+		Model:                  model,
+		Tools:                  tools,
+		MaxIterations:          maxIter,
+		ContextWindowTokens:    contextWindow,
+		ContextPruningMode:     contextPruningMode,
+		ContextPruningKeepLast: contextPruningKeepLast,
+		MaxOverflowCompactions: maxOverflowCompactions,
+		Retry:                  retryCfg,
+		LoopDetection:          loopDetection,
+		LLMOptions: map[string]any{
+			"max_tokens":  4096,
+			"temperature": 0.7,
+		},
+		RebuildContext: func(ctx context.Context) ([]providers.Message, error) {
+			return cloneSubagentMessages(initialMessages), nil
+		},
+	}, messages, originChannel, originChatID)
+}
+
+// enterSubagent returns ctx one subagent level deeper, or an error when that
+// would exceed the configured maximum depth.
+func (sm *SubagentManager) enterSubagent(ctx context.Context) (context.Context, error) {
+	sm.mu.RLock()
+	maxDepth := sm.maxDepth
+	sm.mu.RUnlock()
+	depth := SubagentDepth(ctx) + 1
+	if depth > maxDepth {
+		logger.WarnCF("subagent", "Subagent depth limit reached", map[string]interface{}{
+			"depth":     depth,
+			"max_depth": maxDepth,
+		})
+		return ctx, fmt.Errorf("subagent depth %d exceeds agents.defaults.max_subagent_depth (%d)", depth, maxDepth)
+	}
+	logger.DebugCF("subagent", "Starting subagent", map[string]interface{}{
+		"depth":     depth,
+		"max_depth": maxDepth,
+	})
+	return WithSubagentDepth(ctx, depth), nil
+}
+
 // SubagentTool executes a subagent task synchronously and returns the result.
 // Unlike SpawnTool which runs tasks asynchronously, SubagentTool waits for completion
 // and returns the result directly in the ToolResult.
@@ -694,55 +829,7 @@ func (t *SubagentTool) Execute(ctx context.Context, args map[string]interface{})
 	}
 	t.mu.RUnlock()
 
-	// Build messages for subagent
-	t.manager.mu.RLock()
-	workspaceContext := t.manager.workspaceContext
-	t.manager.mu.RUnlock()
-	messages := []providers.Message{
-		{
-			Role:    "system",
-			Content: buildSubagentSystemPrompt(workspaceContext),
-		},
-		{
-			Role:    "user",
-			Content: task,
-		},
-	}
-
-	// Use RunToolLoop to execute with tools (same as async SpawnTool)
-	sm := t.manager
-	sm.mu.RLock()
-	tools := sm.tools
-	maxIter := sm.maxIterations
-	contextWindow := sm.contextWindow
-	contextPruningMode := sm.contextPruningMode
-	contextPruningKeepLast := sm.contextPruningKeepLast
-	maxOverflowCompactions := sm.maxOverflowCompactions
-	retryCfg := sm.retry
-	loopDetection := sm.loopDetection
-	sm.mu.RUnlock()
-	initialMessages := cloneSubagentMessages(messages)
-
-	loopResult, err := RunToolLoop(ctx, ToolLoopConfig{
-		Provider:               sm.provider,
-		Model:                  sm.defaultModel,
-		Tools:                  tools,
-		MaxIterations:          maxIter,
-		ContextWindowTokens:    contextWindow,
-		ContextPruningMode:     contextPruningMode,
-		ContextPruningKeepLast: contextPruningKeepLast,
-		MaxOverflowCompactions: maxOverflowCompactions,
-		Retry:                  retryCfg,
-		LoopDetection:          loopDetection,
-		LLMOptions: map[string]any{
-			"max_tokens":  4096,
-			"temperature": 0.7,
-		},
-		RebuildContext: func(ctx context.Context) ([]providers.Message, error) {
-			return cloneSubagentMessages(initialMessages), nil
-		},
-	}, messages, originChannel, originChatID)
-
+	loopResult, err := t.manager.Execute(ctx, SubagentRequest{Task: task}, originChannel, originChatID)
 	if err != nil {
 		return ErrorResult(fmt.Sprintf("Subagent execution failed: %v", err)).WithError(err)
 	}
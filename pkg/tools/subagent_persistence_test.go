@@ -92,6 +92,46 @@ func TestSubagentManager_ResumesRunningTaskFromStateFile(t *testing.T) {
 	}
 }
 
+func TestSubagentManager_ResumedTaskKeepsDepth(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Now().Add(-30 * time.Second).UnixMilli()
+	state := persistedSubagentState{
+		Version: subagentStateVersion,
+		NextID:  2,
+		Tasks: []*SubagentTask{{
+			ID:      "subagent-1",
+			Task:    "delegate further",
+			Depth:   defaultMaxSubagentDepth,
+			Status:  "running",
+			Created: now,
+			Updated: now,
+		}},
+	}
+	if err := writeJSONFileAtomic(filepath.Join(tmpDir, "state", subagentStateFile), state); err != nil {
+		t.Fatalf("write state file: %v", err)
+	}
+
+	provider := &scriptedToolProvider{responses: []*providers.LLMResponse{
+		{ToolCalls: []providers.ToolCall{{ID: "call-1", Name: "subagent", Arguments: map[string]interface{}{"task": "nested"}}}},
+		{Content: "outer-done"},
+		{Content: "nested-done"},
+	}}
+	manager := NewSubagentManager(provider, "test-model", tmpDir, tmpDir, bus.NewMessageBus())
+	registry := NewToolRegistry()
+	if err := registry.Register(NewSubagentTool(manager)); err != nil {
+		t.Fatalf("register subagent tool: %v", err)
+	}
+	manager.SetTools(registry)
+
+	task, ok := waitForSubagentTaskStatus(manager, "subagent-1", 3*time.Second, "completed", "failed")
+	if !ok {
+		t.Fatalf("timed out waiting for resumed task")
+	}
+	if !strings.Contains(task.Result, "outer-done") || provider.idx != 2 {
+		t.Fatalf("expected the resumed task at max depth to be refused a nested subagent, got result %q after %d provider calls", task.Result, provider.idx)
+	}
+}
+
 func TestSubagentManager_RetriesPendingCompletionNotificationOnStartup(t *testing.T) {
 	tmpDir := t.TempDir()
 	now := time.Now().Add(-10 * time.Second).UnixMilli()
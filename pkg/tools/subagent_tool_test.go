@@ -313,3 +313,39 @@ func TestSubagentTool_ForUserTruncation(t *testing.T) {
 		t.Error("ForLLM should contain reference to original task")
 	}
 }
+
+type depthRecordingProvider struct {
+	MockLLMProvider
+	depths []int
+}
+
+func (p *depthRecordingProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, options map[string]interface{}) (*providers.LLMResponse, error) {
+	p.depths = append(p.depths, SubagentDepth(ctx))
+	return p.MockLLMProvider.Chat(ctx, messages, tools, model, options)
+}
+
+// TestSubagentManager_MaxDepth verifies nested subagents stop at the configured depth
+func TestSubagentManager_MaxDepth(t *testing.T) {
+	provider := &depthRecordingProvider{}
+	dir := t.TempDir()
+	manager := NewSubagentManager(provider, "test-model", dir, dir, nil)
+	manager.ConfigureLoopRuntime(SubagentLoopRuntimeOptions{MaxDepth: 2})
+
+	if _, err := manager.Execute(WithSubagentDepth(context.Background(), 1), SubagentRequest{Task: "nested"}, "cli", "direct"); err != nil {
+		t.Fatalf("expected depth 2 to be allowed: %v", err)
+	}
+	if len(provider.depths) != 1 || provider.depths[0] != 2 {
+		t.Fatalf("expected the subagent to run at depth 2, got %v", provider.depths)
+	}
+
+	deep := WithSubagentDepth(context.Background(), 2)
+	if _, err := manager.Execute(deep, SubagentRequest{Task: "too deep"}, "cli", "direct"); err == nil || !strings.Contains(err.Error(), "max_subagent_depth") {
+		t.Fatalf("expected depth limit error from Execute, got %v", err)
+	}
+	if _, err := manager.Spawn(deep, "too deep", "", "cli", "direct", nil); err == nil {
+		t.Fatal("expected depth limit error from Spawn")
+	}
+	if len(manager.ListTasks()) != 0 {
+		t.Fatal("expected no task to be recorded past the depth limit")
+	}
+}
@@ -0,0 +1,521 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/go-gota/gota/series"
+)
+
+const (
+	// tableMaxFileBytes caps how much of a CSV/TSV file is loaded into memory.
+	tableMaxFileBytes = 20 << 20
+	tableDefaultLimit = 50
+	tableMaxLimit     = 500
+)
+
+// tableFiles loads the CSV/TSV files the table_* tools answer questions
+// about, such as spreadsheet exports, so the model does not have to read the
+// raw file.
+type tableFiles struct {
+	workspace string
+	restrict  bool
+}
+
+// loadArg loads the table named by the "file" argument.
+func (t tableFiles) loadArg(args map[string]interface{}) (string, dataframe.DataFrame, error) {
+	file, _ := args["file"].(string)
+	if strings.TrimSpace(file) == "" {
+		return "", dataframe.DataFrame{}, fmt.Errorf("file is required")
+	}
+	df, err := t.load(file)
+	return file, df, err
+}
+
+func (t tableFiles) load(file string) (dataframe.DataFrame, error) {
+	resolvedPath, err := validatePath(file, t.workspace, t.restrict)
+	if err != nil {
+		return dataframe.DataFrame{}, err
+	}
+	f, err := os.Open(resolvedPath)
+	if err != nil {
+		return dataframe.DataFrame{}, fmt.Errorf("failed to open table: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, tableMaxFileBytes+1))
+	if err != nil {
+		return dataframe.DataFrame{}, fmt.Errorf("failed to read table: %w", err)
+	}
+	if len(data) > tableMaxFileBytes {
+		return dataframe.DataFrame{}, fmt.Errorf("table %s is larger than %d MB", file, tableMaxFileBytes>>20)
+	}
+
+	delimiter := ','
+	if strings.EqualFold(filepath.Ext(resolvedPath), ".tsv") {
+		delimiter = '\t'
+	}
+	df := dataframe.ReadCSV(bytes.NewReader(data), dataframe.WithDelimiter(delimiter), dataframe.WithLazyQuotes(true))
+	if df.Err != nil {
+		return dataframe.DataFrame{}, fmt.Errorf("failed to parse %s: %w", file, df.Err)
+	}
+	return df, nil
+}
+
+var tableFileParameter = map[string]interface{}{
+	"type":        "string",
+	"description": "Path to a .csv or .tsv file with a header row",
+}
+
+// TableQueryTool filters, sorts and projects the rows of a CSV/TSV file.
+type TableQueryTool struct {
+	tableFiles
+}
+
+func NewTableQueryTool(workspace string, restrict bool) *TableQueryTool {
+	return &TableQueryTool{tableFiles{workspace: workspace, restrict: restrict}}
+}
+
+func (t *TableQueryTool) Name() string {
+	return "table_query"
+}
+
+func (t *TableQueryTool) Description() string {
+	return "Query a CSV/TSV file with a SQL-like filter, e.g. SELECT name, total WHERE total > 100 AND region = 'EU' ORDER BY total DESC LIMIT 10."
+}
+
+func (t *TableQueryTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"file": tableFileParameter,
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "[SELECT col, ... | *] [WHERE col op value [AND|OR ...]] [ORDER BY col [ASC|DESC]] [LIMIT n]. Operators: =, !=, <, <=, >, >=, contains. Quote strings with '...' and column names containing spaces with \"...\". Results are capped at 500 rows (default 50).",
+			},
+		},
+		"required": []string{"file"},
+	}
+}
+
+func (t *TableQueryTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	_, df, err := t.loadArg(args)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	query, _ := args["query"].(string)
+	out, err := queryTable(df, query)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	return NewToolResult(out)
+}
+
+// TableDescribeTool summarizes the columns of a CSV/TSV file.
+type TableDescribeTool struct {
+	tableFiles
+}
+
+func NewTableDescribeTool(workspace string, restrict bool) *TableDescribeTool {
+	return &TableDescribeTool{tableFiles{workspace: workspace, restrict: restrict}}
+}
+
+func (t *TableDescribeTool) Name() string {
+	return "table_describe"
+}
+
+func (t *TableDescribeTool) Description() string {
+	return "Describe a CSV/TSV file: column names and types, row count, and min/max/mean of numeric columns."
+}
+
+func (t *TableDescribeTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"file": tableFileParameter,
+		},
+		"required": []string{"file"},
+	}
+}
+
+func (t *TableDescribeTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	file, df, err := t.loadArg(args)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	return NewToolResult(describeTable(file, df))
+}
+
+func describeTable(file string, df dataframe.DataFrame) string {
+	rows, cols := df.Dims()
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d rows, %d columns\n", file, rows, cols)
+	for _, name := range df.Names() {
+		col := df.Col(name)
+		fmt.Fprintf(&b, "- %s (%s)", name, col.Type())
+		if col.Type() == series.Int || col.Type() == series.Float {
+			if min, max, mean, n := tableColumnStats(col); n > 0 {
+				fmt.Fprintf(&b, ": min=%s max=%s mean=%s", formatTableNumber(min), formatTableNumber(max), formatTableNumber(mean))
+			}
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// tableColumnStats skips missing values, which gota loads as NaN.
+func tableColumnStats(col series.Series) (min, max, mean float64, n int) {
+	min, max = math.Inf(1), math.Inf(-1)
+	var sum float64
+	for _, v := range col.Float() {
+		if math.IsNaN(v) {
+			continue
+		}
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+		sum += v
+		n++
+	}
+	if n == 0 {
+		return 0, 0, 0, 0
+	}
+	return min, max, sum / float64(n), n
+}
+
+func formatTableNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// tableQuery is the parsed form of a table_query query string.
+type tableQuery struct {
+	columns []string
+	filters []dataframe.F
+	agg     dataframe.Aggregation
+	orderBy string
+	desc    bool
+	limit   int
+}
+
+func queryTable(df dataframe.DataFrame, raw string) (string, error) {
+	q, err := parseTableQuery(raw)
+	if err != nil {
+		return "", err
+	}
+	known := map[string]bool{}
+	for _, name := range df.Names() {
+		known[name] = true
+	}
+	check := func(name string) error {
+		if !known[name] {
+			return fmt.Errorf("unknown column %q; columns: %s", name, strings.Join(df.Names(), ", "))
+		}
+		return nil
+	}
+	for _, f := range q.filters {
+		if err := check(f.Colname); err != nil {
+			return "", err
+		}
+	}
+	for _, name := range q.columns {
+		if err := check(name); err != nil {
+			return "", err
+		}
+	}
+	if q.orderBy != "" {
+		if err := check(q.orderBy); err != nil {
+			return "", err
+		}
+	}
+
+	result := df
+	if len(q.filters) > 0 {
+		result = result.FilterAggregation(q.agg, q.filters...)
+	}
+	if q.orderBy != "" {
+		order := dataframe.Sort(q.orderBy)
+		if q.desc {
+			order = dataframe.RevSort(q.orderBy)
+		}
+		result = result.Arrange(order)
+	}
+	if len(q.columns) > 0 {
+		result = result.Select(q.columns)
+	}
+	if result.Err != nil {
+		return "", fmt.Errorf("query failed: %w", result.Err)
+	}
+
+	matched := result.Nrow()
+	if matched > q.limit {
+		indexes := make([]int, q.limit)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		result = result.Subset(indexes)
+	}
+	var buf bytes.Buffer
+	if err := result.WriteCSV(&buf); err != nil {
+		return "", fmt.Errorf("format results: %w", err)
+	}
+	header := fmt.Sprintf("%d matching rows", matched)
+	if matched > q.limit {
+		header += fmt.Sprintf(" (showing first %d)", q.limit)
+	}
+	return header + "\n" + strings.TrimRight(buf.String(), "\n"), nil
+}
+
+func parseTableQuery(raw string) (tableQuery, error) {
+	q := tableQuery{agg: dataframe.And, limit: tableDefaultLimit}
+	tokens, err := tokenizeTableQuery(raw)
+	if err != nil {
+		return q, err
+	}
+	p := &tableQueryParser{tokens: tokens}
+
+	if p.keyword("SELECT") {
+		if p.peek().text == "*" && !p.peek().quoted {
+			p.next()
+		} else {
+			for {
+				name, ok := p.ident()
+				if !ok {
+					return q, fmt.Errorf("expected column name after SELECT")
+				}
+				q.columns = append(q.columns, name)
+				if !p.symbol(",") {
+					break
+				}
+			}
+		}
+	}
+
+	// A query without a leading keyword is treated as a bare WHERE clause.
+	if p.keyword("WHERE") || (p.pos == 0 && p.pos < len(p.tokens) && !p.atKeyword("ORDER", "LIMIT")) {
+		connector := ""
+		for {
+			f, err := p.condition()
+			if err != nil {
+				return q, err
+			}
+			q.filters = append(q.filters, f)
+			var next string
+			switch {
+			case p.keyword("AND"):
+				next = "AND"
+			case p.keyword("OR"):
+				next = "OR"
+			}
+			if next == "" {
+				break
+			}
+			if connector != "" && connector != next {
+				return q, fmt.Errorf("mixing AND and OR in one WHERE clause is not supported")
+			}
+			connector = next
+		}
+		if connector == "OR" {
+			q.agg = dataframe.Or
+		}
+	}
+
+	if p.keyword("ORDER") {
+		if !p.keyword("BY") {
+			return q, fmt.Errorf("expected BY after ORDER")
+		}
+		name, ok := p.ident()
+		if !ok {
+			return q, fmt.Errorf("expected column name after ORDER BY")
+		}
+		q.orderBy = name
+		if p.keyword("DESC") {
+			q.desc = true
+		} else {
+			p.keyword("ASC")
+		}
+	}
+
+	if p.keyword("LIMIT") {
+		tok := p.next()
+		n, err := strconv.Atoi(tok.text)
+		if err != nil || tok.quoted || n < 1 {
+			return q, fmt.Errorf("LIMIT must be a positive integer")
+		}
+		q.limit = min(n, tableMaxLimit)
+	}
+
+	if p.pos < len(p.tokens) {
+		return q, fmt.Errorf("unexpected %q in query", p.tokens[p.pos].text)
+	}
+	return q, nil
+}
+
+type tableToken struct {
+	text string
+	// quoted marks '...' string literals and "..." or `...` identifiers so
+	// they are never mistaken for keywords or operators.
+	quoted bool
+	str    bool
+}
+
+func tokenizeTableQuery(raw string) ([]tableToken, error) {
+	var tokens []tableToken
+	runes := []rune(raw)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'' || r == '"' || r == '`':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if runes[j] == r {
+					// A doubled quote is an escaped quote, as in SQL.
+					if j+1 < len(runes) && runes[j+1] == r {
+						b.WriteRune(r)
+						j++
+						continue
+					}
+					break
+				}
+				b.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated %c quote in query", r)
+			}
+			tokens = append(tokens, tableToken{text: b.String(), quoted: true, str: r == '\''})
+			i = j + 1
+		case strings.ContainsRune("<>!=", r):
+			j := i + 1
+			if j < len(runes) && (runes[j] == '=' || (r == '<' && runes[j] == '>')) {
+				j++
+			}
+			tokens = append(tokens, tableToken{text: string(runes[i:j])})
+			i = j
+		case r == ',' || r == '*':
+			tokens = append(tokens, tableToken{text: string(r)})
+			i++
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune("<>!=,'\"`", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, tableToken{text: string(runes[i:j])})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type tableQueryParser struct {
+	tokens []tableToken
+	pos    int
+}
+
+func (p *tableQueryParser) peek() tableToken {
+	if p.pos >= len(p.tokens) {
+		return tableToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *tableQueryParser) next() tableToken {
+	tok := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *tableQueryParser) atKeyword(words ...string) bool {
+	tok := p.peek()
+	if tok.quoted {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(tok.text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *tableQueryParser) keyword(word string) bool {
+	if p.atKeyword(word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *tableQueryParser) symbol(sym string) bool {
+	tok := p.peek()
+	if !tok.quoted && tok.text == sym {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *tableQueryParser) ident() (string, bool) {
+	tok := p.peek()
+	if tok.text == "" || tok.str || (!tok.quoted && (tok.text == "," || tok.text == "*")) {
+		return "", false
+	}
+	p.pos++
+	return tok.text, true
+}
+
+func (p *tableQueryParser) condition() (dataframe.F, error) {
+	name, ok := p.ident()
+	if !ok {
+		return dataframe.F{}, fmt.Errorf("expected column name in WHERE clause")
+	}
+	opTok := p.next()
+	if opTok.quoted || opTok.text == "" {
+		return dataframe.F{}, fmt.Errorf("expected operator after %q", name)
+	}
+	value := p.next()
+	if value.text == "" && !value.quoted {
+		return dataframe.F{}, fmt.Errorf("expected value after %s %s", name, opTok.text)
+	}
+
+	var comparator series.Comparator
+	switch strings.ToLower(opTok.text) {
+	case "=", "==":
+		comparator = series.Eq
+	case "!=", "<>":
+		comparator = series.Neq
+	case ">":
+		comparator = series.Greater
+	case ">=":
+		comparator = series.GreaterEq
+	case "<":
+		comparator = series.Less
+	case "<=":
+		comparator = series.LessEq
+	case "contains":
+		needle := strings.ToLower(value.text)
+		return dataframe.F{
+			Colname:    name,
+			Comparator: series.CompFunc,
+			Comparando: func(el series.Element) bool {
+				return strings.Contains(strings.ToLower(el.String()), needle)
+			},
+		}, nil
+	default:
+		return dataframe.F{}, fmt.Errorf("unsupported operator %q (use =, !=, <, <=, >, >=, contains)", opTok.text)
+	}
+	// gota converts the comparando to the column's type, so the raw text works
+	// for numeric and string columns alike.
+	return dataframe.F{Colname: name, Comparator: comparator, Comparando: value.text}, nil
+}
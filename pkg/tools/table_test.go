@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSalesCSV = "region,product name,units,price\n" +
+	"EU,Widget,12,2.5\n" +
+	"US,Widget,40,2.5\n" +
+	"EU,Gadget,7,10\n" +
+	"APAC,Gizmo,,4\n" +
+	"US,Gadget,3,10\n"
+
+func writeTestTable(t *testing.T, name, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("write table: %v", err)
+	}
+	return dir
+}
+
+func TestTableDescribeTool(t *testing.T) {
+	dir := writeTestTable(t, "sales.csv", testSalesCSV)
+	tool := NewTableDescribeTool(dir, true)
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"file": "sales.csv",
+	})
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.ForLLM)
+	}
+	for _, want := range []string{
+		"sales.csv: 5 rows, 4 columns",
+		"- region (string)",
+		"- units (int): min=3 max=40 mean=15.5",
+		"- price (float): min=2.5 max=10 mean=5.8",
+	} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Fatalf("expected %q in describe output:\n%s", want, result.ForLLM)
+		}
+	}
+}
+
+func TestTableQueryTool(t *testing.T) {
+	dir := writeTestTable(t, "sales.tsv", strings.ReplaceAll(testSalesCSV, ",", "\t"))
+	tool := NewTableQueryTool(dir, true)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: `SELECT "product name", units WHERE units >= 7 AND region = 'EU' ORDER BY units DESC`,
+			want:  "2 matching rows\nproduct name,units\nWidget,12\nGadget,7",
+		},
+		{
+			query: `region = 'US' OR region = 'APAC' ORDER BY region LIMIT 1`,
+			want:  "3 matching rows (showing first 1)\nregion,product name,units,price\nAPAC,Gizmo,NaN,4.000000",
+		},
+		{
+			query: `SELECT region WHERE "product name" contains 'gad'`,
+			want:  "2 matching rows\nregion\nEU\nUS",
+		},
+	}
+	for _, tt := range tests {
+		result := tool.Execute(context.Background(), map[string]interface{}{
+			"file":  "sales.tsv",
+			"query": tt.query,
+		})
+		if result.IsError {
+			t.Fatalf("query %q: unexpected error: %s", tt.query, result.ForLLM)
+		}
+		if result.ForLLM != tt.want {
+			t.Fatalf("query %q:\ngot:\n%s\nwant:\n%s", tt.query, result.ForLLM, tt.want)
+		}
+	}
+}
+
+func TestTableTools_RejectBadInput(t *testing.T) {
+	dir := writeTestTable(t, "sales.csv", testSalesCSV)
+	tool := NewTableQueryTool(dir, true)
+
+	for query, want := range map[string]string{
+		"WHERE missing = 1":                  "unknown column",
+		"WHERE units > 1 AND units < 9 OR x": "mixing AND and OR",
+		"WHERE units ~ 3":                    "unsupported operator",
+		"LIMIT zero":                         "LIMIT must be a positive integer",
+		"WHERE region = 'EU":                 "unterminated",
+	} {
+		result := tool.Execute(context.Background(), map[string]interface{}{
+			"file":  "sales.csv",
+			"query": query,
+		})
+		if !result.IsError || !strings.Contains(result.ForLLM, want) {
+			t.Fatalf("query %q: expected error containing %q, got %q", query, want, result.ForLLM)
+		}
+	}
+
+	outside := writeTestTable(t, "other.csv", testSalesCSV)
+	result := NewTableDescribeTool(dir, true).Execute(context.Background(), map[string]interface{}{
+		"file": filepath.Join(outside, "other.csv"),
+	})
+	if !result.IsError || !strings.Contains(result.ForLLM, "outside the workspace") {
+		t.Fatalf("expected workspace restriction error, got %q", result.ForLLM)
+	}
+}
@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateTool renders Go text/template files from the workspace.
+type TemplateTool struct {
+	workspace string
+	restrict  bool
+}
+
+func NewTemplateTool(workspace string, restrict bool) *TemplateTool {
+	return &TemplateTool{workspace: workspace, restrict: restrict}
+}
+
+func (t *TemplateTool) Name() string {
+	return "template_render"
+}
+
+func (t *TemplateTool) Description() string {
+	return "Render a Go text/template file with JSON variables. Available functions: now, upper, lower, truncate."
+}
+
+func (t *TemplateTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"file": map[string]interface{}{
+				"type":        "string",
+				"description": "Path to the template file",
+			},
+			"variables_json": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional JSON object exposed to the template as dot (e.g. {{.name}})",
+			},
+		},
+		"required": []string{"file"},
+	}
+}
+
+func (t *TemplateTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	path, ok := args["file"].(string)
+	if !ok || strings.TrimSpace(path) == "" {
+		return ErrorResult("file is required")
+	}
+
+	vars := map[string]interface{}{}
+	if raw, ok := args["variables_json"].(string); ok && strings.TrimSpace(raw) != "" {
+		if err := json.Unmarshal([]byte(raw), &vars); err != nil {
+			return ErrorResult(fmt.Sprintf("variables_json must be a JSON object: %v", err))
+		}
+	}
+
+	resolvedPath, err := validatePath(path, t.workspace, t.restrict)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	content, err := os.ReadFile(resolvedPath)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to read template: %v", err))
+	}
+
+	out, err := renderTemplateFile(path, string(content), vars)
+	if err != nil {
+		return ErrorResult(err.Error()).WithError(err)
+	}
+	return NewToolResult(out)
+}
+
+func renderTemplateFile(name, content string, vars map[string]interface{}) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs()).Option("missingkey=zero").Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// now returns the current time, formatted with an optional Go layout.
+		"now": func(layout ...string) string {
+			if len(layout) > 0 && strings.TrimSpace(layout[0]) != "" {
+				return time.Now().Format(layout[0])
+			}
+			return time.Now().Format(time.RFC3339)
+		},
+		"upper": func(v interface{}) string {
+			return strings.ToUpper(renderTemplateValue(v))
+		},
+		"lower": func(v interface{}) string {
+			return strings.ToLower(renderTemplateValue(v))
+		},
+		// truncate takes the length first so it works in pipelines: {{.body | truncate 80}}.
+		"truncate": func(n int, v interface{}) string {
+			runes := []rune(renderTemplateValue(v))
+			if n < 0 {
+				n = 0
+			}
+			if len(runes) <= n {
+				return string(runes)
+			}
+			return string(runes[:n])
+		},
+	}
+}
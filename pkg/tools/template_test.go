@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateTool_Render_Success(t *testing.T) {
+	tmpDir := t.TempDir()
+	tplPath := filepath.Join(tmpDir, "report.tmpl")
+	content := "Hello {{upper .name}} / {{lower .team}} / {{.summary | truncate 5}} / {{now \"2006\"}}"
+	if err := os.WriteFile(tplPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+
+	tool := NewTemplateTool(tmpDir, true)
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"file":           "report.tmpl",
+		"variables_json": `{"name":"ada","team":"PLATFORM","summary":"quarterly numbers"}`,
+	})
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.ForLLM)
+	}
+	if !strings.HasPrefix(result.ForLLM, "Hello ADA / platform / quart / ") {
+		t.Fatalf("unexpected render output: %q", result.ForLLM)
+	}
+}
+
+func TestTemplateTool_Render_InvalidVariables(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.tmpl"), []byte("{{.x}}"), 0o644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+
+	tool := NewTemplateTool(tmpDir, true)
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"file":           "a.tmpl",
+		"variables_json": `[1,2,3]`,
+	})
+	if !result.IsError {
+		t.Fatalf("expected error for non-object variables_json")
+	}
+	if !strings.Contains(result.ForLLM, "variables_json must be a JSON object") {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+}
+
+func TestTemplateTool_Render_RejectsOutsideWorkspace(t *testing.T) {
+	root := t.TempDir()
+	workspace := filepath.Join(root, "workspace")
+	if err := os.MkdirAll(workspace, 0o755); err != nil {
+		t.Fatalf("mkdir workspace: %v", err)
+	}
+	outside := filepath.Join(root, "outside.tmpl")
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+
+	tool := NewTemplateTool(workspace, true)
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"file": outside,
+	})
+	if !result.IsError {
+		t.Fatalf("expected access denied for template outside workspace")
+	}
+	if !strings.Contains(result.ForLLM, "outside the workspace") {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+}
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -185,3 +186,29 @@ func TestRunToolLoop_MaxIterationsFallbackMessage(t *testing.T) {
 		t.Fatalf("expected 2 iterations, got %d", result.Iterations)
 	}
 }
+
+func TestRunToolLoop_TypedProviderErrorsAreNotRetried(t *testing.T) {
+	calls := 0
+	_, err := RunToolLoop(context.Background(), ToolLoopConfig{
+		Provider:      &scriptedLoopProvider{},
+		Model:         "missing-model",
+		MaxIterations: 3,
+		Retry:         providers.RetryConfig{MaxAttempts: 3},
+		CallLLM: func(context.Context, []providers.Message, []providers.ToolDefinition, string, map[string]interface{}) (*providers.LLMResponse, error) {
+			calls++
+			return nil, providers.NewHTTPErrorWithBody("openrouter", 404, "No endpoints found for missing-model.", []byte(`{"error":{"message":"No endpoints found for missing-model."}}`), 0)
+		},
+	}, nil, "cli", "direct")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single call for a non-transient error, got %d", calls)
+	}
+	if !errors.Is(err, providers.ErrModelNotFound) {
+		t.Fatalf("expected ErrModelNotFound, got %v", err)
+	}
+	if strings.Contains(err.Error(), "after retries") || !strings.Contains(err.Error(), `model "missing-model" is not available`) {
+		t.Fatalf("unexpected error message: %v", err)
+	}
+}
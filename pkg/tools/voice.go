@@ -0,0 +1,243 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	defaultVoiceAPIBase   = "https://api.openai.com/v1"
+	defaultVoiceModel     = "whisper-1"
+	defaultVoiceMaxFileMB = 25
+	voiceTimeout          = 5 * time.Minute
+)
+
+var voiceAudioExtensions = map[string]bool{
+	".wav":  true,
+	".mp3":  true,
+	".m4a":  true,
+	".ogg":  true,
+	".webm": true,
+	".flac": true,
+}
+
+type VoiceToolOptions struct {
+	APIKey        string
+	APIBase       string
+	Model         string
+	Language      string
+	WhisperBinary string
+	MaxFileMB     int
+}
+
+// VoiceTool transcribes audio files from the workspace. A configured local
+// Whisper binary takes precedence so audio never leaves the machine;
+// otherwise the file is sent to the OpenAI speech-to-text API.
+type VoiceTool struct {
+	workspace     string
+	restrict      bool
+	apiKey        string
+	apiBase       string
+	model         string
+	language      string
+	whisperBinary string
+	maxBytes      int64
+}
+
+func NewVoiceTool(workspace string, restrict bool, opts VoiceToolOptions) *VoiceTool {
+	apiBase := strings.TrimRight(strings.TrimSpace(opts.APIBase), "/")
+	if apiBase == "" {
+		apiBase = defaultVoiceAPIBase
+	}
+	model := strings.TrimSpace(opts.Model)
+	if model == "" {
+		model = defaultVoiceModel
+	}
+	maxFileMB := opts.MaxFileMB
+	if maxFileMB <= 0 {
+		maxFileMB = defaultVoiceMaxFileMB
+	}
+	return &VoiceTool{
+		workspace:     workspace,
+		restrict:      restrict,
+		apiKey:        strings.TrimSpace(opts.APIKey),
+		apiBase:       apiBase,
+		model:         model,
+		language:      strings.TrimSpace(opts.Language),
+		whisperBinary: expandHomePath(opts.WhisperBinary),
+		maxBytes:      int64(maxFileMB) << 20,
+	}
+}
+
+func (t *VoiceTool) Name() string {
+	return "voice_transcribe"
+}
+
+func (t *VoiceTool) Description() string {
+	return "Transcribe an audio file (wav, mp3, m4a, ogg, webm, flac) from the workspace and return the transcript text."
+}
+
+func (t *VoiceTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"file_path": map[string]interface{}{
+				"type":        "string",
+				"description": "Path to the audio file, relative to the workspace",
+			},
+		},
+		"required": []string{"file_path"},
+	}
+}
+
+func (t *VoiceTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	path, _ := args["file_path"].(string)
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return ErrorResult("file_path is required")
+	}
+	resolvedPath, err := validatePath(path, t.workspace, t.restrict)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	ext := strings.ToLower(filepath.Ext(resolvedPath))
+	if !voiceAudioExtensions[ext] {
+		return ErrorResult(fmt.Sprintf("unsupported audio format %q (supported: wav, mp3, m4a, ogg, webm, flac)", ext))
+	}
+	info, err := os.Stat(resolvedPath)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to read audio file: %v", err))
+	}
+	if info.IsDir() {
+		return ErrorResult(fmt.Sprintf("%s is a directory", path))
+	}
+	if info.Size() > t.maxBytes {
+		return ErrorResult(fmt.Sprintf("audio file is %.1f MB, above the tools.voice.max_file_mb limit of %d MB", float64(info.Size())/(1<<20), t.maxBytes>>20))
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, voiceTimeout)
+	defer cancel()
+
+	var transcript string
+	switch {
+	case t.whisperBinary != "":
+		transcript, err = t.transcribeLocal(runCtx, resolvedPath)
+	case t.apiKey != "":
+		transcript, err = t.transcribeAPI(runCtx, resolvedPath)
+	default:
+		return ErrorResult("voice transcription is not configured: set tools.voice.api_key or tools.voice.whisper_binary")
+	}
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("transcription failed: %v", err))
+	}
+	transcript = strings.TrimSpace(transcript)
+	if transcript == "" {
+		return NewToolResult("(no speech detected)")
+	}
+	return NewToolResult(transcript)
+}
+
+// transcribeLocal runs the openai-whisper CLI, which writes <name>.txt into
+// the output directory.
+func (t *VoiceTool) transcribeLocal(ctx context.Context, audioPath string) (string, error) {
+	outDir, err := os.MkdirTemp("", "dotagent-whisper-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(outDir)
+
+	args := []string{audioPath, "--output_format", "txt", "--output_dir", outDir}
+	if t.language != "" {
+		args = append(args, "--language", t.language)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.whisperBinary, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("whisper timed out after %v", voiceTimeout)
+		}
+		return "", fmt.Errorf("%s: %v: %s", filepath.Base(t.whisperBinary), err, truncateVoiceDetail(stderr.String()))
+	}
+
+	base := strings.TrimSuffix(filepath.Base(audioPath), filepath.Ext(audioPath))
+	data, err := os.ReadFile(filepath.Join(outDir, base+".txt"))
+	if err != nil {
+		return "", fmt.Errorf("whisper produced no transcript: %w", err)
+	}
+	return string(data), nil
+}
+
+func (t *VoiceTool) transcribeAPI(ctx context.Context, audioPath string) (string, error) {
+	f, err := os.Open(audioPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filepath.Base(audioPath))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, io.LimitReader(f, t.maxBytes)); err != nil {
+		return "", fmt.Errorf("failed to read audio file: %w", err)
+	}
+	_ = writer.WriteField("model", t.model)
+	_ = writer.WriteField("response_format", "json")
+	if t.language != "" {
+		_ = writer.WriteField("language", t.language)
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.apiBase+"/audio/transcriptions", &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	client := &http.Client{Timeout: voiceTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("API returned %s: %s", resp.Status, truncateVoiceDetail(string(respBody)))
+	}
+
+	var parsed struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	return parsed.Text, nil
+}
+
+func truncateVoiceDetail(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > 500 {
+		return s[:500] + "..."
+	}
+	return s
+}
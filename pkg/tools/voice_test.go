@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestVoiceTool_TranscribesViaAPI(t *testing.T) {
+	var gotAuth, gotModel, gotLanguage, gotFile, gotAudio string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/audio/transcriptions" {
+			http.NotFound(w, r)
+			return
+		}
+		gotAuth = r.Header.Get("Authorization")
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		gotModel = r.FormValue("model")
+		gotLanguage = r.FormValue("language")
+		f, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(f)
+		gotFile, gotAudio = header.Filename, string(data)
+		_, _ = w.Write([]byte(`{"text":"  buy milk tomorrow  "}`))
+	}))
+	defer server.Close()
+
+	workspace := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workspace, "note.mp3"), []byte("fake-audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewVoiceTool(workspace, true, VoiceToolOptions{APIKey: "sk-test", APIBase: server.URL + "/", Language: "en"})
+
+	result := tool.Execute(context.Background(), map[string]interface{}{"file_path": "note.mp3"})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if result.ForLLM != "buy milk tomorrow" {
+		t.Fatalf("unexpected transcript %q", result.ForLLM)
+	}
+	if gotAuth != "Bearer sk-test" || gotModel != "whisper-1" || gotLanguage != "en" {
+		t.Fatalf("unexpected request: auth=%q model=%q language=%q", gotAuth, gotModel, gotLanguage)
+	}
+	if gotFile != "note.mp3" || gotAudio != "fake-audio" {
+		t.Fatalf("unexpected upload %q (%q)", gotFile, gotAudio)
+	}
+}
+
+func TestVoiceTool_APIErrorIsReported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"invalid api key"}}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	workspace := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workspace, "note.wav"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewVoiceTool(workspace, true, VoiceToolOptions{APIKey: "bad", APIBase: server.URL})
+	result := tool.Execute(context.Background(), map[string]interface{}{"file_path": "note.wav"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "invalid api key") {
+		t.Fatalf("expected API error, got %+v", result)
+	}
+}
+
+func TestVoiceTool_LocalWhisperBinary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script stub requires a Unix shell")
+	}
+	binDir := t.TempDir()
+	script := `#!/bin/sh
+audio="$1"; shift
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --output_dir) out="$2"; shift ;;
+    --language) lang="$2"; shift ;;
+  esac
+  shift
+done
+name=$(basename "$audio"); name="${name%.*}"
+printf 'hello from whisper (%s)\n' "$lang" > "$out/$name.txt"
+`
+	binary := filepath.Join(binDir, "whisper")
+	if err := os.WriteFile(binary, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	workspace := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workspace, "voice"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workspace, "voice", "memo.wav"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewVoiceTool(workspace, true, VoiceToolOptions{APIKey: "unused", WhisperBinary: binary, Language: "de"})
+	result := tool.Execute(context.Background(), map[string]interface{}{"file_path": "voice/memo.wav"})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if result.ForLLM != "hello from whisper (de)" {
+		t.Fatalf("unexpected transcript %q", result.ForLLM)
+	}
+}
+
+func TestVoiceTool_RejectsBadInput(t *testing.T) {
+	workspace := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workspace, "big.wav"), make([]byte, 2<<20), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workspace, "notes.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewVoiceTool(workspace, true, VoiceToolOptions{APIKey: "k", APIBase: "http://127.0.0.1:0", MaxFileMB: 1})
+
+	cases := map[string]string{
+		"big.wav":       "max_file_mb",
+		"notes.txt":     "unsupported audio format",
+		"../escape.mp3": "outside the workspace",
+		"missing.mp3":   "failed to read audio file",
+	}
+	for path, want := range cases {
+		result := tool.Execute(context.Background(), map[string]interface{}{"file_path": path})
+		if !result.IsError || !strings.Contains(result.ForLLM, want) {
+			t.Fatalf("%s: expected error containing %q, got %q", path, want, result.ForLLM)
+		}
+	}
+}
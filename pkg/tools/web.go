@@ -220,6 +220,8 @@ func (t *WebSearchTool) Name() string {
 	return "web_search"
 }
 
+func (t *WebSearchTool) UntrustedContent() bool { return true }
+
 func (t *WebSearchTool) Description() string {
 	return "Search the web for current information. Returns titles, URLs, and snippets from search results."
 }
@@ -291,6 +293,8 @@ func (t *WebFetchTool) Name() string {
 	return "web_fetch"
 }
 
+func (t *WebFetchTool) UntrustedContent() bool { return true }
+
 func (t *WebFetchTool) Description() string {
 	return "Fetch a URL and extract readable content (HTML to text). Use this to get weather info, news, articles, or any web content."
 }
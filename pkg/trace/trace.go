@@ -0,0 +1,57 @@
+// Package trace carries per-request correlation IDs through context so log
+// lines, provider calls, tool executions, and audit records for a single
+// inbound message can be tied together.
+package trace
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type contextKey struct{}
+
+const (
+	// FieldKey is the structured log field used for trace IDs.
+	FieldKey = "trace_id"
+	// HeaderName is the HTTP header used to forward trace IDs to providers.
+	HeaderName = "X-Request-ID"
+)
+
+// NewID returns a fresh trace ID.
+func NewID() string {
+	return uuid.NewString()
+}
+
+// WithID returns a child context carrying id. An empty id leaves ctx unchanged.
+func WithID(ctx context.Context, id string) context.Context {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the trace ID stored in ctx, or "" if none is set.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Fields adds the trace ID from ctx to fields and returns it. A nil map is
+// allocated when a trace ID is present.
+func Fields(ctx context.Context, fields map[string]interface{}) map[string]interface{} {
+	id := FromContext(ctx)
+	if id == "" {
+		return fields
+	}
+	if fields == nil {
+		fields = make(map[string]interface{}, 1)
+	}
+	fields[FieldKey] = id
+	return fields
+}
@@ -0,0 +1,33 @@
+package trace
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithIDAndFromContext(t *testing.T) {
+	ctx := context.Background()
+	if got := FromContext(ctx); got != "" {
+		t.Fatalf("expected empty trace ID, got %q", got)
+	}
+	if WithID(ctx, "  ") != ctx {
+		t.Fatalf("expected blank ID to leave context unchanged")
+	}
+	ctx = WithID(ctx, "abc")
+	if got := FromContext(ctx); got != "abc" {
+		t.Fatalf("expected abc, got %q", got)
+	}
+}
+
+func TestFields(t *testing.T) {
+	if got := Fields(context.Background(), nil); got != nil {
+		t.Fatalf("expected nil fields without trace ID, got %#v", got)
+	}
+	fields := Fields(WithID(context.Background(), "abc"), map[string]interface{}{"tool": "x"})
+	if fields[FieldKey] != "abc" || fields["tool"] != "x" {
+		t.Fatalf("unexpected fields: %#v", fields)
+	}
+	if NewID() == NewID() {
+		t.Fatalf("expected unique trace IDs")
+	}
+}